@@ -0,0 +1,324 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+// Package admin serves an HTTP API to control the webhook listener and the sender at runtime:
+// pause and resume the dispatch of the notifications, drain the dispatcher queue, turn the dry
+// run on and off, reload the app secrets and dump the configuration, without a redeploy.
+//
+// The API changes how the service behaves, serve it on an internal address only and set a token
+// with WithToken.
+//
+//	control := &webhooks.Control{}
+//	secrets := webhooks.NewSecretCache(loadSecrets)
+//	listener := webhooks.NewEventListener(webhooks.WithControl(control), ...)
+//	api := admin.New(admin.WithListener(listener), admin.WithSender(client),
+//		admin.WithSecretResolver(secrets), admin.WithToken(os.Getenv("ADMIN_TOKEN")))
+//	http.Handle("/admin/", http.StripPrefix("/admin", api))
+//
+// The endpoints answer with the Status as JSON:
+//
+//	GET  /config          the configuration of the listener and the Status
+//	POST /pause           pause the dispatch, the notifications are answered with a 503
+//	POST /resume          resume the dispatch
+//	POST /drain           wait for the queued notifications to be handled
+//	POST /dry-run?on=true turn the dry run of the listener and the sender on, or off with false
+//	POST /secrets/rotate  reload the app secrets
+package admin
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/lowkruc/go-whatsapp-api/webhooks"
+)
+
+// DefaultDrainTimeout is how long POST /drain waits for the queue, unless the request sets a
+// timeout like ?timeout=1m.
+const DefaultDrainTimeout = 30 * time.Second
+
+var (
+	ErrNotConfigured = errors.New("not configured")
+	ErrUnauthorized  = errors.New("unauthorized")
+)
+
+type (
+	// SecretResolver reloads the app secrets the signatures are validated with,
+	// *webhooks.SecretCache implements it.
+	SecretResolver interface {
+		Refresh(ctx context.Context) error
+	}
+
+	// Sender is a sender with a dry run, *whatsapp.Client implements it.
+	Sender interface {
+		SetDryRun(enabled bool)
+		DryRun() bool
+	}
+
+	// Status is the state the endpoints answer with.
+	Status struct {
+		Paused       bool `json:"paused"`
+		DryRun       bool `json:"dry_run"`
+		SenderDryRun bool `json:"sender_dry_run"`
+		Pending      int  `json:"pending"`
+	}
+
+	// API is the admin http.Handler.
+	API struct {
+		listener     *webhooks.EventListener
+		control      *webhooks.Control
+		dispatcher   *webhooks.Dispatcher
+		sender       Sender
+		secrets      SecretResolver
+		token        string
+		drainTimeout time.Duration
+		mux          *http.ServeMux
+	}
+
+	Option func(*API)
+)
+
+// WithListener sets the listener whose configuration is dumped. Its Control and Dispatcher are
+// used unless set with WithControl and WithDispatcher.
+func WithListener(listener *webhooks.EventListener) Option {
+	return func(api *API) {
+		api.listener = listener
+	}
+}
+
+// WithControl sets the Control the dispatch is paused and the dry run turned on with.
+func WithControl(control *webhooks.Control) Option {
+	return func(api *API) {
+		api.control = control
+	}
+}
+
+// WithDispatcher sets the Dispatcher drained by POST /drain.
+func WithDispatcher(dispatcher *webhooks.Dispatcher) Option {
+	return func(api *API) {
+		api.dispatcher = dispatcher
+	}
+}
+
+// WithSender sets the sender whose dry run is turned on and off with the listener's.
+func WithSender(sender Sender) Option {
+	return func(api *API) {
+		api.sender = sender
+	}
+}
+
+// WithSecretResolver sets what POST /secrets/rotate reloads the secrets with.
+func WithSecretResolver(resolver SecretResolver) Option {
+	return func(api *API) {
+		api.secrets = resolver
+	}
+}
+
+// WithToken requires the requests to have the token in a bearer Authorization header.
+func WithToken(token string) Option {
+	return func(api *API) {
+		api.token = token
+	}
+}
+
+// WithDrainTimeout sets how long POST /drain waits by default, DefaultDrainTimeout by default.
+func WithDrainTimeout(timeout time.Duration) Option {
+	return func(api *API) {
+		api.drainTimeout = timeout
+	}
+}
+
+// New creates the admin API.
+func New(options ...Option) *API {
+	api := &API{drainTimeout: DefaultDrainTimeout}
+	for _, option := range options {
+		option(api)
+	}
+	if api.listener != nil {
+		if api.control == nil {
+			api.control = api.listener.Control()
+		}
+		if api.dispatcher == nil {
+			api.dispatcher = api.listener.Dispatcher()
+		}
+	}
+
+	api.mux = http.NewServeMux()
+	api.mux.HandleFunc("/config", api.method(http.MethodGet, api.config))
+	api.mux.HandleFunc("/pause", api.method(http.MethodPost, api.pause))
+	api.mux.HandleFunc("/resume", api.method(http.MethodPost, api.resume))
+	api.mux.HandleFunc("/drain", api.method(http.MethodPost, api.drain))
+	api.mux.HandleFunc("/dry-run", api.method(http.MethodPost, api.dryRun))
+	api.mux.HandleFunc("/secrets/rotate", api.method(http.MethodPost, api.rotate))
+
+	return api
+}
+
+func (api *API) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if api.token != "" {
+		token := r.Header.Get("Authorization")
+		if subtle.ConstantTimeCompare([]byte(token), []byte("Bearer "+api.token)) != 1 {
+			writeError(w, http.StatusUnauthorized, ErrUnauthorized)
+
+			return
+		}
+	}
+
+	api.mux.ServeHTTP(w, r)
+}
+
+// Status returns the current state.
+func (api *API) Status() *Status {
+	status := &Status{Paused: api.control.Paused(), DryRun: api.control.DryRun()}
+	if api.sender != nil {
+		status.SenderDryRun = api.sender.DryRun()
+	}
+	if api.dispatcher != nil {
+		status.Pending = api.dispatcher.Pending()
+	}
+
+	return status
+}
+
+func (api *API) method(method string, handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != method {
+			w.Header().Set("Allow", method)
+			writeError(w, http.StatusMethodNotAllowed, errors.New(http.StatusText(http.StatusMethodNotAllowed)))
+
+			return
+		}
+		handler(w, r)
+	}
+}
+
+func (api *API) config(w http.ResponseWriter, _ *http.Request) {
+	config := struct {
+		Listener *webhooks.Config `json:"listener,omitempty"`
+		Status   *Status          `json:"status"`
+	}{Status: api.Status()}
+	if api.listener != nil {
+		config.Listener = api.listener.Config()
+	}
+
+	writeJSON(w, http.StatusOK, config)
+}
+
+func (api *API) pause(w http.ResponseWriter, _ *http.Request) {
+	if api.control == nil {
+		writeError(w, http.StatusNotImplemented, errorf("control", ErrNotConfigured))
+
+		return
+	}
+	api.control.Pause()
+	writeJSON(w, http.StatusOK, api.Status())
+}
+
+func (api *API) resume(w http.ResponseWriter, _ *http.Request) {
+	if api.control == nil {
+		writeError(w, http.StatusNotImplemented, errorf("control", ErrNotConfigured))
+
+		return
+	}
+	api.control.Resume()
+	writeJSON(w, http.StatusOK, api.Status())
+}
+
+func (api *API) drain(w http.ResponseWriter, r *http.Request) {
+	if api.dispatcher == nil {
+		writeError(w, http.StatusNotImplemented, errorf("dispatcher", ErrNotConfigured))
+
+		return
+	}
+
+	timeout := api.drainTimeout
+	if value := r.URL.Query().Get("timeout"); value != "" {
+		parsed, err := time.ParseDuration(value)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, errorf("timeout", err))
+
+			return
+		}
+		timeout = parsed
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), timeout)
+	defer cancel()
+	if err := api.dispatcher.Drain(ctx); err != nil {
+		writeError(w, http.StatusGatewayTimeout, errorf("drain", err))
+
+		return
+	}
+	writeJSON(w, http.StatusOK, api.Status())
+}
+
+func (api *API) dryRun(w http.ResponseWriter, r *http.Request) {
+	if api.control == nil && api.sender == nil {
+		writeError(w, http.StatusNotImplemented, errorf("control and sender", ErrNotConfigured))
+
+		return
+	}
+
+	on, err := strconv.ParseBool(r.URL.Query().Get("on"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, errorf("on", err))
+
+		return
+	}
+	if api.control != nil {
+		api.control.SetDryRun(on)
+	}
+	if api.sender != nil {
+		api.sender.SetDryRun(on)
+	}
+	writeJSON(w, http.StatusOK, api.Status())
+}
+
+func (api *API) rotate(w http.ResponseWriter, r *http.Request) {
+	if api.secrets == nil {
+		writeError(w, http.StatusNotImplemented, errorf("secret resolver", ErrNotConfigured))
+
+		return
+	}
+	if err := api.secrets.Refresh(r.Context()); err != nil {
+		writeError(w, http.StatusBadGateway, errorf("rotate secrets", err))
+
+		return
+	}
+	writeJSON(w, http.StatusOK, api.Status())
+}
+
+func errorf(what string, err error) error {
+	return fmt.Errorf("%s: %v", what, err)
+}
+
+func writeError(w http.ResponseWriter, code int, err error) {
+	writeJSON(w, code, map[string]string{"error": err.Error()})
+}
+
+func writeJSON(w http.ResponseWriter, code int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(v)
+}
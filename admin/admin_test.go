@@ -0,0 +1,129 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package admin_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	whatsapp "github.com/lowkruc/go-whatsapp-api"
+	"github.com/lowkruc/go-whatsapp-api/admin"
+	"github.com/lowkruc/go-whatsapp-api/webhooks"
+)
+
+type rotator struct {
+	calls int
+	err   error
+}
+
+func (r *rotator) Refresh(context.Context) error {
+	r.calls++
+
+	return r.err
+}
+
+func TestAPI(t *testing.T) {
+	t.Parallel()
+	control := &webhooks.Control{}
+	dispatcher := webhooks.NewDispatcher(1, 1, webhooks.OverflowReject)
+	defer func() { _ = dispatcher.Shutdown(context.Background()) }()
+	listener := webhooks.NewEventListener(webhooks.WithControl(control), webhooks.WithDispatcher(dispatcher),
+		webhooks.WithSecrets("app-secret"))
+	client := whatsapp.NewClient()
+	secrets := &rotator{}
+
+	api := admin.New(admin.WithListener(listener), admin.WithSender(client), admin.WithSecretResolver(secrets),
+		admin.WithToken("admin-token"))
+	call := func(method, target string, v any) int {
+		request := httptest.NewRequest(method, target, nil)
+		request.Header.Set("Authorization", "Bearer admin-token")
+		recorder := httptest.NewRecorder()
+		api.ServeHTTP(recorder, request)
+		if v != nil {
+			if err := json.NewDecoder(recorder.Body).Decode(v); err != nil {
+				t.Fatalf("%s %s: decode: %v", method, target, err)
+			}
+		}
+
+		return recorder.Code
+	}
+
+	var status admin.Status
+	if code := call(http.MethodPost, "/pause", &status); code != http.StatusOK || !status.Paused || !control.Paused() {
+		t.Errorf("POST /pause = %d %+v", code, status)
+	}
+	if code := call(http.MethodPost, "/dry-run?on=true", &status); code != http.StatusOK ||
+		!status.DryRun || !status.SenderDryRun || !client.DryRun() {
+		t.Errorf("POST /dry-run = %d %+v", code, status)
+	}
+	if code := call(http.MethodPost, "/resume", &status); code != http.StatusOK || status.Paused || !status.DryRun {
+		t.Errorf("POST /resume = %d %+v", code, status)
+	}
+	if code := call(http.MethodPost, "/drain?timeout=1s", &status); code != http.StatusOK {
+		t.Errorf("POST /drain = %d", code)
+	}
+	if code := call(http.MethodPost, "/secrets/rotate", nil); code != http.StatusOK || secrets.calls != 1 {
+		t.Errorf("POST /secrets/rotate = %d after %d calls", code, secrets.calls)
+	}
+	secrets.err = errors.New("secret manager unavailable")
+	if code := call(http.MethodPost, "/secrets/rotate", nil); code != http.StatusBadGateway {
+		t.Errorf("POST /secrets/rotate = %d, want %d", code, http.StatusBadGateway)
+	}
+
+	var config struct {
+		Listener *webhooks.Config `json:"listener"`
+		Status   *admin.Status    `json:"status"`
+	}
+	if code := call(http.MethodGet, "/config", &config); code != http.StatusOK || config.Listener == nil ||
+		!config.Listener.ValidateSignature || config.Listener.Secrets != 1 || !config.Listener.Dispatcher ||
+		!config.Status.DryRun {
+		t.Errorf("GET /config = %d %+v", code, config.Listener)
+	}
+
+	for _, tt := range []struct {
+		method, target string
+		want           int
+	}{
+		{http.MethodGet, "/pause", http.StatusMethodNotAllowed},
+		{http.MethodPost, "/dry-run?on=maybe", http.StatusBadRequest},
+		{http.MethodPost, "/drain?timeout=soon", http.StatusBadRequest},
+	} {
+		if code := call(tt.method, tt.target, nil); code != tt.want {
+			t.Errorf("%s %s = %d, want %d", tt.method, tt.target, code, tt.want)
+		}
+	}
+
+	recorder := httptest.NewRecorder()
+	api.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/pause", nil))
+	if recorder.Code != http.StatusUnauthorized {
+		t.Errorf("POST /pause without token = %d, want %d", recorder.Code, http.StatusUnauthorized)
+	}
+
+	unconfigured := admin.New()
+	recorder = httptest.NewRecorder()
+	unconfigured.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/pause", nil))
+	if recorder.Code != http.StatusNotImplemented {
+		t.Errorf("POST /pause without control = %d, want %d", recorder.Code, http.StatusNotImplemented)
+	}
+}
@@ -0,0 +1,522 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+// Package assistant answers the text messages received through webhooks with an AI assistant.
+// The recent history of the conversation is kept in a Transcript and assembled into a Prompt
+// that does not depend on any provider, the Assistant plugged in turns it into a reply, and the
+// reply is sent as one or more text messages.
+//
+// Example:
+//
+//	responder := assistant.NewResponder(client, myAssistant, assistant.NewMemoryTranscript(),
+//		assistant.WithSystemPrompt("You are the assistant of Kerry's bakery."),
+//		assistant.WithWindow(20, 8000))
+//	listener.OnText(responder.Hook(nil))
+package assistant
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	whatsapp "github.com/lowkruc/go-whatsapp-api"
+	"github.com/lowkruc/go-whatsapp-api/clock"
+	"github.com/lowkruc/go-whatsapp-api/handoff"
+	"github.com/lowkruc/go-whatsapp-api/webhooks"
+)
+
+// Defaults of the Responder.
+const (
+	DefaultMaxTurns         = 20
+	DefaultMaxChars         = 8000
+	DefaultMaxMessageLength = 4096
+)
+
+// Roles of the turns of a conversation.
+const (
+	RoleUser      Role = "user"
+	RoleAssistant Role = "assistant"
+)
+
+var ErrEmptyReply = errors.New("assistant returned an empty reply")
+
+type (
+	// Role is who wrote a turn of the conversation.
+	Role string
+
+	// Turn is a message of the conversation.
+	Turn struct {
+		Role      Role
+		Text      string
+		MessageID string
+		At        time.Time
+	}
+
+	// Prompt is what the Assistant is asked to answer. Summary is the summary of the turns older
+	// than the window, when a Summarizer is set. Turns are the recent turns, oldest first, the last
+	// one is the message to answer.
+	Prompt struct {
+		System  string
+		Summary string
+		Turns   []*Turn
+		// Key is the key of the conversation, see handoff.Key.
+		Key string
+	}
+
+	// Assistant writes the reply to a prompt.
+	Assistant interface {
+		Reply(ctx context.Context, prompt *Prompt) (string, error)
+	}
+
+	// AssistantFunc is a function that implements Assistant.
+	AssistantFunc func(ctx context.Context, prompt *Prompt) (string, error)
+
+	// Streamer is implemented by the assistants that stream their reply. emit is called with the
+	// successive chunks of the reply, the Responder sends the completed paragraphs while the reply
+	// is written.
+	Streamer interface {
+		Stream(ctx context.Context, prompt *Prompt, emit func(chunk string) error) error
+	}
+
+	// Summarizer summarizes the turns that no longer fit in the window. previous is the summary of
+	// the turns before them, empty at first.
+	Summarizer interface {
+		Summarize(ctx context.Context, previous string, turns []*Turn) (string, error)
+	}
+
+	// Transcript keeps the turns of the conversations. Recent returns the last limit turns of the
+	// conversation, oldest first, or all of them when limit is 0. Implementations must be safe for
+	// concurrent use.
+	Transcript interface {
+		Append(ctx context.Context, key string, turn *Turn) error
+		Recent(ctx context.Context, key string, limit int) ([]*Turn, error)
+	}
+
+	// SummaryStore keeps the summaries of the conversations, a Transcript that implements it keeps
+	// the summaries with the turns. SetSummary replaces the summary of the conversation, turns is
+	// the number of its oldest turns the summary covers, Recent must not return them anymore.
+	SummaryStore interface {
+		Summary(ctx context.Context, key string) (string, error)
+		SetSummary(ctx context.Context, key, summary string, turns int) error
+	}
+
+	// Sender sends the replies, *whatsapp.Client implements it.
+	Sender interface {
+		SendTextMessage(ctx context.Context, recipient string, message *whatsapp.TextMessage) (
+			*whatsapp.ResponseMessage, error)
+	}
+
+	// Responder answers the text messages with the Assistant.
+	Responder struct {
+		sender     Sender
+		assistant  Assistant
+		transcript Transcript
+		summarizer Summarizer
+		system     string
+		maxTurns   int
+		maxChars   int
+		maxLength  int
+		clock      clock.Clock
+		pacer      *pacer
+	}
+
+	ResponderOption func(*Responder)
+
+	// MemoryTranscript is an in memory Transcript and SummaryStore, only suitable for a single
+	// instance. It keeps the last turns of every conversation, the older turns are dropped
+	// whether they were summarized or not.
+	MemoryTranscript struct {
+		mu        sync.Mutex
+		capacity  int
+		turns     map[string][]*Turn
+		summaries map[string]string
+	}
+)
+
+func (f AssistantFunc) Reply(ctx context.Context, prompt *Prompt) (string, error) {
+	return f(ctx, prompt)
+}
+
+// WithSystemPrompt sets the instructions given to the assistant with every prompt.
+func WithSystemPrompt(system string) ResponderOption {
+	return func(r *Responder) {
+		r.system = system
+	}
+}
+
+// WithWindow bounds the turns of the prompts to the last maxTurns, and to maxChars characters, the
+// last turn is always kept. The defaults are DefaultMaxTurns and DefaultMaxChars.
+func WithWindow(maxTurns, maxChars int) ResponderOption {
+	return func(r *Responder) {
+		r.maxTurns = maxTurns
+		r.maxChars = maxChars
+	}
+}
+
+// WithSummarizer summarizes the turns that leave the window. The summary is kept when the
+// Transcript implements SummaryStore.
+func WithSummarizer(summarizer Summarizer) ResponderOption {
+	return func(r *Responder) {
+		r.summarizer = summarizer
+	}
+}
+
+// WithMaxMessageLength sets the length of the messages the replies are split into, the default is
+// DefaultMaxMessageLength, the limit of the text messages.
+func WithMaxMessageLength(n int) ResponderOption {
+	return func(r *Responder) {
+		r.maxLength = n
+	}
+}
+
+// WithClock sets the clock of the turns, the default is clock.Real.
+func WithClock(c clock.Clock) ResponderOption {
+	return func(r *Responder) {
+		r.clock = c
+	}
+}
+
+// NewResponder creates a Responder that answers with assistant, keeps the conversations in
+// transcript and sends the replies with sender.
+func NewResponder(sender Sender, assistant Assistant, transcript Transcript,
+	options ...ResponderOption,
+) *Responder {
+	r := &Responder{
+		sender:     sender,
+		assistant:  assistant,
+		transcript: transcript,
+		maxTurns:   DefaultMaxTurns,
+		maxChars:   DefaultMaxChars,
+		maxLength:  DefaultMaxMessageLength,
+		clock:      clock.Real{},
+	}
+	for _, option := range options {
+		option(r)
+	}
+
+	return r
+}
+
+// Hook returns a handler of the text messages that answers them with the assistant and then calls
+// next. next may be nil.
+func (r *Responder) Hook(next webhooks.OnTextMessageHook) webhooks.OnTextMessageHook {
+	return func(ctx context.Context, nctx *webhooks.NotificationContext, mctx *webhooks.MessageContext,
+		text *webhooks.Text,
+	) error {
+		if text != nil {
+			key := handoff.KeyFromContext(nctx, mctx.From)
+			if err := r.Respond(ctx, key, mctx.From, mctx.ID, text.Body); err != nil {
+				return err
+			}
+		}
+
+		if next == nil {
+			return nil
+		}
+
+		return next(ctx, nctx, mctx, text)
+	}
+}
+
+// Respond records the message of the user in the conversation key, asks the assistant for the
+// reply and sends it to recipient.
+func (r *Responder) Respond(ctx context.Context, key, recipient, messageID, text string) error {
+	if err := r.transcript.Append(ctx, key, &Turn{
+		Role: RoleUser, Text: text, MessageID: messageID, At: r.clock.Now(),
+	}); err != nil {
+		return fmt.Errorf("assistant: record message: %v", err)
+	}
+
+	prompt, err := r.Prompt(ctx, key)
+	if err != nil {
+		return err
+	}
+
+	var reply string
+	if streamer, ok := r.assistant.(Streamer); ok {
+		reply, err = r.stream(ctx, streamer, prompt, recipient)
+	} else {
+		reply, err = r.reply(ctx, prompt, recipient)
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := r.transcript.Append(ctx, key, &Turn{Role: RoleAssistant, Text: reply, At: r.clock.Now()}); err != nil {
+		return fmt.Errorf("assistant: record reply: %v", err)
+	}
+
+	return nil
+}
+
+func (r *Responder) reply(ctx context.Context, prompt *Prompt, recipient string) (string, error) {
+	reply, err := r.assistant.Reply(ctx, prompt)
+	if err != nil {
+		return "", fmt.Errorf("assistant: reply: %v", err)
+	}
+	if strings.TrimSpace(reply) == "" {
+		return "", ErrEmptyReply
+	}
+
+	split := Split(reply, r.maxLength)
+	parts := make([]Message, len(split))
+	for i, part := range split {
+		parts[i] = Message{Text: part}
+	}
+	if _, err := r.SendSequence(ctx, recipient, parts...); err != nil {
+		return "", err
+	}
+
+	return reply, nil
+}
+
+// stream sends the paragraphs of the reply as they are completed, merged up to the message length.
+func (r *Responder) stream(ctx context.Context, streamer Streamer, prompt *Prompt, recipient string) (
+	string, error,
+) {
+	var reply, pending strings.Builder
+	flush := func(final bool) error {
+		text := pending.String()
+		var parts []string
+		switch i := strings.LastIndex(text, "\n\n"); {
+		case final:
+			parts, text = Split(text, r.maxLength), ""
+		case i >= 0:
+			// the paragraphs before the last one are complete.
+			parts, text = Split(text[:i], r.maxLength), text[i+2:]
+		case utf8.RuneCountInString(text) > r.maxLength:
+			// a paragraph over the message length, its last part may still grow.
+			parts = Split(text, r.maxLength)
+			last := parts[len(parts)-1]
+			parts, text = parts[:len(parts)-1], text[strings.LastIndex(text, last):]
+		}
+		pending.Reset()
+		pending.WriteString(text)
+
+		for _, part := range parts {
+			if err := r.send(ctx, recipient, part); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	err := streamer.Stream(ctx, prompt, func(chunk string) error {
+		reply.WriteString(chunk)
+		pending.WriteString(chunk)
+
+		return flush(false)
+	})
+	if err != nil {
+		return "", fmt.Errorf("assistant: stream: %v", err)
+	}
+	if strings.TrimSpace(reply.String()) == "" {
+		return "", ErrEmptyReply
+	}
+	if err := flush(true); err != nil {
+		return "", err
+	}
+
+	return reply.String(), nil
+}
+
+func (r *Responder) send(ctx context.Context, recipient, text string) error {
+	if strings.TrimSpace(text) == "" {
+		return nil
+	}
+	if _, err := r.sendPart(ctx, recipient, Message{Text: text}); err != nil {
+		return fmt.Errorf("assistant: send reply: %v", err)
+	}
+
+	return nil
+}
+
+// Prompt assembles the prompt of the conversation key: the system prompt, the summary and the
+// turns within the window. The turns that left the window since the last summary are summarized
+// first when a Summarizer is set.
+func (r *Responder) Prompt(ctx context.Context, key string) (*Prompt, error) {
+	store, summarize := r.transcript.(SummaryStore)
+	summarize = summarize && r.summarizer != nil
+
+	// all the turns not summarized yet are read to summarize the ones out of the window.
+	limit := r.maxTurns
+	if summarize {
+		limit = 0
+	}
+	turns, err := r.transcript.Recent(ctx, key, limit)
+	if err != nil {
+		return nil, fmt.Errorf("assistant: read transcript: %v", err)
+	}
+
+	kept := Window(turns, r.maxTurns, r.maxChars)
+	prompt := &Prompt{System: r.system, Turns: kept, Key: key}
+	if !summarize {
+		return prompt, nil
+	}
+
+	summary, err := store.Summary(ctx, key)
+	if err != nil {
+		return nil, fmt.Errorf("assistant: read summary: %v", err)
+	}
+	prompt.Summary = summary
+
+	dropped := turns[:len(turns)-len(kept)]
+	if len(dropped) == 0 {
+		return prompt, nil
+	}
+	if summary, err = r.summarizer.Summarize(ctx, summary, dropped); err != nil {
+		return nil, fmt.Errorf("assistant: summarize: %v", err)
+	}
+	if err := store.SetSummary(ctx, key, summary, len(dropped)); err != nil {
+		return nil, fmt.Errorf("assistant: write summary: %v", err)
+	}
+	prompt.Summary = summary
+
+	return prompt, nil
+}
+
+// Window returns the last turns, at most maxTurns and maxChars characters long. The last turn is
+// always kept.
+func Window(turns []*Turn, maxTurns, maxChars int) []*Turn {
+	if maxTurns > 0 && len(turns) > maxTurns {
+		turns = turns[len(turns)-maxTurns:]
+	}
+
+	chars, start := 0, len(turns)
+	for start > 0 {
+		n := utf8.RuneCountInString(turns[start-1].Text)
+		if maxChars > 0 && chars+n > maxChars && start < len(turns) {
+			break
+		}
+		chars += n
+		start--
+	}
+
+	return turns[start:]
+}
+
+// Split splits text into parts of at most maxLength characters, at the paragraph, line, sentence
+// or word boundaries when possible.
+func Split(text string, maxLength int) []string {
+	text = strings.TrimSpace(text)
+	if maxLength <= 0 || utf8.RuneCountInString(text) <= maxLength {
+		if text == "" {
+			return nil
+		}
+
+		return []string{text}
+	}
+
+	var parts []string
+	for utf8.RuneCountInString(text) > maxLength {
+		head := text[:byteOffset(text, maxLength)]
+		cut := len(head)
+		if rest := text[cut:]; strings.TrimLeft(rest, " \n") == rest {
+			// the head ends within a word, it is cut at the last boundary.
+			for _, separator := range []string{"\n\n", "\n", ". ", " "} {
+				if i := strings.LastIndex(head, separator); i > 0 {
+					cut = i + len(separator)
+
+					break
+				}
+			}
+		}
+		parts = append(parts, strings.TrimSpace(text[:cut]))
+		text = strings.TrimSpace(text[cut:])
+	}
+	if text != "" {
+		parts = append(parts, text)
+	}
+
+	return parts
+}
+
+// byteOffset returns the offset of the rune n of s.
+func byteOffset(s string, n int) int {
+	for i := range s {
+		if n == 0 {
+			return i
+		}
+		n--
+	}
+
+	return len(s)
+}
+
+// NewMemoryTranscript creates a MemoryTranscript that keeps the last DefaultMaxTurns*2 turns of
+// every conversation.
+func NewMemoryTranscript() *MemoryTranscript {
+	return &MemoryTranscript{
+		capacity:  DefaultMaxTurns * 2, //nolint:gomnd
+		turns:     make(map[string][]*Turn),
+		summaries: make(map[string]string),
+	}
+}
+
+func (t *MemoryTranscript) Append(_ context.Context, key string, turn *Turn) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	turns := append(t.turns[key], turn)
+	if len(turns) > t.capacity {
+		turns = turns[len(turns)-t.capacity:]
+	}
+	t.turns[key] = turns
+
+	return nil
+}
+
+func (t *MemoryTranscript) Recent(_ context.Context, key string, limit int) ([]*Turn, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	turns := t.turns[key]
+	if limit > 0 && len(turns) > limit {
+		turns = turns[len(turns)-limit:]
+	}
+
+	return append([]*Turn(nil), turns...), nil
+}
+
+func (t *MemoryTranscript) Summary(_ context.Context, key string) (string, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.summaries[key], nil
+}
+
+// SetSummary keeps the summary and removes the turns it summarizes, the oldest ones.
+func (t *MemoryTranscript) SetSummary(_ context.Context, key, summary string, turns int) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.summaries[key] = summary
+	kept := t.turns[key]
+	if turns > len(kept) {
+		turns = len(kept)
+	}
+	t.turns[key] = append([]*Turn(nil), kept[turns:]...)
+
+	return nil
+}
@@ -0,0 +1,178 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package assistant_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	whatsapp "github.com/lowkruc/go-whatsapp-api"
+	"github.com/lowkruc/go-whatsapp-api/assistant"
+)
+
+type fakeSender struct {
+	sent []string
+}
+
+func (s *fakeSender) SendTextMessage(_ context.Context, _ string, message *whatsapp.TextMessage) (
+	*whatsapp.ResponseMessage, error,
+) {
+	s.sent = append(s.sent, message.Message)
+
+	return &whatsapp.ResponseMessage{}, nil
+}
+
+type streamer struct {
+	chunks []string
+	sender *fakeSender
+	seen   []int
+}
+
+func (s *streamer) Reply(context.Context, *assistant.Prompt) (string, error) {
+	return strings.Join(s.chunks, ""), nil
+}
+
+func (s *streamer) Stream(_ context.Context, _ *assistant.Prompt, emit func(string) error) error {
+	for _, chunk := range s.chunks {
+		if err := emit(chunk); err != nil {
+			return err
+		}
+		s.seen = append(s.seen, len(s.sender.sent))
+	}
+
+	return nil
+}
+
+func TestSplit(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		text string
+		max  int
+		want []string
+	}{
+		{text: "short", max: 10, want: []string{"short"}},
+		{text: "first paragraph\n\nsecond one", max: 20, want: []string{"first paragraph", "second one"}},
+		{text: "one two three four", max: 9, want: []string{"one two", "three", "four"}},
+		{text: "Sentence one. Sentence two.", max: 20, want: []string{"Sentence one.", "Sentence two."}},
+		{text: "abcdefghij", max: 4, want: []string{"abcd", "efgh", "ij"}},
+	}
+
+	for _, tt := range tests {
+		if got := assistant.Split(tt.text, tt.max); strings.Join(got, "|") != strings.Join(tt.want, "|") {
+			t.Errorf("Split(%q, %d) = %q, want %q", tt.text, tt.max, got, tt.want)
+		}
+	}
+}
+
+func TestWindow(t *testing.T) {
+	t.Parallel()
+	turns := []*assistant.Turn{{Text: "aaaa"}, {Text: "bbbb"}, {Text: "cccc"}, {Text: "dddddddddd"}}
+
+	if got := assistant.Window(turns, 2, 0); len(got) != 2 || got[0].Text != "cccc" {
+		t.Errorf("got %d turns starting with %q, want the last 2", len(got), got[0].Text)
+	}
+	if got := assistant.Window(turns, 0, 15); len(got) != 2 || got[0].Text != "cccc" {
+		t.Errorf("got %d turns, want the last 2 within 15 characters", len(got))
+	}
+	// the last turn is always kept.
+	if got := assistant.Window(turns, 0, 5); len(got) != 1 || got[0].Text != "dddddddddd" {
+		t.Errorf("got %d turns, want the last one", len(got))
+	}
+}
+
+type summarizer struct {
+	calls int
+	turns []string
+}
+
+func (s *summarizer) Summarize(_ context.Context, previous string, turns []*assistant.Turn) (string, error) {
+	s.calls++
+	for _, turn := range turns {
+		s.turns = append(s.turns, turn.Text)
+	}
+
+	return strings.Join(s.turns, ","), nil
+}
+
+func TestResponder(t *testing.T) {
+	t.Parallel()
+	sender := &fakeSender{}
+	var prompts []*assistant.Prompt
+	reply := assistant.AssistantFunc(func(ctx context.Context, prompt *assistant.Prompt) (string, error) {
+		prompts = append(prompts, prompt)
+
+		return "reply to " + prompt.Turns[len(prompt.Turns)-1].Text + "\n\nsee you", nil
+	})
+	sum := &summarizer{}
+	transcript := assistant.NewMemoryTranscript()
+	responder := assistant.NewResponder(sender, reply, transcript,
+		assistant.WithSystemPrompt("be nice"),
+		assistant.WithWindow(2, 0),
+		assistant.WithSummarizer(sum),
+		assistant.WithMaxMessageLength(15))
+
+	ctx := context.Background()
+	for _, text := range []string{"hi", "hours?"} {
+		if err := responder.Respond(ctx, "phone:16505551234", "16505551234", "wamid."+text, text); err != nil {
+			t.Fatalf("Respond() error = %v", err)
+		}
+	}
+
+	if got := strings.Join(sender.sent, "|"); got != "reply to hi|see you|reply to hours?|see you" {
+		t.Errorf("sent %q", got)
+	}
+
+	last := prompts[1]
+	if last.System != "be nice" || len(last.Turns) != 2 || last.Turns[0].Text != "reply to hi\n\nsee you" ||
+		last.Turns[0].Role != assistant.RoleAssistant || last.Turns[1].Text != "hours?" {
+		t.Errorf("unexpected prompt %+v", last)
+	}
+	if sum.calls != 1 || last.Summary != "hi" {
+		t.Errorf("got %d summaries, prompt summary %q, want the first message summarized", sum.calls, last.Summary)
+	}
+
+	turns, err := transcript.Recent(ctx, "phone:16505551234", 0)
+	if err != nil || len(turns) != 3 {
+		t.Errorf("got %d turns in the transcript, error %v, want the 3 not summarized", len(turns), err)
+	}
+}
+
+func TestResponder_Stream(t *testing.T) {
+	t.Parallel()
+	sender := &fakeSender{}
+	s := &streamer{
+		chunks: []string{"Hello", " world\n\nSecond", " paragraph"},
+		sender: sender,
+	}
+	responder := assistant.NewResponder(sender, s, assistant.NewMemoryTranscript())
+
+	if err := responder.Respond(context.Background(), "key", "16505551234", "wamid.1", "hi"); err != nil {
+		t.Fatalf("Respond() error = %v", err)
+	}
+
+	if got := strings.Join(sender.sent, "|"); got != "Hello world|Second paragraph" {
+		t.Errorf("sent %q", got)
+	}
+	// the first paragraph is sent while the reply is streamed.
+	if len(s.seen) != 3 || s.seen[0] != 0 || s.seen[1] != 1 || s.seen[2] != 1 {
+		t.Errorf("messages sent after each chunk %v, want [0 1 1]", s.seen)
+	}
+}
@@ -0,0 +1,209 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package assistant
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	whatsapp "github.com/lowkruc/go-whatsapp-api"
+	"github.com/lowkruc/go-whatsapp-api/clock"
+	"github.com/lowkruc/go-whatsapp-api/models"
+)
+
+var ErrNoMessageSender = errors.New("sender can not send messages other than text")
+
+type (
+	// Message is a part of a multi message response. Text is sent as a text message, Content when
+	// set is sent as is, to the recipient of the sequence, and needs a Sender that implements
+	// MessageSender.
+	Message struct {
+		Text       string
+		PreviewURL bool
+		Content    *models.Message
+	}
+
+	// MessageSender is implemented by the senders of any kind of message, *whatsapp.Client
+	// implements it.
+	MessageSender interface {
+		SendMessage(ctx context.Context, message *models.Message) (*whatsapp.ResponseMessage, error)
+	}
+
+	// SequenceResult is the outcome of SendSequence. MessageIDs are the IDs of the parts in order,
+	// empty for the parts that were skipped or not sent. Failed is the index of the part that could
+	// not be sent, -1 when all of them were, the parts after it are not sent. Err is why it failed.
+	SequenceResult struct {
+		MessageIDs []string
+		Sent       int
+		Failed     int
+		Err        error
+	}
+
+	// pacer is a token bucket per recipient: a recipient gets burst messages at once and then one
+	// every interval.
+	pacer struct {
+		mu       sync.Mutex
+		interval time.Duration
+		burst    int
+		clock    clock.Clock
+		buckets  map[string]*bucket
+	}
+
+	bucket struct {
+		tokens float64
+		at     time.Time
+	}
+)
+
+// WithPacing paces the messages sent to a recipient, the replies split in many messages and the
+// parts of SendSequence included: burst messages are sent at once and the next ones one every
+// interval. WhatsApp limits how fast a business can message the same user, too many messages in
+// a row fail with the pair rate limit error 131056. There is no pacing by default.
+func WithPacing(interval time.Duration, burst int) ResponderOption {
+	return func(r *Responder) {
+		if interval <= 0 {
+			r.pacer = nil
+
+			return
+		}
+		if burst < 1 {
+			burst = 1
+		}
+		r.pacer = &pacer{interval: interval, burst: burst, buckets: make(map[string]*bucket)}
+	}
+}
+
+// SendSequence sends the parts to recipient one after the other, in order, paced as set with
+// WithPacing. It stops at the first part that fails, or when ctx is done while waiting, the
+// result tells which parts were sent. The error is the result's Err.
+func (r *Responder) SendSequence(ctx context.Context, recipient string, parts ...Message) (*SequenceResult, error) {
+	result := &SequenceResult{MessageIDs: make([]string, len(parts)), Failed: -1}
+	for i, part := range parts {
+		if part.Content == nil && strings.TrimSpace(part.Text) == "" {
+			continue
+		}
+
+		id, err := r.sendPart(ctx, recipient, part)
+		if err != nil {
+			result.Failed = i
+			result.Err = fmt.Errorf("assistant: send part %d of %d: %v", i+1, len(parts), err)
+
+			return result, result.Err
+		}
+		result.MessageIDs[i] = id
+		result.Sent++
+	}
+
+	return result, nil
+}
+
+func (r *Responder) sendPart(ctx context.Context, recipient string, part Message) (string, error) {
+	if r.pacer != nil {
+		if err := r.pacer.wait(ctx, r.clock, recipient); err != nil {
+			return "", err
+		}
+	}
+
+	var (
+		response *whatsapp.ResponseMessage
+		err      error
+	)
+	if part.Content != nil {
+		sender, ok := r.sender.(MessageSender)
+		if !ok {
+			return "", ErrNoMessageSender
+		}
+		message := *part.Content
+		message.To = recipient
+		response, err = sender.SendMessage(ctx, &message)
+	} else {
+		response, err = r.sender.SendTextMessage(ctx, recipient, &whatsapp.TextMessage{
+			Message: part.Text, PreviewURL: part.PreviewURL,
+		})
+	}
+	if err != nil {
+		return "", err
+	}
+	if response != nil && len(response.Messages) > 0 && response.Messages[0] != nil {
+		return response.Messages[0].ID, nil
+	}
+
+	return "", nil
+}
+
+// wait takes a token of the bucket of recipient, waiting for it when the bucket is empty.
+func (p *pacer) wait(ctx context.Context, c clock.Clock, recipient string) error {
+	p.mu.Lock()
+	now := c.Now()
+	for key, b := range p.buckets {
+		// the full buckets are the same as no bucket.
+		if key != recipient && p.refill(b, now) >= float64(p.burst) {
+			delete(p.buckets, key)
+		}
+	}
+	b, ok := p.buckets[recipient]
+	if !ok {
+		b = &bucket{tokens: float64(p.burst), at: now}
+		p.buckets[recipient] = b
+	}
+	p.refill(b, now)
+	// the token is taken now, the bucket may go negative to queue the concurrent senders.
+	b.tokens--
+	delay := time.Duration(0)
+	if b.tokens < 0 {
+		delay = time.Duration(-b.tokens * float64(p.interval))
+	}
+	p.mu.Unlock()
+
+	if delay <= 0 {
+		return nil
+	}
+
+	done := make(chan struct{})
+	timer := c.AfterFunc(delay, func() { close(done) })
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		timer.Stop()
+		p.mu.Lock()
+		b.tokens++
+		p.mu.Unlock()
+
+		return ctx.Err()
+	}
+}
+
+// refill adds the tokens earned since the last refill, up to burst, and returns the tokens.
+func (p *pacer) refill(b *bucket, now time.Time) float64 {
+	if elapsed := now.Sub(b.at); elapsed > 0 {
+		b.tokens += float64(elapsed) / float64(p.interval)
+		if b.tokens > float64(p.burst) {
+			b.tokens = float64(p.burst)
+		}
+		b.at = now
+	}
+
+	return b.tokens
+}
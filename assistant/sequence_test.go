@@ -0,0 +1,156 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package assistant_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	whatsapp "github.com/lowkruc/go-whatsapp-api"
+	"github.com/lowkruc/go-whatsapp-api/assistant"
+	"github.com/lowkruc/go-whatsapp-api/clock"
+	"github.com/lowkruc/go-whatsapp-api/models"
+)
+
+type messageSender struct {
+	sent   []string
+	at     []time.Time
+	clock  clock.Clock
+	failOn string
+}
+
+func (s *messageSender) send(text string) (*whatsapp.ResponseMessage, error) {
+	if text == s.failOn {
+		return nil, errors.New("pair rate limit hit")
+	}
+	s.sent = append(s.sent, text)
+	if s.clock != nil {
+		s.at = append(s.at, s.clock.Now())
+	}
+
+	return &whatsapp.ResponseMessage{Messages: []*whatsapp.MessageID{{ID: fmt.Sprintf("wamid.%d", len(s.sent))}}}, nil
+}
+
+func (s *messageSender) SendTextMessage(_ context.Context, _ string, message *whatsapp.TextMessage) (
+	*whatsapp.ResponseMessage, error,
+) {
+	return s.send(message.Message)
+}
+
+func (s *messageSender) SendMessage(_ context.Context, message *models.Message) (*whatsapp.ResponseMessage, error) {
+	return s.send(message.Type + ":" + message.To)
+}
+
+func TestResponder_SendSequence(t *testing.T) {
+	t.Parallel()
+	sender := &messageSender{failOn: "three"}
+	responder := assistant.NewResponder(sender, nil, assistant.NewMemoryTranscript())
+
+	result, err := responder.SendSequence(context.TODO(), "16505551234",
+		assistant.Message{Text: "one"},
+		assistant.Message{Text: " "},
+		assistant.Message{Content: &models.Message{Type: "image"}},
+		assistant.Message{Text: "three"},
+		assistant.Message{Text: "four"},
+	)
+	if err == nil || result.Err != err {
+		t.Fatalf("SendSequence() error = %v, result error = %v", err, result.Err)
+	}
+	if result.Failed != 3 || result.Sent != 2 {
+		t.Errorf("got failed %d and sent %d, want 3 and 2", result.Failed, result.Sent)
+	}
+	if want := []string{"wamid.1", "", "wamid.2", "", ""}; fmt.Sprint(result.MessageIDs) != fmt.Sprint(want) {
+		t.Errorf("got message IDs %q, want %q", result.MessageIDs, want)
+	}
+	if want := []string{"one", "image:16505551234"}; fmt.Sprint(sender.sent) != fmt.Sprint(want) {
+		t.Errorf("got sent %q, want %q, the parts after the failure must not be sent", sender.sent, want)
+	}
+
+	texts := assistant.NewResponder(&fakeSender{}, nil, assistant.NewMemoryTranscript())
+	if _, err := texts.SendSequence(context.TODO(), "16505551234",
+		assistant.Message{Content: &models.Message{Type: "image"}}); err == nil {
+		t.Errorf("SendSequence() expected %v", assistant.ErrNoMessageSender)
+	}
+}
+
+func TestResponder_SendSequencePacing(t *testing.T) {
+	t.Parallel()
+	start := time.Date(2026, 10, 16, 9, 0, 0, 0, time.UTC)
+	fake := clock.NewFake(start)
+	sender := &messageSender{clock: fake}
+	responder := assistant.NewResponder(sender, nil, assistant.NewMemoryTranscript(),
+		assistant.WithClock(fake), assistant.WithPacing(time.Second, 2))
+
+	done := make(chan *assistant.SequenceResult)
+	go func() {
+		result, _ := responder.SendSequence(context.TODO(), "16505551234",
+			assistant.Message{Text: "a"}, assistant.Message{Text: "b"},
+			assistant.Message{Text: "c"}, assistant.Message{Text: "d"})
+		done <- result
+	}()
+
+	for {
+		select {
+		case result := <-done:
+			if result.Sent != 4 || result.Failed != -1 {
+				t.Fatalf("got %+v, want all the parts sent", result)
+			}
+			want := []time.Time{start, start, start.Add(time.Second), start.Add(2 * time.Second)}
+			if fmt.Sprint(sender.at) != fmt.Sprint(want) {
+				t.Errorf("got sent at %v, want %v", sender.at, want)
+			}
+
+			return
+		default:
+			if fake.Pending() > 0 {
+				fake.Advance(time.Second)
+			}
+			time.Sleep(time.Millisecond)
+		}
+	}
+}
+
+func TestResponder_SendSequenceCanceled(t *testing.T) {
+	t.Parallel()
+	fake := clock.NewFake(time.Date(2026, 10, 16, 9, 0, 0, 0, time.UTC))
+	sender := &messageSender{}
+	responder := assistant.NewResponder(sender, nil, assistant.NewMemoryTranscript(),
+		assistant.WithClock(fake), assistant.WithPacing(time.Minute, 1))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan *assistant.SequenceResult)
+	go func() {
+		result, _ := responder.SendSequence(ctx, "16505551234", assistant.Message{Text: "a"}, assistant.Message{Text: "b"})
+		done <- result
+	}()
+	for fake.Pending() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	cancel()
+
+	result := <-done
+	if result.Sent != 1 || result.Failed != 1 || !strings.Contains(result.Err.Error(), context.Canceled.Error()) {
+		t.Errorf("got %+v, want the second part not sent", result)
+	}
+}
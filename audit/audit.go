@@ -0,0 +1,268 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+// Package audit records the significant actions of the module in an append-only trail: webhooks
+// received, hooks invoked, sends attempted, retried or throttled. Every event gets a sequence
+// number that only grows, so that the order of the events can be reconstructed after an incident
+// even when their timestamps are equal or the clock moved backwards.
+//
+// A Trail is passed to the client with whatsapp.WithAuditTrail and to the webhooks handlers with
+// the AuditTrail field of webhooks.HandlerOptions.
+package audit
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	whttp "github.com/lowkruc/go-whatsapp-api/http"
+)
+
+// EventType is the kind of action recorded by an Event.
+type EventType string
+
+const (
+	WebhookReceived  EventType = "webhook_received"
+	HookInvoked      EventType = "hook_invoked"
+	SendAttempted    EventType = "send_attempted"
+	SendRetried      EventType = "send_retried"
+	SendThrottled    EventType = "send_throttled"
+	MessageModerated EventType = "message_moderated"
+)
+
+var ErrTrailClosed = errors.New("audit trail is closed")
+
+type (
+	// Event is an entry of the trail. Seq and Time are set by the Trail when the event is
+	// appended, Seq starts at 1.
+	Event struct {
+		Seq        uint64            `json:"seq"`
+		Time       time.Time         `json:"time"`
+		Type       EventType         `json:"type"`
+		Attributes map[string]string `json:"attributes,omitempty"`
+		Error      string            `json:"error,omitempty"`
+	}
+
+	// Trail is an append-only stream of events. Implementations must be safe for concurrent use
+	// and must assign the sequence numbers in the order the events are appended.
+	Trail interface {
+		Append(ctx context.Context, event *Event) error
+	}
+
+	// NoOp is a Trail that discards the events.
+	NoOp struct{}
+
+	// MemoryTrail keeps the events in memory, it is meant for tests.
+	MemoryTrail struct {
+		mu     sync.Mutex
+		seq    uint64
+		events []Event
+	}
+
+	// FileTrail appends the events to a file as JSON lines.
+	FileTrail struct {
+		mu     sync.Mutex
+		seq    uint64
+		file   *os.File
+		writer *bufio.Writer
+		now    func() time.Time
+	}
+)
+
+func (NoOp) Append(context.Context, *Event) error { return nil }
+
+// OrNoOp returns trail, or NoOp when trail is nil.
+func OrNoOp(trail Trail) Trail {
+	if trail == nil {
+		return NoOp{}
+	}
+
+	return trail
+}
+
+// Record appends an event of the given type to the trail. Errors of the trail are ignored, an
+// audit trail that can not be written must not fail the action it records.
+func Record(ctx context.Context, trail Trail, eventType EventType, err error, attributes map[string]string) {
+	if trail == nil {
+		return
+	}
+
+	event := &Event{Type: eventType, Attributes: attributes}
+	if err != nil {
+		event.Error = err.Error()
+	}
+	_ = trail.Append(ctx, event)
+}
+
+func (t *MemoryTrail) Append(_ context.Context, event *Event) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.seq++
+	event.Seq = t.seq
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+	t.events = append(t.events, *event)
+
+	return nil
+}
+
+// Events returns a copy of the events appended so far.
+func (t *MemoryTrail) Events() []Event {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	events := make([]Event, len(t.events))
+	copy(events, t.events)
+
+	return events
+}
+
+// OpenFile opens the trail kept at path, creating the file when it does not exist. The sequence
+// continues from the last event of an existing file.
+func OpenFile(path string) (*FileTrail, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR|os.O_APPEND, 0o600) //nolint:gomnd
+	if err != nil {
+		return nil, fmt.Errorf("audit: open trail: %v", err)
+	}
+
+	seq, err := lastSeq(file)
+	if err != nil {
+		_ = file.Close()
+
+		return nil, fmt.Errorf("audit: open trail: %v", err)
+	}
+
+	return &FileTrail{
+		seq:    seq,
+		file:   file,
+		writer: bufio.NewWriter(file),
+		now:    time.Now,
+	}, nil
+}
+
+// lastSeq reads the sequence number of the last complete line of the file.
+func lastSeq(file *os.File) (uint64, error) {
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return 0, err
+	}
+
+	data = bytes.TrimRight(data, "\n")
+	if len(data) == 0 {
+		return 0, nil
+	}
+	if i := bytes.LastIndexByte(data, '\n'); i >= 0 {
+		data = data[i+1:]
+	}
+
+	var last struct {
+		Seq json.Number `json:"seq"`
+	}
+	if err := json.Unmarshal(data, &last); err != nil {
+		return 0, fmt.Errorf("last event: %v", err)
+	}
+
+	return strconv.ParseUint(last.Seq.String(), 10, 64) //nolint:gomnd
+}
+
+// Append writes the event and flushes it to the file, the file is not synced.
+func (t *FileTrail) Append(_ context.Context, event *Event) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.file == nil {
+		return ErrTrailClosed
+	}
+
+	t.seq++
+	event.Seq = t.seq
+	if event.Time.IsZero() {
+		event.Time = t.now()
+	}
+
+	data, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("audit: append: %v", err)
+	}
+	data = append(data, '\n')
+	if _, err := t.writer.Write(data); err != nil {
+		return fmt.Errorf("audit: append: %v", err)
+	}
+	if err := t.writer.Flush(); err != nil {
+		return fmt.Errorf("audit: append: %v", err)
+	}
+
+	return nil
+}
+
+// Close flushes and closes the file, appending to a closed trail returns ErrTrailClosed.
+func (t *FileTrail) Close() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.file == nil {
+		return nil
+	}
+
+	err := t.writer.Flush()
+	if cerr := t.file.Close(); err == nil {
+		err = cerr
+	}
+	t.file = nil
+
+	return err
+}
+
+// HTTPHook returns a whttp.Hook that records every request sent by the client as SendAttempted,
+// and as SendThrottled when the response status is 429 Too Many Requests.
+func HTTPHook(trail Trail) whttp.Hook {
+	return func(ctx context.Context, request *http.Request, response *http.Response) {
+		attributes := map[string]string{
+			"request": whttp.RequestNameFromContext(ctx),
+		}
+		if request != nil {
+			attributes["method"] = request.Method
+			attributes["path"] = request.URL.Path
+		}
+
+		var err error
+		if response == nil {
+			err = errors.New("no response")
+		} else {
+			attributes["status"] = strconv.Itoa(response.StatusCode)
+		}
+
+		Record(ctx, trail, SendAttempted, err, attributes)
+
+		if response != nil && response.StatusCode == http.StatusTooManyRequests {
+			Record(ctx, trail, SendThrottled, nil, attributes)
+		}
+	}
+}
@@ -0,0 +1,114 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package audit_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/lowkruc/go-whatsapp-api/audit"
+	whttp "github.com/lowkruc/go-whatsapp-api/http"
+	"github.com/lowkruc/go-whatsapp-api/webhooks"
+)
+
+func TestFileTrail(t *testing.T) {
+	t.Parallel()
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+
+	trail, err := audit.OpenFile(path)
+	if err != nil {
+		t.Fatalf("OpenFile() error = %v", err)
+	}
+	audit.Record(context.TODO(), trail, audit.SendAttempted, nil, map[string]string{"request": "send message"})
+	audit.Record(context.TODO(), trail, audit.SendRetried, errors.New("timeout"), nil)
+	if err := trail.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	if err := trail.Append(context.TODO(), &audit.Event{Type: audit.HookInvoked}); !errors.Is(err, audit.ErrTrailClosed) {
+		t.Errorf("Append() after Close() error = %v, want %v", err, audit.ErrTrailClosed)
+	}
+
+	// the sequence continues after the file is opened again.
+	trail, err = audit.OpenFile(path)
+	if err != nil {
+		t.Fatalf("OpenFile() error = %v", err)
+	}
+	defer trail.Close()
+
+	event := &audit.Event{Type: audit.WebhookReceived}
+	if err := trail.Append(context.TODO(), event); err != nil {
+		t.Fatalf("Append() error = %v", err)
+	}
+
+	if event.Seq != 3 || event.Time.IsZero() {
+		t.Errorf("event = %+v, want seq 3 and a time", event)
+	}
+}
+
+func TestHTTPHook(t *testing.T) {
+	t.Parallel()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	trail := &audit.MemoryTrail{}
+	request := &whttp.Request{
+		Method:  http.MethodGet,
+		Context: &whttp.RequestContext{Name: "get media", BaseURL: server.URL, Endpoints: []string{"media"}},
+	}
+	_ = whttp.Do(context.TODO(), server.Client(), request, nil, audit.HTTPHook(trail))
+
+	events := trail.Events()
+	if len(events) != 2 || events[0].Type != audit.SendAttempted || events[1].Type != audit.SendThrottled {
+		t.Fatalf("events = %+v", events)
+	}
+
+	if events[0].Seq != 1 || events[1].Seq != 2 || events[0].Attributes["status"] != "429" ||
+		events[0].Attributes["request"] != "get media" {
+		t.Errorf("unexpected events %+v", events)
+	}
+}
+
+func TestNotificationHandler_AuditTrail(t *testing.T) {
+	t.Parallel()
+	trail := &audit.MemoryTrail{}
+	handler := webhooks.NotificationHandler(&webhooks.Hooks{}, webhooks.NoOpNotificationErrorHandler,
+		webhooks.NoOpHooksErrorHandler, &webhooks.HandlerOptions{AuditTrail: trail})
+
+	body := `{"object":"whatsapp_business_account","entry":[{"id":"1","changes":[{"field":"messages",
+		"value":{"statuses":[{"id":"wamid.1","status":"sent"}]}}]}]}`
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body)))
+
+	events := trail.Events()
+	if len(events) != 2 || events[0].Type != audit.WebhookReceived || events[1].Type != audit.HookInvoked {
+		t.Fatalf("events = %+v", events)
+	}
+
+	if events[0].Attributes["entries"] != "1" || events[1].Attributes["fields"] != "messages" {
+		t.Errorf("unexpected events %+v", events)
+	}
+}
@@ -0,0 +1,86 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package whatsapp
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/lowkruc/go-whatsapp-api/models"
+)
+
+// The budgets of the send path are documented in the README, run them with make bench.
+
+func benchmarkTemplate() *models.Template {
+	return models.NewInteractiveTemplate("order_update", &models.TemplateLanguage{Code: "en_US"},
+		[]*models.TemplateParameter{{Type: "text", Text: "#42"}},
+		[]*models.TemplateParameter{
+			{Type: "text", Text: "Amina"},
+			{Type: "currency", Currency: &models.TemplateCurrency{FallbackValue: "$10.99", Code: "USD", Amount1000: 10990}},
+		},
+		[]*models.InteractiveButtonTemplate{
+			{SubType: "quick_reply", Index: 0, Button: &models.TemplateButton{Type: "payload", Payload: "track"}},
+		})
+}
+
+func BenchmarkMessageBuilder(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_ = models.NewMessage("255700000000", models.WithTemplate(benchmarkTemplate()))
+	}
+}
+
+func BenchmarkMessageMarshal(b *testing.B) {
+	message := models.NewMessage("255700000000", models.WithTemplate(benchmarkTemplate()))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := json.Marshal(message); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkClient_SendTextMessage(b *testing.B) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"messaging_product":"whatsapp","contacts":[{"input":"255700000000",` +
+			`"wa_id":"255700000000"}],"messages":[{"id":"wamid.1"}]}`))
+	}))
+	defer server.Close()
+
+	client := NewClient(
+		WithHTTPClient(server.Client()),
+		WithBaseURL(server.URL),
+		WithPhoneNumberID("phone_id"),
+		WithAccessToken("token"),
+	)
+	message := &TextMessage{Message: "Your order has shipped"}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := client.SendTextMessage(context.TODO(), "255700000000", message); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
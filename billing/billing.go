@@ -0,0 +1,304 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+// Package billing aggregates the conversation and pricing information carried by message
+// status notifications into reports that can be reconciled against Meta invoices.
+//
+// WhatsApp bills per conversation and not per message. Every status notification of a message
+// that opened or belongs to a conversation carries the conversation ID, its origin and the pricing
+// category. The Reporter deduplicates statuses by conversation ID so that each conversation is
+// counted exactly once, on the day it was first seen, against the phone number that sent it.
+//
+// Example:
+//
+//	reporter := billing.NewReporter(billing.WithLocation(time.UTC))
+//	listener := webhooks.NewEventListener()
+//	listener.OnMessageStatusChange(reporter.OnMessageStatusChangeHook())
+//	...
+//	_ = reporter.WriteCSV(os.Stdout)
+package billing
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/lowkruc/go-whatsapp-api/webhooks"
+)
+
+// DateFormat is the format of the Row.Date field.
+const DateFormat = "2006-01-02"
+
+var ErrInvalidStatus = errors.New("invalid status")
+
+type (
+	// Conversation is a single conversation as seen by the Reporter. It is created the first time
+	// a status that belongs to the conversation is tracked.
+	Conversation struct {
+		ID            string    `json:"id"`
+		PhoneNumberID string    `json:"phone_number_id"`
+		Origin        string    `json:"origin,omitempty"`
+		Category      string    `json:"category,omitempty"`
+		PricingModel  string    `json:"pricing_model,omitempty"`
+		Billable      bool      `json:"billable"`
+		StartedAt     time.Time `json:"started_at"`
+	}
+
+	// Row is a single line of a report. It contains the number of conversations opened on Date
+	// by PhoneNumberID for the given Category. Billable is the number of those conversations that
+	// were flagged as billable.
+	Row struct {
+		Date          string `json:"date"`
+		PhoneNumberID string `json:"phone_number_id"`
+		Category      string `json:"category"`
+		PricingModel  string `json:"pricing_model"`
+		Conversations int    `json:"conversations"`
+		Billable      int    `json:"billable"`
+	}
+
+	// Reporter collects conversations from message statuses and aggregates them per day, phone
+	// number and pricing category. It is safe for concurrent use.
+	Reporter struct {
+		mu            sync.RWMutex
+		location      *time.Location
+		conversations map[string]*Conversation
+	}
+
+	ReporterOption func(*Reporter)
+)
+
+// WithLocation sets the location used to bucket conversations into days. Meta invoices are
+// generated in the timezone of the business account, the default is time.UTC.
+func WithLocation(location *time.Location) ReporterOption {
+	return func(reporter *Reporter) {
+		if location != nil {
+			reporter.location = location
+		}
+	}
+}
+
+// NewReporter creates a new Reporter.
+func NewReporter(options ...ReporterOption) *Reporter {
+	reporter := &Reporter{
+		location:      time.UTC,
+		conversations: make(map[string]*Conversation),
+	}
+
+	for _, option := range options {
+		option(reporter)
+	}
+
+	return reporter
+}
+
+// Track records the conversation the status belongs to. Statuses without conversation information,
+// like the read status, are ignored. A conversation that has already been recorded is not counted
+// twice, but pricing details missing from the first status are filled in from later ones.
+func (reporter *Reporter) Track(phoneNumberID string, status *webhooks.Status) error {
+	if status == nil {
+		return fmt.Errorf("%v: status is nil", ErrInvalidStatus)
+	}
+
+	if status.Conversation == nil || status.Conversation.ID == "" {
+		return nil
+	}
+
+	startedAt, err := parseTimestamp(status.Timestamp)
+	if err != nil {
+		return fmt.Errorf("%v: %v", ErrInvalidStatus, err)
+	}
+
+	reporter.mu.Lock()
+	defer reporter.mu.Unlock()
+
+	conversation, ok := reporter.conversations[status.Conversation.ID]
+	if !ok {
+		conversation = &Conversation{
+			ID:            status.Conversation.ID,
+			PhoneNumberID: phoneNumberID,
+			StartedAt:     startedAt,
+		}
+		reporter.conversations[conversation.ID] = conversation
+	}
+
+	if startedAt.Before(conversation.StartedAt) {
+		conversation.StartedAt = startedAt
+	}
+
+	if conversation.Origin == "" && status.Conversation.Origin != nil {
+		conversation.Origin = status.Conversation.Origin.Type
+	}
+
+	if status.Pricing != nil {
+		if conversation.Category == "" {
+			conversation.Category = status.Pricing.Category
+		}
+		if conversation.PricingModel == "" {
+			conversation.PricingModel = status.Pricing.PricingModel
+		}
+		conversation.Billable = conversation.Billable || status.Pricing.Billable
+	}
+
+	return nil
+}
+
+// OnMessageStatusChangeHook returns a webhooks.OnMessageStatusChangeHook that tracks every status
+// received by the listener. The phone number is taken from the notification metadata.
+func (reporter *Reporter) OnMessageStatusChangeHook() webhooks.OnMessageStatusChangeHook {
+	return func(ctx context.Context, nctx *webhooks.NotificationContext, status *webhooks.Status) error {
+		var phoneNumberID string
+		if nctx != nil && nctx.Metadata != nil {
+			phoneNumberID = nctx.Metadata.PhoneNumberID
+		}
+
+		return reporter.Track(phoneNumberID, status)
+	}
+}
+
+// Conversations returns a copy of all the tracked conversations ordered by the time they started.
+func (reporter *Reporter) Conversations() []*Conversation {
+	reporter.mu.RLock()
+	defer reporter.mu.RUnlock()
+
+	list := make([]*Conversation, 0, len(reporter.conversations))
+	for _, conversation := range reporter.conversations {
+		c := *conversation
+		list = append(list, &c)
+	}
+
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].StartedAt.Equal(list[j].StartedAt) {
+			return list[i].ID < list[j].ID
+		}
+
+		return list[i].StartedAt.Before(list[j].StartedAt)
+	})
+
+	return list
+}
+
+// Report aggregates the tracked conversations into rows. The rows are sorted by date, phone number
+// and category.
+func (reporter *Reporter) Report() []*Row {
+	conversations := reporter.Conversations()
+	rows := make(map[string]*Row)
+	for _, conversation := range conversations {
+		date := conversation.StartedAt.In(reporter.location).Format(DateFormat)
+		key := date + "|" + conversation.PhoneNumberID + "|" + conversation.Category + "|" + conversation.PricingModel
+		row, ok := rows[key]
+		if !ok {
+			row = &Row{
+				Date:          date,
+				PhoneNumberID: conversation.PhoneNumberID,
+				Category:      conversation.Category,
+				PricingModel:  conversation.PricingModel,
+			}
+			rows[key] = row
+		}
+		row.Conversations++
+		if conversation.Billable {
+			row.Billable++
+		}
+	}
+
+	report := make([]*Row, 0, len(rows))
+	for _, row := range rows {
+		report = append(report, row)
+	}
+
+	sort.Slice(report, func(i, j int) bool {
+		a, b := report[i], report[j]
+		if a.Date != b.Date {
+			return a.Date < b.Date
+		}
+		if a.PhoneNumberID != b.PhoneNumberID {
+			return a.PhoneNumberID < b.PhoneNumberID
+		}
+		if a.Category != b.Category {
+			return a.Category < b.Category
+		}
+
+		return a.PricingModel < b.PricingModel
+	})
+
+	return report
+}
+
+// Reset removes all the tracked conversations. It is usually called after a report has been
+// exported for a closed billing period.
+func (reporter *Reporter) Reset() {
+	reporter.mu.Lock()
+	defer reporter.mu.Unlock()
+	reporter.conversations = make(map[string]*Conversation)
+}
+
+// WriteCSV writes the report to w as CSV with a header row.
+func (reporter *Reporter) WriteCSV(w io.Writer) error {
+	writer := csv.NewWriter(w)
+	header := []string{"date", "phone_number_id", "category", "pricing_model", "conversations", "billable"}
+	if err := writer.Write(header); err != nil {
+		return fmt.Errorf("write csv report: %v", err)
+	}
+
+	for _, row := range reporter.Report() {
+		record := []string{
+			row.Date,
+			row.PhoneNumberID,
+			row.Category,
+			row.PricingModel,
+			strconv.Itoa(row.Conversations),
+			strconv.Itoa(row.Billable),
+		}
+		if err := writer.Write(record); err != nil {
+			return fmt.Errorf("write csv report: %v", err)
+		}
+	}
+
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return fmt.Errorf("write csv report: %v", err)
+	}
+
+	return nil
+}
+
+// WriteJSON writes the report to w as a JSON array of rows.
+func (reporter *Reporter) WriteJSON(w io.Writer) error {
+	if err := json.NewEncoder(w).Encode(reporter.Report()); err != nil {
+		return fmt.Errorf("write json report: %v", err)
+	}
+
+	return nil
+}
+
+func parseTimestamp(timestamp string) (time.Time, error) {
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("parse timestamp %q: %v", timestamp, err)
+	}
+
+	return time.Unix(seconds, 0), nil
+}
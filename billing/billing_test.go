@@ -0,0 +1,88 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package billing
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/lowkruc/go-whatsapp-api/webhooks"
+)
+
+func status(id, conversationID, timestamp, category string, billable bool) *webhooks.Status {
+	return &webhooks.Status{
+		ID:          id,
+		StatusValue: "sent",
+		Timestamp:   timestamp,
+		Conversation: &webhooks.Conversation{
+			ID:     conversationID,
+			Origin: &webhooks.ConversationOrigin{Type: category},
+		},
+		Pricing: &webhooks.Pricing{
+			Billable:     billable,
+			Category:     category,
+			PricingModel: "CBP",
+		},
+	}
+}
+
+func TestReporter_WriteCSV(t *testing.T) {
+	t.Parallel()
+	reporter := NewReporter()
+
+	statuses := []struct {
+		phone  string
+		status *webhooks.Status
+	}{
+		{phone: "111", status: status("wamid.1", "c1", "1706634353", "service", true)},
+		{phone: "111", status: status("wamid.2", "c1", "1706634400", "service", true)},
+		{phone: "111", status: status("wamid.3", "c2", "1706634500", "marketing", true)},
+		{phone: "222", status: status("wamid.4", "c3", "1706720800", "utility", false)},
+		{phone: "111", status: &webhooks.Status{ID: "wamid.5", StatusValue: "read", Timestamp: "1706720900"}},
+	}
+
+	for _, s := range statuses {
+		if err := reporter.Track(s.phone, s.status); err != nil {
+			t.Fatalf("track: %v", err)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := reporter.WriteCSV(&buf); err != nil {
+		t.Fatalf("write csv: %v", err)
+	}
+
+	want := "date,phone_number_id,category,pricing_model,conversations,billable\n" +
+		"2024-01-30,111,marketing,CBP,1,1\n" +
+		"2024-01-30,111,service,CBP,1,1\n" +
+		"2024-01-31,222,utility,CBP,1,0\n"
+
+	if got := buf.String(); got != want {
+		t.Errorf("WriteCSV() got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestReporter_TrackInvalidTimestamp(t *testing.T) {
+	t.Parallel()
+	reporter := NewReporter()
+	if err := reporter.Track("111", status("wamid.1", "c1", "yesterday", "service", true)); err == nil {
+		t.Errorf("Track() expected error for invalid timestamp")
+	}
+}
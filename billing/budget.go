@@ -0,0 +1,278 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package billing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/lowkruc/go-whatsapp-api/clock"
+	"github.com/lowkruc/go-whatsapp-api/webhooks"
+)
+
+// CategoryMarketing is the pricing category of the marketing conversations.
+const CategoryMarketing = webhooks.PricingCategoryMarketing
+
+var ErrBudgetExceeded = errors.New("marketing budget exceeded")
+
+type (
+	// RateFunc returns the price of a marketing conversation with the recipient, which usually
+	// depends on its country, as found in the pricing analytics of the business account.
+	RateFunc func(recipient string) webhooks.Amount
+
+	// BudgetExceededError is returned by Budget.AllowMarketing when a new marketing conversation
+	// would take the spend of the recipient over the limit. The send can be deferred to RetryAt,
+	// the start of the next month.
+	BudgetExceededError struct {
+		Recipient string
+		Spent     webhooks.Amount
+		Limit     webhooks.Amount
+		RetryAt   time.Time
+	}
+
+	// Budget caps the marketing conversation spend per recipient and per month. The conversations
+	// are charged from the message statuses, once per conversation ID, and a send is allowed when
+	// the recipient has an open marketing conversation, which costs nothing more, or when a new one
+	// fits in the budget. It is safe for concurrent use.
+	//
+	// The spend is known once the statuses are received, so sends made in a burst before that can
+	// go over the limit by the conversations they open. Only the conversations and the spend of
+	// the latest month tracked and of the month before it are kept.
+	Budget struct {
+		mu        sync.Mutex
+		limit     webhooks.Amount
+		rate      RateFunc
+		location  *time.Location
+		clock     clock.Clock
+		seen      map[string]map[string]bool
+		latest    string
+		spent     map[string]map[string]webhooks.Amount
+		open      map[string]time.Time
+		overrides map[string]time.Time
+	}
+
+	BudgetOption func(*Budget)
+
+	transactionalKey struct{}
+)
+
+// WithBudgetLocation sets the location the months start in, the default is time.UTC.
+func WithBudgetLocation(location *time.Location) BudgetOption {
+	return func(budget *Budget) {
+		if location != nil {
+			budget.location = location
+		}
+	}
+}
+
+// WithBudgetClock sets the clock the sends are checked with, the real clock by default.
+func WithBudgetClock(c clock.Clock) BudgetOption {
+	return func(budget *Budget) {
+		budget.clock = c
+	}
+}
+
+// FlatRate returns a RateFunc that prices all the conversations at rate.
+func FlatRate(rate webhooks.Amount) RateFunc {
+	return func(string) webhooks.Amount {
+		return rate
+	}
+}
+
+// NewBudget creates a Budget that allows each recipient limit of marketing conversations per
+// month, priced with rate.
+func NewBudget(limit webhooks.Amount, rate RateFunc, options ...BudgetOption) *Budget {
+	budget := &Budget{
+		limit:     limit,
+		rate:      rate,
+		location:  time.UTC,
+		clock:     clock.Real{},
+		seen:      make(map[string]map[string]bool),
+		spent:     make(map[string]map[string]webhooks.Amount),
+		open:      make(map[string]time.Time),
+		overrides: make(map[string]time.Time),
+	}
+
+	for _, option := range options {
+		option(budget)
+	}
+
+	return budget
+}
+
+// WithTransactional marks the sends made with ctx as transactional traffic, like order updates,
+// which Budget.AllowMarketing always allows.
+func WithTransactional(ctx context.Context) context.Context {
+	return context.WithValue(ctx, transactionalKey{}, true)
+}
+
+// IsTransactional reports whether ctx was marked with WithTransactional.
+func IsTransactional(ctx context.Context) bool {
+	transactional, _ := ctx.Value(transactionalKey{}).(bool)
+
+	return transactional
+}
+
+func (e *BudgetExceededError) Error() string {
+	return fmt.Sprintf("%v: %s spent %s of %s, retry at %s", ErrBudgetExceeded, e.Recipient, e.Spent,
+		e.Limit, e.RetryAt.Format(time.RFC3339))
+}
+
+// Track charges the marketing conversation the status belongs to, the first time it is seen.
+// Statuses of other categories, without conversation or not billable are ignored.
+func (budget *Budget) Track(status *webhooks.Status) error {
+	if status == nil {
+		return fmt.Errorf("%v: status is nil", ErrInvalidStatus)
+	}
+	if status.ConversationID() == "" || status.PricingCategory() != CategoryMarketing {
+		return nil
+	}
+
+	startedAt, err := parseTimestamp(status.Timestamp)
+	if err != nil {
+		return fmt.Errorf("%v: %v", ErrInvalidStatus, err)
+	}
+	recipient := status.RecipientID
+
+	budget.mu.Lock()
+	defer budget.mu.Unlock()
+
+	if expiry := status.ConversationExpiresAt(); expiry.After(budget.open[recipient]) {
+		budget.open[recipient] = expiry
+	}
+
+	if budget.charged(status.ConversationID()) || !status.IsBillable() {
+		return nil
+	}
+	month := budget.month(startedAt)
+	budget.prune(startedAt)
+	if budget.seen[month] == nil {
+		budget.seen[month] = make(map[string]bool)
+	}
+	budget.seen[month][status.ConversationID()] = true
+
+	if budget.spent[recipient] == nil {
+		budget.spent[recipient] = make(map[string]webhooks.Amount)
+	}
+	budget.spent[recipient][month] = budget.spent[recipient][month].Add(budget.rate(recipient))
+
+	return nil
+}
+
+// OnMessageStatusChangeHook returns a webhooks.OnMessageStatusChangeHook that tracks every status
+// received by the listener.
+func (budget *Budget) OnMessageStatusChangeHook() webhooks.OnMessageStatusChangeHook {
+	return func(ctx context.Context, nctx *webhooks.NotificationContext, status *webhooks.Status) error {
+		return budget.Track(status)
+	}
+}
+
+// AllowMarketing returns nil when a marketing message can be sent to the recipient now, and a
+// *BudgetExceededError when it would open a conversation over the budget. The transactional sends,
+// see WithTransactional, and the recipients given an Override are always allowed.
+func (budget *Budget) AllowMarketing(ctx context.Context, recipient string) error {
+	if IsTransactional(ctx) {
+		return nil
+	}
+
+	now := budget.clock.Now()
+	budget.mu.Lock()
+	defer budget.mu.Unlock()
+
+	if now.Before(budget.overrides[recipient]) || now.Before(budget.open[recipient]) {
+		return nil
+	}
+
+	spent := budget.spent[recipient][budget.month(now)].Add(webhooks.Amount{})
+	if spent.Add(budget.rate(recipient)).Cmp(budget.limit) <= 0 {
+		return nil
+	}
+
+	local := now.In(budget.location)
+
+	return &BudgetExceededError{
+		Recipient: recipient,
+		Spent:     spent,
+		Limit:     budget.limit,
+		RetryAt:   time.Date(local.Year(), local.Month()+1, 1, 0, 0, 0, 0, budget.location),
+	}
+}
+
+// Override allows the marketing sends to the recipient until the given time, whatever its spend.
+func (budget *Budget) Override(recipient string, until time.Time) {
+	budget.mu.Lock()
+	defer budget.mu.Unlock()
+	budget.overrides[recipient] = until
+}
+
+// Spent returns the marketing spend of the recipient in the month of t, zero for the months no
+// longer kept.
+func (budget *Budget) Spent(recipient string, t time.Time) webhooks.Amount {
+	budget.mu.Lock()
+	defer budget.mu.Unlock()
+
+	return budget.spent[recipient][budget.month(t)].Add(webhooks.Amount{})
+}
+
+// charged reports whether the conversation has been charged in one of the months kept.
+func (budget *Budget) charged(conversationID string) bool {
+	for _, conversations := range budget.seen {
+		if conversations[conversationID] {
+			return true
+		}
+	}
+
+	return false
+}
+
+// prune drops the conversations and the spend of the months before the one preceding t, when t is
+// in a month after the latest tracked. The month before is kept for the conversations that span
+// the start of a month.
+func (budget *Budget) prune(t time.Time) {
+	month := budget.month(t)
+	if month <= budget.latest {
+		return
+	}
+	budget.latest = month
+	local := t.In(budget.location)
+	previous := budget.month(time.Date(local.Year(), local.Month()-1, 1, 0, 0, 0, 0, budget.location))
+	for m := range budget.seen {
+		if m < previous {
+			delete(budget.seen, m)
+		}
+	}
+	for recipient, months := range budget.spent {
+		for m := range months {
+			if m < previous {
+				delete(months, m)
+			}
+		}
+		if len(months) == 0 {
+			delete(budget.spent, recipient)
+		}
+	}
+}
+
+func (budget *Budget) month(t time.Time) string {
+	return t.In(budget.location).Format("2006-01")
+}
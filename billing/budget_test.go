@@ -0,0 +1,118 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package billing
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/lowkruc/go-whatsapp-api/clock"
+	"github.com/lowkruc/go-whatsapp-api/webhooks"
+)
+
+func TestBudget_AllowMarketing(t *testing.T) {
+	t.Parallel()
+	limit, _ := webhooks.ParseAmount("0.10")
+	rate, _ := webhooks.ParseAmount("0.05")
+	now := clock.NewFake(time.Date(2024, 1, 30, 18, 0, 0, 0, time.UTC))
+	budget := NewBudget(limit, FlatRate(rate), WithBudgetClock(now))
+	ctx := context.Background()
+
+	for i, s := range []struct {
+		conversationID, timestamp, expiry, category string
+	}{
+		{"c1", "1706634353", "1706720753", CategoryMarketing},
+		{"c1", "1706634400", "1706720753", CategoryMarketing},
+		{"c2", "1706600000", "", CategoryMarketing},
+		{"c3", "1706634500", "1706720900", "utility"},
+	} {
+		status := status("wamid."+s.conversationID, s.conversationID, s.timestamp, s.category, true)
+		status.RecipientID = "16505551234"
+		status.Conversation.Expiry = s.expiry
+		if err := budget.Track(status); err != nil {
+			t.Fatalf("Track() status %d error = %v", i, err)
+		}
+	}
+
+	if spent := budget.Spent("16505551234", now.Now()); spent.String() != "0.10" {
+		t.Fatalf("Spent() = %s, want 0.10", spent)
+	}
+	if err := budget.AllowMarketing(ctx, "16505551234"); err != nil {
+		t.Errorf("AllowMarketing() during an open conversation error = %v", err)
+	}
+	if err := budget.AllowMarketing(ctx, "16505550000"); err != nil {
+		t.Errorf("AllowMarketing() for another recipient error = %v", err)
+	}
+
+	now.Advance(24 * time.Hour)
+	err := budget.AllowMarketing(ctx, "16505551234")
+	var exceeded *BudgetExceededError
+	if !errors.As(err, &exceeded) || !exceeded.RetryAt.Equal(time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("AllowMarketing() over budget error = %v, want a BudgetExceededError", err)
+	}
+	if err := budget.AllowMarketing(WithTransactional(ctx), "16505551234"); err != nil {
+		t.Errorf("AllowMarketing() transactional error = %v", err)
+	}
+
+	budget.Override("16505551234", now.Now().Add(time.Hour))
+	if err := budget.AllowMarketing(ctx, "16505551234"); err != nil {
+		t.Errorf("AllowMarketing() with an override error = %v", err)
+	}
+
+	now.Set(exceeded.RetryAt)
+	if err := budget.AllowMarketing(ctx, "16505551234"); err != nil {
+		t.Errorf("AllowMarketing() next month error = %v", err)
+	}
+}
+
+func TestBudget_TrackPrunesMonths(t *testing.T) {
+	t.Parallel()
+	rate, _ := webhooks.ParseAmount("0.05")
+	budget := NewBudget(webhooks.Amount{}, FlatRate(rate))
+	track := func(conversationID string, at time.Time) {
+		t.Helper()
+		status := status("wamid."+conversationID, conversationID, strconv.FormatInt(at.Unix(), 10),
+			CategoryMarketing, true)
+		status.RecipientID = "16505551234"
+		if err := budget.Track(status); err != nil {
+			t.Fatalf("Track() error = %v", err)
+		}
+	}
+
+	january := time.Date(2024, 1, 31, 23, 0, 0, 0, time.UTC)
+	track("c1", january)
+	// a status of the same conversation received in the next month is not charged again.
+	track("c1", january.Add(2*time.Hour))
+	track("c2", time.Date(2024, 2, 10, 0, 0, 0, 0, time.UTC))
+	if spent := budget.Spent("16505551234", january); spent.String() != "0.05" {
+		t.Errorf("Spent() in January = %s, want 0.05", spent)
+	}
+
+	track("c3", time.Date(2024, 4, 2, 0, 0, 0, 0, time.UTC))
+	if len(budget.seen) != 1 || budget.seen["2024-04"] == nil {
+		t.Errorf("got the conversations of the months %v, want only April kept", budget.seen)
+	}
+	if spent := budget.Spent("16505551234", january); spent.Cmp(webhooks.Amount{}) != 0 {
+		t.Errorf("Spent() in January = %s, want the month pruned", spent)
+	}
+}
@@ -0,0 +1,279 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package botspec
+
+import (
+	"context"
+	"fmt"
+
+	whatsapp "github.com/lowkruc/go-whatsapp-api"
+	"github.com/lowkruc/go-whatsapp-api/models"
+	"github.com/lowkruc/go-whatsapp-api/textmatch"
+	"github.com/lowkruc/go-whatsapp-api/webhooks"
+)
+
+// flowMessageVersion is the version of the flow messages the bot sends.
+const flowMessageVersion = "3"
+
+type (
+	// Client sends the replies, *whatsapp.Client implements it.
+	Client interface {
+		SendTextMessage(ctx context.Context, recipient string, message *whatsapp.TextMessage) (
+			*whatsapp.ResponseMessage, error)
+		SendInteractiveMessage(ctx context.Context, recipient string, req *models.Interactive) (
+			*whatsapp.ResponseMessage, error)
+	}
+
+	// Bot is a compiled Spec.
+	Bot struct {
+		spec     *Spec
+		client   Client
+		matcher  *textmatch.Matcher
+		keywords map[string]Target
+		rows     map[string]Target
+	}
+)
+
+// Compile validates the spec and compiles it into a Bot that sends with client.
+func Compile(spec *Spec, client Client) (*Bot, error) {
+	if err := spec.Validate(); err != nil {
+		return nil, err
+	}
+
+	bot := &Bot{
+		spec:     spec,
+		client:   client,
+		keywords: map[string]Target{},
+		rows:     map[string]Target{},
+	}
+
+	var keywords []string
+	for _, route := range spec.Routes {
+		for _, keyword := range route.Keywords {
+			keywords = append(keywords, keyword)
+			bot.keywords[keyword] = route.Target
+		}
+	}
+	bot.matcher = textmatch.NewMatcher(keywords...)
+
+	for name, menu := range spec.Menus {
+		position := 0
+		for _, section := range menu.Sections {
+			for _, row := range section.Rows {
+				position++
+				bot.rows[rowID(name, position, row)] = row.Target
+			}
+		}
+	}
+
+	return bot, nil
+}
+
+// Attach registers the hooks of the bot on the listener: a text handler, added to the other
+// handlers registered with OnText, and the interactive message hook, which replaces the one set.
+// Use InteractiveHook to keep handling the other interactive replies.
+func (bot *Bot) Attach(listener *webhooks.EventListener) {
+	listener.OnText(bot.TextHook())
+	listener.OnInteractiveMessage(bot.InteractiveHook(nil))
+}
+
+// TextHook returns a handler of the text messages that sends the target of the route matching the
+// text, or the fallback.
+func (bot *Bot) TextHook() webhooks.OnTextMessageHook {
+	return func(ctx context.Context, nctx *webhooks.NotificationContext, mctx *webhooks.MessageContext,
+		text *webhooks.Text,
+	) error {
+		if text == nil {
+			return nil
+		}
+		_, err := bot.HandleText(ctx, mctx.From, text.Body)
+
+		return err
+	}
+}
+
+// InteractiveHook returns a hook of the interactive messages that sends the target of the menu row
+// chosen, and calls next with the replies that are not rows of the menus. next may be nil.
+func (bot *Bot) InteractiveHook(next webhooks.OnInteractiveMessageHook) webhooks.OnInteractiveMessageHook {
+	return func(ctx context.Context, nctx *webhooks.NotificationContext, mctx *webhooks.MessageContext,
+		interactive *webhooks.Interactive,
+	) error {
+		if id := replyID(interactive); id != "" {
+			if handled, err := bot.HandleReply(ctx, mctx.From, id); handled || err != nil {
+				return err
+			}
+		}
+
+		if next == nil {
+			return nil
+		}
+
+		return next(ctx, nctx, mctx, interactive)
+	}
+}
+
+// HandleText sends to the sender the target of the route matching the text, or the fallback. It
+// reports whether something was sent.
+func (bot *Bot) HandleText(ctx context.Context, from, text string) (bool, error) {
+	if keyword, ok := bot.matcher.Match(text); ok {
+		return true, bot.Send(ctx, from, bot.keywords[keyword])
+	}
+
+	if bot.spec.Fallback == nil {
+		return false, nil
+	}
+
+	return true, bot.Send(ctx, from, *bot.spec.Fallback)
+}
+
+// HandleReply sends to the sender the target of the menu row with the ID. It reports whether the ID
+// is a row of the menus.
+func (bot *Bot) HandleReply(ctx context.Context, from, id string) (bool, error) {
+	target, ok := bot.rows[id]
+	if !ok {
+		return false, nil
+	}
+
+	return true, bot.Send(ctx, from, target)
+}
+
+// Send sends the target to the recipient. A target that is not in the spec is a *ValidationError.
+func (bot *Bot) Send(ctx context.Context, recipient string, target Target) error {
+	v := &validator{spec: bot.spec}
+	if v.target("target", &target); len(v.problems) > 0 {
+		return &ValidationError{Problems: v.problems}
+	}
+
+	var err error
+	switch {
+	case target.Reply != "":
+		reply := bot.spec.Replies[target.Reply]
+		_, err = bot.client.SendTextMessage(ctx, recipient, &whatsapp.TextMessage{
+			Message:    reply.Text,
+			PreviewURL: reply.PreviewURL,
+		})
+	case target.Menu != "":
+		_, err = bot.client.SendInteractiveMessage(ctx, recipient, bot.menu(target.Menu))
+	default:
+		_, err = bot.client.SendInteractiveMessage(ctx, recipient, bot.flow(target.Flow))
+	}
+	if err != nil {
+		return fmt.Errorf("botspec: send %s: %v", target, err)
+	}
+
+	return nil
+}
+
+// String returns the kind and the name of the target, "menu main".
+func (target Target) String() string {
+	switch {
+	case target.Reply != "":
+		return "reply " + target.Reply
+	case target.Menu != "":
+		return "menu " + target.Menu
+	case target.Flow != "":
+		return "flow " + target.Flow
+	default:
+		return "empty target"
+	}
+}
+
+func (bot *Bot) menu(name string) *models.Interactive {
+	menu := bot.spec.Menus[name]
+	interactive := &models.Interactive{
+		Type:   models.InteractiveMessageList,
+		Body:   &models.InteractiveBody{Text: menu.Body},
+		Action: &models.InteractiveAction{Button: menu.Button},
+	}
+	decorate(interactive, menu.Header, menu.Footer)
+
+	position := 0
+	for _, section := range menu.Sections {
+		rows := make([]*models.InteractiveSectionRow, 0, len(section.Rows))
+		for _, row := range section.Rows {
+			position++
+			rows = append(rows, &models.InteractiveSectionRow{
+				ID:          rowID(name, position, row),
+				Title:       row.Title,
+				Description: row.Description,
+			})
+		}
+		interactive.Action.Sections = append(interactive.Action.Sections, &models.InteractiveSection{
+			Title: section.Title,
+			Rows:  rows,
+		})
+	}
+
+	return interactive
+}
+
+func (bot *Bot) flow(name string) *models.Interactive {
+	flow := bot.spec.Flows[name]
+	parameters := &models.InteractiveFlowParameters{
+		FlowMessageVersion: flowMessageVersion,
+		FlowToken:          flow.Token,
+		FlowID:             flow.FlowID,
+		FlowCTA:            flow.CTA,
+		FlowAction:         "data_exchange",
+	}
+	if flow.Screen != "" {
+		parameters.FlowAction = "navigate"
+		parameters.FlowActionPayload = &models.InteractiveFlowActionPayload{Screen: flow.Screen}
+	}
+	if flow.Draft {
+		parameters.Mode = "draft"
+	}
+
+	interactive := &models.Interactive{
+		Type: models.InteractiveMessageFlow,
+		Body: &models.InteractiveBody{Text: flow.Body},
+		Action: &models.InteractiveAction{
+			Name:       models.InteractiveMessageFlow,
+			Parameters: parameters,
+		},
+	}
+	decorate(interactive, flow.Header, flow.Footer)
+
+	return interactive
+}
+
+func decorate(interactive *models.Interactive, header, footer string) {
+	if header != "" {
+		interactive.Header = &models.InteractiveHeader{Type: string(models.InteractiveHeaderTypeText), Text: header}
+	}
+	if footer != "" {
+		interactive.Footer = &models.InteractiveFooter{Text: footer}
+	}
+}
+
+// replyID returns the ID of the list row or of the button the user chose.
+func replyID(interactive *webhooks.Interactive) string {
+	if interactive == nil || interactive.Type == nil {
+		return ""
+	}
+	if reply := interactive.Type.ListReply; reply != nil {
+		return reply.ID
+	}
+	if reply := interactive.Type.ButtonReply; reply != nil {
+		return reply.ID
+	}
+
+	return ""
+}
@@ -0,0 +1,377 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+// Package botspec defines a bot in a configuration file: keyword routes, menus sent as
+// interactive lists, canned replies and flow triggers. The file is validated and compiled into
+// hooks of a webhooks.EventListener at startup, so that the behavior of the bot can be changed
+// without changing the code.
+//
+// The specs are read from JSON with Load. The types have yaml tags as well, a YAML file can be
+// decoded into a Spec with any YAML package and then given to Compile.
+//
+// Example:
+//
+//	{
+//	  "replies": {"hours": {"text": "We are open 9 to 5, Monday to Friday."}},
+//	  "menus": {
+//	    "main": {
+//	      "body": "How can we help?", "button": "Options",
+//	      "sections": [{"rows": [
+//	        {"title": "Opening hours", "reply": "hours"},
+//	        {"title": "Book a table", "flow": "booking"}
+//	      ]}]
+//	    }
+//	  },
+//	  "flows": {"booking": {"flow_id": "1234", "cta": "Book", "body": "Book a table", "screen": "DATE"}},
+//	  "routes": [
+//	    {"keywords": ["menu", "help"], "menu": "main"},
+//	    {"keywords": ["hours", "open"], "reply": "hours"}
+//	  ],
+//	  "fallback": {"menu": "main"}
+//	}
+package botspec
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/lowkruc/go-whatsapp-api/textmatch"
+)
+
+// Limits of the interactive list messages and of the text messages.
+const (
+	MaxTextLength         = 4096
+	MaxBodyLength         = 1024
+	MaxHeaderLength       = 60
+	MaxFooterLength       = 60
+	MaxButtonLength       = 20
+	MaxRows               = 10
+	MaxSections           = 10
+	MaxRowTitleLength     = 24
+	MaxRowDescLength      = 72
+	MaxRowIDLength        = 200
+	MaxSectionTitleLength = 24
+)
+
+var ErrInvalidSpec = errors.New("invalid bot spec")
+
+type (
+	// Spec is the definition of a bot. Replies, Menus and Flows are named, Routes and the rows of
+	// the menus refer to them by name through a Target.
+	Spec struct {
+		Replies  map[string]*Reply `json:"replies,omitempty"  yaml:"replies,omitempty"`
+		Menus    map[string]*Menu  `json:"menus,omitempty"    yaml:"menus,omitempty"`
+		Flows    map[string]*Flow  `json:"flows,omitempty"    yaml:"flows,omitempty"`
+		Routes   []*Route          `json:"routes,omitempty"   yaml:"routes,omitempty"`
+		Fallback *Target           `json:"fallback,omitempty" yaml:"fallback,omitempty"`
+	}
+
+	// Target is what the bot sends, exactly one of a reply, a menu or a flow, by name.
+	Target struct {
+		Reply string `json:"reply,omitempty" yaml:"reply,omitempty"`
+		Menu  string `json:"menu,omitempty"  yaml:"menu,omitempty"`
+		Flow  string `json:"flow,omitempty"  yaml:"flow,omitempty"`
+	}
+
+	// Route sends its target when a text message has one of the keywords, compared with
+	// textmatch, ignoring the case and the accents.
+	Route struct {
+		Keywords []string `json:"keywords" yaml:"keywords"`
+		Target   `yaml:",inline"`
+	}
+
+	// Reply is a canned text reply.
+	Reply struct {
+		Text       string `json:"text"                  yaml:"text"`
+		PreviewURL bool   `json:"preview_url,omitempty" yaml:"preview_url,omitempty"`
+	}
+
+	// Menu is sent as an interactive list message.
+	Menu struct {
+		Header   string     `json:"header,omitempty" yaml:"header,omitempty"`
+		Body     string     `json:"body"             yaml:"body"`
+		Footer   string     `json:"footer,omitempty" yaml:"footer,omitempty"`
+		Button   string     `json:"button"           yaml:"button"`
+		Sections []*Section `json:"sections"         yaml:"sections"`
+	}
+
+	// Section is a section of a Menu, its title is required when the menu has several sections.
+	Section struct {
+		Title string `json:"title,omitempty" yaml:"title,omitempty"`
+		Rows  []*Row `json:"rows"            yaml:"rows"`
+	}
+
+	// Row is a choice of a Menu, choosing it sends its target. ID is the ID of the row in the
+	// list reply, by default the name of the menu and the position of the row, "main.2".
+	Row struct {
+		ID          string `json:"id,omitempty"          yaml:"id,omitempty"`
+		Title       string `json:"title"                 yaml:"title"`
+		Description string `json:"description,omitempty" yaml:"description,omitempty"`
+		Target      `yaml:",inline"`
+	}
+
+	// Flow is sent as a flow message that opens the flow on Screen, or starts with a data
+	// exchange request when Screen is empty. Draft sends flows that are not published yet.
+	Flow struct {
+		FlowID string `json:"flow_id"          yaml:"flow_id"`
+		CTA    string `json:"cta"              yaml:"cta"`
+		Header string `json:"header,omitempty" yaml:"header,omitempty"`
+		Body   string `json:"body"             yaml:"body"`
+		Footer string `json:"footer,omitempty" yaml:"footer,omitempty"`
+		Screen string `json:"screen,omitempty" yaml:"screen,omitempty"`
+		Token  string `json:"token,omitempty"  yaml:"token,omitempty"`
+		Draft  bool   `json:"draft,omitempty"  yaml:"draft,omitempty"`
+	}
+
+	// ValidationError lists the problems of a Spec.
+	ValidationError struct {
+		Problems []string
+	}
+)
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("%v: %s", ErrInvalidSpec, strings.Join(e.Problems, "; "))
+}
+
+func (e *ValidationError) Unwrap() error {
+	return ErrInvalidSpec
+}
+
+// Load decodes a JSON Spec, keys that are not part of the Spec are errors so that typos are
+// noticed, and validates it.
+func Load(r io.Reader) (*Spec, error) {
+	decoder := json.NewDecoder(r)
+	decoder.DisallowUnknownFields()
+
+	spec := &Spec{}
+	if err := decoder.Decode(spec); err != nil {
+		return nil, &ValidationError{Problems: []string{err.Error()}}
+	}
+
+	if err := spec.Validate(); err != nil {
+		return nil, err
+	}
+
+	return spec, nil
+}
+
+// Parse is Load from a byte slice.
+func Parse(data []byte) (*Spec, error) {
+	return Load(bytes.NewReader(data))
+}
+
+// Validate checks that the targets exist, that the keywords and the row IDs are unique and that
+// the texts are within the limits of WhatsApp. It returns a *ValidationError with all the problems.
+func (spec *Spec) Validate() error {
+	v := &validator{spec: spec}
+
+	for _, name := range sortedKeys(spec.Replies) {
+		reply := spec.Replies[name]
+		if reply == nil || strings.TrimSpace(reply.Text) == "" {
+			v.problem("reply %q: text is empty", name)
+
+			continue
+		}
+		v.length(fmt.Sprintf("reply %q: text", name), reply.Text, MaxTextLength)
+	}
+
+	rowIDs := map[string]string{}
+	for _, name := range sortedKeys(spec.Menus) {
+		v.menu(name, spec.Menus[name], rowIDs)
+	}
+
+	for _, name := range sortedKeys(spec.Flows) {
+		flow := spec.Flows[name]
+		where := fmt.Sprintf("flow %q", name)
+		if flow == nil {
+			v.problem("%s: is empty", where)
+
+			continue
+		}
+		v.required(where+": flow_id", flow.FlowID)
+		v.required(where+": cta", flow.CTA)
+		v.required(where+": body", flow.Body)
+		v.length(where+": body", flow.Body, MaxBodyLength)
+		v.length(where+": header", flow.Header, MaxHeaderLength)
+		v.length(where+": footer", flow.Footer, MaxFooterLength)
+	}
+
+	keywords := map[string]int{}
+	for i, route := range spec.Routes {
+		where := fmt.Sprintf("route %d", i+1)
+		if route == nil {
+			v.problem("%s: is empty", where)
+
+			continue
+		}
+		if len(route.Keywords) == 0 {
+			v.problem("%s: no keywords", where)
+		}
+		for _, keyword := range route.Keywords {
+			folded := textmatch.Fold(keyword)
+			if folded == "" {
+				v.problem("%s: empty keyword", where)
+
+				continue
+			}
+			if other, ok := keywords[folded]; ok && other != i {
+				v.problem("%s: keyword %q is already used by route %d", where, keyword, other+1)
+
+				continue
+			}
+			keywords[folded] = i
+		}
+		v.target(where, &route.Target)
+	}
+
+	if spec.Fallback != nil {
+		v.target("fallback", spec.Fallback)
+	}
+
+	if len(v.problems) == 0 {
+		return nil
+	}
+
+	return &ValidationError{Problems: v.problems}
+}
+
+type validator struct {
+	spec     *Spec
+	problems []string
+}
+
+func (v *validator) problem(format string, args ...any) {
+	v.problems = append(v.problems, fmt.Sprintf(format, args...))
+}
+
+func (v *validator) required(where, value string) {
+	if strings.TrimSpace(value) == "" {
+		v.problem("%s is required", where)
+	}
+}
+
+func (v *validator) length(where, value string, limit int) {
+	if n := utf8.RuneCountInString(value); n > limit {
+		v.problem("%s is %d characters long, the limit is %d", where, n, limit)
+	}
+}
+
+func (v *validator) target(where string, target *Target) {
+	set := 0
+	if target.Reply != "" {
+		set++
+		if _, ok := v.spec.Replies[target.Reply]; !ok {
+			v.problem("%s: unknown reply %q", where, target.Reply)
+		}
+	}
+	if target.Menu != "" {
+		set++
+		if _, ok := v.spec.Menus[target.Menu]; !ok {
+			v.problem("%s: unknown menu %q", where, target.Menu)
+		}
+	}
+	if target.Flow != "" {
+		set++
+		if _, ok := v.spec.Flows[target.Flow]; !ok {
+			v.problem("%s: unknown flow %q", where, target.Flow)
+		}
+	}
+	if set != 1 {
+		v.problem("%s: exactly one of reply, menu or flow must be set", where)
+	}
+}
+
+func (v *validator) menu(name string, menu *Menu, rowIDs map[string]string) {
+	where := fmt.Sprintf("menu %q", name)
+	if menu == nil {
+		v.problem("%s: is empty", where)
+
+		return
+	}
+	v.required(where+": body", menu.Body)
+	v.length(where+": body", menu.Body, MaxBodyLength)
+	v.required(where+": button", menu.Button)
+	v.length(where+": button", menu.Button, MaxButtonLength)
+	v.length(where+": header", menu.Header, MaxHeaderLength)
+	v.length(where+": footer", menu.Footer, MaxFooterLength)
+	if len(menu.Sections) == 0 || len(menu.Sections) > MaxSections {
+		v.problem("%s: has %d sections, it must have 1 to %d", where, len(menu.Sections), MaxSections)
+	}
+
+	rows := 0
+	for i, section := range menu.Sections {
+		swhere := fmt.Sprintf("%s section %d", where, i+1)
+		if section == nil {
+			v.problem("%s: is empty", swhere)
+
+			continue
+		}
+		if len(menu.Sections) > 1 {
+			v.required(swhere+": title", section.Title)
+		}
+		v.length(swhere+": title", section.Title, MaxSectionTitleLength)
+		for _, row := range section.Rows {
+			rows++
+			rwhere := fmt.Sprintf("%s row %d", where, rows)
+			if row == nil {
+				v.problem("%s: is empty", rwhere)
+
+				continue
+			}
+			v.required(rwhere+": title", row.Title)
+			v.length(rwhere+": title", row.Title, MaxRowTitleLength)
+			v.length(rwhere+": description", row.Description, MaxRowDescLength)
+			id := rowID(name, rows, row)
+			v.length(rwhere+": id", id, MaxRowIDLength)
+			if other, ok := rowIDs[id]; ok {
+				v.problem("%s: id %q is already used by %s", rwhere, id, other)
+			}
+			rowIDs[id] = rwhere
+			v.target(rwhere, &row.Target)
+		}
+	}
+	if rows == 0 || rows > MaxRows {
+		v.problem("%s: has %d rows, it must have 1 to %d", where, rows, MaxRows)
+	}
+}
+
+// rowID returns the ID of the row, position is counted from 1 across the sections of the menu.
+func rowID(menu string, position int, row *Row) string {
+	if row.ID != "" {
+		return row.ID
+	}
+
+	return fmt.Sprintf("%s.%d", menu, position)
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
@@ -0,0 +1,194 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package botspec_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	whatsapp "github.com/lowkruc/go-whatsapp-api"
+	"github.com/lowkruc/go-whatsapp-api/botspec"
+	"github.com/lowkruc/go-whatsapp-api/models"
+	"github.com/lowkruc/go-whatsapp-api/webhooks"
+)
+
+const spec = `{
+  "replies": {"hours": {"text": "We are open 9 to 5, Monday to Friday."}},
+  "menus": {
+    "main": {
+      "body": "How can we help?", "button": "Options", "footer": "Reply menu anytime",
+      "sections": [{"rows": [
+        {"title": "Opening hours", "reply": "hours"},
+        {"title": "Book a table", "flow": "booking"}
+      ]}]
+    }
+  },
+  "flows": {"booking": {"flow_id": "1234", "cta": "Book", "body": "Book a table", "screen": "DATE"}},
+  "routes": [
+    {"keywords": ["menu", "help"], "menu": "main"},
+    {"keywords": ["hours", "open"], "reply": "hours"}
+  ],
+  "fallback": {"menu": "main"}
+}`
+
+type sent struct {
+	recipient   string
+	text        string
+	interactive *models.Interactive
+}
+
+type fakeClient struct {
+	sent []sent
+}
+
+func (c *fakeClient) SendTextMessage(_ context.Context, recipient string, message *whatsapp.TextMessage) (
+	*whatsapp.ResponseMessage, error,
+) {
+	c.sent = append(c.sent, sent{recipient: recipient, text: message.Message})
+
+	return &whatsapp.ResponseMessage{}, nil
+}
+
+func (c *fakeClient) SendInteractiveMessage(_ context.Context, recipient string, req *models.Interactive) (
+	*whatsapp.ResponseMessage, error,
+) {
+	c.sent = append(c.sent, sent{recipient: recipient, interactive: req})
+
+	return &whatsapp.ResponseMessage{}, nil
+}
+
+func TestBot(t *testing.T) {
+	t.Parallel()
+	parsed, err := botspec.Parse([]byte(spec))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	client := &fakeClient{}
+	bot, err := botspec.Compile(parsed, client)
+	if err != nil {
+		t.Fatalf("Compile() error = %v", err)
+	}
+	ctx := context.Background()
+
+	if handled, err := bot.HandleText(ctx, "16505551234", "When are you OPEN?"); !handled || err != nil {
+		t.Fatalf("HandleText() = %v, %v", handled, err)
+	}
+	if len(client.sent) != 1 || client.sent[0].text != "We are open 9 to 5, Monday to Friday." {
+		t.Fatalf("sent %+v, want the hours", client.sent)
+	}
+
+	// the fallback sends the menu.
+	if _, err := bot.HandleText(ctx, "16505551234", "something else"); err != nil {
+		t.Fatalf("HandleText() error = %v", err)
+	}
+	menu := client.sent[1].interactive
+	if menu == nil || menu.Type != models.InteractiveMessageList || menu.Action.Button != "Options" ||
+		menu.Footer == nil || len(menu.Action.Sections) != 1 || len(menu.Action.Sections[0].Rows) != 2 ||
+		menu.Action.Sections[0].Rows[1].ID != "main.2" {
+		t.Fatalf("sent %+v, want the main menu", menu)
+	}
+
+	// choosing the second row sends the flow.
+	hook := bot.InteractiveHook(nil)
+	err = hook(ctx, &webhooks.NotificationContext{}, &webhooks.MessageContext{From: "16505551234"},
+		&webhooks.Interactive{Type: &webhooks.InteractiveType{ListReply: &webhooks.ListReply{ID: "main.2"}}})
+	if err != nil {
+		t.Fatalf("interactive hook error = %v", err)
+	}
+	flow := client.sent[2].interactive
+	if flow == nil || flow.Type != models.InteractiveMessageFlow || flow.Action.Parameters == nil ||
+		flow.Action.Parameters.FlowID != "1234" || flow.Action.Parameters.FlowAction != "navigate" ||
+		flow.Action.Parameters.FlowActionPayload.Screen != "DATE" {
+		t.Fatalf("sent %+v, want the booking flow", flow)
+	}
+
+	if handled, _ := bot.HandleReply(ctx, "16505551234", "unknown"); handled {
+		t.Error("an unknown reply ID was handled")
+	}
+}
+
+func TestValidate(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name string
+		spec string
+		want string
+	}{
+		{
+			name: "unknown key",
+			spec: `{"routes": [{"keyword": ["hi"], "reply": "hi"}]}`,
+			want: `unknown field "keyword"`,
+		},
+		{
+			name: "unknown target",
+			spec: `{"routes": [{"keywords": ["hi"], "reply": "hello"}]}`,
+			want: `route 1: unknown reply "hello"`,
+		},
+		{
+			name: "several targets",
+			spec: `{"replies": {"hi": {"text": "Hi"}}, "flows": {"f": {"flow_id": "1", "cta": "Go", "body": "Go"}},
+				"routes": [{"keywords": ["hi"], "reply": "hi", "flow": "f"}]}`,
+			want: "route 1: exactly one of reply, menu or flow must be set",
+		},
+		{
+			name: "duplicate keyword",
+			spec: `{"replies": {"hi": {"text": "Hi"}},
+				"routes": [{"keywords": ["Café"], "reply": "hi"}, {"keywords": ["cafe"], "reply": "hi"}]}`,
+			want: `route 2: keyword "cafe" is already used by route 1`,
+		},
+		{
+			name: "row title too long",
+			spec: `{"replies": {"hi": {"text": "Hi"}}, "menus": {"m": {"body": "Pick", "button": "Pick",
+				"sections": [{"rows": [{"title": "a title longer than the limit", "reply": "hi"}]}]}}}`,
+			want: `menu "m" row 1: title is 29 characters long, the limit is 24`,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			_, err := botspec.Parse([]byte(tt.spec))
+			if !errors.Is(err, botspec.ErrInvalidSpec) || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("got error %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestBot_SendUnknownTarget(t *testing.T) {
+	t.Parallel()
+	parsed, err := botspec.Parse([]byte(spec))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	bot, err := botspec.Compile(parsed, &fakeClient{})
+	if err != nil {
+		t.Fatalf("Compile() error = %v", err)
+	}
+
+	for _, target := range []botspec.Target{{}, {Menu: "missing"}} {
+		if err := bot.Send(context.Background(), "16505551234", target); !errors.Is(err, botspec.ErrInvalidSpec) {
+			t.Errorf("Send(%v) error = %v, want %v", target, err, botspec.ErrInvalidSpec)
+		}
+	}
+}
@@ -0,0 +1,149 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+// Package bridge connects WhatsApp conversations to other chat platforms through the canonical
+// chat.Message model. A Driver receives every inbound message and can answer through the Replier
+// it is given, without touching webhook payloads or the Cloud API request format.
+//
+// Example:
+//
+//	client := whatsapp.NewClient(...)
+//	b := bridge.New(bridge.ClientSender(client), bridge.NewEchoDriver(os.Stdout, true))
+//	listener := webhooks.NewEventListener()
+//	listener.OnMessageReceived(b.OnMessageReceivedHook())
+package bridge
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	whatsapp "github.com/lowkruc/go-whatsapp-api"
+	"github.com/lowkruc/go-whatsapp-api/chat"
+	"github.com/lowkruc/go-whatsapp-api/models"
+	"github.com/lowkruc/go-whatsapp-api/webhooks"
+)
+
+var ErrNoSender = errors.New("bridge has no sender")
+
+type (
+	// Sender sends a Cloud API message. ClientSender adapts a *whatsapp.Client.
+	Sender func(ctx context.Context, message *models.Message) error
+
+	// Replier answers the user that sent the message being delivered.
+	Replier interface {
+		Reply(ctx context.Context, message *chat.Message) error
+	}
+
+	// Driver is implemented by bridges to other platforms. Deliver is called for every inbound
+	// message, the replier can be kept and used later for asynchronous answers.
+	Driver interface {
+		Name() string
+		Deliver(ctx context.Context, message *chat.Message, replier Replier) error
+	}
+
+	// Bridge fans inbound messages out to drivers and sends their replies back to WhatsApp.
+	Bridge struct {
+		sender  Sender
+		drivers []Driver
+	}
+
+	replier struct {
+		sender   Sender
+		original *chat.Message
+	}
+)
+
+// ClientSender returns a Sender that uses client.SendMessage.
+func ClientSender(client *whatsapp.Client) Sender {
+	return func(ctx context.Context, message *models.Message) error {
+		if _, err := client.SendMessage(ctx, message); err != nil {
+			return err
+		}
+
+		return nil
+	}
+}
+
+// New creates a Bridge. The sender can be nil for one way bridges, replies then fail with ErrNoSender.
+func New(sender Sender, drivers ...Driver) *Bridge {
+	return &Bridge{
+		sender:  sender,
+		drivers: drivers,
+	}
+}
+
+// Register adds a driver to the bridge. It is not safe to call Register while messages are dispatched.
+func (b *Bridge) Register(driver Driver) {
+	b.drivers = append(b.drivers, driver)
+}
+
+// Dispatch delivers the message to all the drivers. Every driver receives the message even
+// if a previous one failed, the errors are returned together.
+func (b *Bridge) Dispatch(ctx context.Context, message *chat.Message) error {
+	r := &replier{sender: b.sender, original: message}
+	var failed []string
+	for _, driver := range b.drivers {
+		if err := driver.Deliver(ctx, message, r); err != nil {
+			failed = append(failed, fmt.Sprintf("%s: %v", driver.Name(), err))
+		}
+	}
+
+	if len(failed) > 0 {
+		return fmt.Errorf("bridge dispatch: %s", strings.Join(failed, ", "))
+	}
+
+	return nil
+}
+
+// OnMessageReceivedHook returns a webhooks.OnMessageReceivedHook that converts every received
+// message to a chat.Message and dispatches it.
+func (b *Bridge) OnMessageReceivedHook() webhooks.OnMessageReceivedHook {
+	return func(ctx context.Context, nctx *webhooks.NotificationContext, message *webhooks.Message) error {
+		m, err := chat.FromWebhook(nctx, message)
+		if err != nil {
+			return fmt.Errorf("bridge: %v", err)
+		}
+
+		return b.Dispatch(ctx, m)
+	}
+}
+
+// Reply sends message to the sender of the original message.
+func (r *replier) Reply(ctx context.Context, message *chat.Message) error {
+	if r.sender == nil {
+		return ErrNoSender
+	}
+
+	if r.original == nil || r.original.Sender == nil {
+		return fmt.Errorf("bridge reply: %v", chat.ErrMissingRecipient)
+	}
+
+	request, err := message.ToSendRequest(r.original.Sender.ID)
+	if err != nil {
+		return fmt.Errorf("bridge reply: %v", err)
+	}
+
+	if err := r.sender(ctx, request); err != nil {
+		return fmt.Errorf("bridge reply: %v", err)
+	}
+
+	return nil
+}
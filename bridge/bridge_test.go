@@ -0,0 +1,71 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package bridge
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/lowkruc/go-whatsapp-api/models"
+	"github.com/lowkruc/go-whatsapp-api/webhooks"
+)
+
+func TestEchoDriver(t *testing.T) {
+	t.Parallel()
+	var (
+		out  bytes.Buffer
+		sent []*models.Message
+	)
+
+	sender := func(ctx context.Context, message *models.Message) error {
+		sent = append(sent, message)
+
+		return nil
+	}
+
+	b := New(sender, NewEchoDriver(&out, true))
+	hook := b.OnMessageReceivedHook()
+
+	err := hook(context.TODO(), &webhooks.NotificationContext{}, &webhooks.Message{
+		From:      "255700000000",
+		ID:        "wamid.1",
+		Timestamp: "1706461964",
+		Type:      "text",
+		Text:      &webhooks.Text{Body: "hello"},
+	})
+	if err != nil {
+		t.Fatalf("hook error = %v", err)
+	}
+
+	if !strings.Contains(out.String(), "255700000000: text hello") {
+		t.Errorf("unexpected driver output %q", out.String())
+	}
+
+	if len(sent) != 1 {
+		t.Fatalf("got %d sent messages, want 1", len(sent))
+	}
+
+	if m := sent[0]; m.To != "255700000000" || m.Text == nil || m.Text.Body != "hello" ||
+		m.Context == nil || m.Context.MessageID != "wamid.1" {
+		t.Errorf("unexpected echo message %+v", m)
+	}
+}
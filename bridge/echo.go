@@ -0,0 +1,87 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package bridge
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/lowkruc/go-whatsapp-api/chat"
+)
+
+var _ Driver = (*EchoDriver)(nil)
+
+// EchoDriver is the reference Driver. It writes a line for every message it receives and, when
+// echo is enabled, sends text messages back to the user unchanged. It is meant as a starting
+// point for real bridges and for checking that a deployment receives and sends messages.
+type EchoDriver struct {
+	mu     sync.Mutex
+	writer io.Writer
+	echo   bool
+}
+
+// NewEchoDriver creates an EchoDriver that writes to w, os.Stdout is used when w is nil.
+func NewEchoDriver(w io.Writer, echo bool) *EchoDriver {
+	if w == nil {
+		w = os.Stdout
+	}
+
+	return &EchoDriver{
+		writer: w,
+		echo:   echo,
+	}
+}
+
+// Name returns the name of the driver.
+func (d *EchoDriver) Name() string {
+	return "echo"
+}
+
+// Deliver prints the message and echoes text messages back.
+func (d *EchoDriver) Deliver(ctx context.Context, message *chat.Message, replier Replier) error {
+	var from string
+	if message.Sender != nil {
+		from = message.Sender.ID
+		if message.Sender.Name != "" {
+			from = fmt.Sprintf("%s (%s)", message.Sender.Name, message.Sender.ID)
+		}
+	}
+
+	d.mu.Lock()
+	_, err := fmt.Fprintf(d.writer, "[%s] %s: %s %s\n",
+		message.Timestamp.Format("2006-01-02 15:04:05"), from, message.Kind, message.Text)
+	d.mu.Unlock()
+	if err != nil {
+		return fmt.Errorf("echo driver: %v", err)
+	}
+
+	if !d.echo || message.Kind != chat.KindText {
+		return nil
+	}
+
+	return replier.Reply(ctx, &chat.Message{
+		Kind:    chat.KindText,
+		Text:    message.Text,
+		ReplyTo: message.ID,
+	})
+}
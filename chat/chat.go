@@ -0,0 +1,325 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+// Package chat defines a compact, channel agnostic representation of a chat message. Help desks,
+// bots and bridges can work with chat.Message without knowing the shape of WhatsApp webhooks.
+//
+// Incoming webhook messages are converted with FromWebhook and FromNotification. Messages that
+// have an equivalent in the Cloud API can be converted back to a send request with ToSendRequest.
+package chat
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/lowkruc/go-whatsapp-api/models"
+	"github.com/lowkruc/go-whatsapp-api/webhooks"
+)
+
+const (
+	KindText        Kind = "text"
+	KindImage       Kind = "image"
+	KindAudio       Kind = "audio"
+	KindVideo       Kind = "video"
+	KindDocument    Kind = "document"
+	KindSticker     Kind = "sticker"
+	KindLocation    Kind = "location"
+	KindContacts    Kind = "contacts"
+	KindButton      Kind = "button"
+	KindInteractive Kind = "interactive"
+	KindReaction    Kind = "reaction"
+	KindOrder       Kind = "order"
+	KindSystem      Kind = "system"
+	KindUnknown     Kind = "unknown"
+)
+
+var (
+	ErrNilMessage       = errors.New("message is nil")
+	ErrNotConvertible   = errors.New("message can not be converted to a send request")
+	ErrMissingRecipient = errors.New("recipient is empty")
+)
+
+type (
+	// Kind is the kind of chat message.
+	Kind string
+
+	// Sender identifies who sent the message. ID is the WhatsApp ID of the user and Name is the
+	// profile name when it is available.
+	Sender struct {
+		ID   string `json:"id"`
+		Name string `json:"name,omitempty"`
+	}
+
+	// Attachment is a media file attached to a message. MediaID can be used to download the file
+	// or to send it again.
+	Attachment struct {
+		Kind     Kind   `json:"kind"`
+		MediaID  string `json:"media_id,omitempty"`
+		MimeType string `json:"mime_type,omitempty"`
+		SHA256   string `json:"sha256,omitempty"`
+		Filename string `json:"filename,omitempty"`
+		Caption  string `json:"caption,omitempty"`
+		Animated bool   `json:"animated,omitempty"`
+	}
+
+	// ButtonPress is a button or list item chosen by the user. Payload is only set for template quick
+	// reply buttons, ID is set for interactive replies.
+	ButtonPress struct {
+		ID      string `json:"id,omitempty"`
+		Title   string `json:"title,omitempty"`
+		Payload string `json:"payload,omitempty"`
+	}
+
+	// Message is a channel agnostic chat message.
+	//
+	// ConversationID identifies the business side of the conversation, for WhatsApp it is the phone
+	// number ID that received the message. ReplyTo is the ID of the message this one replies or reacts to.
+	Message struct {
+		ID             string           `json:"id"`
+		ConversationID string           `json:"conversation_id,omitempty"`
+		Sender         *Sender          `json:"sender,omitempty"`
+		Kind           Kind             `json:"kind"`
+		Text           string           `json:"text,omitempty"`
+		Attachments    []*Attachment    `json:"attachments,omitempty"`
+		Buttons        []*ButtonPress   `json:"buttons,omitempty"`
+		Location       *models.Location `json:"location,omitempty"`
+		Reaction       string           `json:"reaction,omitempty"`
+		ReplyTo        string           `json:"reply_to,omitempty"`
+		Timestamp      time.Time        `json:"timestamp"`
+	}
+)
+
+// FromNotification converts all the messages contained in a notification.
+func FromNotification(notification *webhooks.Notification) []*Message {
+	if notification == nil {
+		return nil
+	}
+
+	var messages []*Message
+	for _, entry := range notification.Entry {
+		if entry == nil {
+			continue
+		}
+		for _, change := range entry.Changes {
+			if change == nil || change.Value == nil {
+				continue
+			}
+			nctx := &webhooks.NotificationContext{
+				ID:       entry.ID,
+				Contacts: change.Value.Contacts,
+				Metadata: change.Value.Metadata,
+			}
+			for _, message := range change.Value.Messages {
+				if m, err := FromWebhook(nctx, message); err == nil {
+					messages = append(messages, m)
+				}
+			}
+		}
+	}
+
+	return messages
+}
+
+// FromWebhook converts a webhook message to a chat Message. The NotificationContext is optional,
+// when present it is used to fill in the sender name and the conversation ID.
+//
+//nolint:cyclop
+func FromWebhook(nctx *webhooks.NotificationContext, message *webhooks.Message) (*Message, error) {
+	if message == nil {
+		return nil, ErrNilMessage
+	}
+
+	m := &Message{
+		ID:     message.ID,
+		Sender: &Sender{ID: message.From},
+		Kind:   Kind(webhooks.ParseMessageType(message.Type)),
+	}
+
+	if seconds, err := strconv.ParseInt(message.Timestamp, 10, 64); err == nil {
+		m.Timestamp = time.Unix(seconds, 0).UTC()
+	}
+
+	if message.Context != nil {
+		m.ReplyTo = message.Context.ID
+	}
+
+	if nctx != nil {
+		if nctx.Metadata != nil {
+			m.ConversationID = nctx.Metadata.PhoneNumberID
+		}
+		for _, contact := range nctx.Contacts {
+			if contact != nil && contact.WaID == message.From && contact.Profile != nil {
+				m.Sender.Name = contact.Profile.Name
+			}
+		}
+	}
+
+	switch m.Kind {
+	case KindText:
+		if message.Text != nil {
+			m.Text = message.Text.Body
+		}
+	case KindImage, KindAudio, KindVideo, KindDocument, KindSticker:
+		if media := mediaOf(message, m.Kind); media != nil {
+			m.Text = media.Caption
+			m.Attachments = []*Attachment{attachment(m.Kind, media)}
+		}
+	case KindLocation:
+		m.Location = message.Location
+	case KindReaction:
+		if message.Reaction != nil {
+			m.Reaction = message.Reaction.Emoji
+			m.ReplyTo = message.Reaction.MessageID
+		}
+	case KindButton:
+		if message.Button != nil {
+			m.Text = message.Button.Text
+			m.Buttons = []*ButtonPress{{Title: message.Button.Text, Payload: message.Button.Payload}}
+		}
+	case KindInteractive:
+		m.Buttons = interactiveButtons(message.Interactive)
+		if len(m.Buttons) > 0 {
+			m.Text = m.Buttons[0].Title
+		}
+	case KindOrder:
+		if message.Order != nil {
+			m.Text = message.Order.Text
+		}
+	case KindSystem:
+		if message.System != nil {
+			m.Text = message.System.Body
+		}
+	case KindContacts:
+	default:
+		m.Kind = KindUnknown
+	}
+
+	return m, nil
+}
+
+func mediaOf(message *webhooks.Message, kind Kind) *models.MediaInfo {
+	switch kind {
+	case KindImage:
+		return message.Image
+	case KindAudio:
+		return message.Audio
+	case KindVideo:
+		return message.Video
+	case KindDocument:
+		return message.Document
+	case KindSticker:
+		return message.Sticker
+	default:
+		return nil
+	}
+}
+
+func attachment(kind Kind, media *models.MediaInfo) *Attachment {
+	return &Attachment{
+		Kind:     kind,
+		MediaID:  media.ID,
+		MimeType: media.MimeType,
+		SHA256:   media.Sha256,
+		Filename: media.Filename,
+		Caption:  media.Caption,
+		Animated: media.Animated,
+	}
+}
+
+func interactiveButtons(interactive *webhooks.Interactive) []*ButtonPress {
+	if interactive == nil || interactive.Type == nil {
+		return nil
+	}
+
+	if reply := interactive.Type.ButtonReply; reply != nil {
+		return []*ButtonPress{{ID: reply.ID, Title: reply.Title}}
+	}
+
+	if reply := interactive.Type.ListReply; reply != nil {
+		return []*ButtonPress{{ID: reply.ID, Title: reply.Title}}
+	}
+
+	return nil
+}
+
+// ToSendRequest converts the message to a Cloud API message addressed to recipient. Text, media
+// attachments that have a media ID, locations and reactions can be converted. Other kinds like
+// button presses and orders only exist as incoming messages and return ErrNotConvertible.
+// If ReplyTo is set on a non reaction message, the message is sent as a reply.
+func (m *Message) ToSendRequest(recipient string) (*models.Message, error) {
+	if m == nil {
+		return nil, ErrNilMessage
+	}
+
+	if recipient == "" {
+		return nil, ErrMissingRecipient
+	}
+
+	message := models.NewMessage(recipient)
+	message.Type = string(m.Kind)
+
+	switch m.Kind {
+	case KindText:
+		message.Text = &models.Text{Body: m.Text}
+	case KindImage, KindAudio, KindVideo, KindDocument, KindSticker:
+		if len(m.Attachments) == 0 || m.Attachments[0].MediaID == "" {
+			return nil, fmt.Errorf("%v: %s message without media id", ErrNotConvertible, m.Kind)
+		}
+		a := m.Attachments[0]
+		media := &models.Media{ID: a.MediaID, Filename: a.Filename}
+		if m.Kind != KindAudio && m.Kind != KindSticker {
+			media.Caption = m.Text
+		}
+		setMedia(message, m.Kind, media)
+	case KindLocation:
+		if m.Location == nil {
+			return nil, fmt.Errorf("%v: location message without location", ErrNotConvertible)
+		}
+		message.Location = m.Location
+	case KindReaction:
+		message.Reaction = &models.Reaction{MessageID: m.ReplyTo, Emoji: m.Reaction}
+
+		return message, nil
+	default:
+		return nil, fmt.Errorf("%v: kind %q", ErrNotConvertible, m.Kind)
+	}
+
+	if m.ReplyTo != "" {
+		message.Context = &models.Context{MessageID: m.ReplyTo}
+	}
+
+	return message, nil
+}
+
+func setMedia(message *models.Message, kind Kind, media *models.Media) {
+	switch kind {
+	case KindImage:
+		message.Image = media
+	case KindAudio:
+		message.Audio = media
+	case KindVideo:
+		message.Video = media
+	case KindDocument:
+		message.Document = media
+	case KindSticker:
+		message.Sticker = media
+	}
+}
@@ -0,0 +1,72 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package chat
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/lowkruc/go-whatsapp-api/webhooks"
+)
+
+const imageNotification = `{"object":"whatsapp_business_account","entry":[{"id":"144509515401993","changes":[{"value":{"messaging_product":"whatsapp","metadata":{"display_phone_number":"15550416043","phone_number_id":"121720824363144"},"contacts":[{"profile":{"name":"Ahmad Saekoni"},"wa_id":"6281272128270"}],"messages":[{"from":"6281272128270","id":"wamid.IMAGE","timestamp":"1706462045","type":"image","image":{"caption":"look","mime_type":"image\/jpeg","sha256":"HASH","id":"923699576041120"}}]},"field":"messages"}]}]}` //nolint:lll
+
+func TestFromNotification(t *testing.T) {
+	t.Parallel()
+	var notification webhooks.Notification
+	if err := json.Unmarshal([]byte(imageNotification), &notification); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+
+	messages := FromNotification(&notification)
+	if len(messages) != 1 {
+		t.Fatalf("FromNotification() got %d messages, want 1", len(messages))
+	}
+
+	m := messages[0]
+	if m.Kind != KindImage || m.Text != "look" || m.ConversationID != "121720824363144" {
+		t.Errorf("FromNotification() got %+v", m)
+	}
+
+	if m.Sender == nil || m.Sender.Name != "Ahmad Saekoni" || m.Sender.ID != "6281272128270" {
+		t.Errorf("FromNotification() got sender %+v", m.Sender)
+	}
+
+	if len(m.Attachments) != 1 || m.Attachments[0].MediaID != "923699576041120" {
+		t.Fatalf("FromNotification() got attachments %+v", m.Attachments)
+	}
+
+	req, err := m.ToSendRequest("255700000000")
+	if err != nil {
+		t.Fatalf("ToSendRequest() error = %v", err)
+	}
+
+	if req.Type != "image" || req.Image == nil || req.Image.ID != "923699576041120" || req.Image.Caption != "look" {
+		t.Errorf("ToSendRequest() got %+v", req)
+	}
+}
+
+func TestMessage_ToSendRequestNotConvertible(t *testing.T) {
+	t.Parallel()
+	m := &Message{Kind: KindButton, Buttons: []*ButtonPress{{Title: "Yes"}}}
+	if _, err := m.ToSendRequest("255700000000"); err == nil {
+		t.Errorf("ToSendRequest() expected error for button message")
+	}
+}
@@ -0,0 +1,170 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+// Package clock abstracts the time source of the time dependent components, like the sla
+// Tracker and the poller, so that tests can move time forward deterministically with a Fake
+// instead of sleeping.
+//
+// Example:
+//
+//	fake := clock.NewFake(time.Date(2023, 1, 1, 9, 0, 0, 0, time.UTC))
+//	tracker := sla.NewTracker(5*time.Minute, escalate, sla.WithClock(fake))
+//	tracker.Received("phone_id", "255700000001", "wamid.1")
+//	fake.Advance(5 * time.Minute) // escalate is called before Advance returns
+package clock
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+type (
+	// Clock returns the current time and runs functions after a duration.
+	Clock interface {
+		Now() time.Time
+		AfterFunc(d time.Duration, f func()) Timer
+	}
+
+	// Timer is a function scheduled with Clock.AfterFunc. Stop reports whether it stopped the
+	// timer, false means the function already ran or the timer was already stopped.
+	Timer interface {
+		Stop() bool
+	}
+
+	// Real is the Clock backed by the time package.
+	Real struct{}
+
+	// Fake is a Clock whose time only moves with Advance and Set. The functions of the timers that
+	// are due run synchronously, in order of their due time, before Advance or Set returns.
+	Fake struct {
+		mu     sync.Mutex
+		now    time.Time
+		seq    int
+		timers []*fakeTimer
+	}
+
+	fakeTimer struct {
+		fake *Fake
+		at   time.Time
+		seq  int
+		f    func()
+	}
+)
+
+func (Real) Now() time.Time { return time.Now() }
+
+func (Real) AfterFunc(d time.Duration, f func()) Timer { return time.AfterFunc(d, f) }
+
+// NewFake returns a Fake clock set to now.
+func NewFake(now time.Time) *Fake {
+	return &Fake{now: now}
+}
+
+func (f *Fake) Now() time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.now
+}
+
+func (f *Fake) AfterFunc(d time.Duration, fn func()) Timer {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.seq++
+	timer := &fakeTimer{fake: f, at: f.now.Add(d), seq: f.seq, f: fn}
+	f.timers = append(f.timers, timer)
+
+	return timer
+}
+
+// Advance moves the clock forward by d and runs the timers that are due.
+func (f *Fake) Advance(d time.Duration) {
+	f.Set(f.Now().Add(d))
+}
+
+// Set moves the clock to t and runs the timers that are due. Timers scheduled by the functions
+// that run also fire when they are due before t. Setting a time before the current time only
+// changes what Now returns.
+func (f *Fake) Set(t time.Time) {
+	for {
+		f.mu.Lock()
+		timer := f.nextDue(t)
+		if timer == nil {
+			f.now = t
+			f.mu.Unlock()
+
+			return
+		}
+		if timer.at.After(f.now) {
+			f.now = timer.at
+		}
+		f.mu.Unlock()
+
+		timer.f()
+	}
+}
+
+// Pending returns the number of timers that have not fired or been stopped.
+func (f *Fake) Pending() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return len(f.timers)
+}
+
+// nextDue removes and returns the earliest timer due at or before t, the caller holds the lock.
+func (f *Fake) nextDue(t time.Time) *fakeTimer {
+	if len(f.timers) == 0 {
+		return nil
+	}
+
+	sort.SliceStable(f.timers, func(i, j int) bool {
+		if f.timers[i].at.Equal(f.timers[j].at) {
+			return f.timers[i].seq < f.timers[j].seq
+		}
+
+		return f.timers[i].at.Before(f.timers[j].at)
+	})
+
+	if f.timers[0].at.After(t) {
+		return nil
+	}
+
+	timer := f.timers[0]
+	f.timers = f.timers[1:]
+
+	return timer
+}
+
+func (t *fakeTimer) Stop() bool {
+	t.fake.mu.Lock()
+	defer t.fake.mu.Unlock()
+
+	for i, timer := range t.fake.timers {
+		if timer == t {
+			t.fake.timers = append(t.fake.timers[:i], t.fake.timers[i+1:]...)
+
+			return true
+		}
+	}
+
+	return false
+}
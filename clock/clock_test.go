@@ -0,0 +1,119 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package clock_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/lowkruc/go-whatsapp-api/clock"
+	"github.com/lowkruc/go-whatsapp-api/poller"
+	"github.com/lowkruc/go-whatsapp-api/sla"
+)
+
+func TestFake(t *testing.T) {
+	t.Parallel()
+	start := time.Date(2023, 1, 1, 9, 0, 0, 0, time.UTC)
+	fake := clock.NewFake(start)
+
+	var fired []string
+	fake.AfterFunc(2*time.Minute, func() { fired = append(fired, "b") })
+	fake.AfterFunc(time.Minute, func() {
+		fired = append(fired, "a")
+		// timers scheduled while advancing fire when they are due.
+		fake.AfterFunc(30*time.Second, func() { fired = append(fired, "a2") })
+	})
+	stopped := fake.AfterFunc(time.Minute, func() { fired = append(fired, "stopped") })
+	if !stopped.Stop() {
+		t.Error("Stop() = false, want true")
+	}
+
+	fake.Advance(90 * time.Second)
+	if len(fired) != 2 || fired[0] != "a" || fired[1] != "a2" {
+		t.Errorf("fired %v after 90s, want [a a2]", fired)
+	}
+
+	fake.Advance(time.Hour)
+	if len(fired) != 3 || fired[2] != "b" || fake.Pending() != 0 {
+		t.Errorf("fired %v with %d pending, want [a a2 b] and none pending", fired, fake.Pending())
+	}
+
+	if got := fake.Now(); !got.Equal(start.Add(time.Hour + 90*time.Second)) {
+		t.Errorf("Now() = %v", got)
+	}
+}
+
+func TestFake_SLATracker(t *testing.T) {
+	t.Parallel()
+	fake := clock.NewFake(time.Date(2023, 1, 1, 9, 0, 0, 0, time.UTC))
+
+	var (
+		escalated []string
+		elapsed   time.Duration
+	)
+	tracker := sla.NewTracker(5*time.Minute, func(ctx context.Context, p *sla.Pending) {
+		escalated = append(escalated, p.MessageID)
+	}, sla.WithClock(fake), sla.WithResponseFunc(func(ctx context.Context, p *sla.Pending, d time.Duration) {
+		elapsed = d
+	}))
+	defer tracker.Stop()
+
+	tracker.Received("phone_id", "255700000001", "wamid.1")
+	tracker.Received("phone_id", "255700000002", "wamid.2")
+
+	fake.Advance(3 * time.Minute)
+	tracker.Replied(context.TODO(), "phone_id", "255700000001")
+	if elapsed != 3*time.Minute {
+		t.Errorf("response time = %v, want 3m", elapsed)
+	}
+
+	fake.Advance(time.Minute + 59*time.Second)
+	if len(escalated) != 0 {
+		t.Fatalf("escalated %v before the SLA", escalated)
+	}
+
+	fake.Advance(time.Second)
+	if len(escalated) != 1 || escalated[0] != "wamid.2" {
+		t.Errorf("escalated %v, want [wamid.2]", escalated)
+	}
+}
+
+func TestFake_Poller(t *testing.T) {
+	t.Parallel()
+	start := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	fake := clock.NewFake(start.Add(30 * time.Minute))
+
+	var windows int
+	p := poller.New("analytics", poller.NewMemoryCursorStore(), func(ctx context.Context, from, to time.Time) error {
+		windows++
+
+		return nil
+	}, poller.WithStart(start), poller.WithClock(fake.Now))
+
+	if n, err := p.Poll(context.TODO()); err != nil || n != 0 {
+		t.Fatalf("Poll() = %d, %v, want no complete window", n, err)
+	}
+
+	fake.Advance(3 * time.Hour)
+	if n, err := p.Poll(context.TODO()); err != nil || n != 3 || windows != 3 {
+		t.Errorf("Poll() = %d, %v, want 3 windows", n, err)
+	}
+}
@@ -0,0 +1,111 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+// Command doctor checks a WhatsApp integration end to end: the scopes of the access token, the
+// sending of a test template, and the arrival of its status webhooks at a local listener. The
+// webhooks of the app must be delivered to the listen address, through a tunnel for example.
+//
+//	doctor -to 16505551234 -addr :8080 -path /webhooks -timeout 2m
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"time"
+
+	whatsapp "github.com/lowkruc/go-whatsapp-api"
+	"github.com/lowkruc/go-whatsapp-api/doctor"
+	"github.com/lowkruc/go-whatsapp-api/webhooks"
+)
+
+func main() {
+	var (
+		token    = flag.String("token", os.Getenv("WHATSAPP_ACCESS_TOKEN"), "access token")
+		phoneID  = flag.String("phone-number-id", os.Getenv("WHATSAPP_PHONE_NUMBER_ID"), "phone number ID")
+		version  = flag.String("version", "v16.0", "Graph API version")
+		to       = flag.String("to", os.Getenv("WHATSAPP_TEST_RECIPIENT"), "test recipient phone number")
+		template = flag.String("template", "hello_world", "name of the test template")
+		language = flag.String("language", "en_US", "language of the test template")
+		addr     = flag.String("addr", ":8080", "address of the webhooks listener")
+		path     = flag.String("path", "/webhooks", "path of the webhooks listener")
+		secret   = flag.String("secret", os.Getenv("WHATSAPP_APP_SECRET"), "app secret to verify the webhooks")
+		timeout  = flag.Duration("timeout", 2*time.Minute, "time to wait for the status webhooks")
+	)
+	flag.Parse()
+
+	if *token == "" || *phoneID == "" || *to == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	client := whatsapp.NewClient(whatsapp.WithAccessToken(*token), whatsapp.WithPhoneNumberID(*phoneID),
+		whatsapp.WithVersion(*version))
+	d := doctor.New(client, &doctor.Config{
+		Recipient: *to,
+		Template:  &whatsapp.Template{Name: *template, LanguageCode: *language},
+		Timeout:   *timeout,
+	})
+
+	ok, err := run(d, *addr, *path, *secret)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "doctor:", err)
+		os.Exit(1)
+	}
+	if !ok {
+		os.Exit(1)
+	}
+}
+
+func run(d *doctor.Doctor, addr, path, secret string) (bool, error) {
+	var options []webhooks.ListenerOption
+	if secret != "" {
+		options = append(options, webhooks.WithSecrets(secret))
+	}
+	listener := webhooks.NewEventListener(options...)
+	listener.OnMessageStatusChange(d.OnMessageStatusChangeHook())
+
+	mux := http.NewServeMux()
+	mux.Handle(path, listener.NotificationHandler())
+	server := &http.Server{Addr: addr, Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+	serveErr := make(chan error, 1)
+	go func() { serveErr <- server.ListenAndServe() }()
+	defer func() { _ = server.Shutdown(context.Background()) }()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	// give the listener a moment to fail, like when the address is in use.
+	select {
+	case err := <-serveErr:
+		if !errors.Is(err, http.ErrServerClosed) {
+			return false, fmt.Errorf("listen on %s: %v", addr, err)
+		}
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	report := d.Run(ctx)
+	fmt.Print(report)
+
+	return report.OK(), nil
+}
@@ -0,0 +1,90 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+// Command replay replays a corpus of recorded webhook requests against a running receiver and
+// prints latency and error statistics.
+//
+//	replay -corpus traffic.jsonl -url http://localhost:8080/webhooks -rate 200 -concurrency 16 -n 10000
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/lowkruc/go-whatsapp-api/replay"
+)
+
+func main() {
+	var (
+		corpus      = flag.String("corpus", "", "path of the JSON lines corpus")
+		url         = flag.String("url", "", "URL of the notification handler")
+		rate        = flag.Float64("rate", 0, "requests per second, 0 for no limit")
+		concurrency = flag.Int("concurrency", 1, "requests in flight")
+		requests    = flag.Int("n", 0, "number of requests, defaults to the corpus size")
+		secret      = flag.String("secret", os.Getenv("WHATSAPP_APP_SECRET"), "app secret used to sign the bodies")
+		timeout     = flag.Duration("timeout", 10*time.Second, "timeout of every request")
+	)
+	flag.Parse()
+
+	if *corpus == "" || *url == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	if err := run(*corpus, *url, *secret, *rate, *concurrency, *requests, *timeout); err != nil {
+		fmt.Fprintln(os.Stderr, "replay:", err)
+		os.Exit(1)
+	}
+}
+
+func run(corpus, url, secret string, rate float64, concurrency, requests int, timeout time.Duration) error {
+	file, err := os.Open(corpus)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	records, err := replay.LoadCorpus(file)
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	client := &http.Client{Timeout: timeout}
+	report, err := replay.Run(ctx, replay.URLTarget(client, url), records, &replay.Config{
+		Rate:        rate,
+		Concurrency: concurrency,
+		Requests:    requests,
+		Secret:      secret,
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Println(report)
+
+	return nil
+}
@@ -0,0 +1,72 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+// Command schema prints the JSON Schema or the TypeScript types of the webhook notifications.
+//
+//	schema -format jsonschema -o notification.schema.json
+//	schema -format typescript -o notification.ts
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/lowkruc/go-whatsapp-api/schema"
+)
+
+func main() {
+	var (
+		format = flag.String("format", "jsonschema", "output format, jsonschema or typescript")
+		output = flag.String("o", "", "path of the output file, defaults to the standard output")
+	)
+	flag.Parse()
+
+	if err := run(*format, *output); err != nil {
+		fmt.Fprintln(os.Stderr, "schema:", err)
+		os.Exit(1)
+	}
+}
+
+func run(format, output string) error {
+	generator := schema.Webhooks()
+
+	var content []byte
+	switch format {
+	case "jsonschema":
+		doc, err := json.MarshalIndent(generator.Document(), "", "  ")
+		if err != nil {
+			return err
+		}
+		content = append(doc, '\n')
+	case "typescript":
+		content = []byte(generator.TypeScript())
+	default:
+		return fmt.Errorf("unknown format %q", format)
+	}
+
+	if output == "" {
+		_, err := os.Stdout.Write(content)
+
+		return err
+	}
+
+	return os.WriteFile(output, content, 0o600)
+}
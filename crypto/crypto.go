@@ -0,0 +1,128 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+// Package crypto routes the cryptographic operations of this module through a Provider, so
+// that builds that must use FIPS validated or HSM backed implementations can replace them. The
+// default provider uses the standard library.
+//
+// The package is imported as wcrypto to avoid clashing with the standard library:
+//
+//	import wcrypto "github.com/lowkruc/go-whatsapp-api/crypto"
+//
+//	func init() {
+//		wcrypto.SetDefault(myFIPSProvider{})
+//	}
+package crypto
+
+import (
+	"crypto"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"sync"
+)
+
+type (
+	// Provider implements the primitives used for webhook signatures and flows encryption.
+	//
+	// HMACSHA256 returns the HMAC-SHA256 of message with key.
+	// DecryptRSAOAEP decrypts ciphertext with RSA-OAEP using SHA-256 for the hash and MGF1. The
+	// key can be an *rsa.PrivateKey or any crypto.Decrypter, like a key kept in a KMS or an HSM.
+	// OpenAESGCM and SealAESGCM decrypt and encrypt with AES-GCM, the 16 bytes tag is appended
+	// to the ciphertext.
+	Provider interface {
+		HMACSHA256(key, message []byte) []byte
+		DecryptRSAOAEP(key crypto.Decrypter, ciphertext []byte) ([]byte, error)
+		OpenAESGCM(key, nonce, ciphertext []byte) ([]byte, error)
+		SealAESGCM(key, nonce, plaintext []byte) ([]byte, error)
+	}
+
+	// StdProvider is the Provider implemented with the standard library.
+	StdProvider struct{}
+)
+
+var (
+	mu              sync.RWMutex
+	defaultProvider Provider = StdProvider{}
+)
+
+// Default returns the provider used by the module.
+func Default() Provider {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	return defaultProvider
+}
+
+// SetDefault replaces the provider used by the module, a nil provider restores StdProvider.
+// It is meant to be called once during initialization.
+func SetDefault(provider Provider) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if provider == nil {
+		provider = StdProvider{}
+	}
+	defaultProvider = provider
+}
+
+func (StdProvider) HMACSHA256(key, message []byte) []byte {
+	mac := hmac.New(sha256.New, key)
+	_, _ = mac.Write(message)
+
+	return mac.Sum(nil)
+}
+
+func (StdProvider) DecryptRSAOAEP(key crypto.Decrypter, ciphertext []byte) ([]byte, error) {
+	if private, ok := key.(*rsa.PrivateKey); ok {
+		return rsa.DecryptOAEP(sha256.New(), rand.Reader, private, ciphertext, nil)
+	}
+
+	return key.Decrypt(rand.Reader, ciphertext, &rsa.OAEPOptions{Hash: crypto.SHA256})
+}
+
+func (StdProvider) OpenAESGCM(key, nonce, ciphertext []byte) ([]byte, error) {
+	gcm, err := newGCM(key, len(nonce))
+	if err != nil {
+		return nil, err
+	}
+
+	return gcm.Open(nil, nonce, ciphertext, nil)
+}
+
+func (StdProvider) SealAESGCM(key, nonce, plaintext []byte) ([]byte, error) {
+	gcm, err := newGCM(key, len(nonce))
+	if err != nil {
+		return nil, err
+	}
+
+	return gcm.Seal(nil, nonce, plaintext, nil), nil
+}
+
+func newGCM(key []byte, nonceSize int) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCMWithNonceSize(block, nonceSize)
+}
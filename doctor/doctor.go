@@ -0,0 +1,283 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+// Package doctor checks a WhatsApp integration end to end: the access token has the scopes it
+// needs, a template can be sent to a test number, and the status webhooks of that message reach
+// the listener. It reports every check with the reason it failed.
+//
+// Example:
+//
+//	d := doctor.New(client, &doctor.Config{Recipient: "16505551234"})
+//	listener.OnMessageStatusChange(d.OnMessageStatusChangeHook())
+//	report := d.Run(ctx)
+//	fmt.Print(report)
+package doctor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	whatsapp "github.com/lowkruc/go-whatsapp-api"
+	"github.com/lowkruc/go-whatsapp-api/webhooks"
+)
+
+// Names of the checks, in the order they run.
+const (
+	CheckToken    = "token"
+	CheckSend     = "send"
+	CheckWebhooks = "webhooks"
+)
+
+var (
+	ErrNoRecipient   = errors.New("no test recipient")
+	ErrInvalidToken  = errors.New("access token is not valid")
+	ErrMissingScopes = errors.New("access token is missing scopes")
+	ErrNoStatus      = errors.New("status webhooks did not arrive")
+	ErrSkipped       = errors.New("skipped")
+)
+
+// DefaultScopes are the scopes the token needs to send messages and manage the account.
+var DefaultScopes = []string{"whatsapp_business_messaging", "whatsapp_business_management"}
+
+type (
+	// Client is the part of the *whatsapp.Client the checks use.
+	Client interface {
+		DebugToken(ctx context.Context) (*whatsapp.TokenInfo, error)
+		SendTemplate(ctx context.Context, recipient string, req *whatsapp.Template) (*whatsapp.ResponseMessage, error)
+	}
+
+	// Config configures the checks. Recipient is required, the other fields have defaults: the
+	// hello_world template in en_US, the DefaultScopes, the sent and delivered statuses and a
+	// timeout of 2 minutes for the statuses to arrive.
+	Config struct {
+		Recipient string
+		Template  *whatsapp.Template
+		Scopes    []string
+		Statuses  []webhooks.MessageStatus
+		Timeout   time.Duration
+	}
+
+	// Check is the result of a check, Err is nil when it passed.
+	Check struct {
+		Name     string
+		Detail   string
+		Err      error
+		Duration time.Duration
+	}
+
+	// Report is the result of Run.
+	Report struct {
+		Checks []*Check
+	}
+
+	// Doctor runs the checks. Its OnMessageStatusChangeHook must be set on the listener that
+	// receives the webhooks of the phone number.
+	Doctor struct {
+		client   Client
+		config   Config
+		mu       sync.Mutex
+		statuses map[string]map[webhooks.MessageStatus]*webhooks.Status
+		changed  chan struct{}
+	}
+)
+
+// New creates a Doctor, config can be nil.
+func New(client Client, config *Config) *Doctor {
+	d := &Doctor{
+		client:   client,
+		statuses: make(map[string]map[webhooks.MessageStatus]*webhooks.Status),
+		changed:  make(chan struct{}),
+	}
+	if config != nil {
+		d.config = *config
+	}
+	if d.config.Template == nil {
+		d.config.Template = &whatsapp.Template{Name: "hello_world", LanguageCode: "en_US"}
+	}
+	if d.config.Scopes == nil {
+		d.config.Scopes = DefaultScopes
+	}
+	if len(d.config.Statuses) == 0 {
+		d.config.Statuses = []webhooks.MessageStatus{webhooks.MessageStatusSent, webhooks.MessageStatusDelivered}
+	}
+	if d.config.Timeout <= 0 {
+		d.config.Timeout = 2 * time.Minute
+	}
+
+	return d
+}
+
+// OnMessageStatusChangeHook returns the hook that records the statuses of the messages.
+func (d *Doctor) OnMessageStatusChangeHook() webhooks.OnMessageStatusChangeHook {
+	return func(ctx context.Context, nctx *webhooks.NotificationContext, status *webhooks.Status) error {
+		if status == nil {
+			return nil
+		}
+
+		d.mu.Lock()
+		defer d.mu.Unlock()
+		if d.statuses[status.ID] == nil {
+			d.statuses[status.ID] = make(map[webhooks.MessageStatus]*webhooks.Status)
+		}
+		d.statuses[status.ID][webhooks.MessageStatus(strings.ToLower(status.StatusValue))] = status
+		close(d.changed)
+		d.changed = make(chan struct{})
+
+		return nil
+	}
+}
+
+// Run runs the checks in order. The checks that depend on a failed one are reported as skipped.
+func (d *Doctor) Run(ctx context.Context) *Report {
+	report := &Report{}
+	run := func(name string, check func() (string, error)) error {
+		start := time.Now()
+		detail, err := check()
+		report.Checks = append(report.Checks, &Check{Name: name, Detail: detail, Err: err, Duration: time.Since(start)})
+
+		return err
+	}
+
+	_ = run(CheckToken, func() (string, error) { return d.checkToken(ctx) })
+
+	var messageID string
+	err := run(CheckSend, func() (string, error) {
+		var err error
+		messageID, err = d.send(ctx)
+
+		return messageID, err
+	})
+
+	_ = run(CheckWebhooks, func() (string, error) {
+		if err != nil {
+			return "", fmt.Errorf("%v: no message was sent", ErrSkipped)
+		}
+
+		return d.waitStatuses(ctx, messageID)
+	})
+
+	return report
+}
+
+func (d *Doctor) checkToken(ctx context.Context) (string, error) {
+	info, err := d.client.DebugToken(ctx)
+	if err != nil {
+		return "", err
+	}
+	if !info.IsValid {
+		return "", ErrInvalidToken
+	}
+
+	detail := "scopes " + strings.Join(info.Scopes, ",")
+	if expiration := info.Expiration(); !expiration.IsZero() {
+		detail += ", expires " + expiration.UTC().Format(time.RFC3339)
+	}
+	if missing := info.MissingScopes(d.config.Scopes...); len(missing) > 0 {
+		return detail, fmt.Errorf("%v: %s", ErrMissingScopes, strings.Join(missing, ", "))
+	}
+
+	return detail, nil
+}
+
+func (d *Doctor) send(ctx context.Context) (string, error) {
+	if d.config.Recipient == "" {
+		return "", ErrNoRecipient
+	}
+	response, err := d.client.SendTemplate(ctx, d.config.Recipient, d.config.Template)
+	if err != nil {
+		return "", err
+	}
+	if response == nil || len(response.Messages) == 0 || response.Messages[0].ID == "" {
+		return "", fmt.Errorf("send template %s: no message ID in the response", d.config.Template.Name)
+	}
+
+	return response.Messages[0].ID, nil
+}
+
+// waitStatuses waits for the configured statuses of the message, it fails early on a failed status.
+func (d *Doctor) waitStatuses(ctx context.Context, messageID string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, d.config.Timeout)
+	defer cancel()
+
+	for {
+		d.mu.Lock()
+		received := d.statuses[messageID]
+		changed := d.changed
+		var got, missing []string
+		for _, want := range d.config.Statuses {
+			if received[want] != nil {
+				got = append(got, string(want))
+			} else {
+				missing = append(missing, string(want))
+			}
+		}
+		failed := received[webhooks.MessageStatusFailed]
+		d.mu.Unlock()
+
+		detail := messageID + " " + strings.Join(got, ",")
+		switch {
+		case failed != nil:
+			return detail, fmt.Errorf("message failed: %v", failed.Err())
+		case len(missing) == 0:
+			return detail, nil
+		}
+
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			return detail, fmt.Errorf("%v: %s after %s", ErrNoStatus, strings.Join(missing, ","), d.config.Timeout)
+		}
+	}
+}
+
+// OK reports whether all the checks passed.
+func (report *Report) OK() bool {
+	for _, check := range report.Checks {
+		if check.Err != nil {
+			return false
+		}
+	}
+
+	return true
+}
+
+// String formats the report as one line per check.
+func (report *Report) String() string {
+	var b strings.Builder
+	for _, check := range report.Checks {
+		status := "ok"
+		if check.Err != nil {
+			status = "FAIL"
+		}
+		fmt.Fprintf(&b, "%-4s  %-8s  %8s", status, check.Name, check.Duration.Round(time.Millisecond))
+		if check.Detail != "" {
+			b.WriteString("  " + check.Detail)
+		}
+		if check.Err != nil {
+			b.WriteString("  error: " + check.Err.Error())
+		}
+		b.WriteString("\n")
+	}
+
+	return b.String()
+}
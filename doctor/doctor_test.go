@@ -0,0 +1,115 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package doctor
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	whatsapp "github.com/lowkruc/go-whatsapp-api"
+	werrors "github.com/lowkruc/go-whatsapp-api/errors"
+	"github.com/lowkruc/go-whatsapp-api/webhooks"
+)
+
+type fakeClient struct {
+	info    *whatsapp.TokenInfo
+	sendErr error
+	onSend  func(id string)
+}
+
+func (c *fakeClient) DebugToken(ctx context.Context) (*whatsapp.TokenInfo, error) {
+	return c.info, nil
+}
+
+func (c *fakeClient) SendTemplate(ctx context.Context, recipient string, req *whatsapp.Template) (
+	*whatsapp.ResponseMessage, error,
+) {
+	if c.sendErr != nil {
+		return nil, c.sendErr
+	}
+	if c.onSend != nil {
+		go c.onSend("wamid.1")
+	}
+
+	return &whatsapp.ResponseMessage{Messages: []*whatsapp.MessageID{{ID: "wamid.1"}}}, nil
+}
+
+func TestDoctor_Run(t *testing.T) {
+	t.Parallel()
+	client := &fakeClient{info: &whatsapp.TokenInfo{IsValid: true, Scopes: DefaultScopes}}
+	d := New(client, &Config{Recipient: "16505551234", Timeout: time.Second})
+	hook := d.OnMessageStatusChangeHook()
+	client.onSend = func(id string) {
+		for _, status := range []string{"sent", "delivered"} {
+			_ = hook(context.TODO(), nil, &webhooks.Status{ID: id, StatusValue: status})
+		}
+	}
+
+	report := d.Run(context.TODO())
+	if !report.OK() || len(report.Checks) != 3 {
+		t.Fatalf("Run() report:\n%s", report)
+	}
+	if !strings.Contains(report.String(), "ok    webhooks") || report.Checks[2].Detail != "wamid.1 sent,delivered" {
+		t.Errorf("unexpected report:\n%s", report)
+	}
+}
+
+func TestDoctor_RunFailures(t *testing.T) {
+	t.Parallel()
+	t.Run("missing scopes and send error", func(t *testing.T) {
+		t.Parallel()
+		client := &fakeClient{
+			info:    &whatsapp.TokenInfo{IsValid: true, Scopes: []string{"whatsapp_business_messaging"}},
+			sendErr: errors.New("template does not exist"),
+		}
+		report := New(client, &Config{Recipient: "16505551234"}).Run(context.TODO())
+		if report.OK() || !strings.Contains(report.Checks[0].Err.Error(), "whatsapp_business_management") ||
+			report.Checks[1].Err == nil || !strings.Contains(report.Checks[2].Err.Error(), ErrSkipped.Error()) {
+			t.Errorf("unexpected report:\n%s", report)
+		}
+	})
+
+	t.Run("no status", func(t *testing.T) {
+		t.Parallel()
+		client := &fakeClient{info: &whatsapp.TokenInfo{IsValid: true, Scopes: DefaultScopes}}
+		report := New(client, &Config{Recipient: "16505551234", Timeout: 20 * time.Millisecond}).Run(context.TODO())
+		if err := report.Checks[2].Err; err == nil || !strings.Contains(err.Error(), "sent,delivered") {
+			t.Errorf("unexpected report:\n%s", report)
+		}
+	})
+
+	t.Run("failed status", func(t *testing.T) {
+		t.Parallel()
+		client := &fakeClient{info: &whatsapp.TokenInfo{IsValid: true, Scopes: DefaultScopes}}
+		d := New(client, &Config{Recipient: "16505551234", Timeout: time.Second})
+		hook := d.OnMessageStatusChangeHook()
+		client.onSend = func(id string) {
+			_ = hook(context.TODO(), nil, &webhooks.Status{ID: id, StatusValue: "failed",
+				Errors: []*werrors.Error{{Code: 131026, Title: "Message undeliverable"}}})
+		}
+		report := d.Run(context.TODO())
+		if err := report.Checks[2].Err; err == nil || !strings.Contains(err.Error(), "131026") {
+			t.Errorf("unexpected report:\n%s", report)
+		}
+	})
+}
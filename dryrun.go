@@ -0,0 +1,101 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package whatsapp
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync/atomic"
+)
+
+// dryRunTransport answers the sends with a made up response while the dry run is on, the other
+// requests go through.
+type dryRunTransport struct {
+	next    http.RoundTripper
+	enabled *atomic.Bool
+	count   atomic.Int64
+}
+
+// SetDryRun turns the dry run on or off. During a dry run the messages are built, moderated and
+// checked as usual but not sent: the requests to the messages endpoint are answered with a
+// response whose message ID starts with "dryrun.". The other requests, like media uploads, are
+// still made.
+func (client *Client) SetDryRun(enabled bool) {
+	client.dryRun.Store(enabled)
+}
+
+// DryRun reports whether the dry run is on, see SetDryRun.
+func (client *Client) DryRun() bool {
+	return client.dryRun.Load()
+}
+
+// withDryRun returns a copy of the http client whose transport honors enabled.
+func withDryRun(client *http.Client, enabled *atomic.Bool) *http.Client {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	next := client.Transport
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	dryRun := *client
+	dryRun.Transport = &dryRunTransport{next: next, enabled: enabled}
+
+	return &dryRun
+}
+
+func (t *dryRunTransport) RoundTrip(request *http.Request) (*http.Response, error) {
+	if !t.enabled.Load() || request.Method != http.MethodPost || !strings.HasSuffix(request.URL.Path, "/messages") {
+		return t.next.RoundTrip(request)
+	}
+
+	var message struct {
+		To string `json:"to"`
+	}
+	if request.Body != nil {
+		_ = json.NewDecoder(request.Body).Decode(&message)
+		_ = request.Body.Close()
+	}
+
+	body, err := json.Marshal(&ResponseMessage{
+		Product:  messagingProduct,
+		Contacts: []*ResponseContact{{Input: message.To, WhatsappID: message.To}},
+		Messages: []*MessageID{{ID: fmt.Sprintf("dryrun.%d", t.count.Add(1))}},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &http.Response{
+		Status:        "200 OK",
+		StatusCode:    http.StatusOK,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        http.Header{"Content-Type": []string{"application/json"}},
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       request,
+	}, nil
+}
@@ -0,0 +1,61 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package whatsapp
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestClient_SetDryRun(t *testing.T) {
+	t.Parallel()
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		_, _ = w.Write([]byte(`{"messaging_product":"whatsapp","messages":[{"id":"wamid.1"}]}`))
+	}))
+	defer server.Close()
+
+	client := NewClient(WithBaseURL(server.URL), WithVersion("v16.0"), WithPhoneNumberID("1000"))
+	ctx := context.TODO()
+
+	client.SetDryRun(true)
+	if !client.DryRun() {
+		t.Fatal("DryRun() = false after SetDryRun(true)")
+	}
+	response, err := client.SendTextMessage(ctx, "16505551234", &TextMessage{Message: "hello"})
+	if err != nil {
+		t.Fatalf("SendTextMessage() error = %v", err)
+	}
+	if requests != 0 || len(response.Messages) != 1 || response.Messages[0].ID != "dryrun.1" ||
+		len(response.Contacts) != 1 || response.Contacts[0].WhatsappID != "16505551234" {
+		t.Fatalf("got %d requests and response %+v during the dry run", requests, response)
+	}
+
+	client.SetDryRun(false)
+	if response, err = client.SendTextMessage(ctx, "16505551234", &TextMessage{Message: "hello"}); err != nil {
+		t.Fatalf("SendTextMessage() error = %v", err)
+	}
+	if requests != 1 || response.Messages[0].ID != "wamid.1" {
+		t.Errorf("got %d requests and message %s after the dry run", requests, response.Messages[0].ID)
+	}
+}
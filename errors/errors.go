@@ -41,6 +41,8 @@ type (
 	// of the API request.
 	// FBTraceID: Internal support identifier. When reporting a bug related to a Graph API call, include
 	// the fbtrace_id to help us find log data for debugging.
+	// Title and Href are only set in the errors of webhooks, like the errors of a failed message
+	// status. Href links to the documentation of the error code.
 	// Example of error response
 	//
 	//	"error": {
@@ -66,6 +68,8 @@ type (
 		UserTitle string     `json:"error_user_title,omitempty"`
 		UserMsg   string     `json:"error_user_msg,omitempty"`
 		FBTraceID string     `json:"fbtrace_id,omitempty"`
+		Title     string     `json:"title,omitempty"`
+		Href      string     `json:"href,omitempty"`
 	}
 
 	// ErrorData represents additional information about the error.
@@ -126,6 +130,9 @@ func (e *Error) String() string {
 	if e.FBTraceID != "" {
 		b.WriteString(", FBTraceID: " + e.FBTraceID)
 	}
+	if e.Title != "" {
+		b.WriteString(", Title: " + e.Title)
+	}
 
 	return b.String()
 }
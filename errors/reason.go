@@ -0,0 +1,107 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package errors
+
+// Reason groups the error codes of failed messages by what can be done about them.
+type Reason string
+
+const (
+	// ReasonReengagement means the customer service window is closed, send a template instead.
+	ReasonReengagement Reason = "reengagement_required"
+
+	// ReasonUndeliverable means the message could not be delivered to the recipient, for example
+	// because the number is not on WhatsApp.
+	ReasonUndeliverable Reason = "undeliverable"
+
+	// ReasonMarketingOptOut means the recipient stopped marketing messages.
+	ReasonMarketingOptOut Reason = "marketing_opt_out"
+
+	// ReasonMarketingLimit means the recipient got too many marketing messages from businesses,
+	// try again later.
+	ReasonMarketingLimit Reason = "marketing_limit"
+
+	// ReasonRateLimited means the business sent too many messages, try again later.
+	ReasonRateLimited Reason = "rate_limited"
+
+	// ReasonTemplate means the template or its parameters are not valid.
+	ReasonTemplate Reason = "template"
+
+	// ReasonInvalidRequest means the message or its media is not valid.
+	ReasonInvalidRequest Reason = "invalid_request"
+
+	// ReasonAccount means a problem with the access token, the account or its payment method.
+	ReasonAccount Reason = "account"
+
+	// ReasonTemporary means an error on the side of Meta, try again later.
+	ReasonTemporary Reason = "temporary"
+
+	// ReasonUnknown is any other error.
+	ReasonUnknown Reason = "unknown"
+)
+
+// reasons maps the error codes documented by the Cloud API to their reason.
+var reasons = map[int]Reason{
+	190:    ReasonAccount,
+	131031: ReasonAccount,
+	131042: ReasonAccount,
+	131047: ReasonReengagement,
+	131026: ReasonUndeliverable,
+	131021: ReasonUndeliverable,
+	131050: ReasonMarketingOptOut,
+	131049: ReasonMarketingLimit,
+	4:      ReasonRateLimited,
+	80007:  ReasonRateLimited,
+	130429: ReasonRateLimited,
+	131048: ReasonRateLimited,
+	131056: ReasonRateLimited,
+	100:    ReasonInvalidRequest,
+	131008: ReasonInvalidRequest,
+	131009: ReasonInvalidRequest,
+	131051: ReasonInvalidRequest,
+	131052: ReasonInvalidRequest,
+	131053: ReasonInvalidRequest,
+	1:      ReasonTemporary,
+	2:      ReasonTemporary,
+	131000: ReasonTemporary,
+	131016: ReasonTemporary,
+}
+
+// Classify returns the reason of the error, ReasonTemplate for the 132xxx template errors and
+// ReasonUnknown for codes it does not know.
+func Classify(e *Error) Reason {
+	if e == nil {
+		return ReasonUnknown
+	}
+
+	if reason, ok := reasons[e.Code]; ok {
+		return reason
+	}
+
+	if e.Code >= 132000 && e.Code < 133000 {
+		return ReasonTemplate
+	}
+
+	return ReasonUnknown
+}
+
+// Retryable reports whether sending the same message again later may succeed.
+func (r Reason) Retryable() bool {
+	return r == ReasonRateLimited || r == ReasonMarketingLimit || r == ReasonTemporary
+}
@@ -0,0 +1,53 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package errors
+
+import "testing"
+
+func TestClassify(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		code      int
+		want      Reason
+		retryable bool
+	}{
+		{code: 131047, want: ReasonReengagement},
+		{code: 131026, want: ReasonUndeliverable},
+		{code: 131050, want: ReasonMarketingOptOut},
+		{code: 131049, want: ReasonMarketingLimit, retryable: true},
+		{code: 130429, want: ReasonRateLimited, retryable: true},
+		{code: 132001, want: ReasonTemplate},
+		{code: 131000, want: ReasonTemporary, retryable: true},
+		{code: 0, want: ReasonUnknown},
+		{code: 999999, want: ReasonUnknown},
+	}
+
+	for _, tt := range tests {
+		got := Classify(&Error{Code: tt.code})
+		if got != tt.want || got.Retryable() != tt.retryable {
+			t.Errorf("Classify(%d) = %q (retryable %v), want %q (retryable %v)",
+				tt.code, got, got.Retryable(), tt.want, tt.retryable)
+		}
+	}
+
+	if got := Classify(nil); got != ReasonUnknown {
+		t.Errorf("Classify(nil) = %q, want %q", got, ReasonUnknown)
+	}
+}
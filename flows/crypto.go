@@ -0,0 +1,125 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+// Package flows implements the encryption used by the WhatsApp Flows data exchange endpoint.
+//
+// Every request to the endpoint carries an AES key encrypted with the public key of the business,
+// the flow data encrypted with that AES key in AES-GCM mode and the initialization vector. The
+// response is encrypted with the same key and with the initialization vector inverted.
+//
+// All the operations go through the crypto.Provider returned by crypto.Default.
+package flows
+
+import (
+	"crypto"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	wcrypto "github.com/lowkruc/go-whatsapp-api/crypto"
+)
+
+var (
+	ErrDecryptRequest  = errors.New("could not decrypt flows request")
+	ErrEncryptResponse = errors.New("could not encrypt flows response")
+)
+
+type (
+	// EncryptedRequest is the body of a request sent to the data exchange endpoint.
+	EncryptedRequest struct {
+		EncryptedFlowData string `json:"encrypted_flow_data"`
+		EncryptedAESKey   string `json:"encrypted_aes_key"`
+		InitialVector     string `json:"initial_vector"`
+	}
+
+	// DecryptedRequest is a decrypted data exchange request. Payload is the decrypted JSON, the
+	// key and the initialization vector are kept to encrypt the response.
+	DecryptedRequest struct {
+		Payload json.RawMessage
+		key     []byte
+		iv      []byte
+	}
+)
+
+// DecryptRequest decrypts the request with the private key of the business. The key can be an
+// *rsa.PrivateKey or a crypto.Decrypter backed by a KMS or an HSM that supports RSA-OAEP with SHA-256.
+func DecryptRequest(key crypto.Decrypter, request *EncryptedRequest) (*DecryptedRequest, error) {
+	provider := wcrypto.Default()
+	encryptedKey, err := base64.StdEncoding.DecodeString(request.EncryptedAESKey)
+	if err != nil {
+		return nil, fmt.Errorf("%v: aes key: %v", ErrDecryptRequest, err)
+	}
+
+	aesKey, err := provider.DecryptRSAOAEP(key, encryptedKey)
+	if err != nil {
+		return nil, fmt.Errorf("%v: aes key: %v", ErrDecryptRequest, err)
+	}
+
+	return decryptFlowData(aesKey, request)
+}
+
+func decryptFlowData(aesKey []byte, request *EncryptedRequest) (*DecryptedRequest, error) {
+	iv, err := base64.StdEncoding.DecodeString(request.InitialVector)
+	if err != nil {
+		return nil, fmt.Errorf("%v: initial vector: %v", ErrDecryptRequest, err)
+	}
+
+	data, err := base64.StdEncoding.DecodeString(request.EncryptedFlowData)
+	if err != nil {
+		return nil, fmt.Errorf("%v: flow data: %v", ErrDecryptRequest, err)
+	}
+
+	payload, err := wcrypto.Default().OpenAESGCM(aesKey, iv, data)
+	if err != nil {
+		return nil, fmt.Errorf("%v: flow data: %v", ErrDecryptRequest, err)
+	}
+
+	return &DecryptedRequest{
+		Payload: payload,
+		key:     aesKey,
+		iv:      iv,
+	}, nil
+}
+
+// Decode decodes the decrypted payload into v.
+func (request *DecryptedRequest) Decode(v any) error {
+	return json.Unmarshal(request.Payload, v)
+}
+
+// EncryptResponse encodes v to JSON and encrypts it. The result is the base64 string that must
+// be written as the body of the response.
+func (request *DecryptedRequest) EncryptResponse(v any) (string, error) {
+	plaintext, err := json.Marshal(v)
+	if err != nil {
+		return "", fmt.Errorf("%v: %v", ErrEncryptResponse, err)
+	}
+
+	flipped := make([]byte, len(request.iv))
+	for i, b := range request.iv {
+		flipped[i] = ^b
+	}
+
+	ciphertext, err := wcrypto.Default().SealAESGCM(request.key, flipped, plaintext)
+	if err != nil {
+		return "", fmt.Errorf("%v: %v", ErrEncryptResponse, err)
+	}
+
+	return base64.StdEncoding.EncodeToString(ciphertext), nil
+}
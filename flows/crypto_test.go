@@ -0,0 +1,90 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package flows
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"testing"
+)
+
+// encryptRequest encrypts payload as the WhatsApp client does.
+func encryptRequest(t *testing.T, public *rsa.PublicKey, payload []byte) (*EncryptedRequest, []byte, []byte) {
+	t.Helper()
+	aesKey := make([]byte, 16)
+	iv := make([]byte, 16)
+	_, _ = rand.Read(aesKey)
+	_, _ = rand.Read(iv)
+
+	encryptedKey, err := rsa.EncryptOAEP(sha256.New(), rand.Reader, public, aesKey, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	block, _ := aes.NewCipher(aesKey)
+	gcm, _ := cipher.NewGCMWithNonceSize(block, len(iv))
+
+	return &EncryptedRequest{
+		EncryptedFlowData: base64.StdEncoding.EncodeToString(gcm.Seal(nil, iv, payload, nil)),
+		EncryptedAESKey:   base64.StdEncoding.EncodeToString(encryptedKey),
+		InitialVector:     base64.StdEncoding.EncodeToString(iv),
+	}, aesKey, iv
+}
+
+func TestDecryptRequest(t *testing.T) {
+	t.Parallel()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	encrypted, aesKey, iv := encryptRequest(t, &key.PublicKey, []byte(`{"version":"3.0","action":"ping"}`))
+	request, err := DecryptRequest(key, encrypted)
+	if err != nil {
+		t.Fatalf("DecryptRequest() error = %v", err)
+	}
+
+	var body struct {
+		Action string `json:"action"`
+	}
+	if err := request.Decode(&body); err != nil || body.Action != "ping" {
+		t.Fatalf("Decode() = %+v, %v", body, err)
+	}
+
+	response, err := request.EncryptResponse(map[string]any{"data": map[string]string{"status": "active"}})
+	if err != nil {
+		t.Fatalf("EncryptResponse() error = %v", err)
+	}
+
+	ciphertext, _ := base64.StdEncoding.DecodeString(response)
+	for i := range iv {
+		iv[i] ^= 0xff
+	}
+	block, _ := aes.NewCipher(aesKey)
+	gcm, _ := cipher.NewGCMWithNonceSize(block, len(iv))
+	plaintext, err := gcm.Open(nil, iv, ciphertext, nil)
+	if err != nil || string(plaintext) != `{"data":{"status":"active"}}` {
+		t.Errorf("response decrypted to %s, %v", plaintext, err)
+	}
+}
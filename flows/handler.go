@@ -0,0 +1,116 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package flows
+
+import (
+	"context"
+	"crypto"
+	"encoding/json"
+	"net/http"
+)
+
+// Actions sent in data exchange requests.
+const (
+	ActionPing         = "ping"
+	ActionInit         = "INIT"
+	ActionDataExchange = "data_exchange"
+	ActionBack         = "BACK"
+)
+
+// StatusKeyRefreshRequired is returned when a request can not be decrypted, it tells the client
+// to download the public key of the business again.
+const StatusKeyRefreshRequired = 421
+
+type (
+	// DataExchangeRequest is a decrypted data exchange request.
+	DataExchangeRequest struct {
+		Version   string         `json:"version"`
+		Action    string         `json:"action"`
+		Screen    string         `json:"screen,omitempty"`
+		Data      map[string]any `json:"data,omitempty"`
+		FlowToken string         `json:"flow_token,omitempty"`
+	}
+
+	// DataExchangeResponse is the response to a data exchange request. Screen is the next screen
+	// and Data its data.
+	DataExchangeResponse struct {
+		Version string         `json:"version,omitempty"`
+		Screen  string         `json:"screen,omitempty"`
+		Data    map[string]any `json:"data"`
+	}
+
+	// DataExchangeFunc handles a data exchange request. Health check pings are answered by the
+	// handler and are not passed to it.
+	DataExchangeFunc func(ctx context.Context, request *DataExchangeRequest) (*DataExchangeResponse, error)
+)
+
+// Handler returns the http.Handler of the data exchange endpoint. The private key can live in a
+// KMS or an HSM, only its crypto.Decrypter is needed. Requests that can not be decrypted get a
+// 421 response, errors returned by fn a 500 response.
+func Handler(key crypto.Decrypter, fn DataExchangeFunc) http.Handler {
+	return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		var encrypted EncryptedRequest
+		if err := json.NewDecoder(request.Body).Decode(&encrypted); err != nil {
+			writer.WriteHeader(http.StatusBadRequest)
+
+			return
+		}
+
+		decrypted, err := DecryptRequest(key, &encrypted)
+		if err != nil {
+			writer.WriteHeader(StatusKeyRefreshRequired)
+
+			return
+		}
+
+		var exchange DataExchangeRequest
+		if err := decrypted.Decode(&exchange); err != nil {
+			writer.WriteHeader(http.StatusBadRequest)
+
+			return
+		}
+
+		var response *DataExchangeResponse
+		if exchange.Action == ActionPing {
+			response = &DataExchangeResponse{Data: map[string]any{"status": "active"}}
+		} else if response, err = fn(request.Context(), &exchange); err != nil {
+			writer.WriteHeader(http.StatusInternalServerError)
+
+			return
+		}
+
+		if response == nil {
+			writer.WriteHeader(http.StatusInternalServerError)
+
+			return
+		}
+
+		body, err := decrypted.EncryptResponse(response)
+		if err != nil {
+			writer.WriteHeader(http.StatusInternalServerError)
+
+			return
+		}
+
+		writer.Header().Set("Content-Type", "text/plain")
+		writer.WriteHeader(http.StatusOK)
+		_, _ = writer.Write([]byte(body))
+	})
+}
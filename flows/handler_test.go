@@ -0,0 +1,99 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package flows
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// kmsKey hides the *rsa.PrivateKey behind the crypto.Decrypter interface, like a KMS client does.
+type kmsKey struct {
+	key *rsa.PrivateKey
+}
+
+func (k kmsKey) Public() crypto.PublicKey { return &k.key.PublicKey }
+
+func (k kmsKey) Decrypt(rnd io.Reader, msg []byte, opts crypto.DecrypterOpts) ([]byte, error) {
+	return k.key.Decrypt(rnd, msg, opts)
+}
+
+func TestHandler(t *testing.T) {
+	t.Parallel()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	handler := Handler(kmsKey{key: key}, func(ctx context.Context, r *DataExchangeRequest) (*DataExchangeResponse, error) {
+		return &DataExchangeResponse{Screen: "SUCCESS", Data: map[string]any{"token": r.FlowToken}}, nil
+	})
+
+	for _, tt := range []struct {
+		payload string
+		want    string
+	}{
+		{payload: `{"version":"3.0","action":"ping"}`, want: `{"data":{"status":"active"}}`},
+		{
+			payload: `{"version":"3.0","action":"data_exchange","screen":"START","flow_token":"tk"}`,
+			want:    `{"screen":"SUCCESS","data":{"token":"tk"}}`,
+		},
+	} {
+		encrypted, aesKey, iv := encryptRequest(t, &key.PublicKey, []byte(tt.payload))
+		body, _ := json.Marshal(encrypted)
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/flows", bytes.NewReader(body)))
+		if recorder.Code != http.StatusOK {
+			t.Fatalf("status = %d, want 200", recorder.Code)
+		}
+
+		ciphertext, _ := base64.StdEncoding.DecodeString(recorder.Body.String())
+		for i := range iv {
+			iv[i] ^= 0xff
+		}
+		block, _ := aes.NewCipher(aesKey)
+		gcm, _ := cipher.NewGCMWithNonceSize(block, len(iv))
+		plaintext, err := gcm.Open(nil, iv, ciphertext, nil)
+		if err != nil || string(plaintext) != tt.want {
+			t.Errorf("response = %s, %v, want %s", plaintext, err, tt.want)
+		}
+	}
+
+	// a request encrypted for another key must make the client refresh the public key.
+	other, _ := rsa.GenerateKey(rand.Reader, 2048)
+	encrypted, _, _ := encryptRequest(t, &other.PublicKey, []byte(`{"action":"ping"}`))
+	body, _ := json.Marshal(encrypted)
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/flows", bytes.NewReader(body)))
+	if recorder.Code != StatusKeyRefreshRequired {
+		t.Errorf("status = %d, want %d", recorder.Code, StatusKeyRefreshRequired)
+	}
+}
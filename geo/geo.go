@@ -0,0 +1,153 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+// Package geo checks the locations sent by customers against geofences, circles and polygons,
+// for bots that need to know if a location is within a delivery area.
+//
+// Example:
+//
+//	fences := []*geo.Geofence{
+//		{Name: "city centre", Area: &geo.Circle{Center: geo.Point{Latitude: -6.8161, Longitude: 39.2803}, Radius: 3000}},
+//	}
+//	listener.OnLocationMessage(geo.OnLocationMessageHook(fences, func(ctx context.Context,
+//		nctx *webhooks.NotificationContext, mctx *webhooks.MessageContext, location *models.Location,
+//		fence *geo.Geofence) error {
+//		// the location is in fence
+//		return nil
+//	}))
+package geo
+
+import (
+	"context"
+	"math"
+
+	"github.com/lowkruc/go-whatsapp-api/models"
+	"github.com/lowkruc/go-whatsapp-api/webhooks"
+)
+
+// earthRadius is the mean radius of the earth in meters.
+const earthRadius = 6371008.8
+
+type (
+	// Point is a position in decimal degrees.
+	Point struct {
+		Latitude  float64
+		Longitude float64
+	}
+
+	// Area is a region of the earth.
+	Area interface {
+		Contains(point Point) bool
+	}
+
+	// Circle is the area within Radius meters of Center.
+	Circle struct {
+		Center Point
+		Radius float64
+	}
+
+	// Polygon is the area enclosed by Points, in order. The polygon is closed implicitly and its
+	// edges are treated as straight lines in degrees, which is accurate for city sized areas that
+	// do not cross the antimeridian.
+	Polygon struct {
+		Points []Point
+	}
+
+	// Geofence is a named area.
+	Geofence struct {
+		Name string
+		Area Area
+	}
+
+	// OnLocationInAreaHook is called for every geofence that contains a received location.
+	OnLocationInAreaHook func(ctx context.Context, nctx *webhooks.NotificationContext,
+		mctx *webhooks.MessageContext, location *models.Location, fence *Geofence) error
+)
+
+// PointOf returns the point of a location.
+func PointOf(location *models.Location) Point {
+	return Point{Latitude: location.Latitude, Longitude: location.Longitude}
+}
+
+// Distance returns the great circle distance between a and b in meters.
+func Distance(a, b Point) float64 {
+	lat1, lat2 := radians(a.Latitude), radians(b.Latitude)
+	dLat := lat2 - lat1
+	dLon := radians(b.Longitude - a.Longitude)
+
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) + //nolint:gomnd
+		math.Cos(lat1)*math.Cos(lat2)*math.Sin(dLon/2)*math.Sin(dLon/2) //nolint:gomnd
+
+	return 2 * earthRadius * math.Asin(math.Min(1, math.Sqrt(h))) //nolint:gomnd
+}
+
+func radians(degrees float64) float64 {
+	return degrees * math.Pi / 180 //nolint:gomnd
+}
+
+func (c *Circle) Contains(point Point) bool {
+	return Distance(c.Center, point) <= c.Radius
+}
+
+// Contains uses the ray casting algorithm, points on an edge may be reported either way.
+func (p *Polygon) Contains(point Point) bool {
+	inside := false
+	n := len(p.Points)
+	for i, j := 0, n-1; i < n; j, i = i, i+1 {
+		a, b := p.Points[i], p.Points[j]
+		if (a.Latitude > point.Latitude) != (b.Latitude > point.Latitude) &&
+			point.Longitude < (b.Longitude-a.Longitude)*(point.Latitude-a.Latitude)/(b.Latitude-a.Latitude)+a.Longitude {
+			inside = !inside
+		}
+	}
+
+	return inside
+}
+
+// Match returns the geofences that contain point, in order.
+func Match(fences []*Geofence, point Point) []*Geofence {
+	var matched []*Geofence
+	for _, fence := range fences {
+		if fence != nil && fence.Area != nil && fence.Area.Contains(point) {
+			matched = append(matched, fence)
+		}
+	}
+
+	return matched
+}
+
+// OnLocationMessageHook returns a webhooks.OnLocationMessageHook that calls inArea for every
+// geofence that contains the received location. Locations outside all the geofences are ignored.
+func OnLocationMessageHook(fences []*Geofence, inArea OnLocationInAreaHook) webhooks.OnLocationMessageHook {
+	return func(ctx context.Context, nctx *webhooks.NotificationContext, mctx *webhooks.MessageContext,
+		location *models.Location,
+	) error {
+		if location == nil {
+			return nil
+		}
+
+		for _, fence := range Match(fences, PointOf(location)) {
+			if err := inArea(ctx, nctx, mctx, location, fence); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+}
@@ -0,0 +1,95 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package geo
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	"github.com/lowkruc/go-whatsapp-api/models"
+	"github.com/lowkruc/go-whatsapp-api/webhooks"
+)
+
+func TestDistance(t *testing.T) {
+	t.Parallel()
+	// one degree of latitude is about 111.2km.
+	d := Distance(Point{Latitude: 0, Longitude: 0}, Point{Latitude: 1, Longitude: 0})
+	if math.Abs(d-111195) > 100 {
+		t.Errorf("Distance() = %v, want about 111195", d)
+	}
+}
+
+func TestMatch(t *testing.T) {
+	t.Parallel()
+	centre := &Geofence{Name: "centre", Area: &Circle{Center: Point{Latitude: -6.8161, Longitude: 39.2803}, Radius: 3000}}
+	square := &Geofence{Name: "square", Area: &Polygon{Points: []Point{
+		{Latitude: -6.9, Longitude: 39.2}, {Latitude: -6.9, Longitude: 39.3},
+		{Latitude: -6.8, Longitude: 39.3}, {Latitude: -6.8, Longitude: 39.2},
+	}}}
+	fences := []*Geofence{centre, square}
+
+	tests := []struct {
+		name  string
+		point Point
+		want  []string
+	}{
+		{"in both", Point{Latitude: -6.82, Longitude: 39.28}, []string{"centre", "square"}},
+		{"in the square only", Point{Latitude: -6.89, Longitude: 39.21}, []string{"square"}},
+		{"outside", Point{Latitude: -6.5, Longitude: 39.0}, nil},
+	}
+
+	for _, tt := range tests {
+		got := Match(fences, tt.point)
+		if len(got) != len(tt.want) {
+			t.Errorf("%s: Match() = %d fences, want %v", tt.name, len(got), tt.want)
+
+			continue
+		}
+		for i := range got {
+			if got[i].Name != tt.want[i] {
+				t.Errorf("%s: Match()[%d] = %s, want %s", tt.name, i, got[i].Name, tt.want[i])
+			}
+		}
+	}
+}
+
+func TestOnLocationMessageHook(t *testing.T) {
+	t.Parallel()
+	fences := []*Geofence{
+		{Name: "centre", Area: &Circle{Center: Point{Latitude: -6.8161, Longitude: 39.2803}, Radius: 3000}},
+	}
+
+	var matched []string
+	hook := OnLocationMessageHook(fences, func(ctx context.Context, nctx *webhooks.NotificationContext,
+		mctx *webhooks.MessageContext, location *models.Location, fence *Geofence,
+	) error {
+		matched = append(matched, fence.Name)
+
+		return nil
+	})
+
+	_ = hook(context.TODO(), nil, nil, &models.Location{Latitude: -6.82, Longitude: 39.28})
+	_ = hook(context.TODO(), nil, nil, &models.Location{Latitude: -6.5, Longitude: 39.0})
+
+	if len(matched) != 1 || matched[0] != "centre" {
+		t.Errorf("matched %v, want [centre]", matched)
+	}
+}
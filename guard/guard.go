@@ -0,0 +1,187 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+// Package guard protects the module and the observability backends fed by it from absurdly large
+// values. Limits bounds the size of the string fields of inbound notifications and outbound
+// messages, Labels bounds the number of distinct values and the length of metric labels and log
+// fields.
+package guard
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+	"unicode/utf8"
+)
+
+var ErrFieldTooLarge = errors.New("field too large")
+
+// OtherLabel is the value Labels returns for the values over its limit.
+const OtherLabel = "other"
+
+type (
+	// Limits bounds the length in bytes of every string field of a value. A MaxFieldBytes of zero
+	// or less disables the check. When Truncate is set, the fields over the limit are cut to the
+	// limit on a rune boundary, otherwise Apply returns ErrFieldTooLarge.
+	Limits struct {
+		MaxFieldBytes int
+		Truncate      bool
+	}
+
+	// Labels bounds the cardinality of a metric label or log field. The first MaxValues distinct
+	// values are kept, later ones are replaced by OtherLabel. Values are truncated to MaxLength
+	// bytes before they are counted. Labels is safe for concurrent use.
+	Labels struct {
+		MaxValues int
+		MaxLength int
+
+		mu   sync.Mutex
+		seen map[string]struct{}
+	}
+)
+
+// Truncate returns s cut to at most max bytes without splitting a rune. A max of zero or less
+// returns s unchanged.
+func Truncate(s string, max int) string {
+	if max <= 0 || len(s) <= max {
+		return s
+	}
+
+	// move the cut back while it falls inside a rune.
+	cut := max
+	for cut > 0 && !utf8.RuneStart(s[cut]) {
+		cut--
+	}
+
+	return s[:cut]
+}
+
+// Apply checks every string reachable from v, a pointer to a struct, through struct fields,
+// pointers, slices and maps. It returns the number of fields over the limit, and when Truncate is
+// not set an ErrFieldTooLarge error with the path of the first one.
+func (l *Limits) Apply(v any) (int, error) {
+	if l == nil || l.MaxFieldBytes <= 0 || v == nil {
+		return 0, nil
+	}
+
+	w := &walker{limits: l}
+	w.walk(reflect.ValueOf(v), "")
+	if w.first != "" && !l.Truncate {
+		return w.count, fmt.Errorf("%v: %s is longer than %d bytes", ErrFieldTooLarge, w.first, l.MaxFieldBytes)
+	}
+
+	return w.count, nil
+}
+
+type walker struct {
+	limits *Limits
+	count  int
+	first  string
+}
+
+//nolint:exhaustive
+func (w *walker) walk(v reflect.Value, path string) {
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if !v.IsNil() {
+			w.walk(v.Elem(), path)
+		}
+	case reflect.Struct:
+		t := v.Type()
+		for i := 0; i < v.NumField(); i++ {
+			if t.Field(i).IsExported() {
+				w.walk(v.Field(i), join(path, t.Field(i).Name))
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			return
+		}
+		for i := 0; i < v.Len(); i++ {
+			w.walk(v.Index(i), fmt.Sprintf("%s[%d]", path, i))
+		}
+	case reflect.Map:
+		iter := v.MapRange()
+		for iter.Next() {
+			value := iter.Value()
+			if value.Kind() == reflect.String {
+				if len(value.String()) > w.limits.MaxFieldBytes {
+					w.exceeded(fmt.Sprintf("%s[%v]", path, iter.Key()))
+					if w.limits.Truncate {
+						v.SetMapIndex(iter.Key(), reflect.ValueOf(Truncate(value.String(), w.limits.MaxFieldBytes)).
+							Convert(value.Type()))
+					}
+				}
+
+				continue
+			}
+			w.walk(value, fmt.Sprintf("%s[%v]", path, iter.Key()))
+		}
+	case reflect.String:
+		if len(v.String()) <= w.limits.MaxFieldBytes {
+			return
+		}
+		w.exceeded(path)
+		if w.limits.Truncate && v.CanSet() {
+			v.SetString(Truncate(v.String(), w.limits.MaxFieldBytes))
+		}
+	}
+}
+
+func (w *walker) exceeded(path string) {
+	w.count++
+	if w.first == "" {
+		w.first = path
+	}
+}
+
+func join(path, name string) string {
+	if path == "" {
+		return name
+	}
+
+	return path + "." + name
+}
+
+// Bound returns value truncated to MaxLength, or OtherLabel when MaxValues distinct values have
+// already been seen and value is not one of them. A MaxValues of zero or less does not bound the
+// number of values.
+func (l *Labels) Bound(value string) string {
+	value = Truncate(value, l.MaxLength)
+	if l.MaxValues <= 0 {
+		return value
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.seen == nil {
+		l.seen = make(map[string]struct{})
+	}
+	if _, ok := l.seen[value]; ok {
+		return value
+	}
+	if len(l.seen) >= l.MaxValues {
+		return OtherLabel
+	}
+	l.seen[value] = struct{}{}
+
+	return value
+}
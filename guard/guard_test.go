@@ -0,0 +1,145 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package guard_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/lowkruc/go-whatsapp-api/guard"
+	"github.com/lowkruc/go-whatsapp-api/webhooks"
+)
+
+func TestTruncate(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		s    string
+		max  int
+		want string
+	}{
+		{"hello", 0, "hello"},
+		{"hello", 10, "hello"},
+		{"hello", 3, "hel"},
+		{"héllo", 2, "h"},
+		{"héllo", 3, "hé"},
+		{"日本", 4, "日"},
+	}
+
+	for _, tt := range tests {
+		if got := guard.Truncate(tt.s, tt.max); got != tt.want {
+			t.Errorf("Truncate(%q, %d) = %q, want %q", tt.s, tt.max, got, tt.want)
+		}
+	}
+}
+
+type caption struct {
+	Caption string
+}
+
+type media struct {
+	ID       string
+	Captions []*caption
+	Headers  map[string]string
+	Data     []byte
+}
+
+func TestLimits_Apply(t *testing.T) {
+	t.Parallel()
+	value := func() *media {
+		return &media{
+			ID:       "1",
+			Captions: []*caption{{Caption: "short"}, {Caption: strings.Repeat("x", 20)}},
+			Headers:  map[string]string{"k": strings.Repeat("y", 20)},
+			Data:     make([]byte, 100),
+		}
+	}
+
+	reject := &guard.Limits{MaxFieldBytes: 10}
+	n, err := reject.Apply(value())
+	if n != 2 || err == nil || !strings.Contains(err.Error(), guard.ErrFieldTooLarge.Error()) ||
+		!strings.Contains(err.Error(), "Captions[1].Caption") {
+		t.Errorf("Apply() = %d, %v", n, err)
+	}
+
+	truncate := &guard.Limits{MaxFieldBytes: 10, Truncate: true}
+	v := value()
+	if n, err := truncate.Apply(v); n != 2 || err != nil {
+		t.Fatalf("Apply() = %d, %v", n, err)
+	}
+
+	if len(v.Captions[1].Caption) != 10 || len(v.Headers["k"]) != 10 || v.Captions[0].Caption != "short" {
+		t.Errorf("Apply() did not truncate: %+v %+v", v.Captions[1], v.Headers)
+	}
+
+	var disabled *guard.Limits
+	if n, err := disabled.Apply(value()); n != 0 || err != nil {
+		t.Errorf("nil Limits Apply() = %d, %v", n, err)
+	}
+}
+
+func TestLabels_Bound(t *testing.T) {
+	t.Parallel()
+	labels := &guard.Labels{MaxValues: 2, MaxLength: 5}
+
+	got := []string{
+		labels.Bound("text"),
+		labels.Bound("interactive"),
+		labels.Bound("image"),
+		labels.Bound("text"),
+		labels.Bound("interactive_list"),
+	}
+	want := []string{"text", "inter", guard.OtherLabel, "text", "inter"}
+
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("Bound() #%d = %q, want %q", i, got[i], want[i])
+		}
+	}
+}
+
+func TestNotificationHandler_FieldLimits(t *testing.T) {
+	t.Parallel()
+	body := `{"object":"whatsapp_business_account","entry":[{"id":"1","changes":[{"field":"messages",
+		"value":{"messages":[{"from":"1","id":"wamid.1","type":"text","text":{"body":"` +
+		strings.Repeat("a", 64) + `"}}]}}]}]}`
+
+	var text string
+	hooks := &webhooks.Hooks{
+		OnTextMessageHook: func(ctx context.Context, nctx *webhooks.NotificationContext, mctx *webhooks.MessageContext,
+			text2 *webhooks.Text,
+		) error {
+			text = text2.Body
+
+			return nil
+		},
+	}
+
+	handler := webhooks.NotificationHandler(hooks, webhooks.NoOpNotificationErrorHandler, webhooks.NoOpHooksErrorHandler,
+		&webhooks.HandlerOptions{FieldLimits: &guard.Limits{MaxFieldBytes: 16, Truncate: true}})
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body)))
+
+	if rr.Code != http.StatusOK || text != strings.Repeat("a", 16) {
+		t.Errorf("response %d, text %q", rr.Code, text)
+	}
+}
@@ -0,0 +1,693 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+// Package handoff lets a human agent take over a conversation from a bot and hand it back later.
+//
+// A conversation is identified by the business phone number ID and the WhatsApp ID of the customer.
+// While a conversation is taken over, the bot hooks are not called for its messages. Instead, every
+// message is passed to the OnAgentConversationHook so that it can be routed to the agent.
+//
+// Example:
+//
+//	controller := handoff.NewController(handoff.NewMemoryStore(), forwardToAgent)
+//	listener := webhooks.NewEventListener(webhooks.WithHooks(controller.Wrap(botHooks)))
+//	...
+//	_ = controller.TakeOver(ctx, handoff.Key(phoneNumberID, waID), "agent-7", "customer asked for a human")
+//	...
+//	_ = controller.HandBack(ctx, handoff.Key(phoneNumberID, waID))
+//
+// With a Router, conversations are assigned to agents by a Strategy, such as RoundRobin, LeastLoaded
+// or SkillTags:
+//
+//	router := handoff.NewRouter(handoff.SkillTags(handoff.RoundRobin()), agents...)
+//	controller := handoff.NewController(store, forwardToAgent, handoff.WithRouter(router))
+//	...
+//	state, err := controller.Assign(ctx, key, "customer asked about an invoice", "billing")
+package handoff
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	werrors "github.com/lowkruc/go-whatsapp-api/errors"
+	"github.com/lowkruc/go-whatsapp-api/models"
+	"github.com/lowkruc/go-whatsapp-api/webhooks"
+)
+
+var ErrConversationNotFound = errors.New("conversation is not taken over")
+
+type (
+	// State describes a conversation that has been taken over by an agent.
+	State struct {
+		AgentID string    `json:"agent_id"`
+		Reason  string    `json:"reason,omitempty"`
+		Skills  []string  `json:"skills,omitempty"`
+		Since   time.Time `json:"since"`
+	}
+
+	// Store persists the conversations that are taken over. Get returns nil and no error when the
+	// conversation is handled by the bot. Implementations must be safe for concurrent use.
+	Store interface {
+		Get(ctx context.Context, key string) (*State, error)
+		Set(ctx context.Context, key string, state *State) error
+		Delete(ctx context.Context, key string) error
+	}
+
+	// OnAgentConversationHook is called instead of the bot hooks for every message of a conversation
+	// that has been taken over by an agent.
+	OnAgentConversationHook func(ctx context.Context, nctx *webhooks.NotificationContext,
+		message *webhooks.Message, state *State) error
+
+	// OnHandoffHook is called after a conversation has been taken over by an agent.
+	OnHandoffHook func(ctx context.Context, key string, state *State)
+
+	// OnHandBackHook is called after a conversation has been handed back to the bot. State is the
+	// state the conversation had before it was handed back.
+	OnHandBackHook func(ctx context.Context, key string, state *State)
+
+	// OnReassignHook is called after a conversation has been moved from one agent to another.
+	OnReassignHook func(ctx context.Context, key string, from, to *State)
+
+	// Controller takes over and hands back conversations and wraps bot hooks.
+	Controller struct {
+		store      Store
+		onAgent    OnAgentConversationHook
+		onHandoff  OnHandoffHook
+		onHandBack OnHandBackHook
+		onReassign OnReassignHook
+		router     *Router
+		now        func() time.Time
+	}
+
+	ControllerOption func(*Controller)
+
+	// MemoryStore is an in memory Store. It is lost on restart, so it is only suitable for a
+	// single instance or for tests.
+	MemoryStore struct {
+		mu     sync.RWMutex
+		states map[string]*State
+	}
+)
+
+// Key returns the key identifying the conversation between a business phone number and a customer.
+func Key(phoneNumberID, waID string) string {
+	return phoneNumberID + ":" + waID
+}
+
+// KeyFromContext returns the key of the conversation with the customer from using the
+// notification metadata.
+func KeyFromContext(nctx *webhooks.NotificationContext, from string) string {
+	var phoneNumberID string
+	if nctx != nil && nctx.Metadata != nil {
+		phoneNumberID = nctx.Metadata.PhoneNumberID
+	}
+
+	return Key(phoneNumberID, from)
+}
+
+// WithOnHandoff sets the hook called after a conversation is taken over.
+func WithOnHandoff(hook OnHandoffHook) ControllerOption {
+	return func(c *Controller) {
+		c.onHandoff = hook
+	}
+}
+
+// WithOnHandBack sets the hook called after a conversation is handed back to the bot.
+func WithOnHandBack(hook OnHandBackHook) ControllerOption {
+	return func(c *Controller) {
+		c.onHandBack = hook
+	}
+}
+
+// WithOnReassign sets the hook called after a conversation is moved to another agent. Without it,
+// the OnHandoffHook is called for reassignments.
+func WithOnReassign(hook OnReassignHook) ControllerOption {
+	return func(c *Controller) {
+		c.onReassign = hook
+	}
+}
+
+// WithRouter sets the router used by Assign and Reassign. The load of the router agents is updated
+// when conversations are taken over and handed back.
+func WithRouter(router *Router) ControllerOption {
+	return func(c *Controller) {
+		c.router = router
+	}
+}
+
+// NewController creates a Controller. onAgent receives the messages of conversations handled by agents.
+func NewController(store Store, onAgent OnAgentConversationHook, options ...ControllerOption) *Controller {
+	c := &Controller{
+		store:   store,
+		onAgent: onAgent,
+		now:     time.Now,
+	}
+
+	for _, option := range options {
+		option(c)
+	}
+
+	return c
+}
+
+// TakeOver marks the conversation as handled by the agent. Taking over a conversation that is
+// already handled by an agent reassigns it.
+func (c *Controller) TakeOver(ctx context.Context, key, agentID, reason string) error {
+	previous, err := c.store.Get(ctx, key)
+	if err != nil {
+		return fmt.Errorf("handoff: take over %q: %v", key, err)
+	}
+
+	state := &State{
+		AgentID: agentID,
+		Reason:  reason,
+		Since:   c.now(),
+	}
+	if previous != nil {
+		state.Skills = previous.Skills
+	}
+
+	if err := c.set(ctx, key, previous, state, false); err != nil {
+		return fmt.Errorf("handoff: take over %q: %v", key, err)
+	}
+
+	return nil
+}
+
+// Assign routes the conversation to an agent with the skills and takes it over. It returns
+// ErrNoAgentAvailable when no agent can take it.
+func (c *Controller) Assign(ctx context.Context, key, reason string, skills ...string) (*State, error) {
+	if c.router == nil {
+		return nil, fmt.Errorf("handoff: assign %q: %v", key, ErrNoRouter)
+	}
+
+	previous, err := c.store.Get(ctx, key)
+	if err != nil {
+		return nil, fmt.Errorf("handoff: assign %q: %v", key, err)
+	}
+
+	agent, err := c.router.route(ctx, &Request{Key: key, Skills: skills}, "")
+	if err != nil {
+		return nil, fmt.Errorf("handoff: assign %q: %v", key, err)
+	}
+
+	state := &State{
+		AgentID: agent.ID,
+		Reason:  reason,
+		Skills:  skills,
+		Since:   c.now(),
+	}
+	if err := c.set(ctx, key, previous, state, true); err != nil {
+		return nil, fmt.Errorf("handoff: assign %q: %v", key, err)
+	}
+
+	return state, nil
+}
+
+// Reassign moves a conversation handled by an agent to the agent with agentID. When agentID is
+// empty, the router chooses another agent with the skills the conversation was assigned with.
+// It returns ErrConversationNotFound if the conversation is not handled by an agent.
+func (c *Controller) Reassign(ctx context.Context, key, agentID, reason string) (*State, error) {
+	previous, err := c.store.Get(ctx, key)
+	if err != nil {
+		return nil, fmt.Errorf("handoff: reassign %q: %v", key, err)
+	}
+
+	if previous == nil {
+		return nil, fmt.Errorf("handoff: reassign %q: %v", key, ErrConversationNotFound)
+	}
+
+	routed := false
+	if agentID == "" {
+		if c.router == nil {
+			return nil, fmt.Errorf("handoff: reassign %q: %v", key, ErrNoRouter)
+		}
+
+		request := &Request{Key: key, Skills: previous.Skills}
+		agent, err := c.router.route(ctx, request, previous.AgentID)
+		if err != nil {
+			return nil, fmt.Errorf("handoff: reassign %q: %v", key, err)
+		}
+		agentID, routed = agent.ID, true
+	}
+
+	state := &State{
+		AgentID: agentID,
+		Reason:  reason,
+		Skills:  previous.Skills,
+		Since:   c.now(),
+	}
+	if err := c.set(ctx, key, previous, state, routed); err != nil {
+		return nil, fmt.Errorf("handoff: reassign %q: %v", key, err)
+	}
+
+	return state, nil
+}
+
+// set stores the state, updates the router load and calls the hooks. routed reports whether the
+// router already counted the conversation for the new agent.
+func (c *Controller) set(ctx context.Context, key string, previous, state *State, routed bool) error {
+	if err := c.store.Set(ctx, key, state); err != nil {
+		if routed {
+			c.router.move(state.AgentID, "")
+		}
+
+		return err
+	}
+
+	if c.router != nil {
+		var from, to string
+		if previous != nil {
+			from = previous.AgentID
+		}
+		if !routed {
+			to = state.AgentID
+		}
+		c.router.move(from, to)
+	}
+
+	if previous != nil && previous.AgentID != state.AgentID && c.onReassign != nil {
+		c.onReassign(ctx, key, previous, state)
+
+		return nil
+	}
+
+	if c.onHandoff != nil {
+		c.onHandoff(ctx, key, state)
+	}
+
+	return nil
+}
+
+// HandBack returns the conversation to the bot. It returns ErrConversationNotFound if the
+// conversation is not handled by an agent.
+func (c *Controller) HandBack(ctx context.Context, key string) error {
+	state, err := c.store.Get(ctx, key)
+	if err != nil {
+		return fmt.Errorf("handoff: hand back %q: %v", key, err)
+	}
+
+	if state == nil {
+		return fmt.Errorf("handoff: hand back %q: %v", key, ErrConversationNotFound)
+	}
+
+	if err := c.store.Delete(ctx, key); err != nil {
+		return fmt.Errorf("handoff: hand back %q: %v", key, err)
+	}
+
+	if c.router != nil {
+		c.router.move(state.AgentID, "")
+	}
+
+	if c.onHandBack != nil {
+		c.onHandBack(ctx, key, state)
+	}
+
+	return nil
+}
+
+// State returns the state of the conversation, nil means the conversation is handled by the bot.
+func (c *Controller) State(ctx context.Context, key string) (*State, error) {
+	state, err := c.store.Get(ctx, key)
+	if err != nil {
+		return nil, fmt.Errorf("handoff: state %q: %v", key, err)
+	}
+
+	return state, nil
+}
+
+// withAgent reports whether the conversation with the customer is handled by an agent.
+func (c *Controller) withAgent(ctx context.Context, nctx *webhooks.NotificationContext, from string) (bool, error) {
+	state, err := c.State(ctx, KeyFromContext(nctx, from))
+	if err != nil {
+		return false, err
+	}
+
+	return state != nil, nil
+}
+
+// OnMessageReceivedHook wraps next so that messages of conversations handled by an agent are passed
+// to the OnAgentConversationHook instead. next may be nil.
+func (c *Controller) OnMessageReceivedHook(next webhooks.OnMessageReceivedHook) webhooks.OnMessageReceivedHook {
+	return func(ctx context.Context, nctx *webhooks.NotificationContext, message *webhooks.Message) error {
+		state, err := c.State(ctx, KeyFromContext(nctx, message.From))
+		if err != nil {
+			return err
+		}
+
+		if state != nil {
+			if c.onAgent == nil {
+				return nil
+			}
+
+			return c.onAgent(ctx, nctx, message, state)
+		}
+
+		if next == nil {
+			return nil
+		}
+
+		return next(ctx, nctx, message)
+	}
+}
+
+// Wrap returns a copy of hooks where every message hook is skipped for conversations handled by an
+// agent, and where OnMessageReceivedHook routes those messages to the OnAgentConversationHook.
+// Status, error and other non message hooks are kept as they are.
+//
+//nolint:funlen,gocognit,cyclop
+func (c *Controller) Wrap(hooks *webhooks.Hooks) *webhooks.Hooks {
+	if hooks == nil {
+		hooks = &webhooks.Hooks{}
+	}
+
+	wrapped := *hooks
+	wrapped.OnMessageReceivedHook = c.OnMessageReceivedHook(hooks.OnMessageReceivedHook)
+
+	type mctx = webhooks.MessageContext
+	type nctx = webhooks.NotificationContext
+
+	if h := hooks.OnOrderMessageHook; h != nil {
+		wrapped.OnOrderMessageHook = func(ctx context.Context, n *nctx, m *mctx, v *webhooks.Order) error {
+			if agent, err := c.withAgent(ctx, n, m.From); agent || err != nil {
+				return err
+			}
+
+			return h(ctx, n, m, v)
+		}
+	}
+
+	if h := hooks.OnButtonMessageHook; h != nil {
+		wrapped.OnButtonMessageHook = func(ctx context.Context, n *nctx, m *mctx, v *webhooks.Button) error {
+			if agent, err := c.withAgent(ctx, n, m.From); agent || err != nil {
+				return err
+			}
+
+			return h(ctx, n, m, v)
+		}
+	}
+
+	if h := hooks.OnLocationMessageHook; h != nil {
+		wrapped.OnLocationMessageHook = func(ctx context.Context, n *nctx, m *mctx, v *models.Location) error {
+			if agent, err := c.withAgent(ctx, n, m.From); agent || err != nil {
+				return err
+			}
+
+			return h(ctx, n, m, v)
+		}
+	}
+
+	if h := hooks.OnContactsMessageHook; h != nil {
+		wrapped.OnContactsMessageHook = func(ctx context.Context, n *nctx, m *mctx, v *models.Contacts) error {
+			if agent, err := c.withAgent(ctx, n, m.From); agent || err != nil {
+				return err
+			}
+
+			return h(ctx, n, m, v)
+		}
+	}
+
+	if h := hooks.OnMessageReactionHook; h != nil {
+		wrapped.OnMessageReactionHook = func(ctx context.Context, n *nctx, m *mctx, v *models.Reaction) error {
+			if agent, err := c.withAgent(ctx, n, m.From); agent || err != nil {
+				return err
+			}
+
+			return h(ctx, n, m, v)
+		}
+	}
+
+	if h := hooks.OnUnknownMessageHook; h != nil {
+		wrapped.OnUnknownMessageHook = func(ctx context.Context, n *nctx, m *mctx, v []*werrors.Error) error {
+			if agent, err := c.withAgent(ctx, n, m.From); agent || err != nil {
+				return err
+			}
+
+			return h(ctx, n, m, v)
+		}
+	}
+
+	if h := hooks.OnProductEnquiryHook; h != nil {
+		wrapped.OnProductEnquiryHook = func(ctx context.Context, n *nctx, m *mctx, v *webhooks.Text) error {
+			if agent, err := c.withAgent(ctx, n, m.From); agent || err != nil {
+				return err
+			}
+
+			return h(ctx, n, m, v)
+		}
+	}
+
+	if h := hooks.OnInteractiveMessageHook; h != nil {
+		wrapped.OnInteractiveMessageHook = func(ctx context.Context, n *nctx, m *mctx, v *webhooks.Interactive) error {
+			if agent, err := c.withAgent(ctx, n, m.From); agent || err != nil {
+				return err
+			}
+
+			return h(ctx, n, m, v)
+		}
+	}
+
+	if h := hooks.OnMessageErrorsHook; h != nil {
+		wrapped.OnMessageErrorsHook = func(ctx context.Context, n *nctx, m *mctx, v []*werrors.Error) error {
+			if agent, err := c.withAgent(ctx, n, m.From); agent || err != nil {
+				return err
+			}
+
+			return h(ctx, n, m, v)
+		}
+	}
+
+	if h := hooks.OnTextMessageHook; h != nil {
+		wrapped.OnTextMessageHook = func(ctx context.Context, n *nctx, m *mctx, v *webhooks.Text) error {
+			if agent, err := c.withAgent(ctx, n, m.From); agent || err != nil {
+				return err
+			}
+
+			return h(ctx, n, m, v)
+		}
+	}
+
+	if h := hooks.OnReferralMessageHook; h != nil {
+		wrapped.OnReferralMessageHook = func(ctx context.Context, n *nctx, m *mctx, v *webhooks.Text,
+			r *webhooks.Referral,
+		) error {
+			if agent, err := c.withAgent(ctx, n, m.From); agent || err != nil {
+				return err
+			}
+
+			return h(ctx, n, m, v, r)
+		}
+	}
+
+	if h := hooks.OnCustomerIDChangeHook; h != nil {
+		wrapped.OnCustomerIDChangeHook = func(ctx context.Context, n *nctx, m *mctx, v *webhooks.Identity) error {
+			if agent, err := c.withAgent(ctx, n, m.From); agent || err != nil {
+				return err
+			}
+
+			return h(ctx, n, m, v)
+		}
+	}
+
+	if h := hooks.OnSystemMessageHook; h != nil {
+		wrapped.OnSystemMessageHook = func(ctx context.Context, n *nctx, m *mctx, v *webhooks.System) error {
+			if agent, err := c.withAgent(ctx, n, m.From); agent || err != nil {
+				return err
+			}
+
+			return h(ctx, n, m, v)
+		}
+	}
+
+	if h := hooks.OnMediaMessageHook; h != nil {
+		wrapped.OnMediaMessageHook = func(ctx context.Context, n *nctx, m *mctx, v *models.MediaInfo) error {
+			if agent, err := c.withAgent(ctx, n, m.From); agent || err != nil {
+				return err
+			}
+
+			return h(ctx, n, m, v)
+		}
+	}
+
+	if h := hooks.OnButtonReplyHook; h != nil {
+		wrapped.OnButtonReplyHook = func(ctx context.Context, n *nctx, m *mctx, v *webhooks.ButtonReply) error {
+			if agent, err := c.withAgent(ctx, n, m.From); agent || err != nil {
+				return err
+			}
+
+			return h(ctx, n, m, v)
+		}
+	}
+
+	if h := hooks.OnListReplyHook; h != nil {
+		wrapped.OnListReplyHook = func(ctx context.Context, n *nctx, m *mctx, v *webhooks.ListReply) error {
+			if agent, err := c.withAgent(ctx, n, m.From); agent || err != nil {
+				return err
+			}
+
+			return h(ctx, n, m, v)
+		}
+	}
+
+	if h := hooks.OnFlowReplyHook; h != nil {
+		wrapped.OnFlowReplyHook = func(ctx context.Context, n *nctx, m *mctx, v *webhooks.FlowReply) error {
+			if agent, err := c.withAgent(ctx, n, m.From); agent || err != nil {
+				return err
+			}
+
+			return h(ctx, n, m, v)
+		}
+	}
+
+	if h := hooks.OnStickerMessageHook; h != nil {
+		wrapped.OnStickerMessageHook = func(ctx context.Context, n *nctx, m *mctx, v *webhooks.Sticker) error {
+			if agent, err := c.withAgent(ctx, n, m.From); agent || err != nil {
+				return err
+			}
+
+			return h(ctx, n, m, v)
+		}
+	}
+
+	if h := hooks.OnVoiceNoteHook; h != nil {
+		wrapped.OnVoiceNoteHook = func(ctx context.Context, n *nctx, m *mctx, v *models.MediaInfo) error {
+			if agent, err := c.withAgent(ctx, n, m.From); agent || err != nil {
+				return err
+			}
+
+			return h(ctx, n, m, v)
+		}
+	}
+
+	if h := hooks.OnReactionRemovedHook; h != nil {
+		wrapped.OnReactionRemovedHook = func(ctx context.Context, n *nctx, m *mctx, v *models.Reaction) error {
+			if agent, err := c.withAgent(ctx, n, m.From); agent || err != nil {
+				return err
+			}
+
+			return h(ctx, n, m, v)
+		}
+	}
+
+	if h := hooks.OnLiveLocationUpdateHook; h != nil {
+		wrapped.OnLiveLocationUpdateHook = func(ctx context.Context, n *nctx, m *mctx, v *webhooks.LiveLocation) error {
+			if agent, err := c.withAgent(ctx, n, m.From); agent || err != nil {
+				return err
+			}
+
+			return h(ctx, n, m, v)
+		}
+	}
+
+	if h := hooks.OnNumberChangeHook; h != nil {
+		wrapped.OnNumberChangeHook = func(ctx context.Context, n *nctx, m *mctx, v *webhooks.NumberChange) error {
+			if agent, err := c.withAgent(ctx, n, m.From); agent || err != nil {
+				return err
+			}
+
+			return h(ctx, n, m, v)
+		}
+	}
+
+	if h := hooks.OnIdentityChangeHook; h != nil {
+		wrapped.OnIdentityChangeHook = func(ctx context.Context, n *nctx, m *mctx, v *webhooks.IdentityChange) error {
+			if agent, err := c.withAgent(ctx, n, m.From); agent || err != nil {
+				return err
+			}
+
+			return h(ctx, n, m, v)
+		}
+	}
+
+	if h := hooks.OnAdReferralHook; h != nil {
+		wrapped.OnAdReferralHook = func(ctx context.Context, n *nctx, m *mctx, v *webhooks.Referral) error {
+			if agent, err := c.withAgent(ctx, n, m.From); agent || err != nil {
+				return err
+			}
+
+			return h(ctx, n, m, v)
+		}
+	}
+
+	if h := hooks.OnReplyToBusinessMessageHook; h != nil {
+		wrapped.OnReplyToBusinessMessageHook = func(ctx context.Context, n *nctx, m *mctx, v *webhooks.Reply) error {
+			if agent, err := c.withAgent(ctx, n, m.From); agent || err != nil {
+				return err
+			}
+
+			return h(ctx, n, m, v)
+		}
+	}
+
+	if h := hooks.OnCallPermissionReplyHook; h != nil {
+		wrapped.OnCallPermissionReplyHook = func(ctx context.Context, n *nctx, m *mctx,
+			v *webhooks.CallPermissionReply,
+		) error {
+			if agent, err := c.withAgent(ctx, n, m.From); agent || err != nil {
+				return err
+			}
+
+			return h(ctx, n, m, v)
+		}
+	}
+
+	return &wrapped
+}
+
+// NewMemoryStore creates an empty MemoryStore.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{states: make(map[string]*State)}
+}
+
+// Get returns the state of the conversation or nil.
+func (s *MemoryStore) Get(_ context.Context, key string) (*State, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	state, ok := s.states[key]
+	if !ok {
+		return nil, nil //nolint:nilnil
+	}
+	st := *state
+
+	return &st, nil
+}
+
+// Set stores the state of the conversation.
+func (s *MemoryStore) Set(_ context.Context, key string, state *State) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	st := *state
+	s.states[key] = &st
+
+	return nil
+}
+
+// Delete removes the conversation from the store.
+func (s *MemoryStore) Delete(_ context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.states, key)
+
+	return nil
+}
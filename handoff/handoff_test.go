@@ -0,0 +1,126 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package handoff
+
+import (
+	"context"
+	"testing"
+
+	"github.com/lowkruc/go-whatsapp-api/webhooks"
+)
+
+func TestController(t *testing.T) {
+	t.Parallel()
+	var bot, agent int
+	var handedBack bool
+
+	controller := NewController(NewMemoryStore(),
+		func(ctx context.Context, nctx *webhooks.NotificationContext, message *webhooks.Message, state *State) error {
+			if state.AgentID != "agent-7" {
+				t.Errorf("got agent %q, want agent-7", state.AgentID)
+			}
+			agent++
+
+			return nil
+		},
+		WithOnHandBack(func(ctx context.Context, key string, state *State) {
+			handedBack = true
+		}),
+	)
+
+	hooks := controller.Wrap(&webhooks.Hooks{
+		OnTextMessageHook: func(ctx context.Context, nctx *webhooks.NotificationContext,
+			mctx *webhooks.MessageContext, text *webhooks.Text,
+		) error {
+			bot++
+
+			return nil
+		},
+	})
+
+	ctx := context.TODO()
+	nctx := &webhooks.NotificationContext{Metadata: &webhooks.Metadata{PhoneNumberID: "1000"}}
+	message := &webhooks.Message{From: "255700000000", ID: "wamid.1", Type: "text", Text: &webhooks.Text{Body: "hi"}}
+	mctx := &webhooks.MessageContext{From: message.From, ID: message.ID, Type: message.Type}
+
+	deliver := func() {
+		if err := hooks.OnMessageReceivedHook(ctx, nctx, message); err != nil {
+			t.Fatalf("received hook error = %v", err)
+		}
+		if err := hooks.OnTextMessageHook(ctx, nctx, mctx, message.Text); err != nil {
+			t.Fatalf("text hook error = %v", err)
+		}
+	}
+
+	deliver()
+	key := Key("1000", "255700000000")
+	if err := controller.TakeOver(ctx, key, "agent-7", "asked for a human"); err != nil {
+		t.Fatalf("TakeOver() error = %v", err)
+	}
+	deliver()
+	if err := controller.HandBack(ctx, key); err != nil {
+		t.Fatalf("HandBack() error = %v", err)
+	}
+	deliver()
+
+	if bot != 2 || agent != 1 {
+		t.Errorf("got bot = %d, agent = %d, want 2 and 1", bot, agent)
+	}
+
+	if !handedBack {
+		t.Error("hand back hook was not called")
+	}
+
+	if err := controller.HandBack(ctx, key); err == nil {
+		t.Errorf("HandBack() of a bot conversation error = %v, want an error", err)
+	}
+}
+
+func TestController_WrapButtonReply(t *testing.T) {
+	t.Parallel()
+	var bot int
+	controller := NewController(NewMemoryStore(), nil)
+	hooks := controller.Wrap(&webhooks.Hooks{
+		OnButtonReplyHook: func(ctx context.Context, nctx *webhooks.NotificationContext,
+			mctx *webhooks.MessageContext, reply *webhooks.ButtonReply,
+		) error {
+			bot++
+
+			return nil
+		},
+	})
+
+	ctx := context.TODO()
+	nctx := &webhooks.NotificationContext{Metadata: &webhooks.Metadata{PhoneNumberID: "1000"}}
+	mctx := &webhooks.MessageContext{From: "255700000000", ID: "wamid.1", Type: "interactive"}
+	reply := &webhooks.ButtonReply{ID: "yes", Title: "Yes"}
+
+	if err := controller.TakeOver(ctx, Key("1000", "255700000000"), "agent-7", "asked for a human"); err != nil {
+		t.Fatalf("TakeOver() error = %v", err)
+	}
+
+	if err := hooks.OnButtonReplyHook(ctx, nctx, mctx, reply); err != nil {
+		t.Fatalf("button reply hook error = %v", err)
+	}
+
+	if bot != 0 {
+		t.Errorf("bot button reply hook called %d times during a take over, want 0", bot)
+	}
+}
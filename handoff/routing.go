@@ -0,0 +1,256 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package handoff
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+)
+
+var (
+	ErrNoAgentAvailable = errors.New("no agent available")
+	ErrNoRouter         = errors.New("controller has no router")
+)
+
+type (
+	// Agent is a human agent that conversations can be assigned to. Capacity is the maximum number
+	// of conversations the agent handles at the same time, zero means unlimited.
+	Agent struct {
+		ID       string
+		Skills   []string
+		Capacity int
+	}
+
+	// Candidate is an agent that can take the conversation, together with the number of
+	// conversations it currently handles.
+	Candidate struct {
+		Agent *Agent
+		Load  int
+	}
+
+	// Request describes the conversation being routed. Skills are the skill tags required to
+	// handle it.
+	Request struct {
+		Key    string
+		Skills []string
+	}
+
+	// Strategy chooses the agent a conversation is assigned to among the candidates. Candidates are
+	// never empty, they are ordered by agent ID and exclude the agents without spare capacity.
+	// Returning nil means that none of them should take the conversation.
+	Strategy interface {
+		Choose(ctx context.Context, request *Request, candidates []*Candidate) (*Candidate, error)
+	}
+
+	// StrategyFunc is a function that implements Strategy.
+	StrategyFunc func(ctx context.Context, request *Request, candidates []*Candidate) (*Candidate, error)
+
+	// Router keeps the agents and their load and routes conversations using a Strategy. The load
+	// is counted from the conversations assigned and handed back through the Controller, use
+	// SetLoad to restore it after a restart.
+	Router struct {
+		mu       sync.Mutex
+		strategy Strategy
+		agents   map[string]*Agent
+		load     map[string]int
+	}
+)
+
+func (fn StrategyFunc) Choose(ctx context.Context, request *Request, candidates []*Candidate) (*Candidate, error) {
+	return fn(ctx, request, candidates)
+}
+
+// NewRouter creates a Router. A nil strategy routes to the least loaded agent.
+func NewRouter(strategy Strategy, agents ...*Agent) *Router {
+	if strategy == nil {
+		strategy = LeastLoaded()
+	}
+
+	r := &Router{
+		strategy: strategy,
+		agents:   make(map[string]*Agent),
+		load:     make(map[string]int),
+	}
+	for _, agent := range agents {
+		r.AddAgent(agent)
+	}
+
+	return r
+}
+
+// AddAgent adds the agent or replaces the agent with the same ID. The load of the agent is kept.
+func (r *Router) AddAgent(agent *Agent) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	a := *agent
+	r.agents[agent.ID] = &a
+}
+
+// RemoveAgent stops routing conversations to the agent. Conversations already assigned to the
+// agent are not reassigned.
+func (r *Router) RemoveAgent(agentID string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.agents, agentID)
+}
+
+// Load returns the number of conversations handled by the agent.
+func (r *Router) Load(agentID string) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.load[agentID]
+}
+
+// SetLoad sets the number of conversations handled by the agent.
+func (r *Router) SetLoad(agentID string, load int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.load[agentID] = load
+}
+
+// route chooses an agent for the request, excluding the agent with the exclude ID, and counts
+// the conversation in its load.
+func (r *Router) route(ctx context.Context, request *Request, exclude string) (*Agent, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	candidates := make([]*Candidate, 0, len(r.agents))
+	for id, agent := range r.agents {
+		if id == exclude || (agent.Capacity > 0 && r.load[id] >= agent.Capacity) {
+			continue
+		}
+		candidates = append(candidates, &Candidate{Agent: agent, Load: r.load[id]})
+	}
+	if len(candidates) == 0 {
+		return nil, ErrNoAgentAvailable
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		return candidates[i].Agent.ID < candidates[j].Agent.ID
+	})
+
+	chosen, err := r.strategy.Choose(ctx, request, candidates)
+	if err != nil {
+		return nil, err
+	}
+	if chosen == nil {
+		return nil, ErrNoAgentAvailable
+	}
+	r.load[chosen.Agent.ID]++
+	a := *chosen.Agent
+
+	return &a, nil
+}
+
+// move moves a conversation from one agent to another in the load, empty IDs are ignored.
+func (r *Router) move(from, to string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if from != "" && r.load[from] > 0 {
+		r.load[from]--
+	}
+	if to != "" {
+		r.load[to]++
+	}
+}
+
+// LeastLoaded routes to the agent handling the fewest conversations, ties go to the first
+// candidate.
+func LeastLoaded() Strategy {
+	return StrategyFunc(func(_ context.Context, _ *Request, candidates []*Candidate) (*Candidate, error) {
+		chosen := candidates[0]
+		for _, candidate := range candidates[1:] {
+			if candidate.Load < chosen.Load {
+				chosen = candidate
+			}
+		}
+
+		return chosen, nil
+	})
+}
+
+// RoundRobin routes to the agents in turn, in the order of their IDs. Agents added or removed in
+// between keep their place in the rotation.
+func RoundRobin() Strategy {
+	var (
+		mu   sync.Mutex
+		last string
+	)
+
+	return StrategyFunc(func(_ context.Context, _ *Request, candidates []*Candidate) (*Candidate, error) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		chosen := candidates[0]
+		for _, candidate := range candidates {
+			if candidate.Agent.ID > last {
+				chosen = candidate
+
+				break
+			}
+		}
+		last = chosen.Agent.ID
+
+		return chosen, nil
+	})
+}
+
+// SkillTags keeps the candidates that have every skill required by the request and lets next
+// choose among them. A nil next routes to the least loaded of them.
+func SkillTags(next Strategy) Strategy {
+	if next == nil {
+		next = LeastLoaded()
+	}
+
+	return StrategyFunc(func(ctx context.Context, request *Request, candidates []*Candidate) (*Candidate, error) {
+		var skilled []*Candidate
+		for _, candidate := range candidates {
+			if hasSkills(candidate.Agent, request.Skills) {
+				skilled = append(skilled, candidate)
+			}
+		}
+		if len(skilled) == 0 {
+			return nil, nil //nolint:nilnil
+		}
+
+		return next.Choose(ctx, request, skilled)
+	})
+}
+
+func hasSkills(agent *Agent, skills []string) bool {
+	for _, skill := range skills {
+		found := false
+		for _, s := range agent.Skills {
+			if s == skill {
+				found = true
+
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,120 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package handoff
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestStrategies(t *testing.T) {
+	t.Parallel()
+	ctx := context.TODO()
+	candidates := []*Candidate{
+		{Agent: &Agent{ID: "a", Skills: []string{"sales"}}, Load: 3},
+		{Agent: &Agent{ID: "b", Skills: []string{"billing", "sales"}}, Load: 1},
+		{Agent: &Agent{ID: "c", Skills: []string{"billing"}}, Load: 2},
+	}
+
+	chosen, _ := LeastLoaded().Choose(ctx, &Request{}, candidates)
+	if chosen.Agent.ID != "b" {
+		t.Errorf("least loaded chose %q, want b", chosen.Agent.ID)
+	}
+
+	rr := RoundRobin()
+	var got []string
+	for i := 0; i < 4; i++ {
+		chosen, _ := rr.Choose(ctx, &Request{}, candidates)
+		got = append(got, chosen.Agent.ID)
+	}
+	if strings.Join(got, ",") != "a,b,c,a" {
+		t.Errorf("round robin chose %v, want a,b,c,a", got)
+	}
+
+	chosen, _ = SkillTags(nil).Choose(ctx, &Request{Skills: []string{"billing"}}, candidates)
+	if chosen.Agent.ID != "b" {
+		t.Errorf("skill tags chose %q, want b", chosen.Agent.ID)
+	}
+
+	chosen, _ = SkillTags(nil).Choose(ctx, &Request{Skills: []string{"support"}}, candidates)
+	if chosen != nil {
+		t.Errorf("skill tags chose %q, want none", chosen.Agent.ID)
+	}
+}
+
+func TestControllerRouting(t *testing.T) {
+	t.Parallel()
+	ctx := context.TODO()
+	var reassigned []string
+
+	router := NewRouter(SkillTags(nil),
+		&Agent{ID: "a", Skills: []string{"billing"}, Capacity: 1},
+		&Agent{ID: "b", Skills: []string{"billing", "sales"}, Capacity: 2},
+	)
+	controller := NewController(NewMemoryStore(), nil, WithRouter(router),
+		WithOnReassign(func(ctx context.Context, key string, from, to *State) {
+			reassigned = append(reassigned, from.AgentID+"->"+to.AgentID)
+		}),
+	)
+
+	first, err := controller.Assign(ctx, "1", "", "billing")
+	if err != nil || first.AgentID != "a" {
+		t.Fatalf("Assign() = %+v, %v, want agent a", first, err)
+	}
+	second, err := controller.Assign(ctx, "2", "", "billing")
+	if err != nil || second.AgentID != "b" {
+		t.Fatalf("Assign() = %+v, %v, want agent b", second, err)
+	}
+	_, err = controller.Assign(ctx, "3", "", "support")
+	if err == nil || !strings.Contains(err.Error(), ErrNoAgentAvailable.Error()) {
+		t.Fatalf("Assign() error = %v, want %v", err, ErrNoAgentAvailable)
+	}
+
+	// a is at capacity, so the conversation cannot move back to it.
+	if _, err := controller.Reassign(ctx, "2", "", "escalated"); err == nil {
+		t.Fatal("Reassign() to a busy agent error = nil, want an error")
+	}
+	state, err := controller.Reassign(ctx, "1", "", "escalated")
+	if err != nil || state.AgentID != "b" || state.Skills[0] != "billing" {
+		t.Fatalf("Reassign() = %+v, %v, want agent b with the billing skill", state, err)
+	}
+	if router.Load("a") != 0 || router.Load("b") != 2 {
+		t.Errorf("got loads a = %d, b = %d, want 0 and 2", router.Load("a"), router.Load("b"))
+	}
+
+	if _, err := controller.Reassign(ctx, "2", "a", "manual"); err != nil {
+		t.Fatalf("Reassign() error = %v", err)
+	}
+	if err := controller.HandBack(ctx, "1"); err != nil {
+		t.Fatalf("HandBack() error = %v", err)
+	}
+	if router.Load("a") != 1 || router.Load("b") != 0 {
+		t.Errorf("got loads a = %d, b = %d, want 1 and 0", router.Load("a"), router.Load("b"))
+	}
+
+	if strings.Join(reassigned, ",") != "a->b,b->a" {
+		t.Errorf("got reassignments %v, want a->b,b->a", reassigned)
+	}
+
+	if _, err := NewController(NewMemoryStore(), nil).Assign(ctx, "1", ""); err == nil {
+		t.Error("Assign() without a router error = nil, want an error")
+	}
+}
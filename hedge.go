@@ -0,0 +1,256 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package whatsapp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/lowkruc/go-whatsapp-api/audit"
+	werrors "github.com/lowkruc/go-whatsapp-api/errors"
+	whttp "github.com/lowkruc/go-whatsapp-api/http"
+	"github.com/lowkruc/go-whatsapp-api/models"
+)
+
+var ErrMissingIdempotencyKey = errors.New("idempotency key is required")
+
+type (
+	// HedgePolicy configures hedged sends. If no response is received after Delay, another attempt
+	// is sent, up to MaxAttempts attempts in total. The first successful response wins and the
+	// other attempts are cancelled. An attempt that fails with a retryable error, a transport
+	// error, a 429 or 5xx, or an error werrors.Classify deems retryable, starts the next attempt
+	// right away. Any other error, like an invalid recipient or template, ends the send.
+	//
+	// The Cloud API does not deduplicate messages, so a hedged send can deliver the message more
+	// than once when the first attempt was only slow. Use it for messages where a duplicate is
+	// harmless and latency matters, like one time passwords.
+	HedgePolicy struct {
+		Delay       time.Duration
+		MaxAttempts int
+	}
+
+	// flightGroup makes concurrent hedged sends with the same idempotency key share one result.
+	flightGroup struct {
+		mu      sync.Mutex
+		flights map[string]*flight
+	}
+
+	flight struct {
+		done      chan struct{}
+		response  *ResponseMessage
+		err       error
+		abandoned bool
+	}
+
+	attemptResult struct {
+		response *ResponseMessage
+		err      error
+	}
+)
+
+// WithHedging enables hedged sends for Client.SendHedged.
+func WithHedging(delay time.Duration, maxAttempts int) ClientOption {
+	return func(client *Client) {
+		client.hedging = &HedgePolicy{
+			Delay:       delay,
+			MaxAttempts: maxAttempts,
+		}
+	}
+}
+
+// SendHedged sends the message using the HedgePolicy set by WithHedging, without a policy the
+// message is sent once. The idempotency key identifies the logical send, concurrent calls with
+// the same key wait for the send in flight and get its result instead of sending again.
+func (client *Client) SendHedged(ctx context.Context, idempotencyKey string, message *models.Message,
+) (*ResponseMessage, error) {
+	if idempotencyKey == "" {
+		return nil, fmt.Errorf("send hedged: %v", ErrMissingIdempotencyKey)
+	}
+
+	return client.flights.do(ctx, idempotencyKey, func() (*ResponseMessage, error) {
+		policy := client.hedging
+		if policy == nil || policy.MaxAttempts < 2 { //nolint:gomnd
+			return client.SendMessage(ctx, message)
+		}
+
+		// the message is moderated and checked once, the attempts share its fields.
+		if err := client.moderate(ctx, message); err != nil {
+			return nil, err
+		}
+		if _, err := client.limits.Apply(message); err != nil {
+			return nil, fmt.Errorf("send hedged: %v", err)
+		}
+
+		var attempts int32
+		response, err := hedge(ctx, policy, func(ctx context.Context) (*ResponseMessage, error) {
+			if attempt := atomic.AddInt32(&attempts, 1); attempt > 1 {
+				audit.Record(ctx, client.audit, audit.SendRetried, nil, map[string]string{
+					"request": "send hedged",
+					"attempt": strconv.Itoa(int(attempt)),
+				})
+			}
+
+			// every attempt sends its own copy, postMessage sets fields on the message.
+			m := *message
+
+			return client.postMessage(ctx, &m)
+		})
+		if err != nil {
+			client.reportError(ctx, err, "send_hedged")
+		}
+
+		return response, err
+	})
+}
+
+// hedge runs send and starts another attempt every policy.Delay, or right away when an attempt
+// fails with a retryable error, until one succeeds, one fails with an error that is not retryable
+// or all the attempts failed.
+func hedge(ctx context.Context, policy *HedgePolicy,
+	send func(ctx context.Context) (*ResponseMessage, error),
+) (*ResponseMessage, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	results := make(chan attemptResult, policy.MaxAttempts)
+	launch := func() {
+		go func() {
+			response, err := send(ctx)
+			results <- attemptResult{response: response, err: err}
+		}()
+	}
+
+	timer := time.NewTimer(policy.Delay)
+	defer timer.Stop()
+
+	launched, pending := 1, 1
+	launch()
+
+	var lastErr error
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-timer.C:
+			if launched < policy.MaxAttempts {
+				launched++
+				pending++
+				launch()
+				timer.Reset(policy.Delay)
+			}
+		case result := <-results:
+			pending--
+			if result.err == nil {
+				return result.response, nil
+			}
+			lastErr = result.err
+			if !retryableSendError(result.err) {
+				return nil, fmt.Errorf("hedged send failed after %d attempts: %v", launched, lastErr)
+			}
+
+			if launched < policy.MaxAttempts {
+				launched++
+				pending++
+				launch()
+				resetTimer(timer, policy.Delay)
+			} else if pending == 0 {
+				return nil, fmt.Errorf("hedged send failed after %d attempts: %v", launched, lastErr)
+			}
+		}
+	}
+}
+
+// resetTimer stops the timer, drains a tick it may have sent already and resets it, so that a
+// stale tick does not start the next attempt before d.
+func resetTimer(timer *time.Timer, d time.Duration) {
+	if !timer.Stop() {
+		select {
+		case <-timer.C:
+		default:
+		}
+	}
+	timer.Reset(d)
+}
+
+// retryableSendError reports whether sending the message again may succeed: the transport errors,
+// the 429 and 5xx responses and the errors werrors.Classify deems retryable.
+func retryableSendError(err error) bool {
+	var responseErr *whttp.ResponseError
+	if !errors.As(err, &responseErr) {
+		return true
+	}
+	if responseErr.Code == http.StatusTooManyRequests || responseErr.Code >= http.StatusInternalServerError {
+		return true
+	}
+
+	return werrors.Classify(responseErr.Err).Retryable()
+}
+
+// do calls fn, or waits for the result of the call in flight with the same key. A waiter stops
+// waiting when its ctx ends. When the call in flight is abandoned, its context ended or fn
+// panicked, the waiters do not take its result and call fn again.
+func (g *flightGroup) do(ctx context.Context, key string,
+	fn func() (*ResponseMessage, error),
+) (*ResponseMessage, error) {
+	for {
+		g.mu.Lock()
+		if g.flights == nil {
+			g.flights = make(map[string]*flight)
+		}
+
+		f, ok := g.flights[key]
+		if !ok {
+			break
+		}
+		g.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-f.done:
+		}
+		if !f.abandoned {
+			return f.response, f.err
+		}
+	}
+
+	// abandoned stays true when fn panics.
+	f := &flight{done: make(chan struct{}), abandoned: true}
+	g.flights[key] = f
+	g.mu.Unlock()
+
+	defer func() {
+		g.mu.Lock()
+		delete(g.flights, key)
+		g.mu.Unlock()
+		close(f.done)
+	}()
+
+	f.response, f.err = fn()
+	f.abandoned = ctx.Err() != nil
+
+	return f.response, f.err
+}
@@ -0,0 +1,237 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package whatsapp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/lowkruc/go-whatsapp-api/models"
+	"github.com/lowkruc/go-whatsapp-api/report"
+)
+
+func TestClient_SendHedged(t *testing.T) {
+	t.Parallel()
+
+	var attempts int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&attempts, 1)
+		if n == 1 {
+			// the first attempt is slow, the hedge should win.
+			select {
+			case <-r.Context().Done():
+			case <-time.After(500 * time.Millisecond):
+			}
+
+			return
+		}
+		_, _ = fmt.Fprintf(w, `{"messaging_product":"whatsapp","messages":[{"id":"wamid.%d"}]}`, n)
+	}))
+	defer server.Close()
+
+	client := NewClient(
+		WithBaseURL(server.URL),
+		WithPhoneNumberID("phone_id"),
+		WithHedging(20*time.Millisecond, 2),
+	)
+
+	message := models.NewMessage("255700000000", models.WithTemplate(models.NewTextTemplate("otp",
+		&models.TemplateLanguage{Code: "en_US"}, []*models.TemplateParameter{{Type: "text", Text: "123456"}})))
+	start := time.Now()
+	resp, err := client.SendHedged(context.TODO(), "otp-1", message)
+	if err != nil {
+		t.Fatalf("SendHedged() error = %v", err)
+	}
+
+	if elapsed := time.Since(start); elapsed > 400*time.Millisecond {
+		t.Errorf("SendHedged() took %v, the hedge did not win", elapsed)
+	}
+
+	if len(resp.Messages) != 1 || resp.Messages[0].ID != "wamid.2" {
+		t.Errorf("unexpected response %+v", resp)
+	}
+
+	if _, err := client.SendHedged(context.TODO(), "", message); err == nil {
+		t.Error("SendHedged() without an idempotency key should fail")
+	}
+}
+
+type recordingReporter struct {
+	mu   sync.Mutex
+	errs []error
+	tags []report.Tags
+}
+
+func (r *recordingReporter) CaptureError(_ context.Context, err error, tags report.Tags) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.errs = append(r.errs, err)
+	r.tags = append(r.tags, tags)
+}
+
+func (r *recordingReporter) CaptureMessage(context.Context, string, report.Tags) {}
+
+func TestClient_SendHedged_ReportsExhaustion(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = w.Write([]byte(`{"error":{"message":"internal","code":1}}`))
+	}))
+	defer server.Close()
+
+	reporter := &recordingReporter{}
+	client := NewClient(
+		WithBaseURL(server.URL),
+		WithPhoneNumberID("phone_id"),
+		WithHedging(10*time.Millisecond, 2),
+		WithErrorReporter(reporter),
+	)
+
+	message := &models.Message{Type: "text", To: "255700000000", Text: &models.Text{Body: "hi"}}
+	if _, err := client.SendHedged(context.TODO(), "key", message); err == nil {
+		t.Fatal("SendHedged() should fail")
+	}
+
+	if len(reporter.errs) != 1 || reporter.tags[0][report.TagOperation] != "send_hedged" {
+		t.Errorf("reported %v with tags %v", reporter.errs, reporter.tags)
+	}
+}
+
+func TestClient_SendHedged_PermanentError(t *testing.T) {
+	t.Parallel()
+
+	var attempts int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&attempts, 1)
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`{"error":{"message":"Recipient phone number not in allowed list","code":131030}}`))
+	}))
+	defer server.Close()
+
+	client := NewClient(
+		WithBaseURL(server.URL),
+		WithPhoneNumberID("phone_id"),
+		WithHedging(time.Second, 3),
+	)
+
+	message := &models.Message{Type: "text", To: "255700000000", Text: &models.Text{Body: "hi"}}
+	if _, err := client.SendHedged(context.TODO(), "key", message); err == nil {
+		t.Fatal("SendHedged() should fail")
+	}
+	if n := atomic.LoadInt32(&attempts); n != 1 {
+		t.Errorf("got %d attempts, want the permanent error not to be hedged", n)
+	}
+}
+
+func TestResetTimer(t *testing.T) {
+	t.Parallel()
+
+	timer := time.NewTimer(time.Millisecond)
+	defer timer.Stop()
+	time.Sleep(10 * time.Millisecond)
+
+	resetTimer(timer, time.Hour)
+	select {
+	case <-timer.C:
+		t.Error("the timer ticked right after the reset, the stale tick was not drained")
+	case <-time.After(20 * time.Millisecond):
+	}
+}
+
+func TestFlightGroup_Do(t *testing.T) {
+	t.Parallel()
+	// lead starts a call of the group that runs until release is closed, then ends with end.
+	lead := func(ctx context.Context, g *flightGroup, end func()) (chan struct{}, chan struct{}) {
+		started, release, done := make(chan struct{}), make(chan struct{}), make(chan struct{})
+		go func() {
+			defer close(done)
+			defer func() { _ = recover() }()
+			_, _ = g.do(ctx, "key", func() (*ResponseMessage, error) {
+				close(started)
+				<-release
+				end()
+
+				return &ResponseMessage{Product: "leader"}, nil
+			})
+		}()
+		<-started
+
+		return release, done
+	}
+	wait := func(ctx context.Context, g *flightGroup) (*ResponseMessage, error) {
+		return g.do(ctx, "key", func() (*ResponseMessage, error) {
+			return &ResponseMessage{Product: "waiter"}, nil
+		})
+	}
+
+	t.Run("waiter context ends", func(t *testing.T) {
+		t.Parallel()
+		g := &flightGroup{}
+		release, done := lead(context.Background(), g, func() {})
+		defer func() { close(release); <-done }()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		if _, err := wait(ctx, g); !errors.Is(err, context.Canceled) {
+			t.Errorf("do() error = %v, want %v", err, context.Canceled)
+		}
+	})
+
+	// the waiter starts waiting before the leader gives up, its own call is the one that counts.
+	abandon := map[string]func(ctx context.Context, g *flightGroup) (chan struct{}, chan struct{}){
+		"leader panics": func(ctx context.Context, g *flightGroup) (chan struct{}, chan struct{}) {
+			return lead(ctx, g, func() { panic("boom") })
+		},
+		"leader context ends": func(ctx context.Context, g *flightGroup) (chan struct{}, chan struct{}) {
+			ctx, cancel := context.WithCancel(ctx)
+
+			return lead(ctx, g, cancel)
+		},
+	}
+	for name, start := range abandon {
+		start := start
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			g := &flightGroup{}
+			release, done := start(context.Background(), g)
+
+			result := make(chan *ResponseMessage, 1)
+			go func() {
+				response, _ := wait(context.Background(), g)
+				result <- response
+			}()
+			time.Sleep(10 * time.Millisecond)
+			close(release)
+			<-done
+
+			if response := <-result; response == nil || response.Product != "waiter" {
+				t.Errorf("do() = %+v, want the result of the waiter", response)
+			}
+		})
+	}
+}
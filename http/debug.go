@@ -26,6 +26,8 @@ import (
 	"net/http/httputil"
 	"os"
 	"strings"
+
+	"github.com/lowkruc/go-whatsapp-api/guard"
 )
 
 type DebugFunc func(io.Writer) Hook
@@ -35,6 +37,16 @@ type DebugFunc func(io.Writer) Hook
 // retrieves the request name from the context using RequestNameFromContext and print it with
 // the request and response.
 func DebugHook(writer io.Writer) Hook {
+	return debugHook(writer, 0)
+}
+
+// LimitedDebugHook is like DebugHook but cuts the dump of the request and of the response to
+// maxBytes each, so that large payloads like media uploads do not flood the logs.
+func LimitedDebugHook(writer io.Writer, maxBytes int) Hook {
+	return debugHook(writer, maxBytes)
+}
+
+func debugHook(writer io.Writer, maxBytes int) Hook {
 	return func(ctx context.Context, req *http.Request, resp *http.Response) {
 		var buff strings.Builder
 		name := strings.ToUpper(RequestNameFromContext(ctx))
@@ -43,7 +55,7 @@ func DebugHook(writer io.Writer) Hook {
 		if req != nil {
 			b, err := httputil.DumpRequestOut(req, true)
 			if err == nil {
-				buff.Write(b)
+				buff.WriteString(guard.Truncate(string(b), maxBytes))
 				buff.WriteString("\n")
 			}
 		}
@@ -51,7 +63,7 @@ func DebugHook(writer io.Writer) Hook {
 		if resp != nil {
 			b, err := httputil.DumpResponse(resp, true)
 			if err == nil {
-				buff.Write(b)
+				buff.WriteString(guard.Truncate(string(b), maxBytes))
 				buff.WriteString("\n")
 			}
 		}
@@ -0,0 +1,110 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package http
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// AcceptEncoding is the Accept-Encoding header value sent by Do, the response body is
+// decompressed before it is decoded.
+const AcceptEncoding = "gzip, deflate"
+
+// DefaultMaxDecodedSize is the size DecodeContentEncoding decompresses a body to at most when it
+// is given no maximum size.
+const DefaultMaxDecodedSize int64 = 32 << 20
+
+var (
+	ErrUnsupportedContentEncoding = errors.New("unsupported content encoding")
+	ErrDecodedBodyTooLarge        = errors.New("decoded body is too large")
+)
+
+// IsEncoded reports whether the Content-Encoding header value means the body is compressed.
+func IsEncoded(contentEncoding string) bool {
+	e := strings.TrimSpace(strings.ToLower(contentEncoding))
+
+	return e != "" && e != "identity"
+}
+
+// DecodeContentEncoding decompresses body according to the Content-Encoding header value.
+// gzip, x-gzip and deflate are supported, deflate bodies can be zlib wrapped or raw. Bodies
+// with no or identity encoding are returned unchanged. Multiple encodings are undone in the
+// reverse order they were applied.
+//
+// A body is decompressed to at most maxSize bytes, DefaultMaxDecodedSize when maxSize is not
+// positive, so that a small compressed body cannot expand without bound. Larger bodies return
+// ErrDecodedBodyTooLarge as is.
+func DecodeContentEncoding(contentEncoding string, body []byte, maxSize int64) ([]byte, error) {
+	if maxSize <= 0 {
+		maxSize = DefaultMaxDecodedSize
+	}
+	encodings := strings.Split(contentEncoding, ",")
+	for i := len(encodings) - 1; i >= 0; i-- {
+		var err error
+		if body, err = decode(strings.TrimSpace(strings.ToLower(encodings[i])), body, maxSize); err != nil {
+			return nil, err
+		}
+	}
+
+	return body, nil
+}
+
+func decode(encoding string, body []byte, maxSize int64) ([]byte, error) {
+	var (
+		reader io.ReadCloser
+		err    error
+	)
+	switch encoding {
+	case "", "identity":
+		return body, nil
+	case "gzip", "x-gzip":
+		reader, err = gzip.NewReader(bytes.NewReader(body))
+	case "deflate":
+		// RFC 9110 says deflate is zlib wrapped, but many servers send raw deflate.
+		reader, err = zlib.NewReader(bytes.NewReader(body))
+		if err != nil {
+			reader, err = flate.NewReader(bytes.NewReader(body)), nil
+		}
+	default:
+		return nil, fmt.Errorf("%v: %q", ErrUnsupportedContentEncoding, encoding)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("decode %s body: %v", encoding, err)
+	}
+	defer reader.Close()
+
+	decoded, err := io.ReadAll(io.LimitReader(reader, maxSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("decode %s body: %v", encoding, err)
+	}
+	if int64(len(decoded)) > maxSize {
+		return nil, ErrDecodedBodyTooLarge
+	}
+
+	return decoded, nil
+}
@@ -0,0 +1,129 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package http
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func compress(t *testing.T, encoding string, data []byte) []byte {
+	t.Helper()
+	var (
+		buf bytes.Buffer
+		w   io.WriteCloser
+	)
+	switch encoding {
+	case "gzip":
+		w = gzip.NewWriter(&buf)
+	case "zlib":
+		w = zlib.NewWriter(&buf)
+	case "flate":
+		w, _ = flate.NewWriter(&buf, flate.DefaultCompression)
+	}
+	if _, err := w.Write(data); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	return buf.Bytes()
+}
+
+func TestDecodeContentEncoding(t *testing.T) {
+	t.Parallel()
+	payload := []byte(`{"messaging_product":"whatsapp"}`)
+	tests := []struct {
+		name     string
+		encoding string
+		body     []byte
+		wantErr  bool
+	}{
+		{name: "identity", encoding: "", body: payload},
+		{name: "gzip", encoding: "gzip", body: compress(t, "gzip", payload)},
+		{name: "zlib deflate", encoding: "deflate", body: compress(t, "zlib", payload)},
+		{name: "raw deflate", encoding: "deflate", body: compress(t, "flate", payload)},
+		{name: "stacked", encoding: "deflate, gzip", body: compress(t, "gzip", compress(t, "zlib", payload))},
+		{name: "gzip header on a plain body", encoding: "gzip", body: payload, wantErr: true},
+		{name: "unsupported", encoding: "br", body: payload, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got, err := DecodeContentEncoding(tt.encoding, tt.body, 0)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("DecodeContentEncoding() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !bytes.Equal(got, payload) {
+				t.Errorf("DecodeContentEncoding() = %s, want %s", got, payload)
+			}
+		})
+	}
+}
+
+func TestDecodeContentEncoding_MaxSize(t *testing.T) {
+	t.Parallel()
+	bomb := compress(t, "gzip", make([]byte, 1<<20))
+
+	if _, err := DecodeContentEncoding("gzip", bomb, 1024); !errors.Is(err, ErrDecodedBodyTooLarge) {
+		t.Errorf("DecodeContentEncoding() error = %v, want %v", err, ErrDecodedBodyTooLarge)
+	}
+	if got, err := DecodeContentEncoding("gzip", bomb, 1<<20); err != nil || len(got) != 1<<20 {
+		t.Errorf("DecodeContentEncoding() = %d bytes, %v, want the body at the limit", len(got), err)
+	}
+}
+
+func TestDo_CompressedResponse(t *testing.T) {
+	t.Parallel()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Accept-Encoding") != AcceptEncoding {
+			t.Errorf("Accept-Encoding = %q, want %q", r.Header.Get("Accept-Encoding"), AcceptEncoding)
+		}
+		w.Header().Set("Content-Encoding", "deflate")
+		_, _ = w.Write(compress(t, "zlib", []byte(`{"success":true}`)))
+	}))
+	defer server.Close()
+
+	var v struct {
+		Success bool `json:"success"`
+	}
+	err := Do(context.TODO(), server.Client(), &Request{
+		Context: &RequestContext{Name: "test", BaseURL: server.URL, ApiVersion: "v16.0", SenderID: "1"},
+		Method:  http.MethodGet,
+	}, &v)
+	if err != nil {
+		t.Fatalf("Do() error = %v", err)
+	}
+
+	if !v.Success {
+		t.Error("response body was not decoded")
+	}
+}
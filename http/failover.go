@@ -0,0 +1,328 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package http
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptrace"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+var ErrNoHealthyEndpoint = errors.New("no healthy egress endpoint")
+
+type (
+	// Endpoint is an egress route to the Graph API, for example a regional gateway. Requests are
+	// sent to BaseURL instead of the host they were created for, the path of BaseURL is prepended
+	// to the request path. Proxy, when set, is used for this endpoint only.
+	Endpoint struct {
+		Name    string
+		BaseURL string
+		Proxy   *url.URL
+
+		base      *url.URL
+		transport http.RoundTripper
+		downUntil time.Time
+		failures  int
+	}
+
+	// HealthCheckFunc checks an endpoint, a nil error means the endpoint is healthy.
+	HealthCheckFunc func(ctx context.Context, endpoint *Endpoint) error
+
+	// FailoverTransport is an http.RoundTripper that sends requests through one of several
+	// endpoints. It sticks to an endpoint until it fails, a failed endpoint is skipped for the
+	// cooldown or until a health check passes. A request that failed on one endpoint is sent to
+	// the next one when its body can be replayed.
+	//
+	// Idempotent requests, like GET and DELETE, fail over on transport errors and 5xx responses.
+	// Other requests, like the POST that sends a message, only fail over when the endpoint failed
+	// before the request was written, a dial error for example. A 5xx or an error after the request
+	// was written is returned as is, since the upstream may have accepted the message and sending
+	// it again through another endpoint would deliver it twice.
+	//
+	// Example:
+	//
+	//	transport, err := whttp.NewFailoverTransport([]*whttp.Endpoint{
+	//		{Name: "eu", BaseURL: "https://graph-eu.gateway.example.com"},
+	//		{Name: "us", BaseURL: "https://graph-us.gateway.example.com"},
+	//	}, whttp.WithCooldown(time.Minute))
+	//	client := whatsapp.NewClient(whatsapp.WithHTTPClient(&http.Client{Transport: transport}))
+	FailoverTransport struct {
+		mu        sync.Mutex
+		endpoints []*Endpoint
+		current   int
+		cooldown  time.Duration
+		check     HealthCheckFunc
+		base      *http.Transport
+		now       func() time.Time
+	}
+
+	FailoverOption func(*FailoverTransport)
+)
+
+// WithCooldown sets how long a failed endpoint is skipped, the default is 30 seconds.
+func WithCooldown(cooldown time.Duration) FailoverOption {
+	return func(t *FailoverTransport) {
+		t.cooldown = cooldown
+	}
+}
+
+// WithHealthCheck sets the function used by CheckHealth and Start to probe endpoints.
+func WithHealthCheck(check HealthCheckFunc) FailoverOption {
+	return func(t *FailoverTransport) {
+		t.check = check
+	}
+}
+
+// WithBaseTransport sets the transport cloned for every endpoint, the default is a clone of
+// http.DefaultTransport.
+func WithBaseTransport(base *http.Transport) FailoverOption {
+	return func(t *FailoverTransport) {
+		t.base = base
+	}
+}
+
+// NewFailoverTransport creates a FailoverTransport, endpoints are tried in the given order.
+func NewFailoverTransport(endpoints []*Endpoint, options ...FailoverOption) (*FailoverTransport, error) {
+	if len(endpoints) == 0 {
+		return nil, fmt.Errorf("failover transport: %v", ErrNoHealthyEndpoint)
+	}
+
+	t := &FailoverTransport{
+		endpoints: endpoints,
+		cooldown:  30 * time.Second, //nolint:gomnd
+		now:       time.Now,
+	}
+
+	for _, option := range options {
+		option(t)
+	}
+
+	if t.base == nil {
+		t.base = http.DefaultTransport.(*http.Transport).Clone() //nolint:forcetypeassert
+	}
+
+	for _, endpoint := range endpoints {
+		base, err := url.Parse(endpoint.BaseURL)
+		if err != nil || base.Scheme == "" || base.Host == "" {
+			return nil, fmt.Errorf("failover transport: invalid base url %q", endpoint.BaseURL)
+		}
+		endpoint.base = base
+
+		transport := t.base.Clone()
+		if endpoint.Proxy != nil {
+			transport.Proxy = http.ProxyURL(endpoint.Proxy)
+		}
+		endpoint.transport = transport
+	}
+
+	return t, nil
+}
+
+// Current returns the name of the endpoint requests are sent to.
+func (t *FailoverTransport) Current() string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.endpoints[t.current].Name
+}
+
+// pick returns the index of the sticky endpoint if it is healthy, or of the next healthy one.
+// When every endpoint is down, the first attempt uses the one that recovers first.
+func (t *FailoverTransport) pick(exclude map[int]bool) (int, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := t.now()
+	fallback := -1
+	for i := 0; i < len(t.endpoints); i++ {
+		index := (t.current + i) % len(t.endpoints)
+		if exclude[index] {
+			continue
+		}
+
+		if now.Before(t.endpoints[index].downUntil) {
+			if fallback < 0 || t.endpoints[index].downUntil.Before(t.endpoints[fallback].downUntil) {
+				fallback = index
+			}
+
+			continue
+		}
+		t.current = index
+
+		return index, true
+	}
+
+	if len(exclude) == 0 && fallback >= 0 {
+		return fallback, true
+	}
+
+	return 0, false
+}
+
+func (t *FailoverTransport) markDown(index int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	endpoint := t.endpoints[index]
+	endpoint.failures++
+	endpoint.downUntil = t.now().Add(t.cooldown)
+}
+
+func (t *FailoverTransport) markUp(index int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	endpoint := t.endpoints[index]
+	endpoint.failures = 0
+	endpoint.downUntil = time.Time{}
+}
+
+// RoundTrip sends the request through the selected endpoint and fails over on transport errors
+// and 5xx responses, see FailoverTransport for the requests that are not idempotent.
+func (t *FailoverTransport) RoundTrip(request *http.Request) (*http.Response, error) {
+	idempotent := isIdempotent(request)
+	tried := make(map[int]bool)
+	var lastErr error
+	for {
+		index, ok := t.pick(tried)
+		if !ok {
+			if lastErr != nil {
+				return nil, lastErr
+			}
+
+			return nil, ErrNoHealthyEndpoint
+		}
+		tried[index] = true
+
+		out, err := t.rewrite(request, t.endpoints[index], len(tried) > 1)
+		if err != nil {
+			return nil, err
+		}
+		var written int32
+		out = out.WithContext(httptrace.WithClientTrace(out.Context(), &httptrace.ClientTrace{
+			WroteHeaderField: func(string, []string) { atomic.StoreInt32(&written, 1) },
+		}))
+
+		response, err := t.endpoints[index].transport.RoundTrip(out)
+		if err == nil && response.StatusCode < http.StatusInternalServerError {
+			return response, nil
+		}
+
+		if request.Context().Err() != nil {
+			return response, err
+		}
+
+		t.markDown(index)
+		canRetry := (request.Body == nil || request.GetBody != nil) &&
+			(idempotent || (err != nil && atomic.LoadInt32(&written) == 0))
+		if err == nil {
+			if !canRetry || len(tried) == len(t.endpoints) {
+				return response, nil
+			}
+			_ = response.Body.Close()
+			lastErr = fmt.Errorf("failover: %s: status %d", t.endpoints[index].Name, response.StatusCode)
+
+			continue
+		}
+
+		lastErr = fmt.Errorf("failover: %s: %v", t.endpoints[index].Name, err)
+		if !canRetry {
+			return nil, lastErr
+		}
+	}
+}
+
+// isIdempotent reports whether the request can be sent again without side effects, see RFC 9110
+// section 9.2.2.
+func isIdempotent(request *http.Request) bool {
+	switch request.Method {
+	case "", http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace, http.MethodPut,
+		http.MethodDelete:
+		return true
+	default:
+		return false
+	}
+}
+
+func (t *FailoverTransport) rewrite(request *http.Request, endpoint *Endpoint, replay bool) (*http.Request, error) {
+	out := request.Clone(request.Context())
+	out.URL.Scheme = endpoint.base.Scheme
+	out.URL.Host = endpoint.base.Host
+	out.Host = endpoint.base.Host
+	if prefix := strings.TrimSuffix(endpoint.base.Path, "/"); prefix != "" {
+		out.URL.Path = prefix + request.URL.Path
+		out.URL.RawPath = ""
+	}
+
+	if replay && request.GetBody != nil {
+		body, err := request.GetBody()
+		if err != nil {
+			return nil, fmt.Errorf("failover: replay body: %v", err)
+		}
+		out.Body = body
+	}
+
+	return out, nil
+}
+
+// CheckHealth runs the health check on every endpoint that is down and restores the ones that
+// pass. It does nothing when no HealthCheckFunc was set.
+func (t *FailoverTransport) CheckHealth(ctx context.Context) {
+	if t.check == nil {
+		return
+	}
+
+	for index, endpoint := range t.endpoints {
+		t.mu.Lock()
+		down := endpoint.failures > 0
+		t.mu.Unlock()
+		if !down {
+			continue
+		}
+
+		if err := t.check(ctx, endpoint); err == nil {
+			t.markUp(index)
+		} else {
+			t.markDown(index)
+		}
+	}
+}
+
+// Start calls CheckHealth every interval until the context is done.
+func (t *FailoverTransport) Start(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			t.CheckHealth(ctx)
+		}
+	}
+}
@@ -0,0 +1,139 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package http
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func TestFailoverTransport(t *testing.T) {
+	t.Parallel()
+	var bodies []string
+	var failed int32
+	down := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&failed, 1)
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer down.Close()
+
+	up := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		buf := new(strings.Builder)
+		_, _ = io.Copy(buf, r.Body)
+		bodies = append(bodies, r.Method+" "+r.URL.Path+" "+buf.String())
+		_, _ = w.Write([]byte(`{"success":true}`))
+	}))
+	defer up.Close()
+
+	transport, err := NewFailoverTransport([]*Endpoint{
+		{Name: "eu", BaseURL: down.URL + "/gateway"},
+		{Name: "us", BaseURL: up.URL + "/gateway"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client := &http.Client{Transport: transport}
+	send := func(method string) int {
+		var body io.Reader
+		if method == http.MethodPost {
+			body = strings.NewReader(`{"to":"255"}`)
+		}
+		request, _ := http.NewRequestWithContext(context.TODO(), method,
+			"https://graph.facebook.com/v16.0/1/messages", body)
+		response, err := client.Do(request)
+		if err != nil {
+			t.Fatalf("Do() error = %v", err)
+		}
+		_ = response.Body.Close()
+
+		return response.StatusCode
+	}
+
+	// the 502 of a POST is returned, the gateway may have forwarded the message already.
+	if code := send(http.MethodPost); code != http.StatusBadGateway {
+		t.Fatalf("got status %d, want the 502 of the first endpoint", code)
+	}
+	if len(bodies) != 0 {
+		t.Fatalf("the POST was sent again: %q", bodies)
+	}
+
+	// the first endpoint is down now, the next POST goes to the second one.
+	if code := send(http.MethodPost); code != http.StatusOK {
+		t.Fatalf("got status %d, want 200", code)
+	}
+	if transport.Current() != "us" {
+		t.Errorf("current endpoint = %s, want us", transport.Current())
+	}
+	if len(bodies) != 1 || bodies[0] != `POST /gateway/v16.0/1/messages {"to":"255"}` {
+		t.Errorf("unexpected requests %q", bodies)
+	}
+
+	transport.check = func(ctx context.Context, endpoint *Endpoint) error { return nil }
+	transport.CheckHealth(context.TODO())
+	if _, ok := transport.pick(nil); !ok || transport.Current() != "us" {
+		t.Errorf("failover should stay on the sticky endpoint, got %s", transport.Current())
+	}
+
+	// a GET is idempotent, it fails over on the 502.
+	transport.current = 0
+	if code := send(http.MethodGet); code != http.StatusOK {
+		t.Fatalf("got status %d for the GET, want 200", code)
+	}
+	if atomic.LoadInt32(&failed) != 2 || len(bodies) != 2 || !strings.HasPrefix(bodies[1], "GET ") {
+		t.Errorf("got %d failed requests and requests %q, want the GET sent again", failed, bodies)
+	}
+}
+
+func TestFailoverTransport_DialError(t *testing.T) {
+	t.Parallel()
+	closed := httptest.NewServer(http.NotFoundHandler())
+	closed.Close()
+	var sent int
+	up := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		sent++
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer up.Close()
+
+	transport, err := NewFailoverTransport([]*Endpoint{
+		{Name: "eu", BaseURL: closed.URL},
+		{Name: "us", BaseURL: up.URL},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	request, _ := http.NewRequestWithContext(context.TODO(), http.MethodPost, "https://graph.facebook.com/messages",
+		strings.NewReader(`{"to":"255"}`))
+
+	response, err := transport.RoundTrip(request)
+	if err != nil {
+		t.Fatalf("RoundTrip() error = %v, want the POST sent through the second endpoint", err)
+	}
+	_ = response.Body.Close()
+	if sent != 1 || transport.Current() != "us" {
+		t.Errorf("got %d requests through %s, want 1 through us", sent, transport.Current())
+	}
+}
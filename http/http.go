@@ -29,6 +29,7 @@ import (
 	"net/http"
 	"net/url"
 	"strings"
+	"sync"
 
 	werrors "github.com/lowkruc/go-whatsapp-api/errors"
 )
@@ -181,17 +182,13 @@ func (request *Request) BodyBytes() ([]byte, error) {
 	if request.Payload == nil {
 		return nil, nil
 	}
-	body, err := request.ReaderFunc()()
+
+	body, err := encodePayload(request.Payload)
 	if err != nil {
 		return nil, fmt.Errorf("reader func: %v", err)
 	}
-	buf := new(bytes.Buffer)
-	_, err = buf.ReadFrom(body)
-	if err != nil {
-		return nil, fmt.Errorf("read from: %v", err)
-	}
 
-	return buf.Bytes(), nil
+	return body, nil
 }
 
 var ErrInvalidRequestValue = errors.New("invalid request value")
@@ -285,16 +282,61 @@ func extractRequestBody(payload interface{}) (io.Reader, error) {
 	case string:
 		return strings.NewReader(p), nil
 	default:
-		buf := &bytes.Buffer{}
-		err := json.NewEncoder(buf).Encode(p)
+		body, err := encodePayload(p)
 		if err != nil {
-			return nil, fmt.Errorf("failed to encode payload: %v", err)
+			return nil, err
 		}
 
-		return buf, nil
+		return bytes.NewReader(body), nil
 	}
 }
 
+// bufferPool holds the buffers used to encode json payloads. The encoded bytes are copied out of
+// the buffer, so a buffer is never referenced by a request after it is put back.
+var bufferPool = sync.Pool{ //nolint:gochecknoglobals
+	New: func() any { return new(bytes.Buffer) },
+}
+
+// maxPooledBufferSize is the capacity above which buffers are dropped instead of pooled, so that
+// a single large payload does not keep its memory around.
+const maxPooledBufferSize = 64 << 10
+
+// encodePayload returns the bytes of a payload supported by extractRequestBody.
+func encodePayload(payload any) ([]byte, error) {
+	switch p := payload.(type) {
+	case nil:
+		return nil, nil
+	case []byte:
+		return p, nil
+	case string:
+		return []byte(p), nil
+	case io.Reader:
+		body, err := io.ReadAll(p)
+		if err != nil {
+			return nil, fmt.Errorf("read from: %v", err)
+		}
+
+		return body, nil
+	}
+
+	buf := bufferPool.Get().(*bytes.Buffer) //nolint:forcetypeassert
+	buf.Reset()
+	defer func() {
+		if buf.Cap() <= maxPooledBufferSize {
+			bufferPool.Put(buf)
+		}
+	}()
+
+	if err := json.NewEncoder(buf).Encode(payload); err != nil {
+		return nil, fmt.Errorf("failed to encode payload: %v", err)
+	}
+
+	body := make([]byte, buf.Len())
+	copy(body, buf.Bytes())
+
+	return body, nil
+}
+
 // Do calls http.Client.Do after creating a *http.Request. It takes hooks that are executed after the request
 // is sent. Even when an error occurs, the hooks are executed. Except for the errors caused by NewRequestWithContext,
 // here Do terminates the execution and returns the error. Hooks passed here should always check for nil values of
@@ -304,14 +346,23 @@ func extractRequestBody(payload interface{}) (io.Reader, error) {
 //nolint:cyclop
 func Do(ctx context.Context, client *http.Client, r *Request, v any, hooks ...Hook) error {
 	ctx = withRequestName(ctx, r.Context.Name)
-	request, err := NewRequestWithContext(ctx, r)
+	// the payload is encoded once, the bytes are used for the request and to restore its body
+	// for the hooks.
+	reqBodyBytes, err := r.BodyBytes()
 	if err != nil {
 		return fmt.Errorf("http send: %v", err)
 	}
-	reqBodyBytes, err := r.BodyBytes()
+	encoded := *r
+	if encoded.Form == nil && encoded.Payload != nil {
+		encoded.Payload = reqBodyBytes
+	}
+	request, err := NewRequestWithContext(ctx, &encoded)
 	if err != nil {
 		return fmt.Errorf("http send: %v", err)
 	}
+	if v != nil && request.Header.Get("Accept-Encoding") == "" {
+		request.Header.Set("Accept-Encoding", AcceptEncoding)
+	}
 	response, err := client.Do(request)
 	if err != nil {
 		defer executeHooks(ctx, request, response, hooks)
@@ -337,6 +388,15 @@ func Do(ctx context.Context, client *http.Client, r *Request, v any, hooks ...Ho
 	}
 	bodyBytes := buff.Bytes()
 
+	if encoding := response.Header.Get("Content-Encoding"); IsEncoded(encoding) {
+		if bodyBytes, err = DecodeContentEncoding(encoding, bodyBytes, 0); err != nil {
+			return fmt.Errorf("http send: %v", err)
+		}
+		response.Header.Del("Content-Encoding")
+		response.Header.Del("Content-Length")
+		response.ContentLength = int64(len(bodyBytes))
+	}
+
 	// restore the response body
 	response.Body = io.NopCloser(bytes.NewBuffer(bodyBytes))
 
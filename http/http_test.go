@@ -426,3 +426,22 @@ func ExampleNewRequest() {
 
 	// Output: GET
 }
+
+func BenchmarkNewRequestWithContext(b *testing.B) {
+	request := &Request{
+		Context: &RequestContext{Name: "send message", BaseURL: BaseURL, ApiVersion: "v16.0", SenderID: "1",
+			Endpoints: []string{"messages"}},
+		Method:  http.MethodPost,
+		Headers: map[string]string{"Content-Type": "application/json"},
+		Bearer:  "token",
+		Payload: map[string]any{"messaging_product": "whatsapp", "to": "255700000000", "type": "text",
+			"text": map[string]any{"body": "Your order has shipped"}},
+	}
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := NewRequestWithContext(context.TODO(), request); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
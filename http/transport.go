@@ -0,0 +1,115 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package http
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+type (
+	// DialContextFunc dials a network connection, it has the signature of net.Dialer.DialContext.
+	DialContextFunc func(ctx context.Context, network, address string) (net.Conn, error)
+
+	// TransportConfig configures how requests leave the process. Proxy can be an http, https or
+	// socks5 URL, when it is nil the environment variables are used as by http.ProxyFromEnvironment.
+	// A proxy set on the request context with WithRequestProxy takes precedence.
+	//
+	// Dial replaces the dialer, Resolver is used by the default dialer and is ignored when Dial is set.
+	TransportConfig struct {
+		Proxy    *url.URL
+		Dial     DialContextFunc
+		Resolver *net.Resolver
+	}
+
+	requestProxyKey struct{}
+)
+
+// WithRequestProxy returns a context that makes the requests created with it go through proxy.
+// It only works with transports configured by ConfigureTransport.
+func WithRequestProxy(ctx context.Context, proxy *url.URL) context.Context {
+	return context.WithValue(ctx, requestProxyKey{}, proxy)
+}
+
+// RequestProxyFromContext returns the proxy set with WithRequestProxy.
+func RequestProxyFromContext(ctx context.Context) (*url.URL, bool) {
+	proxy, ok := ctx.Value(requestProxyKey{}).(*url.URL)
+
+	return proxy, ok && proxy != nil
+}
+
+// ConfigureTransport applies config to transport.
+func ConfigureTransport(transport *http.Transport, config *TransportConfig) {
+	if config == nil {
+		return
+	}
+
+	fallback := http.ProxyFromEnvironment
+	if config.Proxy != nil {
+		fallback = http.ProxyURL(config.Proxy)
+	}
+	transport.Proxy = func(request *http.Request) (*url.URL, error) {
+		if proxy, ok := RequestProxyFromContext(request.Context()); ok {
+			return proxy, nil
+		}
+
+		return fallback(request)
+	}
+
+	switch {
+	case config.Dial != nil:
+		transport.DialContext = config.Dial
+	case config.Resolver != nil:
+		dialer := &net.Dialer{
+			Timeout:   30 * time.Second, //nolint:gomnd
+			KeepAlive: 30 * time.Second, //nolint:gomnd
+			Resolver:  config.Resolver,
+		}
+		transport.DialContext = dialer.DialContext
+	}
+}
+
+// ConfigureClient returns a copy of client whose transport is configured with config. The
+// transport of client must be nil or an *http.Transport, it is cloned so the original client is
+// not changed. Other transports are returned unchanged with ok set to false.
+func ConfigureClient(client *http.Client, config *TransportConfig) (*http.Client, bool) {
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	var transport *http.Transport
+	switch t := client.Transport.(type) {
+	case nil:
+		transport = http.DefaultTransport.(*http.Transport).Clone() //nolint:forcetypeassert
+	case *http.Transport:
+		transport = t.Clone()
+	default:
+		return client, false
+	}
+
+	ConfigureTransport(transport, config)
+	configured := *client
+	configured.Transport = transport
+
+	return &configured, true
+}
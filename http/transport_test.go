@@ -0,0 +1,85 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package http
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func TestConfigureClient(t *testing.T) {
+	t.Parallel()
+	proxied := make(chan string, 2)
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// a proxy receives the absolute URL of the request.
+		proxied <- r.URL.String()
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer proxy.Close()
+
+	proxyURL, _ := url.Parse(proxy.URL)
+	dialed := make(chan string, 2)
+	var dialer net.Dialer
+	client, ok := ConfigureClient(nil, &TransportConfig{
+		Dial: func(ctx context.Context, network, address string) (net.Conn, error) {
+			dialed <- address
+
+			return dialer.DialContext(ctx, network, proxyURL.Host)
+		},
+	})
+	if !ok {
+		t.Fatal("ConfigureClient() did not configure the default client")
+	}
+
+	if client == http.DefaultClient || http.DefaultClient.Transport != nil {
+		t.Fatal("ConfigureClient() modified the default client")
+	}
+
+	// without a proxy the dialer sends the request to the test server directly.
+	request, _ := http.NewRequestWithContext(context.TODO(), http.MethodGet, "http://graph.invalid/v16.0/me", nil)
+	response, err := client.Do(request)
+	if err != nil {
+		t.Fatalf("Do() error = %v", err)
+	}
+	_ = response.Body.Close()
+	if address := <-dialed; address != "graph.invalid:80" {
+		t.Errorf("dialed %q, want graph.invalid:80", address)
+	}
+	<-proxied
+
+	// a per request proxy is dialed instead of the target host.
+	ctx := WithRequestProxy(context.TODO(), proxyURL)
+	request, _ = http.NewRequestWithContext(ctx, http.MethodGet, "http://graph.invalid/v16.0/me", nil)
+	response, err = client.Do(request)
+	if err != nil {
+		t.Fatalf("Do() with a request proxy error = %v", err)
+	}
+	_ = response.Body.Close()
+	if address := <-dialed; address != proxyURL.Host {
+		t.Errorf("dialed %q, want the proxy %q", address, proxyURL.Host)
+	}
+	if target := <-proxied; target != "http://graph.invalid/v16.0/me" {
+		t.Errorf("proxy received %q", target)
+	}
+}
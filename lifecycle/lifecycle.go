@@ -0,0 +1,233 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+// Package lifecycle is an event bus for the phases the long-running components go through:
+// Starting, Ready, Draining and Stopped. The listener, the client and the pollers emit their
+// phases on the Bus given to them, so that an application can start the components that depend
+// on others once they are ready, and report its health during rollouts.
+//
+// Example:
+//
+//	bus := lifecycle.NewBus()
+//	bus.Subscribe(func(event lifecycle.Event) {
+//		log.Printf("%s is %s", event.Component, event.Phase)
+//	})
+//	client := whatsapp.NewClient(whatsapp.WithLifecycle(bus), ...)
+//	listener := webhooks.NewEventListener(webhooks.WithLifecycle(bus), ...)
+//	http.Handle("/healthz", bus.HealthHandler())
+package lifecycle
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/lowkruc/go-whatsapp-api/clock"
+)
+
+// Phases of a component, in the order they happen.
+const (
+	Starting Phase = "starting"
+	Ready    Phase = "ready"
+	Draining Phase = "draining"
+	Stopped  Phase = "stopped"
+)
+
+type (
+	// Phase is a step of the life of a component.
+	Phase string
+
+	// Event is the change of phase of a component. Err is why it stopped, when it failed.
+	Event struct {
+		Component string
+		Phase     Phase
+		At        time.Time
+		Err       error
+	}
+
+	// Handler is called with the events of the Bus.
+	Handler func(event Event)
+
+	// Bus dispatches the events to the handlers and keeps the last phase of every component. A
+	// nil *Bus drops the events, so that the components emit without checking. It is safe for
+	// concurrent use.
+	Bus struct {
+		mu       sync.Mutex
+		clock    clock.Clock
+		next     int
+		handlers map[int]Handler
+		phases   map[string]Phase
+		changed  chan struct{}
+	}
+
+	Option func(*Bus)
+)
+
+// WithClock sets the clock the events are timed with, the real clock by default.
+func WithClock(c clock.Clock) Option {
+	return func(b *Bus) {
+		b.clock = c
+	}
+}
+
+// NewBus creates an empty Bus.
+func NewBus(options ...Option) *Bus {
+	b := &Bus{
+		clock:    clock.Real{},
+		handlers: make(map[int]Handler),
+		phases:   make(map[string]Phase),
+		changed:  make(chan struct{}),
+	}
+	for _, option := range options {
+		option(b)
+	}
+
+	return b
+}
+
+// order returns the position of the phase, 0 for unknown phases.
+func (p Phase) order() int {
+	switch p {
+	case Starting:
+		return 1
+	case Ready:
+		return 2
+	case Draining:
+		return 3
+	case Stopped:
+		return 4
+	default:
+		return 0
+	}
+}
+
+// Subscribe calls handler with every event emitted from now on, in the goroutine of the
+// component. The returned function unsubscribes it.
+func (b *Bus) Subscribe(handler Handler) func() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	id := b.next
+	b.next++
+	b.handlers[id] = handler
+
+	return func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		delete(b.handlers, id)
+	}
+}
+
+// Emit records the phase of the component and calls the handlers, in the order they subscribed.
+func (b *Bus) Emit(component string, phase Phase, err error) {
+	if b == nil {
+		return
+	}
+
+	b.mu.Lock()
+	event := Event{Component: component, Phase: phase, At: b.clock.Now(), Err: err}
+	b.phases[component] = phase
+	close(b.changed)
+	b.changed = make(chan struct{})
+	ids := make([]int, 0, len(b.handlers))
+	for id := range b.handlers {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	handlers := make([]Handler, len(ids))
+	for i, id := range ids {
+		handlers[i] = b.handlers[id]
+	}
+	b.mu.Unlock()
+
+	for _, handler := range handlers {
+		handler(event)
+	}
+}
+
+// Phase returns the last phase of the component, empty when it never emitted.
+func (b *Bus) Phase(component string) Phase {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.phases[component]
+}
+
+// Phases returns the last phase of every component.
+func (b *Bus) Phases() map[string]Phase {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	phases := make(map[string]Phase, len(b.phases))
+	for component, phase := range b.phases {
+		phases[component] = phase
+	}
+
+	return phases
+}
+
+// Ready reports whether all the components are Ready, false when there is none.
+func (b *Bus) Ready() bool {
+	phases := b.Phases()
+	for _, phase := range phases {
+		if phase != Ready {
+			return false
+		}
+	}
+
+	return len(phases) > 0
+}
+
+// Wait waits for the component to reach the phase, or a later one, or for ctx to be done.
+func (b *Bus) Wait(ctx context.Context, component string, phase Phase) error {
+	for {
+		b.mu.Lock()
+		reached := b.phases[component].order() >= phase.order()
+		changed := b.changed
+		b.mu.Unlock()
+
+		if reached {
+			return nil
+		}
+
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// HealthHandler returns a http.Handler that answers 200 when all the components are Ready and 503
+// otherwise, with the phases of the components as JSON.
+func (b *Bus) HealthHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		code := http.StatusOK
+		if !b.Ready() {
+			code = http.StatusServiceUnavailable
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(code)
+		_ = json.NewEncoder(w).Encode(b.Phases())
+	})
+}
@@ -0,0 +1,107 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/lowkruc/go-whatsapp-api/clock"
+)
+
+func TestBus(t *testing.T) {
+	t.Parallel()
+	now := time.Date(2023, 5, 1, 0, 0, 0, 0, time.UTC)
+	bus := NewBus(WithClock(clock.NewFake(now)))
+
+	var first, second []Event
+	bus.Subscribe(func(event Event) { first = append(first, event) })
+	unsubscribe := bus.Subscribe(func(event Event) { second = append(second, event) })
+
+	bus.Emit("listener", Starting, nil)
+	unsubscribe()
+	bus.Emit("listener", Ready, nil)
+
+	if len(first) != 2 || first[0].Phase != Starting || first[1].Phase != Ready || !first[1].At.Equal(now) {
+		t.Errorf("first handler got %+v", first)
+	}
+	if len(second) != 1 || second[0].Component != "listener" {
+		t.Errorf("unsubscribed handler got %+v", second)
+	}
+
+	var nilBus *Bus
+	nilBus.Emit("listener", Stopped, nil)
+}
+
+func TestBus_Wait(t *testing.T) {
+	t.Parallel()
+	bus := NewBus()
+
+	done := make(chan error, 1)
+	go func() { done <- bus.Wait(context.Background(), "sender", Ready) }()
+
+	bus.Emit("sender", Starting, nil)
+	bus.Emit("sender", Draining, nil)
+	if err := <-done; err != nil {
+		t.Fatalf("Wait() error = %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := bus.Wait(ctx, "listener", Starting); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Wait() error = %v, want %v", err, context.DeadlineExceeded)
+	}
+}
+
+func TestBus_HealthHandler(t *testing.T) {
+	t.Parallel()
+	bus := NewBus()
+	handler := bus.HealthHandler()
+	get := func() int {
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+
+		return recorder.Code
+	}
+
+	if code := get(); code != http.StatusServiceUnavailable {
+		t.Errorf("no component: got %d, want %d", code, http.StatusServiceUnavailable)
+	}
+
+	bus.Emit("listener", Ready, nil)
+	bus.Emit("sender", Starting, nil)
+	if code := get(); code != http.StatusServiceUnavailable {
+		t.Errorf("sender starting: got %d, want %d", code, http.StatusServiceUnavailable)
+	}
+
+	bus.Emit("sender", Ready, nil)
+	if code := get(); code != http.StatusOK || !bus.Ready() {
+		t.Errorf("all ready: got %d, want %d", code, http.StatusOK)
+	}
+
+	bus.Emit("listener", Draining, nil)
+	if code := get(); code != http.StatusServiceUnavailable {
+		t.Errorf("listener draining: got %d, want %d", code, http.StatusServiceUnavailable)
+	}
+}
@@ -0,0 +1,86 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package whatsapp
+
+import (
+	"context"
+	"errors"
+	"strings"
+)
+
+var ErrMarketingOptedOut = errors.New("recipient opted out of marketing messages")
+
+// OptOutChecker reports whether a user stopped marketing messages, webhooks.MarketingOptOuts
+// implements it from the user_preferences webhooks.
+type OptOutChecker interface {
+	OptedOut(waID string) bool
+}
+
+// SpendGuard decides whether a marketing message can be sent to a recipient, billing.Budget
+// implements it to cap the marketing conversation spend per recipient.
+type SpendGuard interface {
+	AllowMarketing(ctx context.Context, recipient string) error
+}
+
+// WithMarketingOptOuts makes SendMarketingTemplate skip the recipients that opted out of
+// marketing messages.
+func WithMarketingOptOuts(checker OptOutChecker) ClientOption {
+	return func(client *Client) {
+		client.optOuts = checker
+	}
+}
+
+// WithSpendGuard makes SendMarketingTemplate skip the recipients guard does not allow, the error of
+// the guard is returned as is, a *billing.BudgetExceededError for a billing.Budget.
+func WithSpendGuard(guard SpendGuard) ClientOption {
+	return func(client *Client) {
+		client.spendGuard = guard
+	}
+}
+
+// SendMarketingTemplate sends a marketing template like SendTemplate, unless the recipient opted out
+// of marketing messages, in which case nothing is sent and ErrMarketingOptedOut is returned, or
+// the SpendGuard of the client does not allow it, in which case its error is returned. Both are
+// returned as is, so that errors.Is and errors.As match them.
+func (client *Client) SendMarketingTemplate(ctx context.Context, recipient string, req *Template) (
+	*ResponseMessage, error,
+) {
+	if client.optOuts != nil && client.optOuts.OptedOut(waID(recipient)) {
+		return nil, ErrMarketingOptedOut
+	}
+	if client.spendGuard != nil {
+		if err := client.spendGuard.AllowMarketing(ctx, waID(recipient)); err != nil {
+			return nil, err
+		}
+	}
+
+	return client.SendTemplate(ctx, recipient, req)
+}
+
+// waID returns the WhatsApp ID of a recipient phone number, its digits.
+func waID(recipient string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= '0' && r <= '9' {
+			return r
+		}
+
+		return -1
+	}, recipient)
+}
@@ -0,0 +1,95 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package whatsapp
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/lowkruc/go-whatsapp-api/billing"
+	"github.com/lowkruc/go-whatsapp-api/webhooks"
+)
+
+func TestClient_SendMarketingTemplate(t *testing.T) {
+	t.Parallel()
+	var sent int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		sent++
+		_, _ = w.Write([]byte(`{"messaging_product":"whatsapp","messages":[{"id":"wamid.1"}]}`))
+	}))
+	defer server.Close()
+
+	client := NewClient(WithBaseURL(server.URL), WithVersion("v16.0"), WithPhoneNumberID("1000"),
+		WithMarketingOptOuts(webhooks.NewMarketingOptOuts("16505551234")))
+	template := &Template{Name: "spring_sale", LanguageCode: "en_US"}
+
+	_, err := client.SendMarketingTemplate(context.TODO(), "+1 650 555 1234", template)
+	if !errors.Is(err, ErrMarketingOptedOut) {
+		t.Fatalf("SendMarketingTemplate() error = %v, want %v", err, ErrMarketingOptedOut)
+	}
+
+	if _, err := client.SendMarketingTemplate(context.TODO(), "16505550000", template); err != nil {
+		t.Fatalf("SendMarketingTemplate() error = %v", err)
+	}
+
+	if sent != 1 {
+		t.Errorf("got %d requests, want 1", sent)
+	}
+}
+
+func TestClient_SendMarketingTemplateBudget(t *testing.T) {
+	t.Parallel()
+	var sent int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		sent++
+		_, _ = w.Write([]byte(`{"messaging_product":"whatsapp","messages":[{"id":"wamid.1"}]}`))
+	}))
+	defer server.Close()
+
+	rate, _ := webhooks.ParseAmount("0.05")
+	budget := billing.NewBudget(webhooks.Amount{}, billing.FlatRate(rate))
+	client := NewClient(WithBaseURL(server.URL), WithVersion("v16.0"), WithPhoneNumberID("1000"),
+		WithSpendGuard(budget))
+	template := &Template{Name: "spring_sale", LanguageCode: "en_US"}
+
+	_, err := client.SendMarketingTemplate(context.TODO(), "+1 650 555 1234", template)
+	var exceeded *billing.BudgetExceededError
+	if !errors.As(err, &exceeded) || exceeded.Recipient != "16505551234" || exceeded.RetryAt.IsZero() {
+		t.Fatalf("SendMarketingTemplate() error = %v, want a *billing.BudgetExceededError", err)
+	}
+
+	ctx := billing.WithTransactional(context.TODO())
+	if _, err := client.SendMarketingTemplate(ctx, "+1 650 555 1234", template); err != nil {
+		t.Fatalf("SendMarketingTemplate() transactional error = %v", err)
+	}
+
+	budget.Override("16505551234", time.Now().Add(time.Hour))
+	if _, err := client.SendMarketingTemplate(context.TODO(), "+1 650 555 1234", template); err != nil {
+		t.Fatalf("SendMarketingTemplate() with an override error = %v", err)
+	}
+
+	if sent != 2 {
+		t.Errorf("got %d requests, want 2", sent)
+	}
+}
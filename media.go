@@ -30,7 +30,9 @@ import (
 	"net/http"
 	"net/textproto"
 	"path/filepath"
+	"strconv"
 
+	"github.com/lowkruc/go-whatsapp-api/audit"
 	whttp "github.com/lowkruc/go-whatsapp-api/http"
 )
 
@@ -198,6 +200,12 @@ func (client *Client) DownloadMedia(ctx context.Context, mediaID string, retries
 		// retry ...
 		if resp.StatusCode == http.StatusNotFound {
 			_ = resp.Body.Close()
+			if i < retries {
+				audit.Record(ctx, client.audit, audit.SendRetried, nil, map[string]string{
+					"request": "download media",
+					"attempt": strconv.Itoa(i + 2), //nolint:gomnd
+				})
+			}
 
 			continue
 		}
@@ -224,7 +232,10 @@ func (client *Client) DownloadMedia(ctx context.Context, mediaID string, retries
 		}, nil
 	}
 
-	return nil, fmt.Errorf("%v: retries exceeded", ErrMediaDownload)
+	err := fmt.Errorf("%v: retries exceeded", ErrMediaDownload)
+	client.reportError(ctx, err, "download_media")
+
+	return nil, err
 }
 
 // uploadMediaPayload creates upload media request payload.
@@ -0,0 +1,361 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+// Package mediastore keeps the IDs of frequently sent media, like stickers and brand images, so
+// that they are uploaded once and sent by ID. Media IDs expire 30 days after the upload, a
+// Registry remembers when each asset was uploaded and uploads it again when its ID is about to
+// expire at send time. Refresh, or Run in the background, uploads the assets ahead of their
+// expiry so that the media IDs referenced by templates can be updated in time.
+//
+// Example:
+//
+//	registry := mediastore.NewRegistry(client, mediastore.NewMemoryStore())
+//	registry.Register(mediastore.StickerPack("brand", map[string]string{
+//		"hello":  "stickers/hello.webp",
+//		"thanks": "stickers/thanks.webp",
+//	})...)
+//	_, err := registry.Send(ctx, "255700000001", "brand/thanks", "")
+package mediastore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	whatsapp "github.com/lowkruc/go-whatsapp-api"
+	"github.com/lowkruc/go-whatsapp-api/clock"
+)
+
+const (
+	// DefaultExpiry is how long a media ID stays valid after the upload.
+	DefaultExpiry = 30 * 24 * time.Hour
+
+	// DefaultMargin is how long before the expiry an asset is uploaded again.
+	DefaultMargin = 24 * time.Hour
+)
+
+var (
+	ErrUnknownAsset = errors.New("unknown asset")
+	ErrInvalidAsset = errors.New("invalid asset")
+)
+
+type (
+	// Asset is a media file sent often. Name identifies it in the Registry and Open returns its
+	// content each time it is uploaded.
+	Asset struct {
+		Name     string
+		Type     whatsapp.MediaType
+		Filename string
+		Open     func(ctx context.Context) (io.ReadCloser, error)
+	}
+
+	// Entry is the media ID of an asset and when it was uploaded.
+	Entry struct {
+		Name       string    `json:"name"`
+		MediaID    string    `json:"media_id"`
+		UploadedAt time.Time `json:"uploaded_at"`
+		ExpiresAt  time.Time `json:"expires_at"`
+	}
+
+	// Store persists the entries so that the media IDs survive restarts. Get returns nil and no
+	// error when there is no entry for the name. Implementations must be safe for concurrent use.
+	Store interface {
+		Get(ctx context.Context, name string) (*Entry, error)
+		Put(ctx context.Context, entry *Entry) error
+	}
+
+	// MemoryStore is an in memory Store.
+	MemoryStore struct {
+		mu      sync.RWMutex
+		entries map[string]*Entry
+	}
+
+	// Client uploads and sends media, *whatsapp.Client implements it.
+	Client interface {
+		UploadMedia(ctx context.Context, mediaType whatsapp.MediaType, filename string,
+			fr io.Reader) (*whatsapp.UploadMediaResponse, error)
+		SendMedia(ctx context.Context, recipient string, req *whatsapp.MediaMessage,
+			cacheOptions *whatsapp.CacheOptions) (*whatsapp.ResponseMessage, error)
+	}
+
+	// Registry uploads the registered assets when needed and sends them by ID. It is safe for
+	// concurrent use.
+	Registry struct {
+		mu     sync.RWMutex
+		client Client
+		store  Store
+		clock  clock.Clock
+		expiry time.Duration
+		margin time.Duration
+		assets map[string]*Asset
+		ahead  time.Duration
+		refs   map[string]map[string]struct{}
+		notify OnRefreshFunc
+
+		// uploads serializes the uploads so that an asset is not uploaded twice concurrently.
+		uploads sync.Mutex
+	}
+
+	RegistryOption func(*Registry)
+)
+
+// NewMemoryStore creates an empty MemoryStore.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{entries: make(map[string]*Entry)}
+}
+
+func (s *MemoryStore) Get(_ context.Context, name string) (*Entry, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	entry, ok := s.entries[name]
+	if !ok {
+		return nil, nil
+	}
+	clone := *entry
+
+	return &clone, nil
+}
+
+func (s *MemoryStore) Put(_ context.Context, entry *Entry) error {
+	if entry == nil {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	clone := *entry
+	s.entries[entry.Name] = &clone
+
+	return nil
+}
+
+// File returns an asset read from the file at filePath.
+func File(name string, mediaType whatsapp.MediaType, filePath string) *Asset {
+	return &Asset{
+		Name:     name,
+		Type:     mediaType,
+		Filename: filepath.Base(filePath),
+		Open: func(ctx context.Context) (io.ReadCloser, error) {
+			return os.Open(filePath)
+		},
+	}
+}
+
+// StickerPack returns the sticker assets of a pack, named "pack/sticker". stickers maps the
+// sticker names to the paths of their webp files.
+func StickerPack(pack string, stickers map[string]string) []*Asset {
+	names := make([]string, 0, len(stickers))
+	for name := range stickers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	assets := make([]*Asset, 0, len(names))
+	for _, name := range names {
+		assets = append(assets, File(path.Join(pack, name), whatsapp.MediaTypeSticker, stickers[name]))
+	}
+
+	return assets
+}
+
+// WithClock sets the clock used to tell when the media IDs expire, the default is clock.Real.
+func WithClock(c clock.Clock) RegistryOption {
+	return func(r *Registry) {
+		r.clock = c
+	}
+}
+
+// WithExpiry sets how long a media ID stays valid after the upload, DefaultExpiry by default.
+func WithExpiry(expiry time.Duration) RegistryOption {
+	return func(r *Registry) {
+		if expiry > 0 {
+			r.expiry = expiry
+		}
+	}
+}
+
+// WithMargin sets how long before the expiry an asset is uploaded again, DefaultMargin by default.
+func WithMargin(margin time.Duration) RegistryOption {
+	return func(r *Registry) {
+		if margin >= 0 {
+			r.margin = margin
+		}
+	}
+}
+
+// NewRegistry creates a Registry that uploads the assets with client and keeps their media IDs
+// in store.
+func NewRegistry(client Client, store Store, options ...RegistryOption) *Registry {
+	r := &Registry{
+		client: client,
+		store:  store,
+		clock:  clock.Real{},
+		expiry: DefaultExpiry,
+		margin: DefaultMargin,
+		assets: make(map[string]*Asset),
+		ahead:  DefaultRefreshAhead,
+		refs:   make(map[string]map[string]struct{}),
+	}
+	for _, option := range options {
+		option(r)
+	}
+
+	return r
+}
+
+// Register adds the assets to the registry, replacing the assets with the same names. The
+// assets are uploaded the first time they are needed.
+func (r *Registry) Register(assets ...*Asset) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, asset := range assets {
+		if asset != nil {
+			r.assets[asset.Name] = asset
+		}
+	}
+}
+
+// Assets returns the names of the registered assets, sorted.
+func (r *Registry) Assets() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	names := make([]string, 0, len(r.assets))
+	for name := range r.assets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// MediaID returns a valid media ID for the asset, uploading it when it was never uploaded or
+// its media ID expires within the margin.
+func (r *Registry) MediaID(ctx context.Context, name string) (string, error) {
+	asset, err := r.asset(name)
+	if err != nil {
+		return "", err
+	}
+
+	entry, err := r.store.Get(ctx, name)
+	if err != nil {
+		return "", fmt.Errorf("%v: %v", name, err)
+	}
+	if r.fresh(entry) {
+		return entry.MediaID, nil
+	}
+
+	r.uploads.Lock()
+	defer r.uploads.Unlock()
+
+	// another call may have uploaded the asset while this one waited.
+	if entry, err = r.store.Get(ctx, name); err != nil {
+		return "", fmt.Errorf("%v: %v", name, err)
+	}
+	if r.fresh(entry) {
+		return entry.MediaID, nil
+	}
+
+	if entry, err = r.upload(ctx, asset, entry); err != nil {
+		return "", err
+	}
+
+	return entry.MediaID, nil
+}
+
+// Send sends the asset to the recipient, uploading it first if needed. The caption is ignored
+// for stickers and audio, which cannot have one.
+func (r *Registry) Send(ctx context.Context, recipient, name, caption string) (*whatsapp.ResponseMessage, error) {
+	asset, err := r.asset(name)
+	if err != nil {
+		return nil, err
+	}
+	mediaID, err := r.MediaID(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+
+	message := &whatsapp.MediaMessage{Type: asset.Type, MediaID: mediaID}
+	if asset.Type != whatsapp.MediaTypeSticker && asset.Type != whatsapp.MediaTypeAudio {
+		message.Caption = caption
+	}
+	if asset.Type == whatsapp.MediaTypeDocument {
+		message.Filename = asset.Filename
+	}
+
+	return r.client.SendMedia(ctx, recipient, message, nil)
+}
+
+func (r *Registry) asset(name string) (*Asset, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	asset, ok := r.assets[name]
+	if !ok {
+		return nil, fmt.Errorf("%v: %s", ErrUnknownAsset, name)
+	}
+
+	return asset, nil
+}
+
+func (r *Registry) fresh(entry *Entry) bool {
+	return entry != nil && entry.MediaID != "" && r.clock.Now().Add(r.margin).Before(entry.ExpiresAt)
+}
+
+func (r *Registry) upload(ctx context.Context, asset *Asset, previous *Entry) (*Entry, error) {
+	if asset.Open == nil {
+		return nil, fmt.Errorf("%v: %s: no content", ErrInvalidAsset, asset.Name)
+	}
+	content, err := asset.Open(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%v: open: %v", asset.Name, err)
+	}
+	defer content.Close()
+
+	now := r.clock.Now()
+	resp, err := r.client.UploadMedia(ctx, asset.Type, asset.Filename, content)
+	if err != nil {
+		return nil, fmt.Errorf("%v: %v", asset.Name, err)
+	}
+
+	entry := &Entry{
+		Name:       asset.Name,
+		MediaID:    resp.ID,
+		UploadedAt: now,
+		ExpiresAt:  now.Add(r.expiry),
+	}
+	if err = r.store.Put(ctx, entry); err != nil {
+		return nil, fmt.Errorf("%v: %v", asset.Name, err)
+	}
+
+	if r.notify != nil && previous != nil && previous.MediaID != entry.MediaID {
+		r.notify(ctx, &Refresh{
+			Name:      asset.Name,
+			Previous:  previous,
+			Current:   entry,
+			Templates: r.References(asset.Name),
+		})
+	}
+
+	return entry, nil
+}
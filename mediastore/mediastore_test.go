@@ -0,0 +1,121 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package mediastore
+
+import (
+	"context"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	whatsapp "github.com/lowkruc/go-whatsapp-api"
+	"github.com/lowkruc/go-whatsapp-api/clock"
+)
+
+type fakeClient struct {
+	mu       sync.Mutex
+	uploads  []string
+	messages []*whatsapp.MediaMessage
+}
+
+func (c *fakeClient) UploadMedia(ctx context.Context, mediaType whatsapp.MediaType, filename string,
+	fr io.Reader,
+) (*whatsapp.UploadMediaResponse, error) {
+	content, _ := io.ReadAll(fr)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.uploads = append(c.uploads, string(content))
+
+	return &whatsapp.UploadMediaResponse{ID: "media." + strconv.Itoa(len(c.uploads))}, nil
+}
+
+func (c *fakeClient) SendMedia(ctx context.Context, recipient string, req *whatsapp.MediaMessage,
+	cacheOptions *whatsapp.CacheOptions,
+) (*whatsapp.ResponseMessage, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.messages = append(c.messages, req)
+
+	return &whatsapp.ResponseMessage{}, nil
+}
+
+func sticker(name, content string) *Asset {
+	return &Asset{
+		Name:     name,
+		Type:     whatsapp.MediaTypeSticker,
+		Filename: name + ".webp",
+		Open: func(ctx context.Context) (io.ReadCloser, error) {
+			return io.NopCloser(strings.NewReader(content)), nil
+		},
+	}
+}
+
+func TestRegistry_Send(t *testing.T) {
+	t.Parallel()
+	fake := clock.NewFake(time.Date(2023, 1, 1, 9, 0, 0, 0, time.UTC))
+	client := &fakeClient{}
+	store := NewMemoryStore()
+	registry := NewRegistry(client, store, WithClock(fake))
+	registry.Register(sticker("brand/hello", "hello"))
+
+	ctx := context.TODO()
+	for i := 0; i < 2; i++ {
+		if _, err := registry.Send(ctx, "255700000001", "brand/hello", "ignored"); err != nil {
+			t.Fatalf("Send() error = %v", err)
+		}
+	}
+	if len(client.uploads) != 1 {
+		t.Fatalf("got %d uploads, want 1", len(client.uploads))
+	}
+	if got := client.messages[1]; got.MediaID != "media.1" || got.Caption != "" || got.Type != whatsapp.MediaTypeSticker {
+		t.Errorf("got message %+v, want sticker media.1 without caption", got)
+	}
+
+	// the media ID is uploaded again a day before it expires.
+	fake.Advance(DefaultExpiry - DefaultMargin)
+	mediaID, err := registry.MediaID(ctx, "brand/hello")
+	if err != nil {
+		t.Fatalf("MediaID() error = %v", err)
+	}
+	if mediaID != "media.2" || len(client.uploads) != 2 {
+		t.Errorf("got media ID %q after %d uploads, want media.2 after 2", mediaID, len(client.uploads))
+	}
+
+	entry, _ := store.Get(ctx, "brand/hello")
+	if want := fake.Now().Add(DefaultExpiry); !entry.ExpiresAt.Equal(want) {
+		t.Errorf("got expiry %v, want %v", entry.ExpiresAt, want)
+	}
+
+	if _, err = registry.Send(ctx, "255700000001", "brand/missing", ""); err == nil {
+		t.Error("Send() of an unknown asset returned no error")
+	}
+}
+
+func TestStickerPack(t *testing.T) {
+	t.Parallel()
+	assets := StickerPack("brand", map[string]string{"thanks": "stickers/thanks.webp", "hello": "stickers/hello.webp"})
+	if len(assets) != 2 || assets[0].Name != "brand/hello" || assets[1].Filename != "thanks.webp" ||
+		assets[1].Type != whatsapp.MediaTypeSticker {
+		t.Errorf("got assets %+v, %+v", assets[0], assets[1])
+	}
+}
@@ -0,0 +1,203 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package mediastore
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// DefaultRefreshAhead is how long before the expiry Refresh uploads an asset again.
+const DefaultRefreshAhead = 3 * 24 * time.Hour
+
+type (
+	// Refresh describes an asset uploaded again, Previous is the expired or expiring entry and
+	// Current the new one. Templates are the templates referencing the asset, recorded with
+	// Registry.Reference, whose media ID must be updated.
+	Refresh struct {
+		Name      string
+		Previous  *Entry
+		Current   *Entry
+		Templates []string
+	}
+
+	// OnRefreshFunc is called when an asset is uploaded again and its media ID changes, either by
+	// Refresh or at send time.
+	OnRefreshFunc func(ctx context.Context, refresh *Refresh)
+)
+
+// WithRefreshAhead sets how long before the expiry Refresh uploads an asset again,
+// DefaultRefreshAhead by default. It should be longer than the margin and the interval of Run.
+func WithRefreshAhead(ahead time.Duration) RegistryOption {
+	return func(r *Registry) {
+		if ahead > 0 {
+			r.ahead = ahead
+		}
+	}
+}
+
+// WithOnRefresh sets the function called when the media ID of an asset changes.
+func WithOnRefresh(fn OnRefreshFunc) RegistryOption {
+	return func(r *Registry) {
+		r.notify = fn
+	}
+}
+
+// Track registers an asset that was uploaded outside the registry, for example the header of a
+// template, with its media ID and upload time so that its expiry is tracked.
+func (r *Registry) Track(ctx context.Context, asset *Asset, mediaID string, uploadedAt time.Time) error {
+	if asset == nil || mediaID == "" {
+		return fmt.Errorf("%v: no asset or media ID", ErrInvalidAsset)
+	}
+	r.Register(asset)
+
+	entry := &Entry{
+		Name:       asset.Name,
+		MediaID:    mediaID,
+		UploadedAt: uploadedAt,
+		ExpiresAt:  uploadedAt.Add(r.expiry),
+	}
+	if err := r.store.Put(ctx, entry); err != nil {
+		return fmt.Errorf("%v: %v", asset.Name, err)
+	}
+
+	return nil
+}
+
+// Reference records that the templates use the media ID of the asset.
+func (r *Registry) Reference(name string, templates ...string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	refs, ok := r.refs[name]
+	if !ok {
+		refs = make(map[string]struct{})
+		r.refs[name] = refs
+	}
+	for _, template := range templates {
+		refs[template] = struct{}{}
+	}
+}
+
+// References returns the templates referencing the asset, sorted.
+func (r *Registry) References(name string) []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	templates := make([]string, 0, len(r.refs[name]))
+	for template := range r.refs[name] {
+		templates = append(templates, template)
+	}
+	sort.Strings(templates)
+
+	return templates
+}
+
+// Expiring returns the entries of the registered assets that expire within d, the soonest first.
+// The assets never uploaded are not included.
+func (r *Registry) Expiring(ctx context.Context, d time.Duration) ([]*Entry, error) {
+	deadline := r.clock.Now().Add(d)
+	var entries []*Entry
+	for _, name := range r.Assets() {
+		entry, err := r.store.Get(ctx, name)
+		if err != nil {
+			return nil, fmt.Errorf("%v: %v", name, err)
+		}
+		if entry != nil && entry.ExpiresAt.Before(deadline) {
+			entries = append(entries, entry)
+		}
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].ExpiresAt.Before(entries[j].ExpiresAt)
+	})
+
+	return entries, nil
+}
+
+// Refresh uploads again the assets whose media IDs expire within the refresh ahead duration and
+// returns how many were uploaded. An asset that fails does not stop the others, the first error
+// is returned.
+func (r *Registry) Refresh(ctx context.Context) (int, error) {
+	expiring, err := r.Expiring(ctx, r.ahead)
+	if err != nil {
+		return 0, err
+	}
+
+	var (
+		refreshed int
+		first     error
+	)
+	for _, entry := range expiring {
+		uploaded, rerr := r.refresh(ctx, entry.Name)
+		if rerr != nil && first == nil {
+			first = rerr
+		}
+		if uploaded {
+			refreshed++
+		}
+	}
+
+	return refreshed, first
+}
+
+func (r *Registry) refresh(ctx context.Context, name string) (bool, error) {
+	asset, err := r.asset(name)
+	if err != nil {
+		return false, err
+	}
+
+	r.uploads.Lock()
+	defer r.uploads.Unlock()
+
+	// the asset may have been uploaded at send time in the meantime.
+	entry, err := r.store.Get(ctx, name)
+	if err != nil {
+		return false, fmt.Errorf("%v: %v", name, err)
+	}
+	if entry != nil && !entry.ExpiresAt.Before(r.clock.Now().Add(r.ahead)) {
+		return false, nil
+	}
+	if _, err = r.upload(ctx, asset, entry); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// Run calls Refresh right away and then every interval until the context is done. Refresh errors
+// are passed to onError when it is not nil and do not stop the refresh.
+func (r *Registry) Run(ctx context.Context, interval time.Duration, onError ...func(err error)) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := r.Refresh(ctx); err != nil && ctx.Err() == nil {
+			for _, fn := range onError {
+				fn(err)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
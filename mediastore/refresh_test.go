@@ -0,0 +1,78 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package mediastore
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/lowkruc/go-whatsapp-api/clock"
+)
+
+func TestRegistry_Refresh(t *testing.T) {
+	t.Parallel()
+	start := time.Date(2023, 1, 1, 9, 0, 0, 0, time.UTC)
+	fake := clock.NewFake(start)
+	client := &fakeClient{}
+	var refreshes []*Refresh
+	registry := NewRegistry(client, NewMemoryStore(), WithClock(fake),
+		WithOnRefresh(func(ctx context.Context, refresh *Refresh) {
+			refreshes = append(refreshes, refresh)
+		}))
+
+	ctx := context.TODO()
+	if err := registry.Track(ctx, sticker("banner", "banner"), "media.header", start); err != nil {
+		t.Fatalf("Track() error = %v", err)
+	}
+	registry.Register(sticker("hello", "hello"))
+	registry.Reference("banner", "spring_sale", "welcome")
+
+	// nothing expires within the refresh ahead duration yet.
+	if n, err := registry.Refresh(ctx); n != 0 || err != nil {
+		t.Fatalf("Refresh() = %d, %v, want 0, nil", n, err)
+	}
+
+	fake.Advance(DefaultExpiry - DefaultRefreshAhead + time.Hour)
+	expiring, err := registry.Expiring(ctx, DefaultRefreshAhead)
+	if err != nil || len(expiring) != 1 || expiring[0].MediaID != "media.header" {
+		t.Fatalf("Expiring() = %v, %v, want the banner entry", expiring, err)
+	}
+
+	if n, err := registry.Refresh(ctx); n != 1 || err != nil {
+		t.Fatalf("Refresh() = %d, %v, want 1, nil", n, err)
+	}
+	if len(client.uploads) != 1 || client.uploads[0] != "banner" {
+		t.Errorf("got uploads %v, want [banner]", client.uploads)
+	}
+
+	if len(refreshes) != 1 {
+		t.Fatalf("got %d refreshes, want 1", len(refreshes))
+	}
+	refresh := refreshes[0]
+	if refresh.Previous.MediaID != "media.header" || refresh.Current.MediaID != "media.1" ||
+		!reflect.DeepEqual(refresh.Templates, []string{"spring_sale", "welcome"}) {
+		t.Errorf("got refresh %+v", refresh)
+	}
+	if !refresh.Current.UploadedAt.Equal(fake.Now()) {
+		t.Errorf("got upload time %v, want %v", refresh.Current.UploadedAt, fake.Now())
+	}
+}
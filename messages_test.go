@@ -18,3 +18,63 @@
  */
 
 package whatsapp
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/lowkruc/go-whatsapp-api/models"
+)
+
+func TestClient_SendText(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name    string
+		options []models.MessageOption
+		want    string
+	}{
+		{
+			name: "text",
+			want: `{"messaging_product":"whatsapp","to":"16505551234","recipient_type":"individual",` +
+				`"type":"text","text":{"body":"see https://example.com"}}`,
+		},
+		{
+			name:    "preview and reply",
+			options: []models.MessageOption{models.WithPreviewURL(), models.WithReplyContext("wamid.1")},
+			want: `{"messaging_product":"whatsapp","to":"16505551234","recipient_type":"individual",` +
+				`"type":"text","context":{"message_id":"wamid.1"},` +
+				`"text":{"preview_url":true,"body":"see https://example.com"}}`,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			var got json.RawMessage
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != "/v16.0/1000/messages" {
+					t.Errorf("got path %s, want /v16.0/1000/messages", r.URL.Path)
+				}
+				_ = json.NewDecoder(r.Body).Decode(&got)
+				_, _ = w.Write([]byte(`{"messaging_product":"whatsapp","messages":[{"id":"wamid.2"}]}`))
+			}))
+			defer server.Close()
+
+			client := NewClient(WithBaseURL(server.URL), WithVersion("v16.0"), WithPhoneNumberID("1000"))
+			resp, err := client.SendText(context.TODO(), "16505551234", "see https://example.com", tt.options...)
+			if err != nil {
+				t.Fatalf("SendText() error = %v", err)
+			}
+			if len(resp.Messages) != 1 || resp.Messages[0].ID != "wamid.2" {
+				t.Errorf("got response %+v, want wamid.2", resp)
+			}
+			if string(got) != tt.want {
+				t.Errorf("got payload %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
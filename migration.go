@@ -0,0 +1,229 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package whatsapp
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	whttp "github.com/lowkruc/go-whatsapp-api/http"
+)
+
+// OnPremisesAPIVersion is the path prefix used by all the On-Premises API endpoints.
+const OnPremisesAPIVersion = "v1"
+
+type (
+	// Backup contains the settings exported from an On-Premises API client. Data is the encrypted
+	// backup blob and Password is the password that was used to encrypt it.
+	Backup struct {
+		Data     string `json:"data"`
+		Password string `json:"password"`
+	}
+
+	// RegisterRequest contains the parameters used to register a phone number for use with the
+	// Cloud API.
+	//
+	// PIN is the 6-digit two-step verification pin. If the number has two-step verification enabled
+	// it must match the existing pin, otherwise it becomes the new pin.
+	//
+	// Backup is only needed when migrating a number from the On-Premises API, it carries the settings
+	// exported with BackupOnPremisesSettings so that the existing certificate and settings are kept.
+	RegisterRequest struct {
+		MessagingProduct string  `json:"messaging_product"`
+		PIN              string  `json:"pin"`
+		Backup           *Backup `json:"backup,omitempty"`
+	}
+
+	// OnPremisesRequest contains the details needed to talk to an On-Premises API client.
+	// BaseURL is the address of the client e.g. https://wa-onprem.example.com and AccessToken
+	// is the token returned by the client login endpoint.
+	OnPremisesRequest struct {
+		BaseURL     string
+		AccessToken string
+	}
+
+	onPremisesBackupResponse struct {
+		Settings *struct {
+			Data string `json:"data"`
+		} `json:"settings,omitempty"`
+	}
+)
+
+// Register registers the phone number set on the client for use with the Cloud API.
+//
+// The equivalent curl command is:
+//
+//	curl -X POST 'https://graph.facebook.com/v16.0/FROM_PHONE_NUMBER_ID/register' \
+//	-H 'Authorization: Bearer ACCESS_TOKEN' \
+//	-H 'Content-Type: application/json' \
+//	-d '{"messaging_product": "whatsapp", "pin": "6_DIGIT_PIN"}'
+func (client *Client) Register(ctx context.Context, request *RegisterRequest) (*StatusResponse, error) {
+	if request == nil {
+		return nil, fmt.Errorf("register request is nil: %v", ErrBadRequestFormat)
+	}
+
+	request.MessagingProduct = messagingProduct
+
+	cctx := client.context()
+	reqCtx := &whttp.RequestContext{
+		Name:       "register phone number",
+		BaseURL:    cctx.baseURL,
+		ApiVersion: cctx.apiVersion,
+		SenderID:   cctx.phoneNumberID,
+		Endpoints:  []string{"register"},
+	}
+
+	params := &whttp.Request{
+		Context: reqCtx,
+		Method:  http.MethodPost,
+		Headers: map[string]string{"Content-Type": "application/json"},
+		Bearer:  cctx.accessToken,
+		Payload: request,
+	}
+
+	var resp StatusResponse
+	if err := whttp.Do(ctx, client.http, params, &resp, client.hooks...); err != nil {
+		return nil, fmt.Errorf("register phone number: %v", err)
+	}
+
+	return &resp, nil
+}
+
+// Deregister deregisters the phone number set on the client from the Cloud API. Deregistering
+// is needed before moving a number back to the On-Premises API.
+func (client *Client) Deregister(ctx context.Context) (*StatusResponse, error) {
+	cctx := client.context()
+	reqCtx := &whttp.RequestContext{
+		Name:       "deregister phone number",
+		BaseURL:    cctx.baseURL,
+		ApiVersion: cctx.apiVersion,
+		SenderID:   cctx.phoneNumberID,
+		Endpoints:  []string{"deregister"},
+	}
+
+	params := &whttp.Request{
+		Context: reqCtx,
+		Method:  http.MethodPost,
+		Headers: map[string]string{"Content-Type": "application/json"},
+		Bearer:  cctx.accessToken,
+	}
+
+	var resp StatusResponse
+	if err := whttp.Do(ctx, client.http, params, &resp, client.hooks...); err != nil {
+		return nil, fmt.Errorf("deregister phone number: %v", err)
+	}
+
+	return &resp, nil
+}
+
+// BackupOnPremisesSettings exports the settings of an On-Premises API client. The returned
+// Backup can be passed to RestoreOnPremisesSettings or to Register when migrating the number
+// to the Cloud API.
+func BackupOnPremisesSettings(ctx context.Context, client *http.Client, req *OnPremisesRequest,
+	password string, hooks ...whttp.Hook,
+) (*Backup, error) {
+	if req == nil {
+		return nil, fmt.Errorf("on-premises request is nil: %v", ErrBadRequestFormat)
+	}
+
+	params := &whttp.Request{
+		Context: &whttp.RequestContext{
+			Name:       "backup on-premises settings",
+			BaseURL:    req.BaseURL,
+			ApiVersion: OnPremisesAPIVersion,
+			SenderID:   "settings",
+			Endpoints:  []string{"backup"},
+		},
+		Method:  http.MethodPost,
+		Headers: map[string]string{"Content-Type": "application/json"},
+		Bearer:  req.AccessToken,
+		Payload: map[string]string{"password": password},
+	}
+
+	var resp onPremisesBackupResponse
+	if err := whttp.Do(ctx, client, params, &resp, hooks...); err != nil {
+		return nil, fmt.Errorf("backup on-premises settings: %v", err)
+	}
+
+	if resp.Settings == nil || resp.Settings.Data == "" {
+		return nil, fmt.Errorf("backup on-premises settings: empty backup data: %v", ErrBadRequestFormat)
+	}
+
+	return &Backup{
+		Data:     resp.Settings.Data,
+		Password: password,
+	}, nil
+}
+
+// RestoreOnPremisesSettings restores settings previously exported with BackupOnPremisesSettings
+// into an On-Premises API client. It is useful to roll back a failed migration.
+func RestoreOnPremisesSettings(ctx context.Context, client *http.Client, req *OnPremisesRequest,
+	backup *Backup, hooks ...whttp.Hook,
+) error {
+	if req == nil || backup == nil {
+		return fmt.Errorf("on-premises request or backup is nil: %v", ErrBadRequestFormat)
+	}
+
+	params := &whttp.Request{
+		Context: &whttp.RequestContext{
+			Name:       "restore on-premises settings",
+			BaseURL:    req.BaseURL,
+			ApiVersion: OnPremisesAPIVersion,
+			SenderID:   "settings",
+			Endpoints:  []string{"restore"},
+		},
+		Method:  http.MethodPost,
+		Headers: map[string]string{"Content-Type": "application/json"},
+		Bearer:  req.AccessToken,
+		Payload: backup,
+	}
+
+	if err := whttp.Do(ctx, client, params, nil, hooks...); err != nil {
+		return fmt.Errorf("restore on-premises settings: %v", err)
+	}
+
+	return nil
+}
+
+// MigrateFromOnPremises moves the phone number set on the client from the On-Premises API to
+// the Cloud API. It exports the settings of the On-Premises client protected by password and
+// registers the number on the Cloud API with the exported backup and the two-step verification pin.
+//
+// The number must have been added to the WhatsApp Business Account and must not have been
+// deregistered from the On-Premises client before calling this method.
+func (client *Client) MigrateFromOnPremises(ctx context.Context, onPremises *OnPremisesRequest,
+	password, pin string,
+) (*StatusResponse, error) {
+	backup, err := BackupOnPremisesSettings(ctx, client.http, onPremises, password, client.hooks...)
+	if err != nil {
+		return nil, fmt.Errorf("migrate from on-premises: %v", err)
+	}
+
+	resp, err := client.Register(ctx, &RegisterRequest{
+		PIN:    pin,
+		Backup: backup,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("migrate from on-premises: %v", err)
+	}
+
+	return resp, nil
+}
@@ -0,0 +1,83 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package whatsapp
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestClient_MigrateFromOnPremises(t *testing.T) {
+	t.Parallel()
+
+	var registered RegisterRequest
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/settings/backup", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer onprem-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+
+			return
+		}
+		_, _ = w.Write([]byte(`{"settings":{"data":"ENCRYPTED_BACKUP"}}`))
+	})
+	mux.HandleFunc("/v16.0/phone_id/register", func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&registered); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+
+			return
+		}
+		_, _ = w.Write([]byte(`{"success":true}`))
+	})
+
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	client := NewClient(
+		WithBaseURL(server.URL),
+		WithVersion("v16.0"),
+		WithPhoneNumberID("phone_id"),
+		WithAccessToken("cloud-token"),
+	)
+
+	resp, err := client.MigrateFromOnPremises(context.TODO(), &OnPremisesRequest{
+		BaseURL:     server.URL,
+		AccessToken: "onprem-token",
+	}, "backup-password", "123456")
+	if err != nil {
+		t.Fatalf("MigrateFromOnPremises() error = %v", err)
+	}
+
+	if !resp.Success {
+		t.Errorf("MigrateFromOnPremises() success = false")
+	}
+
+	if registered.PIN != "123456" || registered.MessagingProduct != messagingProduct {
+		t.Errorf("unexpected register request: %+v", registered)
+	}
+
+	if registered.Backup == nil || registered.Backup.Data != "ENCRYPTED_BACKUP" ||
+		registered.Backup.Password != "backup-password" {
+		t.Errorf("unexpected backup in register request: %+v", registered.Backup)
+	}
+}
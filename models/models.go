@@ -19,13 +19,17 @@
 
 package models
 
-import "time"
+import (
+	"encoding/json"
+	"time"
+)
 
 const (
 	InteractiveMessageButton      = "button"
 	InteractiveMessageList        = "list"
 	InteractiveMessageProduct     = "product"
 	InteractiveMessageProductList = "product_list"
+	InteractiveMessageFlow        = "flow"
 )
 
 type (
@@ -39,11 +43,19 @@ type (
 		Body       string `json:"body,omitempty"`
 	}
 
+	// Location is a pinned location, or an update of a live location when received with the
+	// fields of live locations: Accuracy in meters, Speed in meters per second and Heading in
+	// degrees clockwise from the north, each only when known, and SequenceNumber that grows with
+	// every update of the same live location.
 	Location struct {
-		Longitude float64 `json:"longitude"`
-		Latitude  float64 `json:"latitude"`
-		Name      string  `json:"name"`
-		Address   string  `json:"address"`
+		Longitude      float64  `json:"longitude"`
+		Latitude       float64  `json:"latitude"`
+		Name           string   `json:"name"`
+		Address        string   `json:"address"`
+		Accuracy       *float64 `json:"accuracy,omitempty"`
+		Speed          *float64 `json:"speed,omitempty"`
+		Heading        *float64 `json:"heading,omitempty"`
+		SequenceNumber int64    `json:"sequence_number,omitempty"`
 	}
 
 	Address struct {
@@ -121,7 +133,7 @@ type (
 	}
 
 	// MediaInfo provides information about a media be it an Audio, Video, etc.
-	// Animated used with stickers only.
+	// Animated used with stickers only, Voice with audio only.
 	MediaInfo struct {
 		ID       string `json:"id,omitempty"`
 		Caption  string `json:"caption,omitempty"`
@@ -129,6 +141,7 @@ type (
 		Sha256   string `json:"sha256,omitempty"`
 		Filename string `json:"filename,omitempty"`
 		Animated bool   `json:"animated,omitempty"` // used with stickers true if animated
+		Voice    bool   `json:"voice,omitempty"`    // used with audio true if recorded as a voice note
 	}
 
 	// Media represents a media object. This object is used to send media messages to WhatsApp users.
@@ -282,15 +295,52 @@ type (
 	//- Video, video (object) Required when type=video. A media object of type video. Captions not supported when used in
 	//  a media template.
 	//
+	//- ParameterName, parameter_name (string) Required for the templates with named parameters, like {{first_name}}.
+	//  The name of the parameter.
+	//
+	//- Location, location (object) Required when type=location. The location of a location header.
+	//
+	//- CouponCode, coupon_code (string) Required when type=coupon_code. The code copied by a copy code button.
+	//
+	//- Product, product (object) Required when type=product. The product of the header of a product carousel card.
+	//
+	//- LimitedTimeOffer, limited_time_offer (object) Required when type=limited_time_offer. The expiration of
+	//  the offer.
+	//
 	TemplateParameter struct {
-		Type     string            `json:"type,omitempty"`
-		Text     string            `json:"text,omitempty"`
-		Payload  string            `json:"payload,omitempty"`
-		Currency *TemplateCurrency `json:"currency,omitempty"`
-		DateTime *TemplateDateTime `json:"date_time,omitempty"`
-		Image    *Media            `json:"image,omitempty"`
-		Document *Media            `json:"document,omitempty"`
-		Video    *Media            `json:"video,omitempty"`
+		Type          string            `json:"type,omitempty"`
+		ParameterName string            `json:"parameter_name,omitempty"`
+		Text          string            `json:"text,omitempty"`
+		Payload       string            `json:"payload,omitempty"`
+		Currency      *TemplateCurrency `json:"currency,omitempty"`
+		DateTime      *TemplateDateTime `json:"date_time,omitempty"`
+		Image         *Media            `json:"image,omitempty"`
+		Document      *Media            `json:"document,omitempty"`
+		Video         *Media            `json:"video,omitempty"`
+		Location      *Location         `json:"location,omitempty"`
+		CouponCode    string            `json:"coupon_code,omitempty"`
+		Product       *TemplateProduct  `json:"product,omitempty"`
+
+		LimitedTimeOffer *TemplateLimitedTimeOffer `json:"limited_time_offer,omitempty"`
+	}
+
+	// TemplateLimitedTimeOffer is the expiration of the offer of a limited_time_offer component,
+	// in milliseconds since the Unix epoch. The countdown is shown to the user.
+	TemplateLimitedTimeOffer struct {
+		ExpirationTimeMs int64 `json:"expiration_time_ms,omitempty"`
+	}
+
+	// TemplateProduct is the product shown in the header of a card of a product carousel.
+	TemplateProduct struct {
+		ProductRetailerID string `json:"product_retailer_id,omitempty"`
+		CatalogID         string `json:"catalog_id,omitempty"`
+	}
+
+	// TemplateCard is a card of a carousel component, CardIndex is its position starting at 0.
+	// Its components are a header, an optional body and its buttons.
+	TemplateCard struct {
+		CardIndex  int                  `json:"card_index"`
+		Components []*TemplateComponent `json:"components,omitempty"`
 	}
 
 	// TemplateComponent contains information about a template component.
@@ -329,11 +379,13 @@ type (
 	// For components of type=button, see the button parameter object.
 	// Index, index. Required when type=button. Not used for the other types. Only used for Cloud API.
 	// Position index of the button. You can have up to 3 buttons using index values of 0 to 2.
+	// Cards, cards (array of objects). Required when type=carousel. The cards of the carousel.
 	TemplateComponent struct {
 		Type       string               `json:"type,omitempty"`
 		SubType    string               `json:"sub_type,omitempty"`
 		Parameters []*TemplateParameter `json:"parameters,omitempty"`
 		Index      int                  `json:"index,omitempty"`
+		Cards      []*TemplateCard      `json:"cards,omitempty"`
 	}
 
 	// Product ...
@@ -441,12 +493,43 @@ type (
 	//
 	//	- Sections, sections (array of objects) Required for List Messages and Multi-Product Messages. Array of
 	//	  section objects. Minimum of 1, maximum of 10. See InteractiveSection object.
+	//
+	//	- Name, name (string) Required for Flow Messages, it must be "flow".
+	//
+	//	- Parameters, parameters (object) Required for Flow Messages. See InteractiveFlowParameters.
 	InteractiveAction struct {
-		Button            string                `json:"button,omitempty"`
-		Buttons           []*InteractiveButton  `json:"buttons,omitempty"`
-		CatalogID         string                `json:"catalog_id,omitempty"`
-		ProductRetailerID string                `json:"product_retailer_id,omitempty"`
-		Sections          []*InteractiveSection `json:"sections,omitempty"`
+		Button            string                     `json:"button,omitempty"`
+		Buttons           []*InteractiveButton       `json:"buttons,omitempty"`
+		CatalogID         string                     `json:"catalog_id,omitempty"`
+		ProductRetailerID string                     `json:"product_retailer_id,omitempty"`
+		Sections          []*InteractiveSection      `json:"sections,omitempty"`
+		Name              string                     `json:"name,omitempty"`
+		Parameters        *InteractiveFlowParameters `json:"parameters,omitempty"`
+	}
+
+	// InteractiveFlowParameters are the parameters of the action of a Flow Message.
+	//
+	//	- FlowMessageVersion, flow_message_version (string) Required, "3".
+	//	- FlowToken, flow_token (string) Identifies the flow session in the data exchange requests.
+	//	- FlowID, flow_id (string) Required. The ID of the flow.
+	//	- FlowCTA, flow_cta (string) Required. The text of the button that opens the flow.
+	//	- FlowAction, flow_action (string) navigate, the default, or data_exchange.
+	//	- FlowActionPayload, flow_action_payload (object) Required for navigate. The first screen and its data.
+	//	- Mode, mode (string) published, the default, or draft to test a flow that is not published.
+	InteractiveFlowParameters struct {
+		FlowMessageVersion string                        `json:"flow_message_version,omitempty"`
+		FlowToken          string                        `json:"flow_token,omitempty"`
+		FlowID             string                        `json:"flow_id,omitempty"`
+		FlowCTA            string                        `json:"flow_cta,omitempty"`
+		FlowAction         string                        `json:"flow_action,omitempty"`
+		FlowActionPayload  *InteractiveFlowActionPayload `json:"flow_action_payload,omitempty"`
+		Mode               string                        `json:"mode,omitempty"`
+	}
+
+	// InteractiveFlowActionPayload is the screen a flow opens on, with the data passed to it.
+	InteractiveFlowActionPayload struct {
+		Screen string         `json:"screen,omitempty"`
+		Data   map[string]any `json:"data,omitempty"`
 	}
 
 	// InteractiveHeader contains information about an interactive header.
@@ -872,8 +955,57 @@ func WithTemplate(template *Template) MessageOption {
 	}
 }
 
+// WithText sets the body of a text message.
+func WithText(body string) MessageOption {
+	return func(m *Message) {
+		m.Type = "text"
+		if m.Text == nil {
+			m.Text = &Text{}
+		}
+		m.Text.Body = body
+	}
+}
+
+// WithPreviewURL renders a preview of the first URL in the body of a text message.
+func WithPreviewURL() MessageOption {
+	return func(m *Message) {
+		if m.Text == nil {
+			m.Text = &Text{}
+		}
+		m.Text.PreviewURL = true
+	}
+}
+
+// WithReplyContext sends the message as a reply to the message with the ID, the recipient sees
+// it quoted.
+func WithReplyContext(messageID string) MessageOption {
+	return func(m *Message) {
+		m.Context = &Context{MessageID: messageID}
+	}
+}
+
+// MarshalJSON encodes the index of the button components even when it is 0, the index of the first
+// button.
+func (c TemplateComponent) MarshalJSON() ([]byte, error) {
+	type plain TemplateComponent
+	if c.Type != "button" {
+		return json.Marshal(plain(c))
+	}
+
+	return json.Marshal(struct {
+		plain
+		Index int `json:"index"`
+	}{plain: plain(c), Index: c.Index})
+}
+
 // SetTemplate sets the template of the message.
 func (m *Message) SetTemplate(template *Template) {
 	m.Type = "template"
 	m.Template = template
 }
+
+// Removed reports whether the reaction removes a previous one, received reactions with an empty
+// emoji mean that the user removed their reaction to the message.
+func (r *Reaction) Removed() bool {
+	return r != nil && r.Emoji == ""
+}
@@ -0,0 +1,137 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package whatsapp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/lowkruc/go-whatsapp-api/audit"
+	"github.com/lowkruc/go-whatsapp-api/models"
+	"github.com/lowkruc/go-whatsapp-api/moderation"
+)
+
+// WithModerator runs moderator on every message before it is sent, templates, reactions and
+// replies included. Blocked messages are not sent and the send returns a *moderation.BlockedError
+// as is, errors.Is matches it with moderation.ErrBlocked. Decisions other than moderation.Allow
+// are recorded in the audit trail set with WithAuditTrail.
+func WithModerator(moderator moderation.Moderator) ClientOption {
+	return func(client *Client) {
+		client.moderator = moderator
+	}
+}
+
+// moderate runs the moderator of the client on the message, which may be modified in place. The
+// send methods return its error as is.
+func (client *Client) moderate(ctx context.Context, message *models.Message) error {
+	if client.moderator == nil || message == nil {
+		return nil
+	}
+
+	decision, err := client.moderator.Moderate(ctx, message)
+	if err != nil {
+		return fmt.Errorf("moderation: %v", err)
+	}
+	if decision == nil || decision.Action == moderation.Allow || decision.Action == "" {
+		return nil
+	}
+
+	var blocked error
+	if decision.Action == moderation.Block {
+		blocked = &moderation.BlockedError{Category: decision.Category, Reason: decision.Reason}
+	}
+	audit.Record(ctx, client.audit, audit.MessageModerated, blocked, map[string]string{
+		"action":   string(decision.Action),
+		"category": decision.Category,
+		"reason":   decision.Reason,
+		"type":     message.Type,
+	})
+
+	return blocked
+}
+
+// mediaMessage returns the message SendMedia sends, for moderation.
+func mediaMessage(recipient string, req *MediaMessage) (*models.Message, *models.Media) {
+	media := &models.Media{
+		ID:       req.MediaID,
+		Link:     req.MediaLink,
+		Caption:  req.Caption,
+		Filename: req.Filename,
+		Provider: req.Provider,
+	}
+	message := &models.Message{To: recipient, Type: string(req.Type)}
+	switch req.Type {
+	case MediaTypeImage:
+		message.Image = media
+	case MediaTypeVideo:
+		message.Video = media
+	case MediaTypeDocument:
+		message.Document = media
+	case MediaTypeAudio:
+		message.Audio = media
+	case MediaTypeSticker:
+		message.Sticker = media
+	}
+
+	return message, media
+}
+
+// replyMessage returns the message Reply sends, for moderation. The content is decoded into the
+// field of its type.
+func replyMessage(recipient string, req *ReplyMessage) (*models.Message, error) {
+	content, err := json.Marshal(req.Content)
+	if err != nil {
+		return nil, fmt.Errorf("reply message: %v", err)
+	}
+	messageType, _ := json.Marshal(string(req.Type))
+	payload, err := json.Marshal(map[string]json.RawMessage{
+		"type":           messageType,
+		string(req.Type): content,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("reply message: %v", err)
+	}
+	message := &models.Message{}
+	if err = json.Unmarshal(payload, message); err != nil {
+		return nil, fmt.Errorf("reply message: %v", err)
+	}
+	message.To = recipient
+
+	return message, nil
+}
+
+// replyContent returns the content of the moderated reply message, or content when the type of
+// the message has no field in models.Message.
+func replyContent(message *models.Message, messageType MessageType, content any) any {
+	payload, err := json.Marshal(message)
+	if err != nil {
+		return content
+	}
+	var fields map[string]json.RawMessage
+	if err = json.Unmarshal(payload, &fields); err != nil {
+		return content
+	}
+	if moderated, ok := fields[string(messageType)]; ok {
+		return moderated
+	}
+
+	return content
+}
@@ -0,0 +1,244 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+// Package moderation inspects the outbound messages before they are sent. A Moderator can let a
+// message through, modify it, for example to redact personal data, or block it. The client runs
+// the Moderator set with whatsapp.WithModerator before sending text, media, interactive and
+// prebuilt messages, and records every decision other than Allow in its audit trail.
+//
+// Example:
+//
+//	moderator := moderation.Chain(
+//		moderation.Blocklist("profanity", "damn", "heck"),
+//		moderation.Redact("pii", "[redacted]", moderation.EmailPattern, moderation.CardNumberPattern),
+//	)
+//	client := whatsapp.NewClient(whatsapp.WithModerator(moderator), whatsapp.WithAuditTrail(trail))
+package moderation
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/lowkruc/go-whatsapp-api/models"
+)
+
+// Action is what happens to a moderated message.
+type Action string
+
+const (
+	// Allow sends the message unchanged.
+	Allow Action = "allow"
+
+	// Modify sends the message as modified by the Moderator.
+	Modify Action = "modify"
+
+	// Block does not send the message, the send returns a *BlockedError.
+	Block Action = "block"
+)
+
+var ErrBlocked = errors.New("message blocked by moderation")
+
+// BlockedError is the error of a send blocked by the Moderator, with the Category and the Reason of
+// the Block decision. errors.Is matches it with ErrBlocked.
+type BlockedError struct {
+	Category string
+	Reason   string
+}
+
+func (e *BlockedError) Error() string {
+	if e.Reason == "" {
+		return ErrBlocked.Error()
+	}
+
+	return ErrBlocked.Error() + ": " + e.Reason
+}
+
+// Is reports whether target is ErrBlocked.
+func (e *BlockedError) Is(target error) bool {
+	return target == ErrBlocked
+}
+
+//nolint:gochecknoglobals
+var (
+	// EmailPattern matches email addresses.
+	EmailPattern = regexp.MustCompile(`[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`)
+
+	// CardNumberPattern matches payment card numbers of 13 to 19 digits, optionally grouped with
+	// spaces or dashes.
+	CardNumberPattern = regexp.MustCompile(`\b\d(?:[ \-]?\d){12,18}\b`)
+)
+
+type (
+	// Decision is the outcome of the moderation of a message. Category is the policy category
+	// that triggered the decision, like "profanity" or "pii", and Reason explains it.
+	Decision struct {
+		Action   Action
+		Category string
+		Reason   string
+	}
+
+	// Moderator inspects a message before it is sent. It may modify the message in place, in
+	// which case it returns a Modify decision. A nil decision is the same as Allow. An error
+	// stops the send.
+	Moderator interface {
+		Moderate(ctx context.Context, message *models.Message) (*Decision, error)
+	}
+
+	// ModeratorFunc is a function that implements Moderator.
+	ModeratorFunc func(ctx context.Context, message *models.Message) (*Decision, error)
+)
+
+func (fn ModeratorFunc) Moderate(ctx context.Context, message *models.Message) (*Decision, error) {
+	return fn(ctx, message)
+}
+
+// Chain runs the moderators in order. The first Block decision or error stops the chain, when
+// none blocks the first Modify decision is returned.
+func Chain(moderators ...Moderator) Moderator {
+	return ModeratorFunc(func(ctx context.Context, message *models.Message) (*Decision, error) {
+		var modified *Decision
+		for _, moderator := range moderators {
+			if moderator == nil {
+				continue
+			}
+			decision, err := moderator.Moderate(ctx, message)
+			if err != nil {
+				return nil, err
+			}
+			if decision == nil {
+				continue
+			}
+			switch decision.Action {
+			case Block:
+				return decision, nil
+			case Modify:
+				if modified == nil {
+					modified = decision
+				}
+			case Allow:
+			}
+		}
+		if modified != nil {
+			return modified, nil
+		}
+
+		return &Decision{Action: Allow}, nil
+	})
+}
+
+// Texts returns pointers to the text of the message shown to the recipient: the text body, the
+// media captions, the header, body and footer of interactive messages, the name and address of
+// locations and the text parameters of templates. Moderators read and modify the message through them.
+func Texts(message *models.Message) []*string {
+	if message == nil {
+		return nil
+	}
+
+	var texts []*string
+	if message.Text != nil {
+		texts = append(texts, &message.Text.Body)
+	}
+	for _, media := range []*models.Media{message.Image, message.Video, message.Document, message.Audio, message.Sticker} {
+		if media != nil {
+			texts = append(texts, &media.Caption)
+		}
+	}
+	if interactive := message.Interactive; interactive != nil {
+		if interactive.Header != nil {
+			texts = append(texts, &interactive.Header.Text)
+		}
+		if interactive.Body != nil {
+			texts = append(texts, &interactive.Body.Text)
+		}
+		if interactive.Footer != nil {
+			texts = append(texts, &interactive.Footer.Text)
+		}
+	}
+	if message.Location != nil {
+		texts = append(texts, &message.Location.Name, &message.Location.Address)
+	}
+	if message.Template != nil {
+		for _, component := range message.Template.Components {
+			if component == nil {
+				continue
+			}
+			for _, parameter := range component.Parameters {
+				if parameter != nil {
+					texts = append(texts, &parameter.Text)
+				}
+			}
+		}
+	}
+
+	return texts
+}
+
+// Blocklist blocks the messages containing any of the words, matched as whole words regardless
+// of case.
+func Blocklist(category string, words ...string) Moderator {
+	quoted := make([]string, 0, len(words))
+	for _, word := range words {
+		if word = strings.TrimSpace(word); word != "" {
+			quoted = append(quoted, regexp.QuoteMeta(word))
+		}
+	}
+	if len(quoted) == 0 {
+		return ModeratorFunc(func(context.Context, *models.Message) (*Decision, error) {
+			return nil, nil
+		})
+	}
+	pattern := regexp.MustCompile(`(?i)\b(?:` + strings.Join(quoted, "|") + `)\b`)
+
+	return ModeratorFunc(func(ctx context.Context, message *models.Message) (*Decision, error) {
+		for _, text := range Texts(message) {
+			if match := pattern.FindString(*text); match != "" {
+				return &Decision{Action: Block, Category: category, Reason: "blocked word " + strings.ToLower(match)}, nil
+			}
+		}
+
+		return nil, nil
+	})
+}
+
+// Redact replaces the matches of the patterns in the texts of the message with replacement.
+func Redact(category, replacement string, patterns ...*regexp.Regexp) Moderator {
+	return ModeratorFunc(func(ctx context.Context, message *models.Message) (*Decision, error) {
+		redacted := 0
+		for _, text := range Texts(message) {
+			for _, pattern := range patterns {
+				if !pattern.MatchString(*text) {
+					continue
+				}
+				*text = pattern.ReplaceAllStringFunc(*text, func(string) string {
+					redacted++
+
+					return replacement
+				})
+			}
+		}
+		if redacted == 0 {
+			return nil, nil
+		}
+
+		return &Decision{Action: Modify, Category: category, Reason: strconv.Itoa(redacted) + " matches redacted"}, nil
+	})
+}
@@ -0,0 +1,77 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package moderation
+
+import (
+	"context"
+	"testing"
+
+	"github.com/lowkruc/go-whatsapp-api/models"
+)
+
+func TestRedact(t *testing.T) {
+	t.Parallel()
+	message := &models.Message{
+		Type:  "image",
+		Image: &models.Media{ID: "media.1", Caption: "Card 4111 1111 1111 1111, mail jane@example.com"},
+	}
+
+	decision, err := Redact("pii", "***", CardNumberPattern, EmailPattern).Moderate(context.TODO(), message)
+	if err != nil {
+		t.Fatalf("Moderate() error = %v", err)
+	}
+	if decision.Action != Modify || decision.Reason != "2 matches redacted" {
+		t.Errorf("got decision %+v, want 2 matches redacted", decision)
+	}
+	if want := "Card ***, mail ***"; message.Image.Caption != want {
+		t.Errorf("got caption %q, want %q", message.Image.Caption, want)
+	}
+}
+
+func TestChain(t *testing.T) {
+	t.Parallel()
+	message := &models.Message{
+		Type: "interactive",
+		Interactive: &models.Interactive{
+			Body:   &models.InteractiveBody{Text: "Pick one"},
+			Footer: &models.InteractiveFooter{Text: "no SPAM here"},
+		},
+	}
+
+	var calls int
+	counter := ModeratorFunc(func(ctx context.Context, message *models.Message) (*Decision, error) {
+		calls++
+
+		return nil, nil
+	})
+
+	decision, err := Chain(counter, Blocklist("spam", "spam"), counter).Moderate(context.TODO(), message)
+	if err != nil {
+		t.Fatalf("Moderate() error = %v", err)
+	}
+	if decision.Action != Block || decision.Category != "spam" || calls != 1 {
+		t.Errorf("got decision %+v after %d calls, want a spam block after 1 call", decision, calls)
+	}
+
+	message.Interactive.Footer.Text = "no spammers here"
+	if decision, _ = Chain(Blocklist("spam", "spam")).Moderate(context.TODO(), message); decision.Action != Allow {
+		t.Errorf("got decision %+v, want allow for a partial word", decision)
+	}
+}
@@ -0,0 +1,172 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package whatsapp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/lowkruc/go-whatsapp-api/audit"
+	"github.com/lowkruc/go-whatsapp-api/models"
+	"github.com/lowkruc/go-whatsapp-api/moderation"
+)
+
+func TestClient_WithModerator(t *testing.T) {
+	t.Parallel()
+	var sent []*models.Message
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		message := &models.Message{}
+		_ = json.NewDecoder(r.Body).Decode(message)
+		sent = append(sent, message)
+		_, _ = w.Write([]byte(`{"messaging_product":"whatsapp","messages":[{"id":"wamid.1"}]}`))
+	}))
+	defer server.Close()
+
+	trail := &audit.MemoryTrail{}
+	client := NewClient(WithBaseURL(server.URL), WithVersion("v16.0"), WithPhoneNumberID("1000"),
+		WithAuditTrail(trail), WithModerator(moderation.Chain(
+			moderation.Blocklist("profanity", "damn"),
+			moderation.Redact("pii", "[redacted]", moderation.EmailPattern),
+		)))
+
+	ctx := context.TODO()
+	_, err := client.SendTextMessage(ctx, "16505551234", &TextMessage{Message: "Damn, that is late"})
+	var blocked *moderation.BlockedError
+	if !errors.Is(err, moderation.ErrBlocked) || !errors.As(err, &blocked) || blocked.Category != "profanity" {
+		t.Fatalf("SendTextMessage() error = %v, want a %v of the profanity category", err, moderation.ErrBlocked)
+	}
+
+	if _, err = client.SendTextMessage(ctx, "16505551234", &TextMessage{Message: "Write to jane@example.com"}); err != nil {
+		t.Fatalf("SendTextMessage() error = %v", err)
+	}
+	if _, err = client.SendMessage(ctx, &models.Message{To: "16505551234", Type: "text",
+		Text: &models.Text{Body: "See you tomorrow"}}); err != nil {
+		t.Fatalf("SendMessage() error = %v", err)
+	}
+
+	if len(sent) != 2 || sent[0].Text.Body != "Write to [redacted]" || sent[1].Text.Body != "See you tomorrow" {
+		t.Fatalf("got %d messages sent, want the redacted text and the allowed one", len(sent))
+	}
+
+	var decisions []string
+	for _, event := range trail.Events() {
+		if event.Type == audit.MessageModerated {
+			decisions = append(decisions, event.Attributes["action"]+":"+event.Attributes["category"])
+		}
+	}
+	if strings.Join(decisions, ",") != "block:profanity,modify:pii" {
+		t.Errorf("got moderation events %v, want [block:profanity modify:pii]", decisions)
+	}
+}
+
+func TestClient_WithModeratorEverySend(t *testing.T) {
+	t.Parallel()
+	var sent []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		sent = append(sent, string(body))
+		_, _ = w.Write([]byte(`{"messaging_product":"whatsapp","messages":[{"id":"wamid.1"}]}`))
+	}))
+	defer server.Close()
+
+	client := NewClient(WithBaseURL(server.URL), WithVersion("v16.0"), WithPhoneNumberID("1000"),
+		WithModerator(moderation.Chain(
+			moderation.Blocklist("profanity", "damn"),
+			moderation.Redact("pii", "[redacted]", moderation.EmailPattern),
+		)))
+	ctx := context.TODO()
+	email := func() []*models.TemplateParameter {
+		return []*models.TemplateParameter{{Type: "text", Text: "jane@example.com"}}
+	}
+	sends := map[string]func() error{
+		"SendTemplate": func() error {
+			_, err := client.SendTemplate(ctx, "16505551234", &Template{Name: "receipt", LanguageCode: "en",
+				Components: []*models.TemplateComponent{{Type: "body", Parameters: email()}}})
+
+			return err
+		},
+		"SendTextTemplate": func() error {
+			_, err := client.SendTextTemplate(ctx, "16505551234", &TextTemplateRequest{Name: "receipt", Body: email()})
+
+			return err
+		},
+		"SendMediaTemplate": func() error {
+			_, err := client.SendMediaTemplate(ctx, "16505551234", &MediaTemplateRequest{Name: "receipt", Body: email()})
+
+			return err
+		},
+		"SendInteractiveTemplate": func() error {
+			_, err := client.SendInteractiveTemplate(ctx, "16505551234",
+				&InteractiveTemplateRequest{Name: "receipt", Body: email()})
+
+			return err
+		},
+		"SendLocationMessage": func() error {
+			_, err := client.SendLocationMessage(ctx, "16505551234", &models.Location{Name: "jane@example.com"})
+
+			return err
+		},
+		"Reply": func() error {
+			_, err := client.Reply(ctx, "16505551234", &ReplyMessage{Context: "wamid.0", Type: "text",
+				Content: &models.Text{Body: "jane@example.com"}})
+
+			return err
+		},
+	}
+	for name, send := range sends {
+		sent = nil
+		if err := send(); err != nil {
+			t.Fatalf("%s() error = %v", name, err)
+		}
+		if len(sent) != 1 || strings.Contains(sent[0], "jane@example.com") || !strings.Contains(sent[0], "[redacted]") {
+			t.Errorf("%s() sent %v, want the redacted message", name, sent)
+		}
+	}
+
+	sent = nil
+	_, err := client.SendContacts(ctx, "16505551234", nil)
+	if err != nil || len(sent) != 1 {
+		t.Errorf("SendContacts() error = %v, sent %d messages, want the allowed message sent", err, len(sent))
+	}
+
+	blocker := NewClient(WithBaseURL(server.URL), WithVersion("v16.0"), WithPhoneNumberID("1000"),
+		WithModerator(moderation.ModeratorFunc(func(ctx context.Context, message *models.Message) (
+			*moderation.Decision, error,
+		) {
+			return &moderation.Decision{Action: moderation.Block, Category: "quiet hours"}, nil
+		})))
+	sent = nil
+	if _, err = blocker.React(ctx, "16505551234", &ReactMessage{MessageID: "wamid.0", Emoji: "👍"}); !errors.Is(err,
+		moderation.ErrBlocked) {
+		t.Errorf("React() error = %v, want %v", err, moderation.ErrBlocked)
+	}
+	if _, err = blocker.SendText(ctx, "16505551234", "hi"); !errors.Is(err, moderation.ErrBlocked) {
+		t.Errorf("SendText() error = %v, want %v", err, moderation.ErrBlocked)
+	}
+	if len(sent) != 0 {
+		t.Errorf("got %d messages sent, want the blocked messages not sent", len(sent))
+	}
+}
@@ -0,0 +1,121 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package whatsapp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/lowkruc/go-whatsapp-api/models"
+)
+
+// namespaceSeparator separates the namespace from the name in a qualified template name.
+const namespaceSeparator = ":"
+
+var ErrNoTemplateNamespace = errors.New("business account has no message template namespace")
+
+// namespaceCache keeps the message template namespace of the business account it was fetched for.
+type namespaceCache struct {
+	mu        sync.Mutex
+	accountID string
+	namespace string
+}
+
+// TemplateNamespace returns the message_template_namespace of the business account of the client.
+// It is fetched with GetWABA on the first call and cached until RefreshTemplateNamespace is called
+// or the business account ID of the client changes.
+func (client *Client) TemplateNamespace(ctx context.Context) (string, error) {
+	return client.templateNamespace(ctx, false)
+}
+
+// RefreshTemplateNamespace fetches the message_template_namespace again and replaces the cached one.
+func (client *Client) RefreshTemplateNamespace(ctx context.Context) (string, error) {
+	return client.templateNamespace(ctx, true)
+}
+
+func (client *Client) templateNamespace(ctx context.Context, refresh bool) (string, error) {
+	cache := client.namespace
+	if cache == nil {
+		return "", errors.New("template namespace: client is not created with NewClient")
+	}
+
+	accountID := client.context().businessAccountID
+
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+
+	if !refresh && cache.namespace != "" && cache.accountID == accountID {
+		return cache.namespace, nil
+	}
+
+	waba, err := client.GetWABA(ctx, accountID)
+	if err != nil {
+		return "", fmt.Errorf("template namespace: %v", err)
+	}
+
+	if waba.MessageTemplateNamespace == "" {
+		return "", fmt.Errorf("template namespace: %v", ErrNoTemplateNamespace)
+	}
+
+	cache.accountID = accountID
+	cache.namespace = waba.MessageTemplateNamespace
+
+	return cache.namespace, nil
+}
+
+// QualifyTemplate sets the namespace of the template to the namespace of the business account,
+// when it is not set, for integrations that still send On-Premises style templates.
+func (client *Client) QualifyTemplate(ctx context.Context, template *models.Template) error {
+	if template == nil || template.Namespace != "" {
+		return nil
+	}
+
+	namespace, err := client.TemplateNamespace(ctx)
+	if err != nil {
+		return err
+	}
+	template.Namespace = namespace
+
+	return nil
+}
+
+// QualifiedTemplateName returns the namespace-qualified identifier of a template, "namespace:name",
+// as used by integrations built around HSM messages. It returns name when namespace is empty.
+func QualifiedTemplateName(namespace, name string) string {
+	if namespace == "" {
+		return name
+	}
+
+	return namespace + namespaceSeparator + name
+}
+
+// ParseQualifiedTemplateName splits an identifier made with QualifiedTemplateName. An identifier
+// without a namespace is returned as the name.
+func ParseQualifiedTemplateName(identifier string) (namespace, name string) {
+	namespace, name, ok := strings.Cut(identifier, namespaceSeparator)
+	if !ok {
+		return "", identifier
+	}
+
+	return namespace, name
+}
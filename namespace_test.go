@@ -0,0 +1,75 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package whatsapp
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/lowkruc/go-whatsapp-api/models"
+)
+
+func TestClient_TemplateNamespace(t *testing.T) {
+	t.Parallel()
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		_, _ = fmt.Fprintf(w, `{"id":"waba_id","message_template_namespace":"ns_%d"}`, requests)
+	}))
+	defer server.Close()
+
+	client := NewClient(WithBaseURL(server.URL), WithBusinessAccountID("waba_id"))
+	ctx := context.TODO()
+
+	for i := 0; i < 2; i++ {
+		if namespace, err := client.TemplateNamespace(ctx); err != nil || namespace != "ns_1" {
+			t.Fatalf("TemplateNamespace() = %q, %v, want ns_1", namespace, err)
+		}
+	}
+
+	if namespace, err := client.RefreshTemplateNamespace(ctx); err != nil || namespace != "ns_2" {
+		t.Fatalf("RefreshTemplateNamespace() = %q, %v, want ns_2", namespace, err)
+	}
+
+	client.SetBusinessAccountID("other_waba_id")
+	template := &models.Template{Name: "order_update"}
+	if err := client.QualifyTemplate(ctx, template); err != nil || template.Namespace != "ns_3" {
+		t.Fatalf("QualifyTemplate() = %q, %v, want ns_3", template.Namespace, err)
+	}
+
+	if requests != 3 {
+		t.Errorf("got %d requests, want 3", requests)
+	}
+}
+
+func TestQualifiedTemplateName(t *testing.T) {
+	t.Parallel()
+	identifier := QualifiedTemplateName("ns_1", "order_update")
+	if namespace, name := ParseQualifiedTemplateName(identifier); namespace != "ns_1" || name != "order_update" {
+		t.Errorf("ParseQualifiedTemplateName(%q) = %q, %q", identifier, namespace, name)
+	}
+
+	if namespace, name := ParseQualifiedTemplateName("order_update"); namespace != "" || name != "order_update" {
+		t.Errorf("ParseQualifiedTemplateName() = %q, %q, want no namespace", namespace, name)
+	}
+}
@@ -0,0 +1,308 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+// Package onprem translates between the On-Premises API (v1) payloads and the Cloud API payloads
+// used by this library. It helps legacy systems that still produce On-Premises requests, or still
+// consume On-Premises webhooks, to move to the Cloud API incrementally.
+//
+// Outgoing messages in the On-Premises format, including the deprecated hsm type, are converted
+// with ToCloudMessage. Contact checks against the removed /v1/contacts endpoint are answered
+// locally by CheckContacts. Cloud API notifications are flattened back into the On-Premises
+// webhook format with FromCloudNotification or with the GlobalNotificationHandler adapter.
+package onprem
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	werrors "github.com/lowkruc/go-whatsapp-api/errors"
+	"github.com/lowkruc/go-whatsapp-api/models"
+	"github.com/lowkruc/go-whatsapp-api/webhooks"
+)
+
+const (
+	HSMMessageType      = "hsm"
+	TemplateMessageType = "template"
+	TextMessageType     = "text"
+)
+
+const (
+	ContactStatusValid   = "valid"
+	ContactStatusInvalid = "invalid"
+)
+
+var ErrUnsupportedMessage = errors.New("unsupported on-premises message")
+
+type (
+	// HSMCurrency is the currency object of a localizable parameter.
+	HSMCurrency struct {
+		CurrencyCode string `json:"currency_code"`
+		Amount1000   int    `json:"amount_1000"`
+	}
+
+	// HSMDateTime is the date_time object of a localizable parameter. Only the default value is
+	// carried over to the Cloud API, the component and unix_epoch objects are kept for completeness.
+	HSMDateTime struct {
+		Component map[string]any `json:"component,omitempty"`
+		UnixEpoch map[string]any `json:"unix_epoch,omitempty"`
+	}
+
+	// LocalizableParam is a parameter of a hsm message. Default is used when localization fails.
+	LocalizableParam struct {
+		Default  string       `json:"default"`
+		Currency *HSMCurrency `json:"currency,omitempty"`
+		DateTime *HSMDateTime `json:"date_time,omitempty"`
+	}
+
+	// HSM is the highly structured message object of the On-Premises API. It was deprecated in favour
+	// of the template object and is not supported by the Cloud API.
+	HSM struct {
+		Namespace         string                   `json:"namespace"`
+		ElementName       string                   `json:"element_name"`
+		Language          *models.TemplateLanguage `json:"language"`
+		LocalizableParams []*LocalizableParam      `json:"localizable_params,omitempty"`
+	}
+
+	// Message is a message in the On-Premises API format as posted to /v1/messages.
+	Message struct {
+		To            string              `json:"to"`
+		RecipientType string              `json:"recipient_type,omitempty"`
+		Type          string              `json:"type,omitempty"`
+		PreviewURL    bool                `json:"preview_url,omitempty"`
+		Context       *models.Context     `json:"context,omitempty"`
+		Text          *models.Text        `json:"text,omitempty"`
+		HSM           *HSM                `json:"hsm,omitempty"`
+		Template      *models.Template    `json:"template,omitempty"`
+		Image         *models.Media       `json:"image,omitempty"`
+		Audio         *models.Media       `json:"audio,omitempty"`
+		Video         *models.Media       `json:"video,omitempty"`
+		Document      *models.Media       `json:"document,omitempty"`
+		Sticker       *models.Media       `json:"sticker,omitempty"`
+		Location      *models.Location    `json:"location,omitempty"`
+		Contacts      models.Contacts     `json:"contacts,omitempty"`
+		Interactive   *models.Interactive `json:"interactive,omitempty"`
+	}
+
+	// ContactsRequest is the body of the On-Premises /v1/contacts request.
+	ContactsRequest struct {
+		Blocking   string   `json:"blocking,omitempty"`
+		Contacts   []string `json:"contacts"`
+		ForceCheck bool     `json:"force_check,omitempty"`
+	}
+
+	// ContactStatus is a single result of a contacts check.
+	ContactStatus struct {
+		Input  string `json:"input"`
+		Status string `json:"status"`
+		WaID   string `json:"wa_id,omitempty"`
+	}
+
+	// ContactsResponse is the response of the On-Premises /v1/contacts request.
+	ContactsResponse struct {
+		Contacts []*ContactStatus `json:"contacts"`
+	}
+
+	// Webhook is a notification in the On-Premises API webhook format. On-Premises webhooks carry
+	// the contents of a Cloud API change value without the envelope.
+	Webhook struct {
+		Contacts []*webhooks.Contact `json:"contacts,omitempty"`
+		Messages []*webhooks.Message `json:"messages,omitempty"`
+		Statuses []*webhooks.Status  `json:"statuses,omitempty"`
+		Errors   []*werrors.Error    `json:"errors,omitempty"`
+	}
+
+	// WebhookHandler handles a single On-Premises webhook.
+	WebhookHandler func(ctx context.Context, webhook *Webhook) error
+)
+
+// ToCloudMessage converts a message in the On-Premises API format to a Cloud API message.
+// Messages of type hsm are converted to template messages, the top level preview_url is moved
+// into the text object. Other message types are the same in both APIs and are copied as is.
+func ToCloudMessage(message *Message) (*models.Message, error) {
+	if message == nil {
+		return nil, fmt.Errorf("%v: message is nil", ErrUnsupportedMessage)
+	}
+
+	msgType := message.Type
+	if msgType == "" {
+		msgType = TextMessageType
+	}
+
+	recipientType := message.RecipientType
+	if recipientType == "" {
+		recipientType = "individual"
+	}
+
+	cloud := &models.Message{
+		Product:       "whatsapp",
+		To:            message.To,
+		RecipientType: recipientType,
+		Type:          msgType,
+		Context:       message.Context,
+		Template:      message.Template,
+		Image:         message.Image,
+		Audio:         message.Audio,
+		Video:         message.Video,
+		Document:      message.Document,
+		Sticker:       message.Sticker,
+		Location:      message.Location,
+		Contacts:      message.Contacts,
+		Interactive:   message.Interactive,
+	}
+
+	switch msgType {
+	case TextMessageType:
+		if message.Text == nil {
+			return nil, fmt.Errorf("%v: text message without text object", ErrUnsupportedMessage)
+		}
+		cloud.Text = &models.Text{
+			PreviewURL: message.PreviewURL || message.Text.PreviewURL,
+			Body:       message.Text.Body,
+		}
+
+	case HSMMessageType:
+		template, err := templateFromHSM(message.HSM)
+		if err != nil {
+			return nil, err
+		}
+		cloud.Type = TemplateMessageType
+		cloud.Template = template
+	}
+
+	return cloud, nil
+}
+
+func templateFromHSM(hsm *HSM) (*models.Template, error) {
+	if hsm == nil {
+		return nil, fmt.Errorf("%v: hsm message without hsm object", ErrUnsupportedMessage)
+	}
+
+	parameters := make([]*models.TemplateParameter, 0, len(hsm.LocalizableParams))
+	for _, param := range hsm.LocalizableParams {
+		if param == nil {
+			continue
+		}
+		switch {
+		case param.Currency != nil:
+			parameters = append(parameters, &models.TemplateParameter{
+				Type: "currency",
+				Currency: &models.TemplateCurrency{
+					FallbackValue: param.Default,
+					Code:          param.Currency.CurrencyCode,
+					Amount1000:    param.Currency.Amount1000,
+				},
+			})
+		case param.DateTime != nil:
+			parameters = append(parameters, &models.TemplateParameter{
+				Type:     "date_time",
+				DateTime: &models.TemplateDateTime{FallbackValue: param.Default},
+			})
+		default:
+			parameters = append(parameters, &models.TemplateParameter{
+				Type: "text",
+				Text: param.Default,
+			})
+		}
+	}
+
+	template := models.NewTextTemplate(hsm.ElementName, hsm.Language, parameters)
+	template.Namespace = hsm.Namespace
+	if len(parameters) == 0 {
+		template.Components = nil
+	}
+
+	return template, nil
+}
+
+// CheckContacts answers a request to the On-Premises /v1/contacts endpoint locally. The Cloud API
+// does not need contacts to be checked before sending a message, phone numbers are used directly.
+// Every input that contains at least one digit is reported as valid with its digits as the wa_id.
+func CheckContacts(request *ContactsRequest) *ContactsResponse {
+	response := &ContactsResponse{Contacts: []*ContactStatus{}}
+	if request == nil {
+		return response
+	}
+
+	for _, input := range request.Contacts {
+		waID := strings.Map(func(r rune) rune {
+			if r >= '0' && r <= '9' {
+				return r
+			}
+
+			return -1
+		}, input)
+
+		status := &ContactStatus{Input: input, Status: ContactStatusInvalid}
+		if waID != "" {
+			status.Status = ContactStatusValid
+			status.WaID = waID
+		}
+		response.Contacts = append(response.Contacts, status)
+	}
+
+	return response
+}
+
+// FromCloudNotification flattens a Cloud API notification into On-Premises webhooks. A webhook is
+// returned for each change that has a value.
+func FromCloudNotification(notification *webhooks.Notification) []*Webhook {
+	if notification == nil {
+		return nil
+	}
+
+	var list []*Webhook
+	for _, entry := range notification.Entry {
+		if entry == nil {
+			continue
+		}
+		for _, change := range entry.Changes {
+			if change == nil || change.Value == nil {
+				continue
+			}
+			value := change.Value
+			list = append(list, &Webhook{
+				Contacts: value.Contacts,
+				Messages: value.Messages,
+				Statuses: value.Statuses,
+				Errors:   value.Errors,
+			})
+		}
+	}
+
+	return list
+}
+
+// GlobalNotificationHandler adapts a WebhookHandler to a webhooks.GlobalNotificationHandler so that
+// an existing On-Premises webhook consumer can be plugged into webhooks.EventListener.GlobalHandler.
+// The handler is called once for every webhook returned by FromCloudNotification, the first error
+// stops the processing.
+func GlobalNotificationHandler(handler WebhookHandler) webhooks.GlobalNotificationHandler {
+	return func(ctx context.Context, _ http.ResponseWriter, notification *webhooks.Notification) error {
+		for _, webhook := range FromCloudNotification(notification) {
+			if err := handler(ctx, webhook); err != nil {
+				return fmt.Errorf("on-premises webhook handler: %v", err)
+			}
+		}
+
+		return nil
+	}
+}
@@ -0,0 +1,95 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package onprem
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestToCloudMessage(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name    string
+		payload string
+		want    string
+		wantErr bool
+	}{
+		{
+			name:    "hsm",
+			payload: `{"to":"255700000000","type":"hsm","hsm":{"namespace":"ns","element_name":"order_ready","language":{"policy":"deterministic","code":"en"},"localizable_params":[{"default":"Pius"},{"default":"$10.50","currency":{"currency_code":"USD","amount_1000":10500}}]}}`,                                                                                                                  //nolint:lll
+			want:    `{"messaging_product":"whatsapp","to":"255700000000","recipient_type":"individual","type":"template","template":{"name":"order_ready","namespace":"ns","language":{"policy":"deterministic","code":"en"},"components":[{"type":"body","parameters":[{"type":"text","text":"Pius"},{"type":"currency","currency":{"fallback_value":"$10.50","code":"USD","amount_1000":10500}}]}]}}`, //nolint:lll
+		},
+		{
+			name:    "text with preview url",
+			payload: `{"to":"255700000000","preview_url":true,"text":{"body":"https://example.com"}}`,
+			want:    `{"messaging_product":"whatsapp","to":"255700000000","recipient_type":"individual","type":"text","text":{"preview_url":true,"body":"https://example.com"}}`, //nolint:lll
+		},
+		{
+			name:    "hsm without hsm object",
+			payload: `{"to":"255700000000","type":"hsm"}`,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			var message Message
+			if err := json.Unmarshal([]byte(tt.payload), &message); err != nil {
+				t.Fatalf("unmarshal: %v", err)
+			}
+
+			got, err := ToCloudMessage(&message)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ToCloudMessage() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			b, err := json.Marshal(got)
+			if err != nil {
+				t.Fatalf("marshal: %v", err)
+			}
+
+			if string(b) != tt.want {
+				t.Errorf("ToCloudMessage() got = %s, want %s", b, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckContacts(t *testing.T) {
+	t.Parallel()
+	resp := CheckContacts(&ContactsRequest{Contacts: []string{"+1 (631) 555-1234", "nope"}})
+	if len(resp.Contacts) != 2 {
+		t.Fatalf("CheckContacts() got %d contacts, want 2", len(resp.Contacts))
+	}
+
+	if c := resp.Contacts[0]; c.Status != ContactStatusValid || c.WaID != "16315551234" {
+		t.Errorf("CheckContacts() got %+v", c)
+	}
+
+	if c := resp.Contacts[1]; c.Status != ContactStatusInvalid || c.WaID != "" {
+		t.Errorf("CheckContacts() got %+v", c)
+	}
+}
@@ -0,0 +1,330 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+// Package poller fetches time windowed data, like conversation analytics or quality history, at
+// regular intervals and remembers where it stopped.
+//
+// The poller splits time into windows of a fixed size and only fetches windows that are
+// complete. After every window is fetched its end is saved in a CursorStore, so a restarted
+// poller continues with the next window, without fetching a window twice or skipping one.
+//
+// Example:
+//
+//	store, _ := poller.NewFileCursorStore("/var/lib/app/cursors.json")
+//	p := poller.New("analytics", store, func(ctx context.Context, start, end time.Time) error {
+//		return fetchAnalytics(ctx, start, end)
+//	}, poller.WithWindow(time.Hour), poller.WithStart(time.Now().Add(-24*time.Hour)))
+//	err := p.Run(ctx, 10*time.Minute)
+package poller
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/lowkruc/go-whatsapp-api/lifecycle"
+)
+
+var ErrNoFetchFunc = errors.New("poller has no fetch function")
+
+type (
+	// Cursor is the position of a poller. Next is the start of the next window to fetch.
+	Cursor struct {
+		Name      string    `json:"name"`
+		Next      time.Time `json:"next"`
+		UpdatedAt time.Time `json:"updated_at"`
+	}
+
+	// CursorStore persists cursors. Load returns nil and no error when the poller has no cursor yet.
+	CursorStore interface {
+		Load(ctx context.Context, name string) (*Cursor, error)
+		Save(ctx context.Context, cursor *Cursor) error
+	}
+
+	// FetchFunc fetches the data of the window [start, end).
+	FetchFunc func(ctx context.Context, start, end time.Time) error
+
+	// Poller fetches complete windows from its cursor up to now.
+	Poller struct {
+		name   string
+		store  CursorStore
+		fetch  FetchFunc
+		window time.Duration
+		start  time.Time
+		now    func() time.Time
+		bus    *lifecycle.Bus
+	}
+
+	Option func(*Poller)
+
+	// MemoryCursorStore keeps cursors in memory, it is meant for tests.
+	MemoryCursorStore struct {
+		mu      sync.RWMutex
+		cursors map[string]Cursor
+	}
+
+	// FileCursorStore keeps cursors in a JSON file. The file is replaced atomically on every save.
+	FileCursorStore struct {
+		mu      sync.Mutex
+		path    string
+		cursors map[string]Cursor
+	}
+)
+
+// WithWindow sets the size of the fetched windows, the default is an hour. A window that is not
+// positive is replaced by the default.
+func WithWindow(window time.Duration) Option {
+	return func(p *Poller) {
+		p.window = window
+	}
+}
+
+// WithStart sets the start of the first window when the poller has no cursor. The default is the
+// start of the current window, so only new data is fetched.
+func WithStart(start time.Time) Option {
+	return func(p *Poller) {
+		p.start = start
+	}
+}
+
+// WithClock sets the function used to get the current time. Tests can pass the Now method of a
+// *clock.Fake to move time forward deterministically.
+func WithClock(now func() time.Time) Option {
+	return func(p *Poller) {
+		p.now = now
+	}
+}
+
+// WithLifecycle emits the phases of Run on bus, as the component "poller:" followed by the name:
+// Starting when it starts, Ready after the first Poll, Draining when the context is done and
+// Stopped when it returns.
+func WithLifecycle(bus *lifecycle.Bus) Option {
+	return func(p *Poller) {
+		p.bus = bus
+	}
+}
+
+// New creates a Poller. The name identifies its cursor in the store.
+func New(name string, store CursorStore, fetch FetchFunc, options ...Option) *Poller {
+	p := &Poller{
+		name:   name,
+		store:  store,
+		fetch:  fetch,
+		window: time.Hour,
+		now:    time.Now,
+	}
+
+	for _, option := range options {
+		option(p)
+	}
+	// an empty window would never move the cursor.
+	if p.window <= 0 {
+		p.window = time.Hour
+	}
+
+	return p
+}
+
+// Poll fetches every complete window from the cursor up to now and returns how many were
+// fetched. It stops at the first failed window, which is fetched again by the next Poll.
+func (p *Poller) Poll(ctx context.Context) (int, error) {
+	if p.fetch == nil {
+		return 0, ErrNoFetchFunc
+	}
+
+	cursor, err := p.store.Load(ctx, p.name)
+	if err != nil {
+		return 0, fmt.Errorf("poller %s: load cursor: %v", p.name, err)
+	}
+
+	now := p.now()
+	if cursor == nil {
+		start := p.start
+		if start.IsZero() {
+			start = now.Truncate(p.window)
+		}
+		cursor = &Cursor{Name: p.name, Next: start}
+	}
+
+	fetched := 0
+	for end := cursor.Next.Add(p.window); !end.After(now); end = cursor.Next.Add(p.window) {
+		if err := ctx.Err(); err != nil {
+			return fetched, err
+		}
+
+		if err := p.fetch(ctx, cursor.Next, end); err != nil {
+			return fetched, fmt.Errorf("poller %s: fetch %s - %s: %v", p.name,
+				cursor.Next.Format(time.RFC3339), end.Format(time.RFC3339), err)
+		}
+
+		cursor.Next = end
+		cursor.UpdatedAt = p.now()
+		if err := p.store.Save(ctx, cursor); err != nil {
+			return fetched, fmt.Errorf("poller %s: save cursor: %v", p.name, err)
+		}
+		fetched++
+	}
+
+	return fetched, nil
+}
+
+// Run calls Poll right away and then every interval until the context is done. Poll errors are
+// passed to onError when it is not nil and do not stop the poller.
+func (p *Poller) Run(ctx context.Context, interval time.Duration, onError ...func(err error)) error {
+	component := "poller:" + p.name
+	p.bus.Emit(component, lifecycle.Starting, nil)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for ready := false; ; {
+		if _, err := p.Poll(ctx); err != nil && ctx.Err() == nil {
+			for _, fn := range onError {
+				fn(err)
+			}
+		}
+		if !ready {
+			ready = true
+			p.bus.Emit(component, lifecycle.Ready, nil)
+		}
+
+		select {
+		case <-ctx.Done():
+			p.bus.Emit(component, lifecycle.Draining, nil)
+			p.bus.Emit(component, lifecycle.Stopped, nil)
+
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// NewMemoryCursorStore creates an empty MemoryCursorStore.
+func NewMemoryCursorStore() *MemoryCursorStore {
+	return &MemoryCursorStore{cursors: make(map[string]Cursor)}
+}
+
+func (s *MemoryCursorStore) Load(_ context.Context, name string) (*Cursor, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	cursor, ok := s.cursors[name]
+	if !ok {
+		return nil, nil //nolint:nilnil
+	}
+
+	return &cursor, nil
+}
+
+func (s *MemoryCursorStore) Save(_ context.Context, cursor *Cursor) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.cursors[cursor.Name] = *cursor
+
+	return nil
+}
+
+// NewFileCursorStore creates a FileCursorStore and loads the cursors saved in path, the file
+// is created on the first save if it does not exist.
+func NewFileCursorStore(path string) (*FileCursorStore, error) {
+	store := &FileCursorStore{
+		path:    path,
+		cursors: make(map[string]Cursor),
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return store, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("file cursor store: %v", err)
+	}
+
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &store.cursors); err != nil {
+			return nil, fmt.Errorf("file cursor store: %s: %v", path, err)
+		}
+	}
+
+	return store, nil
+}
+
+func (s *FileCursorStore) Load(_ context.Context, name string) (*Cursor, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	cursor, ok := s.cursors[name]
+	if !ok {
+		return nil, nil //nolint:nilnil
+	}
+
+	return &cursor, nil
+}
+
+func (s *FileCursorStore) Save(_ context.Context, cursor *Cursor) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	previous, existed := s.cursors[cursor.Name]
+	s.cursors[cursor.Name] = *cursor
+	if err := s.write(); err != nil {
+		if existed {
+			s.cursors[cursor.Name] = previous
+		} else {
+			delete(s.cursors, cursor.Name)
+		}
+
+		return err
+	}
+
+	return nil
+}
+
+func (s *FileCursorStore) write() error {
+	data, err := json.MarshalIndent(s.cursors, "", "  ")
+	if err != nil {
+		return fmt.Errorf("file cursor store: %v", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".*")
+	if err != nil {
+		return fmt.Errorf("file cursor store: %v", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+
+		return fmt.Errorf("file cursor store: %v", err)
+	}
+
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("file cursor store: %v", err)
+	}
+
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		return fmt.Errorf("file cursor store: %v", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,128 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package poller
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/lowkruc/go-whatsapp-api/lifecycle"
+)
+
+func TestPoller_Resume(t *testing.T) {
+	t.Parallel()
+	path := filepath.Join(t.TempDir(), "cursors.json")
+	start := time.Date(2023, 5, 1, 0, 0, 0, 0, time.UTC)
+	now := start.Add(3*time.Hour + 30*time.Minute)
+	clock := func() time.Time { return now }
+
+	var windows []time.Time
+	failAt := start.Add(2 * time.Hour)
+	fetch := func(ctx context.Context, from, to time.Time) error {
+		if from.Equal(failAt) {
+			return errors.New("temporary failure")
+		}
+		windows = append(windows, from)
+
+		return nil
+	}
+
+	store, err := NewFileCursorStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p := New("analytics", store, fetch, WithStart(start), WithClock(clock))
+	if n, err := p.Poll(context.TODO()); err == nil || n != 2 {
+		t.Fatalf("Poll() = %d, %v, want 2 windows and an error", n, err)
+	}
+
+	// restart the poller with a new store reading the same file.
+	failAt = time.Time{}
+	store, err = NewFileCursorStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p = New("analytics", store, fetch, WithStart(start), WithClock(clock))
+	if n, err := p.Poll(context.TODO()); err != nil || n != 1 {
+		t.Fatalf("Poll() after restart = %d, %v, want 1 window", n, err)
+	}
+
+	want := []time.Time{start, start.Add(time.Hour), start.Add(2 * time.Hour)}
+	if len(windows) != len(want) {
+		t.Fatalf("fetched windows %v, want %v", windows, want)
+	}
+	for i := range want {
+		if !windows[i].Equal(want[i]) {
+			t.Errorf("window %d starts at %v, want %v", i, windows[i], want[i])
+		}
+	}
+}
+
+func TestPoller_NonPositiveWindow(t *testing.T) {
+	t.Parallel()
+	start := time.Date(2023, 5, 1, 0, 0, 0, 0, time.UTC)
+	clock := func() time.Time { return start.Add(2 * time.Hour) }
+	fetch := func(ctx context.Context, from, to time.Time) error { return nil }
+
+	for _, window := range []time.Duration{0, -time.Minute} {
+		p := New("analytics", NewMemoryCursorStore(), fetch, WithStart(start), WithClock(clock), WithWindow(window))
+		if n, err := p.Poll(context.TODO()); err != nil || n != 2 {
+			t.Errorf("Poll() with a %v window = %d, %v, want the 2 windows of an hour", window, n, err)
+		}
+	}
+}
+
+func TestPoller_Lifecycle(t *testing.T) {
+	t.Parallel()
+	bus := lifecycle.NewBus()
+	var phases []lifecycle.Phase
+	bus.Subscribe(func(event lifecycle.Event) {
+		if event.Component == "poller:analytics" {
+			phases = append(phases, event.Phase)
+		}
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	p := New("analytics", NewMemoryCursorStore(), func(ctx context.Context, from, to time.Time) error { return nil },
+		WithLifecycle(bus))
+	bus.Subscribe(func(event lifecycle.Event) {
+		if event.Phase == lifecycle.Ready {
+			cancel()
+		}
+	})
+
+	if err := p.Run(ctx, time.Hour); !errors.Is(err, context.Canceled) {
+		t.Fatalf("Run() error = %v, want %v", err, context.Canceled)
+	}
+	want := []lifecycle.Phase{lifecycle.Starting, lifecycle.Ready, lifecycle.Draining, lifecycle.Stopped}
+	if len(phases) != len(want) {
+		t.Fatalf("phases %v, want %v", phases, want)
+	}
+	for i := range want {
+		if phases[i] != want[i] {
+			t.Errorf("phase %d is %q, want %q", i, phases[i], want[i])
+		}
+	}
+}
@@ -0,0 +1,441 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+// Package projection keeps read models of the conversations for dashboards: the open
+// conversations, the messages left unanswered and the traffic per hour. A Projector is fed the
+// notifications received by the webhooks listener and the events of the audit trail, and
+// answers queries from memory.
+//
+// Example:
+//
+//	projector := projection.NewProjector()
+//	listener := webhooks.NewEventListener(webhooks.WithAfterFunc(projector.After))
+//	client := whatsapp.NewClient(whatsapp.WithAuditTrail(projector))
+//	http.Handle("/dashboard/conversations", projector.Handler())
+package projection
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lowkruc/go-whatsapp-api/audit"
+	"github.com/lowkruc/go-whatsapp-api/clock"
+	werrors "github.com/lowkruc/go-whatsapp-api/errors"
+	"github.com/lowkruc/go-whatsapp-api/handoff"
+	"github.com/lowkruc/go-whatsapp-api/webhooks"
+)
+
+const (
+	// DefaultConversationTTL is how long a conversation stays open after the last message of the
+	// customer, the customer service window.
+	DefaultConversationTTL = 24 * time.Hour
+
+	// DefaultRetention is how many hourly buckets are kept.
+	DefaultRetention = 48
+)
+
+type (
+	// Conversation is the state of the conversation between a business phone number and a
+	// customer. Unanswered is the number of messages received since the business last replied.
+	Conversation struct {
+		Key            string    `json:"key"`
+		PhoneNumberID  string    `json:"phone_number_id"`
+		WaID           string    `json:"wa_id"`
+		Unanswered     int       `json:"unanswered"`
+		LastInboundAt  time.Time `json:"last_inbound_at,omitempty"`
+		LastOutboundAt time.Time `json:"last_outbound_at,omitempty"`
+	}
+
+	// Hour is the traffic of an hour. Inbound, Outbound and Failed are the messages received, sent
+	// and failed as reported by the webhooks, Sends and Throttled are the requests recorded in the
+	// audit trail. Failures counts the failed messages by werrors.Reason.
+	Hour struct {
+		Start     time.Time      `json:"start"`
+		Inbound   int            `json:"inbound"`
+		Outbound  int            `json:"outbound"`
+		Failed    int            `json:"failed"`
+		Failures  map[string]int `json:"failures,omitempty"`
+		Sends     int            `json:"sends"`
+		Throttled int            `json:"throttled"`
+	}
+
+	// Snapshot is the aggregates at a point in time. Hours are ordered from the oldest.
+	Snapshot struct {
+		At                 time.Time `json:"at"`
+		OpenConversations  int       `json:"open_conversations"`
+		Unanswered         int       `json:"unanswered_conversations"`
+		UnansweredMessages int       `json:"unanswered_messages"`
+		Hours              []*Hour   `json:"hours"`
+	}
+
+	// Projector maintains the read models. It is safe for concurrent use.
+	Projector struct {
+		mu            sync.RWMutex
+		clock         clock.Clock
+		ttl           time.Duration
+		retention     int
+		conversations map[string]*Conversation
+		hours         map[int64]*Hour
+		prunedAt      time.Time
+	}
+
+	ProjectorOption func(*Projector)
+)
+
+// WithClock sets the clock used to tell which conversations are open, the default is clock.Real.
+func WithClock(c clock.Clock) ProjectorOption {
+	return func(p *Projector) {
+		p.clock = c
+	}
+}
+
+// WithConversationTTL sets how long a conversation stays open after the last message of the
+// customer, DefaultConversationTTL by default.
+func WithConversationTTL(ttl time.Duration) ProjectorOption {
+	return func(p *Projector) {
+		p.ttl = ttl
+	}
+}
+
+// WithRetention sets how many hourly buckets are kept, DefaultRetention by default.
+func WithRetention(hours int) ProjectorOption {
+	return func(p *Projector) {
+		p.retention = hours
+	}
+}
+
+// NewProjector creates an empty Projector.
+func NewProjector(options ...ProjectorOption) *Projector {
+	p := &Projector{
+		clock:         clock.Real{},
+		ttl:           DefaultConversationTTL,
+		retention:     DefaultRetention,
+		conversations: make(map[string]*Conversation),
+		hours:         make(map[int64]*Hour),
+	}
+
+	for _, option := range options {
+		option(p)
+	}
+
+	return p
+}
+
+// After applies the notification, it is a webhooks.AfterFunc. Notifications that failed to be
+// handled are applied too, they were received all the same.
+func (p *Projector) After(_ context.Context, notification *webhooks.Notification, _ error) {
+	p.Apply(notification)
+}
+
+// Apply updates the read models with the messages and the statuses of the notification. A sent
+// status or a message echo counts as a reply of the business.
+func (p *Projector) Apply(notification *webhooks.Notification) {
+	if notification == nil {
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for _, entry := range notification.Entry {
+		if entry == nil {
+			continue
+		}
+		for _, change := range entry.Changes {
+			if change == nil {
+				continue
+			}
+			p.applyChange(change)
+		}
+	}
+
+	p.prune()
+}
+
+func (p *Projector) applyChange(change *webhooks.Change) {
+	if change.Field == webhooks.SMBMessageEchoesField {
+		echoes := &webhooks.MessageEchoes{}
+		if err := change.DecodeValue(echoes); err != nil || echoes.Metadata == nil {
+			return
+		}
+		for _, echo := range echoes.MessageEchoes {
+			if echo != nil {
+				p.outbound(echoes.Metadata.PhoneNumberID, echo.To, echo.Timestamp)
+			}
+		}
+
+		return
+	}
+
+	value := change.Value
+	if change.Field != webhooks.MessagesField || value == nil || value.Metadata == nil {
+		return
+	}
+
+	for _, message := range value.Messages {
+		if message != nil {
+			p.inbound(value.Metadata.PhoneNumberID, message.From, message.Timestamp)
+		}
+	}
+
+	for _, status := range value.Statuses {
+		switch {
+		case status == nil || strings.EqualFold(status.Type, webhooks.StatusTypePayment):
+		case strings.EqualFold(status.StatusValue, string(webhooks.MessageStatusSent)):
+			p.outbound(value.Metadata.PhoneNumberID, status.RecipientID, status.Timestamp)
+		case strings.EqualFold(status.StatusValue, string(webhooks.MessageStatusFailed)):
+			p.failed(status)
+		}
+	}
+}
+
+func (p *Projector) inbound(phoneNumberID, waID, timestamp string) {
+	at := p.parseTime(timestamp)
+	conversation := p.conversation(phoneNumberID, waID)
+	conversation.Unanswered++
+	if at.After(conversation.LastInboundAt) {
+		conversation.LastInboundAt = at
+	}
+	p.hour(at).Inbound++
+}
+
+func (p *Projector) outbound(phoneNumberID, waID, timestamp string) {
+	at := p.parseTime(timestamp)
+	conversation := p.conversation(phoneNumberID, waID)
+	if !at.Before(conversation.LastInboundAt) {
+		conversation.Unanswered = 0
+	}
+	if at.After(conversation.LastOutboundAt) {
+		conversation.LastOutboundAt = at
+	}
+	p.hour(at).Outbound++
+}
+
+func (p *Projector) failed(status *webhooks.Status) {
+	hour := p.hour(p.parseTime(status.Timestamp))
+	hour.Failed++
+
+	reasons := status.FailureReasons()
+	if len(reasons) == 0 {
+		reasons = append(reasons, werrors.ReasonUnknown)
+	}
+	if hour.Failures == nil {
+		hour.Failures = make(map[string]int)
+	}
+	hour.Failures[string(reasons[0])]++
+}
+
+// Append counts the sends and the throttled sends of the audit events, so that the Projector can
+// be given to whatsapp.WithAuditTrail, alone or next to another trail. Other events are ignored.
+func (p *Projector) Append(_ context.Context, event *audit.Event) error {
+	if event.Type != audit.SendAttempted && event.Type != audit.SendThrottled {
+		return nil
+	}
+
+	at := event.Time
+	if at.IsZero() {
+		at = p.clock.Now()
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if event.Type == audit.SendAttempted {
+		p.hour(at).Sends++
+	} else {
+		p.hour(at).Throttled++
+	}
+	p.prune()
+
+	return nil
+}
+
+// Conversation returns a copy of the conversation, or nil if nothing was seen for it.
+func (p *Projector) Conversation(phoneNumberID, waID string) *Conversation {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	conversation, ok := p.conversations[handoff.Key(phoneNumberID, waID)]
+	if !ok {
+		return nil
+	}
+	c := *conversation
+
+	return &c
+}
+
+// OpenConversations returns the open conversations, the ones with unanswered messages first and
+// then from the most recent.
+func (p *Projector) OpenConversations() []*Conversation {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	now := p.clock.Now()
+	var open []*Conversation
+	for _, conversation := range p.conversations {
+		if p.open(conversation, now) {
+			c := *conversation
+			open = append(open, &c)
+		}
+	}
+
+	sort.Slice(open, func(i, j int) bool {
+		if (open[i].Unanswered > 0) != (open[j].Unanswered > 0) {
+			return open[i].Unanswered > 0
+		}
+
+		return open[i].LastInboundAt.After(open[j].LastInboundAt)
+	})
+
+	return open
+}
+
+// Snapshot returns the aggregates.
+func (p *Projector) Snapshot() *Snapshot {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	now := p.clock.Now()
+	snapshot := &Snapshot{At: now, Hours: make([]*Hour, 0, len(p.hours))}
+	for _, conversation := range p.conversations {
+		if !p.open(conversation, now) {
+			continue
+		}
+		snapshot.OpenConversations++
+		if conversation.Unanswered > 0 {
+			snapshot.Unanswered++
+			snapshot.UnansweredMessages += conversation.Unanswered
+		}
+	}
+
+	oldest := p.oldestHour(now)
+	for _, hour := range p.hours {
+		if hour.Start.Before(oldest) {
+			continue
+		}
+		h := *hour
+		if hour.Failures != nil {
+			h.Failures = make(map[string]int, len(hour.Failures))
+			for reason, n := range hour.Failures {
+				h.Failures[reason] = n
+			}
+		}
+		snapshot.Hours = append(snapshot.Hours, &h)
+	}
+	sort.Slice(snapshot.Hours, func(i, j int) bool {
+		return snapshot.Hours[i].Start.Before(snapshot.Hours[j].Start)
+	})
+
+	return snapshot
+}
+
+// Handler serves the Snapshot as JSON, or the open conversations when the query has
+// conversations=open.
+func (p *Projector) Handler() http.Handler {
+	return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		if request.Method != http.MethodGet {
+			writer.WriteHeader(http.StatusMethodNotAllowed)
+
+			return
+		}
+
+		var body any = p.Snapshot()
+		if request.URL.Query().Get("conversations") == "open" {
+			body = p.OpenConversations()
+		}
+
+		writer.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(writer).Encode(body)
+	})
+}
+
+func (p *Projector) open(conversation *Conversation, now time.Time) bool {
+	return !conversation.LastInboundAt.IsZero() && now.Sub(conversation.LastInboundAt) < p.ttl
+}
+
+func (p *Projector) conversation(phoneNumberID, waID string) *Conversation {
+	key := handoff.Key(phoneNumberID, waID)
+	conversation, ok := p.conversations[key]
+	if !ok {
+		conversation = &Conversation{Key: key, PhoneNumberID: phoneNumberID, WaID: waID}
+		p.conversations[key] = conversation
+	}
+
+	return conversation
+}
+
+func (p *Projector) hour(at time.Time) *Hour {
+	start := at.UTC().Truncate(time.Hour)
+	hour, ok := p.hours[start.Unix()]
+	if !ok {
+		hour = &Hour{Start: start}
+		p.hours[start.Unix()] = hour
+	}
+
+	return hour
+}
+
+// prune drops the hours past the retention and the conversations closed for as long as they were
+// open, at most once a minute.
+func (p *Projector) prune() {
+	now := p.clock.Now()
+	if now.Sub(p.prunedAt) < time.Minute {
+		return
+	}
+	p.prunedAt = now
+
+	oldest := p.oldestHour(now)
+	for start, hour := range p.hours {
+		if hour.Start.Before(oldest) {
+			delete(p.hours, start)
+		}
+	}
+
+	for key, conversation := range p.conversations {
+		last := conversation.LastInboundAt
+		if conversation.LastOutboundAt.After(last) {
+			last = conversation.LastOutboundAt
+		}
+		if now.Sub(last) > 2*p.ttl {
+			delete(p.conversations, key)
+		}
+	}
+}
+
+// oldestHour returns the start of the oldest hour kept.
+func (p *Projector) oldestHour(now time.Time) time.Time {
+	return now.UTC().Truncate(time.Hour).Add(-time.Duration(p.retention-1) * time.Hour)
+}
+
+// parseTime parses a webhook timestamp, seconds since the epoch, it returns the current time when
+// the timestamp is invalid.
+func (p *Projector) parseTime(timestamp string) time.Time {
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return p.clock.Now()
+	}
+
+	return time.Unix(seconds, 0).UTC()
+}
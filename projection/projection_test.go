@@ -0,0 +1,127 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package projection
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/lowkruc/go-whatsapp-api/audit"
+	"github.com/lowkruc/go-whatsapp-api/clock"
+	werrors "github.com/lowkruc/go-whatsapp-api/errors"
+	"github.com/lowkruc/go-whatsapp-api/webhooks"
+)
+
+func notification(t *testing.T, payload string) *webhooks.Notification {
+	t.Helper()
+	var n webhooks.Notification
+	if err := json.Unmarshal([]byte(payload), &n); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+
+	return &n
+}
+
+const (
+	inboundPayload = `{"object":"whatsapp_business_account","entry":[{"id":"1","changes":[{"field":"messages",
+		"value":{"messaging_product":"whatsapp","metadata":{"phone_number_id":"1000"},
+		"messages":[
+			{"from":"255700000001","id":"wamid.1","timestamp":"1672567200","type":"text","text":{"body":"hi"}},
+			{"from":"255700000001","id":"wamid.2","timestamp":"1672567260","type":"text","text":{"body":"hello?"}},
+			{"from":"255700000002","id":"wamid.3","timestamp":"1672570800","type":"text","text":{"body":"hey"}}
+		]}}]}]}`
+
+	sentPayload = `{"object":"whatsapp_business_account","entry":[{"id":"1","changes":[{"field":"messages",
+		"value":{"messaging_product":"whatsapp","metadata":{"phone_number_id":"1000"},
+		"statuses":[{"id":"wamid.4","recipient_id":"255700000001","status":"sent","timestamp":"1672570900"},
+			{"id":"wamid.5","recipient_id":"255700000003","status":"failed","timestamp":"1672570900",
+			"errors":[{"code":131047,"title":"Re-engagement message"}]}]
+		}}]}]}`
+)
+
+func TestProjector(t *testing.T) {
+	t.Parallel()
+	// 2023-01-01 11:00 UTC, an hour after the last message.
+	fake := clock.NewFake(time.Unix(1672570800, 0).Add(time.Hour))
+	projector := NewProjector(WithClock(fake))
+
+	projector.After(context.TODO(), notification(t, inboundPayload), nil)
+	projector.Apply(notification(t, sentPayload))
+	_ = projector.Append(context.TODO(), &audit.Event{Type: audit.SendAttempted, Time: time.Unix(1672570900, 0)})
+	_ = projector.Append(context.TODO(), &audit.Event{Type: audit.SendThrottled, Time: time.Unix(1672570900, 0)})
+
+	snapshot := projector.Snapshot()
+	if snapshot.OpenConversations != 2 || snapshot.Unanswered != 1 || snapshot.UnansweredMessages != 1 {
+		t.Errorf("unexpected snapshot %+v", snapshot)
+	}
+
+	if len(snapshot.Hours) != 2 {
+		t.Fatalf("got %d hours, want 2", len(snapshot.Hours))
+	}
+	if h := snapshot.Hours[0]; h.Inbound != 2 || h.Outbound != 0 {
+		t.Errorf("unexpected first hour %+v", h)
+	}
+	if h := snapshot.Hours[1]; h.Inbound != 1 || h.Outbound != 1 || h.Sends != 1 || h.Throttled != 1 ||
+		h.Failed != 1 || h.Failures[string(werrors.ReasonReengagement)] != 1 {
+		t.Errorf("unexpected second hour %+v", h)
+	}
+
+	open := projector.OpenConversations()
+	if len(open) != 2 || open[0].WaID != "255700000002" {
+		t.Errorf("unexpected open conversations %+v", open)
+	}
+
+	if c := projector.Conversation("1000", "255700000001"); c == nil || c.Unanswered != 0 || c.LastOutboundAt.IsZero() {
+		t.Errorf("unexpected conversation %+v", c)
+	}
+
+	fake.Advance(DefaultConversationTTL)
+	if snapshot := projector.Snapshot(); snapshot.OpenConversations != 0 {
+		t.Errorf("got %d open conversations after the ttl, want 0", snapshot.OpenConversations)
+	}
+}
+
+func TestProjector_Handler(t *testing.T) {
+	t.Parallel()
+	fake := clock.NewFake(time.Unix(1672570800, 0))
+	projector := NewProjector(WithClock(fake))
+	projector.Apply(notification(t, inboundPayload))
+
+	recorder := httptest.NewRecorder()
+	projector.Handler().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/?conversations=open", nil))
+
+	var open []*Conversation
+	if err := json.NewDecoder(recorder.Body).Decode(&open); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(open) != 2 || open[0].Unanswered == 0 {
+		t.Errorf("unexpected open conversations %+v", open)
+	}
+
+	recorder = httptest.NewRecorder()
+	projector.Handler().ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/", nil))
+	if recorder.Code != http.StatusMethodNotAllowed {
+		t.Errorf("got status %d, want %d", recorder.Code, http.StatusMethodNotAllowed)
+	}
+}
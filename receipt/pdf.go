@@ -0,0 +1,174 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package receipt
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+const (
+	pdfLinesPerPage = 50
+	pdfLineHeight   = 14
+	pdfFontSize     = 10
+	pdfMarginLeft   = 50
+	pdfFirstLineY   = 780
+)
+
+// PDFRenderer renders a plain text receipt into a PDF with the standard Helvetica font, it has no
+// dependencies. Characters outside Latin-1 are replaced with a question mark.
+type PDFRenderer struct{}
+
+func (PDFRenderer) Filename(receipt *Receipt) string {
+	return "receipt-" + receipt.Number + ".pdf"
+}
+
+func (PDFRenderer) Render(_ context.Context, receipt *Receipt, w io.Writer) error {
+	return writePDF(w, Lines(receipt))
+}
+
+// Lines returns the receipt as lines of text.
+func Lines(receipt *Receipt) []string {
+	var lines []string
+	if m := receipt.Merchant; m != nil {
+		lines = append(lines, m.Name)
+		for _, s := range []string{m.Address, m.Phone, m.Email} {
+			if s != "" {
+				lines = append(lines, s)
+			}
+		}
+		if m.TaxID != "" {
+			lines = append(lines, "Tax ID: "+m.TaxID)
+		}
+		lines = append(lines, "")
+	}
+
+	lines = append(lines, "Receipt "+receipt.Number)
+	if !receipt.IssuedAt.IsZero() {
+		lines = append(lines, "Date: "+receipt.IssuedAt.Format("2006-01-02 15:04"))
+	}
+	if receipt.Customer != "" {
+		lines = append(lines, "Customer: "+receipt.Customer)
+	}
+	lines = append(lines, "")
+
+	for _, item := range receipt.Items {
+		lines = append(lines, fmt.Sprintf("%s  %g x %.2f = %.2f %s",
+			item.Name, item.Quantity, item.UnitPrice, item.Total(), item.Currency))
+	}
+	lines = append(lines, "")
+
+	totals := receipt.Totals()
+	currencies := make([]string, 0, len(totals))
+	for currency := range totals {
+		currencies = append(currencies, currency)
+	}
+	sort.Strings(currencies)
+	for _, currency := range currencies {
+		lines = append(lines, fmt.Sprintf("Total: %.2f %s", totals[currency], currency))
+	}
+
+	if receipt.Note != "" {
+		lines = append(lines, "", receipt.Note)
+	}
+
+	return lines
+}
+
+// writePDF writes a minimal PDF 1.4 document with the lines, pdfLinesPerPage lines per page.
+func writePDF(w io.Writer, lines []string) error {
+	var pages [][]string
+	for len(lines) > pdfLinesPerPage {
+		pages = append(pages, lines[:pdfLinesPerPage])
+		lines = lines[pdfLinesPerPage:]
+	}
+	pages = append(pages, lines)
+
+	// objects: 1 catalog, 2 pages, 3 font, then a page and its content for every page.
+	var objects []string
+	kids := make([]string, len(pages))
+	for i := range pages {
+		kids[i] = fmt.Sprintf("%d 0 R", 4+2*i) //nolint:gomnd
+	}
+	objects = append(objects,
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(pages)),
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>",
+	)
+	for i, page := range pages {
+		var content bytes.Buffer
+		fmt.Fprintf(&content, "BT /F1 %d Tf %d TL %d %d Td\n", pdfFontSize, pdfLineHeight, pdfMarginLeft, pdfFirstLineY)
+		for _, line := range page {
+			fmt.Fprintf(&content, "(%s) '\n", pdfString(line))
+		}
+		content.WriteString("ET")
+
+		objects = append(objects,
+			fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 595 842] "+
+				"/Resources << /Font << /F1 3 0 R >> >> /Contents %d 0 R >>", 5+2*i), //nolint:gomnd
+			fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", content.Len(), content.String()),
+		)
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("%PDF-1.4\n")
+	offsets := make([]int, len(objects))
+	for i, object := range objects {
+		offsets[i] = buf.Len()
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", i+1, object)
+	}
+
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref)
+
+	_, err := w.Write(buf.Bytes())
+
+	return err
+}
+
+// pdfString escapes s for a PDF literal string, in the WinAnsi encoding of the font.
+func pdfString(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r < ' ':
+			b.WriteByte(' ')
+		case r < 0x80: //nolint:gomnd
+			b.WriteRune(r)
+		case r <= 0xff: //nolint:gomnd
+			fmt.Fprintf(&b, "\\%03o", r)
+		default:
+			b.WriteByte('?')
+		}
+	}
+
+	return b.String()
+}
@@ -0,0 +1,173 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+// Package receipt turns an order received through webhooks into a receipt document and sends
+// it back to the customer as a document message. The document is produced by a Renderer, PDF is
+// the default and a renderer backed by any PDF or templating library can be plugged in.
+//
+// Example:
+//
+//	listener.OnOrderMessage(func(ctx context.Context, nctx *webhooks.NotificationContext,
+//		mctx *webhooks.MessageContext, order *webhooks.Order) error {
+//		r := receipt.FromOrder(mctx.ID, merchant, mctx.From, order, productNames)
+//		_, err := receipt.Send(ctx, client, mctx.From, r, receipt.PDFRenderer{})
+//		return err
+//	})
+package receipt
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	whatsapp "github.com/lowkruc/go-whatsapp-api"
+	"github.com/lowkruc/go-whatsapp-api/webhooks"
+)
+
+var ErrEmptyReceipt = errors.New("receipt has no items")
+
+type (
+	// Merchant is the business issuing the receipt.
+	Merchant struct {
+		Name    string
+		Address string
+		TaxID   string
+		Phone   string
+		Email   string
+	}
+
+	// Item is a line of the receipt.
+	Item struct {
+		ProductRetailerID string
+		Name              string
+		Quantity          float64
+		UnitPrice         float64
+		Currency          string
+	}
+
+	// Receipt is the data rendered into the document.
+	Receipt struct {
+		Number   string
+		IssuedAt time.Time
+		Merchant *Merchant
+		Customer string
+		Items    []*Item
+		Note     string
+	}
+
+	// Renderer renders a receipt into a document. Filename names the document, it is shown to the
+	// customer.
+	Renderer interface {
+		Render(ctx context.Context, receipt *Receipt, w io.Writer) error
+		Filename(receipt *Receipt) string
+	}
+
+	// Client uploads and sends media, *whatsapp.Client implements it.
+	Client interface {
+		UploadMedia(ctx context.Context, mediaType whatsapp.MediaType, filename string,
+			fr io.Reader) (*whatsapp.UploadMediaResponse, error)
+		SendMedia(ctx context.Context, recipient string, req *whatsapp.MediaMessage,
+			cacheOptions *whatsapp.CacheOptions) (*whatsapp.ResponseMessage, error)
+	}
+)
+
+// FromOrder builds a receipt from an order message. names maps product retailer IDs to the names
+// shown on the receipt, the ID is shown for products without a name.
+func FromOrder(number string, merchant *Merchant, customer string, order *webhooks.Order,
+	names map[string]string,
+) *Receipt {
+	receipt := &Receipt{
+		Number:   number,
+		IssuedAt: time.Now(),
+		Merchant: merchant,
+		Customer: customer,
+	}
+	if order == nil {
+		return receipt
+	}
+
+	receipt.Note = order.Text
+	for _, product := range order.ProductItems {
+		if product == nil {
+			continue
+		}
+		name := names[product.ProductRetailerID]
+		if name == "" {
+			name = product.ProductRetailerID
+		}
+		receipt.Items = append(receipt.Items, &Item{
+			ProductRetailerID: product.ProductRetailerID,
+			Name:              name,
+			Quantity:          product.Quantity,
+			UnitPrice:         product.ItemPrice,
+			Currency:          product.Currency,
+		})
+	}
+
+	return receipt
+}
+
+// Total returns the amount of the item.
+func (item *Item) Total() float64 {
+	return item.Quantity * item.UnitPrice
+}
+
+// Totals returns the total of the receipt per currency.
+func (receipt *Receipt) Totals() map[string]float64 {
+	totals := make(map[string]float64)
+	for _, item := range receipt.Items {
+		totals[item.Currency] += item.Total()
+	}
+
+	return totals
+}
+
+// Send renders the receipt, uploads it and sends it to recipient as a document message.
+func Send(ctx context.Context, client Client, recipient string, receipt *Receipt, renderer Renderer,
+) (*whatsapp.ResponseMessage, error) {
+	if receipt == nil || len(receipt.Items) == 0 {
+		return nil, fmt.Errorf("send receipt: %v", ErrEmptyReceipt)
+	}
+
+	var buf bytes.Buffer
+	if err := renderer.Render(ctx, receipt, &buf); err != nil {
+		return nil, fmt.Errorf("send receipt: render: %v", err)
+	}
+
+	filename := renderer.Filename(receipt)
+	uploaded, err := client.UploadMedia(ctx, whatsapp.MediaTypeDocument, filename, &buf)
+	if err != nil {
+		return nil, fmt.Errorf("send receipt: %v", err)
+	}
+
+	response, err := client.SendMedia(ctx, recipient, &whatsapp.MediaMessage{
+		Type:     whatsapp.MediaTypeDocument,
+		MediaID:  uploaded.ID,
+		Filename: filename,
+		Caption:  "Receipt " + receipt.Number,
+	}, nil)
+	if err != nil {
+		return nil, fmt.Errorf("send receipt: %v", err)
+	}
+
+	return response, nil
+}
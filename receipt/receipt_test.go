@@ -0,0 +1,139 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package receipt
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	whatsapp "github.com/lowkruc/go-whatsapp-api"
+	"github.com/lowkruc/go-whatsapp-api/webhooks"
+)
+
+type fakeClient struct {
+	uploaded  []byte
+	filename  string
+	mediaType whatsapp.MediaType
+	sent      *whatsapp.MediaMessage
+	uploadErr error
+}
+
+func (c *fakeClient) UploadMedia(_ context.Context, mediaType whatsapp.MediaType, filename string,
+	fr io.Reader,
+) (*whatsapp.UploadMediaResponse, error) {
+	if c.uploadErr != nil {
+		return nil, c.uploadErr
+	}
+	c.mediaType, c.filename = mediaType, filename
+	c.uploaded, _ = io.ReadAll(fr)
+
+	return &whatsapp.UploadMediaResponse{ID: "media-1"}, nil
+}
+
+func (c *fakeClient) SendMedia(_ context.Context, _ string, req *whatsapp.MediaMessage,
+	_ *whatsapp.CacheOptions,
+) (*whatsapp.ResponseMessage, error) {
+	c.sent = req
+
+	return &whatsapp.ResponseMessage{}, nil
+}
+
+func testOrder() *webhooks.Order {
+	return &webhooks.Order{
+		CatalogID: "catalog",
+		Text:      "Thanks (again)",
+		ProductItems: []*webhooks.ProductItem{
+			{ProductRetailerID: "sku-1", Quantity: 2, ItemPrice: 10.5, Currency: "USD"},
+			{ProductRetailerID: "sku-2", Quantity: 1, ItemPrice: 4, Currency: "USD"},
+		},
+	}
+}
+
+func TestFromOrder(t *testing.T) {
+	t.Parallel()
+	r := FromOrder("A-1", &Merchant{Name: "Shop"}, "255700000000", testOrder(),
+		map[string]string{"sku-1": "Coffee"})
+
+	if len(r.Items) != 2 {
+		t.Fatalf("expected 2 items, got %d", len(r.Items))
+	}
+	if r.Items[0].Name != "Coffee" || r.Items[1].Name != "sku-2" {
+		t.Fatalf("unexpected item names: %q, %q", r.Items[0].Name, r.Items[1].Name)
+	}
+	if got := r.Totals()["USD"]; got != 25 {
+		t.Fatalf("expected total 25, got %v", got)
+	}
+}
+
+func TestPDFRenderer(t *testing.T) {
+	t.Parallel()
+	r := FromOrder("A-1", &Merchant{Name: "Café"}, "255700000000", testOrder(), nil)
+
+	var buf bytes.Buffer
+	if err := (PDFRenderer{}).Render(context.Background(), r, &buf); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	if !strings.HasPrefix(out, "%PDF-") || !strings.HasSuffix(out, "%%EOF\n") {
+		t.Fatalf("output is not a PDF document")
+	}
+	for _, want := range []string{`(Caf\351) '`, `(Thanks \(again\)) '`, "(Total: 25.00 USD) '"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in document", want)
+		}
+	}
+
+	// the xref offsets must point at the objects.
+	start := strings.Index(out, "xref\n")
+	if idx := strings.Index(out, "1 0 obj"); idx <= 0 || !strings.Contains(out[start:], "0000000009 00000 n") {
+		t.Errorf("unexpected first object offset %d", idx)
+	}
+}
+
+func TestSend(t *testing.T) {
+	t.Parallel()
+	client := &fakeClient{}
+	r := FromOrder("A-1", &Merchant{Name: "Shop"}, "255700000000", testOrder(), nil)
+
+	if _, err := Send(context.Background(), client, "255700000000", r, PDFRenderer{}); err != nil {
+		t.Fatal(err)
+	}
+	if client.mediaType != whatsapp.MediaTypeDocument || client.filename != "receipt-A-1.pdf" {
+		t.Fatalf("unexpected upload %q %q", client.mediaType, client.filename)
+	}
+	if !bytes.HasPrefix(client.uploaded, []byte("%PDF-")) {
+		t.Fatalf("uploaded content is not a PDF document")
+	}
+	if client.sent == nil || client.sent.MediaID != "media-1" || client.sent.Filename != "receipt-A-1.pdf" {
+		t.Fatalf("unexpected media message %+v", client.sent)
+	}
+
+	client = &fakeClient{uploadErr: errors.New("boom")}
+	if _, err := Send(context.Background(), client, "255700000000", r, PDFRenderer{}); err == nil {
+		t.Fatal("expected upload error")
+	}
+	if _, err := Send(context.Background(), client, "255700000000", &Receipt{}, PDFRenderer{}); err == nil {
+		t.Fatal("expected empty receipt error")
+	}
+}
@@ -0,0 +1,315 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package replay
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	whttp "github.com/lowkruc/go-whatsapp-api/http"
+	"github.com/lowkruc/go-whatsapp-api/webhooks"
+)
+
+var ErrInvalidPayload = errors.New("payload is not JSON")
+
+//nolint:gochecknoglobals
+var recordedHeaders = []string{"Content-Type", webhooks.SignatureHeaderKey, "User-Agent"}
+
+type (
+	// Filter selects the records loaded from a Store. Records received before From or at or after
+	// To are skipped, zero times are not checked. Limit bounds the number of records, zero means
+	// no limit.
+	Filter struct {
+		From  time.Time
+		To    time.Time
+		Limit int
+	}
+
+	// Store persists the recorded requests. Load returns the records in the order they were
+	// received. Implementations must be safe for concurrent use.
+	Store interface {
+		Save(ctx context.Context, record *Record) error
+		Load(ctx context.Context, filter *Filter) ([]*Record, error)
+	}
+
+	// MemoryStore keeps the records in memory, it is meant for tests.
+	MemoryStore struct {
+		mu      sync.RWMutex
+		records []*Record
+	}
+
+	// FileStore appends the records to a JSON lines file that LoadCorpus and the replay command
+	// read. The bodies are compacted to fit on a line.
+	FileStore struct {
+		mu   sync.Mutex
+		path string
+		file *os.File
+	}
+
+	// Recorder saves the webhook requests received by a handler in a Store before passing them on.
+	Recorder struct {
+		store   Store
+		now     func() time.Time
+		onError func(ctx context.Context, err error)
+		options *webhooks.HandlerOptions
+	}
+
+	RecorderOption func(*Recorder)
+)
+
+// matches reports whether the record is selected by the filter.
+func (filter *Filter) matches(record *Record) bool {
+	if filter == nil {
+		return true
+	}
+	if !filter.From.IsZero() && record.ReceivedAt.Before(filter.From) {
+		return false
+	}
+
+	return filter.To.IsZero() || record.ReceivedAt.Before(filter.To)
+}
+
+func (filter *Filter) full(n int) bool {
+	return filter != nil && filter.Limit > 0 && n >= filter.Limit
+}
+
+// NewMemoryStore creates an empty MemoryStore.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{}
+}
+
+func (s *MemoryStore) Save(_ context.Context, record *Record) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	clone := *record
+	s.records = append(s.records, &clone)
+
+	return nil
+}
+
+func (s *MemoryStore) Load(_ context.Context, filter *Filter) ([]*Record, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var records []*Record
+	for _, record := range s.records {
+		if filter.full(len(records)) {
+			break
+		}
+		if filter.matches(record) {
+			clone := *record
+			records = append(records, &clone)
+		}
+	}
+
+	return records, nil
+}
+
+// OpenFileStore opens the file at path for appending, creating it when needed.
+func OpenFileStore(path string) (*FileStore, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600) //nolint:gomnd
+	if err != nil {
+		return nil, fmt.Errorf("open file store: %v", err)
+	}
+
+	return &FileStore{path: path, file: file}, nil
+}
+
+func (s *FileStore) Save(_ context.Context, record *Record) error {
+	line, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("file store: %v", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, err = s.file.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("file store: %v", err)
+	}
+
+	return nil
+}
+
+func (s *FileStore) Load(_ context.Context, filter *Filter) ([]*Record, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	file, err := os.Open(s.path)
+	if err != nil {
+		return nil, fmt.Errorf("file store: %v", err)
+	}
+	defer file.Close()
+
+	all, err := LoadCorpus(bufio.NewReader(file))
+	if err != nil {
+		return nil, fmt.Errorf("file store: %v", err)
+	}
+
+	var records []*Record
+	for _, record := range all {
+		if filter.full(len(records)) {
+			break
+		}
+		if filter.matches(record) {
+			records = append(records, record)
+		}
+	}
+
+	return records, nil
+}
+
+// Close closes the file.
+func (s *FileStore) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.file.Close()
+}
+
+// WithRecorderClock sets the function that returns the time the requests are received at.
+func WithRecorderClock(now func() time.Time) RecorderOption {
+	return func(r *Recorder) {
+		r.now = now
+	}
+}
+
+// WithRecorderErrorHandler sets the function called when a request can not be recorded. The
+// request is passed on to the handler anyway.
+func WithRecorderErrorHandler(fn func(ctx context.Context, err error)) RecorderOption {
+	return func(r *Recorder) {
+		r.onError = fn
+	}
+}
+
+// WithRecorderLimits sets the handler options whose MaxBodySize and ReadTimeout bound the read of
+// the bodies, those of the listener the requests are passed on to. The requests that cross a limit
+// are not recorded, the handler gets the error when it reads the body. Without them the bodies are
+// read whole.
+func WithRecorderLimits(options *webhooks.HandlerOptions) RecorderOption {
+	return func(r *Recorder) {
+		r.options = options
+	}
+}
+
+// NewRecorder creates a Recorder that saves the requests in store.
+func NewRecorder(store Store, options ...RecorderOption) *Recorder {
+	r := &Recorder{store: store, now: time.Now}
+	for _, option := range options {
+		option(r)
+	}
+
+	return r
+}
+
+// Middleware records the requests before passing them to next, typically the notification
+// handler of a webhooks.EventListener. Compressed bodies are recorded decompressed, Meta signs
+// the uncompressed payload.
+func (r *Recorder) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, request *http.Request) {
+		raw, err := webhooks.ReadBody(w, request, r.options)
+		if err != nil {
+			request.Body = io.NopCloser(&errReader{err: err})
+		} else {
+			_ = request.Body.Close()
+			request.Body = io.NopCloser(bytes.NewReader(raw))
+			err = r.Record(request.Context(), request.Header, raw)
+		}
+		if err != nil && r.onError != nil {
+			r.onError(request.Context(), err)
+		}
+
+		next.ServeHTTP(w, request)
+	})
+}
+
+// Record saves a request received with header and body.
+func (r *Recorder) Record(ctx context.Context, header http.Header, body []byte) error {
+	if encoding := header.Get("Content-Encoding"); whttp.IsEncoded(encoding) {
+		var maxSize int64
+		if r.options != nil {
+			maxSize = r.options.MaxBodySize
+		}
+		decoded, err := whttp.DecodeContentEncoding(encoding, body, maxSize)
+		if err != nil {
+			return fmt.Errorf("record: %v", err)
+		}
+		body = decoded
+	}
+	if !json.Valid(body) {
+		return fmt.Errorf("record: %v", ErrInvalidPayload)
+	}
+
+	record := &Record{
+		ReceivedAt: r.now(),
+		Headers:    make(map[string]string, len(recordedHeaders)),
+		Body:       append(json.RawMessage(nil), body...),
+	}
+	for _, key := range recordedHeaders {
+		if value := header.Get(key); value != "" {
+			record.Headers[key] = value
+		}
+	}
+
+	if err := r.store.Save(ctx, record); err != nil {
+		return fmt.Errorf("record: %v", err)
+	}
+
+	return nil
+}
+
+// Replay sends the records to target one at a time, in order, and returns how many were
+// accepted with a 2xx status code. It stops at the first record that is not, so that a backfill
+// can resume from it. When secret is set the bodies are signed again, otherwise the recorded
+// signatures are sent. Handlers that deduplicate notifications drop the records they already
+// handled.
+func Replay(ctx context.Context, target Target, records []*Record, secret string) (int, error) {
+	for i, record := range records {
+		if err := ctx.Err(); err != nil {
+			return i, err
+		}
+
+		status, err := target(ctx, record.Body, headerOf(record, secret))
+		if err != nil {
+			return i, fmt.Errorf("replay record %d: %v", i, err)
+		}
+		if status < 200 || status > 299 {
+			return i, fmt.Errorf("replay record %d: status %d", i, status)
+		}
+	}
+
+	return len(records), nil
+}
+
+// errReader fails every read with err, it passes the errors of the recorder on to the handler.
+type errReader struct {
+	err error
+}
+
+func (r *errReader) Read([]byte) (int, error) {
+	return 0, r.err
+}
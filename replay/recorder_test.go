@@ -0,0 +1,144 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package replay
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lowkruc/go-whatsapp-api/webhooks"
+)
+
+const textPayload = `{"object":"whatsapp_business_account","entry":[{"id":"102290129340398","changes":[{"field":"messages",` +
+	`"value":{"messaging_product":"whatsapp","metadata":{"phone_number_id":"106540352242922"},` +
+	`"messages":[{"from":"16505551234","id":"wamid.1","timestamp":"1750263773","type":"text","text":{"body":"hi"}}]}}]}]}`
+
+func TestRecorder_Replay(t *testing.T) {
+	t.Parallel()
+	file, err := OpenFileStore(filepath.Join(t.TempDir(), "records.jsonl"))
+	if err != nil {
+		t.Fatalf("OpenFileStore() error = %v", err)
+	}
+	defer file.Close()
+
+	start := time.Date(2023, 1, 1, 9, 0, 0, 0, time.UTC)
+	for _, store := range []Store{NewMemoryStore(), file} {
+		now := start
+		recorder := NewRecorder(store, WithRecorderClock(func() time.Time {
+			now = now.Add(time.Minute)
+
+			return now
+		}))
+
+		var received []string
+		listener := webhooks.NewEventListener()
+		listener.OnTextMessage(func(ctx context.Context, nctx *webhooks.NotificationContext,
+			mctx *webhooks.MessageContext, text *webhooks.Text,
+		) error {
+			received = append(received, text.Body)
+
+			return nil
+		})
+		handler := recorder.Middleware(listener.NotificationHandler())
+
+		for _, payload := range []string{textPayload, strings.Replace(textPayload, `"hi"`, `"bye"`, 1)} {
+			request := httptest.NewRequest(http.MethodPost, "/webhooks", strings.NewReader(payload))
+			request.Header.Set(webhooks.SignatureHeaderKey, "sha256=00")
+			handler.ServeHTTP(httptest.NewRecorder(), request)
+		}
+
+		records, err := store.Load(context.TODO(), &Filter{From: start.Add(2 * time.Minute)})
+		if err != nil {
+			t.Fatalf("%T Load() error = %v", store, err)
+		}
+		if len(records) != 1 || records[0].Headers[webhooks.SignatureHeaderKey] != "sha256=00" {
+			t.Fatalf("%T got records %v, want the second request", store, records)
+		}
+
+		replayed, err := Replay(context.TODO(), HandlerTarget(listener.NotificationHandler()), records, "")
+		if err != nil || replayed != 1 {
+			t.Fatalf("%T Replay() = %d, %v, want 1, nil", store, replayed, err)
+		}
+		if strings.Join(received, ",") != "hi,bye,bye" {
+			t.Errorf("%T got texts %v, want [hi bye bye]", store, received)
+		}
+	}
+}
+
+func TestReplay_StopsAtFailure(t *testing.T) {
+	t.Parallel()
+	records, _ := LoadCorpus(strings.NewReader(corpus))
+	calls := 0
+	target := func(ctx context.Context, body []byte, header http.Header) (int, error) {
+		calls++
+		if calls == 2 {
+			return http.StatusInternalServerError, nil
+		}
+
+		return http.StatusOK, nil
+	}
+
+	replayed, err := Replay(context.TODO(), target, records, "")
+	if replayed != 1 || err == nil {
+		t.Errorf("Replay() = %d, %v, want 1 and an error", replayed, err)
+	}
+}
+
+func TestRecorder_MiddlewareLimits(t *testing.T) {
+	t.Parallel()
+	store := NewMemoryStore()
+	options := &webhooks.HandlerOptions{MaxBodySize: 64}
+	var recordErr error
+	recorder := NewRecorder(store, WithRecorderLimits(options),
+		WithRecorderErrorHandler(func(ctx context.Context, err error) {
+			recordErr = err
+		}))
+	var handlerErr error
+	listener := webhooks.NewEventListener(webhooks.WithHandlerOptions(options),
+		webhooks.WithNotificationErrorHandler(func(ctx context.Context, r *http.Request,
+			err error,
+		) *webhooks.NotificationErrHandlerResponse {
+			handlerErr = err
+
+			return &webhooks.NotificationErrHandlerResponse{Skip: true}
+		}))
+	handler := recorder.Middleware(listener.NotificationHandler())
+
+	recorderResponse := httptest.NewRecorder()
+	handler.ServeHTTP(recorderResponse, httptest.NewRequest(http.MethodPost, "/webhooks", strings.NewReader(textPayload)))
+
+	if recorderResponse.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("got status %d, want %d", recorderResponse.Code, http.StatusRequestEntityTooLarge)
+	}
+
+	if !errors.Is(recordErr, webhooks.ErrBodyTooLarge) || !errors.Is(handlerErr, webhooks.ErrBodyTooLarge) {
+		t.Errorf("got errors %v and %v, want %v", recordErr, handlerErr, webhooks.ErrBodyTooLarge)
+	}
+
+	if records, _ := store.Load(context.TODO(), nil); len(records) != 0 {
+		t.Errorf("got %d records, want none", len(records))
+	}
+}
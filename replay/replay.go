@@ -0,0 +1,354 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+// Package replay replays recorded webhook traffic against a notification handler to measure
+// how it behaves under load. A corpus is a JSON lines file, every line is either a notification
+// payload or a record with the headers and the body of a request.
+//
+//	{"object":"whatsapp_business_account","entry":[...]}
+//	{"headers":{"Content-Type":"application/json"},"body":{"object":"whatsapp_business_account","entry":[...]}}
+//
+// When a secret is configured the bodies are signed again, so handlers that validate
+// signatures accept them.
+//
+// Example:
+//
+//	records, _ := replay.LoadCorpus(file)
+//	report, err := replay.Run(ctx, replay.HandlerTarget(listener.NotificationHandler()), records, &replay.Config{
+//		Rate:        200,
+//		Concurrency: 16,
+//		Requests:    10000,
+//	})
+//	fmt.Println(report)
+//
+// The traffic of production is recorded with a Recorder in a Store, a file in the corpus format
+// or a SQL table, and fed again through a listener with Replay to debug an incident or to
+// backfill after a handler bug. The options are the HandlerOptions of the listener, the recorder
+// reads the bodies within their MaxBodySize and ReadTimeout:
+//
+//	recorder := replay.NewRecorder(store, replay.WithRecorderLimits(options))
+//	http.Handle("/webhooks", recorder.Middleware(listener.NotificationHandler()))
+//	...
+//	records, _ := store.Load(ctx, &replay.Filter{From: incidentStart, To: incidentEnd})
+//	replayed, err := replay.Replay(ctx, replay.HandlerTarget(fixed.NotificationHandler()), records, secret)
+package replay
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	wcrypto "github.com/lowkruc/go-whatsapp-api/crypto"
+	"github.com/lowkruc/go-whatsapp-api/webhooks"
+)
+
+var ErrEmptyCorpus = errors.New("corpus has no records")
+
+type (
+	// Record is a recorded webhook request. ReceivedAt is set by the Recorder.
+	Record struct {
+		ReceivedAt time.Time         `json:"received_at,omitempty"`
+		Headers    map[string]string `json:"headers,omitempty"`
+		Body       json.RawMessage   `json:"body"`
+	}
+
+	// Target receives the replayed requests and returns the response status code.
+	Target func(ctx context.Context, body []byte, header http.Header) (int, error)
+
+	// Config configures a replay.
+	//
+	// Rate is the number of requests per second, zero means as fast as possible.
+	// Concurrency is the number of requests in flight, the default is 1.
+	// Requests is the number of requests to send, the corpus is replayed in a loop. The
+	// default is the size of the corpus.
+	// Secret is the app secret used to sign the bodies, no signature is set when it is empty.
+	Config struct {
+		Rate        float64
+		Concurrency int
+		Requests    int
+		Secret      string
+	}
+
+	// Report summarizes a replay. A request is an error when the target returns an error or a
+	// status code other than 2xx.
+	Report struct {
+		Requests   int
+		Errors     int
+		StatusCode map[int]int
+		Duration   time.Duration
+		Min        time.Duration
+		Mean       time.Duration
+		P50        time.Duration
+		P90        time.Duration
+		P99        time.Duration
+		Max        time.Duration
+	}
+)
+
+// LoadCorpus reads a JSON lines corpus, empty lines are skipped.
+func LoadCorpus(reader io.Reader) ([]*Record, error) {
+	var records []*Record
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024) //nolint:gomnd
+	line := 0
+	for scanner.Scan() {
+		line++
+		data := bytes.TrimSpace(scanner.Bytes())
+		if len(data) == 0 {
+			continue
+		}
+
+		var probe map[string]json.RawMessage
+		if err := json.Unmarshal(data, &probe); err != nil {
+			return nil, fmt.Errorf("corpus line %d: %v", line, err)
+		}
+
+		record := &Record{}
+		if _, ok := probe["body"]; ok {
+			if err := json.Unmarshal(data, record); err != nil {
+				return nil, fmt.Errorf("corpus line %d: %v", line, err)
+			}
+		} else {
+			record.Body = append(json.RawMessage(nil), data...)
+		}
+		records = append(records, record)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read corpus: %v", err)
+	}
+
+	return records, nil
+}
+
+// HandlerTarget sends the requests to handler in process.
+func HandlerTarget(handler http.Handler) Target {
+	return func(ctx context.Context, body []byte, header http.Header) (int, error) {
+		request := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(body)).WithContext(ctx)
+		request.Header = header
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, request)
+
+		return recorder.Code, nil
+	}
+}
+
+// URLTarget posts the requests to url with client, http.DefaultClient is used when client is nil.
+func URLTarget(client *http.Client, url string) Target {
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	return func(ctx context.Context, body []byte, header http.Header) (int, error) {
+		request, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+		if err != nil {
+			return 0, err
+		}
+		request.Header = header
+
+		response, err := client.Do(request)
+		if err != nil {
+			return 0, err
+		}
+		_, _ = io.Copy(io.Discard, response.Body)
+		_ = response.Body.Close()
+
+		return response.StatusCode, nil
+	}
+}
+
+type result struct {
+	latency time.Duration
+	status  int
+	err     error
+}
+
+// Run replays the records against target and returns the report. It stops early when the
+// context is done, the report then covers the requests that were sent.
+func Run(ctx context.Context, target Target, records []*Record, config *Config) (*Report, error) {
+	if len(records) == 0 {
+		return nil, ErrEmptyCorpus
+	}
+
+	if config == nil {
+		config = &Config{}
+	}
+
+	concurrency := config.Concurrency
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	total := config.Requests
+	if total < 1 {
+		total = len(records)
+	}
+
+	var tick <-chan time.Time
+	if config.Rate > 0 {
+		ticker := time.NewTicker(time.Duration(float64(time.Second) / config.Rate))
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	jobs := make(chan *Record)
+	results := make(chan result, concurrency)
+	var wg sync.WaitGroup
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for record := range jobs {
+				header := headerOf(record, config.Secret)
+				start := time.Now()
+				status, err := target(ctx, record.Body, header)
+				results <- result{latency: time.Since(start), status: status, err: err}
+			}
+		}()
+	}
+
+	started := time.Now()
+	go func() {
+		defer close(jobs)
+		for i := 0; i < total; i++ {
+			if tick != nil {
+				select {
+				case <-ctx.Done():
+					return
+				case <-tick:
+				}
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case jobs <- records[i%len(records)]:
+			}
+		}
+	}()
+
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	report := &Report{StatusCode: make(map[int]int)}
+	latencies := make([]time.Duration, 0, total)
+	for r := range results {
+		report.Requests++
+		latencies = append(latencies, r.latency)
+		if r.err != nil || r.status < 200 || r.status > 299 {
+			report.Errors++
+		}
+		if r.err == nil {
+			report.StatusCode[r.status]++
+		}
+	}
+	report.Duration = time.Since(started)
+	report.summarize(latencies)
+
+	return report, nil
+}
+
+func headerOf(record *Record, secret string) http.Header {
+	header := make(http.Header, len(record.Headers)+2) //nolint:gomnd
+	header.Set("Content-Type", "application/json")
+	for key, value := range record.Headers {
+		header.Set(key, value)
+	}
+
+	if secret != "" {
+		signature := wcrypto.Default().HMACSHA256([]byte(secret), record.Body)
+		header.Set(webhooks.SignatureHeaderKey, "sha256="+hex.EncodeToString(signature))
+	}
+
+	return header
+}
+
+func (report *Report) summarize(latencies []time.Duration) {
+	if len(latencies) == 0 {
+		return
+	}
+
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+	var sum time.Duration
+	for _, latency := range latencies {
+		sum += latency
+	}
+
+	percentile := func(p float64) time.Duration {
+		return latencies[int(p*float64(len(latencies)-1))]
+	}
+
+	report.Min = latencies[0]
+	report.Max = latencies[len(latencies)-1]
+	report.Mean = sum / time.Duration(len(latencies))
+	report.P50 = percentile(0.50) //nolint:gomnd
+	report.P90 = percentile(0.90) //nolint:gomnd
+	report.P99 = percentile(0.99) //nolint:gomnd
+}
+
+// ErrorRate returns the share of requests that failed.
+func (report *Report) ErrorRate() float64 {
+	if report.Requests == 0 {
+		return 0
+	}
+
+	return float64(report.Errors) / float64(report.Requests)
+}
+
+// Throughput returns the number of requests per second.
+func (report *Report) Throughput() float64 {
+	if report.Duration <= 0 {
+		return 0
+	}
+
+	return float64(report.Requests) / report.Duration.Seconds()
+}
+
+// String formats the report for the terminal.
+func (report *Report) String() string {
+	codes := make([]int, 0, len(report.StatusCode))
+	for code := range report.StatusCode {
+		codes = append(codes, code)
+	}
+	sort.Ints(codes)
+
+	statuses := make([]string, 0, len(codes))
+	for _, code := range codes {
+		statuses = append(statuses, fmt.Sprintf("%d=%d", code, report.StatusCode[code]))
+	}
+
+	return fmt.Sprintf("requests: %d in %v (%.1f/s)\nerrors: %d (%.2f%%)\nstatus: %s\n"+
+		"latency: min=%v mean=%v p50=%v p90=%v p99=%v max=%v",
+		report.Requests, report.Duration.Round(time.Millisecond), report.Throughput(),
+		report.Errors, report.ErrorRate()*100, strings.Join(statuses, " "), //nolint:gomnd
+		report.Min, report.Mean, report.P50, report.P90, report.P99, report.Max)
+}
@@ -0,0 +1,72 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package replay
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/lowkruc/go-whatsapp-api/webhooks"
+)
+
+const corpus = `{"object":"whatsapp_business_account","entry":[]}
+
+{"headers":{"X-Replay":"1"},"body":{"object":"whatsapp_business_account","entry":[]}}
+`
+
+func TestRun(t *testing.T) {
+	t.Parallel()
+	records, err := LoadCorpus(strings.NewReader(corpus))
+	if err != nil {
+		t.Fatalf("LoadCorpus() error = %v", err)
+	}
+
+	if len(records) != 2 || records[1].Headers["X-Replay"] != "1" {
+		t.Fatalf("unexpected records %+v", records)
+	}
+
+	target := func(ctx context.Context, body []byte, header http.Header) (int, error) {
+		signature, err := webhooks.ExtractSignatureFromHeader(header)
+		if err != nil || !webhooks.ValidateSignature(body, signature, "secret") {
+			return http.StatusUnauthorized, nil
+		}
+
+		if header.Get("X-Replay") != "" {
+			return http.StatusInternalServerError, nil
+		}
+
+		return http.StatusOK, nil
+	}
+
+	report, err := Run(context.TODO(), target, records, &Config{Concurrency: 1, Requests: 10, Secret: "secret"})
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	if report.Requests != 10 || report.Errors != 5 || report.ErrorRate() != 0.5 {
+		t.Errorf("unexpected report\n%s", report)
+	}
+
+	if report.Min > report.P50 || report.P50 > report.Max {
+		t.Errorf("latencies are not ordered\n%s", report)
+	}
+}
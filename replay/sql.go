@@ -0,0 +1,133 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package replay
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type (
+	// SQLStore keeps the records in a table of a SQL database, created with a schema like:
+	//
+	//	CREATE TABLE webhook_records (
+	//		id          BIGSERIAL PRIMARY KEY,
+	//		received_at TIMESTAMP NOT NULL,
+	//		headers     TEXT NOT NULL,
+	//		body        TEXT NOT NULL
+	//	);
+	//
+	// The id column orders the records received at the same time. Placeholder formats the n-th
+	// query argument, starting at 1, for the driver: "?" by default, see DollarPlaceholder.
+	SQLStore struct {
+		DB          *sql.DB
+		Table       string
+		Placeholder func(n int) string
+	}
+)
+
+// DollarPlaceholder formats the query arguments as $1, $2... for PostgreSQL drivers.
+func DollarPlaceholder(n int) string {
+	return "$" + strconv.Itoa(n)
+}
+
+// NewSQLStore creates a SQLStore using the table of db.
+func NewSQLStore(db *sql.DB, table string) *SQLStore {
+	return &SQLStore{DB: db, Table: table}
+}
+
+func (s *SQLStore) placeholder(n int) string {
+	if s.Placeholder == nil {
+		return "?"
+	}
+
+	return s.Placeholder(n)
+}
+
+func (s *SQLStore) Save(ctx context.Context, record *Record) error {
+	headers, err := json.Marshal(record.Headers)
+	if err != nil {
+		return fmt.Errorf("sql store: %v", err)
+	}
+
+	query := fmt.Sprintf("INSERT INTO %s (received_at, headers, body) VALUES (%s, %s, %s)",
+		s.Table, s.placeholder(1), s.placeholder(2), s.placeholder(3)) //nolint:gomnd
+	if _, err = s.DB.ExecContext(ctx, query, record.ReceivedAt.UTC(), string(headers), string(record.Body)); err != nil {
+		return fmt.Errorf("sql store: %v", err)
+	}
+
+	return nil
+}
+
+func (s *SQLStore) Load(ctx context.Context, filter *Filter) ([]*Record, error) {
+	var (
+		conditions []string
+		args       []any
+	)
+	if filter != nil && !filter.From.IsZero() {
+		args = append(args, filter.From.UTC())
+		conditions = append(conditions, "received_at >= "+s.placeholder(len(args)))
+	}
+	if filter != nil && !filter.To.IsZero() {
+		args = append(args, filter.To.UTC())
+		conditions = append(conditions, "received_at < "+s.placeholder(len(args)))
+	}
+
+	query := "SELECT received_at, headers, body FROM " + s.Table
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	query += " ORDER BY received_at, id"
+	if filter != nil && filter.Limit > 0 {
+		query += " LIMIT " + strconv.Itoa(filter.Limit)
+	}
+
+	rows, err := s.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("sql store: %v", err)
+	}
+	defer rows.Close()
+
+	var records []*Record
+	for rows.Next() {
+		var (
+			receivedAt    time.Time
+			headers, body string
+		)
+		if err = rows.Scan(&receivedAt, &headers, &body); err != nil {
+			return nil, fmt.Errorf("sql store: %v", err)
+		}
+		record := &Record{ReceivedAt: receivedAt, Body: json.RawMessage(body)}
+		if err = json.Unmarshal([]byte(headers), &record.Headers); err != nil {
+			return nil, fmt.Errorf("sql store: headers: %v", err)
+		}
+		records = append(records, record)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("sql store: %v", err)
+	}
+
+	return records, nil
+}
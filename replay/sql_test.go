@@ -0,0 +1,142 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package replay
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeDriver is a database/sql driver that keeps the inserted rows and returns all of them to
+// every query, it records the queries to check the SQL built by the store. It is its own
+// driver.Connector, so that every test opens its own database without registering a driver.
+type fakeDriver struct {
+	mu      sync.Mutex
+	rows    [][]driver.Value
+	queries []string
+}
+
+type (
+	fakeConn struct{ driver *fakeDriver }
+	fakeStmt struct {
+		driver *fakeDriver
+		query  string
+	}
+	fakeRows struct {
+		rows [][]driver.Value
+		next int
+	}
+)
+
+func (d *fakeDriver) Open(string) (driver.Conn, error) { return &fakeConn{driver: d}, nil }
+
+func (d *fakeDriver) Connect(context.Context) (driver.Conn, error) { return d.Open("") }
+
+func (d *fakeDriver) Driver() driver.Driver { return d }
+
+func (c *fakeConn) Prepare(query string) (driver.Stmt, error) {
+	return &fakeStmt{driver: c.driver, query: query}, nil
+}
+
+func (c *fakeConn) Close() error { return nil }
+
+func (c *fakeConn) Begin() (driver.Tx, error) { return nil, errors.New("not supported") }
+
+func (s *fakeStmt) Close() error { return nil }
+
+func (s *fakeStmt) NumInput() int { return -1 }
+
+func (s *fakeStmt) Exec(args []driver.Value) (driver.Result, error) {
+	s.driver.mu.Lock()
+	defer s.driver.mu.Unlock()
+	s.driver.queries = append(s.driver.queries, s.query)
+	s.driver.rows = append(s.driver.rows, args)
+
+	return driver.RowsAffected(1), nil
+}
+
+func (s *fakeStmt) Query(args []driver.Value) (driver.Rows, error) {
+	s.driver.mu.Lock()
+	defer s.driver.mu.Unlock()
+	s.driver.queries = append(s.driver.queries, s.query)
+
+	return &fakeRows{rows: s.driver.rows}, nil
+}
+
+func (r *fakeRows) Columns() []string { return []string{"received_at", "headers", "body"} }
+
+func (r *fakeRows) Close() error { return nil }
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if r.next >= len(r.rows) {
+		return io.EOF
+	}
+	copy(dest, r.rows[r.next])
+	r.next++
+
+	return nil
+}
+
+func TestSQLStore(t *testing.T) {
+	t.Parallel()
+	fake := &fakeDriver{}
+	db := sql.OpenDB(fake)
+	defer db.Close()
+
+	store := NewSQLStore(db, "webhook_records")
+	store.Placeholder = DollarPlaceholder
+	receivedAt := time.Date(2023, 1, 1, 9, 0, 0, 0, time.UTC)
+	record := &Record{
+		ReceivedAt: receivedAt,
+		Headers:    map[string]string{"Content-Type": "application/json"},
+		Body:       json.RawMessage(`{"object":"whatsapp_business_account","entry":[]}`),
+	}
+
+	ctx := context.TODO()
+	if err := store.Save(ctx, record); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	records, err := store.Load(ctx, &Filter{From: receivedAt, To: receivedAt.Add(time.Hour), Limit: 10})
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	want := []string{
+		"INSERT INTO webhook_records (received_at, headers, body) VALUES ($1, $2, $3)",
+		"SELECT received_at, headers, body FROM webhook_records WHERE received_at >= $1 AND received_at < $2 " +
+			"ORDER BY received_at, id LIMIT 10",
+	}
+	if strings.Join(fake.queries, "\n") != strings.Join(want, "\n") {
+		t.Errorf("got queries\n%s\nwant\n%s", strings.Join(fake.queries, "\n"), strings.Join(want, "\n"))
+	}
+
+	if len(records) != 1 || !records[0].ReceivedAt.Equal(receivedAt) ||
+		string(records[0].Body) != string(record.Body) || records[0].Headers["Content-Type"] != "application/json" {
+		t.Errorf("got records %+v, want %+v", records, record)
+	}
+}
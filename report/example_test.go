@@ -0,0 +1,66 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package report_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/lowkruc/go-whatsapp-api/report"
+	"github.com/lowkruc/go-whatsapp-api/webhooks"
+)
+
+// tracker stands for the client of an error tracker, like *sentry.Hub.
+type tracker struct{}
+
+func (tracker) CaptureException(err error, tags map[string]string) {
+	fmt.Printf("exception %q component=%s\n", err, tags[report.TagComponent])
+}
+
+func (tracker) CaptureMessage(message string, tags map[string]string) {
+	fmt.Printf("message %q component=%s\n", message, tags[report.TagComponent])
+}
+
+// trackerAdapter adapts the tracker to report.ErrorReporter.
+type trackerAdapter struct {
+	tracker tracker
+}
+
+func (a trackerAdapter) CaptureError(_ context.Context, err error, tags report.Tags) {
+	a.tracker.CaptureException(err, tags)
+}
+
+func (a trackerAdapter) CaptureMessage(_ context.Context, message string, tags report.Tags) {
+	a.tracker.CaptureMessage(message, tags)
+}
+
+func Example() {
+	reporter := trackerAdapter{}
+
+	// pass the reporter to the webhooks handlers and to the client with
+	// whatsapp.WithErrorReporter(reporter).
+	_ = &webhooks.HandlerOptions{ErrorReporter: reporter}
+
+	reporter.CaptureError(context.TODO(), errors.New("boom"), report.Tags{report.TagComponent: "webhooks"})
+
+	// Output:
+	// exception "boom" component=webhooks
+}
@@ -0,0 +1,100 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+// Package report defines ErrorReporter, the interface the module uses to make failures visible in
+// error trackers like Sentry or Rollbar. The webhooks handlers report hook panics and hook dispatch
+// failures, the client reports sends that failed after all their attempts.
+//
+// The module does not depend on any error tracker, an adapter is a small type that implements
+// ErrorReporter with the tracker's client, see the example.
+package report
+
+import (
+	"context"
+	"fmt"
+	"log"
+)
+
+// Tags that are set by the module.
+const (
+	TagComponent = "component"
+	TagStage     = "stage"
+	TagOperation = "operation"
+)
+
+type (
+	// Tags are key value pairs attached to a reported error or message, like the component and
+	// the stage where it happened.
+	Tags map[string]string
+
+	// ErrorReporter sends errors and messages to an error tracker. Implementations must be safe
+	// for concurrent use and should not block, they are called on the request path.
+	ErrorReporter interface {
+		CaptureError(ctx context.Context, err error, tags Tags)
+		CaptureMessage(ctx context.Context, message string, tags Tags)
+	}
+
+	// NoOp is the ErrorReporter used when none is set, it discards everything.
+	NoOp struct{}
+
+	// PanicError is the error reported for a recovered panic.
+	PanicError struct {
+		Value any
+		Stack []byte
+	}
+
+	logReporter struct {
+		logger *log.Logger
+	}
+)
+
+func (NoOp) CaptureError(context.Context, error, Tags) {}
+
+func (NoOp) CaptureMessage(context.Context, string, Tags) {}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("panic: %v", e.Value)
+}
+
+// OrNoOp returns reporter, or NoOp when reporter is nil.
+func OrNoOp(reporter ErrorReporter) ErrorReporter {
+	if reporter == nil {
+		return NoOp{}
+	}
+
+	return reporter
+}
+
+// Logger returns an ErrorReporter that prints errors and messages with their tags to logger.
+// A nil logger uses the standard logger.
+func Logger(logger *log.Logger) ErrorReporter {
+	if logger == nil {
+		logger = log.Default()
+	}
+
+	return &logReporter{logger: logger}
+}
+
+func (r *logReporter) CaptureError(_ context.Context, err error, tags Tags) {
+	r.logger.Printf("error: %v %v", err, tags)
+}
+
+func (r *logReporter) CaptureMessage(_ context.Context, message string, tags Tags) {
+	r.logger.Printf("message: %s %v", message, tags)
+}
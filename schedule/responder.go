@@ -0,0 +1,160 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package schedule
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/lowkruc/go-whatsapp-api/clock"
+	"github.com/lowkruc/go-whatsapp-api/handoff"
+	"github.com/lowkruc/go-whatsapp-api/webhooks"
+)
+
+type (
+	// OutOfOfficeReply sends the out-of-office reply to the author of the message. reopens is the
+	// time the business opens next, zero when it does not open within a year.
+	OutOfOfficeReply func(ctx context.Context, nctx *webhooks.NotificationContext, message *webhooks.Message,
+		reopens time.Time) error
+
+	// AutoResponder replies to the messages received outside business hours. Every customer gets a
+	// single reply per closed period.
+	AutoResponder struct {
+		calendar *Calendar
+		reply    OutOfOfficeReply
+		clock    clock.Clock
+		mu       sync.Mutex
+		replied  map[string]time.Time
+	}
+
+	AutoResponderOption func(*AutoResponder)
+)
+
+// WithClock sets the clock used to tell whether a message is received outside business hours, the
+// default is clock.Real.
+func WithClock(c clock.Clock) AutoResponderOption {
+	return func(a *AutoResponder) {
+		a.clock = c
+	}
+}
+
+// NewAutoResponder creates an AutoResponder that sends the reply using the calendar.
+func NewAutoResponder(calendar *Calendar, reply OutOfOfficeReply, options ...AutoResponderOption) *AutoResponder {
+	a := &AutoResponder{
+		calendar: calendar,
+		reply:    reply,
+		clock:    clock.Real{},
+		replied:  make(map[string]time.Time),
+	}
+
+	for _, option := range options {
+		option(a)
+	}
+
+	return a
+}
+
+// Hook returns an OnMessageReceivedHook that sends the out-of-office reply when the business is
+// closed and then calls next. next may be nil.
+func (a *AutoResponder) Hook(next webhooks.OnMessageReceivedHook) webhooks.OnMessageReceivedHook {
+	return func(ctx context.Context, nctx *webhooks.NotificationContext, message *webhooks.Message) error {
+		if err := a.respond(ctx, nctx, message); err != nil {
+			return err
+		}
+
+		if next == nil {
+			return nil
+		}
+
+		return next(ctx, nctx, message)
+	}
+}
+
+func (a *AutoResponder) respond(ctx context.Context, nctx *webhooks.NotificationContext,
+	message *webhooks.Message,
+) error {
+	now := a.clock.Now()
+	reopens, ok := a.calendar.NextOpen(now)
+	if ok && !reopens.After(now) {
+		return nil
+	}
+
+	key := handoff.KeyFromContext(nctx, message.From)
+	a.mu.Lock()
+	for k, until := range a.replied {
+		if !until.After(now) {
+			delete(a.replied, k)
+		}
+	}
+	if _, done := a.replied[key]; done {
+		a.mu.Unlock()
+
+		return nil
+	}
+	if ok {
+		a.replied[key] = reopens
+	} else {
+		a.replied[key] = now.AddDate(1, 0, 0)
+		reopens = time.Time{}
+	}
+	a.mu.Unlock()
+
+	if err := a.reply(ctx, nctx, message, reopens); err != nil {
+		// let the next message try again.
+		a.mu.Lock()
+		delete(a.replied, key)
+		a.mu.Unlock()
+
+		return err
+	}
+
+	return nil
+}
+
+// Strategy keeps the candidates that are on duty according to their calendar and lets next choose
+// among them, agents without a calendar are always on duty. A nil next routes to the least loaded
+// of them. A nil c uses clock.Real.
+func Strategy(calendars map[string]*Calendar, c clock.Clock, next handoff.Strategy) handoff.Strategy {
+	if next == nil {
+		next = handoff.LeastLoaded()
+	}
+	if c == nil {
+		c = clock.Real{}
+	}
+
+	return handoff.StrategyFunc(func(ctx context.Context, request *handoff.Request,
+		candidates []*handoff.Candidate,
+	) (*handoff.Candidate, error) {
+		now := c.Now()
+		var onDuty []*handoff.Candidate
+		for _, candidate := range candidates {
+			calendar, ok := calendars[candidate.Agent.ID]
+			if !ok || calendar.Open(now) {
+				onDuty = append(onDuty, candidate)
+			}
+		}
+		if len(onDuty) == 0 {
+			return nil, nil //nolint:nilnil
+		}
+
+		return next.Choose(ctx, request, onDuty)
+	})
+}
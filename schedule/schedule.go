@@ -0,0 +1,269 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+// Package schedule describes the business hours of a business, weekly hours with holiday
+// exceptions in a timezone, and uses them to answer customers with an out-of-office reply, to
+// route conversations only to agents on duty and to defer notifications to the next opening.
+//
+// The Calendar holding the schedule can be updated at runtime with Set.
+//
+// Example:
+//
+//	calendar, err := schedule.NewCalendar(&schedule.Schedule{
+//		Timezone: "Africa/Dar_es_Salaam",
+//		Weekly: map[time.Weekday][]schedule.Window{
+//			time.Monday: {{Start: "08:00", End: "12:30"}, {Start: "13:30", End: "17:00"}},
+//			time.Saturday: {{Start: "09:00", End: "13:00"}},
+//		},
+//		Holidays: []*schedule.Holiday{{Date: "2023-12-25", Name: "Christmas"}},
+//	})
+//	responder := schedule.NewAutoResponder(calendar, sendOutOfOfficeReply)
+//	listener.OnMessageReceived(responder.Hook(nil))
+package schedule
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	dateLayout = "2006-01-02"
+
+	// maxLookahead is how far NextOpen looks for an opening.
+	maxLookahead = 366
+)
+
+var (
+	ErrInvalidWindow   = errors.New("invalid window")
+	ErrInvalidHoliday  = errors.New("invalid holiday")
+	ErrInvalidTimezone = errors.New("invalid timezone")
+)
+
+type (
+	// Window is a period of a day during which the business is open, from Start included to End
+	// excluded, both as "15:04" in the schedule timezone. End can be "24:00". A window can not go
+	// past midnight, split it between the two days instead.
+	Window struct {
+		Start string `json:"start"`
+		End   string `json:"end"`
+	}
+
+	// Holiday replaces the weekly hours of a date, "2006-01-02". The business is closed the whole
+	// day when Hours is empty.
+	Holiday struct {
+		Date  string    `json:"date"`
+		Name  string    `json:"name,omitempty"`
+		Hours []*Window `json:"hours,omitempty"`
+	}
+
+	// Schedule is the business hours. Timezone is an IANA timezone name, UTC when empty. Days
+	// missing from Weekly are closed.
+	Schedule struct {
+		Timezone string                    `json:"timezone,omitempty"`
+		Weekly   map[time.Weekday][]Window `json:"weekly,omitempty"`
+		Holidays []*Holiday                `json:"holidays,omitempty"`
+	}
+
+	// Calendar answers whether the business is open using a Schedule that can be replaced at
+	// runtime. It is safe for concurrent use.
+	Calendar struct {
+		mu       sync.RWMutex
+		schedule *Schedule
+		location *time.Location
+		holidays map[string]*Holiday
+	}
+)
+
+// NewCalendar creates a Calendar with the schedule.
+func NewCalendar(schedule *Schedule) (*Calendar, error) {
+	c := &Calendar{}
+	if err := c.Set(schedule); err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// Set validates the schedule and replaces the schedule of the calendar with it. The calendar is
+// left unchanged when the schedule is invalid.
+func (c *Calendar) Set(schedule *Schedule) error {
+	location, err := time.LoadLocation(schedule.Timezone)
+	if err != nil {
+		return fmt.Errorf("schedule: %v: %v", ErrInvalidTimezone, err)
+	}
+
+	for day, windows := range schedule.Weekly {
+		for i := range windows {
+			if err := windows[i].validate(); err != nil {
+				return fmt.Errorf("schedule: %s: %v", day, err)
+			}
+		}
+	}
+
+	holidays := make(map[string]*Holiday, len(schedule.Holidays))
+	for _, holiday := range schedule.Holidays {
+		if _, err := time.Parse(dateLayout, holiday.Date); err != nil {
+			return fmt.Errorf("schedule: %v: %q", ErrInvalidHoliday, holiday.Date)
+		}
+		for _, window := range holiday.Hours {
+			if err := window.validate(); err != nil {
+				return fmt.Errorf("schedule: %s: %v", holiday.Date, err)
+			}
+		}
+		holidays[holiday.Date] = holiday
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.schedule = schedule
+	c.location = location
+	c.holidays = holidays
+
+	return nil
+}
+
+// Schedule returns the current schedule.
+func (c *Calendar) Schedule() *Schedule {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return c.schedule
+}
+
+// Open reports whether the business is open at t.
+func (c *Calendar) Open(t time.Time) bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	year, month, day := t.In(c.location).Date()
+	for _, window := range c.windows(year, month, day) {
+		start, end := c.bounds(year, month, day, window)
+		if !t.Before(start) && t.Before(end) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// NextOpen returns t if the business is open at t, otherwise the time it opens next. It returns
+// false when the business does not open within a year.
+func (c *Calendar) NextOpen(t time.Time) (time.Time, bool) {
+	if c.Open(t) {
+		return t, true
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	year, month, day := t.In(c.location).Date()
+	for i := 0; i <= maxLookahead; i++ {
+		var next time.Time
+		for _, window := range c.windows(year, month, day+i) {
+			start, _ := c.bounds(year, month, day+i, window)
+			if start.After(t) && (next.IsZero() || start.Before(next)) {
+				next = start
+			}
+		}
+
+		if !next.IsZero() {
+			return next, true
+		}
+	}
+
+	return time.Time{}, false
+}
+
+// Defer returns the time a notification due at t should be sent, t itself during business hours
+// and the next opening otherwise. It returns t when the business never opens.
+func (c *Calendar) Defer(t time.Time) time.Time {
+	next, ok := c.NextOpen(t)
+	if !ok {
+		return t
+	}
+
+	return next
+}
+
+// windows returns the open windows of the date, day may overflow the month.
+func (c *Calendar) windows(year int, month time.Month, day int) []*Window {
+	date := time.Date(year, month, day, 0, 0, 0, 0, c.location)
+	if holiday, ok := c.holidays[date.Format(dateLayout)]; ok {
+		return holiday.Hours
+	}
+
+	weekly := c.schedule.Weekly[date.Weekday()]
+	windows := make([]*Window, len(weekly))
+	for i := range weekly {
+		windows[i] = &weekly[i]
+	}
+
+	return windows
+}
+
+// bounds returns the start and the end of the window on the date, day may overflow the month.
+func (c *Calendar) bounds(year int, month time.Month, day int, window *Window) (time.Time, time.Time) {
+	start, _ := minutes(window.Start)
+	end, _ := minutes(window.End)
+
+	return time.Date(year, month, day, 0, start, 0, 0, c.location),
+		time.Date(year, month, day, 0, end, 0, 0, c.location)
+}
+
+func (w *Window) validate() error {
+	start, err := minutes(w.Start)
+	if err != nil {
+		return err
+	}
+
+	end, err := minutes(w.End)
+	if err != nil {
+		return err
+	}
+
+	if end <= start {
+		return fmt.Errorf("%v: %s-%s ends before it starts", ErrInvalidWindow, w.Start, w.End)
+	}
+
+	return nil
+}
+
+// minutes parses a "15:04" time of day into minutes since midnight, "24:00" included.
+func minutes(s string) (int, error) {
+	hh, mm, ok := strings.Cut(s, ":")
+	if !ok {
+		return 0, fmt.Errorf("%v: %q is not hh:mm", ErrInvalidWindow, s)
+	}
+
+	h, err := strconv.Atoi(hh)
+	if err != nil {
+		return 0, fmt.Errorf("%v: %q is not hh:mm", ErrInvalidWindow, s)
+	}
+
+	m, err := strconv.Atoi(mm)
+	if err != nil || h < 0 || m < 0 || m > 59 || h > 24 || (h == 24 && m != 0) {
+		return 0, fmt.Errorf("%v: %q is not hh:mm", ErrInvalidWindow, s)
+	}
+
+	return h*60 + m, nil //nolint:gomnd
+}
@@ -0,0 +1,172 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package schedule
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/lowkruc/go-whatsapp-api/clock"
+	"github.com/lowkruc/go-whatsapp-api/handoff"
+	"github.com/lowkruc/go-whatsapp-api/webhooks"
+)
+
+func testSchedule() *Schedule {
+	return &Schedule{
+		Timezone: "Africa/Nairobi", // UTC+3, no daylight saving
+		Weekly: map[time.Weekday][]Window{
+			time.Monday:  {{Start: "08:00", End: "12:00"}, {Start: "13:00", End: "17:00"}},
+			time.Tuesday: {{Start: "08:00", End: "17:00"}},
+		},
+		Holidays: []*Holiday{{Date: "2023-01-03", Name: "Closed Tuesday"}},
+	}
+}
+
+func TestCalendar(t *testing.T) {
+	t.Parallel()
+	calendar, err := NewCalendar(testSchedule())
+	if err != nil {
+		t.Fatalf("NewCalendar() error = %v", err)
+	}
+
+	nairobi, _ := time.LoadLocation("Africa/Nairobi")
+	at := func(day, hour, minute int) time.Time {
+		return time.Date(2023, time.January, day, hour, minute, 0, 0, nairobi)
+	}
+
+	tests := []struct {
+		name string
+		at   time.Time
+		open bool
+		next time.Time
+	}{
+		{name: "monday morning", at: at(2, 9, 0), open: true, next: at(2, 9, 0)},
+		{name: "monday lunch", at: at(2, 12, 0), open: false, next: at(2, 13, 0)},
+		{name: "monday evening skips the holiday", at: at(2, 17, 0), open: false, next: at(9, 8, 0)},
+		{name: "holiday", at: at(3, 10, 0), open: false, next: at(9, 8, 0)},
+		{name: "sunday in utc", at: time.Date(2023, time.January, 8, 23, 30, 0, 0, time.UTC), open: false,
+			next: at(9, 8, 0)},
+	}
+
+	for _, tt := range tests {
+		if got := calendar.Open(tt.at); got != tt.open {
+			t.Errorf("%s: Open() = %v, want %v", tt.name, got, tt.open)
+		}
+		if got, ok := calendar.NextOpen(tt.at); !ok || !got.Equal(tt.next) {
+			t.Errorf("%s: NextOpen() = %v, %v, want %v", tt.name, got, ok, tt.next)
+		}
+	}
+
+	if got := calendar.Defer(at(2, 18, 0)); !got.Equal(at(9, 8, 0)) {
+		t.Errorf("Defer() = %v, want %v", got, at(9, 8, 0))
+	}
+
+	if err := calendar.Set(&Schedule{Weekly: map[time.Weekday][]Window{
+		time.Monday: {{Start: "17:00", End: "09:00"}},
+	}}); err == nil {
+		t.Error("Set() with an inverted window error = nil, want an error")
+	}
+	if err := calendar.Set(&Schedule{Timezone: "Mars/Olympus"}); err == nil {
+		t.Error("Set() with an unknown timezone error = nil, want an error")
+	}
+	if !calendar.Open(at(2, 9, 0)) {
+		t.Error("an invalid schedule replaced the calendar schedule")
+	}
+
+	if err := calendar.Set(&Schedule{}); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	if _, ok := calendar.NextOpen(at(2, 9, 0)); ok {
+		t.Error("NextOpen() of an always closed calendar ok = true, want false")
+	}
+}
+
+func TestAutoResponder(t *testing.T) {
+	t.Parallel()
+	calendar, err := NewCalendar(testSchedule())
+	if err != nil {
+		t.Fatalf("NewCalendar() error = %v", err)
+	}
+
+	nairobi, _ := time.LoadLocation("Africa/Nairobi")
+	fake := clock.NewFake(time.Date(2023, time.January, 2, 12, 15, 0, 0, nairobi))
+	var replies, received int
+	responder := NewAutoResponder(calendar, func(ctx context.Context, nctx *webhooks.NotificationContext,
+		message *webhooks.Message, reopens time.Time,
+	) error {
+		replies++
+		if reopens.Hour() != 13 {
+			t.Errorf("got reopening %v, want 13:00", reopens)
+		}
+
+		return nil
+	}, WithClock(fake))
+
+	hook := responder.Hook(func(ctx context.Context, nctx *webhooks.NotificationContext, message *webhooks.Message) error {
+		received++
+
+		return nil
+	})
+
+	nctx := &webhooks.NotificationContext{Metadata: &webhooks.Metadata{PhoneNumberID: "1000"}}
+	message := &webhooks.Message{From: "255700000000"}
+	for i := 0; i < 2; i++ {
+		if err := hook(context.TODO(), nctx, message); err != nil {
+			t.Fatalf("hook error = %v", err)
+		}
+	}
+
+	fake.Advance(time.Hour) // 13:15, open
+	if err := hook(context.TODO(), nctx, message); err != nil {
+		t.Fatalf("hook error = %v", err)
+	}
+
+	if replies != 1 || received != 3 {
+		t.Errorf("got replies = %d, received = %d, want 1 and 3", replies, received)
+	}
+}
+
+func TestStrategy(t *testing.T) {
+	t.Parallel()
+	calendar, err := NewCalendar(testSchedule())
+	if err != nil {
+		t.Fatalf("NewCalendar() error = %v", err)
+	}
+
+	nairobi, _ := time.LoadLocation("Africa/Nairobi")
+	fake := clock.NewFake(time.Date(2023, time.January, 2, 18, 0, 0, 0, nairobi))
+	strategy := Strategy(map[string]*Calendar{"day": calendar}, fake, nil)
+	candidates := []*handoff.Candidate{
+		{Agent: &handoff.Agent{ID: "day"}, Load: 0},
+		{Agent: &handoff.Agent{ID: "night"}, Load: 5},
+	}
+
+	chosen, _ := strategy.Choose(context.TODO(), &handoff.Request{}, candidates)
+	if chosen == nil || chosen.Agent.ID != "night" {
+		t.Fatalf("Choose() = %+v, want night", chosen)
+	}
+
+	fake.Set(time.Date(2023, time.January, 2, 9, 0, 0, 0, nairobi))
+	chosen, _ = strategy.Choose(context.TODO(), &handoff.Request{}, candidates)
+	if chosen == nil || chosen.Agent.ID != "day" {
+		t.Fatalf("Choose() = %+v, want day", chosen)
+	}
+}
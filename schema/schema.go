@@ -0,0 +1,354 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+// Package schema describes the models of the webhook notifications as JSON Schema and as
+// TypeScript types, so that the services receiving the relayed notifications in other languages
+// validate and decode them the same way this library does.
+//
+// The schemas are generated from the Go types: the JSON names come from the json tags, the
+// struct types become definitions referenced by name, and every property is optional because the
+// notifications omit the empty fields. The types encoded with a different shape than their
+// fields, like webhooks.Interactive, are substituted with the shape they are encoded with.
+//
+// Example:
+//
+//	doc, _ := json.MarshalIndent(schema.Webhooks().Document(), "", "  ")
+//	ts := schema.Webhooks().TypeScript()
+package schema
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/lowkruc/go-whatsapp-api/webhooks"
+)
+
+// Draft is the version of JSON Schema of the documents.
+const Draft = "https://json-schema.org/draft/2020-12/schema"
+
+type (
+	// Schema is a JSON Schema, limited to the keywords needed to describe the models.
+	// AdditionalProperties is only set for the maps.
+	Schema struct {
+		Schema               string             `json:"$schema,omitempty"`
+		ID                   string             `json:"$id,omitempty"`
+		Ref                  string             `json:"$ref,omitempty"`
+		Title                string             `json:"title,omitempty"`
+		Type                 string             `json:"type,omitempty"`
+		Format               string             `json:"format,omitempty"`
+		Properties           map[string]*Schema `json:"properties,omitempty"`
+		AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+		Items                *Schema            `json:"items,omitempty"`
+		Defs                 map[string]*Schema `json:"$defs,omitempty"`
+
+		// order is the order of the properties, the order of the fields.
+		order []string
+	}
+
+	// Generator builds the schemas of Go types. The struct types are added to the definitions
+	// the first time they are met, named after the type, prefixed with the package name when two
+	// packages have a type of the same name.
+	Generator struct {
+		root        reflect.Type
+		names       map[reflect.Type]string
+		defs        map[string]*Schema
+		order       []string
+		substitutes map[reflect.Type]reflect.Type
+	}
+)
+
+var (
+	timeType       = reflect.TypeOf(time.Time{})
+	rawMessageType = reflect.TypeOf(json.RawMessage{})
+)
+
+// NewGenerator creates an empty Generator.
+func NewGenerator() *Generator {
+	return &Generator{
+		names:       make(map[reflect.Type]string),
+		defs:        make(map[string]*Schema),
+		substitutes: make(map[reflect.Type]reflect.Type),
+	}
+}
+
+// Webhooks returns a Generator with the webhooks.Notification as root, the Message and the Status
+// are among its definitions.
+func Webhooks() *Generator {
+	g := NewGenerator()
+	// Interactive is encoded the way the Cloud API sends it, the name of the reply in type and the
+	// reply next to it.
+	g.Substitute(webhooks.Interactive{}, struct {
+		Type string `json:"type,omitempty"`
+		webhooks.InteractiveType
+	}{})
+	g.Root(webhooks.Notification{})
+
+	return g
+}
+
+// Substitute describes the values of the type of v with the type of shape, for the types whose
+// JSON encoding differs from their fields. The definition keeps the name of the type of v.
+func (g *Generator) Substitute(v, shape any) {
+	g.substitutes[indirect(reflect.TypeOf(v))] = indirect(reflect.TypeOf(shape))
+}
+
+// Root sets the type of the documents and returns its schema, a reference to its definition for
+// the struct types.
+func (g *Generator) Root(v any) *Schema {
+	g.root = indirect(reflect.TypeOf(v))
+
+	return g.Add(v)
+}
+
+// Add adds the type of v and the types it refers to the definitions, and returns its schema.
+func (g *Generator) Add(v any) *Schema {
+	return g.schema(reflect.TypeOf(v))
+}
+
+// Document returns the JSON Schema document of the root with all the definitions.
+func (g *Generator) Document() *Schema {
+	doc := &Schema{Schema: Draft, Defs: make(map[string]*Schema, len(g.defs))}
+	if g.root != nil {
+		root := g.schema(g.root)
+		doc.Ref, doc.Title = root.Ref, g.names[g.root]
+		doc.Type, doc.Properties, doc.Items = root.Type, root.Properties, root.Items
+	}
+	for name, def := range g.defs {
+		doc.Defs[name] = def
+	}
+
+	return doc
+}
+
+// Definitions returns the names of the definitions in the order they were added.
+func (g *Generator) Definitions() []string {
+	return append([]string(nil), g.order...)
+}
+
+func (g *Generator) schema(t reflect.Type) *Schema {
+	t = indirect(t)
+	switch {
+	case t == nil:
+		return &Schema{}
+	case t == timeType:
+		return &Schema{Type: "string", Format: "date-time"}
+	case t == rawMessageType:
+		return &Schema{}
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: "integer"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+
+		return &Schema{Type: "array", Items: g.schema(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: g.schema(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return g.object(t)
+		}
+
+		return &Schema{Ref: "#/$defs/" + g.define(t)}
+	default:
+		return &Schema{}
+	}
+}
+
+// define adds the definition of the struct type t, if needed, and returns its name.
+func (g *Generator) define(t reflect.Type) string {
+	if name, ok := g.names[t]; ok {
+		return name
+	}
+
+	name := t.Name()
+	if _, taken := g.defs[name]; taken {
+		pkg := t.PkgPath()
+		pkg = pkg[strings.LastIndex(pkg, "/")+1:]
+		name = exported(pkg) + name
+	}
+	g.names[t] = name
+	// the definition is registered before its fields so that the recursive types end.
+	def := &Schema{}
+	g.defs[name] = def
+	g.order = append(g.order, name)
+
+	shape := t
+	if substitute, ok := g.substitutes[t]; ok {
+		shape = substitute
+	}
+	*def = *g.object(shape)
+	def.Title = name
+
+	return name
+}
+
+// object returns the schema of the fields of the struct type t, following the rules of
+// encoding/json: the fields of the embedded structs without a name are promoted.
+func (g *Generator) object(t reflect.Type) *Schema {
+	s := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+	g.fields(t, s)
+
+	return s
+}
+
+func (g *Generator) fields(t reflect.Type, s *Schema) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+
+		if field.Anonymous && name == "" && indirect(field.Type).Kind() == reflect.Struct {
+			g.fields(indirect(field.Type), s)
+
+			continue
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		if _, ok := s.Properties[name]; ok {
+			// the shallower field wins, like in encoding/json.
+			continue
+		}
+		s.Properties[name] = g.schema(field.Type)
+		s.order = append(s.order, name)
+	}
+}
+
+// TypeScript returns the TypeScript interfaces of the definitions, in the order they were added.
+func (g *Generator) TypeScript() string {
+	var b strings.Builder
+	b.WriteString("// Code generated by the schema package of go-whatsapp-api. DO NOT EDIT.\n")
+	for _, name := range g.order {
+		def := g.defs[name]
+		b.WriteString(fmt.Sprintf("\nexport interface %s {\n", name))
+		for _, property := range def.propertyOrder() {
+			key := property
+			if !isIdentifier(key) {
+				key = fmt.Sprintf("%q", key)
+			}
+			b.WriteString(fmt.Sprintf("  %s?: %s;\n", key, typeScript(def.Properties[property])))
+		}
+		b.WriteString("}\n")
+	}
+
+	return b.String()
+}
+
+// propertyOrder returns the names of the properties in the order of the fields, sorted for the
+// schemas that were not generated.
+func (s *Schema) propertyOrder() []string {
+	if len(s.order) == len(s.Properties) {
+		return s.order
+	}
+
+	names := make([]string, 0, len(s.Properties))
+	for name := range s.Properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+func typeScript(s *Schema) string {
+	switch {
+	case s == nil:
+		return "unknown"
+	case s.Ref != "":
+		return strings.TrimPrefix(s.Ref, "#/$defs/")
+	}
+
+	switch s.Type {
+	case "string":
+		return "string"
+	case "integer", "number":
+		return "number"
+	case "boolean":
+		return "boolean"
+	case "array":
+		items := typeScript(s.Items)
+		if strings.ContainsAny(items, " {") {
+			items = "(" + items + ")"
+		}
+
+		return items + "[]"
+	case "object":
+		if s.AdditionalProperties != nil {
+			return "Record<string, " + typeScript(s.AdditionalProperties) + ">"
+		}
+		parts := make([]string, 0, len(s.Properties))
+		for _, property := range s.propertyOrder() {
+			parts = append(parts, fmt.Sprintf("%q?: %s", property, typeScript(s.Properties[property])))
+		}
+
+		return "{ " + strings.Join(parts, "; ") + " }"
+	default:
+		return "unknown"
+	}
+}
+
+func indirect(t reflect.Type) reflect.Type {
+	for t != nil && t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	return t
+}
+
+func exported(s string) string {
+	if s == "" {
+		return s
+	}
+	r := []rune(s)
+	r[0] = unicode.ToUpper(r[0])
+
+	return string(r)
+}
+
+func isIdentifier(s string) bool {
+	for i, r := range s {
+		if !(r == '_' || r == '$' || unicode.IsLetter(r) || (i > 0 && unicode.IsDigit(r))) {
+			return false
+		}
+	}
+
+	return s != ""
+}
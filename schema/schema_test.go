@@ -0,0 +1,109 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package schema_test
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/lowkruc/go-whatsapp-api/models"
+	"github.com/lowkruc/go-whatsapp-api/schema"
+	"github.com/lowkruc/go-whatsapp-api/webhooks"
+)
+
+func TestWebhooks_Document(t *testing.T) {
+	t.Parallel()
+	doc := schema.Webhooks().Document()
+	if doc.Schema != schema.Draft || doc.Ref != "#/$defs/Notification" {
+		t.Fatalf("unexpected root %+v", doc)
+	}
+
+	for _, name := range []string{"Notification", "Message", "Status", "Interactive", "Contact", "ModelsContact"} {
+		if doc.Defs[name] == nil {
+			t.Errorf("definition %s is missing", name)
+		}
+	}
+
+	interactive := doc.Defs["Interactive"].Properties
+	if interactive["type"] == nil || interactive["type"].Type != "string" ||
+		interactive["button_reply"] == nil || interactive["button_reply"].Ref != "#/$defs/ButtonReply" {
+		t.Errorf("Interactive is not described with its encoded shape: %+v", interactive)
+	}
+	if _, ok := doc.Defs["Change"].Properties["RawValue"]; ok {
+		t.Error("the fields tagged with json:\"-\" must be skipped")
+	}
+	if messages := doc.Defs["Value"].Properties["messages"]; messages.Type != "array" ||
+		messages.Items.Ref != "#/$defs/Message" {
+		t.Errorf("unexpected messages %+v", messages)
+	}
+
+	if _, err := json.Marshal(doc); err != nil {
+		t.Errorf("json.Marshal() error = %v", err)
+	}
+}
+
+type node struct {
+	Name     string            `json:"name"`
+	Children []*node           `json:"children,omitempty"`
+	Labels   map[string]string `json:"labels,omitempty"`
+	Raw      json.RawMessage   `json:"raw,omitempty"`
+	embedded
+	hidden string
+}
+
+type embedded struct {
+	Weight float64 `json:"weight,omitempty"`
+}
+
+func TestGenerator(t *testing.T) {
+	t.Parallel()
+	g := schema.NewGenerator()
+	g.Root(&node{})
+	g.Add(models.Contact{})
+	g.Add(webhooks.Contact{})
+
+	doc := g.Document()
+	def := doc.Defs["node"]
+	if def == nil || def.Properties["children"].Items.Ref != "#/$defs/node" ||
+		def.Properties["labels"].AdditionalProperties.Type != "string" || def.Properties["weight"].Type != "number" {
+		t.Fatalf("unexpected definition %+v", def)
+	}
+	if _, ok := def.Properties["hidden"]; ok {
+		t.Error("the unexported fields must be skipped")
+	}
+	if names := g.Definitions(); len(names) < 3 || names[0] != "node" {
+		t.Errorf("Definitions() = %v", names)
+	}
+	if doc.Defs["Contact"] == nil || doc.Defs["WebhooksContact"] == nil {
+		t.Errorf("the types of the same name are not told apart: %v", g.Definitions())
+	}
+
+	ts := g.TypeScript()
+	for _, want := range []string{
+		"export interface node {\n  name?: string;\n  children?: node[];\n  labels?: Record<string, string>;\n" +
+			"  raw?: unknown;\n  weight?: number;\n}",
+		"export interface WebhooksContact {",
+	} {
+		if !strings.Contains(ts, want) {
+			t.Errorf("TypeScript() does not contain %q:\n%s", want, ts)
+		}
+	}
+}
@@ -0,0 +1,104 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package whatsapp
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/lowkruc/go-whatsapp-api/lifecycle"
+)
+
+// LifecycleComponent is the component the client emits its phases as, see WithLifecycle.
+const LifecycleComponent = "sender"
+
+// ErrClientShutdown is returned for the requests made after Shutdown was called.
+var ErrClientShutdown = errors.New("client is shut down")
+
+// shutdownTransport rejects the requests once the client is shut down and counts the ones in
+// flight, so that Shutdown can wait for them.
+type shutdownTransport struct {
+	next   http.RoundTripper
+	closed *atomic.Bool
+	active *atomic.Int64
+}
+
+// WithLifecycle emits the phases of the client on bus as LifecycleComponent: Starting and Ready
+// when created, and Draining then Stopped during Shutdown.
+func WithLifecycle(bus *lifecycle.Bus) ClientOption {
+	return func(client *Client) {
+		client.bus = bus
+	}
+}
+
+// Shutdown stops the client: the requests made from now on fail with ErrClientShutdown, and
+// Shutdown waits for the ones in flight to be answered, or for ctx to be done.
+func (client *Client) Shutdown(ctx context.Context) error {
+	if client.closed == nil {
+		return errors.New("shutdown: client is not created with NewClient")
+	}
+	client.bus.Emit(LifecycleComponent, lifecycle.Draining, nil)
+	client.closed.Store(true)
+
+	ticker := time.NewTicker(10 * time.Millisecond)
+	defer ticker.Stop()
+
+	var err error
+	for client.active.Load() > 0 && err == nil {
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			err = ctx.Err()
+		}
+	}
+	client.bus.Emit(LifecycleComponent, lifecycle.Stopped, err)
+
+	return err
+}
+
+// withShutdown returns a copy of the http client whose transport honors Shutdown.
+func withShutdown(client *http.Client, closed *atomic.Bool, active *atomic.Int64) *http.Client {
+	next := client.Transport
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	shutdown := *client
+	shutdown.Transport = &shutdownTransport{next: next, closed: closed, active: active}
+
+	return &shutdown
+}
+
+func (t *shutdownTransport) RoundTrip(request *http.Request) (*http.Response, error) {
+	t.active.Add(1)
+	defer t.active.Add(-1)
+
+	if t.closed.Load() {
+		if request.Body != nil {
+			_ = request.Body.Close()
+		}
+
+		return nil, ErrClientShutdown
+	}
+
+	return t.next.RoundTrip(request)
+}
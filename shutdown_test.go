@@ -0,0 +1,91 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package whatsapp
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lowkruc/go-whatsapp-api/lifecycle"
+)
+
+func TestClient_Shutdown(t *testing.T) {
+	t.Parallel()
+	received := make(chan struct{})
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(received)
+		<-release
+		_, _ = w.Write([]byte(`{"messaging_product":"whatsapp","messages":[{"id":"wamid.1"}]}`))
+	}))
+	defer server.Close()
+
+	bus := lifecycle.NewBus()
+	var phases []lifecycle.Phase
+	bus.Subscribe(func(event lifecycle.Event) { phases = append(phases, event.Phase) })
+
+	client := NewClient(WithBaseURL(server.URL), WithVersion("v16.0"), WithPhoneNumberID("1000"),
+		WithLifecycle(bus))
+	if phase := bus.Phase(LifecycleComponent); phase != lifecycle.Ready {
+		t.Fatalf("phase after NewClient = %q, want %q", phase, lifecycle.Ready)
+	}
+
+	sent := make(chan error, 1)
+	go func() {
+		_, err := client.SendTextMessage(context.Background(), "16505551234", &TextMessage{Message: "hello"})
+		sent <- err
+	}()
+	<-received
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Millisecond)
+	defer cancel()
+	if err := client.Shutdown(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Shutdown() error = %v, want %v while a request is in flight", err, context.DeadlineExceeded)
+	}
+
+	close(release)
+	if err := <-sent; err != nil {
+		t.Fatalf("in flight SendTextMessage() error = %v", err)
+	}
+	if err := client.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown() error = %v", err)
+	}
+
+	_, err := client.SendTextMessage(context.Background(), "16505551234", &TextMessage{Message: "hello"})
+	if err == nil || !strings.Contains(err.Error(), ErrClientShutdown.Error()) {
+		t.Errorf("SendTextMessage() after Shutdown error = %v, want %v", err, ErrClientShutdown)
+	}
+
+	want := []lifecycle.Phase{lifecycle.Starting, lifecycle.Ready, lifecycle.Draining, lifecycle.Stopped,
+		lifecycle.Draining, lifecycle.Stopped}
+	if len(phases) != len(want) {
+		t.Fatalf("phases %v, want %v", phases, want)
+	}
+	for i := range want {
+		if phases[i] != want[i] {
+			t.Errorf("phase %d is %q, want %q", i, phases[i], want[i])
+		}
+	}
+}
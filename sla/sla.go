@@ -0,0 +1,244 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+// Package sla measures the first response time of inbound messages and escalates the ones that
+// are not answered in time.
+//
+// A timer is started for every inbound message. The timers of a conversation are stopped when an
+// outbound message to the customer is recorded, either with Tracker.Replied or through the "sent"
+// status notification received by OnMessageStatusChangeHook. When a timer fires, the escalation
+// callback is called with the unanswered message.
+//
+// Example:
+//
+//	tracker := sla.NewTracker(5*time.Minute, func(ctx context.Context, p *sla.Pending) {
+//		log.Printf("message %s from %s is not answered", p.MessageID, p.From)
+//	})
+//	listener := webhooks.NewEventListener()
+//	listener.OnMessageReceived(tracker.OnMessageReceivedHook())
+//	listener.OnMessageStatusChange(tracker.OnMessageStatusChangeHook())
+package sla
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lowkruc/go-whatsapp-api/clock"
+	"github.com/lowkruc/go-whatsapp-api/webhooks"
+)
+
+type (
+	// Pending is an inbound message that has not been answered yet.
+	Pending struct {
+		PhoneNumberID string
+		From          string
+		MessageID     string
+		ReceivedAt    time.Time
+	}
+
+	// EscalationFunc is called when a message is not answered within the SLA. It is called from
+	// its own goroutine with a background context.
+	EscalationFunc func(ctx context.Context, pending *Pending)
+
+	// ResponseFunc is called for every pending message when the conversation is answered, with the
+	// time it took to answer it.
+	ResponseFunc func(ctx context.Context, pending *Pending, elapsed time.Duration)
+
+	// Tracker keeps a timer for every unanswered inbound message.
+	Tracker struct {
+		mu         sync.Mutex
+		sla        time.Duration
+		escalate   EscalationFunc
+		onResponse ResponseFunc
+		clock      clock.Clock
+		pending    map[string]map[string]*entry
+	}
+
+	TrackerOption func(*Tracker)
+
+	entry struct {
+		pending *Pending
+		timer   clock.Timer
+	}
+)
+
+// WithResponseFunc sets the callback used to record first response times.
+func WithResponseFunc(fn ResponseFunc) TrackerOption {
+	return func(t *Tracker) {
+		t.onResponse = fn
+	}
+}
+
+// WithClock sets the clock used for the received times and the timers, the default is
+// clock.Real. Tests use a *clock.Fake to escalate messages without waiting.
+func WithClock(c clock.Clock) TrackerOption {
+	return func(t *Tracker) {
+		t.clock = c
+	}
+}
+
+// NewTracker creates a Tracker that calls escalate for messages not answered within sla.
+func NewTracker(sla time.Duration, escalate EscalationFunc, options ...TrackerOption) *Tracker {
+	t := &Tracker{
+		sla:      sla,
+		escalate: escalate,
+		clock:    clock.Real{},
+		pending:  make(map[string]map[string]*entry),
+	}
+
+	for _, option := range options {
+		option(t)
+	}
+
+	return t
+}
+
+func key(phoneNumberID, waID string) string {
+	return phoneNumberID + ":" + waID
+}
+
+// Received starts the timer of an inbound message. Receiving the same message ID twice, as happens
+// with webhook retries, does not restart its timer.
+func (t *Tracker) Received(phoneNumberID, from, messageID string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	k := key(phoneNumberID, from)
+	conversation, ok := t.pending[k]
+	if !ok {
+		conversation = make(map[string]*entry)
+		t.pending[k] = conversation
+	}
+
+	if _, ok := conversation[messageID]; ok {
+		return
+	}
+
+	p := &Pending{
+		PhoneNumberID: phoneNumberID,
+		From:          from,
+		MessageID:     messageID,
+		ReceivedAt:    t.clock.Now(),
+	}
+	conversation[messageID] = &entry{
+		pending: p,
+		timer:   t.clock.AfterFunc(t.sla, func() { t.expire(k, messageID) }),
+	}
+}
+
+// Replied records an outbound message to the customer and stops the timers of all the pending
+// messages of the conversation.
+func (t *Tracker) Replied(ctx context.Context, phoneNumberID, to string) {
+	t.mu.Lock()
+	k := key(phoneNumberID, to)
+	conversation := t.pending[k]
+	delete(t.pending, k)
+	t.mu.Unlock()
+
+	now := t.clock.Now()
+	for _, e := range conversation {
+		if !e.timer.Stop() {
+			// the timer already fired and the message has been escalated.
+			continue
+		}
+
+		if t.onResponse != nil {
+			t.onResponse(ctx, e.pending, now.Sub(e.pending.ReceivedAt))
+		}
+	}
+}
+
+// Pending returns the messages that are waiting for an answer.
+func (t *Tracker) Pending() []*Pending {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var list []*Pending
+	for _, conversation := range t.pending {
+		for _, e := range conversation {
+			p := *e.pending
+			list = append(list, &p)
+		}
+	}
+
+	return list
+}
+
+// Stop stops all the timers without escalating the pending messages.
+func (t *Tracker) Stop() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for _, conversation := range t.pending {
+		for _, e := range conversation {
+			e.timer.Stop()
+		}
+	}
+	t.pending = make(map[string]map[string]*entry)
+}
+
+func (t *Tracker) expire(k, messageID string) {
+	t.mu.Lock()
+	conversation := t.pending[k]
+	e, ok := conversation[messageID]
+	if ok {
+		delete(conversation, messageID)
+		if len(conversation) == 0 {
+			delete(t.pending, k)
+		}
+	}
+	t.mu.Unlock()
+
+	if ok && t.escalate != nil {
+		p := *e.pending
+		t.escalate(context.Background(), &p)
+	}
+}
+
+func phoneNumberID(nctx *webhooks.NotificationContext) string {
+	if nctx != nil && nctx.Metadata != nil {
+		return nctx.Metadata.PhoneNumberID
+	}
+
+	return ""
+}
+
+// OnMessageReceivedHook returns a webhooks.OnMessageReceivedHook that starts a timer for every
+// received message.
+func (t *Tracker) OnMessageReceivedHook() webhooks.OnMessageReceivedHook {
+	return func(ctx context.Context, nctx *webhooks.NotificationContext, message *webhooks.Message) error {
+		t.Received(phoneNumberID(nctx), message.From, message.ID)
+
+		return nil
+	}
+}
+
+// OnMessageStatusChangeHook returns a webhooks.OnMessageStatusChangeHook that records a reply when
+// an outbound message is reported as sent.
+func (t *Tracker) OnMessageStatusChangeHook() webhooks.OnMessageStatusChangeHook {
+	return func(ctx context.Context, nctx *webhooks.NotificationContext, status *webhooks.Status) error {
+		if status != nil && strings.EqualFold(status.StatusValue, string(webhooks.MessageStatusSent)) {
+			t.Replied(ctx, phoneNumberID(nctx), status.RecipientID)
+		}
+
+		return nil
+	}
+}
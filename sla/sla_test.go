@@ -0,0 +1,60 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package sla
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestTracker(t *testing.T) {
+	t.Parallel()
+	escalated := make(chan *Pending, 1)
+	var answered []string
+
+	tracker := NewTracker(20*time.Millisecond, func(ctx context.Context, pending *Pending) {
+		escalated <- pending
+	}, WithResponseFunc(func(ctx context.Context, pending *Pending, elapsed time.Duration) {
+		answered = append(answered, pending.MessageID)
+	}))
+	defer tracker.Stop()
+
+	tracker.Received("1000", "255700000001", "wamid.1")
+	tracker.Received("1000", "255700000002", "wamid.2")
+	tracker.Replied(context.TODO(), "1000", "255700000001")
+
+	select {
+	case p := <-escalated:
+		if p.MessageID != "wamid.2" {
+			t.Errorf("escalated %q, want wamid.2", p.MessageID)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("message was not escalated")
+	}
+
+	if len(answered) != 1 || answered[0] != "wamid.1" {
+		t.Errorf("answered = %v, want [wamid.1]", answered)
+	}
+
+	if pending := tracker.Pending(); len(pending) != 0 {
+		t.Errorf("got %d pending messages, want 0", len(pending))
+	}
+}
@@ -0,0 +1,107 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package whatsapp
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	whttp "github.com/lowkruc/go-whatsapp-api/http"
+	"github.com/lowkruc/go-whatsapp-api/webhooks"
+)
+
+// whatsappBusinessAccountObject is the object of the app subscriptions to WhatsApp webhooks.
+const whatsappBusinessAccountObject = "whatsapp_business_account"
+
+type (
+	// SubscriptionField is a webhook field an app is subscribed to.
+	SubscriptionField struct {
+		Name    string `json:"name"`
+		Version string `json:"version,omitempty"`
+	}
+
+	// AppSubscription is a webhook subscription of an app.
+	AppSubscription struct {
+		Object      string               `json:"object"`
+		CallbackURL string               `json:"callback_url,omitempty"`
+		Active      bool                 `json:"active"`
+		Fields      []*SubscriptionField `json:"fields,omitempty"`
+	}
+
+	AppSubscriptions struct {
+		Data []*AppSubscription `json:"data"`
+	}
+)
+
+// AppSubscriptions lists the webhook subscriptions of the app. The request is made with an app
+// access token, "{app-id}|{app-secret}", and not with the access token of the client.
+func (client *Client) AppSubscriptions(ctx context.Context, appID, appAccessToken string,
+) (*AppSubscriptions, error) {
+	cctx := client.context()
+	request := &whttp.Request{
+		Context: &whttp.RequestContext{
+			Name:       "list app subscriptions",
+			BaseURL:    cctx.baseURL,
+			ApiVersion: cctx.apiVersion,
+			SenderID:   appID,
+			Endpoints:  []string{"subscriptions"},
+		},
+		Method: http.MethodGet,
+		Bearer: appAccessToken,
+	}
+
+	var subscriptions AppSubscriptions
+	if err := whttp.Do(ctx, client.http, request, &subscriptions, client.hooks...); err != nil {
+		return nil, fmt.Errorf("list app subscriptions: %v", err)
+	}
+
+	return &subscriptions, nil
+}
+
+// SubscribedFields returns the names of the fields of the active WhatsApp Business Account
+// subscription.
+func (subscriptions *AppSubscriptions) SubscribedFields() []string {
+	var fields []string
+	for _, subscription := range subscriptions.Data {
+		if subscription == nil || !subscription.Active || subscription.Object != whatsappBusinessAccountObject {
+			continue
+		}
+		for _, field := range subscription.Fields {
+			fields = append(fields, field.Name)
+		}
+	}
+
+	return fields
+}
+
+// CheckWebhookDrift compares the fields the app is subscribed to with the fields handled by
+// hooks. It is meant to run at startup, a drift usually means a hook was added without
+// subscribing to its field in the App Dashboard, or the other way around.
+func (client *Client) CheckWebhookDrift(ctx context.Context, appID, appAccessToken string,
+	hooks *webhooks.Hooks,
+) (*webhooks.Drift, error) {
+	subscriptions, err := client.AppSubscriptions(ctx, appID, appAccessToken)
+	if err != nil {
+		return nil, fmt.Errorf("check webhook drift: %v", err)
+	}
+
+	return webhooks.DetectDrift(subscriptions.SubscribedFields(), hooks), nil
+}
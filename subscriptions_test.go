@@ -0,0 +1,71 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package whatsapp
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/lowkruc/go-whatsapp-api/webhooks"
+)
+
+func TestClient_CheckWebhookDrift(t *testing.T) {
+	t.Parallel()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v16.0/app_id/subscriptions" || r.Header.Get("Authorization") != "Bearer app_id|secret" {
+			w.WriteHeader(http.StatusNotFound)
+
+			return
+		}
+		_, _ = w.Write([]byte(`{"data":[
+			{"object":"whatsapp_business_account","active":true,"fields":[
+				{"name":"messages","version":"v16.0"},{"name":"account_update","version":"v16.0"}]},
+			{"object":"page","active":true,"fields":[{"name":"feed"}]}]}`))
+	}))
+	defer server.Close()
+
+	client := NewClient(WithBaseURL(server.URL), WithVersion("v16.0"))
+	hooks := &webhooks.Hooks{
+		OnTextMessageHook: func(ctx context.Context, nctx *webhooks.NotificationContext,
+			mctx *webhooks.MessageContext, text *webhooks.Text,
+		) error {
+			return nil
+		},
+		OnHandoverHook: func(ctx context.Context, nctx *webhooks.NotificationContext, h *webhooks.Handover) error {
+			return nil
+		},
+	}
+
+	drift, err := client.CheckWebhookDrift(context.TODO(), "app_id", "app_id|secret", hooks)
+	if err != nil {
+		t.Fatalf("CheckWebhookDrift() error = %v", err)
+	}
+
+	want := &webhooks.Drift{
+		Unhandled:    []string{webhooks.AccountUpdateField},
+		Unsubscribed: []string{webhooks.MessagingHandoversField},
+	}
+	if !reflect.DeepEqual(drift, want) || !drift.HasDrift() {
+		t.Errorf("CheckWebhookDrift() = %+v, want %+v", drift, want)
+	}
+}
@@ -0,0 +1,173 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package templates
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/lowkruc/go-whatsapp-api/models"
+)
+
+// ButtonOTP is the type of the button of an authentication template, its OTPType tells how the
+// code reaches the user.
+const ButtonOTP = "OTP"
+
+// The OTP types of the button of an authentication template. With copy code the user copies the
+// code, with one tap the user taps to fill it in the app and with zero tap the app receives it
+// without the user.
+const (
+	OTPCopyCode = "COPY_CODE"
+	OTPOneTap   = "ONE_TAP"
+	OTPZeroTap  = "ZERO_TAP"
+)
+
+// MaxCodeExpirationMinutes bounds the CodeExpirationMinutes of an authentication template.
+const MaxCodeExpirationMinutes = 90
+
+// signatureHashLength is the length of the app signature hash of Android apps.
+const signatureHashLength = 11
+
+type (
+	// SupportedApp is an Android app that can autofill the code of a one tap or zero tap
+	// authentication template. SignatureHash is the 11 characters hash of the app signing key.
+	SupportedApp struct {
+		PackageName   string `json:"package_name,omitempty"`
+		SignatureHash string `json:"signature_hash,omitempty"`
+	}
+
+	// Authentication describes an authentication template, see Definition. Meta fixes the text of
+	// the body, "{{1}} is your verification code.", and of the footer.
+	//
+	// OTPType is OTPCopyCode, OTPOneTap or OTPZeroTap, one tap and zero tap need the SupportedApps
+	// and zero tap needs ZeroTapTermsAccepted. CopyCodeText and AutofillText override the texts
+	// of the buttons. CodeExpirationMinutes adds the expiration of the code to the footer, 0 for
+	// no footer.
+	Authentication struct {
+		Name                      string
+		Language                  string
+		OTPType                   string
+		SupportedApps             []*SupportedApp
+		ZeroTapTermsAccepted      bool
+		CopyCodeText              string
+		AutofillText              string
+		AddSecurityRecommendation bool
+		CodeExpirationMinutes     int
+	}
+)
+
+// Definition validates the authentication template and returns its definition, to be created with
+// the template management API. The error wraps ErrInvalidTemplateMessage.
+func (a *Authentication) Definition() (*Definition, error) {
+	if err := a.validate(); err != nil {
+		return nil, err
+	}
+
+	body := &Component{Type: ComponentBody, AddSecurityRecommendation: a.AddSecurityRecommendation}
+	components := []*Component{body}
+	if a.CodeExpirationMinutes > 0 {
+		components = append(components, &Component{
+			Type:                  ComponentFooter,
+			CodeExpirationMinutes: a.CodeExpirationMinutes,
+		})
+	}
+	button := &Button{
+		Type:    ButtonOTP,
+		OTPType: a.OTPType,
+		Text:    a.CopyCodeText,
+	}
+	if a.OTPType != OTPCopyCode {
+		button.AutofillText = a.AutofillText
+		button.SupportedApps = a.SupportedApps
+		button.ZeroTapTermsAccepted = a.ZeroTapTermsAccepted
+	}
+	components = append(components, &Component{Type: ComponentButtons, Buttons: []*Button{button}})
+
+	return &Definition{
+		Name:       a.Name,
+		Language:   a.Language,
+		Category:   "AUTHENTICATION",
+		Components: components,
+	}, nil
+}
+
+func (a *Authentication) validate() error {
+	var errs []string
+	if a.Name == "" {
+		errs = append(errs, "the name is empty")
+	}
+	if a.Language == "" {
+		errs = append(errs, "the language is empty")
+	}
+	switch a.OTPType {
+	case OTPCopyCode:
+	case OTPOneTap, OTPZeroTap:
+		if len(a.SupportedApps) == 0 {
+			errs = append(errs, fmt.Sprintf("%s needs the supported apps", strings.ToLower(a.OTPType)))
+		}
+		for i, app := range a.SupportedApps {
+			if app == nil || app.PackageName == "" || len(app.SignatureHash) != signatureHashLength {
+				errs = append(errs, fmt.Sprintf("supported app %d needs a package name and a %d characters "+
+					"signature hash", i, signatureHashLength))
+			}
+		}
+		if a.OTPType == OTPZeroTap && !a.ZeroTapTermsAccepted {
+			errs = append(errs, "zero tap needs the terms to be accepted")
+		}
+	default:
+		errs = append(errs, fmt.Sprintf("unknown OTP type %q", a.OTPType))
+	}
+	if a.CodeExpirationMinutes < 0 || a.CodeExpirationMinutes > MaxCodeExpirationMinutes {
+		errs = append(errs, fmt.Sprintf("the code expiration is %d minutes, want 0 to %d",
+			a.CodeExpirationMinutes, MaxCodeExpirationMinutes))
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("%v: %s", ErrInvalidTemplateMessage, strings.Join(errs, "; "))
+	}
+
+	return nil
+}
+
+// NewAuthenticationMessage returns the message of the authentication template with the code. The
+// code fills the body and the button, whatever the OTP type of the button: copy code, one tap and
+// zero tap buttons are all sent as a URL button.
+func NewAuthenticationMessage(name, language, code string) *TemplateMessage {
+	return NewTemplateMessage(name, language).BodyText(code).URLButton(0, code)
+}
+
+// buttonSubType returns the sub type of the button parameters for a button of the definition.
+func buttonSubType(buttonType string) string {
+	if strings.EqualFold(buttonType, ButtonOTP) {
+		return ButtonURL
+	}
+
+	return strings.ToLower(buttonType)
+}
+
+// otpValue returns the code of the parameters of an OTP button.
+func otpValue(params []*models.TemplateParameter) string {
+	for _, param := range params {
+		if param.Text != "" {
+			return param.Text
+		}
+	}
+
+	return ""
+}
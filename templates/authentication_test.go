@@ -0,0 +1,134 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package templates
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestAuthentication_Definition(t *testing.T) {
+	t.Parallel()
+	auth := &Authentication{
+		Name:                      "verify",
+		Language:                  "en_US",
+		OTPType:                   OTPOneTap,
+		SupportedApps:             []*SupportedApp{{PackageName: "com.example.app", SignatureHash: "K8a/AINcGX7"}},
+		AutofillText:              "Autofill",
+		AddSecurityRecommendation: true,
+		CodeExpirationMinutes:     10,
+	}
+	definition, err := auth.Definition()
+	if err != nil {
+		t.Fatalf("Definition() error = %v", err)
+	}
+
+	got, _ := json.Marshal(definition)
+	want := `{"name":"verify","language":"en_US","category":"AUTHENTICATION","components":[` +
+		`{"type":"BODY","add_security_recommendation":true},{"type":"FOOTER","code_expiration_minutes":10},` +
+		`{"type":"BUTTONS","buttons":[{"type":"OTP","otp_type":"ONE_TAP","autofill_text":"Autofill",` +
+		`"supported_apps":[{"package_name":"com.example.app","signature_hash":"K8a/AINcGX7"}]}]}]}`
+	if string(got) != want {
+		t.Errorf("got %s\nwant %s", got, want)
+	}
+
+	tmpl, err := NewAuthenticationMessage("verify", "en_US", "123456").BuildFor(definition)
+	if err != nil {
+		t.Fatalf("BuildFor() error = %v", err)
+	}
+	got, _ = json.Marshal(tmpl.Components)
+	want = `[{"type":"body","parameters":[{"type":"text","text":"123456"}]},` +
+		`{"type":"button","sub_type":"url","parameters":[{"type":"text","text":"123456"}],"index":0}]`
+	if string(got) != want {
+		t.Errorf("got %s\nwant %s", got, want)
+	}
+
+	preview, err := Render(definition, tmpl)
+	if err != nil || len(preview.Buttons) != 1 || preview.Buttons[0].Value != "123456" {
+		t.Errorf("Render() = %+v, %v, want the code on the button", preview, err)
+	}
+}
+
+func TestAuthentication_DefinitionInvalid(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name string
+		auth *Authentication
+		want string
+	}{
+		{
+			name: "unknown type",
+			auth: &Authentication{Name: "verify", Language: "en_US", OTPType: "SMS"},
+			want: `unknown OTP type "SMS"`,
+		},
+		{
+			name: "one tap without apps",
+			auth: &Authentication{Name: "verify", Language: "en_US", OTPType: OTPOneTap},
+			want: "one_tap needs the supported apps",
+		},
+		{
+			name: "signature hash",
+			auth: &Authentication{Name: "verify", Language: "en_US", OTPType: OTPOneTap,
+				SupportedApps: []*SupportedApp{{PackageName: "com.example.app", SignatureHash: "short"}}},
+			want: "supported app 0 needs a package name and a 11 characters signature hash",
+		},
+		{
+			name: "zero tap terms",
+			auth: &Authentication{Name: "verify", Language: "en_US", OTPType: OTPZeroTap,
+				SupportedApps: []*SupportedApp{{PackageName: "com.example.app", SignatureHash: "K8a/AINcGX7"}}},
+			want: "zero tap needs the terms to be accepted",
+		},
+		{
+			name: "expiration",
+			auth: &Authentication{Name: "verify", Language: "en_US", OTPType: OTPCopyCode, CodeExpirationMinutes: 91},
+			want: "the code expiration is 91 minutes, want 0 to 90",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			_, err := tt.auth.Definition()
+			if err == nil || !strings.Contains(err.Error(), ErrInvalidTemplateMessage.Error()) ||
+				!strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Definition() error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestAuthentication_CopyCode(t *testing.T) {
+	t.Parallel()
+	auth := &Authentication{
+		Name: "verify", Language: "en_US", OTPType: OTPCopyCode, CopyCodeText: "Copy",
+		SupportedApps: []*SupportedApp{{PackageName: "com.example.app", SignatureHash: "K8a/AINcGX7"}},
+	}
+	definition, err := auth.Definition()
+	if err != nil {
+		t.Fatalf("Definition() error = %v", err)
+	}
+
+	button := definition.Components[len(definition.Components)-1].Buttons[0]
+	if button.Text != "Copy" || button.SupportedApps != nil {
+		t.Errorf("got button %+v, want a copy code button without the apps", button)
+	}
+}
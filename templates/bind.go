@@ -0,0 +1,372 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package templates
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lowkruc/go-whatsapp-api/models"
+)
+
+// TagName is the struct tag read by Bind.
+const TagName = "whatsapp"
+
+var ErrInvalidBinding = errors.New("invalid template binding")
+
+var (
+	stringerType = reflect.TypeOf((*fmt.Stringer)(nil)).Elem()
+	timeType     = reflect.TypeOf(time.Time{})
+	currencyType = reflect.TypeOf(models.TemplateCurrency{})
+	mediaType    = reflect.TypeOf(models.Media{})
+	paramType    = reflect.TypeOf(models.TemplateParameter{})
+)
+
+// binding is a field of the struct given to Bind.
+type binding struct {
+	field     string
+	component string
+	key       string
+	value     reflect.Value
+}
+
+// Bind builds the components of a template message from the fields of v, a struct or a pointer
+// to one, so that the parameters are named instead of positional. The fields are tagged with the
+// placeholder they fill:
+//
+//	type OrderUpdate struct {
+//		Name    string                  `whatsapp:"1"`           // {{1}} of the body
+//		Total   models.TemplateCurrency `whatsapp:"body:2"`      // {{2}} of the body
+//		Order   int                     `whatsapp:"header:1"`    // {{1}} of a text header
+//		Photo   string                  `whatsapp:"header"`      // link of a media header
+//		Tracker string                  `whatsapp:"button:0"`    // {{1}} of the URL of the first button
+//		City    string                  `whatsapp:"city"`        // {{city}} of the body
+//	}
+//
+// Strings, numbers, booleans and fmt.Stringer are sent as text, time.Time as date_time,
+// models.TemplateCurrency as currency and models.TemplateParameter as is. A media header takes a
+// link or a *models.Media. Every placeholder of the definition must be filled by a field that is
+// neither empty nor nil, and every tagged field must fill a placeholder, otherwise the error wraps
+// ErrMissingParameter or ErrInvalidBinding.
+func Bind(definition *Definition, v any) ([]*models.TemplateComponent, error) {
+	if definition == nil {
+		return nil, ErrNilDefinition
+	}
+
+	bindings, err := bindingsOf(v)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		components []*models.TemplateComponent
+		missing    []string
+		used       = make(map[string]bool)
+		buttons    = 0
+	)
+	bind := func(component, format, subType string, index int, keys []string) error {
+		var params []*models.TemplateParameter
+		for _, key := range keys {
+			b, ok := bindings[component+":"+key]
+			if !ok || isEmpty(b.value) {
+				missing = append(missing, component+" {{"+key+"}}")
+
+				continue
+			}
+			used[component+":"+key] = true
+			param, err := parameterOf(b, format)
+			if err != nil {
+				return err
+			}
+			if _, positional := strconv.Atoi(key); positional != nil {
+				param.ParameterName = key
+			}
+			params = append(params, param)
+		}
+		if len(params) > 0 {
+			components = append(components, &models.TemplateComponent{
+				Type:       strings.Split(component, ".")[0],
+				SubType:    subType,
+				Index:      index,
+				Parameters: params,
+			})
+		}
+
+		return nil
+	}
+
+	for _, component := range definition.Components {
+		var err error
+		switch strings.ToUpper(component.Type) {
+		case ComponentHeader:
+			format := strings.ToUpper(component.Format)
+			keys := placeholders(component.Text)
+			if format != "" && format != FormatText && format != FormatLocation {
+				keys = []string{"1"}
+			}
+			err = bind("header", format, "", 0, keys)
+		case ComponentBody:
+			err = bind("body", FormatText, "", 0, placeholders(component.Text))
+		case ComponentButtons:
+			for _, button := range component.Buttons {
+				name := "button." + strconv.Itoa(buttons)
+				switch strings.ToUpper(button.Type) {
+				case "URL":
+					err = bind(name, FormatText, "url", buttons, placeholders(button.URL))
+				case "QUICK_REPLY":
+					if _, ok := bindings[name+":1"]; ok {
+						err = bind(name, "PAYLOAD", "quick_reply", buttons, []string{"1"})
+					}
+				}
+				if err != nil {
+					break
+				}
+				buttons++
+			}
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("%v: %s", ErrMissingParameter, strings.Join(missing, ", "))
+	}
+
+	var unused []string
+	for key, b := range bindings {
+		if !used[key] && !isEmpty(b.value) {
+			unused = append(unused, b.field)
+		}
+	}
+	if len(unused) > 0 {
+		sort.Strings(unused)
+
+		return nil, fmt.Errorf("%v: fields %s fill no placeholder of %s", ErrInvalidBinding,
+			strings.Join(unused, ", "), definition.Name)
+	}
+
+	return components, nil
+}
+
+// placeholders returns the keys of the placeholders of text: 1 to the largest number for the
+// positional ones, so that a gap is reported as missing, then the named ones in order.
+func placeholders(text string) []string {
+	var (
+		keys []string
+		max  int
+		seen = make(map[string]bool)
+	)
+	for _, match := range placeholderPattern.FindAllStringSubmatch(text, -1) {
+		if n, err := strconv.Atoi(match[1]); err == nil {
+			if n > max {
+				max = n
+			}
+
+			continue
+		}
+		if !seen[match[1]] {
+			seen[match[1]] = true
+			keys = append(keys, match[1])
+		}
+	}
+
+	positional := make([]string, 0, max+len(keys))
+	for n := 1; n <= max; n++ {
+		positional = append(positional, strconv.Itoa(n))
+	}
+
+	return append(positional, keys...)
+}
+
+// bindingsOf returns the tagged fields of v by component and key.
+func bindingsOf(v any) (map[string]*binding, error) {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Pointer {
+		if rv.IsNil() {
+			return nil, fmt.Errorf("%v: nil %s", ErrInvalidBinding, rv.Type())
+		}
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("%v: %T is not a struct", ErrInvalidBinding, v)
+	}
+
+	bindings := make(map[string]*binding)
+	for i := 0; i < rv.NumField(); i++ {
+		field := rv.Type().Field(i)
+		tag, ok := field.Tag.Lookup(TagName)
+		if !ok || tag == "-" || !field.IsExported() {
+			continue
+		}
+
+		component, key, err := parseTag(tag)
+		if err != nil {
+			return nil, fmt.Errorf("%v: field %s: %v", ErrInvalidBinding, field.Name, err)
+		}
+		if previous, ok := bindings[component+":"+key]; ok {
+			return nil, fmt.Errorf("%v: fields %s and %s fill the same placeholder", ErrInvalidBinding,
+				previous.field, field.Name)
+		}
+		bindings[component+":"+key] = &binding{field: field.Name, component: component, key: key, value: rv.Field(i)}
+	}
+
+	return bindings, nil
+}
+
+// parseTag parses "key", "body:key", "header", "header:key", "button:n" and "button:n:key".
+func parseTag(tag string) (string, string, error) {
+	parts := strings.Split(tag, ":")
+	component, key := "body", parts[len(parts)-1]
+	switch {
+	case len(parts) == 1 && parts[0] == "header":
+		component, key = "header", "1"
+	case len(parts) == 2 && (parts[0] == "body" || parts[0] == "header"):
+		component = parts[0]
+	case parts[0] == "button" && (len(parts) == 2 || len(parts) == 3):
+		if _, err := strconv.Atoi(parts[1]); err != nil {
+			return "", "", fmt.Errorf("invalid button index in tag %q", tag)
+		}
+		component, key = "button."+parts[1], "1"
+		if len(parts) == 3 {
+			key = parts[2]
+		}
+	case len(parts) != 1:
+		return "", "", fmt.Errorf("invalid tag %q", tag)
+	}
+
+	if !placeholderPattern.MatchString("{{" + key + "}}") {
+		return "", "", fmt.Errorf("invalid placeholder %q in tag %q", key, tag)
+	}
+
+	return component, key, nil
+}
+
+// isEmpty reports whether the value is nil or the zero value of a string.
+func isEmpty(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		return v.IsNil()
+	case reflect.String:
+		return v.Len() == 0
+	default:
+		return false
+	}
+}
+
+// parameterOf converts the value of the binding into a parameter, format is the header format
+// for the media headers.
+func parameterOf(b *binding, format string) (*models.TemplateParameter, error) {
+	v := b.value
+	for (v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface) && !v.IsNil() {
+		v = v.Elem()
+	}
+	invalid := func() error {
+		return fmt.Errorf("%v: field %s: %s cannot fill %s {{%s}}", ErrInvalidBinding, b.field, b.value.Type(),
+			b.component, b.key)
+	}
+	if v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		return nil, invalid()
+	}
+
+	switch format {
+	case FormatImage, FormatVideo, FormatDocument:
+		var media *models.Media
+		switch {
+		case v.Type() == mediaType:
+			m := v.Interface().(models.Media)
+			media = &m
+		case v.Kind() == reflect.String:
+			media = &models.Media{Link: v.String()}
+		default:
+			return nil, invalid()
+		}
+		param := &models.TemplateParameter{Type: strings.ToLower(format)}
+		switch format {
+		case FormatImage:
+			param.Image = media
+		case FormatVideo:
+			param.Video = media
+		default:
+			param.Document = media
+		}
+
+		return param, nil
+
+	case "PAYLOAD":
+		if v.Kind() != reflect.String {
+			return nil, invalid()
+		}
+
+		return &models.TemplateParameter{Type: "payload", Payload: v.String()}, nil
+	}
+
+	switch {
+	case v.Type() == paramType:
+		param := v.Interface().(models.TemplateParameter)
+
+		return &param, nil
+	case v.Type() == currencyType:
+		currency := v.Interface().(models.TemplateCurrency)
+
+		return &models.TemplateParameter{Type: "currency", Currency: &currency}, nil
+	case v.Type() == timeType:
+		t := v.Interface().(time.Time)
+
+		return &models.TemplateParameter{Type: "date_time", DateTime: &models.TemplateDateTime{
+			FallbackValue: t.Format("January 2, 2006"),
+			DayOfWeek:     int(t.Weekday()),
+			Year:          t.Year(),
+			Month:         int(t.Month()),
+			DayOfMonth:    t.Day(),
+			Hour:          t.Hour(),
+			Minute:        t.Minute(),
+			Calendar:      "GREGORIAN",
+		}}, nil
+	case v.Type().Implements(stringerType):
+		return text(v.Interface().(fmt.Stringer).String()), nil
+	case reflect.PointerTo(v.Type()).Implements(stringerType) && v.CanAddr():
+		return text(v.Addr().Interface().(fmt.Stringer).String()), nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		return text(v.String()), nil
+	case reflect.Bool:
+		return text(strconv.FormatBool(v.Bool())), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return text(strconv.FormatInt(v.Int(), 10)), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return text(strconv.FormatUint(v.Uint(), 10)), nil
+	case reflect.Float32, reflect.Float64:
+		return text(strconv.FormatFloat(v.Float(), 'f', -1, v.Type().Bits())), nil
+	default:
+		return nil, invalid()
+	}
+}
+
+func text(s string) *models.TemplateParameter {
+	return &models.TemplateParameter{Type: "text", Text: s}
+}
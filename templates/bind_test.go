@@ -0,0 +1,169 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package templates
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lowkruc/go-whatsapp-api/models"
+)
+
+type orderID int
+
+func (id orderID) String() string { return fmt.Sprintf("#%04d", int(id)) }
+
+type orderUpdate struct {
+	Name     string                  `whatsapp:"1"`
+	Total    models.TemplateCurrency `whatsapp:"body:2"`
+	ShipsOn  time.Time               `whatsapp:"3"`
+	Order    orderID                 `whatsapp:"header:1"`
+	Tracking string                  `whatsapp:"button:0"`
+	Help     string                  `whatsapp:"button:1"`
+	Internal string
+}
+
+func orderDefinition() *Definition {
+	return &Definition{
+		Name: "order_update",
+		Components: []*Component{
+			{Type: ComponentHeader, Format: FormatText, Text: "Order {{1}}"},
+			{Type: ComponentBody, Text: "Hi {{1}}, you paid {{2}}. It ships on {{3}}."},
+			{Type: ComponentButtons, Buttons: []*Button{
+				{Type: "URL", Text: "Track", URL: "https://example.com/orders/{{1}}"},
+				{Type: "QUICK_REPLY", Text: "Help"},
+			}},
+		},
+	}
+}
+
+func TestBind(t *testing.T) {
+	t.Parallel()
+	update := &orderUpdate{
+		Name:     "Amina",
+		Total:    models.TemplateCurrency{FallbackValue: "$10.99", Code: "USD", Amount1000: 10990},
+		ShipsOn:  time.Date(2024, 3, 5, 0, 0, 0, 0, time.UTC),
+		Order:    7,
+		Tracking: "42",
+		Help:     "help",
+	}
+
+	components, err := Bind(orderDefinition(), update)
+	if err != nil {
+		t.Fatalf("Bind() error = %v", err)
+	}
+
+	preview, err := Render(orderDefinition(), &models.Template{Name: "order_update", Components: components})
+	if err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	want := "*Order #0007*\nHi Amina, you paid $10.99. It ships on March 5, 2024.\n" +
+		"[ Track ] https://example.com/orders/42\n[ Help ] help"
+	if got := preview.Text(); got != want {
+		t.Errorf("Text() = %q, want %q", got, want)
+	}
+	if dt := components[1].Parameters[2].DateTime; dt == nil || dt.DayOfWeek != 2 || dt.Month != 3 {
+		t.Errorf("unexpected date_time %+v", dt)
+	}
+
+	update.Name = ""
+	if _, err := Bind(orderDefinition(), update); err == nil || !strings.Contains(err.Error(), "body {{1}}") {
+		t.Errorf("Bind() with an empty field error = %v", err)
+	}
+}
+
+func TestBind_Named(t *testing.T) {
+	t.Parallel()
+	definition := &Definition{
+		Name: "welcome",
+		Components: []*Component{
+			{Type: ComponentHeader, Format: FormatImage},
+			{Type: ComponentBody, Text: "Welcome {{first_name}}, you have {{points}} points."},
+		},
+	}
+	binding := struct {
+		Banner    string  `whatsapp:"header"`
+		FirstName string  `whatsapp:"first_name"`
+		Points    float64 `whatsapp:"points"`
+	}{Banner: "https://example.com/banner.png", FirstName: "Amina", Points: 12.5}
+
+	components, err := Bind(definition, binding)
+	if err != nil {
+		t.Fatalf("Bind() error = %v", err)
+	}
+	if header := components[0].Parameters[0]; header.Type != "image" || header.Image.Link != binding.Banner {
+		t.Errorf("unexpected header parameter %+v", header)
+	}
+	preview, err := Render(definition, &models.Template{Components: components})
+	if err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	if preview.Body != "Welcome Amina, you have 12.5 points." || components[1].Parameters[0].ParameterName != "first_name" {
+		t.Errorf("unexpected body %q", preview.Body)
+	}
+}
+
+func TestBind_Invalid(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name string
+		v    any
+		want string
+	}{
+		{name: "not a struct", v: "Amina", want: ErrInvalidBinding.Error()},
+		{name: "missing placeholder", v: struct {
+			Name string `whatsapp:"1"`
+		}{Name: "Amina"}, want: "body {{2}}"},
+		{name: "unknown placeholder", v: &struct {
+			Name  string                  `whatsapp:"1"`
+			Total models.TemplateCurrency `whatsapp:"2"`
+			Date  string                  `whatsapp:"3"`
+			Extra string                  `whatsapp:"4"`
+			Order string                  `whatsapp:"header:1"`
+			Track string                  `whatsapp:"button:0"`
+		}{"a", models.TemplateCurrency{}, "b", "c", "d", "e"}, want: "fields Extra fill no placeholder"},
+		{name: "unsupported type", v: struct {
+			Name  []string `whatsapp:"1"`
+			Total int      `whatsapp:"2"`
+			Date  int      `whatsapp:"3"`
+			Order int      `whatsapp:"header:1"`
+			Track int      `whatsapp:"button:0"`
+		}{Name: []string{"a"}}, want: "[]string cannot fill body {{1}}"},
+		{name: "invalid tag", v: struct {
+			Name string `whatsapp:"footer:1"`
+		}{}, want: "invalid tag"},
+		{name: "duplicate", v: struct {
+			A string `whatsapp:"1"`
+			B string `whatsapp:"body:1"`
+		}{}, want: "fill the same placeholder"},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if _, err := Bind(orderDefinition(), tt.v); err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Bind() error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
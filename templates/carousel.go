@@ -0,0 +1,176 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package templates
+
+import (
+	"fmt"
+
+	"github.com/lowkruc/go-whatsapp-api/models"
+)
+
+// MinCards and MaxCards bound the number of cards of a carousel.
+const (
+	MinCards = 2
+	MaxCards = 10
+)
+
+// CarouselCard builds a card of a media or product carousel template, see TemplateMessage.Carousel.
+// A card has a media or product header, an optional body and its buttons. The cards of a carousel
+// must have the same kind of header and the same buttons.
+//
+// Example:
+//
+//	tmpl, err := templates.NewTemplateMessage("summer_sale", "en_US").
+//		BodyText("Kerry").
+//		Carousel(
+//			templates.NewCarouselCard().ImageHeader(&models.Media{ID: "1"}).BodyText("20%").URLButton(0, "hats"),
+//			templates.NewCarouselCard().ImageHeader(&models.Media{ID: "2"}).BodyText("30%").URLButton(0, "shoes"),
+//		).
+//		Build()
+type CarouselCard struct {
+	message *TemplateMessage
+}
+
+// NewCarouselCard returns an empty card.
+func NewCarouselCard() *CarouselCard {
+	return &CarouselCard{message: NewTemplateMessage("", "")}
+}
+
+// ImageHeader sets the image of the header of the card, usually the handle of an uploaded image.
+func (c *CarouselCard) ImageHeader(media *models.Media) *CarouselCard {
+	c.message.ImageHeader(media)
+
+	return c
+}
+
+// VideoHeader sets the video of the header of the card, usually the handle of an uploaded video.
+func (c *CarouselCard) VideoHeader(media *models.Media) *CarouselCard {
+	c.message.VideoHeader(media)
+
+	return c
+}
+
+// ProductHeader sets the product of the header of a product carousel card.
+func (c *CarouselCard) ProductHeader(catalogID, productRetailerID string) *CarouselCard {
+	c.message.setHeader(&models.TemplateParameter{Type: "product", Product: &models.TemplateProduct{
+		ProductRetailerID: productRetailerID,
+		CatalogID:         catalogID,
+	}})
+
+	return c
+}
+
+// Body appends parameters of the body of the card.
+func (c *CarouselCard) Body(parameters ...*models.TemplateParameter) *CarouselCard {
+	c.message.Body(parameters...)
+
+	return c
+}
+
+// BodyText appends text parameters of the body of the card.
+func (c *CarouselCard) BodyText(values ...string) *CarouselCard {
+	c.message.BodyText(values...)
+
+	return c
+}
+
+// QuickReplyButton sets the payload of the quick reply button of the card at the index.
+func (c *CarouselCard) QuickReplyButton(index int, payload string) *CarouselCard {
+	c.message.QuickReplyButton(index, payload)
+
+	return c
+}
+
+// URLButton sets the suffix of the URL button of the card at the index.
+func (c *CarouselCard) URLButton(index int, suffix string) *CarouselCard {
+	c.message.URLButton(index, suffix)
+
+	return c
+}
+
+// Carousel appends cards to the carousel of the template. The body of the template is the text
+// shown above the cards, a carousel template has no header or buttons of its own.
+func (m *TemplateMessage) Carousel(cards ...*CarouselCard) *TemplateMessage {
+	m.cards = append(m.cards, cards...)
+
+	return m
+}
+
+// carouselProblems returns the problems of the cards, none when there are no cards.
+//
+//nolint:cyclop
+func (m *TemplateMessage) carouselProblems() []string {
+	if len(m.cards) == 0 {
+		return nil
+	}
+
+	var errs []string
+	if len(m.cards) < MinCards || len(m.cards) > MaxCards {
+		errs = append(errs, fmt.Sprintf("the carousel has %d cards, want %d to %d", len(m.cards), MinCards,
+			MaxCards))
+	}
+	if m.header != nil || len(m.buttons) > 0 {
+		errs = append(errs, "a carousel template has no header or buttons, set them on the cards")
+	}
+
+	first := m.cards[0]
+	for i, card := range m.cards {
+		prefix := fmt.Sprintf("card %d: ", i)
+		if card == nil {
+			errs = append(errs, prefix+"is nil")
+
+			continue
+		}
+		errs = append(errs, card.message.problems(prefix)...)
+		header := card.message.header
+		switch {
+		case header == nil:
+			errs = append(errs, prefix+"the header is missing")
+		case header.Type != "image" && header.Type != "video" && header.Type != "product":
+			errs = append(errs, fmt.Sprintf("%s%s header, want an image, a video or a product", prefix, header.Type))
+		case header.Type == "product" && header.Product != nil &&
+			(header.Product.CatalogID == "" || header.Product.ProductRetailerID == ""):
+			errs = append(errs, prefix+"the product needs a catalog ID and a product retailer ID")
+		}
+		if first == nil || i == 0 {
+			continue
+		}
+		if first.message.header != nil && header != nil && header.Type != first.message.header.Type {
+			errs = append(errs, fmt.Sprintf("%sheader type %s, the first card has %s", prefix, header.Type,
+				first.message.header.Type))
+		}
+		if buttonTypes(card.message) != buttonTypes(first.message) {
+			errs = append(errs, fmt.Sprintf("%sbuttons %s, the first card has %s", prefix, buttonTypes(card.message),
+				buttonTypes(first.message)))
+		}
+	}
+
+	return errs
+}
+
+// buttonTypes describes the sub types of the buttons in the order of their indexes.
+func buttonTypes(m *TemplateMessage) string {
+	types := make([]string, 0, len(m.buttons))
+	for _, index := range m.buttonIndexes() {
+		types = append(types, fmt.Sprintf("%d:%s", index, m.buttons[index].SubType))
+	}
+
+	return fmt.Sprint(types)
+}
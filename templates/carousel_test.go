@@ -0,0 +1,144 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package templates
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/lowkruc/go-whatsapp-api/models"
+)
+
+func TestTemplateMessage_Carousel(t *testing.T) {
+	t.Parallel()
+	tmpl, err := NewTemplateMessage("summer_sale", "en_US").
+		BodyText("Kerry").
+		Carousel(
+			NewCarouselCard().ImageHeader(&models.Media{ID: "1"}).BodyText("20%").
+				QuickReplyButton(0, "more-hats").URLButton(1, "hats"),
+			NewCarouselCard().ImageHeader(&models.Media{ID: "2"}).BodyText("30%").
+				QuickReplyButton(0, "more-shoes").URLButton(1, "shoes"),
+		).
+		Build()
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+
+	got, _ := json.Marshal(tmpl.Components)
+	card := func(index int, media, discount, payload, suffix string) string {
+		return `{"card_index":` + strconv.Itoa(index) + `,"components":[` +
+			`{"type":"header","parameters":[{"type":"image","image":{"id":"` + media + `"}}]},` +
+			`{"type":"body","parameters":[{"type":"text","text":"` + discount + `"}]},` +
+			`{"type":"button","sub_type":"quick_reply","parameters":[{"type":"payload","payload":"` + payload +
+			`"}],"index":0},` +
+			`{"type":"button","sub_type":"url","parameters":[{"type":"text","text":"` + suffix + `"}],"index":1}]}`
+	}
+	want := `[{"type":"body","parameters":[{"type":"text","text":"Kerry"}]},{"type":"carousel","cards":[` +
+		card(0, "1", "20%", "more-hats", "hats") + `,` + card(1, "2", "30%", "more-shoes", "shoes") + `]}]`
+	if string(got) != want {
+		t.Errorf("got %s\nwant %s", got, want)
+	}
+}
+
+func TestTemplateMessage_ProductCarousel(t *testing.T) {
+	t.Parallel()
+	tmpl, err := NewTemplateMessage("new_arrivals", "en_US").
+		Carousel(
+			NewCarouselCard().ProductHeader("catalog-1", "sku-1"),
+			NewCarouselCard().ProductHeader("catalog-1", "sku-2"),
+		).
+		Build()
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+
+	got, _ := json.Marshal(tmpl.Components[0].Cards[1])
+	want := `{"card_index":1,"components":[{"type":"header","parameters":[{"type":"product",` +
+		`"product":{"product_retailer_id":"sku-2","catalog_id":"catalog-1"}}]}]}`
+	if string(got) != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+}
+
+func TestTemplateMessage_CarouselInvalid(t *testing.T) {
+	t.Parallel()
+	image := func(id string) *CarouselCard {
+		return NewCarouselCard().ImageHeader(&models.Media{ID: id}).URLButton(0, id)
+	}
+	tests := []struct {
+		name    string
+		message *TemplateMessage
+		want    string
+	}{
+		{
+			name:    "one card",
+			message: NewTemplateMessage("sale", "en_US").Carousel(image("1")),
+			want:    "the carousel has 1 cards, want 2 to 10",
+		},
+		{
+			name:    "missing header",
+			message: NewTemplateMessage("sale", "en_US").Carousel(image("1"), NewCarouselCard().URLButton(0, "a")),
+			want:    "card 1: the header is missing",
+		},
+		{
+			name: "mixed headers",
+			message: NewTemplateMessage("sale", "en_US").Carousel(image("1"),
+				NewCarouselCard().VideoHeader(&models.Media{ID: "2"}).URLButton(0, "2")),
+			want: "card 1: header type video, the first card has image",
+		},
+		{
+			name: "different buttons",
+			message: NewTemplateMessage("sale", "en_US").Carousel(image("1"),
+				NewCarouselCard().ImageHeader(&models.Media{ID: "2"}).QuickReplyButton(0, "2")),
+			want: "card 1: buttons [0:quick_reply], the first card has [0:url]",
+		},
+		{
+			name:    "template header",
+			message: NewTemplateMessage("sale", "en_US").TextHeader("Hi").Carousel(image("1"), image("2")),
+			want:    "a carousel template has no header or buttons",
+		},
+		{
+			name: "card parameter",
+			message: NewTemplateMessage("sale", "en_US").Carousel(image("1"),
+				image("2").BodyText("")),
+			want: "card 1: body parameter 1: the text is empty",
+		},
+		{
+			name: "product",
+			message: NewTemplateMessage("sale", "en_US").Carousel(
+				NewCarouselCard().ProductHeader("catalog-1", ""), NewCarouselCard().ProductHeader("catalog-1", "sku-2")),
+			want: "card 0: the product needs a catalog ID and a product retailer ID",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			_, err := tt.message.Build()
+			if err == nil || !strings.Contains(err.Error(), ErrInvalidTemplateMessage.Error()) ||
+				!strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Build() error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
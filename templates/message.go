@@ -0,0 +1,420 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package templates
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/lowkruc/go-whatsapp-api/models"
+)
+
+// Button sub types of the button components of a template message.
+const (
+	ButtonQuickReply = "quick_reply"
+	ButtonURL        = "url"
+	ButtonCopyCode   = "copy_code"
+)
+
+// MaxButtons is the number of buttons a template can have.
+const MaxButtons = 10
+
+var ErrInvalidTemplateMessage = errors.New("invalid template message")
+
+type (
+	// TemplateMessage builds the template of a template message and validates it before it is
+	// sent, so that a malformed component fails locally instead of with a Graph error. The footer
+	// and the buttons without parameters, like phone number buttons, are fixed by the approved
+	// template and are not sent.
+	//
+	// Example:
+	//
+	//	tmpl, err := templates.NewTemplateMessage("order_update", "en_US").
+	//		ImageHeader(&models.Media{Link: "https://example.com/order.jpg"}).
+	//		BodyText("Kerry", "ORD-1234").
+	//		URLButton(0, "ORD-1234").
+	//		QuickReplyButton(1, "stop").
+	//		Build()
+	TemplateMessage struct {
+		name    string
+		policy  string
+		code    string
+		header  *models.TemplateParameter
+		headers int
+		offer   *models.TemplateParameter
+		body    []*models.TemplateParameter
+		buttons map[int]*models.TemplateComponent
+		cards   []*CarouselCard
+		errs    []string
+	}
+)
+
+// NewTemplateMessage returns a TemplateMessage of the template with the name and the language code.
+func NewTemplateMessage(name, language string) *TemplateMessage {
+	return &TemplateMessage{
+		name:    name,
+		policy:  "deterministic",
+		code:    language,
+		buttons: make(map[int]*models.TemplateComponent),
+	}
+}
+
+// TextHeader fills the placeholder of a text header.
+func (m *TemplateMessage) TextHeader(text string) *TemplateMessage {
+	return m.setHeader(&models.TemplateParameter{Type: "text", Text: text})
+}
+
+// ImageHeader sets the image of an image header, with its ID or its link.
+func (m *TemplateMessage) ImageHeader(media *models.Media) *TemplateMessage {
+	return m.setHeader(&models.TemplateParameter{Type: "image", Image: media})
+}
+
+// VideoHeader sets the video of a video header, with its ID or its link.
+func (m *TemplateMessage) VideoHeader(media *models.Media) *TemplateMessage {
+	return m.setHeader(&models.TemplateParameter{Type: "video", Video: media})
+}
+
+// DocumentHeader sets the document of a document header, with its ID or its link.
+func (m *TemplateMessage) DocumentHeader(media *models.Media) *TemplateMessage {
+	return m.setHeader(&models.TemplateParameter{Type: "document", Document: media})
+}
+
+// LocationHeader sets the location of a location header.
+func (m *TemplateMessage) LocationHeader(location *models.Location) *TemplateMessage {
+	return m.setHeader(&models.TemplateParameter{Type: "location", Location: location})
+}
+
+// LimitedTimeOffer sets the expiration of the offer of a limited time offer template, shown as a
+// countdown. Only set it when the offer of the template has an expiration.
+func (m *TemplateMessage) LimitedTimeOffer(expiresAt time.Time) *TemplateMessage {
+	m.offer = &models.TemplateParameter{
+		Type:             "limited_time_offer",
+		LimitedTimeOffer: &models.TemplateLimitedTimeOffer{ExpirationTimeMs: expiresAt.UnixMilli()},
+	}
+
+	return m
+}
+
+// Body appends parameters of the body, in the order of the placeholders.
+func (m *TemplateMessage) Body(parameters ...*models.TemplateParameter) *TemplateMessage {
+	m.body = append(m.body, parameters...)
+
+	return m
+}
+
+// BodyText appends text parameters of the body, in the order of the placeholders.
+func (m *TemplateMessage) BodyText(values ...string) *TemplateMessage {
+	for _, value := range values {
+		m.body = append(m.body, &models.TemplateParameter{Type: "text", Text: value})
+	}
+
+	return m
+}
+
+// NamedBodyText sets the text parameter of the named placeholder of the body, like {{first_name}}.
+func (m *TemplateMessage) NamedBodyText(name, value string) *TemplateMessage {
+	m.body = append(m.body, &models.TemplateParameter{Type: "text", ParameterName: name, Text: value})
+
+	return m
+}
+
+// QuickReplyButton sets the payload of the quick reply button at the index, sent back in the
+// webhook when the button is clicked.
+func (m *TemplateMessage) QuickReplyButton(index int, payload string) *TemplateMessage {
+	return m.setButton(index, ButtonQuickReply, &models.TemplateParameter{Type: "payload", Payload: payload})
+}
+
+// URLButton sets the suffix appended to the URL of the dynamic URL button at the index.
+func (m *TemplateMessage) URLButton(index int, suffix string) *TemplateMessage {
+	return m.setButton(index, ButtonURL, &models.TemplateParameter{Type: "text", Text: suffix})
+}
+
+// CopyCodeButton sets the code copied by the copy code button at the index.
+func (m *TemplateMessage) CopyCodeButton(index int, code string) *TemplateMessage {
+	return m.setButton(index, ButtonCopyCode, &models.TemplateParameter{Type: "coupon_code", CouponCode: code})
+}
+
+// Build validates the components and returns the template. The error wraps
+// ErrInvalidTemplateMessage and lists every problem found.
+func (m *TemplateMessage) Build() (*models.Template, error) {
+	var errs []string
+	if m.name == "" {
+		errs = append(errs, "the name is empty")
+	}
+	if m.code == "" {
+		errs = append(errs, "the language is empty")
+	}
+	errs = append(errs, m.problems("")...)
+	errs = append(errs, m.carouselProblems()...)
+	if len(errs) > 0 {
+		return nil, fmt.Errorf("%v: %s", ErrInvalidTemplateMessage, strings.Join(errs, "; "))
+	}
+
+	tmpl := &models.Template{
+		Name:       m.name,
+		Language:   &models.TemplateLanguage{Policy: m.policy, Code: m.code},
+		Components: m.components(),
+	}
+	if len(m.cards) > 0 {
+		carousel := &models.TemplateComponent{Type: "carousel"}
+		for i, card := range m.cards {
+			carousel.Cards = append(carousel.Cards, &models.TemplateCard{
+				CardIndex:  i,
+				Components: card.message.components(),
+			})
+		}
+		tmpl.Components = append(tmpl.Components, carousel)
+	}
+
+	return tmpl, nil
+}
+
+// problems returns the problems of the header, the body and the buttons, each prefixed.
+func (m *TemplateMessage) problems(prefix string) []string {
+	errs := make([]string, 0, len(m.errs))
+	for _, problem := range m.errs {
+		errs = append(errs, prefix+problem)
+	}
+	if m.headers > 1 {
+		errs = append(errs, fmt.Sprintf("%s%d headers are set, a template has one", prefix, m.headers))
+	}
+	if m.header != nil {
+		if problem := checkParameter(prefix+"header", m.header); problem != "" {
+			errs = append(errs, problem)
+		}
+	}
+	if m.offer != nil {
+		if problem := checkParameter(prefix+"limited time offer", m.offer); problem != "" {
+			errs = append(errs, problem)
+		}
+	}
+	for i, param := range m.body {
+		if problem := checkParameter(fmt.Sprintf("%sbody parameter %d", prefix, i+1), param); problem != "" {
+			errs = append(errs, problem)
+		}
+	}
+	if named := namedParameters(m.body); named > 0 && named < len(m.body) {
+		errs = append(errs, prefix+"the body mixes named and positional parameters")
+	}
+	for _, index := range m.buttonIndexes() {
+		button := m.buttons[index]
+		if problem := checkParameter(fmt.Sprintf("%sbutton %d", prefix, index), button.Parameters[0]); problem != "" {
+			errs = append(errs, problem)
+		}
+	}
+
+	return errs
+}
+
+// components returns the header, the limited time offer, the body and the buttons components, in
+// that order.
+func (m *TemplateMessage) components() []*models.TemplateComponent {
+	var components []*models.TemplateComponent
+	if m.header != nil {
+		components = append(components, &models.TemplateComponent{
+			Type:       "header",
+			Parameters: []*models.TemplateParameter{m.header},
+		})
+	}
+	if m.offer != nil {
+		components = append(components, &models.TemplateComponent{
+			Type:       "limited_time_offer",
+			Parameters: []*models.TemplateParameter{m.offer},
+		})
+	}
+	if len(m.body) > 0 {
+		components = append(components, &models.TemplateComponent{
+			Type:       "body",
+			Parameters: m.body,
+		})
+	}
+	for _, index := range m.buttonIndexes() {
+		components = append(components, m.buttons[index])
+	}
+
+	return components
+}
+
+// BuildFor builds the template like Build and checks it against the approved definition: every
+// placeholder must be filled and the buttons must be of the type of the definition at their index.
+// The error wraps ErrInvalidTemplateMessage, or the errors of Render.
+func (m *TemplateMessage) BuildFor(definition *Definition) (*models.Template, error) {
+	tmpl, err := m.Build()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := Render(definition, tmpl); err != nil {
+		return nil, err
+	}
+
+	var (
+		buttons  []*Button
+		expiring bool
+	)
+	for _, component := range definition.Components {
+		switch strings.ToUpper(component.Type) {
+		case ComponentLimitedTimeOffer:
+			expiring = component.LimitedTimeOffer != nil && component.LimitedTimeOffer.HasExpiration
+		case ComponentHeader:
+			format := strings.ToLower(component.Format)
+			if format == "" {
+				format = "text"
+			}
+			if m.header != nil && m.header.Type != format {
+				return nil, fmt.Errorf("%v: %s header for a %s header", ErrInvalidTemplateMessage,
+					m.header.Type, format)
+			}
+		case ComponentButtons:
+			buttons = append(buttons, component.Buttons...)
+		}
+	}
+	if expiring && m.offer == nil {
+		return nil, fmt.Errorf("%v: the offer of the template expires, set LimitedTimeOffer",
+			ErrInvalidTemplateMessage)
+	}
+	if !expiring && m.offer != nil {
+		return nil, fmt.Errorf("%v: the template has no expiring offer", ErrInvalidTemplateMessage)
+	}
+	for _, index := range m.buttonIndexes() {
+		subType := m.buttons[index].SubType
+		if index >= len(buttons) {
+			return nil, fmt.Errorf("%v: button %d: the template has %d buttons", ErrInvalidTemplateMessage,
+				index, len(buttons))
+		}
+		if want := buttonSubType(buttons[index].Type); want != subType {
+			return nil, fmt.Errorf("%v: button %d: %s parameter for a %s button", ErrInvalidTemplateMessage,
+				index, subType, want)
+		}
+	}
+
+	return tmpl, nil
+}
+
+func (m *TemplateMessage) setHeader(param *models.TemplateParameter) *TemplateMessage {
+	m.header = param
+	m.headers++
+
+	return m
+}
+
+func (m *TemplateMessage) setButton(index int, subType string, param *models.TemplateParameter) *TemplateMessage {
+	if index < 0 || index >= MaxButtons {
+		m.errs = append(m.errs, fmt.Sprintf("button index %d is not between 0 and %d", index, MaxButtons-1))
+
+		return m
+	}
+	if _, ok := m.buttons[index]; ok {
+		m.errs = append(m.errs, fmt.Sprintf("button %d is set twice", index))
+	}
+	m.buttons[index] = &models.TemplateComponent{
+		Type:       "button",
+		SubType:    subType,
+		Index:      index,
+		Parameters: []*models.TemplateParameter{param},
+	}
+
+	return m
+}
+
+func (m *TemplateMessage) buttonIndexes() []int {
+	indexes := make([]int, 0, len(m.buttons))
+	for index := range m.buttons {
+		indexes = append(indexes, index)
+	}
+	sort.Ints(indexes)
+
+	return indexes
+}
+
+// checkParameter returns the problem of the parameter, empty when it is valid.
+//
+//nolint:cyclop
+func checkParameter(name string, param *models.TemplateParameter) string {
+	if param == nil {
+		return name + " is nil"
+	}
+
+	media := func(media *models.Media) string {
+		if media == nil || (media.ID == "" && media.Link == "") {
+			return fmt.Sprintf("%s: the %s has neither an ID nor a link", name, param.Type)
+		}
+
+		return ""
+	}
+	switch param.Type {
+	case "text":
+		if param.Text == "" {
+			return name + ": the text is empty"
+		}
+	case "payload":
+		if param.Payload == "" {
+			return name + ": the payload is empty"
+		}
+	case "coupon_code":
+		if param.CouponCode == "" {
+			return name + ": the coupon code is empty"
+		}
+	case "currency":
+		if c := param.Currency; c == nil || c.Code == "" || c.FallbackValue == "" {
+			return name + ": the currency needs a code and a fallback value"
+		}
+	case "date_time":
+		if param.DateTime == nil || param.DateTime.FallbackValue == "" {
+			return name + ": the date_time needs a fallback value"
+		}
+	case "image":
+		return media(param.Image)
+	case "video":
+		return media(param.Video)
+	case "document":
+		return media(param.Document)
+	case "product":
+		if param.Product == nil {
+			return name + ": the product is nil"
+		}
+	case "limited_time_offer":
+		if param.LimitedTimeOffer == nil || param.LimitedTimeOffer.ExpirationTimeMs <= 0 {
+			return name + ": the expiration time is missing"
+		}
+	case "location":
+		if l := param.Location; l == nil || l.Latitude < -90 || l.Latitude > 90 ||
+			l.Longitude < -180 || l.Longitude > 180 {
+			return name + ": the location needs a latitude and a longitude"
+		}
+	default:
+		return fmt.Sprintf("%s: unknown parameter type %q", name, param.Type)
+	}
+
+	return ""
+}
+
+func namedParameters(params []*models.TemplateParameter) int {
+	var named int
+	for _, param := range params {
+		if param != nil && param.ParameterName != "" {
+			named++
+		}
+	}
+
+	return named
+}
@@ -0,0 +1,230 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package templates
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lowkruc/go-whatsapp-api/models"
+)
+
+func TestTemplateMessage_Build(t *testing.T) {
+	t.Parallel()
+	tmpl, err := NewTemplateMessage("order_update", "en_US").
+		ImageHeader(&models.Media{Link: "https://example.com/order.jpg"}).
+		BodyText("Kerry", "ORD-1234").
+		CopyCodeButton(2, "SPRING10").
+		QuickReplyButton(0, "stop").
+		URLButton(1, "ORD-1234").
+		Build()
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+
+	got, _ := json.Marshal(tmpl)
+	want := `{"name":"order_update","language":{"policy":"deterministic","code":"en_US"},"components":[` +
+		`{"type":"header","parameters":[{"type":"image","image":{"link":"https://example.com/order.jpg"}}]},` +
+		`{"type":"body","parameters":[{"type":"text","text":"Kerry"},{"type":"text","text":"ORD-1234"}]},` +
+		`{"type":"button","sub_type":"quick_reply","parameters":[{"type":"payload","payload":"stop"}],"index":0},` +
+		`{"type":"button","sub_type":"url","parameters":[{"type":"text","text":"ORD-1234"}],"index":1},` +
+		`{"type":"button","sub_type":"copy_code","parameters":[{"type":"coupon_code","coupon_code":"SPRING10"}],` +
+		`"index":2}]}`
+	if string(got) != want {
+		t.Errorf("got %s\nwant %s", got, want)
+	}
+}
+
+func TestTemplateMessage_BuildInvalid(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name    string
+		message *TemplateMessage
+		want    string
+	}{
+		{
+			name:    "empty name",
+			message: NewTemplateMessage("", "en_US"),
+			want:    "the name is empty",
+		},
+		{
+			name:    "empty text",
+			message: NewTemplateMessage("welcome", "en_US").BodyText("Kerry", ""),
+			want:    "body parameter 2: the text is empty",
+		},
+		{
+			name:    "media without link",
+			message: NewTemplateMessage("welcome", "en_US").VideoHeader(&models.Media{}),
+			want:    "header: the video has neither an ID nor a link",
+		},
+		{
+			name: "two headers",
+			message: NewTemplateMessage("welcome", "en_US").TextHeader("Hi").
+				LocationHeader(&models.Location{Latitude: 1, Longitude: 2}),
+			want: "2 headers are set",
+		},
+		{
+			name:    "button index",
+			message: NewTemplateMessage("welcome", "en_US").QuickReplyButton(10, "stop"),
+			want:    "button index 10 is not between 0 and 9",
+		},
+		{
+			name:    "duplicate button",
+			message: NewTemplateMessage("welcome", "en_US").QuickReplyButton(0, "stop").URLButton(0, "a"),
+			want:    "button 0 is set twice",
+		},
+		{
+			name:    "mixed parameters",
+			message: NewTemplateMessage("welcome", "en_US").BodyText("Kerry").NamedBodyText("order", "1"),
+			want:    "the body mixes named and positional parameters",
+		},
+		{
+			name: "currency",
+			message: NewTemplateMessage("welcome", "en_US").Body(&models.TemplateParameter{
+				Type: "currency", Currency: &models.TemplateCurrency{Amount1000: 1000},
+			}),
+			want: "the currency needs a code and a fallback value",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			_, err := tt.message.Build()
+			if err == nil || !strings.Contains(err.Error(), ErrInvalidTemplateMessage.Error()) ||
+				!strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Build() error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestTemplateMessage_BuildFor(t *testing.T) {
+	t.Parallel()
+	definition := &Definition{
+		Name:     "order_update",
+		Language: "en_US",
+		Components: []*Component{
+			{Type: ComponentHeader, Format: FormatImage},
+			{Type: ComponentBody, Text: "Hi {{1}}, your order {{2}} ships today."},
+			{Type: ComponentFooter, Text: "Reply STOP to unsubscribe"},
+			{Type: ComponentButtons, Buttons: []*Button{
+				{Type: "QUICK_REPLY", Text: "Stop"},
+				{Type: "URL", Text: "Track", URL: "https://example.com/track/{{1}}"},
+			}},
+		},
+	}
+	message := func() *TemplateMessage {
+		return NewTemplateMessage("order_update", "en_US").
+			ImageHeader(&models.Media{ID: "media-1"}).
+			BodyText("Kerry", "ORD-1234")
+	}
+
+	tests := []struct {
+		name    string
+		message *TemplateMessage
+		wantErr error
+	}{
+		{name: "valid", message: message().QuickReplyButton(0, "stop").URLButton(1, "ORD-1234")},
+		{name: "missing placeholder", message: message().QuickReplyButton(0, "stop"), wantErr: ErrMissingParameter},
+		{
+			name:    "button type",
+			message: message().URLButton(0, "a").URLButton(1, "ORD-1234"),
+			wantErr: ErrInvalidTemplateMessage,
+		},
+		{
+			name: "header format",
+			message: NewTemplateMessage("order_update", "en_US").
+				DocumentHeader(&models.Media{ID: "media-1"}).BodyText("Kerry", "ORD-1234").URLButton(1, "ORD-1234"),
+			wantErr: ErrInvalidTemplateMessage,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			_, err := tt.message.BuildFor(definition)
+			if (tt.wantErr == nil) != (err == nil) || (err != nil && !strings.Contains(err.Error(), tt.wantErr.Error())) {
+				t.Errorf("BuildFor() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestTemplateMessage_LimitedTimeOffer(t *testing.T) {
+	t.Parallel()
+	definition := &Definition{
+		Name:     "spring_sale",
+		Language: "en_US",
+		Category: "MARKETING",
+		Components: []*Component{
+			{Type: ComponentHeader, Format: FormatImage},
+			{
+				Type:             ComponentLimitedTimeOffer,
+				LimitedTimeOffer: &LimitedTimeOffer{Text: "Spring sale", HasExpiration: true},
+			},
+			{Type: ComponentBody, Text: "Hi {{1}}, the sale ends soon."},
+			{Type: ComponentButtons, Buttons: []*Button{
+				{Type: "COPY_CODE", Text: "Copy offer code"},
+				{Type: "URL", Text: "Shop", URL: "https://example.com/shop/{{1}}"},
+			}},
+		},
+	}
+	expiresAt := time.Date(2024, 3, 20, 12, 0, 0, 0, time.UTC)
+	message := func() *TemplateMessage {
+		return NewTemplateMessage("spring_sale", "en_US").
+			ImageHeader(&models.Media{ID: "media-1"}).
+			BodyText("Kerry").
+			CopyCodeButton(0, "SPRING10").
+			URLButton(1, "spring")
+	}
+
+	tmpl, err := message().LimitedTimeOffer(expiresAt).BuildFor(definition)
+	if err != nil {
+		t.Fatalf("BuildFor() error = %v", err)
+	}
+	got, _ := json.Marshal(tmpl.Components[:2])
+	want := `[{"type":"header","parameters":[{"type":"image","image":{"id":"media-1"}}]},` +
+		`{"type":"limited_time_offer","parameters":[{"type":"limited_time_offer",` +
+		`"limited_time_offer":{"expiration_time_ms":1710936000000}}]}]`
+	if string(got) != want {
+		t.Errorf("got %s\nwant %s", got, want)
+	}
+
+	if _, err := message().BuildFor(definition); err == nil ||
+		!strings.Contains(err.Error(), "the offer of the template expires") {
+		t.Errorf("BuildFor() without expiration error = %v", err)
+	}
+
+	definition.Components[1].LimitedTimeOffer.HasExpiration = false
+	if _, err := message().LimitedTimeOffer(expiresAt).BuildFor(definition); err == nil ||
+		!strings.Contains(err.Error(), "the template has no expiring offer") {
+		t.Errorf("BuildFor() with expiration error = %v", err)
+	}
+
+	if _, err := message().LimitedTimeOffer(time.Time{}).Build(); err == nil ||
+		!strings.Contains(err.Error(), "limited time offer: the expiration time is missing") {
+		t.Errorf("Build() with zero expiration error = %v", err)
+	}
+}
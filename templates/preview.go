@@ -0,0 +1,386 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+// Package templates renders previews of template messages. A preview shows how a message will
+// look once the parameters are substituted in the approved template, which is useful for
+// approval interfaces and for testing what is sent.
+//
+// Example:
+//
+//	definition := &templates.Definition{
+//		Name:     "order_update",
+//		Language: "en_US",
+//		Components: []*templates.Component{
+//			{Type: templates.ComponentHeader, Format: templates.FormatText, Text: "Order {{1}}"},
+//			{Type: templates.ComponentBody, Text: "Hi {{1}}, your order ships on {{2}}."},
+//			{Type: templates.ComponentButtons, Buttons: []*templates.Button{{Type: "QUICK_REPLY", Text: "Track"}}},
+//		},
+//	}
+//	preview, err := templates.Render(definition, message.Template)
+//	fmt.Println(preview.Text())
+package templates
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"html/template"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/lowkruc/go-whatsapp-api/models"
+)
+
+// Component types and header formats as returned by the template management API.
+const (
+	ComponentHeader  = "HEADER"
+	ComponentBody    = "BODY"
+	ComponentFooter  = "FOOTER"
+	ComponentButtons = "BUTTONS"
+
+	ComponentLimitedTimeOffer = "LIMITED_TIME_OFFER"
+
+	FormatText     = "TEXT"
+	FormatImage    = "IMAGE"
+	FormatVideo    = "VIDEO"
+	FormatDocument = "DOCUMENT"
+	FormatLocation = "LOCATION"
+)
+
+var (
+	ErrNilDefinition     = errors.New("template definition is nil")
+	ErrTemplateMismatch  = errors.New("template does not match the definition")
+	ErrMissingParameter  = errors.New("missing template parameter")
+	placeholderPattern   = regexp.MustCompile(`{{\s*([0-9]+|[a-z_][a-z0-9_]*)\s*}}`)
+	errInvalidHTMLFormat = errors.New("could not render html preview")
+)
+
+type (
+	// Definition is an approved message template as returned by the template management API.
+	Definition struct {
+		ID         string       `json:"id,omitempty"`
+		Name       string       `json:"name,omitempty"`
+		Language   string       `json:"language,omitempty"`
+		Category   string       `json:"category,omitempty"`
+		Status     string       `json:"status,omitempty"`
+		Components []*Component `json:"components,omitempty"`
+	}
+
+	// Component is a component of a Definition. Text may contain positional placeholders like {{1}}, or
+	// named ones like {{first_name}}.
+	//
+	// AddSecurityRecommendation and CodeExpirationMinutes are only set on the body and the footer of
+	// authentication templates, see Authentication. LimitedTimeOffer is only set on the
+	// LIMITED_TIME_OFFER component.
+	Component struct {
+		Type                      string            `json:"type,omitempty"`
+		Format                    string            `json:"format,omitempty"`
+		Text                      string            `json:"text,omitempty"`
+		Buttons                   []*Button         `json:"buttons,omitempty"`
+		AddSecurityRecommendation bool              `json:"add_security_recommendation,omitempty"`
+		CodeExpirationMinutes     int               `json:"code_expiration_minutes,omitempty"`
+		LimitedTimeOffer          *LimitedTimeOffer `json:"limited_time_offer,omitempty"`
+	}
+
+	// LimitedTimeOffer is the offer of a LIMITED_TIME_OFFER component. With HasExpiration, the
+	// messages set the expiration of the offer and a countdown is shown.
+	LimitedTimeOffer struct {
+		Text          string `json:"text,omitempty"`
+		HasExpiration bool   `json:"has_expiration,omitempty"`
+	}
+
+	// Button is a button of a BUTTONS component. URL may end with a {{1}} placeholder. The OTP
+	// fields are only set on the ButtonOTP button of authentication templates.
+	Button struct {
+		Type                 string          `json:"type,omitempty"`
+		Text                 string          `json:"text,omitempty"`
+		URL                  string          `json:"url,omitempty"`
+		PhoneNumber          string          `json:"phone_number,omitempty"`
+		OTPType              string          `json:"otp_type,omitempty"`
+		AutofillText         string          `json:"autofill_text,omitempty"`
+		ZeroTapTermsAccepted bool            `json:"zero_tap_terms_accepted,omitempty"`
+		SupportedApps        []*SupportedApp `json:"supported_apps,omitempty"`
+	}
+
+	// Preview is a rendered template message.
+	//
+	// HeaderMedia is the link or media ID of a media header, HeaderFormat tells which kind of
+	// media it is. Header is only set for text headers.
+	Preview struct {
+		Header       string
+		HeaderFormat string
+		HeaderMedia  string
+		Body         string
+		Footer       string
+		Buttons      []*PreviewButton
+	}
+
+	// PreviewButton is a rendered button. Value is the URL, phone number or payload of the button.
+	PreviewButton struct {
+		Type  string
+		Text  string
+		Value string
+	}
+)
+
+// Render substitutes the parameters of tmpl in the definition. tmpl can be nil for templates
+// without parameters. Missing parameters are reported with ErrMissingParameter.
+func Render(definition *Definition, tmpl *models.Template) (*Preview, error) {
+	if definition == nil {
+		return nil, ErrNilDefinition
+	}
+
+	if tmpl != nil && tmpl.Name != "" && definition.Name != "" && tmpl.Name != definition.Name {
+		return nil, fmt.Errorf("%v: name %q, want %q", ErrTemplateMismatch, tmpl.Name, definition.Name)
+	}
+
+	params := collectParameters(tmpl)
+	preview := &Preview{}
+	buttonIndex := 0
+	for _, component := range definition.Components {
+		var err error
+		switch strings.ToUpper(component.Type) {
+		case ComponentHeader:
+			err = renderHeader(preview, component, params.header)
+		case ComponentBody:
+			preview.Body, err = substitute("body", component.Text, params.body)
+		case ComponentFooter:
+			preview.Footer = component.Text
+		case ComponentButtons:
+			for _, button := range component.Buttons {
+				rendered, berr := renderButton(button, params.buttons[buttonIndex])
+				if berr != nil {
+					return nil, berr
+				}
+				preview.Buttons = append(preview.Buttons, rendered)
+				buttonIndex++
+			}
+		}
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return preview, nil
+}
+
+type parameters struct {
+	header  []*models.TemplateParameter
+	body    []*models.TemplateParameter
+	buttons map[int][]*models.TemplateParameter
+}
+
+func collectParameters(tmpl *models.Template) *parameters {
+	params := &parameters{buttons: make(map[int][]*models.TemplateParameter)}
+	if tmpl == nil {
+		return params
+	}
+
+	for _, component := range tmpl.Components {
+		switch strings.ToLower(component.Type) {
+		case "header":
+			params.header = append(params.header, component.Parameters...)
+		case "body":
+			params.body = append(params.body, component.Parameters...)
+		case "button":
+			params.buttons[component.Index] = append(params.buttons[component.Index], component.Parameters...)
+		}
+	}
+
+	return params
+}
+
+func renderHeader(preview *Preview, component *Component, params []*models.TemplateParameter) error {
+	format := strings.ToUpper(component.Format)
+	if format == "" {
+		format = FormatText
+	}
+	preview.HeaderFormat = format
+
+	if format == FormatText {
+		header, err := substitute("header", component.Text, params)
+		preview.Header = header
+
+		return err
+	}
+
+	for _, param := range params {
+		if media := mediaOf(param); media != nil {
+			preview.HeaderMedia = media.Link
+			if media.Link == "" {
+				preview.HeaderMedia = media.ID
+			}
+
+			return nil
+		}
+	}
+
+	if format == FormatLocation {
+		return nil
+	}
+
+	return fmt.Errorf("%v: header %s", ErrMissingParameter, strings.ToLower(format))
+}
+
+func renderButton(button *Button, params []*models.TemplateParameter) (*PreviewButton, error) {
+	rendered := &PreviewButton{
+		Type: button.Type,
+		Text: button.Text,
+	}
+
+	switch strings.ToUpper(button.Type) {
+	case "URL":
+		url, err := substitute("button url", button.URL, params)
+		if err != nil {
+			return nil, err
+		}
+		rendered.Value = url
+	case "PHONE_NUMBER":
+		rendered.Value = button.PhoneNumber
+	case "QUICK_REPLY":
+		for _, param := range params {
+			if param.Payload != "" {
+				rendered.Value = param.Payload
+
+				break
+			}
+		}
+	case ButtonOTP:
+		rendered.Value = otpValue(params)
+	case "COPY_CODE":
+		for _, param := range params {
+			if param.CouponCode != "" {
+				rendered.Value = param.CouponCode
+
+				break
+			}
+		}
+	}
+
+	return rendered, nil
+}
+
+func mediaOf(param *models.TemplateParameter) *models.Media {
+	switch {
+	case param.Image != nil:
+		return param.Image
+	case param.Video != nil:
+		return param.Video
+	case param.Document != nil:
+		return param.Document
+	}
+
+	return nil
+}
+
+// substitute replaces the {{n}} placeholders of text with the text of the n-th parameter, and the
+// named ones, like {{first_name}}, with the parameter of that name.
+func substitute(name, text string, params []*models.TemplateParameter) (string, error) {
+	var missing []string
+	result := placeholderPattern.ReplaceAllStringFunc(text, func(placeholder string) string {
+		key := placeholderPattern.FindStringSubmatch(placeholder)[1]
+		n, err := strconv.Atoi(key)
+		if err != nil {
+			for _, param := range params {
+				if param.ParameterName == key {
+					return ParameterText(param)
+				}
+			}
+			n = 0
+		}
+		if n < 1 || n > len(params) {
+			missing = append(missing, placeholder)
+
+			return placeholder
+		}
+
+		return ParameterText(params[n-1])
+	})
+
+	if len(missing) > 0 {
+		return result, fmt.Errorf("%v: %s %s", ErrMissingParameter, name, strings.Join(missing, ", "))
+	}
+
+	return result, nil
+}
+
+// ParameterText returns the text shown for a parameter. Currency and date_time parameters use
+// their fallback values, as localization happens on the device.
+func ParameterText(param *models.TemplateParameter) string {
+	if param == nil {
+		return ""
+	}
+
+	switch {
+	case param.Currency != nil:
+		return param.Currency.FallbackValue
+	case param.DateTime != nil:
+		return param.DateTime.FallbackValue
+	case param.Text != "":
+		return param.Text
+	}
+
+	return param.Payload
+}
+
+// Text returns a plain text preview of the message.
+func (preview *Preview) Text() string {
+	var b strings.Builder
+	if preview.Header != "" {
+		b.WriteString("*" + preview.Header + "*\n")
+	} else if preview.HeaderFormat != "" && preview.HeaderFormat != FormatText {
+		fmt.Fprintf(&b, "[%s %s]\n", strings.ToLower(preview.HeaderFormat), preview.HeaderMedia)
+	}
+
+	b.WriteString(preview.Body)
+	if preview.Footer != "" {
+		b.WriteString("\n_" + preview.Footer + "_")
+	}
+
+	for _, button := range preview.Buttons {
+		b.WriteString("\n[ " + button.Text + " ]")
+		if button.Value != "" {
+			b.WriteString(" " + button.Value)
+		}
+	}
+
+	return b.String()
+}
+
+var htmlPreview = template.Must(template.New("preview").Parse(`<div class="wa-template">
+{{- if .Header}}<p class="wa-header"><strong>{{.Header}}</strong></p>{{end}}
+{{- if eq .HeaderFormat "IMAGE"}}<img class="wa-header" src="{{.HeaderMedia}}">{{end}}
+{{- if eq .HeaderFormat "VIDEO"}}<video class="wa-header" src="{{.HeaderMedia}}"></video>{{end}}
+{{- if eq .HeaderFormat "DOCUMENT"}}<a class="wa-header" href="{{.HeaderMedia}}">document</a>{{end}}
+<p class="wa-body">{{.Body}}</p>
+{{- if .Footer}}<p class="wa-footer"><small>{{.Footer}}</small></p>{{end}}
+{{- range .Buttons}}<button class="wa-button" data-type="{{.Type}}" data-value="{{.Value}}">{{.Text}}</button>{{end}}
+</div>`))
+
+// HTML returns an HTML preview of the message. All the values are escaped.
+func (preview *Preview) HTML() (string, error) {
+	var buf bytes.Buffer
+	if err := htmlPreview.Execute(&buf, preview); err != nil {
+		return "", fmt.Errorf("%v: %v", errInvalidHTMLFormat, err)
+	}
+
+	return buf.String(), nil
+}
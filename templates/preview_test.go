@@ -0,0 +1,81 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package templates
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/lowkruc/go-whatsapp-api/models"
+)
+
+func TestRender(t *testing.T) {
+	t.Parallel()
+	definition := &Definition{
+		Name: "order_update",
+		Components: []*Component{
+			{Type: ComponentHeader, Format: FormatText, Text: "Order {{1}}"},
+			{Type: ComponentBody, Text: "Hi {{1}}, you paid {{2}}. <b>Thanks</b>"},
+			{Type: ComponentFooter, Text: "Reply STOP to opt out"},
+			{Type: ComponentButtons, Buttons: []*Button{
+				{Type: "URL", Text: "Track", URL: "https://example.com/orders/{{1}}"},
+				{Type: "QUICK_REPLY", Text: "Help"},
+			}},
+		},
+	}
+
+	tmpl := &models.Template{
+		Name: "order_update",
+		Components: []*models.TemplateComponent{
+			{Type: "header", Parameters: []*models.TemplateParameter{{Type: "text", Text: "#42"}}},
+			{Type: "body", Parameters: []*models.TemplateParameter{
+				{Type: "text", Text: "Amina"},
+				{Type: "currency", Currency: &models.TemplateCurrency{FallbackValue: "$10.99", Code: "USD", Amount1000: 10990}},
+			}},
+			{Type: "button", SubType: "url", Index: 0, Parameters: []*models.TemplateParameter{{Type: "text", Text: "42"}}},
+			{Type: "button", SubType: "quick_reply", Index: 1, Parameters: []*models.TemplateParameter{{Type: "payload", Payload: "help"}}},
+		},
+	}
+
+	preview, err := Render(definition, tmpl)
+	if err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+
+	want := "*Order #42*\nHi Amina, you paid $10.99. <b>Thanks</b>\n_Reply STOP to opt out_\n" +
+		"[ Track ] https://example.com/orders/42\n[ Help ] help"
+	if got := preview.Text(); got != want {
+		t.Errorf("Text() = %q, want %q", got, want)
+	}
+
+	html, err := preview.HTML()
+	if err != nil {
+		t.Fatalf("HTML() error = %v", err)
+	}
+
+	if strings.Contains(html, "<b>") || !strings.Contains(html, "&lt;b&gt;Thanks") {
+		t.Errorf("HTML() does not escape the body: %s", html)
+	}
+
+	tmpl.Components[1].Parameters = tmpl.Components[1].Parameters[:1]
+	if _, err := Render(definition, tmpl); err == nil || !strings.Contains(err.Error(), "{{2}}") {
+		t.Errorf("Render() with a missing parameter error = %v", err)
+	}
+}
@@ -0,0 +1,169 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+// Package textmatch normalizes the text and the emoji sent by users so that they can be compared
+// the way people read them: reactions regardless of skin tone and presentation selectors, and
+// keywords regardless of case, diacritics and spacing.
+//
+// Example:
+//
+//	matcher := textmatch.NewMatcher("menu", "opt out", "café")
+//	keyword, ok := matcher.Match("  I want the CAFE menu!") // "café", true
+//	textmatch.SameReaction("👍🏽", "👍") // true
+package textmatch
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+)
+
+//nolint:gochecknoglobals
+var folded = map[rune]string{
+	'ß': "ss", 'à': "a", 'á': "a", 'â': "a", 'ã': "a", 'ä': "a", 'å': "a", 'æ': "ae", 'ç': "c",
+	'è': "e", 'é': "e", 'ê': "e", 'ë': "e", 'ì': "i", 'í': "i", 'î': "i", 'ï': "i", 'ñ': "n",
+	'ò': "o", 'ó': "o", 'ô': "o", 'õ': "o", 'ö': "o", 'ø': "o", 'ù': "u", 'ú': "u", 'û': "u",
+	'ü': "u", 'ý': "y", 'ÿ': "y", 'ā': "a", 'ă': "a", 'ą': "a", 'ć': "c", 'ĉ': "c", 'ċ': "c",
+	'č': "c", 'ď': "d", 'đ': "d", 'ē': "e", 'ĕ': "e", 'ė': "e", 'ę': "e", 'ě': "e", 'ĝ': "g",
+	'ğ': "g", 'ġ': "g", 'ģ': "g", 'ĥ': "h", 'ħ': "h", 'ĩ': "i", 'ī': "i", 'ĭ': "i", 'į': "i",
+	'ı': "i", 'ĵ': "j", 'ķ': "k", 'ĺ': "l", 'ļ': "l", 'ľ': "l", 'ł': "l", 'ń': "n", 'ņ': "n",
+	'ň': "n", 'ō': "o", 'ŏ': "o", 'ő': "o", 'œ': "oe", 'ŕ': "r", 'ŗ': "r", 'ř': "r", 'ś': "s",
+	'ŝ': "s", 'ş': "s", 'š': "s", 'ţ': "t", 'ť': "t", 'ŧ': "t", 'ũ': "u", 'ū': "u", 'ŭ': "u",
+	'ů': "u", 'ű': "u", 'ų': "u", 'ŵ': "w", 'ŷ': "y", 'ź': "z", 'ż': "z", 'ž': "z", 'ơ': "o",
+	'ư': "u", 'ǎ': "a", 'ǐ': "i", 'ǒ': "o", 'ǔ': "u", 'ǖ': "u", 'ǘ': "u", 'ǚ': "u", 'ǜ': "u",
+	'ǟ': "a", 'ǡ': "a", 'ǧ': "g", 'ǩ': "k", 'ǫ': "o", 'ǭ': "o", 'ǰ': "j", 'ǵ': "g", 'ǹ': "n",
+	'ǻ': "a", 'ȁ': "a", 'ȃ': "a", 'ȅ': "e", 'ȇ': "e", 'ȉ': "i", 'ȋ': "i", 'ȍ': "o", 'ȏ': "o",
+	'ȑ': "r", 'ȓ': "r", 'ȕ': "u", 'ȗ': "u", 'ș': "s", 'ț': "t", 'ȟ': "h", 'ȧ': "a", 'ȩ': "e",
+	'ȫ': "o", 'ȭ': "o", 'ȯ': "o", 'ȱ': "o", 'ȳ': "y"}
+
+// emoji modifiers that change how an emoji looks but not what it means.
+const (
+	textPresentation  = '\uFE0E'
+	emojiPresentation = '\uFE0F'
+	skinToneFirst     = '\U0001F3FB'
+	skinToneLast      = '\U0001F3FF'
+	zeroWidthJoiner   = '\u200D'
+)
+
+// NormalizeEmoji removes the presentation selectors and the skin tone modifiers from s, so that
+// "👍🏽" and "👍" or "❤️" and "❤" compare equal. Joined sequences keep their other parts.
+func NormalizeEmoji(s string) string {
+	var b strings.Builder
+	b.Grow(len(s))
+	for _, r := range s {
+		if r == textPresentation || r == emojiPresentation || (r >= skinToneFirst && r <= skinToneLast) {
+			continue
+		}
+		b.WriteRune(r)
+	}
+
+	// a skin tone between a zero width joiner and the next part leaves two joiners in a row.
+	return strings.ReplaceAll(b.String(), string([]rune{zeroWidthJoiner, zeroWidthJoiner}), string(zeroWidthJoiner))
+}
+
+// SameReaction reports whether two reaction emoji are the same once normalized with
+// NormalizeEmoji. Gendered variants are different reactions.
+func SameReaction(a, b string) bool {
+	return NormalizeEmoji(strings.TrimSpace(a)) == NormalizeEmoji(strings.TrimSpace(b))
+}
+
+// Fold lowercases s, removes the diacritics of the Latin letters, the combining marks and the
+// emoji modifiers, and collapses the punctuation and the spaces into single spaces. Two texts
+// that read the same match once folded: "Café  Menu!" and "cafe menu" both fold to "cafe menu".
+func Fold(s string) string {
+	var b strings.Builder
+	b.Grow(len(s))
+	space := false
+	for _, r := range NormalizeEmoji(s) {
+		r = unicode.ToLower(r)
+		switch {
+		case unicode.Is(unicode.Mn, r):
+			continue
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			if space && b.Len() > 0 {
+				b.WriteByte(' ')
+			}
+			space = false
+			if f, ok := folded[r]; ok {
+				b.WriteString(f)
+			} else {
+				b.WriteRune(r)
+			}
+		case unicode.IsSpace(r) || unicode.IsPunct(r):
+			space = true
+		default:
+			// symbols like emoji are kept as words of their own.
+			if b.Len() > 0 {
+				b.WriteByte(' ')
+			}
+			b.WriteRune(r)
+			space = true
+		}
+	}
+
+	return b.String()
+}
+
+// Matcher finds keywords in texts. The keywords and the texts are folded with Fold and a keyword
+// matches whole words only, "menu" matches "the menu please" but not "menus".
+type Matcher struct {
+	keywords []matcherKeyword
+}
+
+type matcherKeyword struct {
+	keyword string
+	folded  string
+}
+
+// NewMatcher creates a Matcher of the keywords. Longer keywords are tried first, so "opt out"
+// wins over "out".
+func NewMatcher(keywords ...string) *Matcher {
+	m := &Matcher{}
+	for _, keyword := range keywords {
+		if f := Fold(keyword); f != "" {
+			m.keywords = append(m.keywords, matcherKeyword{keyword: keyword, folded: f})
+		}
+	}
+	sort.SliceStable(m.keywords, func(i, j int) bool {
+		return len(m.keywords[i].folded) > len(m.keywords[j].folded)
+	})
+
+	return m
+}
+
+// Match returns the keyword, as given to NewMatcher, found in text.
+func (m *Matcher) Match(text string) (string, bool) {
+	if m == nil {
+		return "", false
+	}
+	f := " " + Fold(text) + " "
+	for _, k := range m.keywords {
+		if strings.Contains(f, " "+k.folded+" ") {
+			return k.keyword, true
+		}
+	}
+
+	return "", false
+}
+
+// Equal reports whether two texts are the same once folded, for example a reply and the title of
+// a button.
+func Equal(a, b string) bool {
+	return Fold(a) == Fold(b)
+}
@@ -0,0 +1,78 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package textmatch
+
+import "testing"
+
+func TestSameReaction(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		a, b string
+		want bool
+	}{
+		{"\U0001F44D\U0001F3FD", "\U0001F44D", true},                                 // thumbs up, medium skin tone
+		{"\u2764\uFE0F", "\u2764", true},                                             // red heart with and without selector
+		{"\U0001F469\U0001F3FE\u200D\U0001F4BB", "\U0001F469\u200D\U0001F4BB", true}, // woman technologist
+		{"\U0001F937\u200D\u2640\uFE0F", "\U0001F937\u200D\u2642\uFE0F", false},      // gendered shrugs
+		{"\U0001F44D", "\U0001F44E", false},
+	}
+	for _, tt := range tests {
+		if got := SameReaction(tt.a, tt.b); got != tt.want {
+			t.Errorf("SameReaction(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestFold(t *testing.T) {
+	t.Parallel()
+	tests := map[string]string{
+		"  Caf\u00e9  Menu!":               "cafe menu",
+		"Cafe\u0301":                       "cafe", // decomposed accent
+		"STRA\u00dfE, \u0141\u00f3d\u017a": "strasse lodz",
+		"ok\U0001F44D\U0001F3FB":           "ok \U0001F44D",
+		"":                                 "",
+	}
+	for in, want := range tests {
+		if got := Fold(in); got != want {
+			t.Errorf("Fold(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestMatcher_Match(t *testing.T) {
+	t.Parallel()
+	matcher := NewMatcher("out", "opt out", "Caf\u00e9", "menu")
+	tests := []struct {
+		text    string
+		keyword string
+		ok      bool
+	}{
+		{"Please OPT-OUT now", "opt out", true},
+		{"one cafe, please", "Caf\u00e9", true},
+		{"show me the menus", "", false},
+		{"MENU", "menu", true},
+	}
+	for _, tt := range tests {
+		keyword, ok := matcher.Match(tt.text)
+		if keyword != tt.keyword || ok != tt.ok {
+			t.Errorf("Match(%q) = %q, %v, want %q, %v", tt.text, keyword, ok, tt.keyword, tt.ok)
+		}
+	}
+}
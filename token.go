@@ -0,0 +1,128 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package whatsapp
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	whttp "github.com/lowkruc/go-whatsapp-api/http"
+)
+
+type (
+	// GranularScope is a scope of a token restricted to some objects, like the WhatsApp Business
+	// Accounts the whatsapp_business_messaging scope is granted on.
+	GranularScope struct {
+		Scope     string   `json:"scope"`
+		TargetIDs []string `json:"target_ids,omitempty"`
+	}
+
+	// TokenInfo is the information of an access token as returned by the debug_token endpoint.
+	// ExpiresAt is 0 for the tokens that never expire, like the system user ones.
+	TokenInfo struct {
+		AppID          string           `json:"app_id,omitempty"`
+		Type           string           `json:"type,omitempty"`
+		Application    string           `json:"application,omitempty"`
+		ExpiresAt      int64            `json:"expires_at,omitempty"`
+		IsValid        bool             `json:"is_valid"`
+		Scopes         []string         `json:"scopes,omitempty"`
+		GranularScopes []*GranularScope `json:"granular_scopes,omitempty"`
+		UserID         string           `json:"user_id,omitempty"`
+	}
+)
+
+// DebugToken returns the information of the access token of the client, like its scopes.
+//
+//	curl -X GET "https://graph.facebook.com/v16.0/debug_token?input_token={access-token}" \
+//		-H "Authorization: Bearer {access-token}"
+func (client *Client) DebugToken(ctx context.Context) (*TokenInfo, error) {
+	cctx := client.context()
+	reqCtx := &whttp.RequestContext{
+		Name:       "debug token",
+		BaseURL:    cctx.baseURL,
+		ApiVersion: cctx.apiVersion,
+		SenderID:   "debug_token",
+	}
+	request := &whttp.Request{
+		Context: reqCtx,
+		Method:  http.MethodGet,
+		Bearer:  cctx.accessToken,
+		Query:   map[string]string{"input_token": cctx.accessToken},
+	}
+
+	var response struct {
+		Data *TokenInfo `json:"data"`
+	}
+	if err := whttp.Do(ctx, client.http, request, &response, client.hooks...); err != nil {
+		return nil, fmt.Errorf("debug token: %v", redactToken(err, cctx.accessToken))
+	}
+	if response.Data == nil {
+		return nil, fmt.Errorf("debug token: %v", ErrBadRequestFormat)
+	}
+
+	return response.Data, nil
+}
+
+// redactToken returns the message of err without the token. The transport errors quote the URL of
+// the request, and the token is a parameter of the debug_token one.
+func redactToken(err error, token string) string {
+	message := err.Error()
+	if token == "" {
+		return message
+	}
+
+	for _, t := range []string{token, url.QueryEscape(token)} {
+		message = strings.ReplaceAll(message, t, "REDACTED")
+	}
+
+	return message
+}
+
+// MissingScopes returns the scopes the token does not have.
+func (info *TokenInfo) MissingScopes(scopes ...string) []string {
+	granted := make(map[string]bool)
+	if info != nil {
+		for _, scope := range info.Scopes {
+			granted[scope] = true
+		}
+	}
+
+	var missing []string
+	for _, scope := range scopes {
+		if !granted[scope] {
+			missing = append(missing, scope)
+		}
+	}
+
+	return missing
+}
+
+// Expiration returns when the token expires, the zero time when it never does.
+func (info *TokenInfo) Expiration() time.Time {
+	if info == nil || info.ExpiresAt == 0 {
+		return time.Time{}
+	}
+
+	return time.Unix(info.ExpiresAt, 0)
+}
@@ -0,0 +1,75 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package whatsapp
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestClient_DebugToken(t *testing.T) {
+	t.Parallel()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v16.0/debug_token" || r.URL.Query().Get("input_token") != "token" {
+			http.Error(w, "unexpected request "+r.URL.String(), http.StatusBadRequest)
+
+			return
+		}
+		_, _ = w.Write([]byte(`{"data":{"app_id":"1","type":"SYSTEM_USER","is_valid":true,"expires_at":0,
+			"scopes":["whatsapp_business_messaging","business_management"],
+			"granular_scopes":[{"scope":"whatsapp_business_messaging","target_ids":["102290129340398"]}]}}`))
+	}))
+	defer server.Close()
+
+	client := NewClient(WithBaseURL(server.URL), WithVersion("v16.0"), WithAccessToken("token"))
+	info, err := client.DebugToken(context.TODO())
+	if err != nil {
+		t.Fatalf("DebugToken() error = %v", err)
+	}
+	if !info.IsValid || !info.Expiration().IsZero() || len(info.GranularScopes) != 1 ||
+		info.GranularScopes[0].TargetIDs[0] != "102290129340398" {
+		t.Errorf("unexpected token info %+v", info)
+	}
+
+	missing := info.MissingScopes("whatsapp_business_messaging", "whatsapp_business_management")
+	if len(missing) != 1 || missing[0] != "whatsapp_business_management" {
+		t.Errorf("MissingScopes() = %v, want [whatsapp_business_management]", missing)
+	}
+}
+
+func TestClient_DebugTokenRedactsToken(t *testing.T) {
+	t.Parallel()
+	server := httptest.NewServer(http.NotFoundHandler())
+	baseURL := server.URL
+	server.Close()
+
+	client := NewClient(WithBaseURL(baseURL), WithVersion("v16.0"), WithAccessToken("SECRET+TOKEN/123"))
+	_, err := client.DebugToken(context.TODO())
+	if err == nil {
+		t.Fatal("DebugToken() error = nil, want a transport error")
+	}
+
+	if strings.Contains(err.Error(), "SECRET") {
+		t.Errorf("DebugToken() error = %q, leaks the token", err)
+	}
+}
@@ -0,0 +1,214 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+// Package transcribe turns the voice notes received through webhooks into text before the media
+// hook runs, so that voice commands can be handled like text messages. The speech to text
+// service is plugged in as a Transcriber, the package does not depend on any.
+//
+// Example:
+//
+//	voice := transcribe.NewVoiceNotes(client, myTranscriber)
+//	listener.OnMediaMessage(voice.Hook(func(ctx context.Context, nctx *webhooks.NotificationContext,
+//		mctx *webhooks.MessageContext, media *models.MediaInfo) error {
+//		if t, ok := transcribe.FromContext(ctx); ok {
+//			return handleCommand(ctx, mctx.From, t.Text)
+//		}
+//		return nil
+//	}))
+package transcribe
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	whatsapp "github.com/lowkruc/go-whatsapp-api"
+	"github.com/lowkruc/go-whatsapp-api/models"
+	"github.com/lowkruc/go-whatsapp-api/webhooks"
+)
+
+// DefaultRetries is how many times a voice note download is retried.
+const DefaultRetries = 2
+
+var ErrNoTranscriber = errors.New("no transcriber")
+
+type (
+	// Transcription is the text of a voice note. Language and Confidence are set when the
+	// Transcriber provides them.
+	Transcription struct {
+		MediaID    string
+		Text       string
+		Language   string
+		Confidence float64
+	}
+
+	// Transcriber converts the audio of a voice note to text. mimeType is the type of the audio,
+	// usually "audio/ogg; codecs=opus" for voice notes.
+	Transcriber interface {
+		Transcribe(ctx context.Context, audio io.Reader, mimeType string) (*Transcription, error)
+	}
+
+	// TranscriberFunc is a function that implements Transcriber.
+	TranscriberFunc func(ctx context.Context, audio io.Reader, mimeType string) (*Transcription, error)
+
+	// Downloader downloads inbound media, *whatsapp.Client implements it.
+	Downloader interface {
+		DownloadMedia(ctx context.Context, mediaID string, retries int) (*whatsapp.DownloadMediaResponse, error)
+	}
+
+	// VoiceNotes downloads and transcribes the voice notes before the media hook runs. A failed
+	// download or transcription does not fail the hook, it is called without a transcription
+	// and the error is passed to the OnError function.
+	VoiceNotes struct {
+		downloader  Downloader
+		transcriber Transcriber
+		retries     int
+		onError     func(ctx context.Context, mediaID string, err error)
+	}
+
+	VoiceNotesOption func(*VoiceNotes)
+
+	transcriptionKey struct{}
+)
+
+func (fn TranscriberFunc) Transcribe(ctx context.Context, audio io.Reader, mimeType string) (*Transcription, error) {
+	return fn(ctx, audio, mimeType)
+}
+
+// WithContext returns a context that carries the transcription.
+func WithContext(ctx context.Context, transcription *Transcription) context.Context {
+	return context.WithValue(ctx, transcriptionKey{}, transcription)
+}
+
+// FromContext returns the transcription of the voice note being handled.
+func FromContext(ctx context.Context) (*Transcription, bool) {
+	transcription, ok := ctx.Value(transcriptionKey{}).(*Transcription)
+
+	return transcription, ok && transcription != nil
+}
+
+// WithRetries sets how many times a download is retried, DefaultRetries by default.
+func WithRetries(retries int) VoiceNotesOption {
+	return func(v *VoiceNotes) {
+		v.retries = retries
+	}
+}
+
+// WithOnError sets the function called when a voice note can not be downloaded or transcribed.
+func WithOnError(fn func(ctx context.Context, mediaID string, err error)) VoiceNotesOption {
+	return func(v *VoiceNotes) {
+		v.onError = fn
+	}
+}
+
+// NewVoiceNotes creates a VoiceNotes that downloads the voice notes with downloader and
+// transcribes them with transcriber.
+func NewVoiceNotes(downloader Downloader, transcriber Transcriber, options ...VoiceNotesOption) *VoiceNotes {
+	v := &VoiceNotes{
+		downloader:  downloader,
+		transcriber: transcriber,
+		retries:     DefaultRetries,
+	}
+	for _, option := range options {
+		option(v)
+	}
+
+	return v
+}
+
+// Hook returns a media hook that transcribes the audio messages and calls next with the
+// transcription in the context, see FromContext. Other media are passed to next unchanged.
+func (v *VoiceNotes) Hook(next webhooks.OnMediaMessageHook) webhooks.OnMediaMessageHook {
+	return func(ctx context.Context, nctx *webhooks.NotificationContext, mctx *webhooks.MessageContext,
+		media *models.MediaInfo,
+	) error {
+		if mctx != nil && webhooks.ParseMessageType(mctx.Type) == webhooks.AudioMessageType && media != nil {
+			transcription, err := v.Transcribe(ctx, media)
+			if err != nil {
+				if v.onError != nil {
+					v.onError(ctx, media.ID, err)
+				}
+			} else {
+				ctx = WithContext(ctx, transcription)
+			}
+		}
+
+		if next == nil {
+			return nil
+		}
+
+		return next(ctx, nctx, mctx, media)
+	}
+}
+
+// VoiceNoteHook returns a voice note hook that transcribes every voice note and calls next with
+// the transcription in the context, leaving the audio files sent as attachments to the media hook.
+func (v *VoiceNotes) VoiceNoteHook(next webhooks.OnVoiceNoteHook) webhooks.OnVoiceNoteHook {
+	return func(ctx context.Context, nctx *webhooks.NotificationContext, mctx *webhooks.MessageContext,
+		media *models.MediaInfo,
+	) error {
+		if media != nil {
+			transcription, err := v.Transcribe(ctx, media)
+			if err != nil {
+				if v.onError != nil {
+					v.onError(ctx, media.ID, err)
+				}
+			} else {
+				ctx = WithContext(ctx, transcription)
+			}
+		}
+
+		if next == nil {
+			return nil
+		}
+
+		return next(ctx, nctx, mctx, media)
+	}
+}
+
+// Transcribe downloads the audio and transcribes it.
+func (v *VoiceNotes) Transcribe(ctx context.Context, media *models.MediaInfo) (*Transcription, error) {
+	if v.transcriber == nil {
+		return nil, ErrNoTranscriber
+	}
+
+	download, err := v.downloader.DownloadMedia(ctx, media.ID, v.retries)
+	if err != nil {
+		return nil, fmt.Errorf("transcribe %s: %v", media.ID, err)
+	}
+
+	mimeType := media.MimeType
+	if mimeType == "" && download.Headers != nil {
+		mimeType = download.Headers.Get("Content-Type")
+	}
+
+	transcription, err := v.transcriber.Transcribe(ctx, download.Body, mimeType)
+	if err != nil {
+		return nil, fmt.Errorf("transcribe %s: %v", media.ID, err)
+	}
+	if transcription == nil {
+		transcription = &Transcription{}
+	}
+	transcription.MediaID = media.ID
+	transcription.Text = strings.TrimSpace(transcription.Text)
+
+	return transcription, nil
+}
@@ -0,0 +1,123 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package transcribe
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"testing"
+
+	whatsapp "github.com/lowkruc/go-whatsapp-api"
+	"github.com/lowkruc/go-whatsapp-api/models"
+	"github.com/lowkruc/go-whatsapp-api/webhooks"
+)
+
+type fakeDownloader map[string]string
+
+func (d fakeDownloader) DownloadMedia(ctx context.Context, mediaID string, retries int) (
+	*whatsapp.DownloadMediaResponse, error,
+) {
+	content, ok := d[mediaID]
+	if !ok {
+		return nil, whatsapp.ErrMediaDownload
+	}
+
+	return &whatsapp.DownloadMediaResponse{
+		Headers: http.Header{"Content-Type": []string{"audio/ogg"}},
+		Body:    bytes.NewBufferString(content),
+	}, nil
+}
+
+func TestVoiceNotes_Hook(t *testing.T) {
+	t.Parallel()
+	transcriber := TranscriberFunc(func(ctx context.Context, audio io.Reader, mimeType string) (*Transcription, error) {
+		content, _ := io.ReadAll(audio)
+
+		return &Transcription{Text: " " + string(content) + " (" + mimeType + ")", Language: "en"}, nil
+	})
+
+	var failed []string
+	voice := NewVoiceNotes(fakeDownloader{"media.1": "order status"}, transcriber,
+		WithOnError(func(ctx context.Context, mediaID string, err error) {
+			failed = append(failed, mediaID)
+		}))
+
+	var got []*Transcription
+	hook := voice.Hook(func(ctx context.Context, nctx *webhooks.NotificationContext, mctx *webhooks.MessageContext,
+		media *models.MediaInfo,
+	) error {
+		transcription, _ := FromContext(ctx)
+		got = append(got, transcription)
+
+		return nil
+	})
+
+	ctx := context.TODO()
+	nctx := &webhooks.NotificationContext{}
+	_ = hook(ctx, nctx, &webhooks.MessageContext{Type: "audio"}, &models.MediaInfo{ID: "media.1"})
+	_ = hook(ctx, nctx, &webhooks.MessageContext{Type: "audio"}, &models.MediaInfo{ID: "media.2"})
+	_ = hook(ctx, nctx, &webhooks.MessageContext{Type: "image"}, &models.MediaInfo{ID: "media.1"})
+
+	if len(got) != 3 || got[0] == nil || got[1] != nil || got[2] != nil {
+		t.Fatalf("got transcriptions %v, want only the first one", got)
+	}
+	if want := (Transcription{MediaID: "media.1", Text: "order status (audio/ogg)", Language: "en"}); *got[0] != want {
+		t.Errorf("got %+v, want %+v", *got[0], want)
+	}
+	if len(failed) != 1 || failed[0] != "media.2" {
+		t.Errorf("got failed downloads %v, want [media.2]", failed)
+	}
+
+	if _, err := NewVoiceNotes(fakeDownloader{}, nil).Transcribe(ctx, &models.MediaInfo{ID: "media.1"}); !errors.Is(err, ErrNoTranscriber) {
+		t.Errorf("Transcribe() error = %v, want %v", err, ErrNoTranscriber)
+	}
+}
+
+func TestVoiceNotes_VoiceNoteHook(t *testing.T) {
+	t.Parallel()
+	transcriber := TranscriberFunc(func(ctx context.Context, audio io.Reader, mimeType string) (*Transcription, error) {
+		content, _ := io.ReadAll(audio)
+
+		return &Transcription{Text: string(content)}, nil
+	})
+
+	var got string
+	hook := NewVoiceNotes(fakeDownloader{"media.1": "call me back"}, transcriber).VoiceNoteHook(
+		func(ctx context.Context, nctx *webhooks.NotificationContext, mctx *webhooks.MessageContext,
+			media *models.MediaInfo,
+		) error {
+			if transcription, ok := FromContext(ctx); ok {
+				got = transcription.Text
+			}
+
+			return nil
+		})
+
+	media := &models.MediaInfo{ID: "media.1", MimeType: "audio/ogg; codecs=opus", Voice: true}
+	if err := hook(context.TODO(), &webhooks.NotificationContext{}, &webhooks.MessageContext{Type: "audio"}, media); err != nil {
+		t.Fatalf("hook() error = %v", err)
+	}
+	if got != "call me back" {
+		t.Errorf("got transcription %q, want %q", got, "call me back")
+	}
+}
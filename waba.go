@@ -0,0 +1,108 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package whatsapp
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	whttp "github.com/lowkruc/go-whatsapp-api/http"
+)
+
+// Ownership types of a WhatsApp Business Account.
+const (
+	OwnershipTypeClientOwned = "CLIENT_OWNED"
+	OwnershipTypeOnBehalfOf  = "ON_BEHALF_OF"
+	OwnershipTypeSelfOwned   = "SELF"
+)
+
+// wabaFields are the fields requested by GetWABA.
+var wabaFields = []string{
+	"id", "name", "currency", "timezone_id", "message_template_namespace", "account_review_status",
+	"business_verification_status", "country", "ownership_type", "owner_business_info",
+	"on_behalf_of_business_info", "primary_funding_id", "purchase_order_number",
+}
+
+type (
+	// BusinessInfo identifies a business that owns, or acts on behalf of, a WhatsApp Business
+	// Account. Status and Type are only set for the on behalf of business.
+	BusinessInfo struct {
+		ID     string `json:"id,omitempty"`
+		Name   string `json:"name,omitempty"`
+		Status string `json:"status,omitempty"`
+		Type   string `json:"type,omitempty"`
+	}
+
+	// WABA is the information of a WhatsApp Business Account.
+	//
+	// TimezoneID is the Graph API timezone ID, not an IANA name. MessageTemplateNamespace is the
+	// namespace of the templates of the account, needed by on-premises clients. OwnershipType is
+	// one of the OwnershipType constants.
+	WABA struct {
+		ID                         string        `json:"id"`
+		Name                       string        `json:"name,omitempty"`
+		Currency                   string        `json:"currency,omitempty"`
+		TimezoneID                 string        `json:"timezone_id,omitempty"`
+		MessageTemplateNamespace   string        `json:"message_template_namespace,omitempty"`
+		AccountReviewStatus        string        `json:"account_review_status,omitempty"`
+		BusinessVerificationStatus string        `json:"business_verification_status,omitempty"`
+		Country                    string        `json:"country,omitempty"`
+		OwnershipType              string        `json:"ownership_type,omitempty"`
+		OwnerBusinessInfo          *BusinessInfo `json:"owner_business_info,omitempty"`
+		OnBehalfOfBusinessInfo     *BusinessInfo `json:"on_behalf_of_business_info,omitempty"`
+		PrimaryFundingID           string        `json:"primary_funding_id,omitempty"`
+		PurchaseOrderNumber        string        `json:"purchase_order_number,omitempty"`
+	}
+)
+
+// GetWABA returns the information of the WhatsApp Business Account with the id, or of the account
+// of the client when id is empty, in a single request.
+//
+//	curl -X GET "https://graph.facebook.com/v16.0/{whatsapp-business-account-id}\
+//		?fields=id,name,currency,timezone_id,message_template_namespace,..." \
+//		-H "Authorization: Bearer {access-token}"
+func (client *Client) GetWABA(ctx context.Context, id string) (*WABA, error) {
+	cctx := client.context()
+	if id == "" {
+		id = cctx.businessAccountID
+	}
+
+	reqCtx := &whttp.RequestContext{
+		Name:       "get waba",
+		BaseURL:    cctx.baseURL,
+		ApiVersion: cctx.apiVersion,
+		SenderID:   id,
+	}
+	request := &whttp.Request{
+		Context: reqCtx,
+		Method:  http.MethodGet,
+		Bearer:  cctx.accessToken,
+		Query:   map[string]string{"fields": strings.Join(wabaFields, ",")},
+	}
+
+	var waba WABA
+	if err := whttp.Do(ctx, client.http, request, &waba, client.hooks...); err != nil {
+		return nil, fmt.Errorf("get waba: %v", err)
+	}
+
+	return &waba, nil
+}
@@ -0,0 +1,64 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package whatsapp
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestClient_GetWABA(t *testing.T) {
+	t.Parallel()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v16.0/waba_id" || r.Header.Get("Authorization") != "Bearer token" ||
+			!strings.Contains(r.URL.Query().Get("fields"), "message_template_namespace") {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"error":{"message":"Unsupported get request.","code":100}}`))
+
+			return
+		}
+		_, _ = w.Write([]byte(`{"id":"waba_id","name":"Jasper's Market","currency":"USD","timezone_id":"1",
+			"message_template_namespace":"ns_1","ownership_type":"ON_BEHALF_OF",
+			"owner_business_info":{"id":"1","name":"Jasper"},
+			"on_behalf_of_business_info":{"id":"2","name":"Provider","status":"APPROVED","type":"SELF"}}`))
+	}))
+	defer server.Close()
+
+	client := NewClient(WithBaseURL(server.URL), WithVersion("v16.0"), WithAccessToken("token"),
+		WithBusinessAccountID("waba_id"))
+
+	waba, err := client.GetWABA(context.TODO(), "")
+	if err != nil {
+		t.Fatalf("GetWABA() error = %v", err)
+	}
+
+	if waba.Name != "Jasper's Market" || waba.Currency != "USD" || waba.MessageTemplateNamespace != "ns_1" ||
+		waba.OwnershipType != OwnershipTypeOnBehalfOf || waba.OwnerBusinessInfo == nil ||
+		waba.OnBehalfOfBusinessInfo == nil || waba.OnBehalfOfBusinessInfo.Status != "APPROVED" {
+		t.Errorf("unexpected waba %+v", waba)
+	}
+
+	if _, err := client.GetWABA(context.TODO(), "other"); err == nil {
+		t.Error("GetWABA() of an unknown account error = nil, want an error")
+	}
+}
@@ -0,0 +1,130 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package webhooks
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+)
+
+// Events of an account_update change.
+const (
+	AccountEventVerifiedAccount    = "VERIFIED_ACCOUNT"
+	AccountEventDisabledUpdate     = "DISABLED_UPDATE"
+	AccountEventBan                = "BAN"
+	AccountEventAccountViolation   = "ACCOUNT_VIOLATION"
+	AccountEventAccountRestriction = "ACCOUNT_RESTRICTION"
+	AccountEventAccountDeleted     = "ACCOUNT_DELETED"
+	AccountEventPartnerAdded       = "PARTNER_ADDED"
+	AccountEventPartnerRemoved     = "PARTNER_REMOVED"
+
+	AccountEventPartnerAppInstalled   = "PARTNER_APP_INSTALLED"
+	AccountEventPartnerAppUninstalled = "PARTNER_APP_UNINSTALLED"
+)
+
+// Decisions of an account_review_update change.
+const (
+	AccountReviewApproved = "APPROVED"
+	AccountReviewRejected = "REJECTED"
+	AccountReviewPending  = "PENDING"
+	AccountReviewDeferred = "DEFERRED"
+)
+
+type (
+	// BanInfo is set on DISABLED_UPDATE and BAN events.
+	//
+	// WabaBanState, waba_ban_state — for example SCHEDULE_FOR_DISABLE, DISABLE or REINSTATE.
+	// WabaBanDate, waba_ban_date — when the account was or will be disabled.
+	BanInfo struct {
+		WabaBanState string `json:"waba_ban_state,omitempty"`
+		WabaBanDate  string `json:"waba_ban_date,omitempty"`
+	}
+
+	// RestrictionInfo is a restriction put on the account, Expiration is a unix timestamp.
+	RestrictionInfo struct {
+		RestrictionType string      `json:"restriction_type,omitempty"`
+		Expiration      json.Number `json:"expiration,omitempty"`
+	}
+
+	// ViolationInfo describes the policy the account violated.
+	ViolationInfo struct {
+		ViolationType string `json:"violation_type,omitempty"`
+	}
+
+	// AccountUpdate is the value of an account_update change. It is sent when the business
+	// account is verified, banned, restricted, flagged for a policy violation or deleted, and when
+	// a partner is added or removed.
+	//
+	// PhoneNumber, phone_number — the phone number the update is about, when there is one.
+	// Event, event — what happened, see the AccountEvent constants.
+	// WabaInfo, waba_info — the onboarded account, set on the partner events.
+	AccountUpdate struct {
+		PhoneNumber     string             `json:"phone_number,omitempty"`
+		Event           string             `json:"event,omitempty"`
+		WabaInfo        *WabaInfo          `json:"waba_info,omitempty"`
+		BanInfo         *BanInfo           `json:"ban_info,omitempty"`
+		RestrictionInfo []*RestrictionInfo `json:"restriction_info,omitempty"`
+		ViolationInfo   *ViolationInfo     `json:"violation_info,omitempty"`
+	}
+
+	// AccountReviewUpdate is the value of an account_review_update change, it is sent when the
+	// review of the business account is done.
+	//
+	// Decision, decision — see the AccountReview constants.
+	AccountReviewUpdate struct {
+		Decision string `json:"decision,omitempty"`
+	}
+
+	// OnAccountUpdateHook is called for every account_update change.
+	OnAccountUpdateHook func(ctx context.Context, nctx *NotificationContext, update *AccountUpdate) error
+
+	// OnAccountReviewUpdateHook is called for every account_review_update change.
+	OnAccountReviewUpdateHook func(ctx context.Context, nctx *NotificationContext, update *AccountReviewUpdate) error
+)
+
+var (
+	ErrOnAccountUpdateHook       = errors.New("on account update hook error")
+	ErrOnAccountReviewUpdateHook = errors.New("on account review update hook error")
+)
+
+func attachHooksToAccountUpdate(ctx context.Context, id string, change *Change, hooks *Hooks) error {
+	if hooks.OnAccountUpdateHook == nil && hooks.OnPartnerOnboardingHook == nil {
+		return nil
+	}
+
+	update, err := decodeChange[AccountUpdate](change, ErrOnAccountUpdateHook)
+	if err != nil {
+		return err
+	}
+
+	if hooks.OnPartnerOnboardingHook != nil && update.PartnerEvent() {
+		return hooks.OnPartnerOnboardingHook(ctx, &NotificationContext{ID: id}, update)
+	}
+	if hooks.OnAccountUpdateHook == nil {
+		return nil
+	}
+
+	return hooks.OnAccountUpdateHook(ctx, &NotificationContext{ID: id}, update)
+}
+
+func attachHooksToAccountReviewUpdate(ctx context.Context, id string, change *Change, hooks *Hooks) error {
+	return attachFieldHook(ctx, id, change, hooks.OnAccountReviewUpdateHook, ErrOnAccountReviewUpdateHook)
+}
@@ -0,0 +1,94 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package webhooks
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+)
+
+const partnerPayload = `{
+  "object": "whatsapp_business_account",
+  "entry": [{
+    "id": "35602282435505",
+    "changes": [{
+      "field": "account_update",
+      "value": {
+        "event": "PARTNER_ADDED",
+        "waba_info": {
+          "waba_id": "495709166956424", "owner_business_id": "2729063490586005",
+          "solution_id": "1028213295298543", "solution_partner_business_ids": ["506914307656634"]
+        }
+      }
+    }, {
+      "field": "account_update",
+      "value": {"event": "VERIFIED_ACCOUNT"}
+    }, {
+      "field": "partner_solutions",
+      "value": {"event": "SOLUTION_UPDATED", "solution_id": "1028213295298543", "solution_status": "PENDING_ACTIVATION"}
+    }]
+  }]
+}`
+
+func TestAttachHooksToNotification_PartnerOnboarding(t *testing.T) {
+	t.Parallel()
+	var notification Notification
+	if err := json.Unmarshal([]byte(partnerPayload), &notification); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+
+	var (
+		onboarding *AccountUpdate
+		updates    []*AccountUpdate
+		solution   *PartnerSolution
+	)
+	listener := NewEventListener()
+	listener.OnPartnerOnboarding(func(ctx context.Context, nctx *NotificationContext, u *AccountUpdate) error {
+		onboarding = u
+
+		return nil
+	})
+	listener.OnAccountUpdate(func(ctx context.Context, nctx *NotificationContext, u *AccountUpdate) error {
+		updates = append(updates, u)
+
+		return nil
+	})
+	listener.OnPartnerSolution(func(ctx context.Context, nctx *NotificationContext, s *PartnerSolution) error {
+		solution = s
+
+		return nil
+	})
+
+	if err := AttachHooksToNotification(context.TODO(), &notification, listener.h, NoOpHooksErrorHandler); err != nil {
+		t.Fatalf("AttachHooksToNotification() error = %v", err)
+	}
+
+	if onboarding == nil || !onboarding.Onboarded() || !onboarding.SolutionMigration() ||
+		onboarding.WabaInfo.WabaID != "495709166956424" || len(onboarding.WabaInfo.SolutionPartnerBusinessIDs) != 1 {
+		t.Errorf("unexpected onboarding %+v", onboarding)
+	}
+	if len(updates) != 1 || updates[0].Event != AccountEventVerifiedAccount || updates[0].PartnerEvent() {
+		t.Errorf("unexpected account updates %+v", updates)
+	}
+	if solution == nil || solution.Active() || !solution.Pending() {
+		t.Errorf("unexpected solution %+v", solution)
+	}
+}
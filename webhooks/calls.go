@@ -0,0 +1,166 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package webhooks
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	werrors "github.com/lowkruc/go-whatsapp-api/errors"
+)
+
+// Events of a call.
+const (
+	CallEventConnect   = "connect"
+	CallEventTerminate = "terminate"
+)
+
+// Directions of a call.
+const (
+	CallDirectionUserInitiated     = "USER_INITIATED"
+	CallDirectionBusinessInitiated = "BUSINESS_INITIATED"
+)
+
+// Responses to a call permission request.
+const (
+	CallPermissionAccept = "accept"
+	CallPermissionReject = "reject"
+)
+
+type (
+	// CallPermissionReply is the reply of a user to a call permission request, sent as an
+	// interactive message of type call_permission_reply.
+	//
+	// Response, response — accept or reject.
+	// IsPermanent, is_permanent — whether the permission was granted without expiration.
+	// ExpirationTimestamp, expiration_timestamp — when a temporary permission expires, in seconds.
+	// ResponseSource, response_source — user_action when the user replied to the request, or
+	// automatic when the permission was granted by calling the business.
+	CallPermissionReply struct {
+		Response            string `json:"response,omitempty"`
+		IsPermanent         bool   `json:"is_permanent,omitempty"`
+		ExpirationTimestamp int64  `json:"expiration_timestamp,omitempty"`
+		ResponseSource      string `json:"response_source,omitempty"`
+	}
+
+	// OnCallPermissionReplyHook is called for the replies to call permission requests.
+	OnCallPermissionReplyHook func(ctx context.Context, nctx *NotificationContext, mctx *MessageContext,
+		reply *CallPermissionReply) error
+
+	// CallSession carries the SDP of a call, SDPType is "offer" for calls started by users and
+	// "answer" for calls started by the business.
+	CallSession struct {
+		SDPType string `json:"sdp_type,omitempty"`
+		SDP     string `json:"sdp,omitempty"`
+	}
+
+	// Call is a call event.
+	//
+	// ID, id — the call ID.
+	// From, To — the phone numbers of the caller and the callee.
+	// Event, event — connect or terminate.
+	// Direction, direction — USER_INITIATED or BUSINESS_INITIATED.
+	// Session, session — the SDP offer or answer, set on connect.
+	// Status, status — COMPLETED or FAILED, set on terminate.
+	// StartTime, EndTime, Duration — set on terminate for answered calls, Duration is in seconds.
+	Call struct {
+		ID        string           `json:"id,omitempty"`
+		From      string           `json:"from,omitempty"`
+		To        string           `json:"to,omitempty"`
+		Event     string           `json:"event,omitempty"`
+		Timestamp string           `json:"timestamp,omitempty"`
+		Direction string           `json:"direction,omitempty"`
+		Session   *CallSession     `json:"session,omitempty"`
+		Status    string           `json:"status,omitempty"`
+		StartTime string           `json:"start_time,omitempty"`
+		EndTime   string           `json:"end_time,omitempty"`
+		Duration  int              `json:"duration,omitempty"`
+		Errors    []*werrors.Error `json:"errors,omitempty"`
+	}
+
+	// CallStatus is the status of a call started by the business, for example RINGING, ACCEPTED
+	// or REJECTED.
+	CallStatus struct {
+		ID          string `json:"id,omitempty"`
+		Type        string `json:"type,omitempty"`
+		Status      string `json:"status,omitempty"`
+		Timestamp   string `json:"timestamp,omitempty"`
+		RecipientID string `json:"recipient_id,omitempty"`
+	}
+
+	// CallEvent is the value of a calls change.
+	CallEvent struct {
+		MessagingProduct string           `json:"messaging_product,omitempty"`
+		Metadata         *Metadata        `json:"metadata,omitempty"`
+		Contacts         []*Contact       `json:"contacts,omitempty"`
+		Calls            []*Call          `json:"calls,omitempty"`
+		Statuses         []*CallStatus    `json:"statuses,omitempty"`
+		Errors           []*werrors.Error `json:"errors,omitempty"`
+	}
+
+	// OnCallEventHook is called for every calls change, with all its calls and statuses.
+	OnCallEventHook func(ctx context.Context, nctx *NotificationContext, event *CallEvent) error
+)
+
+var ErrOnCallEventHook = errors.New("on call event hook error")
+
+// Granted reports whether the user accepted the call permission request.
+func (reply *CallPermissionReply) Granted() bool {
+	return reply != nil && reply.Response == CallPermissionAccept
+}
+
+// Expiration returns when a temporary permission expires, the zero time for permanent permissions
+// and rejections.
+func (reply *CallPermissionReply) Expiration() time.Time {
+	if reply == nil || reply.IsPermanent || reply.ExpirationTimestamp == 0 {
+		return time.Time{}
+	}
+
+	return time.Unix(reply.ExpirationTimestamp, 0)
+}
+
+// CallPermissionReply returns the reply to a call permission request the message carries, or nil.
+func (message *Message) CallPermissionReply() *CallPermissionReply {
+	if message == nil || message.Interactive == nil || message.Interactive.Type == nil {
+		return nil
+	}
+
+	return message.Interactive.Type.CallPermissionReply
+}
+
+func attachHooksToCallEvent(ctx context.Context, id string, change *Change, hooks *Hooks) error {
+	if hooks.OnCallEventHook == nil {
+		return nil
+	}
+
+	event, err := decodeChange[CallEvent](change, ErrOnCallEventHook)
+	if err != nil {
+		return err
+	}
+
+	nctx := &NotificationContext{
+		ID:       id,
+		Contacts: event.Contacts,
+		Metadata: event.Metadata,
+	}
+
+	return hooks.OnCallEventHook(ctx, nctx, event)
+}
@@ -0,0 +1,132 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package webhooks
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+const callsPayload = `{
+  "object": "whatsapp_business_account",
+  "entry": [{
+    "id": "102290129340398",
+    "changes": [{
+      "field": "calls",
+      "value": {
+        "messaging_product": "whatsapp",
+        "metadata": {"display_phone_number": "15550783881", "phone_number_id": "106540352242922"},
+        "contacts": [{"wa_id": "16505551234", "profile": {"name": "Kerry Fisher"}}],
+        "calls": [{
+          "id": "wacid.1", "from": "16505551234", "to": "15550783881", "event": "connect",
+          "timestamp": "1706461964", "direction": "USER_INITIATED",
+          "session": {"sdp_type": "offer", "sdp": "v=0"}
+        }]
+      }
+    }]
+  }]
+}`
+
+const callPermissionReplyPayload = `{
+  "object": "whatsapp_business_account",
+  "entry": [{
+    "id": "102290129340398",
+    "changes": [{
+      "field": "messages",
+      "value": {
+        "messaging_product": "whatsapp",
+        "metadata": {"display_phone_number": "15550783881", "phone_number_id": "106540352242922"},
+        "contacts": [{"wa_id": "16505551234", "profile": {"name": "Kerry Fisher"}}],
+        "messages": [{
+          "from": "16505551234", "id": "wamid.1", "timestamp": "1750263773", "type": "interactive",
+          "interactive": {
+            "type": "call_permission_reply",
+            "call_permission_reply": {
+              "response": "accept", "is_permanent": false,
+              "expiration_timestamp": 1750868573, "response_source": "user_action"
+            }
+          }
+        }]
+      }
+    }]
+  }]
+}`
+
+func TestAttachHooksToNotification_CallPermissionReply(t *testing.T) {
+	t.Parallel()
+	var notification Notification
+	if err := json.Unmarshal([]byte(callPermissionReplyPayload), &notification); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+
+	var (
+		reply *CallPermissionReply
+		mctx  *MessageContext
+	)
+	listener := NewEventListener()
+	listener.OnCallPermissionReply(func(ctx context.Context, n *NotificationContext, m *MessageContext,
+		r *CallPermissionReply,
+	) error {
+		reply, mctx = r, m
+
+		return nil
+	})
+
+	if err := AttachHooksToNotification(context.TODO(), &notification, listener.h, NoOpHooksErrorHandler); err != nil {
+		t.Fatalf("AttachHooksToNotification() error = %v", err)
+	}
+
+	if reply == nil || !reply.Granted() || reply.ResponseSource != "user_action" ||
+		reply.Expiration() != time.Unix(1750868573, 0) {
+		t.Fatalf("unexpected reply %+v", reply)
+	}
+	if mctx.From != "16505551234" || mctx.ID != "wamid.1" {
+		t.Errorf("unexpected message context %+v", mctx)
+	}
+}
+
+func TestInteractive_JSON(t *testing.T) {
+	t.Parallel()
+	var nested Interactive
+	if err := json.Unmarshal([]byte(`{"type": {"button_reply": {"id": "yes", "title": "Yes"}}}`), &nested); err != nil {
+		t.Fatalf("unmarshal the nested form: %v", err)
+	}
+	if nested.ReplyType != InteractiveButtonReply || nested.Type.ButtonReply.ID != "yes" {
+		t.Errorf("unexpected interactive %+v", nested)
+	}
+
+	data, err := json.Marshal(nested)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	if want := `{"type":"button_reply","button_reply":{"id":"yes","title":"Yes"}}`; string(data) != want {
+		t.Errorf("got %s, want %s", data, want)
+	}
+
+	var decoded Interactive
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if decoded.ReplyType != InteractiveButtonReply || decoded.Type.ButtonReply.Title != "Yes" {
+		t.Errorf("unexpected interactive %+v", decoded)
+	}
+}
@@ -0,0 +1,52 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package webhooks
+
+import (
+	"context"
+	"errors"
+)
+
+type (
+	// BusinessCapabilityUpdate is the value of a business_capability_update change. It is sent
+	// when Meta changes the limits of the business.
+	//
+	// MaxDailyConversationPerPhone, max_daily_conversation_per_phone — the number of business
+	// initiated conversations a phone number can start in 24 hours.
+	// MaxPhoneNumbersPerBusiness, max_phone_numbers_per_business — the number of phone numbers
+	// the business can register.
+	// MaxPhoneNumbersPerWaba, max_phone_numbers_per_waba — the number of phone numbers a
+	// WhatsApp Business Account can have.
+	BusinessCapabilityUpdate struct {
+		MaxDailyConversationPerPhone int `json:"max_daily_conversation_per_phone,omitempty"`
+		MaxPhoneNumbersPerBusiness   int `json:"max_phone_numbers_per_business,omitempty"`
+		MaxPhoneNumbersPerWaba       int `json:"max_phone_numbers_per_waba,omitempty"`
+	}
+
+	// OnBusinessCapabilityUpdateHook is called for every business_capability_update change.
+	OnBusinessCapabilityUpdateHook func(ctx context.Context, nctx *NotificationContext,
+		update *BusinessCapabilityUpdate) error
+)
+
+var ErrOnBusinessCapabilityUpdateHook = errors.New("on business capability update hook error")
+
+func attachHooksToBusinessCapabilityUpdate(ctx context.Context, id string, change *Change, hooks *Hooks) error {
+	return attachFieldHook(ctx, id, change, hooks.OnBusinessCapabilityUpdateHook, ErrOnBusinessCapabilityUpdateHook)
+}
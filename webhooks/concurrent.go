@@ -0,0 +1,181 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package webhooks
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// AttachHooksConcurrently is AttachHooksToNotification with the changes of the notification
+// handled by up to concurrency goroutines. The changes about the same WhatsApp user, found from the
+// wa_id of the contacts, the senders of the messages and the recipients of the statuses, are
+// handled one after the other in the order they were received, so are the changes of the same
+// entry and field that are not about a user, like template status updates. The hooks must be safe
+// for concurrent use.
+//
+// A fatal error stops the changes that follow in its group, not the other groups. The errors of
+// the groups are returned together, unless a hook panicked, then its *report.PanicError is
+// returned. A concurrency under 2 handles the changes serially.
+func AttachHooksConcurrently(ctx context.Context, notification *Notification, hooks *Hooks,
+	heh HooksErrorHandler, concurrency int,
+) error {
+	if concurrency < 2 { //nolint:gomnd
+		return AttachHooksToNotification(ctx, notification, hooks, heh)
+	}
+	if notification == nil || hooks == nil {
+		return nil
+	}
+
+	groups := changeGroups(notification)
+	errs := make([]error, len(groups))
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for i, group := range groups {
+		i, group := i, group
+		sem <- struct{}{}
+		wg.Add(1)
+		go func() {
+			defer func() {
+				if r := recover(); r != nil {
+					errs[i] = recoveredPanic(r, heh)
+				}
+				<-sem
+				wg.Done()
+			}()
+			collected := &hookErrors{heh: heh}
+			for _, entry := range group {
+				if attachHooksToEntry(ctx, entry, hooks, collected) {
+					break
+				}
+			}
+			errs[i] = collected.err()
+		}()
+	}
+	wg.Wait()
+
+	var encountered HookErrors
+	for _, err := range errs {
+		if isPanic(err) {
+			return err
+		}
+		var herrs HookErrors
+		if errors.As(err, &herrs) {
+			encountered = append(encountered, herrs...)
+		}
+	}
+	if len(encountered) == 0 {
+		return nil
+	}
+
+	return encountered
+}
+
+// changeGroups splits the changes of the notification into groups that must be handled in order,
+// each change as an entry of its own. Changes sharing a key are in the same group, see
+// changeKeys, and the groups are in the order of their first change.
+func changeGroups(notification *Notification) [][]*Entry {
+	var (
+		entries []*Entry
+		parents []int
+		owners  = map[string]int{}
+	)
+	find := func(i int) int {
+		for parents[i] != i {
+			parents[i] = parents[parents[i]]
+			i = parents[i]
+		}
+
+		return i
+	}
+
+	for _, entry := range notification.Entry {
+		if entry == nil {
+			continue
+		}
+		for _, change := range entry.Changes {
+			if change == nil {
+				continue
+			}
+			index := len(entries)
+			entries = append(entries, &Entry{ID: entry.ID, Changes: []*Change{change}})
+			parents = append(parents, index)
+			for _, key := range changeKeys(entry.ID, change) {
+				owner, ok := owners[key]
+				if !ok {
+					owners[key] = index
+
+					continue
+				}
+				// the group of the earliest change is kept as the root, so the order of the groups
+				// is the order of their first change.
+				a, b := find(owner), find(index)
+				if a > b {
+					a, b = b, a
+				}
+				parents[b] = a
+			}
+		}
+	}
+
+	var groups [][]*Entry
+	positions := map[int]int{}
+	for i, entry := range entries {
+		root := find(i)
+		position, ok := positions[root]
+		if !ok {
+			position = len(groups)
+			positions[root] = position
+			groups = append(groups, nil)
+		}
+		groups[position] = append(groups[position], entry)
+	}
+
+	return groups
+}
+
+// changeKeys returns the ordering keys of a change: the WhatsApp IDs of the users it is about, or
+// its entry and field when it is not about a user.
+func changeKeys(entryID string, change *Change) []string {
+	var keys []string
+	if value := change.Value; value != nil {
+		for _, contact := range value.Contacts {
+			if contact != nil && contact.WaID != "" {
+				keys = append(keys, "wa_id:"+contact.WaID)
+			}
+		}
+		for _, message := range value.Messages {
+			if message != nil && message.From != "" {
+				keys = append(keys, "wa_id:"+message.From)
+			}
+		}
+		for _, status := range value.Statuses {
+			if status != nil && status.RecipientID != "" {
+				keys = append(keys, "wa_id:"+status.RecipientID)
+			}
+		}
+	}
+	if len(keys) == 0 {
+		keys = append(keys, "entry:"+entryID+":"+change.Field)
+	}
+
+	return keys
+}
@@ -0,0 +1,146 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package webhooks
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func textChange(from, id string) *Change {
+	return &Change{Field: MessagesField, Value: &Value{
+		Messages: []*Message{{From: from, ID: id, Type: "text", Text: &Text{Body: id}}},
+	}}
+}
+
+func TestChangeGroups(t *testing.T) {
+	t.Parallel()
+	notification := &Notification{Entry: []*Entry{
+		{ID: "waba.1", Changes: []*Change{
+			textChange("alice", "a1"),
+			textChange("bob", "b1"),
+			{Field: MessageTemplateStatusUpdateField},
+			textChange("alice", "a2"),
+		}},
+		{ID: "waba.2", Changes: []*Change{
+			{Field: MessagesField, Value: &Value{Statuses: []*Status{{ID: "s1", RecipientID: "bob"}}}},
+			{Field: MessageTemplateStatusUpdateField},
+		}},
+	}}
+
+	var got []string
+	for _, group := range changeGroups(notification) {
+		var names []string
+		for _, entry := range group {
+			change := entry.Changes[0]
+			switch {
+			case change.Value != nil && len(change.Value.Messages) > 0:
+				names = append(names, change.Value.Messages[0].ID)
+			case change.Value != nil && len(change.Value.Statuses) > 0:
+				names = append(names, change.Value.Statuses[0].ID)
+			default:
+				names = append(names, entry.ID+"/"+change.Field)
+			}
+		}
+		got = append(got, strings.Join(names, ","))
+	}
+
+	want := []string{
+		"a1,a2",
+		"b1,s1",
+		"waba.1/" + MessageTemplateStatusUpdateField,
+		"waba.2/" + MessageTemplateStatusUpdateField,
+	}
+	if strings.Join(got, " ") != strings.Join(want, " ") {
+		t.Errorf("got groups %v, want %v", got, want)
+	}
+}
+
+func TestAttachHooksConcurrently(t *testing.T) {
+	t.Parallel()
+	notification := &Notification{Entry: []*Entry{{ID: "waba.1", Changes: []*Change{
+		textChange("alice", "a1"),
+		textChange("bob", "b1"),
+		textChange("alice", "a2"),
+	}}}}
+
+	var (
+		mu       sync.Mutex
+		received = map[string][]string{}
+		bobDone  = make(chan struct{})
+	)
+	hooks := &Hooks{
+		OnTextMessageHook: func(ctx context.Context, nctx *NotificationContext, mctx *MessageContext, text *Text) error {
+			if mctx.ID == "a1" {
+				// bob is handled while alice waits, the changes run concurrently.
+				select {
+				case <-bobDone:
+				case <-time.After(time.Second):
+					t.Error("the change of bob was not handled concurrently")
+				}
+			}
+			mu.Lock()
+			received[mctx.From] = append(received[mctx.From], mctx.ID)
+			mu.Unlock()
+			if mctx.ID == "b1" {
+				close(bobDone)
+			}
+
+			return nil
+		},
+	}
+
+	if err := AttachHooksConcurrently(context.Background(), notification, hooks, NoOpHooksErrorHandler, 4); err != nil {
+		t.Fatalf("AttachHooksConcurrently() error = %v", err)
+	}
+
+	if got := strings.Join(received["alice"], ","); got != "a1,a2" {
+		t.Errorf("got alice messages %s, want a1,a2", got)
+	}
+	if got := strings.Join(received["bob"], ","); got != "b1" {
+		t.Errorf("got bob messages %s, want b1", got)
+	}
+}
+
+func TestAttachHooksConcurrently_Panic(t *testing.T) {
+	t.Parallel()
+	notification := &Notification{Entry: []*Entry{{ID: "waba.1", Changes: []*Change{
+		textChange("alice", "a1"),
+		textChange("bob", "b1"),
+	}}}}
+
+	hooks := &Hooks{
+		OnTextMessageHook: func(ctx context.Context, nctx *NotificationContext, mctx *MessageContext, text *Text) error {
+			if mctx.From == "bob" {
+				panic("boom")
+			}
+
+			return nil
+		},
+	}
+
+	err := AttachHooksConcurrently(context.Background(), notification, hooks, NoOpHooksErrorHandler, 2)
+	if err == nil || !strings.Contains(err.Error(), "boom") {
+		t.Errorf("got error %v, want the panic", err)
+	}
+}
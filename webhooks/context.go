@@ -0,0 +1,100 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package webhooks
+
+import "context"
+
+type (
+	entryIDKey  struct{}
+	metadataKey struct{}
+	contactKey  struct{}
+)
+
+// WithEntryID returns a context that carries the ID of the entry being handled, the ID of the
+// WhatsApp Business Account.
+func WithEntryID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, entryIDKey{}, id)
+}
+
+// EntryIDFromContext returns the ID of the entry the hook is called for, or "" outside the hooks.
+func EntryIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(entryIDKey{}).(string)
+
+	return id
+}
+
+// WithMetadata returns a context that carries the metadata of the change being handled.
+func WithMetadata(ctx context.Context, metadata *Metadata) context.Context {
+	return context.WithValue(ctx, metadataKey{}, metadata)
+}
+
+// MetadataFromContext returns the metadata of the change the hook is called for, the
+// phone_number_id and display_phone_number of the business number. It returns nil outside the
+// hooks and for the changes without metadata.
+func MetadataFromContext(ctx context.Context) *Metadata {
+	metadata, _ := ctx.Value(metadataKey{}).(*Metadata)
+
+	return metadata
+}
+
+// WithContact returns a context that carries the contact the hook is called for.
+func WithContact(ctx context.Context, contact *Contact) context.Context {
+	return context.WithValue(ctx, contactKey{}, contact)
+}
+
+// ContactFromContext returns the contact, WhatsApp ID and profile, of the user the hook is called
+// for: the sender of a message, the recipient of a status, or the only contact of a change. It
+// returns nil when the change does not say which contact it is about.
+func ContactFromContext(ctx context.Context) *Contact {
+	contact, _ := ctx.Value(contactKey{}).(*Contact)
+
+	return contact
+}
+
+// changeContext returns ctx with the entry ID and the metadata of the change, and its contact when
+// it has only one.
+func changeContext(ctx context.Context, entryID string, change *Change) context.Context {
+	ctx = WithEntryID(ctx, entryID)
+	if change == nil || change.Value == nil {
+		return ctx
+	}
+	if change.Value.Metadata != nil {
+		ctx = WithMetadata(ctx, change.Value.Metadata)
+	}
+	if contacts := change.Value.Contacts; len(contacts) == 1 && contacts[0] != nil {
+		ctx = WithContact(ctx, contacts[0])
+	}
+
+	return ctx
+}
+
+// contactContext returns ctx with the contact of the given WhatsApp ID, when the contacts have it.
+func contactContext(ctx context.Context, contacts []*Contact, waID string) context.Context {
+	if waID == "" {
+		return ctx
+	}
+	for _, contact := range contacts {
+		if contact != nil && contact.WaID == waID {
+			return WithContact(ctx, contact)
+		}
+	}
+
+	return ctx
+}
@@ -0,0 +1,79 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package webhooks
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+)
+
+func TestContextAccessors(t *testing.T) {
+	t.Parallel()
+	var (
+		entryID  string
+		metadata *Metadata
+		contact  *Contact
+		caller   *Contact
+	)
+
+	listener := NewEventListener()
+	listener.OnInteractiveMessage(func(ctx context.Context, nctx *NotificationContext, mctx *MessageContext,
+		interactive *Interactive,
+	) error {
+		entryID, metadata, contact = EntryIDFromContext(ctx), MetadataFromContext(ctx), ContactFromContext(ctx)
+
+		return nil
+	})
+	listener.OnCallEvent(func(ctx context.Context, nctx *NotificationContext, event *CallEvent) error {
+		caller = ContactFromContext(ctx)
+
+		return nil
+	})
+
+	for _, payload := range []string{callPermissionReplyPayload, callsPayload} {
+		var notification Notification
+		if err := json.Unmarshal([]byte(payload), &notification); err != nil {
+			t.Fatalf("unmarshal: %v", err)
+		}
+		if err := AttachHooksToNotification(context.Background(), &notification, listener.h,
+			NoOpHooksErrorHandler); err != nil {
+			t.Fatalf("AttachHooksToNotification() error = %v", err)
+		}
+	}
+
+	if entryID != "102290129340398" {
+		t.Errorf("got entry ID %q", entryID)
+	}
+	if metadata == nil || metadata.PhoneNumberID != "106540352242922" || metadata.DisplayPhoneNumber != "15550783881" {
+		t.Errorf("got metadata %+v", metadata)
+	}
+	if contact == nil || contact.WaID != "16505551234" || contact.Profile == nil || contact.Profile.Name != "Kerry Fisher" {
+		t.Errorf("got contact %+v", contact)
+	}
+	if caller == nil || caller.WaID != "16505551234" {
+		t.Errorf("got caller %+v", caller)
+	}
+
+	ctx := context.Background()
+	if EntryIDFromContext(ctx) != "" || MetadataFromContext(ctx) != nil || ContactFromContext(ctx) != nil {
+		t.Error("the accessors return values outside the hooks")
+	}
+}
@@ -0,0 +1,142 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package webhooks
+
+import (
+	"context"
+	"sync/atomic"
+
+	"github.com/lowkruc/go-whatsapp-api/lifecycle"
+)
+
+// LifecycleComponent is the component the listener emits its phases as, see WithLifecycle.
+const LifecycleComponent = "listener"
+
+type (
+	// Control switches the handling of the notifications at runtime, without a redeploy. Set it in
+	// the Control field of HandlerOptions. While paused, the notifications are answered with a 503
+	// so that Meta delivers them again later. During a dry run, they are validated and
+	// acknowledged but the hooks are not called. A Control is safe for concurrent use.
+	Control struct {
+		paused atomic.Bool
+		dryRun atomic.Bool
+	}
+
+	// Config is a snapshot of the configuration of an EventListener, without the secrets.
+	Config struct {
+		ValidateSignature bool             `json:"validate_signature"`
+		Secrets           int              `json:"secrets"`
+		SecretProvider    bool             `json:"secret_provider"`
+		LenientNumbers    bool             `json:"lenient_numbers"`
+		NormalizeVersions bool             `json:"normalize_versions"`
+		StrictDecoding    bool             `json:"strict_decoding"`
+		Deduplication     bool             `json:"deduplication"`
+		ReplayWindow      bool             `json:"replay_window"`
+		Concurrency       int              `json:"concurrency"`
+		Dispatcher        bool             `json:"dispatcher"`
+		Pending           int              `json:"pending"`
+		Dispatch          *DispatcherStats `json:"dispatch,omitempty"`
+		Paused            bool             `json:"paused"`
+		DryRun            bool             `json:"dry_run"`
+		HandledFields     []string         `json:"handled_fields"`
+	}
+)
+
+// Pause stops the dispatch of the notifications until Resume.
+func (c *Control) Pause() { c.paused.Store(true) }
+
+// Resume dispatches the notifications again.
+func (c *Control) Resume() { c.paused.Store(false) }
+
+// Paused reports whether the dispatch is paused.
+func (c *Control) Paused() bool { return c != nil && c.paused.Load() }
+
+// SetDryRun turns the dry run on or off.
+func (c *Control) SetDryRun(enabled bool) { c.dryRun.Store(enabled) }
+
+// DryRun reports whether the dry run is on.
+func (c *Control) DryRun() bool { return c != nil && c.dryRun.Load() }
+
+// Control returns the Control of the listener, nil when none was set with WithControl.
+func (ls *EventListener) Control() *Control {
+	if ls.options == nil {
+		return nil
+	}
+
+	return ls.options.Control
+}
+
+// Dispatcher returns the Dispatcher of the listener, nil when none was set with WithDispatcher.
+func (ls *EventListener) Dispatcher() *Dispatcher {
+	if ls.options == nil {
+		return nil
+	}
+
+	return ls.options.Dispatcher
+}
+
+// Config returns a snapshot of the configuration of the listener.
+func (ls *EventListener) Config() *Config {
+	config := &Config{HandledFields: HandledFields(ls.h)}
+	options := ls.options
+	if options == nil {
+		return config
+	}
+
+	config.ValidateSignature = options.ValidateSignature
+	for _, secret := range append([]string{options.Secret}, options.Secrets...) {
+		if secret != "" {
+			config.Secrets++
+		}
+	}
+	config.SecretProvider = options.SecretProvider != nil
+	config.LenientNumbers = options.LenientNumbers
+	config.NormalizeVersions = options.NormalizeVersions
+	config.StrictDecoding = options.StrictDecoding
+	config.Deduplication = options.Deduplication != nil
+	config.ReplayWindow = options.ReplayWindow != nil
+	config.Concurrency = options.Concurrency
+	if options.Dispatcher != nil {
+		config.Dispatcher = true
+		config.Pending = options.Dispatcher.Pending()
+		config.Dispatch = options.Dispatcher.Stats()
+	}
+	config.Paused = options.Control.Paused()
+	config.DryRun = options.Control.DryRun()
+
+	return config
+}
+
+// Shutdown stops the listener: the dispatch is paused when a Control is set, so that Meta delivers
+// the next notifications again later, possibly to another instance, and the Dispatcher, if any,
+// is shut down once its queue is done or ctx is done. The listener is Starting when created, Ready
+// once a handler is built, and Draining then Stopped during Shutdown.
+func (ls *EventListener) Shutdown(ctx context.Context) error {
+	ls.bus.Emit(LifecycleComponent, lifecycle.Draining, nil)
+	ls.Control().Pause()
+
+	var err error
+	if dispatcher := ls.Dispatcher(); dispatcher != nil {
+		err = dispatcher.Shutdown(ctx)
+	}
+	ls.bus.Emit(LifecycleComponent, lifecycle.Stopped, err)
+
+	return err
+}
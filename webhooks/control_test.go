@@ -0,0 +1,203 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/lowkruc/go-whatsapp-api/lifecycle"
+	"github.com/lowkruc/go-whatsapp-api/models"
+)
+
+func TestControl(t *testing.T) {
+	t.Parallel()
+	control := &Control{}
+	listener := NewEventListener(WithControl(control))
+	var received int
+	listener.OnTextMessage(func(ctx context.Context, nctx *NotificationContext, mctx *MessageContext, text *Text) error {
+		received++
+
+		return nil
+	})
+	listener.OnMediaMessage(func(ctx context.Context, nctx *NotificationContext, mctx *MessageContext,
+		media *models.MediaInfo,
+	) error {
+		return nil
+	})
+	handler := listener.NotificationHandler()
+	post := func() int {
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/", bytes.NewBufferString(mixedMessagesPayload)))
+
+		return recorder.Code
+	}
+
+	control.Pause()
+	if code := post(); code != http.StatusServiceUnavailable || received != 0 {
+		t.Errorf("paused: got %d and %d messages, want %d and none", code, received, http.StatusServiceUnavailable)
+	}
+
+	control.Resume()
+	control.SetDryRun(true)
+	if code := post(); code != http.StatusOK || received != 0 {
+		t.Errorf("dry run: got %d and %d messages, want %d and none", code, received, http.StatusOK)
+	}
+
+	control.SetDryRun(false)
+	if code := post(); code != http.StatusOK || received != 1 {
+		t.Errorf("got %d and %d messages, want %d and one", code, received, http.StatusOK)
+	}
+
+	config := listener.Config()
+	if config.Paused || config.DryRun || len(config.HandledFields) != 1 || config.HandledFields[0] != MessagesField {
+		t.Errorf("unexpected config %+v", config)
+	}
+}
+
+func TestControl_GlobalHandler(t *testing.T) {
+	t.Parallel()
+	control := &Control{}
+	var received int
+	listener := NewEventListener(WithControl(control),
+		WithGlobalNotificationHandler(func(ctx context.Context, w http.ResponseWriter, n *Notification) error {
+			received++
+
+			return nil
+		}))
+	handler := listener.GlobalHandler()
+	post := func() int {
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/", bytes.NewBufferString(mixedMessagesPayload)))
+
+		return recorder.Code
+	}
+
+	control.Pause()
+	if code := post(); code != http.StatusServiceUnavailable || received != 0 {
+		t.Errorf("paused: got %d and %d calls, want %d and none", code, received, http.StatusServiceUnavailable)
+	}
+
+	control.Resume()
+	control.SetDryRun(true)
+	if code := post(); code != http.StatusOK || received != 0 {
+		t.Errorf("dry run: got %d and %d calls, want %d and none", code, received, http.StatusOK)
+	}
+
+	control.SetDryRun(false)
+	if code := post(); code != http.StatusOK || received != 1 {
+		t.Errorf("got %d and %d calls, want %d and one", code, received, http.StatusOK)
+	}
+}
+
+func TestDispatcher_Drain(t *testing.T) {
+	t.Parallel()
+	dispatcher := NewDispatcher(1, 4, OverflowReject)
+	defer func() { _ = dispatcher.Shutdown(context.Background()) }()
+
+	release := make(chan struct{})
+	done := 0
+	for i := 0; i < 3; i++ {
+		if err := dispatcher.Submit(context.Background(), func() {
+			<-release
+			done++
+		}); err != nil {
+			t.Fatalf("Submit() error = %v", err)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Millisecond)
+	defer cancel()
+	if err := dispatcher.Drain(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Drain() error = %v, want %v", err, context.DeadlineExceeded)
+	}
+
+	close(release)
+	if err := dispatcher.Drain(context.Background()); err != nil || done != 3 {
+		t.Errorf("Drain() error = %v with %d jobs done, want all 3", err, done)
+	}
+}
+
+func TestSecretCache(t *testing.T) {
+	t.Parallel()
+	calls := 0
+	current := "secret.1"
+	cache := NewSecretCache(func(ctx context.Context) ([]string, error) {
+		calls++
+		if current == "" {
+			return nil, errors.New("secret manager unavailable")
+		}
+
+		return []string{current}, nil
+	})
+	provider := cache.Provider()
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		if secrets, err := provider(ctx); err != nil || len(secrets) != 1 || secrets[0] != "secret.1" || calls != 1 {
+			t.Fatalf("provider() = %v, %v after %d calls", secrets, err, calls)
+		}
+	}
+
+	current = "secret.2"
+	if err := cache.Refresh(ctx); err != nil {
+		t.Fatalf("Refresh() error = %v", err)
+	}
+	current = ""
+	if err := cache.Refresh(ctx); err == nil {
+		t.Error("Refresh() expected an error")
+	}
+	if secrets, _ := provider(ctx); len(secrets) != 1 || secrets[0] != "secret.2" {
+		t.Errorf("provider() = %v, want the secrets of the last successful refresh", secrets)
+	}
+}
+
+func TestEventListener_Shutdown(t *testing.T) {
+	t.Parallel()
+	bus := lifecycle.NewBus()
+	control := &Control{}
+	dispatcher := NewDispatcher(1, 4, OverflowReject)
+	listener := NewEventListener(WithLifecycle(bus), WithControl(control), WithDispatcher(dispatcher))
+	if phase := bus.Phase(LifecycleComponent); phase != lifecycle.Starting {
+		t.Fatalf("phase after NewEventListener = %q, want %q", phase, lifecycle.Starting)
+	}
+
+	_ = listener.NotificationHandler()
+	if phase := bus.Phase(LifecycleComponent); phase != lifecycle.Ready {
+		t.Fatalf("phase after NotificationHandler = %q, want %q", phase, lifecycle.Ready)
+	}
+
+	done := false
+	if err := dispatcher.Submit(context.Background(), func() { done = true }); err != nil {
+		t.Fatalf("Submit() error = %v", err)
+	}
+	if err := listener.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown() error = %v", err)
+	}
+	if !done || !control.Paused() || bus.Phase(LifecycleComponent) != lifecycle.Stopped {
+		t.Errorf("after Shutdown: job done %t, paused %t, phase %q", done, control.Paused(),
+			bus.Phase(LifecycleComponent))
+	}
+}
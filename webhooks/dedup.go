@@ -0,0 +1,172 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package webhooks
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// DefaultDeduplicationTTL is how long the IDs are remembered when Deduplication.TTL is not set.
+// Meta retries the delivery of a webhook for up to 7 days, but most redeliveries happen within a
+// few hours.
+const DefaultDeduplicationTTL = 24 * time.Hour
+
+type (
+	// DedupStore remembers the keys of the messages and statuses already handled. SeenOrAdd records
+	// the key for ttl and reports whether it was already recorded, Forget removes a recorded key.
+	// Implementations must be safe for concurrent use, a store shared by several instances, like
+	// Redis with SET NX EX and DEL, makes the deduplication work across them.
+	DedupStore interface {
+		SeenOrAdd(ctx context.Context, key string, ttl time.Duration) (bool, error)
+		Forget(ctx context.Context, key string) error
+	}
+
+	// Deduplication drops the messages and statuses that were already handled from the
+	// notifications before the hooks are called. Messages are keyed by their ID and statuses by
+	// their ID and status value, since the statuses of a message share its ID. When the store
+	// fails, the message or status is kept. The keys are recorded before the hooks run, so that
+	// concurrent redeliveries are dropped, and forgotten when the notification is not answered
+	// with a 2xx, after a publish or a fatal hook error for example, so that Meta's redelivery
+	// is handled.
+	Deduplication struct {
+		Store DedupStore
+		TTL   time.Duration
+	}
+
+	// MemoryDedupStore is an in memory DedupStore, only suitable for a single instance.
+	MemoryDedupStore struct {
+		mu      sync.Mutex
+		keys    map[string]time.Time
+		now     func() time.Time
+		sweptAt time.Time
+	}
+)
+
+// NewMemoryDedupStore creates an empty MemoryDedupStore.
+func NewMemoryDedupStore() *MemoryDedupStore {
+	return &MemoryDedupStore{
+		keys: make(map[string]time.Time),
+		now:  time.Now,
+	}
+}
+
+func (s *MemoryDedupStore) SeenOrAdd(_ context.Context, key string, ttl time.Duration) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	if now.Sub(s.sweptAt) > time.Minute {
+		for k, expiry := range s.keys {
+			if !expiry.After(now) {
+				delete(s.keys, k)
+			}
+		}
+		s.sweptAt = now
+	}
+
+	if expiry, ok := s.keys[key]; ok && expiry.After(now) {
+		return true, nil
+	}
+	s.keys[key] = now.Add(ttl)
+
+	return false, nil
+}
+
+// Forget removes the key.
+func (s *MemoryDedupStore) Forget(_ context.Context, key string) error {
+	s.mu.Lock()
+	delete(s.keys, key)
+	s.mu.Unlock()
+
+	return nil
+}
+
+// apply removes the messages and statuses already seen from the notification. It returns the
+// keys it recorded, to forget them if the notification fails, and the first error of the store.
+func (d *Deduplication) apply(ctx context.Context, notification *Notification) ([]string, error) {
+	if d == nil || d.Store == nil || notification == nil {
+		return nil, nil
+	}
+
+	ttl := d.TTL
+	if ttl <= 0 {
+		ttl = DefaultDeduplicationTTL
+	}
+
+	var (
+		recorded []string
+		first    error
+	)
+	seen := func(key string) bool {
+		dup, err := d.Store.SeenOrAdd(ctx, key, ttl)
+		if err != nil && first == nil {
+			first = err
+		}
+		if !dup && err == nil {
+			recorded = append(recorded, key)
+		}
+
+		return dup && err == nil
+	}
+
+	for _, entry := range notification.Entry {
+		if entry == nil {
+			continue
+		}
+		for _, change := range entry.Changes {
+			if change == nil || change.Value == nil || change.Field != MessagesField {
+				continue
+			}
+			value := change.Value
+
+			messages := value.Messages[:0]
+			for _, message := range value.Messages {
+				if message == nil || message.ID == "" || !seen("message:"+message.ID) {
+					messages = append(messages, message)
+				}
+			}
+			value.Messages = messages
+
+			statuses := value.Statuses[:0]
+			for _, status := range value.Statuses {
+				if status == nil || status.ID == "" || !seen("status:"+status.ID+":"+status.StatusValue) {
+					statuses = append(statuses, status)
+				}
+			}
+			value.Statuses = statuses
+		}
+	}
+
+	return recorded, first
+}
+
+// forget removes the keys recorded by apply. It returns the first error of the store.
+func (d *Deduplication) forget(ctx context.Context, keys []string) error {
+	var first error
+	for _, key := range keys {
+		if err := d.Store.Forget(ctx, key); err != nil && first == nil {
+			first = err
+		}
+	}
+
+	return first
+}
@@ -0,0 +1,166 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package webhooks
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestNotificationHandler_Deduplication(t *testing.T) {
+	t.Parallel()
+	var (
+		mu       sync.Mutex
+		received []string
+	)
+
+	listener := NewEventListener(WithDeduplication(NewMemoryDedupStore(), time.Hour))
+	listener.OnMessageStatusChange(func(ctx context.Context, nctx *NotificationContext, status *Status) error {
+		mu.Lock()
+		received = append(received, status.ID+":"+status.StatusValue)
+		mu.Unlock()
+
+		return nil
+	})
+
+	// the second delivery repeats wamid.1 as sent and reports it as delivered.
+	redelivered := strings.Replace(statusesPayload, `"statuses": [`,
+		`"statuses": [{"id": "wamid.1", "status": "delivered", "timestamp": "1750263780", "recipient_id": "16505551234"},`, 1)
+	for _, payload := range []string{statusesPayload, redelivered} {
+		request := httptest.NewRequest(http.MethodPost, "/webhooks", strings.NewReader(payload))
+		recorder := httptest.NewRecorder()
+		listener.NotificationHandler().ServeHTTP(recorder, request)
+		if recorder.Code != http.StatusOK {
+			t.Fatalf("got status code %d, want %d", recorder.Code, http.StatusOK)
+		}
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	want := []string{"wamid.1:sent", "wamid.2:delivered", "wamid.3:read", "wamid.4:failed", "wamid.1:delivered"}
+	if strings.Join(received, ",") != strings.Join(want, ",") {
+		t.Errorf("got statuses %v, want %v", received, want)
+	}
+}
+
+func TestGlobalHandler_Deduplication(t *testing.T) {
+	t.Parallel()
+	var statuses []int
+	listener := NewEventListener(WithDeduplication(NewMemoryDedupStore(), time.Hour),
+		WithGlobalNotificationHandler(func(ctx context.Context, w http.ResponseWriter, n *Notification) error {
+			count := 0
+			for _, entry := range n.Entry {
+				for _, change := range entry.Changes {
+					count += len(change.Value.Statuses)
+				}
+			}
+			statuses = append(statuses, count)
+
+			return nil
+		}))
+
+	for i := 0; i < 2; i++ {
+		request := httptest.NewRequest(http.MethodPost, "/webhooks", strings.NewReader(statusesPayload))
+		recorder := httptest.NewRecorder()
+		listener.GlobalHandler().ServeHTTP(recorder, request)
+		if recorder.Code != http.StatusOK {
+			t.Fatalf("got status code %d, want %d", recorder.Code, http.StatusOK)
+		}
+	}
+
+	if len(statuses) != 2 || statuses[0] != 4 || statuses[1] != 0 {
+		t.Errorf("got statuses per delivery %v, want [4 0]", statuses)
+	}
+}
+
+func TestMemoryDedupStore_SeenOrAdd(t *testing.T) {
+	t.Parallel()
+	now := time.Unix(1750263773, 0)
+	store := NewMemoryDedupStore()
+	store.now = func() time.Time { return now }
+
+	ctx := context.TODO()
+	if seen, _ := store.SeenOrAdd(ctx, "message:wamid.1", time.Minute); seen {
+		t.Error("first SeenOrAdd() = true, want false")
+	}
+	if seen, _ := store.SeenOrAdd(ctx, "message:wamid.1", time.Minute); !seen {
+		t.Error("second SeenOrAdd() = false, want true")
+	}
+
+	now = now.Add(2 * time.Minute)
+	if seen, _ := store.SeenOrAdd(ctx, "message:wamid.1", time.Minute); seen {
+		t.Error("SeenOrAdd() after the ttl = true, want false")
+	}
+}
+
+func TestNotificationHandler_DeduplicationAfterFailure(t *testing.T) {
+	t.Parallel()
+	var (
+		mu       sync.Mutex
+		received []string
+		fail     = true
+	)
+
+	listener := NewEventListener(
+		WithDeduplication(NewMemoryDedupStore(), time.Hour),
+		WithHooksErrorHandler(func(err error) error { return err }),
+		WithNotificationErrorHandler(func(ctx context.Context, r *http.Request, err error) *NotificationErrHandlerResponse {
+			return &NotificationErrHandlerResponse{StatusCode: http.StatusInternalServerError}
+		}),
+	)
+	listener.OnMessageStatusChange(func(ctx context.Context, nctx *NotificationContext, status *Status) error {
+		mu.Lock()
+		defer mu.Unlock()
+		received = append(received, status.ID)
+		if fail {
+			return NewFatalError(errors.New("database down"), "status hook")
+		}
+
+		return nil
+	})
+
+	codes := make([]int, 0, 2)
+	for i := 0; i < 2; i++ {
+		request := httptest.NewRequest(http.MethodPost, "/webhooks", strings.NewReader(statusesPayload))
+		recorder := httptest.NewRecorder()
+		listener.NotificationHandler().ServeHTTP(recorder, request)
+		codes = append(codes, recorder.Code)
+		mu.Lock()
+		fail = false
+		mu.Unlock()
+	}
+
+	if codes[0] != http.StatusInternalServerError || codes[1] != http.StatusOK {
+		t.Errorf("got status codes %v, want a failure then a success", codes)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	// the first delivery stops at the fatal error, the redelivery handles all the statuses again.
+	want := []string{"wamid.1", "wamid.1", "wamid.2", "wamid.3", "wamid.4"}
+	if strings.Join(received, ",") != strings.Join(want, ",") {
+		t.Errorf("got statuses %v, want %v", received, want)
+	}
+}
@@ -0,0 +1,260 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package webhooks
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// OverflowPolicy is what a Dispatcher does with a notification when its queue is full.
+type OverflowPolicy int
+
+const (
+	// OverflowReject rejects the notification, the handler answers 503 and Meta delivers it again
+	// later.
+	OverflowReject OverflowPolicy = iota
+
+	// OverflowBlock waits for room in the queue until the request is canceled.
+	OverflowBlock
+
+	// OverflowInline runs the hooks in the handler, as without a Dispatcher.
+	OverflowInline
+
+	// OverflowDropOldest drops the oldest queued notification to make room for the new one. The
+	// dropped notification was acknowledged, so it is lost, see DispatcherStats.Dropped.
+	OverflowDropOldest
+)
+
+var (
+	ErrDispatchQueueFull = errors.New("dispatch queue is full")
+	ErrDispatcherClosed  = errors.New("dispatcher is shut down")
+)
+
+// Dispatcher runs the hooks of the notifications on a fixed number of workers, fed by a bounded
+// queue, so that the webhooks can be acknowledged within the time Meta allows. Set it in the
+// Dispatcher field of HandlerOptions and call Shutdown when the server stops.
+type Dispatcher struct {
+	mu           sync.RWMutex
+	closed       bool
+	queue        chan func()
+	policy       OverflowPolicy
+	blockTimeout time.Duration
+	wg           sync.WaitGroup
+	// active counts the jobs queued or running.
+	active   atomic.Int64
+	dropped  atomic.Int64
+	rejected atomic.Int64
+}
+
+// DispatcherOption configures a Dispatcher.
+type DispatcherOption func(*Dispatcher)
+
+// DispatcherStats is a snapshot of the queue of a Dispatcher, for monitoring. Active counts the
+// jobs queued or running, Dropped the jobs dropped by OverflowDropOldest and Rejected the ones
+// refused because the queue was full.
+type DispatcherStats struct {
+	Pending  int   `json:"pending"`
+	Capacity int   `json:"capacity"`
+	Active   int64 `json:"active"`
+	Dropped  int64 `json:"dropped"`
+	Rejected int64 `json:"rejected"`
+}
+
+// WithBlockTimeout bounds the time OverflowBlock waits for room in the queue, after which the
+// notification is rejected. Without it, it waits until the request is canceled.
+func WithBlockTimeout(timeout time.Duration) DispatcherOption {
+	return func(d *Dispatcher) {
+		d.blockTimeout = timeout
+	}
+}
+
+// drainInterval is how often Drain checks whether the jobs are done.
+const drainInterval = 10 * time.Millisecond
+
+// NewDispatcher starts a Dispatcher with the number of workers and the size of the queue, both at
+// least 1.
+func NewDispatcher(workers, queueSize int, policy OverflowPolicy, options ...DispatcherOption) *Dispatcher {
+	if workers < 1 {
+		workers = 1
+	}
+	if queueSize < 1 {
+		queueSize = 1
+	}
+
+	d := &Dispatcher{
+		queue:  make(chan func(), queueSize),
+		policy: policy,
+	}
+	for _, option := range options {
+		option(d)
+	}
+
+	d.wg.Add(workers)
+	for i := 0; i < workers; i++ {
+		go d.work()
+	}
+
+	return d
+}
+
+func (d *Dispatcher) work() {
+	defer d.wg.Done()
+	for job := range d.queue {
+		job()
+		d.active.Add(-1)
+	}
+}
+
+// Submit queues the job following the overflow policy. ctx is the context of the request, it is
+// only used to stop waiting with OverflowBlock.
+func (d *Dispatcher) Submit(ctx context.Context, job func()) error {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	if d.closed {
+		return ErrDispatcherClosed
+	}
+
+	d.active.Add(1)
+	select {
+	case d.queue <- job:
+		return nil
+	default:
+	}
+
+	switch d.policy {
+	case OverflowBlock:
+		var timeout <-chan time.Time
+		if d.blockTimeout > 0 {
+			timer := time.NewTimer(d.blockTimeout)
+			defer timer.Stop()
+			timeout = timer.C
+		}
+		select {
+		case d.queue <- job:
+			return nil
+		case <-ctx.Done():
+		case <-timeout:
+		}
+	case OverflowInline:
+		d.active.Add(-1)
+		job()
+
+		return nil
+	case OverflowDropOldest:
+		for {
+			select {
+			case d.queue <- job:
+				return nil
+			default:
+			}
+			select {
+			case <-d.queue:
+				d.active.Add(-1)
+				d.dropped.Add(1)
+			default:
+			}
+		}
+	}
+
+	d.active.Add(-1)
+	d.rejected.Add(1)
+
+	return ErrDispatchQueueFull
+}
+
+// Pending returns the number of jobs waiting in the queue.
+func (d *Dispatcher) Pending() int {
+	return len(d.queue)
+}
+
+// Stats returns a snapshot of the queue.
+func (d *Dispatcher) Stats() *DispatcherStats {
+	return &DispatcherStats{
+		Pending:  len(d.queue),
+		Capacity: cap(d.queue),
+		Active:   d.active.Load(),
+		Dropped:  d.dropped.Load(),
+		Rejected: d.rejected.Load(),
+	}
+}
+
+// Drain waits for the queued jobs and the running ones to be done, or for ctx to be done, while the
+// Dispatcher keeps accepting jobs. The jobs submitted meanwhile are waited for too, pause the
+// dispatch first, see Control, to empty the queue.
+func (d *Dispatcher) Drain(ctx context.Context) error {
+	ticker := time.NewTicker(drainInterval)
+	defer ticker.Stop()
+
+	for d.active.Load() > 0 {
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	return nil
+}
+
+// Shutdown stops accepting jobs and waits for the queued ones to be done, or for ctx to be done.
+func (d *Dispatcher) Shutdown(ctx context.Context) error {
+	d.mu.Lock()
+	if !d.closed {
+		d.closed = true
+		close(d.queue)
+	}
+	d.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		d.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// detachedContext keeps the values of a request context without its deadline and cancellation,
+// the hooks dispatched asynchronously outlive the request.
+type detachedContext struct {
+	parent context.Context //nolint:containedctx
+}
+
+func detach(ctx context.Context) context.Context {
+	return detachedContext{parent: ctx}
+}
+
+func (detachedContext) Deadline() (time.Time, bool) { return time.Time{}, false }
+
+func (detachedContext) Done() <-chan struct{} { return nil }
+
+func (detachedContext) Err() error { return nil }
+
+func (c detachedContext) Value(key any) any { return c.parent.Value(key) }
@@ -0,0 +1,198 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package webhooks
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type ctxKey struct{}
+
+func TestNotificationHandler_Dispatcher(t *testing.T) {
+	t.Parallel()
+	dispatcher := NewDispatcher(1, 1, OverflowReject)
+	release := make(chan struct{})
+	var (
+		mu     sync.Mutex
+		values []any
+	)
+
+	hooks := &Hooks{
+		OnMessageStatusChangeHook: func(ctx context.Context, nctx *NotificationContext, status *Status) error {
+			<-release
+			mu.Lock()
+			values = append(values, ctx.Value(ctxKey{}))
+			mu.Unlock()
+
+			// the request is over, its cancellation must not reach the hooks.
+			return ctx.Err()
+		},
+	}
+	handler := NotificationHandler(hooks, NoOpNotificationErrorHandler, NoOpHooksErrorHandler,
+		&HandlerOptions{Dispatcher: dispatcher})
+
+	post := func() int {
+		ctx, cancel := context.WithCancel(context.WithValue(context.Background(), ctxKey{}, "request"))
+		defer cancel()
+		request := httptest.NewRequest(http.MethodPost, "/webhooks", strings.NewReader(statusesPayload)).WithContext(ctx)
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, request)
+
+		return recorder.Code
+	}
+
+	// the first notification is taken by the worker, the second waits in the queue.
+	if code := post(); code != http.StatusOK {
+		t.Fatalf("got %d, want 200", code)
+	}
+	deadline := time.Now().Add(time.Second)
+	for dispatcher.Pending() != 0 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if code := post(); code != http.StatusOK {
+		t.Fatalf("got %d, want 200", code)
+	}
+	if code := post(); code != http.StatusServiceUnavailable {
+		t.Fatalf("got %d with a full queue, want 503", code)
+	}
+
+	close(release)
+	if err := dispatcher.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown() error = %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(values) < 2 || values[0] != "request" {
+		t.Errorf("got context values %v, want the request values", values)
+	}
+
+	if err := dispatcher.Submit(context.Background(), func() {}); err == nil {
+		t.Error("Submit() after Shutdown() error = nil, want an error")
+	}
+}
+
+func TestDispatcher_Overflow(t *testing.T) {
+	t.Parallel()
+	block := make(chan struct{})
+	dispatcher := NewDispatcher(1, 1, OverflowInline)
+	_ = dispatcher.Submit(context.Background(), func() { <-block })
+	deadline := time.Now().Add(time.Second)
+	for dispatcher.Pending() != 0 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	_ = dispatcher.Submit(context.Background(), func() {})
+
+	inline := false
+	if err := dispatcher.Submit(context.Background(), func() { inline = true }); err != nil || !inline {
+		t.Errorf("Submit() = %v, inline = %v, want the job to run inline", err, inline)
+	}
+
+	close(block)
+	_ = dispatcher.Shutdown(context.Background())
+
+	blocking := NewDispatcher(1, 1, OverflowBlock)
+	hold := make(chan struct{})
+	_ = blocking.Submit(context.Background(), func() { <-hold })
+	deadline = time.Now().Add(time.Second)
+	for blocking.Pending() != 0 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	_ = blocking.Submit(context.Background(), func() {})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := blocking.Submit(ctx, func() {}); err == nil {
+		t.Error("Submit() on a full queue error = nil, want an error once the context is done")
+	}
+	close(hold)
+	_ = blocking.Shutdown(context.Background())
+}
+
+func TestDispatcher_DropOldest(t *testing.T) {
+	t.Parallel()
+	hold := make(chan struct{})
+	dispatcher := NewDispatcher(1, 2, OverflowDropOldest)
+	_ = dispatcher.Submit(context.Background(), func() { <-hold })
+	deadline := time.Now().Add(time.Second)
+	for dispatcher.Pending() != 0 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+
+	var (
+		mu  sync.Mutex
+		ran []int
+	)
+	for i := 1; i <= 4; i++ {
+		i := i
+		if err := dispatcher.Submit(context.Background(), func() {
+			mu.Lock()
+			ran = append(ran, i)
+			mu.Unlock()
+		}); err != nil {
+			t.Fatalf("Submit() %d error = %v", i, err)
+		}
+	}
+
+	stats := dispatcher.Stats()
+	if stats.Pending != 2 || stats.Capacity != 2 || stats.Dropped != 2 || stats.Active != 3 {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+
+	close(hold)
+	_ = dispatcher.Shutdown(context.Background())
+	if len(ran) != 2 || ran[0] != 3 || ran[1] != 4 {
+		t.Errorf("ran jobs %v, want the 2 newest", ran)
+	}
+}
+
+func TestDispatcher_BlockTimeout(t *testing.T) {
+	t.Parallel()
+	hold := make(chan struct{})
+	dispatcher := NewDispatcher(1, 1, OverflowBlock, WithBlockTimeout(20*time.Millisecond))
+	defer func() {
+		close(hold)
+		_ = dispatcher.Shutdown(context.Background())
+	}()
+	_ = dispatcher.Submit(context.Background(), func() { <-hold })
+	deadline := time.Now().Add(time.Second)
+	for dispatcher.Pending() != 0 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	_ = dispatcher.Submit(context.Background(), func() {})
+
+	start := time.Now()
+	if err := dispatcher.Submit(context.Background(), func() {}); !errors.Is(err, ErrDispatchQueueFull) {
+		t.Fatalf("Submit() error = %v, want %v", err, ErrDispatchQueueFull)
+	}
+	if elapsed := time.Since(start); elapsed < 20*time.Millisecond {
+		t.Errorf("Submit() returned after %v, want the block timeout", elapsed)
+	}
+	if stats := dispatcher.Stats(); stats.Rejected != 1 {
+		t.Errorf("Rejected = %d, want 1", stats.Rejected)
+	}
+}
@@ -0,0 +1,124 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package webhooks
+
+import (
+	"sort"
+)
+
+// Drift compares the webhook fields the app is subscribed to with the fields that have hooks.
+//
+// Unhandled are fields the app is subscribed to that no hook handles, their notifications are
+// received and dropped. Unsubscribed are fields with hooks that the app is not subscribed to,
+// their hooks are never called.
+type Drift struct {
+	Unhandled    []string
+	Unsubscribed []string
+}
+
+// HasDrift reports whether the subscription and the hooks disagree.
+func (d *Drift) HasDrift() bool {
+	return d != nil && (len(d.Unhandled) > 0 || len(d.Unsubscribed) > 0)
+}
+
+// HandledFields returns the webhook fields that have at least one hook set, sorted.
+func HandledFields(hooks *Hooks) []string {
+	if hooks == nil {
+		return nil
+	}
+
+	handled := map[string]bool{
+		MessagesField: hooks.OnOrderMessageHook != nil || hooks.OnButtonMessageHook != nil ||
+			hooks.OnLocationMessageHook != nil || hooks.OnContactsMessageHook != nil ||
+			hooks.OnMessageReactionHook != nil || hooks.OnUnknownMessageHook != nil ||
+			hooks.OnReactionRemovedHook != nil || hooks.OnStickerMessageHook != nil ||
+			hooks.OnProductEnquiryHook != nil || hooks.OnInteractiveMessageHook != nil ||
+			hooks.OnCallPermissionReplyHook != nil || hooks.OnVoiceNoteHook != nil ||
+			hooks.OnButtonReplyHook != nil || hooks.OnListReplyHook != nil || hooks.OnFlowReplyHook != nil ||
+			hooks.OnLiveLocationUpdateHook != nil || hooks.OnAdReferralHook != nil ||
+			hooks.OnNumberChangeHook != nil || hooks.OnIdentityChangeHook != nil ||
+			hooks.OnReplyToBusinessMessageHook != nil ||
+			hooks.OnMessageErrorsHook != nil || hooks.OnTextMessageHook != nil ||
+			hooks.OnReferralMessageHook != nil || hooks.OnCustomerIDChangeHook != nil ||
+			hooks.OnSystemMessageHook != nil || hooks.OnMediaMessageHook != nil ||
+			hooks.OnNotificationErrorHook != nil || hooks.OnMessageStatusChangeHook != nil ||
+			hooks.OnMessageReceivedHook != nil || hooks.OnMessageSentHook != nil ||
+			hooks.OnMessageDeliveredHook != nil || hooks.OnMessageReadHook != nil ||
+			hooks.OnMessageFailedHook != nil || hooks.OnPaymentStatusHook != nil,
+		MessagingHandoversField:          hooks.OnHandoverHook != nil,
+		PartnerSolutionsField:            hooks.OnPartnerSolutionHook != nil,
+		MessageTemplateStatusUpdateField: hooks.OnTemplateStatusUpdateHook != nil,
+		TemplateCategoryUpdateField:      hooks.OnTemplateCategoryUpdateHook != nil,
+		PhoneNumberQualityUpdateField:    hooks.OnPhoneNumberQualityUpdateHook != nil,
+		AccountUpdateField:               hooks.OnAccountUpdateHook != nil || hooks.OnPartnerOnboardingHook != nil,
+		AccountReviewUpdateField:         hooks.OnAccountReviewUpdateHook != nil,
+		BusinessCapabilityUpdateField:    hooks.OnBusinessCapabilityUpdateHook != nil,
+		SecurityField:                    hooks.OnSecurityEventHook != nil,
+		FlowsField:                       hooks.OnFlowEventHook != nil,
+		CallsField:                       hooks.OnCallEventHook != nil,
+		UserPreferencesField:             hooks.OnUserPreferencesHook != nil,
+		SMBMessageEchoesField:            hooks.OnMessageEchoHook != nil,
+	}
+
+	var fields []string
+	for field, ok := range handled {
+		if ok {
+			fields = append(fields, field)
+		}
+	}
+	sort.Strings(fields)
+
+	return fields
+}
+
+// DetectDrift compares the subscribed fields with the fields handled by hooks.
+func DetectDrift(subscribed []string, hooks *Hooks) *Drift {
+	handled := HandledFields(hooks)
+	drift := &Drift{
+		Unhandled:    difference(subscribed, handled),
+		Unsubscribed: difference(handled, subscribed),
+	}
+
+	return drift
+}
+
+// Drift compares the subscribed fields with the hooks of the listener.
+func (ls *EventListener) Drift(subscribed []string) *Drift {
+	return DetectDrift(subscribed, ls.h)
+}
+
+// difference returns the sorted distinct elements of a that are not in b.
+func difference(a, b []string) []string {
+	in := make(map[string]bool, len(b))
+	for _, s := range b {
+		in[s] = true
+	}
+
+	var diff []string
+	for _, s := range a {
+		if !in[s] {
+			in[s] = true
+			diff = append(diff, s)
+		}
+	}
+	sort.Strings(diff)
+
+	return diff
+}
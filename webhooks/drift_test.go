@@ -0,0 +1,52 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package webhooks
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestEventListener_Drift(t *testing.T) {
+	t.Parallel()
+	listener := NewEventListener()
+	if drift := listener.Drift(nil); drift.HasDrift() {
+		t.Errorf("Drift() of an empty listener = %+v", drift)
+	}
+
+	listener.OnMessageRead(func(ctx context.Context, nctx *NotificationContext, status *Status) error {
+		return nil
+	})
+	listener.OnTemplateStatusUpdate(func(ctx context.Context, nctx *NotificationContext,
+		update *TemplateStatusUpdate,
+	) error {
+		return nil
+	})
+
+	if got, want := HandledFields(listener.h), []string{MessageTemplateStatusUpdateField, MessagesField}; !reflect.DeepEqual(got, want) {
+		t.Errorf("HandledFields() = %v, want %v", got, want)
+	}
+
+	drift := listener.Drift([]string{MessagesField, MessageTemplateStatusUpdateField})
+	if drift.HasDrift() {
+		t.Errorf("Drift() = %+v, want no drift", drift)
+	}
+}
@@ -0,0 +1,76 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package webhooks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+type (
+	// MessageEcho is a message sent by the business from the WhatsApp Business app, when the app is
+	// used together with the Cloud API. It has the fields of a Message, From is the business phone
+	// number and To is the customer. chat.FromWebhook converts &echo.Message like a received message.
+	MessageEcho struct {
+		Message
+		To string `json:"to,omitempty"`
+	}
+
+	// MessageEchoes is the value of a smb_message_echoes change.
+	MessageEchoes struct {
+		MessagingProduct string         `json:"messaging_product,omitempty"`
+		Metadata         *Metadata      `json:"metadata,omitempty"`
+		MessageEchoes    []*MessageEcho `json:"message_echoes,omitempty"`
+	}
+
+	// OnMessageEchoHook is called for every message echo of a smb_message_echoes change.
+	OnMessageEchoHook func(ctx context.Context, nctx *NotificationContext, echo *MessageEcho) error
+)
+
+var ErrOnMessageEchoHook = errors.New("on message echo hook error")
+
+func attachHooksToMessageEchoes(ctx context.Context, id string, change *Change, hooks *Hooks) error {
+	if hooks.OnMessageEchoHook == nil {
+		return nil
+	}
+
+	value, err := decodeChange[MessageEchoes](change, ErrOnMessageEchoHook)
+	if err != nil {
+		return err
+	}
+
+	nctx := &NotificationContext{
+		ID:       id,
+		Metadata: value.Metadata,
+	}
+
+	for _, echo := range value.MessageEchoes {
+		if echo == nil {
+			continue
+		}
+
+		if err := hooks.OnMessageEchoHook(ctx, nctx, echo); err != nil {
+			return fmt.Errorf("%v: %v", ErrOnMessageEchoHook, err)
+		}
+	}
+
+	return nil
+}
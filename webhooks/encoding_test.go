@@ -0,0 +1,116 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package webhooks
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestNotificationHandler_GzipBody(t *testing.T) {
+	t.Parallel()
+	const secret = "lilsecretofold"
+	body := []byte(`{"object":"whatsapp_business_account","entry":[{"id":"1","changes":[{"field":"messages",` +
+		`"value":{"messaging_product":"whatsapp","messages":[{"from":"255","id":"wamid.1","type":"text",` +
+		`"text":{"body":"hi"}}]}}]}]}`)
+	var compressed bytes.Buffer
+	zw := gzip.NewWriter(&compressed)
+	_, _ = zw.Write(body)
+	_ = zw.Close()
+
+	tests := []struct {
+		name      string
+		signed    []byte
+		wantCalls int
+	}{
+		{name: "signature of the uncompressed body", signed: body, wantCalls: 1},
+		{name: "signature of the compressed body", signed: compressed.Bytes(), wantCalls: 1},
+		{name: "wrong signature", signed: []byte("other"), wantCalls: 0},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			var calls int
+			hooks := &Hooks{
+				OnTextMessageHook: func(ctx context.Context, nctx *NotificationContext, mctx *MessageContext,
+					text *Text,
+				) error {
+					calls++
+
+					return nil
+				},
+			}
+			neh := func(ctx context.Context, r *http.Request, err error) *NotificationErrHandlerResponse {
+				return &NotificationErrHandlerResponse{StatusCode: http.StatusUnauthorized}
+			}
+			handler := NotificationHandler(hooks, neh, NoOpHooksErrorHandler, &HandlerOptions{
+				ValidateSignature: true,
+				Secret:            secret,
+			})
+
+			request := httptest.NewRequest(http.MethodPost, "/webhooks", bytes.NewReader(compressed.Bytes()))
+			request.Header.Set("Content-Encoding", "gzip")
+			request.Header.Set(SignatureHeaderKey, "sha256="+computeSignature(tt.signed, secret))
+			handler.ServeHTTP(httptest.NewRecorder(), request)
+
+			if calls != tt.wantCalls {
+				t.Errorf("got %d hook calls, want %d", calls, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestGlobalHandler_GzipBody(t *testing.T) {
+	t.Parallel()
+	const secret = "lilsecretofold"
+	body := []byte(`{"object":"whatsapp_business_account","entry":[{"id":"1","changes":[]}]}`)
+	var compressed bytes.Buffer
+	zw := gzip.NewWriter(&compressed)
+	_, _ = zw.Write(body)
+	_ = zw.Close()
+
+	var object string
+	listener := NewEventListener(WithSecrets(secret),
+		WithGlobalNotificationHandler(func(ctx context.Context, w http.ResponseWriter, n *Notification) error {
+			object = n.Object
+
+			return nil
+		}))
+
+	request := httptest.NewRequest(http.MethodPost, "/webhooks", bytes.NewReader(compressed.Bytes()))
+	request.Header.Set("Content-Encoding", "gzip")
+	request.Header.Set(SignatureHeaderKey, "sha256="+computeSignature(body, secret))
+	recorder := httptest.NewRecorder()
+	listener.GlobalHandler().ServeHTTP(recorder, request)
+
+	if recorder.Code != http.StatusOK {
+		t.Errorf("got status %d, want %d", recorder.Code, http.StatusOK)
+	}
+
+	if object != "whatsapp_business_account" {
+		t.Errorf("global handler got object %q", object)
+	}
+}
@@ -0,0 +1,71 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package webhooks
+
+import (
+	"context"
+	"fmt"
+)
+
+// decodeChange decodes the value of the change into a new T, decoding errors are prefixed with
+// errPrefix, the error of the hook the value was decoded for.
+func decodeChange[T any](change *Change, errPrefix error) (*T, error) {
+	value := new(T)
+	if err := change.DecodeValue(value); err != nil {
+		return nil, fmt.Errorf("%v: %v", errPrefix, err)
+	}
+
+	return value, nil
+}
+
+// attachFieldHook decodes the change and calls the hook with it, it does nothing when the hook is
+// not set. It serves the fields whose hooks only need the ID of the entry as their context.
+func attachFieldHook[T any](ctx context.Context, id string, change *Change,
+	hook func(ctx context.Context, nctx *NotificationContext, value *T) error, errPrefix error,
+) error {
+	if hook == nil {
+		return nil
+	}
+
+	value, err := decodeChange[T](change, errPrefix)
+	if err != nil {
+		return err
+	}
+
+	return hook(ctx, &NotificationContext{ID: id}, value)
+}
+
+// fieldHooks maps the fields that do not carry messages, statuses and errors to the function that
+// calls their typed hooks.
+var fieldHooks = map[string]func(ctx context.Context, id string, change *Change, hooks *Hooks) error{
+	MessagingHandoversField:          attachHooksToHandover,
+	PartnerSolutionsField:            attachHooksToPartnerSolution,
+	MessageTemplateStatusUpdateField: attachHooksToTemplateStatusUpdate,
+	TemplateCategoryUpdateField:      attachHooksToTemplateCategoryUpdate,
+	PhoneNumberQualityUpdateField:    attachHooksToPhoneNumberQualityUpdate,
+	AccountUpdateField:               attachHooksToAccountUpdate,
+	AccountReviewUpdateField:         attachHooksToAccountReviewUpdate,
+	BusinessCapabilityUpdateField:    attachHooksToBusinessCapabilityUpdate,
+	SecurityField:                    attachHooksToSecurityEvent,
+	FlowsField:                       attachHooksToFlowEvent,
+	CallsField:                       attachHooksToCallEvent,
+	UserPreferencesField:             attachHooksToUserPreferences,
+	SMBMessageEchoesField:            attachHooksToMessageEchoes,
+}
@@ -0,0 +1,372 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package webhooks
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestAttachHooksToNotification_FieldHooks(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name    string
+		payload string
+		// listen sets the hooks of the field on the listener and returns the check of the values
+		// they were called with.
+		listen func(t *testing.T, listener *EventListener) func()
+		fields []string
+	}{
+		{
+			name: "business capability update",
+			payload: `{"object":"whatsapp_business_account","entry":[{"id":"102290129340398","changes":[{
+				"field":"business_capability_update",
+				"value":{"max_daily_conversation_per_phone":1000,"max_phone_numbers_per_business":2}}]}]}`,
+			listen: func(t *testing.T, listener *EventListener) func() {
+				var update *BusinessCapabilityUpdate
+				listener.OnBusinessCapabilityUpdate(capture(&update))
+
+				return func() {
+					if update == nil || update.MaxDailyConversationPerPhone != 1000 || update.MaxPhoneNumbersPerBusiness != 2 {
+						t.Errorf("unexpected update %+v", update)
+					}
+				}
+			},
+			fields: []string{BusinessCapabilityUpdateField},
+		},
+		{
+			name: "phone number quality update",
+			payload: `{"object":"whatsapp_business_account","entry":[{"id":"102290129340398","changes":[{
+				"field":"phone_number_quality_update",
+				"value":{"display_phone_number":"15550783881","event":"DOWNGRADE","current_limit":"TIER_1K",
+					"old_limit":"TIER_10K"}}]}]}`,
+			listen: func(t *testing.T, listener *EventListener) func() {
+				var update *PhoneNumberQualityUpdate
+				listener.OnPhoneNumberQualityUpdate(capture(&update))
+
+				return func() {
+					if update == nil || update.Event != QualityEventDowngrade || update.DisplayPhoneNumber != "15550783881" ||
+						update.CurrentLimit.Limit() != 1000 || update.OldLimit.Limit() != 10000 {
+						t.Errorf("unexpected update %+v", update)
+					}
+				}
+			},
+			fields: []string{PhoneNumberQualityUpdateField},
+		},
+		{
+			name: "account updates",
+			payload: `{"object":"whatsapp_business_account","entry":[{"id":"102290129340398","changes":[{
+				"field":"account_update",
+				"value":{"phone_number":"15550783881","event":"ACCOUNT_RESTRICTION",
+					"restriction_info":[{"restriction_type":"RESTRICTED_BIZ_INITIATED_MESSAGING","expiration":1706461964}]}
+			},{
+				"field":"account_review_update",
+				"value":{"decision":"APPROVED"}}]}]}`,
+			listen: func(t *testing.T, listener *EventListener) func() {
+				var (
+					update *AccountUpdate
+					review *AccountReviewUpdate
+				)
+				listener.OnAccountUpdate(capture(&update))
+				listener.OnAccountReviewUpdate(capture(&review))
+
+				return func() {
+					if update == nil || update.Event != AccountEventAccountRestriction || len(update.RestrictionInfo) != 1 ||
+						update.RestrictionInfo[0].Expiration.String() != "1706461964" {
+						t.Errorf("unexpected account update %+v", update)
+					}
+					if review == nil || review.Decision != AccountReviewApproved {
+						t.Errorf("unexpected account review update %+v", review)
+					}
+				}
+			},
+			fields: []string{AccountReviewUpdateField, AccountUpdateField},
+		},
+		{
+			name: "security event",
+			payload: `{"object":"whatsapp_business_account","entry":[{"id":"102290129340398","changes":[{
+				"field":"security",
+				"value":{"display_phone_number":"15550783881","event":"PIN_RESET_REQUEST","requester":"2862381067"}}]}]}`,
+			listen: func(t *testing.T, listener *EventListener) func() {
+				var event *SecurityEvent
+				listener.OnSecurityEvent(capture(&event))
+
+				return func() {
+					if event == nil || event.Event != SecurityEventPinResetRequest ||
+						event.DisplayPhoneNumber != "15550783881" || event.Requester != "2862381067" {
+						t.Errorf("unexpected event %+v", event)
+					}
+				}
+			},
+			fields: []string{SecurityField},
+		},
+		{
+			name: "template status update",
+			payload: `{"object":"whatsapp_business_account","entry":[{"id":"102290129340398","changes":[{
+				"field":"message_template_status_update",
+				"value":{"event":"REJECTED","message_template_id":1234567890123456789,
+					"message_template_name":"order_update","message_template_language":"en_US",
+					"reason":"INCORRECT_CATEGORY","disable_info":{"disable_date":"1706461964"}}}]}]}`,
+			listen: func(t *testing.T, listener *EventListener) func() {
+				var (
+					update *TemplateStatusUpdate
+					id     string
+				)
+				listener.OnTemplateStatusUpdate(func(ctx context.Context, nctx *NotificationContext,
+					u *TemplateStatusUpdate,
+				) error {
+					update, id = u, nctx.ID
+
+					return nil
+				})
+
+				return func() {
+					if id != "102290129340398" {
+						t.Errorf("notification context ID = %q", id)
+					}
+					if update == nil || update.Event != TemplateStatusRejected || update.Reason != "INCORRECT_CATEGORY" ||
+						update.MessageTemplateID.String() != "1234567890123456789" ||
+						update.MessageTemplateName != "order_update" || update.DisableInfo == nil ||
+						update.DisableInfo.DisableDate != "1706461964" {
+						t.Errorf("unexpected update %+v", update)
+					}
+				}
+			},
+			fields: []string{MessageTemplateStatusUpdateField},
+		},
+		{
+			name: "template category update",
+			payload: `{"object":"whatsapp_business_account","entry":[{"id":"102290129340398","changes":[{
+				"field":"template_category_update",
+				"value":{"message_template_id":278077987, "message_template_name":"order_update",
+					"message_template_language":"en_US","previous_category":"UTILITY","new_category":"MARKETING"}}]}]}`,
+			listen: func(t *testing.T, listener *EventListener) func() {
+				var update *TemplateCategoryUpdate
+				listener.OnTemplateCategoryUpdate(capture(&update))
+
+				return func() {
+					if update == nil || update.MessageTemplateID.String() != "278077987" ||
+						update.PreviousCategory != "UTILITY" || update.NewCategory != "MARKETING" ||
+						update.MessageTemplateName != "order_update" {
+						t.Errorf("unexpected update %+v", update)
+					}
+				}
+			},
+			fields: []string{TemplateCategoryUpdateField},
+		},
+		{
+			name: "flow events",
+			payload: `{"object":"whatsapp_business_account","entry":[{"id":"102290129340398","changes":[{
+				"field":"flows",
+				"value":{"event":"FLOW_STATUS_CHANGE","message":"Flow Webhook Test","flow_id":"8796",
+					"old_status":"DRAFT","new_status":"PUBLISHED"}
+			},{
+				"field":"flows",
+				"value":{"event":"ENDPOINT_ERROR_RATE","flow_id":"8796","error_rate":0.3,"threshold":0.1,
+					"alert_state":"ACTIVATED","errors":[{"error_type":"TIMEOUT_ERROR","error_rate":0.3,"error_count":12}]}
+			}]}]}`,
+			listen: func(t *testing.T, listener *EventListener) func() {
+				var events []*FlowEvent
+				listener.OnFlowEvent(func(ctx context.Context, nctx *NotificationContext, e *FlowEvent) error {
+					events = append(events, e)
+
+					return nil
+				})
+
+				return func() {
+					if len(events) != 2 {
+						t.Fatalf("got %d events, want 2", len(events))
+					}
+					if e := events[0]; e.Event != FlowEventStatusChange || e.OldStatus != "DRAFT" ||
+						e.NewStatus != "PUBLISHED" {
+						t.Errorf("unexpected status change %+v", e)
+					}
+					if e := events[1]; e.Event != FlowEventEndpointErrorRate || e.AlertState != FlowAlertActivated ||
+						len(e.Errors) != 1 || e.Errors[0].ErrorCount != 12 {
+						t.Errorf("unexpected error rate alert %+v", e)
+					}
+				}
+			},
+			fields: []string{FlowsField},
+		},
+		{
+			// the calls change has no messages, it must not be taken for a messages change.
+			name:    "call event",
+			payload: callsPayload,
+			listen: func(t *testing.T, listener *EventListener) func() {
+				var (
+					event *CallEvent
+					nctx  *NotificationContext
+				)
+				listener.OnCallEvent(func(ctx context.Context, n *NotificationContext, e *CallEvent) error {
+					event, nctx = e, n
+
+					return nil
+				})
+
+				return func() {
+					if event == nil || len(event.Calls) != 1 {
+						t.Fatalf("unexpected event %+v", event)
+					}
+					call := event.Calls[0]
+					if call.Event != CallEventConnect || call.Direction != CallDirectionUserInitiated ||
+						call.Session == nil || call.Session.SDPType != "offer" {
+						t.Errorf("unexpected call %+v", call)
+					}
+					if nctx.Metadata == nil || nctx.Metadata.PhoneNumberID != "106540352242922" || len(nctx.Contacts) != 1 {
+						t.Errorf("unexpected notification context %+v", nctx)
+					}
+				}
+			},
+			fields: []string{CallsField},
+		},
+		{
+			name:    "user preferences",
+			payload: userPreferencesPayload,
+			listen: func(t *testing.T, listener *EventListener) func() {
+				var (
+					update *UserPreferencesUpdate
+					nctx   *NotificationContext
+				)
+				listener.OnUserPreferences(func(ctx context.Context, n *NotificationContext,
+					u *UserPreferencesUpdate,
+				) error {
+					update, nctx = u, n
+
+					return nil
+				})
+
+				return func() {
+					if update == nil || len(update.UserPreferences) != 1 ||
+						update.UserPreferences[0].Timestamp != 1731705721 {
+						t.Fatalf("unexpected update %+v", update)
+					}
+					if nctx.Metadata == nil || nctx.Metadata.PhoneNumberID != "106540352242922" || len(nctx.Contacts) != 1 {
+						t.Errorf("unexpected notification context %+v", nctx)
+					}
+				}
+			},
+			fields: []string{UserPreferencesField},
+		},
+		{
+			name: "message echoes",
+			payload: `{"object":"whatsapp_business_account","entry":[{"id":"102290129340398","changes":[{
+				"field":"smb_message_echoes",
+				"value":{"messaging_product":"whatsapp",
+					"metadata":{"display_phone_number":"15550783881","phone_number_id":"106540352242922"},
+					"message_echoes":[
+						{"from":"15550783881","to":"16505551234","id":"wamid.1","timestamp":"1739321024",
+							"type":"text","text":{"body":"Your order has shipped"}},
+						{"from":"15550783881","to":"16505551234","id":"wamid.2","timestamp":"1739321025",
+							"type":"image","image":{"id":"media.1","mime_type":"image/jpeg"}}]}}]}]}`,
+			listen: func(t *testing.T, listener *EventListener) func() {
+				var echoes []*MessageEcho
+				listener.OnMessageEcho(func(ctx context.Context, nctx *NotificationContext, echo *MessageEcho) error {
+					if nctx.Metadata == nil || nctx.Metadata.PhoneNumberID != "106540352242922" {
+						t.Errorf("unexpected notification context %+v", nctx)
+					}
+					echoes = append(echoes, echo)
+
+					return nil
+				})
+
+				return func() {
+					if len(echoes) != 2 {
+						t.Fatalf("got %d echoes, want 2", len(echoes))
+					}
+					if e := echoes[0]; e.To != "16505551234" || e.From != "15550783881" || e.Text == nil ||
+						e.Text.Body != "Your order has shipped" {
+						t.Errorf("unexpected echo %+v", e)
+					}
+					if e := echoes[1]; e.Type != "image" || e.Image == nil || e.Image.ID != "media.1" {
+						t.Errorf("unexpected echo %+v", e)
+					}
+				}
+			},
+			fields: []string{SMBMessageEchoesField},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			var notification Notification
+			if err := json.Unmarshal([]byte(tt.payload), &notification); err != nil {
+				t.Fatalf("unmarshal: %v", err)
+			}
+
+			listener := NewEventListener()
+			check := tt.listen(t, listener)
+
+			if err := AttachHooksToNotification(context.TODO(), &notification, listener.h, NoOpHooksErrorHandler); err != nil {
+				t.Fatalf("AttachHooksToNotification() error = %v", err)
+			}
+
+			check()
+
+			if fields := HandledFields(listener.h); !reflect.DeepEqual(fields, tt.fields) {
+				t.Errorf("HandledFields() = %v, want %v", fields, tt.fields)
+			}
+		})
+	}
+}
+
+func TestAttachHooksToNotification_FieldHookDecodeError(t *testing.T) {
+	t.Parallel()
+	payload := `{"object":"whatsapp_business_account","entry":[{"id":"102290129340398","changes":[{
+		"field":"business_capability_update","value":{"max_daily_conversation_per_phone":"1000"}}]}]}`
+
+	var notification Notification
+	if err := json.Unmarshal([]byte(payload), &notification); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+
+	called := false
+	listener := NewEventListener()
+	listener.OnBusinessCapabilityUpdate(func(ctx context.Context, nctx *NotificationContext,
+		u *BusinessCapabilityUpdate,
+	) error {
+		called = true
+
+		return nil
+	})
+
+	err := AttachHooksToNotification(context.TODO(), &notification, listener.h, NoOpHooksErrorHandler)
+	if err == nil || !strings.Contains(err.Error(), ErrOnBusinessCapabilityUpdateHook.Error()) {
+		t.Errorf("AttachHooksToNotification() error = %v, want a %v", err, ErrOnBusinessCapabilityUpdateHook)
+	}
+
+	if called {
+		t.Error("hook called with a value that failed to decode")
+	}
+}
+
+// capture returns a hook that stores the value it is called with in v.
+func capture[T any](v **T) func(ctx context.Context, nctx *NotificationContext, value *T) error {
+	return func(ctx context.Context, nctx *NotificationContext, value *T) error {
+		*v = value
+
+		return nil
+	}
+}
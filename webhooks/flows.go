@@ -0,0 +1,89 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package webhooks
+
+import (
+	"context"
+	"errors"
+)
+
+// Events of a flows change.
+const (
+	FlowEventStatusChange         = "FLOW_STATUS_CHANGE"
+	FlowEventClientErrorRate      = "CLIENT_ERROR_RATE"
+	FlowEventEndpointErrorRate    = "ENDPOINT_ERROR_RATE"
+	FlowEventEndpointLatency      = "ENDPOINT_LATENCY"
+	FlowEventEndpointAvailability = "ENDPOINT_AVAILABILITY"
+)
+
+// Alert states of the flows alerts.
+const (
+	FlowAlertActivated   = "ACTIVATED"
+	FlowAlertDeactivated = "DEACTIVATED"
+)
+
+type (
+	// FlowError is the rate and count of an error type of a flow.
+	FlowError struct {
+		ErrorType  string  `json:"error_type,omitempty"`
+		ErrorRate  float64 `json:"error_rate,omitempty"`
+		ErrorCount int     `json:"error_count,omitempty"`
+	}
+
+	// FlowEvent is the value of a flows change. It is sent when the status of a flow changes and
+	// when an alert about the client errors, or the error rate, latency or availability of the
+	// flow endpoint, is activated or deactivated. Only the fields relevant to the event are set.
+	//
+	// Event, event — see the FlowEvent constants.
+	// Message, message — a description of the event.
+	// FlowID, flow_id — the ID of the flow.
+	// OldStatus, NewStatus, old_status, new_status — set on FLOW_STATUS_CHANGE, for example
+	// DRAFT, PUBLISHED, THROTTLED, BLOCKED or DEPRECATED.
+	// AlertState, alert_state — ACTIVATED or DEACTIVATED for the alerts.
+	// ErrorRate, Threshold — the error rate, latency or availability measured and the threshold
+	// of the alert.
+	// P50Latency, P90Latency, RequestsCount — set on ENDPOINT_LATENCY, in milliseconds.
+	// Availability — set on ENDPOINT_AVAILABILITY.
+	// Errors — the error types of the error rate alerts.
+	FlowEvent struct {
+		Event         string       `json:"event,omitempty"`
+		Message       string       `json:"message,omitempty"`
+		FlowID        string       `json:"flow_id,omitempty"`
+		OldStatus     string       `json:"old_status,omitempty"`
+		NewStatus     string       `json:"new_status,omitempty"`
+		AlertState    string       `json:"alert_state,omitempty"`
+		ErrorRate     float64      `json:"error_rate,omitempty"`
+		Threshold     float64      `json:"threshold,omitempty"`
+		P50Latency    float64      `json:"p50_latency,omitempty"`
+		P90Latency    float64      `json:"p90_latency,omitempty"`
+		RequestsCount int          `json:"requests_count,omitempty"`
+		Availability  float64      `json:"availability,omitempty"`
+		Errors        []*FlowError `json:"errors,omitempty"`
+	}
+
+	// OnFlowEventHook is called for every flows change.
+	OnFlowEventHook func(ctx context.Context, nctx *NotificationContext, event *FlowEvent) error
+)
+
+var ErrOnFlowEventHook = errors.New("on flow event hook error")
+
+func attachHooksToFlowEvent(ctx context.Context, id string, change *Change, hooks *Hooks) error {
+	return attachFieldHook(ctx, id, change, hooks.OnFlowEventHook, ErrOnFlowEventHook)
+}
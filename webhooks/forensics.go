@@ -0,0 +1,167 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	wcrypto "github.com/lowkruc/go-whatsapp-api/crypto"
+	whttp "github.com/lowkruc/go-whatsapp-api/http"
+)
+
+type (
+	// SignatureMismatch is a report about a notification whose signature could not be validated.
+	// Digests are redacted, only their first characters are kept so that they can be compared
+	// without leaking a valid signature. Findings lists the likely causes of the mismatch, for
+	// example a body that was decompressed or re-encoded by a proxy.
+	SignatureMismatch struct {
+		ProvidedDigest   string
+		ComputedDigest   string
+		ContentLength    int64
+		BodyLength       int
+		ContentEncoding  string
+		TransferEncoding []string
+		BodyAltered      bool
+		Findings         []string
+	}
+
+	// OnSignatureMismatchFunc receives a SignatureMismatch report. It is called before the
+	// NotificationErrorHandler handles ErrInvalidSignature.
+	OnSignatureMismatchFunc func(ctx context.Context, request *http.Request, mismatch *SignatureMismatch)
+)
+
+const redactedDigestLength = 8
+
+func redactDigest(digest string) string {
+	if digest == "" {
+		return "<none>"
+	}
+
+	if len(digest) <= redactedDigestLength {
+		return digest
+	}
+
+	return digest[:redactedDigestLength] + "..."
+}
+
+func computeSignature(payload []byte, secret string) string {
+	return hex.EncodeToString(wcrypto.Default().HMACSHA256([]byte(secret), payload))
+}
+
+// InspectSignatureMismatch builds a SignatureMismatch report for the request and the body that
+// was read from it.
+//
+//nolint:cyclop
+func InspectSignatureMismatch(request *http.Request, body []byte, secret string) *SignatureMismatch {
+	provided, extractErr := ExtractSignatureFromHeader(request.Header)
+	computed := computeSignature(body, secret)
+	report := &SignatureMismatch{
+		ProvidedDigest:   redactDigest(provided),
+		ComputedDigest:   redactDigest(computed),
+		ContentLength:    request.ContentLength,
+		BodyLength:       len(body),
+		ContentEncoding:  request.Header.Get("Content-Encoding"),
+		TransferEncoding: request.TransferEncoding,
+	}
+
+	finding := func(altered bool, format string, args ...any) {
+		report.BodyAltered = report.BodyAltered || altered
+		report.Findings = append(report.Findings, fmt.Sprintf(format, args...))
+	}
+
+	if secret == "" {
+		finding(false, "the app secret is empty")
+	}
+
+	if extractErr != nil {
+		finding(false, "the %s header is missing or has no sha256= prefix", SignatureHeaderKey)
+	} else if _, err := hex.DecodeString(provided); err != nil {
+		finding(false, "the provided signature is not hex encoded")
+	}
+
+	// the body has already been decompressed when it was encoded, so its length can not be compared.
+	if header := request.Header.Get("Content-Length"); header != "" && !whttp.IsEncoded(report.ContentEncoding) {
+		if n, err := strconv.Atoi(header); err == nil && n != len(body) {
+			finding(true, "content-length header is %d but %d bytes were read", n, len(body))
+		}
+	}
+
+	if whttp.IsEncoded(report.ContentEncoding) {
+		finding(false, "the body has content-encoding %q, it was checked decompressed and as received",
+			report.ContentEncoding)
+	}
+
+	if len(body) > 1 && body[0] == 0x1f && body[1] == 0x8b {
+		finding(false, "the body is gzip compressed")
+	}
+
+	if len(report.TransferEncoding) > 0 {
+		finding(false, "the request has transfer-encoding %s", strings.Join(report.TransferEncoding, ", "))
+	}
+
+	if len(body) == 0 {
+		finding(true, "the body is empty, it may have been read before the handler")
+
+		return report
+	}
+
+	// a proxy commonly adds or strips a trailing new line or re-encodes the JSON.
+	if trimmed := bytes.TrimSpace(body); len(trimmed) != len(body) &&
+		ValidateSignature(trimmed, provided, secret) {
+		finding(true, "the signature matches the body without surrounding whitespace")
+	}
+
+	var compact bytes.Buffer
+	if err := json.Compact(&compact, body); err != nil {
+		finding(true, "the body is not valid JSON")
+	} else if compact.Len() != len(body) && ValidateSignature(compact.Bytes(), provided, secret) {
+		finding(true, "the signature matches the compacted JSON body, a proxy reformatted it")
+	}
+
+	if len(report.Findings) == 0 {
+		finding(false, "no transport issue found, check that the secret is the app secret of the subscribed app")
+	}
+
+	return report
+}
+
+// String returns the report as a single line.
+func (mismatch *SignatureMismatch) String() string {
+	return fmt.Sprintf("signature mismatch: provided=%s computed=%s content_length=%d body_length=%d "+
+		"content_encoding=%q transfer_encoding=%q body_altered=%t findings=[%s]",
+		mismatch.ProvidedDigest, mismatch.ComputedDigest, mismatch.ContentLength, mismatch.BodyLength,
+		mismatch.ContentEncoding, strings.Join(mismatch.TransferEncoding, ","), mismatch.BodyAltered,
+		strings.Join(mismatch.Findings, "; "))
+}
+
+// LogSignatureMismatch returns an OnSignatureMismatchFunc that writes every report to w.
+func LogSignatureMismatch(w io.Writer) OnSignatureMismatchFunc {
+	return func(ctx context.Context, request *http.Request, mismatch *SignatureMismatch) {
+		_, _ = fmt.Fprintf(w, "%s %s: %s\n", request.Method, request.URL.Path, mismatch)
+	}
+}
@@ -0,0 +1,74 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestNotificationHandler_SignatureForensics(t *testing.T) {
+	t.Parallel()
+	const secret = "lilsecretofold"
+	body := []byte(`{"object":"whatsapp_business_account","entry":[]}`)
+	signature := "sha256=" + computeSignature(body, secret)
+
+	var (
+		log        bytes.Buffer
+		mismatches []*SignatureMismatch
+	)
+	handler := NotificationHandler(&Hooks{}, NoOpNotificationErrorHandler, NoOpHooksErrorHandler, &HandlerOptions{
+		ValidateSignature: true,
+		Secret:            secret,
+		OnSignatureMismatch: func(ctx context.Context, request *http.Request, mismatch *SignatureMismatch) {
+			mismatches = append(mismatches, mismatch)
+			LogSignatureMismatch(&log)(ctx, request, mismatch)
+		},
+	})
+
+	send := func(payload []byte) {
+		request := httptest.NewRequest(http.MethodPost, "/webhooks", bytes.NewReader(payload))
+		request.Header.Set(SignatureHeaderKey, signature)
+		handler.ServeHTTP(httptest.NewRecorder(), request)
+	}
+
+	send(body)
+	if len(mismatches) != 0 {
+		t.Fatalf("valid signature reported as a mismatch: %v", mismatches[0])
+	}
+
+	send(append(append([]byte{}, body...), '\n'))
+	if len(mismatches) != 1 {
+		t.Fatalf("got %d mismatches, want 1", len(mismatches))
+	}
+
+	mismatch := mismatches[0]
+	if !mismatch.BodyAltered || !strings.Contains(strings.Join(mismatch.Findings, ";"), "whitespace") {
+		t.Errorf("unexpected report %v", mismatch)
+	}
+
+	if strings.Contains(log.String(), signature[7:]) {
+		t.Errorf("log contains the full signature: %s", log.String())
+	}
+}
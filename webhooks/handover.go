@@ -0,0 +1,117 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package webhooks
+
+import (
+	"context"
+	"errors"
+)
+
+// Events and statuses of a partner solution.
+const (
+	PartnerSolutionCreated = "SOLUTION_CREATED"
+	PartnerSolutionUpdated = "SOLUTION_UPDATED"
+
+	PartnerSolutionStatusInitiated           = "INITIATED"
+	PartnerSolutionStatusActive              = "ACTIVE"
+	PartnerSolutionStatusPendingActivation   = "PENDING_ACTIVATION"
+	PartnerSolutionStatusRejected            = "REJECTED"
+	PartnerSolutionStatusPendingDeactivation = "PENDING_DEACTIVATION"
+	PartnerSolutionStatusDeactivated         = "DEACTIVATED"
+)
+
+type (
+	// ThreadControl describes a change of the app that owns a conversation in the handover protocol.
+	// Only the fields relevant to the event are set.
+	//
+	// NewOwnerAppID, new_owner_app_id — the app that received control of the conversation.
+	// PreviousOwnerAppID, previous_owner_app_id — the app that had control of the conversation.
+	// RequestedOwnerAppID, requested_owner_app_id — the app that asks for control of the conversation.
+	// Metadata, metadata — custom string passed by the app that started the event.
+	ThreadControl struct {
+		NewOwnerAppID       string `json:"new_owner_app_id,omitempty"`
+		PreviousOwnerAppID  string `json:"previous_owner_app_id,omitempty"`
+		RequestedOwnerAppID string `json:"requested_owner_app_id,omitempty"`
+		Metadata            string `json:"metadata,omitempty"`
+	}
+
+	// Handover is the value of a messaging_handovers change. It is sent when the control of a
+	// conversation shared between several apps is passed, taken or requested, and when the roles
+	// of the apps change.
+	//
+	// AppRoles maps app IDs to their roles, for example "primary_receiver" or "secondary_receiver".
+	Handover struct {
+		MessagingProduct     string              `json:"messaging_product,omitempty"`
+		Metadata             *Metadata           `json:"metadata,omitempty"`
+		Contacts             []*Contact          `json:"contacts,omitempty"`
+		Timestamp            string              `json:"timestamp,omitempty"`
+		PassThreadControl    *ThreadControl      `json:"pass_thread_control,omitempty"`
+		TakeThreadControl    *ThreadControl      `json:"take_thread_control,omitempty"`
+		RequestThreadControl *ThreadControl      `json:"request_thread_control,omitempty"`
+		AppRoles             map[string][]string `json:"app_roles,omitempty"`
+	}
+
+	// PartnerSolution is the value of a partner_solutions change. It is sent when a Multi-Partner
+	// Solution is created or its status changes. The ID of the notification context is the ID of
+	// the entry, which tells the tenants of a multi-tenant platform apart.
+	//
+	// Event, event — PartnerSolutionCreated or PartnerSolutionUpdated.
+	// SolutionID, solution_id — the ID of the solution.
+	// SolutionStatus, solution_status — one of the PartnerSolutionStatus constants.
+	PartnerSolution struct {
+		Event          string `json:"event,omitempty"`
+		SolutionID     string `json:"solution_id,omitempty"`
+		SolutionStatus string `json:"solution_status,omitempty"`
+	}
+
+	// OnHandoverHook is called for every messaging_handovers change.
+	OnHandoverHook func(ctx context.Context, nctx *NotificationContext, handover *Handover) error
+
+	// OnPartnerSolutionHook is called for every partner_solutions change.
+	OnPartnerSolutionHook func(ctx context.Context, nctx *NotificationContext, solution *PartnerSolution) error
+)
+
+var (
+	ErrOnHandoverHook        = errors.New("on handover hook error")
+	ErrOnPartnerSolutionHook = errors.New("on partner solution hook error")
+)
+
+func attachHooksToHandover(ctx context.Context, id string, change *Change, hooks *Hooks) error {
+	if hooks.OnHandoverHook == nil {
+		return nil
+	}
+
+	handover, err := decodeChange[Handover](change, ErrOnHandoverHook)
+	if err != nil {
+		return err
+	}
+
+	nctx := &NotificationContext{
+		ID:       id,
+		Contacts: handover.Contacts,
+		Metadata: handover.Metadata,
+	}
+
+	return hooks.OnHandoverHook(ctx, nctx, handover)
+}
+
+func attachHooksToPartnerSolution(ctx context.Context, id string, change *Change, hooks *Hooks) error {
+	return attachFieldHook(ctx, id, change, hooks.OnPartnerSolutionHook, ErrOnPartnerSolutionHook)
+}
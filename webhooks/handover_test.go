@@ -0,0 +1,95 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package webhooks
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+const handoverPayload = `{
+  "object": "whatsapp_business_account",
+  "entry": [{
+    "id": "102290129340398",
+    "changes": [{
+      "field": "messaging_handovers",
+      "value": {
+        "messaging_product": "whatsapp",
+        "metadata": {"display_phone_number": "15550783881", "phone_number_id": "106540352242922"},
+        "contacts": [{"wa_id": "16505551234", "profile": {"name": "Kerry Fisher"}}],
+        "timestamp": "1706461964",
+        "pass_thread_control": {"new_owner_app_id": "123", "previous_owner_app_id": "456", "metadata": "escalated"}
+      }
+    }, {
+      "field": "partner_solutions",
+      "value": {"event": "SOLUTION_CREATED", "solution_id": "789", "solution_status": "ACTIVE"}
+    }]
+  }]
+}`
+
+func TestAttachHooksToNotification_Handover(t *testing.T) {
+	t.Parallel()
+	var notification Notification
+	if err := json.Unmarshal([]byte(handoverPayload), &notification); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+
+	var (
+		handover *Handover
+		solution *PartnerSolution
+	)
+	hooks := &Hooks{
+		OnHandoverHook: func(ctx context.Context, nctx *NotificationContext, h *Handover) error {
+			handover = h
+
+			return nil
+		},
+		OnPartnerSolutionHook: func(ctx context.Context, nctx *NotificationContext, s *PartnerSolution) error {
+			solution = s
+
+			return nil
+		},
+	}
+
+	if err := AttachHooksToNotification(context.TODO(), &notification, hooks, NoOpHooksErrorHandler); err != nil {
+		t.Fatalf("AttachHooksToNotification() error = %v", err)
+	}
+
+	if handover == nil || handover.PassThreadControl == nil || handover.PassThreadControl.NewOwnerAppID != "123" ||
+		handover.Metadata == nil || handover.Metadata.PhoneNumberID != "106540352242922" {
+		t.Errorf("unexpected handover %+v", handover)
+	}
+
+	if solution == nil || solution.SolutionID != "789" || solution.Event != PartnerSolutionCreated ||
+		solution.SolutionStatus != PartnerSolutionStatusActive {
+		t.Errorf("unexpected partner solution %+v", solution)
+	}
+
+	data, err := json.Marshal(&notification)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+
+	if !strings.Contains(string(data), `"pass_thread_control"`) {
+		t.Errorf("handover value lost when marshalling: %s", data)
+	}
+}
@@ -0,0 +1,164 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package webhooks
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+type (
+	// HookError is the error returned by a hook. Hook is the name of the hook, like
+	// "OnMessageReceivedHook", or the kind of hook called for a message type, a status or a webhook
+	// field, like "message:text", "status:read" or "flows". ID is the ID of the message or the
+	// status, if any. Kind is the sentinel of the hook, like ErrOnMessageStatusChangeHook, if any,
+	// and Err the error returned by the hook, as is.
+	HookError struct {
+		Hook string
+		ID   string
+		Kind error
+		Err  error
+	}
+
+	// HookErrors are the errors of all the hooks called for a notification. A hook that fails
+	// does not stop the hooks that follow, their errors are collected and passed together to the
+	// HooksErrorHandler, once per change, message or status. errors.Is and errors.As look into
+	// each of them.
+	HookErrors []*HookError
+)
+
+func (e *HookError) Error() string {
+	message := e.Err.Error()
+	if e.Kind != nil {
+		message = fmt.Sprintf("%v: %s", e.Kind, message)
+	}
+	if e.ID != "" {
+		return fmt.Sprintf("%s %s: %s", e.Hook, e.ID, message)
+	}
+
+	return fmt.Sprintf("%s: %s", e.Hook, message)
+}
+
+func (e *HookError) Unwrap() error {
+	return e.Err
+}
+
+// Is reports whether the target is the Kind of the error.
+func (e *HookError) Is(target error) bool {
+	return e.Kind != nil && e.Kind == target
+}
+
+func (errs HookErrors) Error() string {
+	messages := make([]string, len(errs))
+	for i, err := range errs {
+		messages[i] = err.Error()
+	}
+
+	return strings.Join(messages, "; ")
+}
+
+// Unwrap returns the errors of the hooks.
+func (errs HookErrors) Unwrap() []error {
+	unwrapped := make([]error, len(errs))
+	for i, err := range errs {
+		unwrapped[i] = err
+	}
+
+	return unwrapped
+}
+
+// Is reports whether the error of any hook matches the target.
+func (errs HookErrors) Is(target error) bool {
+	for _, err := range errs {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// As finds the first error of the hooks that matches the target.
+func (errs HookErrors) As(target any) bool {
+	for _, err := range errs {
+		if errors.As(err, target) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// newHookErrors returns err as the HookErrors of the hook, nil when err is nil. HookErrors are
+// returned as is, the hook and the ID filling the entries without them.
+func newHookErrors(hook, id string, err error) HookErrors {
+	return newKindHookErrors(hook, id, nil, err)
+}
+
+// newKindHookErrors is newHookErrors with the sentinel of the hook set as the Kind of the errors.
+func newKindHookErrors(hook, id string, kind, err error) HookErrors {
+	if err == nil {
+		return nil
+	}
+	var errs HookErrors
+	if !errors.As(err, &errs) {
+		return HookErrors{{Hook: hook, ID: id, Kind: kind, Err: err}}
+	}
+	for _, e := range errs {
+		if e.Hook == "" {
+			e.Hook = hook
+		}
+		if e.ID == "" {
+			e.ID = id
+		}
+		if e.Kind == nil {
+			e.Kind = kind
+		}
+	}
+
+	return errs
+}
+
+// hookErrors collects the errors of the hooks called for a notification.
+type hookErrors struct {
+	heh  HooksErrorHandler
+	errs HookErrors
+}
+
+// handle passes the errors of the hooks called for one change, message or status to the
+// HooksErrorHandler and keeps them. It reports whether the handler deemed them fatal.
+func (c *hookErrors) handle(errs HookErrors) bool {
+	if len(errs) == 0 {
+		return false
+	}
+	c.errs = append(c.errs, errs...)
+
+	return IsFatalError(c.heh(errs))
+}
+
+// err returns the collected errors, nil when there are none.
+func (c *hookErrors) err() error {
+	if len(c.errs) == 0 {
+		return nil
+	}
+
+	return c.errs
+}
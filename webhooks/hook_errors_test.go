@@ -0,0 +1,168 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package webhooks
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func hookErrorsNotification() *Notification {
+	text := func(id string) *Message {
+		return &Message{From: "255", ID: id, Type: "text", Text: &Text{Body: "hi"}}
+	}
+
+	return &Notification{
+		Object: "whatsapp_business_account",
+		Entry: []*Entry{
+			{ID: "1", Changes: []*Change{{Field: MessagesField, Value: &Value{
+				Messages: []*Message{text("wamid.1"), text("wamid.2")},
+			}}}},
+			{ID: "2", Changes: []*Change{{Field: MessagesField, Value: &Value{
+				Messages: []*Message{text("wamid.3")},
+			}}}},
+		},
+	}
+}
+
+func TestAttachHooksToNotification_HookErrors(t *testing.T) {
+	t.Parallel()
+	errUnavailable := errors.New("crm unavailable")
+	var texts []string
+	hooks := &Hooks{
+		OnMessageReceivedHook: func(ctx context.Context, nctx *NotificationContext, message *Message) error {
+			if message.ID == "wamid.1" {
+				return errUnavailable
+			}
+
+			return nil
+		},
+		OnTextMessageHook: func(ctx context.Context, nctx *NotificationContext, mctx *MessageContext,
+			text *Text,
+		) error {
+			texts = append(texts, mctx.ID)
+			if mctx.ID == "wamid.3" {
+				return NewFatalError(errUnavailable, "text hook")
+			}
+
+			return errors.New("no reply")
+		},
+	}
+	var handled []HookErrors
+	heh := func(err error) error {
+		var errs HookErrors
+		if !errors.As(err, &errs) {
+			t.Fatalf("the hooks error handler got %T, want HookErrors", err)
+		}
+		handled = append(handled, errs)
+
+		return err
+	}
+
+	err := AttachHooksToNotification(context.TODO(), hookErrorsNotification(), hooks, heh)
+
+	if len(texts) != 3 {
+		t.Errorf("got text hook calls %v, want the 3 messages", texts)
+	}
+	if len(handled) != 3 {
+		t.Fatalf("got %d calls of the hooks error handler, want one per message", len(handled))
+	}
+	first := handled[0]
+	if len(first) != 2 || first[0].Hook != "OnMessageReceivedHook" || first[0].ID != "wamid.1" ||
+		first[1].Hook != "message:text" || first[1].ID != "wamid.1" {
+		t.Errorf("got errors of the first message %v, want the received and the text hooks", first)
+	}
+	var errs HookErrors
+	if !errors.As(err, &errs) || len(errs) != 4 {
+		t.Fatalf("got error %v, want the 4 errors of the hooks", err)
+	}
+	if !errors.Is(err, errUnavailable) || !IsFatalError(err) {
+		t.Errorf("got error %v, want it to match the errors of the hooks", err)
+	}
+}
+
+func TestAttachHooksToNotification_FatalHookError(t *testing.T) {
+	t.Parallel()
+	var texts int
+	hooks := &Hooks{
+		OnTextMessageHook: func(ctx context.Context, nctx *NotificationContext, mctx *MessageContext,
+			text *Text,
+		) error {
+			texts++
+
+			return NewFatalError(errors.New("database down"), "text hook")
+		},
+	}
+
+	err := AttachHooksToNotification(context.TODO(), hookErrorsNotification(), hooks, NoOpHooksErrorHandler)
+
+	if texts != 1 {
+		t.Errorf("got %d text hook calls, want the hooks to stop at the fatal error", texts)
+	}
+	if !IsFatalError(err) {
+		t.Errorf("got error %v, want a fatal error", err)
+	}
+}
+
+func TestAttachHooksToNotification_FatalStatusHookError(t *testing.T) {
+	t.Parallel()
+	errDown := errors.New("database down")
+	var calls int
+	hooks := &Hooks{
+		OnMessageStatusChangeHook: func(ctx context.Context, nctx *NotificationContext, status *Status) error {
+			calls++
+
+			return NewFatalError(errDown, "status hook")
+		},
+	}
+	notification := &Notification{
+		Object: "whatsapp_business_account",
+		Entry: []*Entry{{ID: "1", Changes: []*Change{{Field: MessagesField, Value: &Value{
+			Statuses: []*Status{{ID: "wamid.1", StatusValue: "sent"}, {ID: "wamid.2", StatusValue: "read"}},
+		}}}}},
+	}
+
+	err := AttachHooksToNotification(context.TODO(), notification, hooks, func(err error) error { return err })
+
+	if calls != 1 {
+		t.Errorf("got %d status hook calls, want the hooks to stop at the fatal error", calls)
+	}
+	if !IsFatalError(err) {
+		t.Errorf("got error %v, want a fatal error", err)
+	}
+	if !errors.Is(err, errDown) || !errors.Is(err, ErrOnMessageStatusChangeHook) {
+		t.Errorf("got error %v, want it to match %v and %v", err, errDown, ErrOnMessageStatusChangeHook)
+	}
+}
+
+func TestHookErrors_Error(t *testing.T) {
+	t.Parallel()
+	errs := HookErrors{
+		{Hook: "OnMessageReceivedHook", ID: "wamid.1", Err: errors.New("a")},
+		{Hook: "flows", Err: errors.New("b")},
+		{Hook: "OnMessageStatusChangeHook", ID: "wamid.2", Kind: ErrOnMessageStatusChangeHook, Err: errors.New("c")},
+	}
+	want := "OnMessageReceivedHook wamid.1: a; flows: b; " +
+		"OnMessageStatusChangeHook wamid.2: on message status change hook error: c"
+	if got := errs.Error(); got != want {
+		t.Errorf("Error() = %q, want %q", got, want)
+	}
+}
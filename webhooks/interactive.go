@@ -0,0 +1,109 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package webhooks
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+var ErrOnFlowReplyHook = errors.New("on flow reply hook error")
+
+type (
+	// NfmReply is the response to a Flow, sent when the user completes it. ResponseJSON is the
+	// JSON object of the values the Flow collected, with the flow_token it was sent with. Name is
+	// "flow" and Body is "Sent".
+	NfmReply struct {
+		Name         string `json:"name,omitempty"`
+		Body         string `json:"body,omitempty"`
+		ResponseJSON string `json:"response_json,omitempty"`
+	}
+
+	// FlowReply is the response to a Flow with the response_json decoded.
+	FlowReply struct {
+		Name     string
+		Body     string
+		Response map[string]any
+	}
+
+	// OnButtonReplyHook is called for the replies to the reply buttons of interactive messages.
+	OnButtonReplyHook func(
+		ctx context.Context, nctx *NotificationContext, mctx *MessageContext, reply *ButtonReply) error
+
+	// OnListReplyHook is called for the rows chosen in the lists of interactive messages.
+	OnListReplyHook func(
+		ctx context.Context, nctx *NotificationContext, mctx *MessageContext, reply *ListReply) error
+
+	// OnFlowReplyHook is called for the responses to Flows. A response_json that is not a JSON
+	// object is returned as an error and the hook is not called.
+	OnFlowReplyHook func(
+		ctx context.Context, nctx *NotificationContext, mctx *MessageContext, reply *FlowReply) error
+)
+
+// Decode decodes the response_json into v.
+func (reply *NfmReply) Decode(v any) error {
+	if reply == nil || reply.ResponseJSON == "" {
+		return fmt.Errorf("decode flow response: empty response_json")
+	}
+	if err := json.Unmarshal([]byte(reply.ResponseJSON), v); err != nil {
+		return fmt.Errorf("decode flow response: %v", err)
+	}
+
+	return nil
+}
+
+// FlowToken returns the flow_token the Flow was sent with, empty when the response has none.
+func (reply *FlowReply) FlowToken() string {
+	if reply == nil {
+		return ""
+	}
+	token, _ := reply.Response["flow_token"].(string)
+
+	return token
+}
+
+// attachHooksToInteractiveReply calls the hook of the type of the reply, it reports false when
+// the hook is not set, so that the reply goes to the OnInteractiveMessageHook.
+func attachHooksToInteractiveReply(ctx context.Context, nctx *NotificationContext, mctx *MessageContext,
+	interactive *Interactive, hooks *Hooks,
+) (bool, error) {
+	if interactive == nil || interactive.Type == nil {
+		return false, nil
+	}
+	reply := interactive.Type
+
+	switch {
+	case reply.ButtonReply != nil && hooks.OnButtonReplyHook != nil:
+		return true, hooks.OnButtonReplyHook(ctx, nctx, mctx, reply.ButtonReply)
+	case reply.ListReply != nil && hooks.OnListReplyHook != nil:
+		return true, hooks.OnListReplyHook(ctx, nctx, mctx, reply.ListReply)
+	case reply.NfmReply != nil && hooks.OnFlowReplyHook != nil:
+		flow := &FlowReply{Name: reply.NfmReply.Name, Body: reply.NfmReply.Body}
+		if err := reply.NfmReply.Decode(&flow.Response); err != nil {
+			return true, fmt.Errorf("%v: %v", ErrOnFlowReplyHook, err)
+		}
+
+		return true, hooks.OnFlowReplyHook(ctx, nctx, mctx, flow)
+	default:
+		return false, nil
+	}
+}
@@ -0,0 +1,139 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package webhooks
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+const interactiveRepliesPayload = `{
+  "object": "whatsapp_business_account",
+  "entry": [{
+    "id": "102290129340398",
+    "changes": [{
+      "field": "messages",
+      "value": {
+        "messaging_product": "whatsapp",
+        "metadata": {"display_phone_number": "15550783881", "phone_number_id": "106540352242922"},
+        "messages": [
+          {"from": "16505551234", "id": "wamid.1", "timestamp": "1750263773", "type": "interactive",
+           "interactive": {"type": "button_reply", "button_reply": {"id": "yes", "title": "Yes"}}},
+          {"from": "16505551234", "id": "wamid.2", "timestamp": "1750263774", "type": "interactive",
+           "interactive": {"type": "list_reply", "list_reply": {"id": "row.1", "title": "Pickup", "description": "At the store"}}},
+          {"from": "16505551234", "id": "wamid.3", "timestamp": "1750263775", "type": "interactive",
+           "interactive": {"type": "nfm_reply", "nfm_reply": {"name": "flow", "body": "Sent",
+             "response_json": "{\"flow_token\": \"token.1\", \"date\": \"2026-10-20\", \"guests\": 2}"}}}
+        ]
+      }
+    }]
+  }]
+}`
+
+func TestAttachHooksToNotification_InteractiveReplies(t *testing.T) {
+	t.Parallel()
+	var notification Notification
+	if err := json.Unmarshal([]byte(interactiveRepliesPayload), &notification); err != nil {
+		t.Fatalf("json.Unmarshal() error = %v", err)
+	}
+
+	var (
+		generic []string
+		button  *ButtonReply
+		list    *ListReply
+		flow    *FlowReply
+	)
+	listener := NewEventListener()
+	listener.OnInteractiveMessage(func(ctx context.Context, nctx *NotificationContext, mctx *MessageContext,
+		interactive *Interactive,
+	) error {
+		generic = append(generic, string(interactive.ReplyType))
+
+		return nil
+	})
+	listener.OnButtonReply(func(ctx context.Context, nctx *NotificationContext, mctx *MessageContext,
+		reply *ButtonReply,
+	) error {
+		button = reply
+
+		return nil
+	})
+	listener.OnFlowReply(func(ctx context.Context, nctx *NotificationContext, mctx *MessageContext,
+		reply *FlowReply,
+	) error {
+		flow = reply
+
+		return nil
+	})
+
+	if err := AttachHooksToNotification(context.TODO(), &notification, listener.h, NoOpHooksErrorHandler); err != nil {
+		t.Fatalf("AttachHooksToNotification() error = %v", err)
+	}
+	if button == nil || button.ID != "yes" {
+		t.Errorf("got button reply %+v", button)
+	}
+	if len(generic) != 1 || generic[0] != string(InteractiveListReply) {
+		t.Errorf("got generic replies %v, want only the list reply without its hook", generic)
+	}
+	if flow == nil || flow.FlowToken() != "token.1" || flow.Response["date"] != "2026-10-20" ||
+		flow.Response["guests"] != float64(2) || flow.Name != "flow" {
+		t.Errorf("got flow reply %+v", flow)
+	}
+
+	listener.OnListReply(func(ctx context.Context, nctx *NotificationContext, mctx *MessageContext,
+		reply *ListReply,
+	) error {
+		list = reply
+
+		return nil
+	})
+	generic = nil
+	if err := AttachHooksToNotification(context.TODO(), &notification, listener.h, NoOpHooksErrorHandler); err != nil {
+		t.Fatalf("AttachHooksToNotification() error = %v", err)
+	}
+	if list == nil || list.Description != "At the store" || len(generic) != 0 {
+		t.Errorf("got list reply %+v and generic replies %v", list, generic)
+	}
+}
+
+func TestNfmReply_Decode(t *testing.T) {
+	t.Parallel()
+	reply := &NfmReply{ResponseJSON: `{"flow_token": "token.1", "guests": 2}`}
+	var booking struct {
+		FlowToken string `json:"flow_token"`
+		Guests    int    `json:"guests"`
+	}
+	if err := reply.Decode(&booking); err != nil || booking.FlowToken != "token.1" || booking.Guests != 2 {
+		t.Errorf("Decode() = %+v, %v", booking, err)
+	}
+
+	hooks := &Hooks{OnFlowReplyHook: func(ctx context.Context, nctx *NotificationContext, mctx *MessageContext,
+		reply *FlowReply,
+	) error {
+		return nil
+	}}
+	interactive := &Interactive{Type: &InteractiveType{NfmReply: &NfmReply{ResponseJSON: `["not", "an", "object"]`}}}
+	handled, err := attachHooksToInteractiveReply(context.TODO(), &NotificationContext{}, &MessageContext{}, interactive, hooks)
+	if !handled || err == nil || !strings.Contains(err.Error(), ErrOnFlowReplyHook.Error()) {
+		t.Errorf("attachHooksToInteractiveReply() = %v, %v, want the decoding error", handled, err)
+	}
+}
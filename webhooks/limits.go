@@ -0,0 +1,159 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package webhooks
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	whttp "github.com/lowkruc/go-whatsapp-api/http"
+)
+
+var (
+	ErrBodyTooLarge    = errors.New("notification body is too large")
+	ErrBodyReadTimeout = errors.New("notification body read timed out")
+)
+
+// ReadBody reads the body of the request within the MaxBodySize and the ReadTimeout of the options.
+// It returns ErrBodyTooLarge and ErrBodyReadTimeout as is, the other errors are read errors. The
+// middlewares that read the body before the handler use it to keep the limits, and pass the error
+// on in the body they give to the handler.
+func ReadBody(w http.ResponseWriter, request *http.Request, options *HandlerOptions) ([]byte, error) {
+	body := request.Body
+	var timeout time.Duration
+	if options != nil {
+		if options.MaxBodySize > 0 {
+			body = http.MaxBytesReader(w, body, options.MaxBodySize)
+		}
+		timeout = options.ReadTimeout
+	}
+
+	read := func() ([]byte, error) {
+		var buff bytes.Buffer
+		_, err := io.Copy(&buff, body)
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			return nil, ErrBodyTooLarge
+		}
+		if err != nil && !errors.Is(err, io.EOF) {
+			return nil, err
+		}
+
+		return buff.Bytes(), nil
+	}
+	if timeout <= 0 {
+		return read()
+	}
+
+	type result struct {
+		body []byte
+		err  error
+	}
+	done := make(chan result, 1)
+	go func() {
+		body, err := read()
+		done <- result{body: body, err: err}
+	}()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case r := <-done:
+		return r.body, r.err
+	case <-timer.C:
+		// the read goroutine ends when the server closes the connection, see http.Server.ReadTimeout.
+		_ = request.Body.Close()
+
+		return nil, ErrBodyReadTimeout
+	}
+}
+
+// decodeBody decompresses the body within the MaxBodySize of the options, so that a compressed
+// body cannot expand past the limit, it stops reading and returns ErrBodyTooLarge as soon as the
+// limit is crossed.
+func decodeBody(contentEncoding string, body []byte, options *HandlerOptions) ([]byte, error) {
+	var maxSize int64
+	if options != nil {
+		maxSize = options.MaxBodySize
+	}
+	decoded, err := whttp.DecodeContentEncoding(contentEncoding, body, maxSize)
+	if errors.Is(err, whttp.ErrDecodedBodyTooLarge) {
+		return nil, ErrBodyTooLarge
+	}
+
+	return decoded, err
+}
+
+// readNotification reads the body of the request and decompresses it when it has a Content-Encoding,
+// it returns the body as received and the decoded one. It reports false, after writing the response,
+// when the body is unusable.
+func readNotification(writer *responseWriter, request *http.Request, neh NotificationErrorHandler,
+	options *HandlerOptions,
+) ([]byte, []byte, bool) {
+	raw, err := ReadBody(writer.ResponseWriter, request, options)
+	if err != nil {
+		handleBodyError(writer, request, neh, options, err)
+
+		return nil, nil, false
+	}
+
+	contentEncoding := request.Header.Get("Content-Encoding")
+	if !whttp.IsEncoded(contentEncoding) {
+		return raw, raw, true
+	}
+
+	body, err := decodeBody(contentEncoding, raw, options)
+	if err != nil {
+		if errors.Is(err, ErrBodyTooLarge) {
+			handleBodyError(writer, request, neh, options, err)
+		} else {
+			writer.failure(options, http.StatusBadRequest)
+		}
+
+		return nil, nil, false
+	}
+
+	return raw, body, true
+}
+
+// handleBodyError passes the errors of ReadBody to the NotificationErrorHandler, the body is
+// unusable so the handling stops even when the error is skipped.
+func handleBodyError(writer *responseWriter, request *http.Request, neh NotificationErrorHandler,
+	options *HandlerOptions, err error,
+) {
+	code := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, ErrBodyTooLarge):
+		code = http.StatusRequestEntityTooLarge
+	case errors.Is(err, ErrBodyReadTimeout):
+		code = http.StatusRequestTimeout
+	default:
+		writer.failure(options, code)
+
+		return
+	}
+
+	if !handleError(request.Context(), writer, request, neh, err) {
+		writer.failure(options, code)
+	}
+}
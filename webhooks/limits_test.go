@@ -0,0 +1,194 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package webhooks
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"testing"
+	"time"
+)
+
+const limitsPayload = `{"object":"whatsapp_business_account","entry":[{"id":"1","changes":[{"field":"messages",` +
+	`"value":{"messaging_product":"whatsapp","messages":[{"from":"255","id":"wamid.1","type":"text",` +
+	`"text":{"body":"hi"}}]}}]}]}`
+
+func TestNotificationHandler_MaxBodySize(t *testing.T) {
+	t.Parallel()
+	var compressed bytes.Buffer
+	zw := gzip.NewWriter(&compressed)
+	_, _ = zw.Write(bytes.Repeat([]byte(" "), 1<<20))
+	_ = zw.Close()
+
+	tests := []struct {
+		name     string
+		body     []byte
+		encoding string
+		skip     bool
+		wantErr  error
+		wantCode int
+	}{
+		{name: "within the limit", body: []byte(limitsPayload), wantCode: http.StatusOK},
+		{
+			name: "oversized body", body: bytes.Repeat([]byte(" "), 4096),
+			wantErr: ErrBodyTooLarge, wantCode: http.StatusTeapot,
+		},
+		{
+			name: "oversized body skipped", body: bytes.Repeat([]byte(" "), 4096), skip: true,
+			wantErr: ErrBodyTooLarge, wantCode: http.StatusRequestEntityTooLarge,
+		},
+		{
+			name: "oversized decompressed body", body: compressed.Bytes(), encoding: "gzip",
+			wantErr: ErrBodyTooLarge, wantCode: http.StatusTeapot,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			var got error
+			neh := func(ctx context.Context, r *http.Request, err error) *NotificationErrHandlerResponse {
+				got = err
+
+				return &NotificationErrHandlerResponse{StatusCode: http.StatusTeapot, Skip: tt.skip}
+			}
+			handler := NotificationHandler(&Hooks{}, neh, NoOpHooksErrorHandler, &HandlerOptions{
+				MaxBodySize: 1024,
+			})
+
+			request := httptest.NewRequest(http.MethodPost, "/webhooks", bytes.NewReader(tt.body))
+			if tt.encoding != "" {
+				request.Header.Set("Content-Encoding", tt.encoding)
+			}
+			recorder := httptest.NewRecorder()
+			handler.ServeHTTP(recorder, request)
+
+			if !errors.Is(got, tt.wantErr) {
+				t.Errorf("got error %v, want %v", got, tt.wantErr)
+			}
+			if recorder.Code != tt.wantCode {
+				t.Errorf("got status %d, want %d", recorder.Code, tt.wantCode)
+			}
+		})
+	}
+}
+
+// TestNotificationHandler_GzipBomb is not parallel, it measures the memory allocated while the
+// body is handled.
+func TestNotificationHandler_GzipBomb(t *testing.T) {
+	// 64 MiB of zeros compress to less than 100 KiB, within the limit of the compressed body.
+	var compressed bytes.Buffer
+	zw, _ := gzip.NewWriterLevel(&compressed, gzip.BestCompression)
+	chunk := make([]byte, 1<<20)
+	for i := 0; i < 64; i++ {
+		_, _ = zw.Write(chunk)
+	}
+	_ = zw.Close()
+
+	var got error
+	neh := func(ctx context.Context, r *http.Request, err error) *NotificationErrHandlerResponse {
+		got = err
+
+		return &NotificationErrHandlerResponse{StatusCode: http.StatusRequestEntityTooLarge}
+	}
+	handler := NotificationHandler(&Hooks{}, neh, NoOpHooksErrorHandler, &HandlerOptions{
+		MaxBodySize: 1 << 20,
+	})
+	request := httptest.NewRequest(http.MethodPost, "/webhooks", bytes.NewReader(compressed.Bytes()))
+	request.Header.Set("Content-Encoding", "gzip")
+	recorder := httptest.NewRecorder()
+
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	handler.ServeHTTP(recorder, request)
+	runtime.ReadMemStats(&after)
+
+	if !errors.Is(got, ErrBodyTooLarge) || recorder.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("got error %v and status %d, want %v and 413", got, recorder.Code, ErrBodyTooLarge)
+	}
+	if allocated := after.TotalAlloc - before.TotalAlloc; allocated > 16<<20 {
+		t.Errorf("allocated %d bytes decoding the body, want the decompression to stop at the limit", allocated)
+	}
+}
+
+func TestNotificationHandler_ReadTimeout(t *testing.T) {
+	t.Parallel()
+	var got error
+	neh := func(ctx context.Context, r *http.Request, err error) *NotificationErrHandlerResponse {
+		got = err
+
+		return &NotificationErrHandlerResponse{Skip: true}
+	}
+	handler := NotificationHandler(&Hooks{}, neh, NoOpHooksErrorHandler, &HandlerOptions{
+		ReadTimeout: 20 * time.Millisecond,
+	})
+
+	// the sender never writes the body.
+	pr, pw := io.Pipe()
+	defer pw.Close()
+	request := httptest.NewRequest(http.MethodPost, "/webhooks", pr)
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, request)
+
+	if !errors.Is(got, ErrBodyReadTimeout) {
+		t.Errorf("got error %v, want %v", got, ErrBodyReadTimeout)
+	}
+	if recorder.Code != http.StatusRequestTimeout {
+		t.Errorf("got status %d, want %d", recorder.Code, http.StatusRequestTimeout)
+	}
+}
+
+func TestNotificationHandler_ResponseDeadline(t *testing.T) {
+	t.Parallel()
+	var (
+		deadline time.Time
+		ok       bool
+	)
+	hooks := &Hooks{
+		OnTextMessageHook: func(ctx context.Context, nctx *NotificationContext, mctx *MessageContext,
+			text *Text,
+		) error {
+			deadline, ok = ctx.Deadline()
+
+			return nil
+		},
+	}
+	handler := NotificationHandler(hooks, NoOpNotificationErrorHandler, NoOpHooksErrorHandler, &HandlerOptions{
+		ResponseDeadline: time.Minute,
+	})
+
+	start := time.Now()
+	request := httptest.NewRequest(http.MethodPost, "/webhooks", bytes.NewReader([]byte(limitsPayload)))
+	handler.ServeHTTP(httptest.NewRecorder(), request)
+
+	if !ok {
+		t.Fatal("the context of the hooks has no deadline")
+	}
+	if deadline.Before(start) || deadline.After(start.Add(2*time.Minute)) {
+		t.Errorf("got deadline %v, want about a minute after %v", deadline, start)
+	}
+}
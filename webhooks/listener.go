@@ -21,11 +21,16 @@ package webhooks
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"time"
+
+	"github.com/lowkruc/go-whatsapp-api/lifecycle"
+	"github.com/lowkruc/go-whatsapp-api/report"
 )
 
 // EventListener wraps all the parts needed to listen and respond to incoming events
@@ -73,6 +78,8 @@ type EventListener struct {
 	v       SubscriptionVerifier
 	options *HandlerOptions
 	g       GlobalNotificationHandler
+	r       *routes
+	bus     *lifecycle.Bus
 }
 
 type ListenerOption func(*EventListener)
@@ -95,6 +102,7 @@ func NewEventListener(options ...ListenerOption) *EventListener {
 	for _, option := range options {
 		option(listener)
 	}
+	listener.bus.Emit(LifecycleComponent, lifecycle.Starting, nil)
 
 	return listener
 }
@@ -150,6 +158,14 @@ func (ls *EventListener) OnMessageReaction(hook OnMessageReactionHook) {
 	ls.h.OnMessageReactionHook = hook
 }
 
+// OnReactionRemoved sets the hook called when a user removes their reaction to a message.
+func (ls *EventListener) OnReactionRemoved(hook OnReactionRemovedHook) {
+	if ls.h == nil {
+		ls.h = &Hooks{}
+	}
+	ls.h.OnReactionRemovedHook = hook
+}
+
 func (ls *EventListener) OnUnknownMessage(hook OnUnknownMessageHook) {
 	if ls.h == nil {
 		ls.h = &Hooks{}
@@ -213,6 +229,87 @@ func (ls *EventListener) OnMediaMessage(hook OnMediaMessageHook) {
 	ls.h.OnMediaMessageHook = hook
 }
 
+// OnStickerMessage sets the hook of the sticker messages.
+func (ls *EventListener) OnStickerMessage(hook OnStickerMessageHook) {
+	if ls.h == nil {
+		ls.h = &Hooks{}
+	}
+	ls.h.OnStickerMessageHook = hook
+}
+
+// OnButtonReply sets the hook of the replies to the reply buttons of interactive messages.
+func (ls *EventListener) OnButtonReply(hook OnButtonReplyHook) {
+	if ls.h == nil {
+		ls.h = &Hooks{}
+	}
+	ls.h.OnButtonReplyHook = hook
+}
+
+// OnListReply sets the hook of the rows chosen in the lists of interactive messages.
+func (ls *EventListener) OnListReply(hook OnListReplyHook) {
+	if ls.h == nil {
+		ls.h = &Hooks{}
+	}
+	ls.h.OnListReplyHook = hook
+}
+
+// OnFlowReply sets the hook of the responses to Flows.
+func (ls *EventListener) OnFlowReply(hook OnFlowReplyHook) {
+	if ls.h == nil {
+		ls.h = &Hooks{}
+	}
+	ls.h.OnFlowReplyHook = hook
+}
+
+// OnLiveLocationUpdate sets the hook of the updates of the live locations shared by the users.
+func (ls *EventListener) OnLiveLocationUpdate(hook OnLiveLocationUpdateHook) {
+	if ls.h == nil {
+		ls.h = &Hooks{}
+	}
+	ls.h.OnLiveLocationUpdateHook = hook
+}
+
+// OnNumberChange sets the hook of the customers changing their phone number.
+func (ls *EventListener) OnNumberChange(hook OnNumberChangeHook) {
+	if ls.h == nil {
+		ls.h = &Hooks{}
+	}
+	ls.h.OnNumberChangeHook = hook
+}
+
+// OnIdentityChange sets the hook of the changes of the customers identity.
+func (ls *EventListener) OnIdentityChange(hook OnIdentityChangeHook) {
+	if ls.h == nil {
+		ls.h = &Hooks{}
+	}
+	ls.h.OnIdentityChangeHook = hook
+}
+
+// OnReplyToBusinessMessage sets the hook of the messages the users send in reply to a message of
+// the business.
+func (ls *EventListener) OnReplyToBusinessMessage(hook OnReplyToBusinessMessageHook) {
+	if ls.h == nil {
+		ls.h = &Hooks{}
+	}
+	ls.h.OnReplyToBusinessMessageHook = hook
+}
+
+// OnAdReferral sets the hook of the messages sent from click-to-WhatsApp ads and posts.
+func (ls *EventListener) OnAdReferral(hook OnAdReferralHook) {
+	if ls.h == nil {
+		ls.h = &Hooks{}
+	}
+	ls.h.OnAdReferralHook = hook
+}
+
+// OnVoiceNote sets the hook of the voice notes, the audio messages recorded in the chat.
+func (ls *EventListener) OnVoiceNote(hook OnVoiceNoteHook) {
+	if ls.h == nil {
+		ls.h = &Hooks{}
+	}
+	ls.h.OnVoiceNoteHook = hook
+}
+
 func (ls *EventListener) OnNotificationError(hook OnNotificationErrorHook) {
 	if ls.h == nil {
 		ls.h = &Hooks{}
@@ -234,6 +331,150 @@ func (ls *EventListener) OnMessageReceived(hook OnMessageReceivedHook) {
 	ls.h.OnMessageReceivedHook = hook
 }
 
+func (ls *EventListener) OnMessageSent(hook OnMessageSentHook) {
+	if ls.h == nil {
+		ls.h = &Hooks{}
+	}
+	ls.h.OnMessageSentHook = hook
+}
+
+func (ls *EventListener) OnMessageDelivered(hook OnMessageDeliveredHook) {
+	if ls.h == nil {
+		ls.h = &Hooks{}
+	}
+	ls.h.OnMessageDeliveredHook = hook
+}
+
+func (ls *EventListener) OnMessageRead(hook OnMessageReadHook) {
+	if ls.h == nil {
+		ls.h = &Hooks{}
+	}
+	ls.h.OnMessageReadHook = hook
+}
+
+func (ls *EventListener) OnMessageFailed(hook OnMessageFailedHook) {
+	if ls.h == nil {
+		ls.h = &Hooks{}
+	}
+	ls.h.OnMessageFailedHook = hook
+}
+
+func (ls *EventListener) OnHandover(hook OnHandoverHook) {
+	if ls.h == nil {
+		ls.h = &Hooks{}
+	}
+	ls.h.OnHandoverHook = hook
+}
+
+func (ls *EventListener) OnPartnerSolution(hook OnPartnerSolutionHook) {
+	if ls.h == nil {
+		ls.h = &Hooks{}
+	}
+	ls.h.OnPartnerSolutionHook = hook
+}
+
+func (ls *EventListener) OnTemplateStatusUpdate(hook OnTemplateStatusUpdateHook) {
+	if ls.h == nil {
+		ls.h = &Hooks{}
+	}
+	ls.h.OnTemplateStatusUpdateHook = hook
+}
+
+func (ls *EventListener) OnTemplateCategoryUpdate(hook OnTemplateCategoryUpdateHook) {
+	if ls.h == nil {
+		ls.h = &Hooks{}
+	}
+	ls.h.OnTemplateCategoryUpdateHook = hook
+}
+
+func (ls *EventListener) OnPhoneNumberQualityUpdate(hook OnPhoneNumberQualityUpdateHook) {
+	if ls.h == nil {
+		ls.h = &Hooks{}
+	}
+	ls.h.OnPhoneNumberQualityUpdateHook = hook
+}
+
+func (ls *EventListener) OnAccountUpdate(hook OnAccountUpdateHook) {
+	if ls.h == nil {
+		ls.h = &Hooks{}
+	}
+	ls.h.OnAccountUpdateHook = hook
+}
+
+// OnPartnerOnboarding sets the hook of the partner events of the account_update changes, the
+// accounts onboarded, migrated to a solution or removed.
+func (ls *EventListener) OnPartnerOnboarding(hook OnPartnerOnboardingHook) {
+	if ls.h == nil {
+		ls.h = &Hooks{}
+	}
+	ls.h.OnPartnerOnboardingHook = hook
+}
+
+func (ls *EventListener) OnAccountReviewUpdate(hook OnAccountReviewUpdateHook) {
+	if ls.h == nil {
+		ls.h = &Hooks{}
+	}
+	ls.h.OnAccountReviewUpdateHook = hook
+}
+
+func (ls *EventListener) OnBusinessCapabilityUpdate(hook OnBusinessCapabilityUpdateHook) {
+	if ls.h == nil {
+		ls.h = &Hooks{}
+	}
+	ls.h.OnBusinessCapabilityUpdateHook = hook
+}
+
+func (ls *EventListener) OnSecurityEvent(hook OnSecurityEventHook) {
+	if ls.h == nil {
+		ls.h = &Hooks{}
+	}
+	ls.h.OnSecurityEventHook = hook
+}
+
+func (ls *EventListener) OnFlowEvent(hook OnFlowEventHook) {
+	if ls.h == nil {
+		ls.h = &Hooks{}
+	}
+	ls.h.OnFlowEventHook = hook
+}
+
+func (ls *EventListener) OnCallEvent(hook OnCallEventHook) {
+	if ls.h == nil {
+		ls.h = &Hooks{}
+	}
+	ls.h.OnCallEventHook = hook
+}
+
+// OnCallPermissionReply sets the hook of the replies of the users to call permission requests.
+// Without it, the replies go to the OnInteractiveMessageHook.
+func (ls *EventListener) OnCallPermissionReply(hook OnCallPermissionReplyHook) {
+	if ls.h == nil {
+		ls.h = &Hooks{}
+	}
+	ls.h.OnCallPermissionReplyHook = hook
+}
+
+func (ls *EventListener) OnUserPreferences(hook OnUserPreferencesHook) {
+	if ls.h == nil {
+		ls.h = &Hooks{}
+	}
+	ls.h.OnUserPreferencesHook = hook
+}
+
+func (ls *EventListener) OnMessageEcho(hook OnMessageEchoHook) {
+	if ls.h == nil {
+		ls.h = &Hooks{}
+	}
+	ls.h.OnMessageEchoHook = hook
+}
+
+func (ls *EventListener) OnPaymentStatus(hook OnPaymentStatusHook) {
+	if ls.h == nil {
+		ls.h = &Hooks{}
+	}
+	ls.h.OnPaymentStatusHook = hook
+}
+
 func WithGlobalNotificationHandler(g GlobalNotificationHandler) ListenerOption {
 	return func(ls *EventListener) {
 		ls.g = g
@@ -258,6 +499,8 @@ func WithNotificationErrorHandler(notificationErrorHandler NotificationErrorHand
 	}
 }
 
+// WithSubscriptionVerifier sets the verifier of SubscriptionVerificationHandler, TokenVerifier
+// checks the verify token set in the App Dashboard.
 func WithSubscriptionVerifier(verifier SubscriptionVerifier) ListenerOption {
 	return func(ls *EventListener) {
 		ls.v = verifier
@@ -288,32 +531,44 @@ func WithAfterFunc(afterFunc AfterFunc) ListenerOption {
 	}
 }
 
+// WithSuccessResponse sets the response written when a notification has been handled.
+func WithSuccessResponse(response *Response) ListenerOption {
+	return func(ls *EventListener) {
+		if ls.options == nil {
+			ls.options = &HandlerOptions{}
+		}
+		ls.options.SuccessResponse = response
+	}
+}
+
 // NotificationHandler returns a http.Handler that can be used to handle the notification.
 func (ls *EventListener) NotificationHandler() http.Handler {
-	return NotificationHandler(ls.h, ls.neh, ls.hef, ls.options)
+	handler := NotificationHandler(ls.h, ls.neh, ls.hef, ls.options)
+	ls.bus.Emit(LifecycleComponent, lifecycle.Ready, nil)
+
+	return handler
 }
 
 // GlobalHandler returns a http.Handler that handles all type of notification in one function.
 // It  calls GlobalNotificationHandler. So before using this function, you should set GlobalNotificationHandler
-// with WithGlobalNotificationHandler.
+// with WithGlobalNotificationHandler. Like NotificationHandler, it decompresses the body, checks the
+// signature, the ReplayWindow and the Control, and deduplicates the notification before the call.
 //
 //nolint:cyclop
 func (ls *EventListener) GlobalHandler() http.Handler {
-	return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
-		var buff bytes.Buffer
-		if _, err := io.Copy(&buff, request.Body); err != nil && !errors.Is(err, io.EOF) {
-			writer.WriteHeader(http.StatusInternalServerError)
+	defer ls.bus.Emit(LifecycleComponent, lifecycle.Ready, nil)
 
+	return Recover(http.HandlerFunc(func(w http.ResponseWriter, request *http.Request) {
+		writer := newResponseWriter(w, ls.options)
+		raw, body, ok := readNotification(writer, request, ls.neh, ls.options)
+		if !ok {
 			return
 		}
-		request.Body = io.NopCloser(&buff)
+		request.Body = io.NopCloser(bytes.NewReader(body))
 
 		if ls.options != nil && ls.options.ValidateSignature {
-			signature, _ := ExtractSignatureFromHeader(request.Header)
-			if !ValidateSignature(buff.Bytes(), signature, ls.options.Secret) {
-				if handleError(
-					request.Context(), writer, request,
-					ls.neh, ErrInvalidSignature) {
+			if err := validateSignature(request.Context(), request, ls.options, raw, body); err != nil {
+				if handleError(request.Context(), writer, request, ls.neh, err) {
 					return
 				}
 			}
@@ -321,22 +576,228 @@ func (ls *EventListener) GlobalHandler() http.Handler {
 
 		// Construct the notification
 		var notification Notification
-		if err := json.NewDecoder(&buff).Decode(&notification); err != nil && !errors.Is(err, io.EOF) {
-			writer.WriteHeader(http.StatusInternalServerError)
+		if err := json.NewDecoder(bytes.NewReader(body)).Decode(&notification); err != nil && !errors.Is(err, io.EOF) {
+			writer.failure(ls.options, http.StatusInternalServerError)
 
 			return
 		}
 
-		// call the generic handler
-		if err := ls.g(request.Context(), writer, &notification); err != nil {
+		if ls.options != nil && ls.options.ReplayWindow != nil {
+			if err := ls.options.ReplayWindow.check(request.Context(), &notification, body); err != nil {
+				if handleError(request.Context(), writer, request, ls.neh, err) {
+					return
+				}
+			}
+		}
+
+		if ls.options != nil && ls.options.Control.Paused() {
+			writer.failure(ls.options, http.StatusServiceUnavailable)
+
+			return
+		}
+		if ls.options != nil && ls.options.Control.DryRun() {
+			writer.success(ls.options)
+
+			return
+		}
+
+		defer deduplicate(request.Context(), writer, ls.options, &notification)()
+
+		if err := publish(request.Context(), ls.options, &notification); err != nil {
+			reportError(request.Context(), ls.options, err, "publish")
+			if handleError(request.Context(), writer, request, ls.neh, err) {
+				return
+			}
+		}
+
+		// call the generic handler, a panic is acknowledged like in NotificationHandler.
+		if err := ls.callGlobalHandler(request.Context(), writer, &notification); err != nil {
+			reportError(request.Context(), ls.options, err, "global_handler")
+			if isPanic(err) {
+				writer.success(ls.options)
+
+				return
+			}
 			err = fmt.Errorf("%v: %v", ErrOnGenericHandlerFunc, err)
 			if handleError(request.Context(), writer, request, ls.neh, err) {
 				return
 			}
 		}
 
-		writer.WriteHeader(http.StatusOK)
-	})
+		writer.success(ls.options)
+	}), ls.hef, ls.options)
+}
+
+// callGlobalHandler calls the GlobalNotificationHandler and returns a panic as a *report.PanicError,
+// after passing it to the HooksErrorHandler.
+func (ls *EventListener) callGlobalHandler(ctx context.Context, writer http.ResponseWriter,
+	notification *Notification,
+) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = recoveredPanic(r, ls.hef)
+		}
+	}()
+
+	return ls.g(ctx, writer, notification)
+}
+
+// WithErrorReporter sets the ErrorReporter of the handler options.
+func WithErrorReporter(reporter report.ErrorReporter) ListenerOption {
+	return func(ls *EventListener) {
+		if ls.options == nil {
+			ls.options = &HandlerOptions{}
+		}
+		ls.options.ErrorReporter = reporter
+	}
+}
+
+// WithDispatcher runs the hooks asynchronously on the dispatcher, see HandlerOptions.Dispatcher.
+func WithDispatcher(dispatcher *Dispatcher) ListenerOption {
+	return func(ls *EventListener) {
+		if ls.options == nil {
+			ls.options = &HandlerOptions{}
+		}
+		ls.options.Dispatcher = dispatcher
+	}
+}
+
+// WithStageTimeouts sets the deadlines of the stages of the handler and the function called when a
+// stage exceeds its budget, see HandlerOptions.StageTimeouts.
+func WithStageTimeouts(timeouts *StageTimeouts, onExceeded OnStageExceededFunc) ListenerOption {
+	return func(ls *EventListener) {
+		if ls.options == nil {
+			ls.options = &HandlerOptions{}
+		}
+		ls.options.StageTimeouts = timeouts
+		ls.options.OnStageExceeded = onExceeded
+	}
+}
+
+// WithSecrets validates the signatures of the notifications with any of the secrets, the first
+// one is the current app secret. See HandlerOptions.Secrets.
+func WithSecrets(secrets ...string) ListenerOption {
+	return func(ls *EventListener) {
+		if ls.options == nil {
+			ls.options = &HandlerOptions{}
+		}
+		ls.options.ValidateSignature = true
+		ls.options.Secrets = secrets
+	}
+}
+
+// WithSecretProvider validates the signatures of the notifications with the secrets returned by
+// provider. See HandlerOptions.SecretProvider.
+func WithSecretProvider(provider SecretProvider) ListenerOption {
+	return func(ls *EventListener) {
+		if ls.options == nil {
+			ls.options = &HandlerOptions{}
+		}
+		ls.options.ValidateSignature = true
+		ls.options.SecretProvider = provider
+	}
+}
+
+// WithPublisher forwards every notification to the publisher, see HandlerOptions.Publisher.
+func WithPublisher(publisher Publisher) ListenerOption {
+	return func(ls *EventListener) {
+		if ls.options == nil {
+			ls.options = &HandlerOptions{}
+		}
+		ls.options.Publisher = publisher
+	}
+}
+
+// WithMaxBodySize bounds the size of the notification bodies. See HandlerOptions.MaxBodySize.
+func WithMaxBodySize(size int64) ListenerOption {
+	return func(ls *EventListener) {
+		if ls.options == nil {
+			ls.options = &HandlerOptions{}
+		}
+		ls.options.MaxBodySize = size
+	}
+}
+
+// WithReadTimeout bounds the time to read the notification bodies. See HandlerOptions.ReadTimeout.
+func WithReadTimeout(timeout time.Duration) ListenerOption {
+	return func(ls *EventListener) {
+		if ls.options == nil {
+			ls.options = &HandlerOptions{}
+		}
+		ls.options.ReadTimeout = timeout
+	}
+}
+
+// WithResponseDeadline bounds the handling of the notifications. See HandlerOptions.ResponseDeadline.
+func WithResponseDeadline(deadline time.Duration) ListenerOption {
+	return func(ls *EventListener) {
+		if ls.options == nil {
+			ls.options = &HandlerOptions{}
+		}
+		ls.options.ResponseDeadline = deadline
+	}
+}
+
+// WithStrictDecoding passes the notifications that do not fully match the models to the
+// NotificationErrorHandler as a *StrictDecodingError. See HandlerOptions.StrictDecoding.
+func WithStrictDecoding() ListenerOption {
+	return func(ls *EventListener) {
+		if ls.options == nil {
+			ls.options = &HandlerOptions{}
+		}
+		ls.options.StrictDecoding = true
+	}
+}
+
+// WithReplayWindow rejects the notifications with messages or statuses older than window, unless
+// known has their deduplication key. Use the DedupStore given to WithDeduplication as known when
+// it implements DedupKeyChecker. See ReplayWindow.
+func WithReplayWindow(window time.Duration, known DedupKeyChecker) ListenerOption {
+	return func(ls *EventListener) {
+		if ls.options == nil {
+			ls.options = &HandlerOptions{}
+		}
+		ls.options.ReplayWindow = &ReplayWindow{Window: window, Known: known}
+	}
+}
+
+// WithConcurrency handles up to n changes of a notification at once, keeping the order of the
+// changes about the same user. See AttachHooksConcurrently.
+func WithConcurrency(n int) ListenerOption {
+	return func(ls *EventListener) {
+		if ls.options == nil {
+			ls.options = &HandlerOptions{}
+		}
+		ls.options.Concurrency = n
+	}
+}
+
+// WithDeduplication drops the messages and statuses already handled, remembered in store for ttl.
+// A zero ttl uses DefaultDeduplicationTTL.
+func WithDeduplication(store DedupStore, ttl time.Duration) ListenerOption {
+	return func(ls *EventListener) {
+		if ls.options == nil {
+			ls.options = &HandlerOptions{}
+		}
+		ls.options.Deduplication = &Deduplication{Store: store, TTL: ttl}
+	}
+}
+
+// WithControl pauses the dispatch or turns the dry run on with control. See Control.
+func WithControl(control *Control) ListenerOption {
+	return func(ls *EventListener) {
+		if ls.options == nil {
+			ls.options = &HandlerOptions{}
+		}
+		ls.options.Control = control
+	}
+}
+
+// WithLifecycle emits the phases of the listener on bus as LifecycleComponent, see Shutdown.
+func WithLifecycle(bus *lifecycle.Bus) ListenerOption {
+	return func(ls *EventListener) {
+		ls.bus = bus
+	}
 }
 
 // SubscriptionVerificationHandler returns a http.Handler that can be used to verify the subscription.
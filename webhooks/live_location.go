@@ -0,0 +1,68 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package webhooks
+
+import (
+	"context"
+
+	"github.com/lowkruc/go-whatsapp-api/models"
+)
+
+type (
+	// LiveLocation is an update of a live location. A live location is updated many times while
+	// it is shared, SequenceNumber grows with every update so that the late ones can be dropped.
+	// Accuracy, Speed and Heading are nil when not sent.
+	LiveLocation struct {
+		Latitude       float64
+		Longitude      float64
+		Accuracy       *float64
+		Speed          *float64
+		Heading        *float64
+		SequenceNumber int64
+	}
+
+	// OnLiveLocationUpdateHook is called for the updates of the live locations. Without it, they
+	// go to the OnLocationMessageHook with the pinned locations.
+	OnLiveLocationUpdateHook func(
+		ctx context.Context, nctx *NotificationContext, mctx *MessageContext, location *LiveLocation) error
+)
+
+// IsLive reports whether the location is an update of a live location, it has at least one of
+// the fields only sent for live locations.
+func IsLive(location *models.Location) bool {
+	return location != nil && (location.Accuracy != nil || location.Speed != nil || location.Heading != nil ||
+		location.SequenceNumber > 0)
+}
+
+// newLiveLocation returns the LiveLocation of location, nil when it is not live.
+func newLiveLocation(location *models.Location) *LiveLocation {
+	if !IsLive(location) {
+		return nil
+	}
+
+	return &LiveLocation{
+		Latitude:       location.Latitude,
+		Longitude:      location.Longitude,
+		Accuracy:       location.Accuracy,
+		Speed:          location.Speed,
+		Heading:        location.Heading,
+		SequenceNumber: location.SequenceNumber,
+	}
+}
@@ -0,0 +1,99 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package webhooks
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/lowkruc/go-whatsapp-api/models"
+)
+
+const locationsPayload = `{
+  "object": "whatsapp_business_account",
+  "entry": [{
+    "id": "102290129340398",
+    "changes": [{
+      "field": "messages",
+      "value": {
+        "messaging_product": "whatsapp",
+        "metadata": {"display_phone_number": "15550783881", "phone_number_id": "106540352242922"},
+        "messages": [
+          {"from": "16505551234", "id": "wamid.1", "timestamp": "1750263773", "type": "location",
+           "location": {"latitude": 37.4847, "longitude": -122.1477, "name": "Main Office", "address": "1 Hacker Way"}},
+          {"from": "16505551234", "id": "wamid.2", "timestamp": "1750263774", "type": "location",
+           "location": {"latitude": 37.4848, "longitude": -122.1478, "accuracy": 12.5, "speed": 0, "heading": 270,
+             "sequence_number": 3}}
+        ]
+      }
+    }]
+  }]
+}`
+
+func TestAttachHooksToNotification_LiveLocation(t *testing.T) {
+	t.Parallel()
+	var notification Notification
+	if err := json.Unmarshal([]byte(locationsPayload), &notification); err != nil {
+		t.Fatalf("json.Unmarshal() error = %v", err)
+	}
+	if err := CheckStrict([]byte(locationsPayload)); err != nil {
+		t.Errorf("CheckStrict() error = %v", err)
+	}
+
+	var (
+		pinned []string
+		live   *LiveLocation
+	)
+	listener := NewEventListener()
+	listener.OnLocationMessage(func(ctx context.Context, nctx *NotificationContext, mctx *MessageContext,
+		location *models.Location,
+	) error {
+		pinned = append(pinned, mctx.ID)
+
+		return nil
+	})
+
+	if err := AttachHooksToNotification(context.TODO(), &notification, listener.h, NoOpHooksErrorHandler); err != nil {
+		t.Fatalf("AttachHooksToNotification() error = %v", err)
+	}
+	if len(pinned) != 2 {
+		t.Fatalf("got locations %v, want both without a live location hook", pinned)
+	}
+
+	pinned = nil
+	listener.OnLiveLocationUpdate(func(ctx context.Context, nctx *NotificationContext, mctx *MessageContext,
+		location *LiveLocation,
+	) error {
+		live = location
+
+		return nil
+	})
+	if err := AttachHooksToNotification(context.TODO(), &notification, listener.h, NoOpHooksErrorHandler); err != nil {
+		t.Fatalf("AttachHooksToNotification() error = %v", err)
+	}
+	if len(pinned) != 1 || pinned[0] != "wamid.1" {
+		t.Errorf("got pinned locations %v, want only wamid.1", pinned)
+	}
+	if live == nil || live.Accuracy == nil || *live.Accuracy != 12.5 || live.Speed == nil || *live.Speed != 0 ||
+		live.Heading == nil || *live.Heading != 270 || live.SequenceNumber != 3 || live.Latitude != 37.4848 {
+		t.Errorf("unexpected live location %+v", live)
+	}
+}
@@ -20,6 +20,9 @@
 package webhooks
 
 import (
+	"encoding/json"
+	"errors"
+
 	werrors "github.com/lowkruc/go-whatsapp-api/errors"
 	"github.com/lowkruc/go-whatsapp-api/models"
 )
@@ -98,6 +101,7 @@ type (
 	//		- delivered – A webhook is triggered when a message sent by a business has been delivered
 	//		- read – A webhook is triggered when a message sent by a business has been read
 	//		- sent – A webhook is triggered when a business sends a message to a customer
+	//		- failed – A webhook is triggered when a message sent by a business failed to send
 	//
 	// Timestamp , timestamp Unix timestamp Date for the status message.
 	//
@@ -119,9 +123,11 @@ type (
 		RecipientID  string           `json:"recipient_id,omitempty"`
 		StatusValue  string           `json:"status,omitempty"`
 		Timestamp    string           `json:"timestamp,omitempty"`
+		Type         string           `json:"type,omitempty"`
+		Payment      *Payment         `json:"payment,omitempty"`
 		Conversation *Conversation    `json:"conversation,omitempty"`
 		Pricing      *Pricing         `json:"pricing,omitempty"`
-		Errors       []*werrors.Error `json:"werrors,omitempty"`
+		Errors       []*werrors.Error `json:"errors,omitempty"`
 	}
 
 	// Event is the type of event that occurred and leads to the notification being sent.
@@ -159,7 +165,7 @@ type (
 		Button      *Button           `json:"button,omitempty"`
 		Context     *Context          `json:"context,omitempty"`
 		Document    *models.MediaInfo `json:"document,omitempty"`
-		Errors      []*werrors.Error  `json:"werrors,omitempty"`
+		Errors      []*werrors.Error  `json:"errors,omitempty"`
 		From        string            `json:"from,omitempty"`
 		ID          string            `json:"id,omitempty"`
 		Identity    *Identity         `json:"identity,omitempty"`
@@ -204,16 +210,21 @@ type (
 		Body string `json:"body,omitempty"`
 	}
 
-	// Interactive represent the interactive template.
+	// Interactive represent the interactive template. ReplyType is the type of the reply,
+	// button_reply, list_reply or call_permission_reply, and Type holds the reply itself.
 	Interactive struct {
-		Type *InteractiveType `json:"type,omitempty"`
+		Type      *InteractiveType `json:"type,omitempty"`
+		ReplyType InteractiveReply `json:"-"`
 	}
 
 	// InteractiveType represent an item sent to user. It can be a reply button
-	// (ButtonReply) or a list reply containing a list of items (ListReply).
+	// (ButtonReply), a list reply containing a list of items (ListReply) or the reply
+	// to a call permission request (CallPermissionReply) or the response to a Flow (NfmReply).
 	InteractiveType struct {
-		ButtonReply *ButtonReply `json:"button_reply,omitempty"`
-		ListReply   *ListReply   `json:"list_reply,omitempty"`
+		ButtonReply         *ButtonReply         `json:"button_reply,omitempty"`
+		ListReply           *ListReply           `json:"list_reply,omitempty"`
+		CallPermissionReply *CallPermissionReply `json:"call_permission_reply,omitempty"`
+		NfmReply            *NfmReply            `json:"nfm_reply,omitempty"`
 	}
 
 	ButtonReply struct {
@@ -229,12 +240,14 @@ type (
 
 	// ProductItem represents a product item, Whereas the ProductRetailerID is the unique identifier of
 	// the product in a catalog. Quantity represents the number of items. ItemPrice represents the price
-	// of a single item. Currency represents the price currency.
+	// of a single item, use Price for the exact amount. Currency represents the price currency.
 	ProductItem struct {
 		ProductRetailerID string  `json:"product_retailer_id,omitempty"`
 		Quantity          float64 `json:"quantity,omitempty"`
 		ItemPrice         float64 `json:"item_price,omitempty"`
 		Currency          string  `json:"currency,omitempty"`
+
+		price string // item_price as received
 	}
 
 	// Order have information about order created by the customer. Order objects have the following properties:
@@ -267,6 +280,9 @@ type (
 	// VideoURL – String. URL of the video, when media_type is a video.
 	//
 	// ThumbnailURL – String. URL for the thumbnail, when media_type is a video.
+	//
+	// CtwaClid – String. Click ID generated by Meta for the click-to-WhatsApp ad, used to attribute
+	// the conversation to the ad.
 	Referral struct {
 		SourceURL    string `json:"source_url,omitempty"`
 		SourceType   string `json:"source_type,omitempty"`
@@ -277,6 +293,7 @@ type (
 		ImageURL     string `json:"image_url,omitempty"`
 		VideoURL     string `json:"video_url,omitempty"`
 		ThumbnailURL string `json:"thumbnail_url,omitempty"`
+		CtwaClid     string `json:"ctwa_clid,omitempty"`
 	}
 
 	// Button embedded in the Message object. When the messages type field is set to button,
@@ -320,11 +337,11 @@ type (
 	//	  	- CatalogID, catalog_id — String. Unique identifier of the Meta catalog linked to the WhatsApp Business Account.
 	//      - ProductRetailerID,product_retailer_id — String. Unique identifier of the product in a catalog.
 	Context struct {
-		Forwarded           bool   `json:"forwarded,omitempty"`
-		FrequentlyForwarded bool   `json:"frequently_forwarded,omitempty"`
-		From                string `json:"from,omitempty"`
-		ID                  string `json:"id,omitempty"`
-		ReferredProduct     *ReferredProduct
+		Forwarded           bool             `json:"forwarded,omitempty"`
+		FrequentlyForwarded bool             `json:"frequently_forwarded,omitempty"`
+		From                string           `json:"from,omitempty"`
+		ID                  string           `json:"id,omitempty"`
+		ReferredProduct     *ReferredProduct `json:"referred_product,omitempty"`
 	}
 
 	// ReferredProduct ,Referred product object describing the product the user is
@@ -362,15 +379,18 @@ type (
 	Value struct {
 		MessagingProduct string           `json:"messaging_product,omitempty"`
 		Metadata         *Metadata        `json:"metadata,omitempty"`
-		Errors           []*werrors.Error `json:"werrors,omitempty"`
+		Errors           []*werrors.Error `json:"errors,omitempty"`
 		Contacts         []*Contact       `json:"contacts,omitempty"`
 		Messages         []*Message       `json:"messages,omitempty"`
 		Statuses         []*Status        `json:"statuses,omitempty"`
 	}
 
+	// Change is a change in the subscribed field. Value is decoded for every field, RawValue keeps
+	// the value as received so that fields with a different shape can be decoded with DecodeValue.
 	Change struct {
-		Value *Value `json:"value,omitempty"`
-		Field string `json:"field,omitempty"`
+		Value    *Value          `json:"value,omitempty"`
+		Field    string          `json:"field,omitempty"`
+		RawValue json.RawMessage `json:"-"`
 	}
 
 	Entry struct {
@@ -383,3 +403,186 @@ type (
 		Entry  []*Entry `json:"entry,omitempty"`
 	}
 )
+
+// Webhook fields that have typed support. Changes of other fields are decoded as Value.
+const (
+	MessagesField                    = "messages"
+	MessagingHandoversField          = "messaging_handovers"
+	PartnerSolutionsField            = "partner_solutions"
+	MessageTemplateStatusUpdateField = "message_template_status_update"
+	TemplateCategoryUpdateField      = "template_category_update"
+	PhoneNumberQualityUpdateField    = "phone_number_quality_update"
+	AccountUpdateField               = "account_update"
+	AccountReviewUpdateField         = "account_review_update"
+	BusinessCapabilityUpdateField    = "business_capability_update"
+	SecurityField                    = "security"
+	FlowsField                       = "flows"
+	CallsField                       = "calls"
+	UserPreferencesField             = "user_preferences"
+	SMBMessageEchoesField            = "smb_message_echoes"
+)
+
+// Media returns the media of an audio, document, image, sticker or video message, nil for the
+// other messages.
+func (message *Message) Media() *models.MediaInfo {
+	switch ParseMessageType(message.Type) {
+	case AudioMessageType:
+		return message.Audio
+	case DocumentMessageType:
+		return message.Document
+	case ImageMessageType:
+		return message.Image
+	case StickerMessageType:
+		return message.Sticker
+	case VideoMessageType:
+		return message.Video
+	default:
+		return nil
+	}
+}
+
+// ErrNoChangeValue is returned by Change.DecodeValue when the change has no value.
+var ErrNoChangeValue = errors.New("change has no value")
+
+// UnmarshalJSON decodes the change and keeps a copy of the raw value.
+func (change *Change) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		Value json.RawMessage `json:"value,omitempty"`
+		Field string          `json:"field,omitempty"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	change.Field = raw.Field
+	change.RawValue = nil
+	change.Value = nil
+	if len(raw.Value) == 0 || string(raw.Value) == "null" {
+		return nil
+	}
+
+	change.RawValue = raw.Value
+	value := &Value{}
+	if err := json.Unmarshal(raw.Value, value); err != nil {
+		return err
+	}
+	change.Value = value
+
+	return nil
+}
+
+// MarshalJSON encodes the change. Changes of fields other than messages that were decoded from
+// JSON are encoded with their raw value so that no field is lost.
+func (change Change) MarshalJSON() ([]byte, error) {
+	type plain struct {
+		Value any    `json:"value,omitempty"`
+		Field string `json:"field,omitempty"`
+	}
+
+	if change.Field != MessagesField && len(change.RawValue) > 0 {
+		return json.Marshal(plain{Value: change.RawValue, Field: change.Field})
+	}
+
+	if change.Value == nil {
+		return json.Marshal(plain{Field: change.Field})
+	}
+
+	return json.Marshal(plain{Value: change.Value, Field: change.Field})
+}
+
+// DecodeValue decodes the raw value of the change into v. Changes created in code have no raw
+// value, their Value is used instead.
+func (change *Change) DecodeValue(v any) error {
+	data := []byte(change.RawValue)
+	if len(data) == 0 {
+		if change.Value == nil {
+			return ErrNoChangeValue
+		}
+
+		var err error
+		if data, err = json.Marshal(change.Value); err != nil {
+			return err
+		}
+	}
+
+	return json.Unmarshal(data, v)
+}
+
+// FailureReasons classifies the errors of the status, see werrors.Classify. The errors are only
+// set for failed messages.
+func (status *Status) FailureReasons() []werrors.Reason {
+	reasons := make([]werrors.Reason, 0, len(status.Errors))
+	for _, e := range status.Errors {
+		if e != nil {
+			reasons = append(reasons, werrors.Classify(e))
+		}
+	}
+
+	return reasons
+}
+
+// interactiveReplies are the replies of an interactive message as sent by the Cloud API, next to
+// its type.
+type interactiveReplies struct {
+	Type json.RawMessage `json:"type,omitempty"`
+	InteractiveType
+}
+
+// UnmarshalJSON decodes the interactive reply as sent by the Cloud API, where type is the name of
+// the reply and the reply is next to it, as well as the nested form where type is the reply.
+func (interactive *Interactive) UnmarshalJSON(data []byte) error {
+	var replies interactiveReplies
+	if err := json.Unmarshal(data, &replies); err != nil {
+		return err
+	}
+
+	var replyType string
+	if err := json.Unmarshal(replies.Type, &replyType); err == nil {
+		interactive.ReplyType = InteractiveReply(replyType)
+		interactive.Type = &replies.InteractiveType
+
+		return nil
+	}
+
+	var nested InteractiveType
+	if len(replies.Type) > 0 && string(replies.Type) != "null" {
+		if err := json.Unmarshal(replies.Type, &nested); err != nil {
+			return err
+		}
+		interactive.Type = &nested
+	}
+	interactive.ReplyType = nested.replyType()
+
+	return nil
+}
+
+// MarshalJSON encodes the interactive reply the way the Cloud API sends it.
+func (interactive Interactive) MarshalJSON() ([]byte, error) {
+	replies := struct {
+		Type InteractiveReply `json:"type,omitempty"`
+		InteractiveType
+	}{Type: interactive.ReplyType}
+	if interactive.Type != nil {
+		replies.InteractiveType = *interactive.Type
+		if replies.Type == "" {
+			replies.Type = interactive.Type.replyType()
+		}
+	}
+
+	return json.Marshal(replies)
+}
+
+func (reply *InteractiveType) replyType() InteractiveReply {
+	switch {
+	case reply.ButtonReply != nil:
+		return InteractiveButtonReply
+	case reply.ListReply != nil:
+		return InteractiveListReply
+	case reply.CallPermissionReply != nil:
+		return InteractiveCallPermissionReply
+	case reply.NfmReply != nil:
+		return InteractiveNfmReply
+	default:
+		return ""
+	}
+}
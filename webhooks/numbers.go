@@ -0,0 +1,101 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package webhooks
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+// StringKeys are the keys whose values are always strings in the public model. When decoding
+// leniently, numbers found under these keys are converted to strings with all their digits.
+var StringKeys = map[string]bool{ //nolint:gochecknoglobals
+	"id":                   true,
+	"wa_id":                true,
+	"from":                 true,
+	"timestamp":            true,
+	"recipient_id":         true,
+	"phone_number_id":      true,
+	"display_phone_number": true,
+	"expiration_timestamp": true,
+	"catalog_id":           true,
+	"product_retailer_id":  true,
+	"message_template_id":  true,
+	"source_id":            true,
+	"new_wa_id":            true,
+}
+
+// NormalizeNumbers rewrites the payload so that numbers found under StringKeys become strings.
+// Numbers are read with json.Decoder.UseNumber so that large IDs keep all their digits.
+func NormalizeNumbers(data []byte) ([]byte, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	var v any
+	if err := decoder.Decode(&v); err != nil {
+		return nil, fmt.Errorf("normalize numbers: %v", err)
+	}
+
+	out, err := json.Marshal(normalizeNumbers(v))
+	if err != nil {
+		return nil, fmt.Errorf("normalize numbers: %v", err)
+	}
+
+	return out, nil
+}
+
+func normalizeNumbers(v any) any {
+	switch value := v.(type) {
+	case map[string]any:
+		for key, item := range value {
+			if n, ok := item.(json.Number); ok && StringKeys[key] {
+				value[key] = n.String()
+
+				continue
+			}
+			value[key] = normalizeNumbers(item)
+		}
+	case []any:
+		for i, item := range value {
+			value[i] = normalizeNumbers(item)
+		}
+	}
+
+	return v
+}
+
+// DecodeNotification decodes a notification payload. When lenient is true, IDs and timestamps
+// sent as JSON numbers instead of strings are accepted and converted to strings.
+func DecodeNotification(data []byte, lenient bool) (*Notification, error) {
+	notification := &Notification{}
+	if lenient {
+		var err error
+		if data, err = NormalizeNumbers(data); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := json.Unmarshal(data, notification); err != nil {
+		return nil, err
+	}
+
+	return notification, nil
+}
@@ -0,0 +1,52 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package webhooks
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestDecodeNotification_Lenient(t *testing.T) {
+	t.Parallel()
+	payload := []byte(`{"object":"whatsapp_business_account","entry":[{"id":102290129340398,"changes":[{` +
+		`"field":"messages","value":{"messaging_product":"whatsapp","metadata":{"display_phone_number":15550783881,` +
+		`"phone_number_id":106540352242922},"contacts":[{"wa_id":16505551234,"profile":{"name":"Kerry"}}],` +
+		`"messages":[{"from":16505551234,"id":"wamid.1","timestamp":1706461964,"type":"text","text":{"body":"42"}}]}}]}]}`)
+
+	if _, err := DecodeNotification(payload, false); err == nil {
+		t.Fatal("strict decoding of numeric IDs should fail")
+	}
+
+	notification, err := DecodeNotification(payload, true)
+	if err != nil {
+		t.Fatalf("DecodeNotification() error = %v", err)
+	}
+
+	entry := notification.Entry[0]
+	value := entry.Changes[0].Value
+	message := value.Messages[0]
+	if entry.ID != "102290129340398" || value.Metadata.PhoneNumberID != "106540352242922" ||
+		value.Contacts[0].WaID != "16505551234" || message.From != "16505551234" ||
+		message.Timestamp != "1706461964" || message.Text.Body != "42" {
+		data, _ := json.Marshal(notification)
+		t.Errorf("unexpected notification %s", data)
+	}
+}
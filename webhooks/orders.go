@@ -0,0 +1,269 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package webhooks
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+var ErrMixedCurrencies = errors.New("order items have different currencies")
+
+// OrderItem is a product of an order with its price as an exact Amount, see Order.Items.
+type OrderItem struct {
+	CatalogID         string
+	ProductRetailerID string
+	Quantity          int64
+	Price             Amount
+	Currency          string
+}
+
+// maxAmountExponent is the largest exponent, positive or negative, ParseAmount accepts.
+const maxAmountExponent = 18
+
+// ParseAmount parses a decimal number, like the item_price of the orders, into an exact Amount:
+// "10.50" is 1050 with an offset of 100.
+func ParseAmount(s string) (Amount, error) {
+	mantissa, exponent, scientific := strings.Cut(strings.ToLower(strings.TrimSpace(s)), "e")
+	exp := 0
+	if scientific {
+		var err error
+		if exp, err = strconv.Atoi(exponent); err != nil {
+			return Amount{}, fmt.Errorf("parse amount %q: %v", s, err)
+		}
+		// an int64 has 19 digits at most, larger exponents cannot give a valid amount.
+		if exp > maxAmountExponent || exp < -maxAmountExponent {
+			return Amount{}, fmt.Errorf("parse amount %q: exponent out of range", s)
+		}
+	}
+
+	whole, fraction, _ := strings.Cut(mantissa, ".")
+	digits := whole + fraction
+	decimals := len(fraction) - exp
+	for ; decimals < 0; decimals++ {
+		digits += "0"
+	}
+
+	value, err := strconv.ParseInt(digits, 10, 64)
+	if err != nil || strings.HasPrefix(fraction, "-") || strings.HasPrefix(fraction, "+") {
+		return Amount{}, fmt.Errorf("parse amount %q: invalid decimal", s)
+	}
+	offset := int64(1)
+	for i := 0; i < decimals; i++ {
+		if offset > math.MaxInt64/10 {
+			return Amount{}, fmt.Errorf("parse amount %q: too many decimals", s)
+		}
+		offset *= 10
+	}
+
+	return Amount{Value: value, Offset: offset}, nil
+}
+
+// String formats the amount as an exact decimal when the offset is a power of ten, "10.50" for
+// 1050 with an offset of 100.
+func (a Amount) String() string {
+	offset := a.Offset
+	if offset <= 0 {
+		offset = 1
+	}
+	decimals := 0
+	for o := offset; o > 1 && o%10 == 0; o /= 10 {
+		decimals++
+	}
+	if pow10(decimals) != offset {
+		return strconv.FormatFloat(a.Float(), 'f', -1, 64)
+	}
+
+	sign, value := "", a.Value
+	if value < 0 {
+		sign, value = "-", -value
+	}
+	if decimals == 0 {
+		return sign + strconv.FormatInt(value, 10)
+	}
+
+	return fmt.Sprintf("%s%d.%0*d", sign, value/offset, decimals, value%offset)
+}
+
+// Add returns the sum of the amounts, with the larger offset of the two when they are powers of
+// ten.
+func (a Amount) Add(b Amount) Amount {
+	a, b = a.normalized(), b.normalized()
+	switch {
+	case a.Offset == b.Offset:
+		return Amount{Value: a.Value + b.Value, Offset: a.Offset}
+	case a.Offset > b.Offset && a.Offset%b.Offset == 0:
+		return Amount{Value: a.Value + b.Value*(a.Offset/b.Offset), Offset: a.Offset}
+	case b.Offset%a.Offset == 0:
+		return Amount{Value: a.Value*(b.Offset/a.Offset) + b.Value, Offset: b.Offset}
+	default:
+		return Amount{Value: a.Value*b.Offset + b.Value*a.Offset, Offset: a.Offset * b.Offset}
+	}
+}
+
+// Mul returns the amount multiplied by n, the price of n items.
+func (a Amount) Mul(n int64) Amount {
+	a = a.normalized()
+
+	return Amount{Value: a.Value * n, Offset: a.Offset}
+}
+
+// Cmp compares the amounts and returns -1, 0 or +1 when a is less than, equal to or greater than b.
+func (a Amount) Cmp(b Amount) int {
+	a, b = a.normalized(), b.normalized()
+	x, y := a.Value*b.Offset, b.Value*a.Offset
+	switch {
+	case x < y:
+		return -1
+	case x > y:
+		return 1
+	default:
+		return 0
+	}
+}
+
+func (a Amount) normalized() Amount {
+	if a.Offset <= 0 {
+		a.Offset = 1
+	}
+
+	return a
+}
+
+func pow10(n int) int64 {
+	p := int64(1)
+	for i := 0; i < n; i++ {
+		p *= 10
+	}
+
+	return p
+}
+
+// UnmarshalJSON keeps the item_price as sent so that Price is exact, ItemPrice is the nearest
+// float.
+func (item *ProductItem) UnmarshalJSON(data []byte) error {
+	type plain ProductItem
+	var decoded struct {
+		*plain
+		ItemPrice json.Number `json:"item_price,omitempty"`
+	}
+	decoded.plain = (*plain)(item)
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return err
+	}
+
+	item.price = decoded.ItemPrice.String()
+	if item.price != "" {
+		price, err := decoded.ItemPrice.Float64()
+		if err != nil {
+			return fmt.Errorf("item_price: %v", err)
+		}
+		item.ItemPrice = price
+	}
+
+	return nil
+}
+
+// Price returns the exact price of a single item, parsed from the item_price as received, or from
+// ItemPrice when the item was not decoded from JSON. It returns an error when the price does not fit
+// in an Amount.
+func (item *ProductItem) Price() (Amount, error) {
+	price := item.price
+	if price == "" {
+		price = strconv.FormatFloat(item.ItemPrice, 'f', -1, 64)
+	}
+	amount, err := ParseAmount(price)
+	if err != nil {
+		return Amount{}, fmt.Errorf("product %s: item_price %q: %w", item.ProductRetailerID, price, err)
+	}
+
+	return amount, nil
+}
+
+// Items returns the products of the order as OrderItems, or the first error of ProductItem.Price.
+func (order *Order) Items() ([]*OrderItem, error) {
+	if order == nil {
+		return nil, nil
+	}
+
+	items := make([]*OrderItem, 0, len(order.ProductItems))
+	for _, product := range order.ProductItems {
+		if product == nil {
+			continue
+		}
+		price, err := product.Price()
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, &OrderItem{
+			CatalogID:         order.CatalogID,
+			ProductRetailerID: product.ProductRetailerID,
+			Quantity:          int64(math.Round(product.Quantity)),
+			Price:             price,
+			Currency:          product.Currency,
+		})
+	}
+
+	return items, nil
+}
+
+// ProductRetailerIDs returns the IDs of the ordered products in the catalog, in order.
+func (order *Order) ProductRetailerIDs() []string {
+	if order == nil {
+		return nil
+	}
+
+	ids := make([]string, 0, len(order.ProductItems))
+	for _, product := range order.ProductItems {
+		if product != nil {
+			ids = append(ids, product.ProductRetailerID)
+		}
+	}
+
+	return ids
+}
+
+// Subtotal returns the price of the item times its quantity.
+func (item *OrderItem) Subtotal() Amount {
+	return item.Price.Mul(item.Quantity)
+}
+
+// Total returns the sum of the subtotals of the items and their currency, ErrMixedCurrencies when
+// the items are not in the same currency, or the error of Items when a price can not be parsed.
+func (order *Order) Total() (Amount, string, error) {
+	items, err := order.Items()
+	if err != nil {
+		return Amount{}, "", err
+	}
+	total, currency := Amount{Offset: 1}, ""
+	for _, item := range items {
+		if currency != "" && item.Currency != currency {
+			return Amount{}, "", ErrMixedCurrencies
+		}
+		currency = item.Currency
+		total = total.Add(item.Subtotal())
+	}
+
+	return total, currency, nil
+}
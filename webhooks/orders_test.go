@@ -0,0 +1,135 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package webhooks
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestParseAmount(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		in   string
+		want Amount
+		str  string
+	}{
+		{"10", Amount{Value: 10, Offset: 1}, "10"},
+		{"10.50", Amount{Value: 1050, Offset: 100}, "10.50"},
+		{"0.1", Amount{Value: 1, Offset: 10}, "0.1"},
+		{"-1.25", Amount{Value: -125, Offset: 100}, "-1.25"},
+		{"1.5e3", Amount{Value: 1500, Offset: 1}, "1500"},
+		{"5e-2", Amount{Value: 5, Offset: 100}, "0.05"},
+		{"1e18", Amount{Value: 1e18, Offset: 1}, "1000000000000000000"},
+	}
+	for _, tt := range tests {
+		got, err := ParseAmount(tt.in)
+		if err != nil || got != tt.want || got.String() != tt.str {
+			t.Errorf("ParseAmount(%q) = %+v (%s), %v, want %+v (%s)", tt.in, got, got, err, tt.want, tt.str)
+		}
+	}
+
+	for _, in := range []string{"", "ten", "1.-5", "1e", "1e19", "1e80000", "1e-80000"} {
+		if _, err := ParseAmount(in); err == nil {
+			t.Errorf("ParseAmount(%q) expected an error", in)
+		}
+	}
+}
+
+func TestOrder_Total(t *testing.T) {
+	t.Parallel()
+	payload := `{"catalog_id":"catalog.1","text":"asap","product_items":[
+{"product_retailer_id":"sku-1","quantity":3,"item_price":0.1,"currency":"USD"},
+{"product_retailer_id":"sku-2","quantity":1,"item_price":19.99,"currency":"USD"},
+{"product_retailer_id":"sku-3","quantity":2,"item_price":5,"currency":"USD"}]}`
+
+	var order Order
+	if err := json.Unmarshal([]byte(payload), &order); err != nil {
+		t.Fatalf("json.Unmarshal() error = %v", err)
+	}
+	if order.ProductItems[1].ItemPrice != 19.99 {
+		t.Errorf("got ItemPrice %v, want 19.99", order.ProductItems[1].ItemPrice)
+	}
+
+	items, err := order.Items()
+	if err != nil {
+		t.Fatalf("Items() error = %v", err)
+	}
+	if len(items) != 3 || items[0].CatalogID != "catalog.1" || items[0].Quantity != 3 ||
+		items[0].Subtotal().String() != "0.3" {
+		t.Fatalf("unexpected items %+v", items)
+	}
+	if ids := order.ProductRetailerIDs(); len(ids) != 3 || ids[0] != "sku-1" || ids[2] != "sku-3" {
+		t.Errorf("ProductRetailerIDs() = %v", ids)
+	}
+
+	total, currency, err := order.Total()
+	if err != nil || currency != "USD" || total.String() != "30.29" {
+		t.Errorf("Total() = %s %s, %v, want 30.29 USD", total, currency, err)
+	}
+
+	order.ProductItems = append(order.ProductItems, &ProductItem{ProductRetailerID: "sku-4", Quantity: 1,
+		ItemPrice: 2.5, Currency: "EUR"})
+	if _, _, err := order.Total(); !errors.Is(err, ErrMixedCurrencies) {
+		t.Errorf("Total() error = %v, want %v", err, ErrMixedCurrencies)
+	}
+	if price, err := order.ProductItems[3].Price(); err != nil || price.String() != "2.5" {
+		t.Errorf("Price() = %s, %v, want 2.5", price, err)
+	}
+}
+
+func TestOrder_UnparsablePrice(t *testing.T) {
+	t.Parallel()
+	payload := `{"catalog_id":"catalog.1","product_items":[
+{"product_retailer_id":"sku-1","quantity":1,"item_price":123456789012345678901,"currency":"USD"}]}`
+
+	var order Order
+	if err := json.Unmarshal([]byte(payload), &order); err != nil {
+		t.Fatalf("json.Unmarshal() error = %v", err)
+	}
+	if price, err := order.ProductItems[0].Price(); err == nil {
+		t.Errorf("Price() = %s, want an error", price)
+	}
+	if items, err := order.Items(); err == nil {
+		t.Errorf("Items() = %+v, want an error", items)
+	}
+	if total, _, err := order.Total(); err == nil {
+		t.Errorf("Total() = %s, want an error", total)
+	}
+}
+
+func TestAmount_Cmp(t *testing.T) {
+	t.Parallel()
+	for _, tt := range []struct {
+		a, b string
+		want int
+	}{
+		{"0.10", "0.1", 0},
+		{"0.05", "0.1", -1},
+		{"1", "0.999", 1},
+	} {
+		a, _ := ParseAmount(tt.a)
+		b, _ := ParseAmount(tt.b)
+		if got := a.Cmp(b); got != tt.want {
+			t.Errorf("%s.Cmp(%s) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
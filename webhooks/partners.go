@@ -0,0 +1,81 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package webhooks
+
+import "context"
+
+type (
+	// WabaInfo is the account a tech provider or solution partner was added to, sent with the
+	// partner events of the account_update changes.
+	//
+	// WabaID, waba_id — the WhatsApp Business Account of the client.
+	// OwnerBusinessID, owner_business_id — the business portfolio that owns the account.
+	// PartnerAppID, partner_app_id — the app installed on the account.
+	// SolutionID, solution_id — the Multi-Partner Solution the account was onboarded or migrated to.
+	// SolutionPartnerBusinessIDs, solution_partner_business_ids — the partners of the solution.
+	WabaInfo struct {
+		WabaID                     string   `json:"waba_id,omitempty"`
+		OwnerBusinessID            string   `json:"owner_business_id,omitempty"`
+		PartnerAppID               string   `json:"partner_app_id,omitempty"`
+		SolutionID                 string   `json:"solution_id,omitempty"`
+		SolutionPartnerBusinessIDs []string `json:"solution_partner_business_ids,omitempty"`
+	}
+
+	// OnPartnerOnboardingHook is called for the partner events of the account_update changes, when
+	// a client account is onboarded through Embedded Signup, moved to a solution or leaves the
+	// partner, see AccountUpdate.PartnerEvent. The ID of the notification context is the ID of the
+	// entry. Without it, the partner events go to the OnAccountUpdateHook.
+	OnPartnerOnboardingHook func(ctx context.Context, nctx *NotificationContext, update *AccountUpdate) error
+)
+
+// PartnerEvent reports whether the update is about the partners of the account: a partner or its
+// app added to or removed from the account.
+func (update *AccountUpdate) PartnerEvent() bool {
+	switch update.Event {
+	case AccountEventPartnerAdded, AccountEventPartnerRemoved,
+		AccountEventPartnerAppInstalled, AccountEventPartnerAppUninstalled:
+		return true
+	default:
+		return false
+	}
+}
+
+// Onboarded reports whether the update adds the partner or its app to a client account.
+func (update *AccountUpdate) Onboarded() bool {
+	return update.Event == AccountEventPartnerAdded || update.Event == AccountEventPartnerAppInstalled
+}
+
+// SolutionMigration reports whether the update moves the account to a Multi-Partner Solution, a
+// partner added with the ID of the solution.
+func (update *AccountUpdate) SolutionMigration() bool {
+	return update.Onboarded() && update.WabaInfo != nil && update.WabaInfo.SolutionID != ""
+}
+
+// Active reports whether the solution can be used to onboard accounts.
+func (solution *PartnerSolution) Active() bool {
+	return solution.SolutionStatus == PartnerSolutionStatusActive
+}
+
+// Pending reports whether the solution waits for a partner to accept its activation or
+// deactivation.
+func (solution *PartnerSolution) Pending() bool {
+	return solution.SolutionStatus == PartnerSolutionStatusPendingActivation ||
+		solution.SolutionStatus == PartnerSolutionStatusPendingDeactivation
+}
@@ -0,0 +1,104 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package webhooks
+
+import "context"
+
+// StatusTypePayment is the type of the statuses about the payment of an order, sent in India and
+// Brazil for orders paid through WhatsApp.
+const StatusTypePayment = "payment"
+
+// Statuses of a payment and of its transactions.
+const (
+	PaymentStatusPending  = "pending"
+	PaymentStatusCaptured = "captured"
+	PaymentStatusFailed   = "failed"
+
+	TransactionStatusPending = "pending"
+	TransactionStatusSuccess = "success"
+	TransactionStatusFailed  = "failed"
+)
+
+type (
+	// Amount is a money amount, Value divided by Offset. For example 21000 with an offset of 100
+	// is 210.00.
+	Amount struct {
+		Value  int64 `json:"value"`
+		Offset int64 `json:"offset"`
+	}
+
+	// PaymentMethod is how a transaction was paid, for example upi, card or pix.
+	PaymentMethod struct {
+		Type string `json:"type,omitempty"`
+	}
+
+	// TransactionError is the reason a transaction failed.
+	TransactionError struct {
+		Code   string `json:"code,omitempty"`
+		Reason string `json:"reason,omitempty"`
+	}
+
+	// Transaction is an attempt to pay an order. The timestamps are in seconds since the epoch.
+	Transaction struct {
+		ID               string            `json:"id,omitempty"`
+		Type             string            `json:"type,omitempty"`
+		Status           string            `json:"status,omitempty"`
+		CreatedTimestamp int64             `json:"created_timestamp,omitempty"`
+		UpdatedTimestamp int64             `json:"updated_timestamp,omitempty"`
+		Amount           *Amount           `json:"amount,omitempty"`
+		Currency         string            `json:"currency,omitempty"`
+		Method           *PaymentMethod    `json:"method,omitempty"`
+		Error            *TransactionError `json:"error,omitempty"`
+	}
+
+	// Refund is a refund of a captured payment.
+	Refund struct {
+		ID        string  `json:"id,omitempty"`
+		Status    string  `json:"status,omitempty"`
+		Amount    *Amount `json:"amount,omitempty"`
+		Speed     string  `json:"speed,omitempty"`
+		Timestamp int64   `json:"timestamp,omitempty"`
+	}
+
+	// Payment is the payment of an order, set in the statuses of type StatusTypePayment. ReferenceID
+	// is the reference the business gave to the order.
+	Payment struct {
+		ReferenceID string       `json:"reference_id,omitempty"`
+		Amount      *Amount      `json:"amount,omitempty"`
+		Currency    string       `json:"currency,omitempty"`
+		Transaction *Transaction `json:"transaction,omitempty"`
+		Receipt     string       `json:"receipt,omitempty"`
+		Refunds     []*Refund    `json:"refunds,omitempty"`
+	}
+
+	// OnPaymentStatusHook is called after OnMessageStatusChangeHook for the statuses of type
+	// StatusTypePayment, instead of the hooks of the message statuses. The StatusValue is one of
+	// the PaymentStatus constants and Payment is set.
+	OnPaymentStatusHook func(ctx context.Context, nctx *NotificationContext, status *Status) error
+)
+
+// Float returns the amount as a float, for display.
+func (a *Amount) Float() float64 {
+	if a == nil || a.Offset == 0 {
+		return 0
+	}
+
+	return float64(a.Value) / float64(a.Offset)
+}
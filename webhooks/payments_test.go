@@ -0,0 +1,97 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package webhooks
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+)
+
+const paymentStatusPayload = `{
+  "object": "whatsapp_business_account",
+  "entry": [{
+    "id": "102290129340398",
+    "changes": [{
+      "field": "messages",
+      "value": {
+        "messaging_product": "whatsapp",
+        "metadata": {"display_phone_number": "15550783881", "phone_number_id": "106540352242922"},
+        "statuses": [{
+          "id": "wamid.1", "recipient_id": "919000000000", "status": "failed", "timestamp": "1670987490",
+          "type": "payment",
+          "payment": {
+            "reference_id": "order-42",
+            "amount": {"value": 21000, "offset": 100},
+            "currency": "INR",
+            "transaction": {
+              "id": "txn-1", "type": "upi", "status": "failed", "created_timestamp": 1670987480,
+              "updated_timestamp": 1670987490, "amount": {"value": 21000, "offset": 100}, "currency": "INR",
+              "method": {"type": "upi"}, "error": {"code": "insufficient-funds", "reason": "Insufficient funds"}
+            }
+          }
+        }]
+      }
+    }]
+  }]
+}`
+
+func TestAttachHooksToNotification_PaymentStatus(t *testing.T) {
+	t.Parallel()
+	var notification Notification
+	if err := json.Unmarshal([]byte(paymentStatusPayload), &notification); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+
+	var (
+		payment *Status
+		failed  bool
+	)
+	listener := NewEventListener()
+	listener.OnPaymentStatus(func(ctx context.Context, nctx *NotificationContext, status *Status) error {
+		payment = status
+
+		return nil
+	})
+	listener.OnMessageFailed(func(ctx context.Context, nctx *NotificationContext, status *Status) error {
+		failed = true
+
+		return nil
+	})
+
+	if err := AttachHooksToNotification(context.TODO(), &notification, listener.h, NoOpHooksErrorHandler); err != nil {
+		t.Fatalf("AttachHooksToNotification() error = %v", err)
+	}
+
+	if failed {
+		t.Error("a failed payment was passed to the message failed hook")
+	}
+
+	if payment == nil || payment.StatusValue != PaymentStatusFailed || payment.Payment == nil {
+		t.Fatalf("unexpected payment status %+v", payment)
+	}
+
+	p := payment.Payment
+	if p.ReferenceID != "order-42" || p.Amount.Float() != 210 || p.Transaction == nil ||
+		p.Transaction.Status != TransactionStatusFailed || p.Transaction.Error == nil ||
+		p.Transaction.Error.Code != "insufficient-funds" || p.Transaction.Method.Type != "upi" {
+		t.Errorf("unexpected payment %+v", p)
+	}
+}
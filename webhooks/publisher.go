@@ -0,0 +1,57 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package webhooks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+var ErrPublishNotification = errors.New("publish notification error")
+
+type (
+	// Publisher forwards the decoded notifications to an event bus such as Kafka, NATS or SQS, so
+	// that they are processed apart from the webhook handler.
+	Publisher interface {
+		Publish(ctx context.Context, notification *Notification) error
+	}
+
+	// PublisherFunc is a function that implements the Publisher interface.
+	PublisherFunc func(ctx context.Context, notification *Notification) error
+)
+
+// Publish calls fn(ctx, notification).
+func (fn PublisherFunc) Publish(ctx context.Context, notification *Notification) error {
+	return fn(ctx, notification)
+}
+
+// publish passes the notification to the Publisher of the options, if any. The error wraps
+// ErrPublishNotification.
+func publish(ctx context.Context, options *HandlerOptions, notification *Notification) error {
+	if options == nil || options.Publisher == nil {
+		return nil
+	}
+	if err := options.Publisher.Publish(ctx, notification); err != nil {
+		return fmt.Errorf("%v: %v", ErrPublishNotification, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,117 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestNotificationHandler_Publisher(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name      string
+		err       error
+		skip      bool
+		wantCode  int
+		wantHooks int
+	}{
+		{name: "published", wantCode: http.StatusOK, wantHooks: 1},
+		{name: "publish error", err: errors.New("broker down"), wantCode: http.StatusServiceUnavailable},
+		{name: "publish error skipped", err: errors.New("broker down"), skip: true, wantCode: http.StatusOK, wantHooks: 1},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			var (
+				order     []string
+				handled   error
+				published *Notification
+			)
+			publisher := PublisherFunc(func(ctx context.Context, notification *Notification) error {
+				order = append(order, "publish")
+				published = notification
+
+				return tt.err
+			})
+			neh := func(ctx context.Context, r *http.Request, err error) *NotificationErrHandlerResponse {
+				handled = err
+
+				return &NotificationErrHandlerResponse{StatusCode: http.StatusServiceUnavailable, Skip: tt.skip}
+			}
+			listener := NewEventListener(WithPublisher(publisher), WithNotificationErrorHandler(neh))
+			listener.OnTextMessage(func(ctx context.Context, nctx *NotificationContext, mctx *MessageContext,
+				text *Text,
+			) error {
+				order = append(order, "hook")
+
+				return nil
+			})
+
+			recorder := httptest.NewRecorder()
+			listener.NotificationHandler().ServeHTTP(recorder,
+				httptest.NewRequest(http.MethodPost, "/", bytes.NewBufferString(limitsPayload)))
+
+			if recorder.Code != tt.wantCode {
+				t.Errorf("got status %d, want %d", recorder.Code, tt.wantCode)
+			}
+			if published == nil || len(published.Entry) != 1 {
+				t.Fatalf("got published notification %+v, want the decoded notification", published)
+			}
+			if order[0] != "publish" || len(order)-1 != tt.wantHooks {
+				t.Errorf("got calls %v, want publish then %d hook calls", order, tt.wantHooks)
+			}
+			if tt.err != nil && (handled == nil || !strings.Contains(handled.Error(), ErrPublishNotification.Error())) {
+				t.Errorf("got error %v, want %v", handled, ErrPublishNotification)
+			}
+		})
+	}
+}
+
+func TestEventListener_GlobalHandlerPublisher(t *testing.T) {
+	t.Parallel()
+	var published, handled int
+	listener := NewEventListener(
+		WithPublisher(PublisherFunc(func(ctx context.Context, notification *Notification) error {
+			published++
+
+			return nil
+		})),
+		WithGlobalNotificationHandler(func(ctx context.Context, w http.ResponseWriter, n *Notification) error {
+			handled++
+
+			return nil
+		}),
+	)
+
+	listener.GlobalHandler().ServeHTTP(httptest.NewRecorder(),
+		httptest.NewRequest(http.MethodPost, "/", bytes.NewBufferString(limitsPayload)))
+
+	if published != 1 || handled != 1 {
+		t.Errorf("got %d published and %d handled, want 1 and 1", published, handled)
+	}
+}
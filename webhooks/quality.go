@@ -0,0 +1,99 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package webhooks
+
+import (
+	"context"
+	"errors"
+)
+
+type (
+	// QualityEvent is the event of a phone_number_quality_update change.
+	QualityEvent string
+
+	// MessagingLimitTier is the number of business initiated conversations a phone number can
+	// start in a rolling 24 hours window.
+	MessagingLimitTier string
+)
+
+const (
+	QualityEventFlagged   QualityEvent = "FLAGGED"
+	QualityEventUnflagged QualityEvent = "UNFLAGGED"
+	QualityEventDowngrade QualityEvent = "DOWNGRADE"
+	QualityEventUpgrade   QualityEvent = "UPGRADE"
+	QualityEventOnboarded QualityEvent = "ONBOARDING"
+)
+
+const (
+	MessagingLimitTier50        MessagingLimitTier = "TIER_50"
+	MessagingLimitTier250       MessagingLimitTier = "TIER_250"
+	MessagingLimitTier1K        MessagingLimitTier = "TIER_1K"
+	MessagingLimitTier10K       MessagingLimitTier = "TIER_10K"
+	MessagingLimitTier100K      MessagingLimitTier = "TIER_100K"
+	MessagingLimitTierUnlimited MessagingLimitTier = "TIER_UNLIMITED"
+)
+
+// Limit returns the number of conversations allowed by the tier, -1 for TIER_UNLIMITED and 0
+// for an unknown tier.
+func (tier MessagingLimitTier) Limit() int {
+	switch tier {
+	case MessagingLimitTier50:
+		return 50 //nolint:gomnd
+	case MessagingLimitTier250:
+		return 250 //nolint:gomnd
+	case MessagingLimitTier1K:
+		return 1000 //nolint:gomnd
+	case MessagingLimitTier10K:
+		return 10000 //nolint:gomnd
+	case MessagingLimitTier100K:
+		return 100000 //nolint:gomnd
+	case MessagingLimitTierUnlimited:
+		return -1
+	default:
+		return 0
+	}
+}
+
+type (
+	// PhoneNumberQualityUpdate is the value of a phone_number_quality_update change. It is sent
+	// when the quality of a phone number is flagged or unflagged and when its messaging limit
+	// changes.
+	//
+	// DisplayPhoneNumber, display_phone_number — the phone number the update is about.
+	// Event, event — what happened, see the QualityEvent constants.
+	// CurrentLimit, current_limit — the messaging limit tier after the update.
+	// OldLimit, old_limit — the messaging limit tier before the update, when it changed.
+	PhoneNumberQualityUpdate struct {
+		DisplayPhoneNumber string             `json:"display_phone_number,omitempty"`
+		Event              QualityEvent       `json:"event,omitempty"`
+		CurrentLimit       MessagingLimitTier `json:"current_limit,omitempty"`
+		OldLimit           MessagingLimitTier `json:"old_limit,omitempty"`
+	}
+
+	// OnPhoneNumberQualityUpdateHook is called for every phone_number_quality_update change.
+	OnPhoneNumberQualityUpdateHook func(ctx context.Context, nctx *NotificationContext,
+		update *PhoneNumberQualityUpdate) error
+)
+
+var ErrOnPhoneNumberQualityUpdateHook = errors.New("on phone number quality update hook error")
+
+func attachHooksToPhoneNumberQualityUpdate(ctx context.Context, id string, change *Change, hooks *Hooks) error {
+	return attachFieldHook(ctx, id, change, hooks.OnPhoneNumberQualityUpdateHook, ErrOnPhoneNumberQualityUpdateHook)
+}
@@ -0,0 +1,41 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package webhooks
+
+import "context"
+
+type rawPayloadKey struct{}
+
+// WithRawPayload returns a context that carries the raw payload of a notification. The
+// NotificationHandler sets it before decoding so that the BeforeFunc, the hooks and the
+// AfterFunc can read it with RawPayloadFromContext.
+func WithRawPayload(ctx context.Context, payload []byte) context.Context {
+	return context.WithValue(ctx, rawPayloadKey{}, payload)
+}
+
+// RawPayloadFromContext returns the JSON body of the notification being handled as it was
+// received, after the content encoding is removed and before any normalization. It returns
+// nil outside the NotificationHandler. The returned slice is shared and must not be modified,
+// copy it before keeping it beyond the hook.
+func RawPayloadFromContext(ctx context.Context) []byte {
+	payload, _ := ctx.Value(rawPayloadKey{}).([]byte)
+
+	return payload
+}
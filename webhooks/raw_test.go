@@ -0,0 +1,69 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package webhooks
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestNotificationHandler_RawPayload(t *testing.T) {
+	t.Parallel()
+	var before, hook, after []byte
+
+	listener := NewEventListener(
+		WithBeforeFunc(func(ctx context.Context, notification *Notification) error {
+			before = RawPayloadFromContext(ctx)
+
+			return nil
+		}),
+		WithAfterFunc(func(ctx context.Context, notification *Notification, err error) {
+			after = RawPayloadFromContext(ctx)
+		}),
+	)
+	listener.OnMessageStatusChange(func(ctx context.Context, nctx *NotificationContext, status *Status) error {
+		hook = RawPayloadFromContext(ctx)
+
+		return nil
+	})
+
+	var compressed bytes.Buffer
+	gz := gzip.NewWriter(&compressed)
+	_, _ = gz.Write([]byte(statusesPayload))
+	_ = gz.Close()
+
+	request := httptest.NewRequest(http.MethodPost, "/webhooks", &compressed)
+	request.Header.Set("Content-Encoding", "gzip")
+	listener.NotificationHandler().ServeHTTP(httptest.NewRecorder(), request)
+
+	for name, got := range map[string][]byte{"BeforeFunc": before, "hook": hook, "AfterFunc": after} {
+		if string(got) != statusesPayload {
+			t.Errorf("%s got raw payload %q, want %q", name, got, statusesPayload)
+		}
+	}
+
+	if got := RawPayloadFromContext(context.TODO()); got != nil {
+		t.Errorf("RawPayloadFromContext() outside the handler = %q, want nil", got)
+	}
+}
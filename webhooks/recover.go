@@ -0,0 +1,66 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package webhooks
+
+import (
+	"errors"
+	"net/http"
+	"runtime/debug"
+
+	"github.com/lowkruc/go-whatsapp-api/report"
+)
+
+// Recover is a middleware that recovers the panics of next, so that a panicking hook or
+// BeforeFunc does not take the server down. The panic is passed to heh as a *report.PanicError
+// carrying the stack trace, sent to the ErrorReporter of the options, and the SuccessResponse, by
+// default a 200, is written unless next already wrote a response, so that Meta keeps the
+// subscription enabled. NotificationHandler and EventListener.GlobalHandler already use it.
+func Recover(next http.Handler, heh HooksErrorHandler, options *HandlerOptions) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, request *http.Request) {
+		writer := newResponseWriter(w, options)
+		defer func() {
+			if r := recover(); r != nil {
+				err := recoveredPanic(r, heh)
+				reportError(request.Context(), options, err, "handler")
+				writer.success(options)
+			}
+		}()
+
+		next.ServeHTTP(writer, request)
+	})
+}
+
+// recoveredPanic returns the recovered value r as a *report.PanicError with the current stack,
+// after passing it to heh. It must be called from the deferred function that recovered r.
+func recoveredPanic(r any, heh HooksErrorHandler) *report.PanicError {
+	err := &report.PanicError{Value: r, Stack: debug.Stack()}
+	if heh != nil {
+		_ = heh(err)
+	}
+
+	return err
+}
+
+// isPanic reports whether err is a recovered panic.
+func isPanic(err error) bool {
+	var panicErr *report.PanicError
+
+	return errors.As(err, &panicErr)
+}
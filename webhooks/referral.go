@@ -0,0 +1,66 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package webhooks
+
+import (
+	"context"
+	"errors"
+)
+
+// Sources of the referrals, see Referral.SourceType.
+const (
+	ReferralSourceAd   = "ad"
+	ReferralSourcePost = "post"
+)
+
+var ErrOnAdReferralHook = errors.New("on ad referral hook error")
+
+// OnAdReferralHook is called for the messages sent from an ad or a post, the click-to-WhatsApp
+// ads, before the hook of their type. The referral carries the ad and the click ID, CtwaClid,
+// used to attribute the conversation.
+type OnAdReferralHook func(
+	ctx context.Context, nctx *NotificationContext, mctx *MessageContext, referral *Referral) error
+
+// IsAd reports whether the referral comes from an ad rather than a post.
+func (referral *Referral) IsAd() bool {
+	return referral != nil && referral.SourceType == ReferralSourceAd
+}
+
+// MediaURL returns the URL of the image or video of the ad or post, empty when it has none.
+func (referral *Referral) MediaURL() string {
+	if referral == nil {
+		return ""
+	}
+	if referral.MediaType == "video" {
+		return referral.VideoURL
+	}
+
+	return referral.ImageURL
+}
+
+// attachAdReferralHook calls the OnAdReferralHook for the messages with a referral.
+func attachAdReferralHook(ctx context.Context, nctx *NotificationContext, mctx *MessageContext,
+	message *Message, hooks *Hooks,
+) error {
+	if message.Referral == nil || hooks.OnAdReferralHook == nil {
+		return nil
+	}
+	return hooks.OnAdReferralHook(ctx, nctx, mctx, message.Referral)
+}
@@ -0,0 +1,140 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package webhooks
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/lowkruc/go-whatsapp-api/models"
+)
+
+const referralsPayload = `{
+  "object": "whatsapp_business_account",
+  "entry": [{
+    "id": "102290129340398",
+    "changes": [{
+      "field": "messages",
+      "value": {
+        "messaging_product": "whatsapp",
+        "metadata": {"display_phone_number": "15550783881", "phone_number_id": "106540352242922"},
+        "messages": [
+          {"from": "16505551234", "id": "wamid.1", "timestamp": "1750263773", "type": "text",
+           "text": {"body": "Is it still on sale?"},
+           "referral": {"source_url": "https://fb.me/3cr4Wqqkv", "source_type": "ad", "source_id": "120201234",
+             "headline": "Spring sale", "body": "Up to 50% off", "media_type": "video",
+             "video_url": "https://example.com/ad.mp4", "thumbnail_url": "https://example.com/ad.jpg",
+             "ctwa_clid": "ARAkLkA8rmlFeiCktEJQ-QTwRiyYHAFDLMNDBH0CD3qpjd0HR4irJ6LEkR7JwFF4XvnO2E4Nx0-eM-GABDLOPaOdRMv-_zfUQ2a"}},
+          {"from": "16505551234", "id": "wamid.2", "timestamp": "1750263774", "type": "image",
+           "image": {"id": "media.1", "mime_type": "image/jpeg", "sha256": "abc"},
+           "referral": {"source_url": "https://fb.me/post", "source_type": "post", "source_id": "987",
+             "media_type": "image", "image_url": "https://example.com/post.jpg"}}
+        ]
+      }
+    }]
+  }]
+}`
+
+func TestAttachHooksToNotification_AdReferral(t *testing.T) {
+	t.Parallel()
+	var notification Notification
+	if err := json.Unmarshal([]byte(referralsPayload), &notification); err != nil {
+		t.Fatalf("json.Unmarshal() error = %v", err)
+	}
+	if err := CheckStrict([]byte(referralsPayload)); err != nil {
+		t.Errorf("CheckStrict() error = %v", err)
+	}
+
+	var (
+		referrals []*Referral
+		handled   []string
+	)
+	listener := NewEventListener()
+	listener.OnAdReferral(func(ctx context.Context, nctx *NotificationContext, mctx *MessageContext,
+		referral *Referral,
+	) error {
+		referrals = append(referrals, referral)
+
+		return nil
+	})
+	listener.OnReferralMessage(func(ctx context.Context, nctx *NotificationContext, mctx *MessageContext,
+		text *Text, referral *Referral,
+	) error {
+		handled = append(handled, mctx.ID)
+
+		return nil
+	})
+	listener.OnMediaMessage(func(ctx context.Context, nctx *NotificationContext, mctx *MessageContext,
+		media *models.MediaInfo,
+	) error {
+		handled = append(handled, mctx.ID)
+
+		return nil
+	})
+
+	if err := AttachHooksToNotification(context.TODO(), &notification, listener.h, NoOpHooksErrorHandler); err != nil {
+		t.Fatalf("AttachHooksToNotification() error = %v", err)
+	}
+	if len(handled) != 2 {
+		t.Errorf("got messages %v, want both passed to the hooks of their type", handled)
+	}
+	if len(referrals) != 2 {
+		t.Fatalf("got %d referrals, want 2", len(referrals))
+	}
+	ad, post := referrals[0], referrals[1]
+	if !ad.IsAd() || ad.MediaURL() != "https://example.com/ad.mp4" || !strings.HasPrefix(ad.CtwaClid, "ARAkLkA8") ||
+		ad.Headline != "Spring sale" || ad.SourceID != "120201234" {
+		t.Errorf("unexpected ad referral %+v", ad)
+	}
+	if post.IsAd() || post.MediaURL() != "https://example.com/post.jpg" || post.CtwaClid != "" {
+		t.Errorf("unexpected post referral %+v", post)
+	}
+}
+
+func TestAttachHooksToMessage_AdReferralError(t *testing.T) {
+	t.Parallel()
+	var called bool
+	hooks := &Hooks{
+		OnAdReferralHook: func(ctx context.Context, nctx *NotificationContext, mctx *MessageContext,
+			referral *Referral,
+		) error {
+			return errors.New("analytics unavailable")
+		},
+		OnReferralMessageHook: func(ctx context.Context, nctx *NotificationContext, mctx *MessageContext,
+			text *Text, referral *Referral,
+		) error {
+			called = true
+
+			return nil
+		},
+	}
+	message := &Message{ID: "wamid.1", Type: "text", Text: &Text{Body: "hi"}, Referral: &Referral{SourceType: "ad"}}
+
+	err := attachHooksToMessage(context.TODO(), &NotificationContext{}, hooks, message)
+	if !errors.Is(err, ErrOnAdReferralHook) {
+		t.Errorf("attachHooksToMessage() error = %v, want %v", err, ErrOnAdReferralHook)
+	}
+	if !called {
+		t.Error("the referral message hook was not called after the ad referral hook failed")
+	}
+}
@@ -0,0 +1,79 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package webhooks
+
+import (
+	"context"
+	"errors"
+)
+
+var ErrOnReplyToBusinessMessageHook = errors.New("on reply to business message hook error")
+
+type (
+	// Reply is a message of a user quoting a message of the business. MessageID is the ID of the
+	// quoted message, as returned when it was sent, and From the phone number that sent it.
+	Reply struct {
+		MessageID string
+		From      string
+	}
+
+	// OnReplyToBusinessMessageHook is called for the messages that quote a message of the business,
+	// before the hook of their type, so that the conversations can be threaded. The product
+	// enquiries, which quote a product, are not replies.
+	OnReplyToBusinessMessageHook func(
+		ctx context.Context, nctx *NotificationContext, mctx *MessageContext, reply *Reply) error
+)
+
+// Reply returns the message of the business the message replies to, or nil.
+func (mctx *MessageContext) Reply() *Reply {
+	if mctx == nil || mctx.Ctx == nil || mctx.Ctx.ID == "" || mctx.Ctx.ReferredProduct != nil {
+		return nil
+	}
+
+	return &Reply{MessageID: mctx.Ctx.ID, From: mctx.Ctx.From}
+}
+
+// ReferredProduct returns the product the user asks about in a product enquiry, or nil.
+func (mctx *MessageContext) ReferredProduct() *ReferredProduct {
+	if mctx == nil || mctx.Ctx == nil {
+		return nil
+	}
+
+	return mctx.Ctx.ReferredProduct
+}
+
+// Forwarded reports whether the message was forwarded by the user.
+func (mctx *MessageContext) Forwarded() bool {
+	return mctx != nil && mctx.Ctx != nil && (mctx.Ctx.Forwarded || mctx.Ctx.FrequentlyForwarded)
+}
+
+// FrequentlyForwarded reports whether the message was forwarded more than 5 times.
+func (mctx *MessageContext) FrequentlyForwarded() bool {
+	return mctx != nil && mctx.Ctx != nil && mctx.Ctx.FrequentlyForwarded
+}
+
+// attachReplyHook calls the OnReplyToBusinessMessageHook for the replies.
+func attachReplyHook(ctx context.Context, nctx *NotificationContext, mctx *MessageContext, hooks *Hooks) error {
+	reply := mctx.Reply()
+	if reply == nil || hooks.OnReplyToBusinessMessageHook == nil {
+		return nil
+	}
+	return hooks.OnReplyToBusinessMessageHook(ctx, nctx, mctx, reply)
+}
@@ -0,0 +1,107 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package webhooks
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+)
+
+const repliesPayload = `{
+  "object": "whatsapp_business_account",
+  "entry": [{
+    "id": "102290129340398",
+    "changes": [{
+      "field": "messages",
+      "value": {
+        "messaging_product": "whatsapp",
+        "metadata": {"display_phone_number": "15550783881", "phone_number_id": "106540352242922"},
+        "messages": [
+          {"from": "16505551234", "id": "wamid.1", "timestamp": "1750263773", "type": "text",
+           "text": {"body": "Yes, that works"},
+           "context": {"from": "15550783881", "id": "wamid.business.1"}},
+          {"from": "16505551234", "id": "wamid.2", "timestamp": "1750263774", "type": "text",
+           "text": {"body": "Is it available in blue?"},
+           "context": {"from": "15550783881", "id": "wamid.business.2",
+             "referred_product": {"catalog_id": "catalog.1", "product_retailer_id": "shirt.1"}}},
+          {"from": "16505551234", "id": "wamid.3", "timestamp": "1750263775", "type": "text",
+           "text": {"body": "Look at this"},
+           "context": {"forwarded": true, "frequently_forwarded": true}}
+        ]
+      }
+    }]
+  }]
+}`
+
+func TestAttachHooksToNotification_Replies(t *testing.T) {
+	t.Parallel()
+	var notification Notification
+	if err := json.Unmarshal([]byte(repliesPayload), &notification); err != nil {
+		t.Fatalf("json.Unmarshal() error = %v", err)
+	}
+	if err := CheckStrict([]byte(repliesPayload)); err != nil {
+		t.Errorf("CheckStrict() error = %v", err)
+	}
+
+	var (
+		replies   []*Reply
+		texts     []string
+		enquiries []*ReferredProduct
+		forwarded []string
+	)
+	listener := NewEventListener()
+	listener.OnReplyToBusinessMessage(func(ctx context.Context, nctx *NotificationContext, mctx *MessageContext,
+		reply *Reply,
+	) error {
+		replies = append(replies, reply)
+
+		return nil
+	})
+	listener.OnTextMessage(func(ctx context.Context, nctx *NotificationContext, mctx *MessageContext, text *Text) error {
+		texts = append(texts, mctx.ID)
+		if mctx.Forwarded() && mctx.FrequentlyForwarded() {
+			forwarded = append(forwarded, mctx.ID)
+		}
+
+		return nil
+	})
+	listener.OnProductEnquiry(func(ctx context.Context, nctx *NotificationContext, mctx *MessageContext, text *Text) error {
+		enquiries = append(enquiries, mctx.ReferredProduct())
+
+		return nil
+	})
+
+	if err := AttachHooksToNotification(context.TODO(), &notification, listener.h, NoOpHooksErrorHandler); err != nil {
+		t.Fatalf("AttachHooksToNotification() error = %v", err)
+	}
+	if len(replies) != 1 || replies[0].MessageID != "wamid.business.1" || replies[0].From != "15550783881" {
+		t.Errorf("unexpected replies %+v", replies)
+	}
+	if len(texts) != 2 || texts[0] != "wamid.1" || texts[1] != "wamid.3" {
+		t.Errorf("got text messages %v, want the reply and the forwarded message", texts)
+	}
+	if len(enquiries) != 1 || enquiries[0] == nil || enquiries[0].ProductRetailerID != "shirt.1" {
+		t.Errorf("unexpected product enquiries %+v", enquiries)
+	}
+	if len(forwarded) != 1 || forwarded[0] != "wamid.3" {
+		t.Errorf("got forwarded messages %v, want wamid.3", forwarded)
+	}
+}
@@ -0,0 +1,151 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package webhooks
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/lowkruc/go-whatsapp-api/report"
+)
+
+type recordingReporter struct {
+	mu   sync.Mutex
+	errs []error
+	tags []report.Tags
+}
+
+func (r *recordingReporter) CaptureError(_ context.Context, err error, tags report.Tags) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.errs = append(r.errs, err)
+	r.tags = append(r.tags, tags)
+}
+
+func (r *recordingReporter) CaptureMessage(context.Context, string, report.Tags) {}
+
+func TestNotificationHandler_ReportsHookPanic(t *testing.T) {
+	t.Parallel()
+	reporter := &recordingReporter{}
+	hooks := &Hooks{
+		OnMessageReadHook: func(ctx context.Context, nctx *NotificationContext, status *Status) error {
+			panic("read hook")
+		},
+	}
+
+	handler := NotificationHandler(hooks, NoOpNotificationErrorHandler, NoOpHooksErrorHandler,
+		&HandlerOptions{ErrorReporter: reporter})
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/", strings.NewReader(statusesPayload)))
+
+	if len(reporter.errs) != 1 || reporter.tags[0][report.TagStage] != "hooks" {
+		t.Fatalf("reported %v with tags %v", reporter.errs, reporter.tags)
+	}
+
+	var panicErr *report.PanicError
+	if !errors.As(reporter.errs[0], &panicErr) || panicErr.Value != "read hook" || len(panicErr.Stack) == 0 {
+		t.Errorf("reported %v, want a *report.PanicError", reporter.errs[0])
+	}
+}
+
+func TestEventListener_GlobalHandler_ReportsPanic(t *testing.T) {
+	t.Parallel()
+	reporter := &recordingReporter{}
+	listener := NewEventListener(
+		WithNotificationErrorHandler(NoOpNotificationErrorHandler),
+		WithErrorReporter(reporter),
+		WithGlobalNotificationHandler(func(ctx context.Context, w http.ResponseWriter, n *Notification) error {
+			panic("global handler")
+		}),
+	)
+
+	rr := httptest.NewRecorder()
+	listener.GlobalHandler().ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/", strings.NewReader(statusesPayload)))
+
+	if len(reporter.errs) != 1 || reporter.tags[0][report.TagStage] != "global_handler" {
+		t.Errorf("reported %v with tags %v", reporter.errs, reporter.tags)
+	}
+}
+
+func TestNotificationHandler_RecoversPanics(t *testing.T) {
+	t.Parallel()
+	failing := func(context.Context, *http.Request, error) *NotificationErrHandlerResponse {
+		return &NotificationErrHandlerResponse{StatusCode: http.StatusInternalServerError}
+	}
+
+	tests := []struct {
+		name    string
+		hooks   *Hooks
+		options *HandlerOptions
+	}{
+		{
+			name: "hook",
+			hooks: &Hooks{
+				OnMessageReadHook: func(ctx context.Context, nctx *NotificationContext, status *Status) error {
+					panic("read hook")
+				},
+			},
+			options: &HandlerOptions{},
+		},
+		{
+			name:  "before func",
+			hooks: &Hooks{},
+			options: &HandlerOptions{BeforeFunc: func(ctx context.Context, notification *Notification) error {
+				panic("before func")
+			}},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			var handled []error
+			heh := func(err error) error {
+				handled = append(handled, err)
+
+				return err
+			}
+			reporter := &recordingReporter{}
+			tt.options.ErrorReporter = reporter
+
+			handler := NotificationHandler(tt.hooks, failing, heh, tt.options)
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/", strings.NewReader(statusesPayload)))
+
+			if rr.Code != http.StatusOK {
+				t.Errorf("got status %d, want %d", rr.Code, http.StatusOK)
+			}
+
+			var panicErr *report.PanicError
+			if len(handled) == 0 || !errors.As(handled[len(handled)-1], &panicErr) || len(panicErr.Stack) == 0 {
+				t.Fatalf("the hooks error handler got %v, want a *report.PanicError", handled)
+			}
+			if len(reporter.errs) != 1 {
+				t.Errorf("reported %v, want the panic once", reporter.errs)
+			}
+		})
+	}
+}
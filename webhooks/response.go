@@ -0,0 +1,146 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package webhooks
+
+import (
+	"net/http"
+)
+
+// Response is what the handlers write back to the whatsapp server. A zero StatusCode keeps the
+// status code the handler would write, headers are set before the status code is written.
+type Response struct {
+	StatusCode int
+	Headers    map[string]string
+	Body       []byte
+}
+
+// JSONAck returns a success Response with a small JSON body, for proxies and load balancers that
+// expect a body on a 200 response.
+func JSONAck() *Response {
+	return &Response{
+		StatusCode: http.StatusOK,
+		Headers:    map[string]string{"Content-Type": "application/json"},
+		Body:       []byte(`{"success":true}`),
+	}
+}
+
+// responseWriter wraps the http.ResponseWriter given to the handlers. Only the first status code is
+// written, later calls to WriteHeader are ignored, so that a GlobalNotificationHandler that writes
+// its own response does not make the listener write a second one.
+type responseWriter struct {
+	http.ResponseWriter
+	headers map[string]string
+	written bool
+	code    int
+}
+
+func newResponseWriter(writer http.ResponseWriter, options *HandlerOptions) *responseWriter {
+	if rw, ok := writer.(*responseWriter); ok {
+		return rw
+	}
+
+	rw := &responseWriter{ResponseWriter: writer}
+	if options != nil {
+		rw.headers = options.ResponseHeaders
+	}
+
+	return rw
+}
+
+func (rw *responseWriter) WriteHeader(code int) {
+	if rw.written {
+		return
+	}
+	rw.written = true
+	rw.code = code
+	for k, v := range rw.headers {
+		if rw.Header().Get(k) == "" {
+			rw.Header().Set(k, v)
+		}
+	}
+	rw.ResponseWriter.WriteHeader(code)
+}
+
+func (rw *responseWriter) Write(b []byte) (int, error) {
+	if !rw.written {
+		rw.WriteHeader(http.StatusOK)
+	}
+
+	return rw.ResponseWriter.Write(b)
+}
+
+// succeeded reports whether a 2xx status code has been written, Meta redelivers the notifications
+// answered with any other code.
+func (rw *responseWriter) succeeded() bool {
+	return rw.written && rw.code >= http.StatusOK && rw.code < http.StatusMultipleChoices
+}
+
+// Unwrap returns the wrapped http.ResponseWriter, it is used by http.ResponseController.
+func (rw *responseWriter) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
+
+// write writes the response if nothing has been written yet. When response is nil only the
+// status code is written.
+func (rw *responseWriter) write(code int, response *Response) {
+	if rw.written {
+		return
+	}
+
+	if response == nil {
+		rw.WriteHeader(code)
+
+		return
+	}
+
+	for k, v := range response.Headers {
+		rw.Header().Set(k, v)
+	}
+	if response.StatusCode != 0 {
+		code = response.StatusCode
+	}
+	rw.WriteHeader(code)
+	if len(response.Body) > 0 {
+		_, _ = rw.ResponseWriter.Write(response.Body)
+	}
+}
+
+// success writes the SuccessResponse of the options or a bare 200.
+func (rw *responseWriter) success(options *HandlerOptions) {
+	var response *Response
+	if options != nil {
+		response = options.SuccessResponse
+	}
+	rw.write(http.StatusOK, response)
+}
+
+// failure writes the headers and the body of the FailureResponse of the options with code, or the
+// bare code. It is used for failures that happen before a NotificationErrorHandler can be called,
+// like an unreadable body. The StatusCode of the FailureResponse is ignored, the code tells Meta
+// whether to redeliver the notification.
+func (rw *responseWriter) failure(options *HandlerOptions, code int) {
+	var response *Response
+	if options != nil && options.FailureResponse != nil {
+		failure := *options.FailureResponse
+		failure.StatusCode = 0
+		response = &failure
+	}
+	rw.write(code, response)
+}
@@ -0,0 +1,117 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package webhooks
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestNotificationHandler_Response(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name       string
+		body       string
+		options    *HandlerOptions
+		wantStatus int
+		wantBody   string
+		wantHeader string
+	}{
+		{
+			name:       "default success",
+			body:       statusesPayload,
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "json ack",
+			body:       statusesPayload,
+			options:    &HandlerOptions{SuccessResponse: JSONAck(), ResponseHeaders: map[string]string{"X-Ack": "1"}},
+			wantStatus: http.StatusOK,
+			wantBody:   `{"success":true}`,
+			wantHeader: "1",
+		},
+		{
+			name: "failure response",
+			body: `{`,
+			options: &HandlerOptions{
+				FailureResponse: &Response{Body: []byte("bad payload")},
+				ResponseHeaders: map[string]string{"X-Ack": "1"},
+			},
+			wantStatus: http.StatusInternalServerError,
+			wantBody:   "bad payload",
+			wantHeader: "1",
+		},
+		{
+			name: "failure response keeps the status code",
+			body: `{`,
+			options: &HandlerOptions{
+				FailureResponse: &Response{StatusCode: http.StatusOK, Body: []byte("bad payload")},
+			},
+			wantStatus: http.StatusInternalServerError,
+			wantBody:   "bad payload",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			handler := NotificationHandler(&Hooks{}, NoOpNotificationErrorHandler, NoOpHooksErrorHandler, tt.options)
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/", strings.NewReader(tt.body)))
+
+			if rr.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", rr.Code, tt.wantStatus)
+			}
+
+			if rr.Body.String() != tt.wantBody {
+				t.Errorf("body = %q, want %q", rr.Body.String(), tt.wantBody)
+			}
+
+			if got := rr.Header().Get("X-Ack"); got != tt.wantHeader {
+				t.Errorf("X-Ack = %q, want %q", got, tt.wantHeader)
+			}
+		})
+	}
+}
+
+func TestEventListener_GlobalHandler_Response(t *testing.T) {
+	t.Parallel()
+	listener := NewEventListener(
+		WithNotificationErrorHandler(NoOpNotificationErrorHandler),
+		WithSuccessResponse(JSONAck()),
+		WithGlobalNotificationHandler(func(ctx context.Context, w http.ResponseWriter, n *Notification) error {
+			w.WriteHeader(http.StatusAccepted)
+			_, _ = w.Write([]byte("queued"))
+
+			return nil
+		}),
+	)
+
+	rr := httptest.NewRecorder()
+	listener.GlobalHandler().ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/", strings.NewReader(statusesPayload)))
+
+	if rr.Code != http.StatusAccepted || rr.Body.String() != "queued" {
+		t.Errorf("response = %d %q, want %d %q", rr.Code, rr.Body.String(), http.StatusAccepted, "queued")
+	}
+}
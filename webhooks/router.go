@@ -0,0 +1,386 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package webhooks
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lowkruc/go-whatsapp-api/models"
+)
+
+// OnChangeHook is called with every change of a notification, before the typed hooks of its field.
+type OnChangeHook func(ctx context.Context, entryID string, change *Change) error
+
+// HandlerFunc is a registered handler bound to the event it is called for, it is what the
+// HandlerMiddleware wrap.
+type HandlerFunc func(ctx context.Context) error
+
+// HandlerMiddleware wraps a single registered handler, to log, measure or retry it for example.
+type HandlerMiddleware func(next HandlerFunc) HandlerFunc
+
+// HandlerOption configures a handler registered with OnText, OnMedia, OnStatus, OnField... See
+// routes for the events that take several handlers.
+type HandlerOption func(*handlerConfig)
+
+type handlerConfig struct {
+	priority    int
+	middlewares []HandlerMiddleware
+}
+
+// WithPriority sets the priority of a handler. The handlers with a higher priority are called
+// first, the ones with the same priority in registration order. The default priority is 0.
+func WithPriority(priority int) HandlerOption {
+	return func(config *handlerConfig) {
+		config.priority = priority
+	}
+}
+
+// WithHandlerMiddleware wraps the handler with the given middlewares, the first one is the
+// outermost. They only apply to this handler, not to the others registered for the same event.
+func WithHandlerMiddleware(middlewares ...HandlerMiddleware) HandlerOption {
+	return func(config *handlerConfig) {
+		config.middlewares = append(config.middlewares, middlewares...)
+	}
+}
+
+// RetryHandler is a HandlerMiddleware that calls the handler again, up to attempts times in total,
+// while it fails and the context is not done, waiting backoff between the attempts.
+func RetryHandler(attempts int, backoff time.Duration) HandlerMiddleware {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(ctx context.Context) error {
+			var err error
+			for attempt := 0; attempt < attempts || attempt == 0; attempt++ {
+				if attempt > 0 {
+					timer := time.NewTimer(backoff)
+					select {
+					case <-ctx.Done():
+						timer.Stop()
+
+						return err
+					case <-timer.C:
+					}
+				}
+				if err = next(ctx); err == nil {
+					return nil
+				}
+			}
+
+			return err
+		}
+	}
+}
+
+// registered is a handler with its configuration.
+type registered[H any] struct {
+	handler H
+	config  handlerConfig
+}
+
+// register adds the handler to the list, after the handlers with the same or a higher priority.
+func register[H any](list []registered[H], handler H, options []HandlerOption) []registered[H] {
+	entry := registered[H]{handler: handler}
+	for _, option := range options {
+		option(&entry.config)
+	}
+	index := len(list)
+	for index > 0 && list[index-1].config.priority < entry.config.priority {
+		index--
+	}
+	updated := make([]registered[H], 0, len(list)+1)
+	updated = append(updated, list[:index]...)
+	updated = append(updated, entry)
+
+	return append(updated, list[index:]...)
+}
+
+// call calls the handler through its middlewares.
+func (config handlerConfig) call(ctx context.Context, handler HandlerFunc) error {
+	for i := len(config.middlewares) - 1; i >= 0; i-- {
+		handler = config.middlewares[i](handler)
+	}
+
+	return handler(ctx)
+}
+
+// callAll calls every handler through its middlewares, call binds a handler to the event. Their
+// errors are returned as HookErrors, one per failed handler.
+func callAll[H any](ctx context.Context, handlers []registered[H],
+	call func(ctx context.Context, handler H) error,
+) error {
+	var errs HookErrors
+	for _, entry := range handlers {
+		handler := entry.handler
+		errs = append(errs, newHookErrors("", "", entry.config.call(ctx, func(ctx context.Context) error {
+			return call(ctx, handler)
+		}))...)
+	}
+	if len(errs) == 0 {
+		return nil
+	}
+
+	return errs
+}
+
+// routes keeps the handlers registered with the EventListener registration methods. Several
+// handlers can be registered for the same event, they are all called, by priority then
+// registration order, and their errors are returned as HookErrors, one per failed handler. The
+// events with registration methods are:
+//
+//   - the text messages, OnText
+//   - the media messages, OnMedia, OnImage, OnAudio, OnVideo, OnDocument and OnSticker
+//   - the interactive messages, OnInteractive
+//   - the location messages, OnLocation
+//   - the reactions, OnReaction
+//   - the statuses, OnStatus
+//   - the changes of every webhook field, OnField, with the change as received
+//
+// The other hooks, the typed hooks of the webhook fields among them, take a single function. The
+// handlers of a field registered with OnField decode its value with Change.DecodeValue.
+type routes struct {
+	mu          sync.RWMutex
+	text        []registered[OnTextMessageHook]
+	media       map[MessageType][]registered[OnMediaMessageHook]
+	interactive []registered[OnInteractiveMessageHook]
+	location    []registered[OnLocationMessageHook]
+	reaction    []registered[OnMessageReactionHook]
+	status      map[MessageStatus][]registered[OnMessageStatusChangeHook]
+	fields      map[string][]registered[OnChangeHook]
+}
+
+func newRoutes() *routes {
+	return &routes{
+		media:  make(map[MessageType][]registered[OnMediaMessageHook]),
+		status: make(map[MessageStatus][]registered[OnMessageStatusChangeHook]),
+		fields: make(map[string][]registered[OnChangeHook]),
+	}
+}
+
+func (r *routes) textHook(ctx context.Context, nctx *NotificationContext, mctx *MessageContext, text *Text) error {
+	r.mu.RLock()
+	handlers := r.text
+	r.mu.RUnlock()
+
+	return callAll(ctx, handlers, func(ctx context.Context, handler OnTextMessageHook) error {
+		return handler(ctx, nctx, mctx, text)
+	})
+}
+
+func (r *routes) mediaHook(ctx context.Context, nctx *NotificationContext, mctx *MessageContext,
+	media *models.MediaInfo,
+) error {
+	r.mu.RLock()
+	handlers := r.media[ParseMessageType(mctx.Type)]
+	r.mu.RUnlock()
+
+	return callAll(ctx, handlers, func(ctx context.Context, handler OnMediaMessageHook) error {
+		return handler(ctx, nctx, mctx, media)
+	})
+}
+
+func (r *routes) interactiveHook(ctx context.Context, nctx *NotificationContext, mctx *MessageContext,
+	interactive *Interactive,
+) error {
+	r.mu.RLock()
+	handlers := r.interactive
+	r.mu.RUnlock()
+
+	return callAll(ctx, handlers, func(ctx context.Context, handler OnInteractiveMessageHook) error {
+		return handler(ctx, nctx, mctx, interactive)
+	})
+}
+
+func (r *routes) locationHook(ctx context.Context, nctx *NotificationContext, mctx *MessageContext,
+	location *models.Location,
+) error {
+	r.mu.RLock()
+	handlers := r.location
+	r.mu.RUnlock()
+
+	return callAll(ctx, handlers, func(ctx context.Context, handler OnLocationMessageHook) error {
+		return handler(ctx, nctx, mctx, location)
+	})
+}
+
+func (r *routes) reactionHook(ctx context.Context, nctx *NotificationContext, mctx *MessageContext,
+	reaction *models.Reaction,
+) error {
+	r.mu.RLock()
+	handlers := r.reaction
+	r.mu.RUnlock()
+
+	return callAll(ctx, handlers, func(ctx context.Context, handler OnMessageReactionHook) error {
+		return handler(ctx, nctx, mctx, reaction)
+	})
+}
+
+// statusHook calls the handlers of all the statuses and of the status value, merged by priority,
+// the handlers of all the statuses first when the priorities are equal. A nil status, from a null
+// in the statuses, only goes to the handlers of all the statuses.
+func (r *routes) statusHook(ctx context.Context, nctx *NotificationContext, status *Status) error {
+	r.mu.RLock()
+	all := r.status[""]
+	var matching []registered[OnMessageStatusChangeHook]
+	if status != nil {
+		matching = r.status[MessageStatus(strings.ToLower(status.StatusValue))]
+	}
+	r.mu.RUnlock()
+	handlers := make([]registered[OnMessageStatusChangeHook], 0, len(all)+len(matching))
+	for len(all) > 0 || len(matching) > 0 {
+		if len(matching) == 0 || (len(all) > 0 && all[0].config.priority >= matching[0].config.priority) {
+			handlers, all = append(handlers, all[0]), all[1:]
+		} else {
+			handlers, matching = append(handlers, matching[0]), matching[1:]
+		}
+	}
+
+	return callAll(ctx, handlers, func(ctx context.Context, handler OnMessageStatusChangeHook) error {
+		return handler(ctx, nctx, status)
+	})
+}
+
+func (r *routes) changeHook(ctx context.Context, entryID string, change *Change) error {
+	r.mu.RLock()
+	handlers := r.fields[change.Field]
+	r.mu.RUnlock()
+
+	return callAll(ctx, handlers, func(ctx context.Context, handler OnChangeHook) error {
+		return handler(ctx, entryID, change)
+	})
+}
+
+func (ls *EventListener) routes() *routes {
+	if ls.r == nil {
+		ls.r = newRoutes()
+	}
+	if ls.h == nil {
+		ls.h = &Hooks{}
+	}
+
+	return ls.r
+}
+
+// OnText registers a handler of the text messages. Unlike OnTextMessage, which sets the only
+// hook, every handler registered with OnText is called, by priority then in registration order,
+// see WithPriority and WithHandlerMiddleware. OnTextMessage replaces the handlers registered with
+// OnText, and the other way around.
+func (ls *EventListener) OnText(handler OnTextMessageHook, options ...HandlerOption) {
+	r := ls.routes()
+	r.mu.Lock()
+	r.text = register(r.text, handler, options)
+	r.mu.Unlock()
+	ls.h.OnTextMessageHook = r.textHook
+}
+
+// OnMedia registers a handler of the media messages of the given type: audio, document, image,
+// sticker or video. The handlers share the OnMediaMessageHook, OnMediaMessage replaces them.
+func (ls *EventListener) OnMedia(messageType MessageType, handler OnMediaMessageHook,
+	options ...HandlerOption,
+) {
+	r := ls.routes()
+	r.mu.Lock()
+	r.media[messageType] = register(r.media[messageType], handler, options)
+	r.mu.Unlock()
+	ls.h.OnMediaMessageHook = r.mediaHook
+}
+
+// OnImage registers a handler of the image messages, see OnMedia.
+func (ls *EventListener) OnImage(handler OnMediaMessageHook, options ...HandlerOption) {
+	ls.OnMedia(ImageMessageType, handler, options...)
+}
+
+// OnAudio registers a handler of the audio messages and voice notes, see OnMedia.
+func (ls *EventListener) OnAudio(handler OnMediaMessageHook, options ...HandlerOption) {
+	ls.OnMedia(AudioMessageType, handler, options...)
+}
+
+// OnVideo registers a handler of the video messages, see OnMedia.
+func (ls *EventListener) OnVideo(handler OnMediaMessageHook, options ...HandlerOption) {
+	ls.OnMedia(VideoMessageType, handler, options...)
+}
+
+// OnDocument registers a handler of the document messages, see OnMedia.
+func (ls *EventListener) OnDocument(handler OnMediaMessageHook, options ...HandlerOption) {
+	ls.OnMedia(DocumentMessageType, handler, options...)
+}
+
+// OnSticker registers a handler of the sticker messages, see OnMedia.
+func (ls *EventListener) OnSticker(handler OnMediaMessageHook, options ...HandlerOption) {
+	ls.OnMedia(StickerMessageType, handler, options...)
+}
+
+// OnInteractive registers a handler of the interactive messages, see OnText. The handlers share
+// the OnInteractiveMessageHook, OnInteractiveMessage replaces them. The replies taken by
+// OnButtonReply, OnListReply and OnFlowReply do not reach them.
+func (ls *EventListener) OnInteractive(handler OnInteractiveMessageHook, options ...HandlerOption) {
+	r := ls.routes()
+	r.mu.Lock()
+	r.interactive = register(r.interactive, handler, options)
+	r.mu.Unlock()
+	ls.h.OnInteractiveMessageHook = r.interactiveHook
+}
+
+// OnLocation registers a handler of the location messages, see OnText. The handlers share the
+// OnLocationMessageHook, OnLocationMessage replaces them.
+func (ls *EventListener) OnLocation(handler OnLocationMessageHook, options ...HandlerOption) {
+	r := ls.routes()
+	r.mu.Lock()
+	r.location = register(r.location, handler, options)
+	r.mu.Unlock()
+	ls.h.OnLocationMessageHook = r.locationHook
+}
+
+// OnReaction registers a handler of the reactions, see OnText. The handlers share the
+// OnMessageReactionHook, OnMessageReaction replaces them.
+func (ls *EventListener) OnReaction(handler OnMessageReactionHook, options ...HandlerOption) {
+	r := ls.routes()
+	r.mu.Lock()
+	r.reaction = register(r.reaction, handler, options)
+	r.mu.Unlock()
+	ls.h.OnMessageReactionHook = r.reactionHook
+}
+
+// OnStatus registers a handler of the statuses with the given value, or of all the statuses when
+// status is empty. The handlers of all the statuses are called first among the handlers with the
+// same priority. The handlers share the OnMessageStatusChangeHook, OnMessageStatusChange replaces
+// them.
+func (ls *EventListener) OnStatus(status MessageStatus, handler OnMessageStatusChangeHook,
+	options ...HandlerOption,
+) {
+	status = MessageStatus(strings.ToLower(string(status)))
+	r := ls.routes()
+	r.mu.Lock()
+	r.status[status] = register(r.status[status], handler, options)
+	r.mu.Unlock()
+	ls.h.OnMessageStatusChangeHook = r.statusHook
+}
+
+// OnField registers a handler of the changes of a webhook field, like MessagesField. It is called
+// with the change as received, before the typed hooks of the field, and works for the fields
+// without typed support as well. See Change.DecodeValue.
+func (ls *EventListener) OnField(field string, handler OnChangeHook, options ...HandlerOption) {
+	r := ls.routes()
+	r.mu.Lock()
+	r.fields[field] = register(r.fields[field], handler, options)
+	r.mu.Unlock()
+	ls.h.OnChangeHook = r.changeHook
+}
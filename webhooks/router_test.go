@@ -0,0 +1,305 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package webhooks
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lowkruc/go-whatsapp-api/models"
+)
+
+const mixedMessagesPayload = `{
+  "object": "whatsapp_business_account",
+  "entry": [{
+    "id": "102290129340398",
+    "changes": [{
+      "field": "messages",
+      "value": {
+        "messaging_product": "whatsapp",
+        "metadata": {"display_phone_number": "15550783881", "phone_number_id": "106540352242922"},
+        "messages": [
+          {"from": "16505551234", "id": "wamid.1", "timestamp": "1750263773", "type": "text", "text": {"body": "hi"}},
+          {"from": "16505551234", "id": "wamid.2", "timestamp": "1750263774", "type": "image",
+           "image": {"id": "media.1", "mime_type": "image/jpeg"}},
+          {"from": "16505551234", "id": "wamid.3", "timestamp": "1750263775", "type": "audio",
+           "audio": {"id": "media.2", "mime_type": "audio/ogg"}}
+        ]
+      }
+    }, {
+      "field": "message_drafts",
+      "value": {"id": "draft.1"}
+    }]
+  }]
+}`
+
+func TestEventListener_Registration(t *testing.T) {
+	t.Parallel()
+	var calls []string
+	record := func(call string) { calls = append(calls, call) }
+
+	listener := NewEventListener()
+	listener.OnText(func(ctx context.Context, nctx *NotificationContext, mctx *MessageContext, text *Text) error {
+		record("text 1: " + text.Body)
+
+		return nil
+	})
+	listener.OnText(func(ctx context.Context, nctx *NotificationContext, mctx *MessageContext, text *Text) error {
+		record("text 2: " + text.Body)
+
+		return nil
+	})
+	listener.OnImage(func(ctx context.Context, nctx *NotificationContext, mctx *MessageContext, media *models.MediaInfo) error {
+		record("image: " + media.ID)
+
+		return nil
+	})
+	listener.OnField("message_drafts", func(ctx context.Context, entryID string, change *Change) error {
+		record("field: " + string(change.RawValue))
+
+		return nil
+	})
+
+	request := httptest.NewRequest(http.MethodPost, "/webhooks", strings.NewReader(mixedMessagesPayload))
+	listener.NotificationHandler().ServeHTTP(httptest.NewRecorder(), request)
+
+	want := []string{"text 1: hi", "text 2: hi", "image: media.1", `field: {"id": "draft.1"}`}
+	if strings.Join(calls, "\n") != strings.Join(want, "\n") {
+		t.Errorf("got calls\n%s\nwant\n%s", strings.Join(calls, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestEventListener_OnStatus(t *testing.T) {
+	t.Parallel()
+	var calls []string
+	errStop := errors.New("stop")
+
+	listener := NewEventListener()
+	listener.OnStatus("", func(ctx context.Context, nctx *NotificationContext, status *Status) error {
+		calls = append(calls, "all: "+status.ID)
+
+		return nil
+	})
+	listener.OnStatus(MessageStatusRead, func(ctx context.Context, nctx *NotificationContext, status *Status) error {
+		calls = append(calls, "read: "+status.ID)
+
+		return errStop
+	})
+	listener.OnStatus(MessageStatusRead, func(ctx context.Context, nctx *NotificationContext, status *Status) error {
+		calls = append(calls, "read after error: "+status.ID)
+
+		return nil
+	})
+
+	listener.OnStatus(MessageStatusRead, func(ctx context.Context, nctx *NotificationContext, status *Status) error {
+		calls = append(calls, "read failing again: "+status.ID)
+
+		return errStop
+	})
+	var handled []HookErrors
+	listener.HooksErrorHandler(func(err error) error {
+		var errs HookErrors
+		if errors.As(err, &errs) {
+			handled = append(handled, errs)
+		}
+
+		return nil
+	})
+
+	request := httptest.NewRequest(http.MethodPost, "/webhooks", strings.NewReader(statusesPayload))
+	listener.NotificationHandler().ServeHTTP(httptest.NewRecorder(), request)
+
+	want := []string{
+		"all: wamid.1", "all: wamid.2", "all: wamid.3", "read: wamid.3", "read after error: wamid.3",
+		"read failing again: wamid.3", "all: wamid.4",
+	}
+	if strings.Join(calls, ",") != strings.Join(want, ",") {
+		t.Errorf("got calls %v, want %v", calls, want)
+	}
+	if len(handled) != 1 || len(handled[0]) != 2 {
+		t.Fatalf("got hook errors %v, want one error per failed handler", handled)
+	}
+	for _, err := range handled[0] {
+		if err.Hook != "OnMessageStatusChangeHook" || err.ID != "wamid.3" || !errors.Is(err, errStop) {
+			t.Errorf("got hook error %+v, want the error of a read handler of wamid.3", err)
+		}
+	}
+}
+
+func TestEventListener_OnStatusNilStatus(t *testing.T) {
+	t.Parallel()
+	var calls int
+	listener := NewEventListener()
+	listener.OnStatus("", func(ctx context.Context, nctx *NotificationContext, status *Status) error {
+		calls++
+
+		return nil
+	})
+	listener.OnStatus(MessageStatusRead, func(ctx context.Context, nctx *NotificationContext, status *Status) error {
+		t.Error("the read handler was called with a nil status")
+
+		return nil
+	})
+	notification := &Notification{Entry: []*Entry{{ID: "1", Changes: []*Change{{Field: MessagesField,
+		Value: &Value{Statuses: []*Status{nil}}}}}}}
+
+	if err := AttachHooksToNotification(context.TODO(), notification, listener.h, NoOpHooksErrorHandler); err != nil {
+		t.Fatalf("AttachHooksToNotification() error = %v", err)
+	}
+	if calls != 1 {
+		t.Errorf("got %d calls of the handler of all the statuses, want 1", calls)
+	}
+}
+
+func TestEventListener_PriorityAndMiddleware(t *testing.T) {
+	t.Parallel()
+	var calls []string
+	tag := func(name string) HandlerMiddleware {
+		return func(next HandlerFunc) HandlerFunc {
+			return func(ctx context.Context) error {
+				calls = append(calls, name+" before")
+				err := next(ctx)
+				calls = append(calls, name+" after")
+
+				return err
+			}
+		}
+	}
+	failures := 2
+
+	listener := NewEventListener()
+	listener.OnStatus("", func(ctx context.Context, nctx *NotificationContext, status *Status) error {
+		calls = append(calls, "default: "+status.ID)
+
+		return nil
+	})
+	listener.OnStatus(MessageStatusRead, func(ctx context.Context, nctx *NotificationContext, status *Status) error {
+		calls = append(calls, "read: "+status.ID)
+
+		return nil
+	}, WithPriority(10), WithHandlerMiddleware(tag("outer"), tag("inner")))
+	listener.OnStatus("", func(ctx context.Context, nctx *NotificationContext, status *Status) error {
+		if status.ID != "wamid.3" {
+			return nil
+		}
+		calls = append(calls, "flaky: "+status.ID)
+		if failures > 0 {
+			failures--
+
+			return errors.New("temporary")
+		}
+
+		return nil
+	}, WithPriority(5), WithHandlerMiddleware(RetryHandler(3, time.Millisecond)))
+
+	if err := listener.h.OnMessageStatusChangeHook(context.Background(), &NotificationContext{},
+		&Status{ID: "wamid.3", StatusValue: "read"}); err != nil {
+		t.Fatalf("status hook: %v", err)
+	}
+
+	want := []string{
+		"outer before", "inner before", "read: wamid.3", "inner after", "outer after",
+		"flaky: wamid.3", "flaky: wamid.3", "flaky: wamid.3",
+		"default: wamid.3",
+	}
+	if strings.Join(calls, ",") != strings.Join(want, ",") {
+		t.Errorf("got calls %v, want %v", calls, want)
+	}
+}
+
+func TestEventListener_MessageRoutes(t *testing.T) {
+	t.Parallel()
+	var calls []string
+	listener := NewEventListener()
+	for _, name := range []string{"low", "high"} {
+		name := name
+		priority := 0
+		if name == "high" {
+			priority = 1
+		}
+		listener.OnInteractive(func(ctx context.Context, nctx *NotificationContext, mctx *MessageContext,
+			interactive *Interactive,
+		) error {
+			calls = append(calls, "interactive "+name)
+
+			return nil
+		}, WithPriority(priority))
+		listener.OnLocation(func(ctx context.Context, nctx *NotificationContext, mctx *MessageContext,
+			location *models.Location,
+		) error {
+			calls = append(calls, "location "+name)
+
+			return errors.New("location " + name)
+		}, WithPriority(priority))
+		listener.OnReaction(func(ctx context.Context, nctx *NotificationContext, mctx *MessageContext,
+			reaction *models.Reaction,
+		) error {
+			calls = append(calls, "reaction "+name)
+
+			return nil
+		}, WithPriority(priority))
+	}
+
+	ctx, nctx, mctx := context.Background(), &NotificationContext{}, &MessageContext{}
+	if err := listener.h.OnInteractiveMessageHook(ctx, nctx, mctx, &Interactive{}); err != nil {
+		t.Errorf("interactive hook: %v", err)
+	}
+	var errs HookErrors
+	if err := listener.h.OnLocationMessageHook(ctx, nctx, mctx, &models.Location{}); !errors.As(err, &errs) ||
+		len(errs) != 2 {
+		t.Errorf("location hook error = %v, want the 2 handler errors", err)
+	}
+	if err := listener.h.OnMessageReactionHook(ctx, nctx, mctx, &models.Reaction{}); err != nil {
+		t.Errorf("reaction hook: %v", err)
+	}
+
+	want := []string{
+		"interactive high", "interactive low", "location high", "location low", "reaction high", "reaction low",
+	}
+	if strings.Join(calls, ",") != strings.Join(want, ",") {
+		t.Errorf("got calls %v, want %v", calls, want)
+	}
+}
+
+func TestRetryHandler(t *testing.T) {
+	t.Parallel()
+	calls := 0
+	errFailed := errors.New("failed")
+	handler := RetryHandler(3, time.Millisecond)(func(ctx context.Context) error {
+		calls++
+
+		return errFailed
+	})
+	if err := handler(context.Background()); !errors.Is(err, errFailed) || calls != 3 {
+		t.Errorf("got error %v after %d calls, want %v after 3", err, calls, errFailed)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	calls = 0
+	if err := handler(ctx); !errors.Is(err, errFailed) || calls != 1 {
+		t.Errorf("got error %v after %d calls with a done context, want %v after 1", err, calls, errFailed)
+	}
+}
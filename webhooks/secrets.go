@@ -0,0 +1,123 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package webhooks
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// SecretProvider returns the app secrets the signatures are validated with, for example read from
+// a secret manager. It is called for every notification, implementations should cache.
+type SecretProvider func(ctx context.Context) ([]string, error)
+
+// ValidateSignatureWithSecrets validates the signature of the payload like ValidateSignature and
+// reports whether it matches any of the secrets. Every secret is compared in constant time, so
+// that an app secret can be rotated without rejecting the notifications signed with the other.
+func ValidateSignatureWithSecrets(payload []byte, signature string, secrets ...string) bool {
+	valid := false
+	for _, secret := range secrets {
+		// all the secrets are tried, the time does not tell which one matched.
+		if ValidateSignature(payload, signature, secret) {
+			valid = true
+		}
+	}
+
+	return valid
+}
+
+// signatureSecrets returns the secrets of the options: Secret, Secrets and the ones from the
+// SecretProvider. Empty secrets are skipped, unless no secret is set at all.
+func signatureSecrets(ctx context.Context, options *HandlerOptions) ([]string, error) {
+	var secrets []string
+	add := func(list ...string) {
+		for _, secret := range list {
+			if secret != "" {
+				secrets = append(secrets, secret)
+			}
+		}
+	}
+
+	add(options.Secret)
+	add(options.Secrets...)
+	if options.SecretProvider != nil {
+		provided, err := options.SecretProvider(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("%v: secret provider: %v", ErrInvalidSignature, err)
+		}
+		add(provided...)
+	}
+
+	if len(secrets) == 0 {
+		return []string{options.Secret}, nil
+	}
+
+	return secrets, nil
+}
+
+// SecretCache keeps the secrets of a SecretProvider, for example one reading a secret manager, so
+// that it is only asked again on Refresh. Set its Provider as the SecretProvider of the handler
+// options and call Refresh after rotating the app secret.
+type SecretCache struct {
+	mu      sync.Mutex
+	source  SecretProvider
+	secrets []string
+	loaded  bool
+}
+
+// NewSecretCache creates a SecretCache of the secrets of source, they are loaded on first use.
+func NewSecretCache(source SecretProvider) *SecretCache {
+	return &SecretCache{source: source}
+}
+
+// Provider returns the SecretProvider of the cached secrets.
+func (c *SecretCache) Provider() SecretProvider {
+	return func(ctx context.Context) ([]string, error) {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+
+		if !c.loaded {
+			if err := c.load(ctx); err != nil {
+				return nil, err
+			}
+		}
+
+		return c.secrets, nil
+	}
+}
+
+// Refresh asks the source for the secrets again. The cached secrets are kept when it fails.
+func (c *SecretCache) Refresh(ctx context.Context) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.load(ctx)
+}
+
+func (c *SecretCache) load(ctx context.Context) error {
+	secrets, err := c.source(ctx)
+	if err != nil {
+		return fmt.Errorf("load secrets: %v", err)
+	}
+	c.secrets, c.loaded = secrets, true
+
+	return nil
+}
@@ -0,0 +1,106 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package webhooks
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestNotificationHandler_SecretRotation(t *testing.T) {
+	t.Parallel()
+	body := []byte(`{"object":"whatsapp_business_account","entry":[]}`)
+	var providerErr error
+
+	tests := []struct {
+		name    string
+		options *HandlerOptions
+		secret  string
+		want    int
+	}{
+		{
+			name:    "current secret",
+			options: &HandlerOptions{ValidateSignature: true, Secret: "new", Secrets: []string{"old"}},
+			secret:  "new",
+			want:    http.StatusOK,
+		},
+		{
+			name:    "previous secret",
+			options: &HandlerOptions{ValidateSignature: true, Secret: "new", Secrets: []string{"old"}},
+			secret:  "old",
+			want:    http.StatusOK,
+		},
+		{
+			name:    "unknown secret",
+			options: &HandlerOptions{ValidateSignature: true, Secret: "new", Secrets: []string{"old"}},
+			secret:  "leaked",
+			want:    http.StatusUnauthorized,
+		},
+		{
+			name:    "empty secret is not tried when others are set",
+			options: &HandlerOptions{ValidateSignature: true, Secrets: []string{"old"}},
+			secret:  "",
+			want:    http.StatusUnauthorized,
+		},
+		{
+			name: "provider",
+			options: &HandlerOptions{ValidateSignature: true, SecretProvider: func(ctx context.Context) ([]string, error) {
+				return []string{"rotated"}, nil
+			}},
+			secret: "rotated",
+			want:   http.StatusOK,
+		},
+		{
+			name: "provider error",
+			options: &HandlerOptions{ValidateSignature: true, SecretProvider: func(ctx context.Context) ([]string, error) {
+				return nil, errors.New("vault sealed")
+			}},
+			secret: "rotated",
+			want:   http.StatusUnauthorized,
+		},
+	}
+
+	neh := func(ctx context.Context, request *http.Request, err error) *NotificationErrHandlerResponse {
+		if !errors.Is(err, ErrInvalidSignature) {
+			providerErr = err
+		}
+
+		return &NotificationErrHandlerResponse{StatusCode: http.StatusUnauthorized}
+	}
+
+	for _, tt := range tests {
+		handler := NotificationHandler(&Hooks{}, neh, NoOpHooksErrorHandler, tt.options)
+		request := httptest.NewRequest(http.MethodPost, "/webhooks", strings.NewReader(string(body)))
+		request.Header.Set(SignatureHeaderKey, "sha256="+computeSignature(body, tt.secret))
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, request)
+		if recorder.Code != tt.want {
+			t.Errorf("%s: got status code %d, want %d", tt.name, recorder.Code, tt.want)
+		}
+	}
+
+	if providerErr == nil || !strings.Contains(providerErr.Error(), "vault sealed") {
+		t.Errorf("got provider error %v, want the vault error", providerErr)
+	}
+}
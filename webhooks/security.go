@@ -0,0 +1,55 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package webhooks
+
+import (
+	"context"
+	"errors"
+)
+
+// Events of a security change.
+const (
+	SecurityEventPinChanged      = "PIN_CHANGED"
+	SecurityEventPinResetRequest = "PIN_RESET_REQUEST"
+	SecurityEventPinResetSuccess = "PIN_RESET_SUCCESS"
+)
+
+type (
+	// SecurityEvent is the value of a security change. It is sent when the two-step verification
+	// PIN of a phone number is changed, or a reset of the PIN is requested or done.
+	//
+	// DisplayPhoneNumber, display_phone_number — the phone number the event is about.
+	// Event, event — what happened, see the SecurityEvent constants.
+	// Requester, requester — the ID of the user that made the change, when it is known.
+	SecurityEvent struct {
+		DisplayPhoneNumber string `json:"display_phone_number,omitempty"`
+		Event              string `json:"event,omitempty"`
+		Requester          string `json:"requester,omitempty"`
+	}
+
+	// OnSecurityEventHook is called for every security change.
+	OnSecurityEventHook func(ctx context.Context, nctx *NotificationContext, event *SecurityEvent) error
+)
+
+var ErrOnSecurityEventHook = errors.New("on security event hook error")
+
+func attachHooksToSecurityEvent(ctx context.Context, id string, change *Change, hooks *Hooks) error {
+	return attachFieldHook(ctx, id, change, hooks.OnSecurityEventHook, ErrOnSecurityEventHook)
+}
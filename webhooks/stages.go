@@ -0,0 +1,93 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package webhooks
+
+import (
+	"context"
+	"time"
+)
+
+// Stage is a step of the handling of a notification that can be given a deadline.
+type Stage string
+
+const (
+	StageBefore Stage = "before"
+	StageHooks  Stage = "hooks"
+	StageAfter  Stage = "after"
+)
+
+type (
+	// StageTimeouts is the budget of every stage, zero means no deadline. The deadlines only
+	// cancel the context of the stage, a stage that ignores its context still runs to the end.
+	StageTimeouts struct {
+		Before time.Duration
+		Hooks  time.Duration
+		After  time.Duration
+	}
+
+	// OnStageExceededFunc is called with the time a stage took and its budget when it took longer.
+	OnStageExceededFunc func(ctx context.Context, stage Stage, elapsed, budget time.Duration)
+)
+
+// budget returns the timeout of the stage.
+func (t *StageTimeouts) budget(stage Stage) time.Duration {
+	if t == nil {
+		return 0
+	}
+
+	switch stage {
+	case StageBefore:
+		return t.Before
+	case StageHooks:
+		return t.Hooks
+	case StageAfter:
+		return t.After
+	default:
+		return 0
+	}
+}
+
+// runStage runs fn with a context derived from ctx, with the deadline of the stage if any, and
+// reports the stage when it exceeds its budget. The context of the stage is canceled when fn
+// returns, and earlier when ctx is, for example when the client disconnects.
+func runStage(ctx context.Context, options *HandlerOptions, stage Stage, fn func(ctx context.Context) error) error {
+	var budget time.Duration
+	if options != nil {
+		budget = options.StageTimeouts.budget(stage)
+	}
+
+	if budget <= 0 {
+		sctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+
+		return fn(sctx)
+	}
+
+	sctx, cancel := context.WithTimeout(ctx, budget)
+	defer cancel()
+
+	start := time.Now()
+	err := fn(sctx)
+	if elapsed := time.Since(start); elapsed > budget && options.OnStageExceeded != nil {
+		options.OnStageExceeded(ctx, stage, elapsed, budget)
+	}
+
+	return err
+}
@@ -0,0 +1,77 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package webhooks
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestNotificationHandler_StageTimeouts(t *testing.T) {
+	t.Parallel()
+	var (
+		mu       sync.Mutex
+		exceeded []Stage
+		before   bool
+		hooksErr error
+	)
+
+	listener := NewEventListener(
+		WithBeforeFunc(func(ctx context.Context, notification *Notification) error {
+			_, before = ctx.Deadline()
+
+			return nil
+		}),
+		WithStageTimeouts(&StageTimeouts{Before: time.Second, Hooks: 10 * time.Millisecond},
+			func(ctx context.Context, stage Stage, elapsed, budget time.Duration) {
+				mu.Lock()
+				exceeded = append(exceeded, stage)
+				mu.Unlock()
+			}),
+	)
+	listener.OnMessageStatusChange(func(ctx context.Context, nctx *NotificationContext, status *Status) error {
+		<-ctx.Done()
+		hooksErr = ctx.Err()
+
+		return nil
+	})
+
+	request := httptest.NewRequest(http.MethodPost, "/webhooks", strings.NewReader(statusesPayload))
+	recorder := httptest.NewRecorder()
+	listener.NotificationHandler().ServeHTTP(recorder, request)
+
+	if !before {
+		t.Error("the BeforeFunc context has no deadline")
+	}
+	if hooksErr != context.DeadlineExceeded {
+		t.Errorf("got hooks context error %v, want %v", hooksErr, context.DeadlineExceeded)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(exceeded) != 1 || exceeded[0] != StageHooks {
+		t.Errorf("got exceeded stages %v, want [hooks]", exceeded)
+	}
+}
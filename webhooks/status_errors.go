@@ -0,0 +1,111 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package webhooks
+
+import (
+	"fmt"
+	"strings"
+
+	werrors "github.com/lowkruc/go-whatsapp-api/errors"
+)
+
+// StatusError is the error of a failed message, built from the errors of its status. It unwraps to
+// the first werrors.Error so that errors.As works with both:
+//
+//	var statusErr *webhooks.StatusError
+//	if errors.As(status.Err(), &statusErr) && statusErr.Reason() == werrors.ReasonReengagement {
+//		// the customer service window is closed, send a template instead.
+//	}
+type StatusError struct {
+	MessageID   string
+	RecipientID string
+	Errors      []*werrors.Error
+}
+
+// Err returns the *StatusError of the status, nil when the status carries no error, which is the
+// case for all the statuses but failed.
+func (status *Status) Err() error {
+	if status == nil {
+		return nil
+	}
+
+	var errs []*werrors.Error
+	for _, e := range status.Errors {
+		if e != nil {
+			errs = append(errs, e)
+		}
+	}
+	if len(errs) == 0 {
+		return nil
+	}
+
+	return &StatusError{MessageID: status.ID, RecipientID: status.RecipientID, Errors: errs}
+}
+
+func (e *StatusError) Error() string {
+	messages := make([]string, len(e.Errors))
+	for i, err := range e.Errors {
+		message := err.Title
+		if message == "" {
+			message = err.Message
+		}
+		messages[i] = fmt.Sprintf("%d %s", err.Code, message)
+	}
+
+	return fmt.Sprintf("message %s to %s failed: %s", e.MessageID, e.RecipientID, strings.Join(messages, "; "))
+}
+
+// Unwrap returns the first error of the status.
+func (e *StatusError) Unwrap() error {
+	if len(e.Errors) == 0 {
+		return nil
+	}
+
+	return e.Errors[0]
+}
+
+// Code returns the code of the first error, 0 when there is none.
+func (e *StatusError) Code() int {
+	if len(e.Errors) == 0 {
+		return 0
+	}
+
+	return e.Errors[0].Code
+}
+
+// HasCode reports whether any of the errors has the code.
+func (e *StatusError) HasCode(code int) bool {
+	for _, err := range e.Errors {
+		if err.Code == code {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Reason classifies the first error, see werrors.Classify.
+func (e *StatusError) Reason() werrors.Reason {
+	if len(e.Errors) == 0 {
+		return werrors.ReasonUnknown
+	}
+
+	return werrors.Classify(e.Errors[0])
+}
@@ -0,0 +1,123 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package webhooks
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	werrors "github.com/lowkruc/go-whatsapp-api/errors"
+)
+
+const failedStatusPayload = `{
+  "object": "whatsapp_business_account",
+  "entry": [{
+    "id": "102290129340398",
+    "changes": [{
+      "field": "messages",
+      "value": {
+        "messaging_product": "whatsapp",
+        "metadata": {"display_phone_number": "15550783881", "phone_number_id": "106540352242922"},
+        "statuses": [{
+          "id": "wamid.1", "recipient_id": "16505551234", "status": "failed", "timestamp": "1700000000",
+          "errors": [{
+            "code": 131050,
+            "title": "Unable to deliver the message. This recipient has chosen to stop receiving marketing messages on WhatsApp from your business",
+            "message": "Unable to deliver the message.",
+            "error_data": {"details": "The user has stopped marketing messages from this business."},
+            "href": "https://developers.facebook.com/docs/whatsapp/cloud-api/support/error-codes/"
+          }]
+        }]
+      }
+    }]
+  }]
+}`
+
+func TestAttachHooksToNotification_FailedStatusErrors(t *testing.T) {
+	t.Parallel()
+	var notification Notification
+	if err := json.Unmarshal([]byte(failedStatusPayload), &notification); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+
+	var failed *Status
+	optOuts := NewMarketingOptOuts()
+	listener := NewEventListener()
+	listener.OnMessageFailed(optOuts.FailedHook(func(ctx context.Context, nctx *NotificationContext,
+		status *Status,
+	) error {
+		failed = status
+
+		return nil
+	}))
+
+	if err := AttachHooksToNotification(context.TODO(), &notification, listener.h, NoOpHooksErrorHandler); err != nil {
+		t.Fatalf("AttachHooksToNotification() error = %v", err)
+	}
+
+	if failed == nil || len(failed.Errors) != 1 {
+		t.Fatalf("unexpected failed status %+v", failed)
+	}
+
+	e := failed.Errors[0]
+	if e.Title == "" || e.Href == "" || e.Data == nil || e.Data.Details == "" {
+		t.Errorf("error fields are not decoded: %+v", e)
+	}
+
+	if reasons := failed.FailureReasons(); len(reasons) != 1 || reasons[0] != werrors.ReasonMarketingOptOut {
+		t.Errorf("FailureReasons() = %v, want [%s]", reasons, werrors.ReasonMarketingOptOut)
+	}
+
+	if !optOuts.OptedOut("16505551234") {
+		t.Error("the recipient that stopped marketing messages is not opted out")
+	}
+}
+
+func TestStatus_Err(t *testing.T) {
+	t.Parallel()
+	if err := (&Status{ID: "wamid.1", StatusValue: "delivered"}).Err(); err != nil {
+		t.Errorf("Err() = %v, want nil for a status without errors", err)
+	}
+
+	status := &Status{ID: "wamid.1", RecipientID: "16505551234", StatusValue: "failed", Errors: []*werrors.Error{
+		{Code: 131047, Title: "Re-engagement message", Message: "Re-engagement message"},
+		{Code: 131026, Message: "Message undeliverable"},
+	}}
+	err := status.Err()
+	var statusErr *StatusError
+	if !errors.As(err, &statusErr) {
+		t.Fatalf("errors.As(%v) did not match a *StatusError", err)
+	}
+	if statusErr.Code() != 131047 || statusErr.Reason() != werrors.ReasonReengagement ||
+		!statusErr.HasCode(131026) || statusErr.HasCode(131050) {
+		t.Errorf("unexpected status error %+v", statusErr)
+	}
+	want := "message wamid.1 to 16505551234 failed: 131047 Re-engagement message; 131026 Message undeliverable"
+	if err.Error() != want {
+		t.Errorf("Error() = %q, want %q", err.Error(), want)
+	}
+
+	var apiErr *werrors.Error
+	if !errors.As(err, &apiErr) || apiErr.Code != 131047 {
+		t.Errorf("errors.As() = %v, want the first error of the status", apiErr)
+	}
+}
@@ -0,0 +1,107 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package webhooks
+
+import (
+	"strconv"
+	"time"
+)
+
+// Pricing categories of the conversations, see Pricing.Category.
+const (
+	PricingCategoryAuthentication     = "authentication"
+	PricingCategoryMarketing          = "marketing"
+	PricingCategoryUtility            = "utility"
+	PricingCategoryService            = "service"
+	PricingCategoryReferralConversion = "referral_conversion"
+)
+
+// ConversationID returns the ID of the conversation the status belongs to, empty for the statuses
+// without conversation, like read.
+func (status *Status) ConversationID() string {
+	if status == nil || status.Conversation == nil {
+		return ""
+	}
+
+	return status.Conversation.ID
+}
+
+// ConversationOrigin returns the origin type of the conversation, like marketing or service,
+// empty when unknown.
+func (status *Status) ConversationOrigin() string {
+	if status == nil || status.Conversation == nil || status.Conversation.Origin == nil {
+		return ""
+	}
+
+	return status.Conversation.Origin.Type
+}
+
+// ConversationExpiresAt returns when the conversation expires, the zero time when the status has
+// no expiration_timestamp, which is only sent with the first status of a conversation.
+func (status *Status) ConversationExpiresAt() time.Time {
+	if status == nil || status.Conversation == nil {
+		return time.Time{}
+	}
+	seconds, err := strconv.ParseInt(status.Conversation.Expiry, 10, 64)
+	if err != nil {
+		return time.Time{}
+	}
+
+	return time.Unix(seconds, 0)
+}
+
+// IsBillable reports whether the message of the status is billable, false when the status has
+// no pricing.
+func (status *Status) IsBillable() bool {
+	return status != nil && status.Pricing != nil && status.Pricing.Billable
+}
+
+// PricingCategory returns the pricing category of the message, empty when the status has no
+// pricing.
+func (status *Status) PricingCategory() string {
+	if status == nil || status.Pricing == nil {
+		return ""
+	}
+
+	return status.Pricing.Category
+}
+
+// PricingModel returns the pricing model of the message, like CBP or PMP, empty when the status
+// has no pricing.
+func (status *Status) PricingModel() string {
+	if status == nil || status.Pricing == nil {
+		return ""
+	}
+
+	return status.Pricing.PricingModel
+}
+
+// Time returns the time of the status, the zero time when its timestamp is invalid.
+func (status *Status) Time() time.Time {
+	if status == nil {
+		return time.Time{}
+	}
+	seconds, err := strconv.ParseInt(status.Timestamp, 10, 64)
+	if err != nil {
+		return time.Time{}
+	}
+
+	return time.Unix(seconds, 0)
+}
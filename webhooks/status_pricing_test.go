@@ -0,0 +1,56 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package webhooks
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestStatus_Pricing(t *testing.T) {
+	t.Parallel()
+	var status Status
+	payload := `{"id": "wamid.1", "status": "sent", "timestamp": "1750263773", "recipient_id": "16505551234",
+	  "conversation": {"id": "conv1", "expiration_timestamp": "1750350173", "origin": {"type": "marketing"}},
+	  "pricing": {"billable": true, "pricing_model": "CBP", "category": "marketing"}}`
+	if err := json.Unmarshal([]byte(payload), &status); err != nil {
+		t.Fatalf("json.Unmarshal() error = %v", err)
+	}
+
+	if status.ConversationID() != "conv1" || status.ConversationOrigin() != PricingCategoryMarketing ||
+		!status.ConversationExpiresAt().Equal(time.Unix(1750350173, 0)) || !status.Time().Equal(time.Unix(1750263773, 0)) {
+		t.Errorf("unexpected conversation of %+v", status)
+	}
+	if !status.IsBillable() || status.PricingCategory() != PricingCategoryMarketing || status.PricingModel() != "CBP" {
+		t.Errorf("unexpected pricing %+v", status.Pricing)
+	}
+
+	read := &Status{ID: "wamid.1", StatusValue: "read", Timestamp: "now"}
+	if read.ConversationID() != "" || read.ConversationOrigin() != "" || !read.ConversationExpiresAt().IsZero() ||
+		read.IsBillable() || read.PricingCategory() != "" || read.PricingModel() != "" || !read.Time().IsZero() {
+		t.Errorf("unexpected helpers of a status without conversation %+v", read)
+	}
+
+	var nilStatus *Status
+	if nilStatus.IsBillable() || nilStatus.ConversationID() != "" {
+		t.Error("nil status helpers returned values")
+	}
+}
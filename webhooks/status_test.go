@@ -0,0 +1,174 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package webhooks
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+const statusesPayload = `{
+  "object": "whatsapp_business_account",
+  "entry": [{
+    "id": "102290129340398",
+    "changes": [{
+      "field": "messages",
+      "value": {
+        "messaging_product": "whatsapp",
+        "metadata": {"display_phone_number": "15550783881", "phone_number_id": "106540352242922"},
+        "statuses": [
+          {"id": "wamid.1", "status": "sent", "timestamp": "1750263773", "recipient_id": "16505551234",
+           "conversation": {"id": "conv1", "origin": {"type": "service"}},
+           "pricing": {"billable": true, "pricing_model": "CBP", "category": "service"}},
+          {"id": "wamid.2", "status": "delivered", "timestamp": "1750263774", "recipient_id": "16505551234"},
+          {"id": "wamid.3", "status": "read", "timestamp": "1750263775", "recipient_id": "16505551234"},
+          {"id": "wamid.4", "status": "failed", "timestamp": "1750263776", "recipient_id": "16505551234",
+           "errors": [{"code": 131026, "title": "Message undeliverable"}]}
+        ]
+      }
+    }]
+  }]
+}`
+
+func TestAttachHooksToNotification_Statuses(t *testing.T) {
+	t.Parallel()
+	var notification Notification
+	if err := json.Unmarshal([]byte(statusesPayload), &notification); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+
+	var changed int
+	got := map[MessageStatus]*Status{}
+	record := func(s MessageStatus) func(context.Context, *NotificationContext, *Status) error {
+		return func(ctx context.Context, nctx *NotificationContext, status *Status) error {
+			got[s] = status
+
+			return nil
+		}
+	}
+
+	listener := &EventListener{}
+	listener.OnMessageStatusChange(func(ctx context.Context, nctx *NotificationContext, status *Status) error {
+		changed++
+
+		return nil
+	})
+	listener.OnMessageSent(record(MessageStatusSent))
+	listener.OnMessageDelivered(record(MessageStatusDelivered))
+	listener.OnMessageRead(record(MessageStatusRead))
+	listener.OnMessageFailed(record(MessageStatusFailed))
+
+	if err := AttachHooksToNotification(context.TODO(), &notification, listener.h, NoOpHooksErrorHandler); err != nil {
+		t.Fatalf("AttachHooksToNotification() error = %v", err)
+	}
+
+	if changed != 4 {
+		t.Errorf("OnMessageStatusChangeHook called %d times, want 4", changed)
+	}
+
+	if s := got[MessageStatusSent]; s == nil || s.Conversation == nil || s.Conversation.ID != "conv1" ||
+		s.Pricing == nil || !s.Pricing.Billable {
+		t.Errorf("unexpected sent status %+v", s)
+	}
+
+	if s := got[MessageStatusDelivered]; s == nil || s.ID != "wamid.2" {
+		t.Errorf("unexpected delivered status %+v", s)
+	}
+
+	if s := got[MessageStatusRead]; s == nil || s.ID != "wamid.3" {
+		t.Errorf("unexpected read status %+v", s)
+	}
+
+	if s := got[MessageStatusFailed]; s == nil || len(s.Errors) != 1 || s.Errors[0].Code != 131026 {
+		t.Errorf("unexpected failed status %+v", s)
+	}
+}
+
+func TestChange_UnmarshalJSON_Statuses(t *testing.T) {
+	t.Parallel()
+	var statuses, mixed Notification
+	if err := json.Unmarshal([]byte(statusesPayload), &statuses); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+
+	change := statuses.Entry[0].Changes[0]
+	if !json.Valid(change.RawValue) || !strings.Contains(string(change.RawValue), `"statuses"`) {
+		t.Errorf("statuses change kept the raw value %s, want the value as received", change.RawValue)
+	}
+
+	if change.Field != MessagesField || change.Value == nil || len(change.Value.Statuses) != 4 ||
+		change.Value.Metadata == nil || change.Value.Metadata.PhoneNumberID != "106540352242922" {
+		t.Fatalf("unexpected change %+v", change)
+	}
+
+	var value Value
+	if err := change.DecodeValue(&value); err != nil || len(value.Statuses) != 4 {
+		t.Errorf("DecodeValue() = %+v, %v", value, err)
+	}
+
+	// the keys the models do not know are kept in the raw value.
+	var unknown Notification
+	if err := json.Unmarshal([]byte(strings.Replace(statusesPayload, `"statuses": [`,
+		`"recipient_note": "vip", "statuses": [`, 1)), &unknown); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	var extra struct {
+		RecipientNote string `json:"recipient_note"`
+	}
+	if err := unknown.Entry[0].Changes[0].DecodeValue(&extra); err != nil || extra.RecipientNote != "vip" {
+		t.Errorf("DecodeValue() = %+v, %v, want the unknown key kept", extra, err)
+	}
+
+	// the same change with a message as well.
+	payload := strings.Replace(statusesPayload, `"statuses": [`,
+		`"messages": [{"from": "16505551234", "id": "wamid.5", "timestamp": "1750263777", "type": "text",
+		 "text": {"body": "hi"}}], "statuses": [`, 1)
+	if err := json.Unmarshal([]byte(payload), &mixed); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+
+	change = mixed.Entry[0].Changes[0]
+	if change.RawValue == nil || change.Value == nil || len(change.Value.Messages) != 1 ||
+		len(change.Value.Statuses) != 4 {
+		t.Errorf("unexpected change %+v", change)
+	}
+}
+
+func BenchmarkChange_UnmarshalJSON(b *testing.B) {
+	var notification struct {
+		Entry []struct {
+			Changes []json.RawMessage `json:"changes"`
+		} `json:"entry"`
+	}
+	if err := json.Unmarshal([]byte(statusesPayload), &notification); err != nil {
+		b.Fatal(err)
+	}
+	data := notification.Entry[0].Changes[0]
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		var change Change
+		if err := change.UnmarshalJSON(data); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
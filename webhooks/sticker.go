@@ -0,0 +1,60 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package webhooks
+
+import (
+	"context"
+
+	"github.com/lowkruc/go-whatsapp-api/models"
+)
+
+type (
+	// Sticker is a sticker message.
+	//
+	// ID, id — the media ID, to download the sticker.
+	// MimeType, mime_type — image/webp.
+	// Sha256, sha256 — the hash of the file.
+	// Animated, animated — whether the sticker is animated.
+	Sticker struct {
+		ID       string `json:"id,omitempty"`
+		MimeType string `json:"mime_type,omitempty"`
+		Sha256   string `json:"sha256,omitempty"`
+		Animated bool   `json:"animated,omitempty"`
+	}
+
+	// OnStickerMessageHook is called for the sticker messages. Without it, the stickers go to the
+	// OnMediaMessageHook.
+	OnStickerMessageHook func(
+		ctx context.Context, nctx *NotificationContext, mctx *MessageContext, sticker *Sticker) error
+)
+
+// newSticker returns the Sticker of the media of a sticker message, nil when media is nil.
+func newSticker(media *models.MediaInfo) *Sticker {
+	if media == nil {
+		return nil
+	}
+
+	return &Sticker{
+		ID:       media.ID,
+		MimeType: media.MimeType,
+		Sha256:   media.Sha256,
+		Animated: media.Animated,
+	}
+}
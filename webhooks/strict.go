@@ -0,0 +1,184 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package webhooks
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ErrStrictDecoding is wrapped by the StrictDecodingError returned by CheckStrict.
+var ErrStrictDecoding = errors.New("notification does not match the models")
+
+// StrictDecodingError lists the parts of a notification that the models do not know: fields of
+// the payload the models do not have, webhook fields without typed support and message types
+// not handled by the hooks. Decoding ignores all of them, new shapes of the Cloud API payloads
+// show up here first. The decoder stops at the first unknown key, so UnknownKeys has at most one
+// key for the envelope and one for each change.
+type StrictDecodingError struct {
+	UnknownKeys         []string
+	UnknownFields       []string
+	UnknownMessageTypes []string
+}
+
+func (e *StrictDecodingError) Unwrap() error {
+	return ErrStrictDecoding
+}
+
+func (e *StrictDecodingError) Error() string {
+	var parts []string
+	if len(e.UnknownKeys) > 0 {
+		parts = append(parts, "unknown keys: "+strings.Join(e.UnknownKeys, ", "))
+	}
+	if len(e.UnknownFields) > 0 {
+		parts = append(parts, "unknown webhook fields: "+strings.Join(e.UnknownFields, ", "))
+	}
+	if len(e.UnknownMessageTypes) > 0 {
+		parts = append(parts, "unknown message types: "+strings.Join(e.UnknownMessageTypes, ", "))
+	}
+
+	return fmt.Sprintf("%v: %s", ErrStrictDecoding, strings.Join(parts, "; "))
+}
+
+// fieldModels returns the model each webhook field with typed support is decoded into.
+//
+//nolint:gochecknoglobals
+var fieldModels = map[string]func() any{
+	MessagesField:                    func() any { return &Value{} },
+	MessagingHandoversField:          func() any { return &Handover{} },
+	PartnerSolutionsField:            func() any { return &PartnerSolution{} },
+	MessageTemplateStatusUpdateField: func() any { return &TemplateStatusUpdate{} },
+	TemplateCategoryUpdateField:      func() any { return &TemplateCategoryUpdate{} },
+	PhoneNumberQualityUpdateField:    func() any { return &PhoneNumberQualityUpdate{} },
+	AccountUpdateField:               func() any { return &AccountUpdate{} },
+	AccountReviewUpdateField:         func() any { return &AccountReviewUpdate{} },
+	BusinessCapabilityUpdateField:    func() any { return &BusinessCapabilityUpdate{} },
+	SecurityField:                    func() any { return &SecurityEvent{} },
+	FlowsField:                       func() any { return &FlowEvent{} },
+	CallsField:                       func() any { return &CallEvent{} },
+	UserPreferencesField:             func() any { return &UserPreferencesUpdate{} },
+	SMBMessageEchoesField:            func() any { return &MessageEchoes{} },
+}
+
+// CheckStrict decodes the notification payload with unknown keys disallowed and reports the
+// parts the models do not know as a *StrictDecodingError. It returns other errors when data is
+// not a notification at all, and nil when the payload fully matches the models.
+func CheckStrict(data []byte) error {
+	var envelope struct {
+		Object string `json:"object"`
+		Entry  []struct {
+			ID      string `json:"id"`
+			Changes []struct {
+				Value json.RawMessage `json:"value"`
+				Field string          `json:"field"`
+			} `json:"changes"`
+		} `json:"entry"`
+	}
+
+	strictErr := &StrictDecodingError{}
+	if err := decodeStrict(data, &envelope); err != nil {
+		key, ok := unknownKey(err)
+		if !ok {
+			return err
+		}
+		strictErr.UnknownKeys = append(strictErr.UnknownKeys, key)
+		// the unknown key stops the strict decoder, the rest is checked on the lenient decoding.
+		if err = json.Unmarshal(data, &envelope); err != nil {
+			return err
+		}
+	}
+
+	fields := map[string]bool{}
+	messageTypes := map[string]bool{}
+	for i, entry := range envelope.Entry {
+		for j, change := range entry.Changes {
+			model, ok := fieldModels[change.Field]
+			if !ok {
+				fields[change.Field] = true
+
+				continue
+			}
+			if len(change.Value) == 0 || string(change.Value) == "null" {
+				continue
+			}
+
+			value := model()
+			if err := decodeStrict(change.Value, value); err != nil {
+				key, ok := unknownKey(err)
+				if !ok {
+					return fmt.Errorf("entry %d change %d: %v", i, j, err)
+				}
+				strictErr.UnknownKeys = append(strictErr.UnknownKeys,
+					fmt.Sprintf("%s: %s", change.Field, key))
+			}
+
+			if v, ok := value.(*Value); ok {
+				for _, message := range v.Messages {
+					if message != nil && ParseMessageType(message.Type) == "" {
+						messageTypes[message.Type] = true
+					}
+				}
+			}
+		}
+	}
+
+	strictErr.UnknownFields = sortedKeys(fields)
+	strictErr.UnknownMessageTypes = sortedKeys(messageTypes)
+	if len(strictErr.UnknownKeys) == 0 && len(strictErr.UnknownFields) == 0 &&
+		len(strictErr.UnknownMessageTypes) == 0 {
+		return nil
+	}
+
+	return strictErr
+}
+
+func decodeStrict(data []byte, v any) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+
+	return decoder.Decode(v)
+}
+
+// unknownKey returns the key named by the error the decoder returns for an unknown key.
+func unknownKey(err error) (string, bool) {
+	const prefix = "json: unknown field "
+	if !strings.HasPrefix(err.Error(), prefix) {
+		return "", false
+	}
+
+	return strings.Trim(strings.TrimPrefix(err.Error(), prefix), `"`), true
+}
+
+func sortedKeys(set map[string]bool) []string {
+	if len(set) == 0 {
+		return nil
+	}
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
@@ -0,0 +1,112 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package webhooks
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const unknownShapesPayload = `{
+  "object": "whatsapp_business_account",
+  "entry": [{
+    "id": "102290129340398",
+    "changes": [{
+      "field": "messages",
+      "value": {
+        "messaging_product": "whatsapp",
+        "metadata": {"display_phone_number": "15550783881", "phone_number_id": "106540352242922"},
+        "messages": [
+          {"from": "16505551234", "id": "wamid.1", "timestamp": "1750263773", "type": "request_welcome"},
+          {"from": "16505551234", "id": "wamid.2", "timestamp": "1750263774", "type": "text",
+           "text": {"body": "hi"}, "errors": [{"code": 131051, "title": "Message type unknown"}]}
+        ],
+        "message_source": "ctwa"
+      }
+    }, {
+      "field": "message_drafts",
+      "value": {"id": "draft.1"}
+    }]
+  }]
+}`
+
+func TestCheckStrict(t *testing.T) {
+	t.Parallel()
+	if err := CheckStrict([]byte(statusesPayload)); err != nil {
+		t.Errorf("CheckStrict(statusesPayload) error = %v, want nil", err)
+	}
+
+	err := CheckStrict([]byte(unknownShapesPayload))
+	var strictErr *StrictDecodingError
+	if !errors.As(err, &strictErr) || !errors.Is(err, ErrStrictDecoding) {
+		t.Fatalf("CheckStrict() error = %v, want a *StrictDecodingError", err)
+	}
+
+	want := &StrictDecodingError{
+		UnknownKeys:         []string{"messages: message_source"},
+		UnknownFields:       []string{"message_drafts"},
+		UnknownMessageTypes: []string{"request_welcome"},
+	}
+	if !reflect.DeepEqual(strictErr, want) {
+		t.Errorf("CheckStrict() = %+v, want %+v", strictErr, want)
+	}
+
+	if err = CheckStrict([]byte(`[]`)); err == nil || errors.Is(err, ErrStrictDecoding) {
+		t.Errorf("CheckStrict([]) error = %v, want a decoding error", err)
+	}
+}
+
+func TestNotificationHandler_StrictDecoding(t *testing.T) {
+	t.Parallel()
+	var (
+		handled  int
+		received []error
+	)
+
+	listener := NewEventListener(
+		WithStrictDecoding(),
+		WithNotificationErrorHandler(func(ctx context.Context, request *http.Request, err error) *NotificationErrHandlerResponse {
+			received = append(received, err)
+
+			return &NotificationErrHandlerResponse{Skip: true}
+		}),
+	)
+	listener.OnTextMessage(func(ctx context.Context, nctx *NotificationContext, mctx *MessageContext, text *Text) error {
+		handled++
+
+		return nil
+	})
+
+	request := httptest.NewRequest(http.MethodPost, "/webhooks", strings.NewReader(unknownShapesPayload))
+	recorder := httptest.NewRecorder()
+	listener.NotificationHandler().ServeHTTP(recorder, request)
+
+	if len(received) == 0 || !errors.Is(received[0], ErrStrictDecoding) {
+		t.Errorf("NotificationErrorHandler got %v, want %v first", received, ErrStrictDecoding)
+	}
+	if handled != 1 || recorder.Code != http.StatusOK {
+		t.Errorf("got %d text messages handled and status %d, want 1 and 200", handled, recorder.Code)
+	}
+}
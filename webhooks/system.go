@@ -0,0 +1,144 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package webhooks
+
+import (
+	"context"
+	"strconv"
+	"time"
+)
+
+// Types of the system messages, see System.Type.
+const (
+	SystemCustomerChangedNumber   = "customer_changed_number"
+	SystemCustomerIdentityChanged = "customer_identity_changed"
+)
+
+type (
+	// NumberChange is a customer changing their phone number: the conversations with PreviousWaID
+	// continue with NewWaID.
+	NumberChange struct {
+		PreviousWaID string
+		NewWaID      string
+		Body         string
+	}
+
+	// IdentityChange is a customer that may have reinstalled WhatsApp or changed phone, their
+	// identity Hash changed. CreatedTimestamp is when the change was detected, in Unix seconds.
+	IdentityChange struct {
+		WaID             string
+		Hash             string
+		CreatedTimestamp string
+		Acknowledged     bool
+		Body             string
+	}
+
+	// OnNumberChangeHook is called for the customer_changed_number system messages. Without it,
+	// they go to the OnSystemMessageHook.
+	OnNumberChangeHook func(
+		ctx context.Context, nctx *NotificationContext, mctx *MessageContext, change *NumberChange) error
+
+	// OnIdentityChangeHook is called for the customer_identity_changed system messages. Without it,
+	// they go to the OnSystemMessageHook.
+	OnIdentityChangeHook func(
+		ctx context.Context, nctx *NotificationContext, mctx *MessageContext, change *IdentityChange) error
+)
+
+// NewID returns the new WhatsApp ID of the customer, from wa_id or, on webhook versions v11.0 and
+// earlier, new_wa_id.
+func (system *System) NewID() string {
+	if system == nil {
+		return ""
+	}
+	if system.WaID != "" {
+		return system.WaID
+	}
+
+	return system.NewWaID
+}
+
+// CreatedAt returns when the identity change was detected, the zero time when unknown.
+func (change *IdentityChange) CreatedAt() time.Time {
+	if change == nil {
+		return time.Time{}
+	}
+	seconds, err := strconv.ParseInt(change.CreatedTimestamp, 10, 64)
+	if err != nil {
+		return time.Time{}
+	}
+
+	return time.Unix(seconds, 0)
+}
+
+// NumberChange returns the number change the message carries, or nil.
+func (message *Message) NumberChange() *NumberChange {
+	if message == nil || message.System == nil || message.System.Type != SystemCustomerChangedNumber {
+		return nil
+	}
+	previous := message.System.Customer
+	if previous == "" {
+		previous = message.From
+	}
+
+	return &NumberChange{
+		PreviousWaID: previous,
+		NewWaID:      message.System.NewID(),
+		Body:         message.System.Body,
+	}
+}
+
+// IdentityChange returns the identity change the message carries, or nil.
+func (message *Message) IdentityChange() *IdentityChange {
+	if message == nil || message.System == nil || message.System.Type != SystemCustomerIdentityChanged {
+		return nil
+	}
+	change := &IdentityChange{
+		WaID: message.System.Customer,
+		Hash: message.System.Identity,
+		Body: message.System.Body,
+	}
+	if change.WaID == "" {
+		change.WaID = message.From
+	}
+	if message.Identity != nil {
+		change.CreatedTimestamp = message.Identity.CreatedTimestamp
+		change.Acknowledged = message.Identity.Acknowledged
+		if change.Hash == "" {
+			change.Hash = message.Identity.Hash
+		}
+	}
+
+	return change
+}
+
+// attachHooksToSystemMessage calls the typed hook of the system message, it reports false when
+// there is none and the message goes to the OnSystemMessageHook.
+func attachHooksToSystemMessage(ctx context.Context, nctx *NotificationContext, mctx *MessageContext,
+	message *Message, hooks *Hooks,
+) (bool, error) {
+	if change := message.NumberChange(); change != nil && hooks.OnNumberChangeHook != nil {
+		return true, hooks.OnNumberChangeHook(ctx, nctx, mctx, change)
+	}
+	if change := message.IdentityChange(); change != nil && hooks.OnIdentityChangeHook != nil {
+		return true, hooks.OnIdentityChangeHook(ctx, nctx, mctx, change)
+	}
+
+	return false, nil
+}
@@ -0,0 +1,116 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package webhooks
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+const systemPayload = `{
+  "object": "whatsapp_business_account",
+  "entry": [{
+    "id": "102290129340398",
+    "changes": [{
+      "field": "messages",
+      "value": {
+        "messaging_product": "whatsapp",
+        "metadata": {"display_phone_number": "15550783881", "phone_number_id": "106540352242922"},
+        "messages": [
+          {"from": "16505551234", "id": "wamid.1", "timestamp": "1750263773", "type": "system",
+           "system": {"body": "User A changed from 16505551234 to 16505559999", "wa_id": "16505559999",
+             "type": "customer_changed_number", "customer": "16505551234"}},
+          {"from": "16505550000", "id": "wamid.2", "timestamp": "1750263774", "type": "system",
+           "system": {"body": "User B changed", "identity": "hash.2", "type": "customer_identity_changed",
+             "customer": "16505550000"},
+           "identity": {"acknowledged": true, "created_timestamp": "1750263700", "hash": "hash.2"}},
+          {"from": "16505550001", "id": "wamid.3", "timestamp": "1750263775", "type": "system",
+           "system": {"body": "User C changed from 16505550001 to 16505550002", "new_wa_id": "16505550002",
+             "type": "customer_changed_number"}}
+        ]
+      }
+    }]
+  }]
+}`
+
+func TestAttachHooksToNotification_SystemMessages(t *testing.T) {
+	t.Parallel()
+	var notification Notification
+	if err := json.Unmarshal([]byte(systemPayload), &notification); err != nil {
+		t.Fatalf("json.Unmarshal() error = %v", err)
+	}
+
+	var (
+		system     []string
+		numbers    []*NumberChange
+		identities []*IdentityChange
+	)
+	listener := NewEventListener()
+	listener.OnSystemMessage(func(ctx context.Context, nctx *NotificationContext, mctx *MessageContext,
+		s *System,
+	) error {
+		system = append(system, mctx.ID)
+
+		return nil
+	})
+
+	if err := AttachHooksToNotification(context.TODO(), &notification, listener.h, NoOpHooksErrorHandler); err != nil {
+		t.Fatalf("AttachHooksToNotification() error = %v", err)
+	}
+	if len(system) != 3 {
+		t.Fatalf("got system messages %v, want all 3 without typed hooks", system)
+	}
+
+	system = nil
+	listener.OnNumberChange(func(ctx context.Context, nctx *NotificationContext, mctx *MessageContext,
+		change *NumberChange,
+	) error {
+		numbers = append(numbers, change)
+
+		return nil
+	})
+	listener.OnIdentityChange(func(ctx context.Context, nctx *NotificationContext, mctx *MessageContext,
+		change *IdentityChange,
+	) error {
+		identities = append(identities, change)
+
+		return nil
+	})
+	if err := AttachHooksToNotification(context.TODO(), &notification, listener.h, NoOpHooksErrorHandler); err != nil {
+		t.Fatalf("AttachHooksToNotification() error = %v", err)
+	}
+	if len(system) != 0 {
+		t.Errorf("got system messages %v, want none with typed hooks", system)
+	}
+	if len(numbers) != 2 || numbers[0].PreviousWaID != "16505551234" || numbers[0].NewWaID != "16505559999" ||
+		numbers[1].PreviousWaID != "16505550001" || numbers[1].NewWaID != "16505550002" {
+		t.Errorf("unexpected number changes %+v", numbers)
+	}
+	if len(identities) != 1 {
+		t.Fatalf("got %d identity changes, want 1", len(identities))
+	}
+	identity := identities[0]
+	if identity.WaID != "16505550000" || identity.Hash != "hash.2" || !identity.Acknowledged ||
+		!identity.CreatedAt().Equal(time.Unix(1750263700, 0)) {
+		t.Errorf("unexpected identity change %+v", identity)
+	}
+}
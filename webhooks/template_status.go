@@ -0,0 +1,116 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package webhooks
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+)
+
+// Events of a message_template_status_update change.
+const (
+	TemplateStatusApproved          = "APPROVED"
+	TemplateStatusRejected          = "REJECTED"
+	TemplateStatusPaused            = "PAUSED"
+	TemplateStatusDisabled          = "DISABLED"
+	TemplateStatusPendingDeletion   = "PENDING_DELETION"
+	TemplateStatusFlagged           = "FLAGGED"
+	TemplateStatusReinstated        = "REINSTATED"
+	TemplateStatusInAppeal          = "IN_APPEAL"
+	TemplateStatusPending           = "PENDING"
+	TemplateStatusLimitExceeded     = "LIMIT_EXCEEDED"
+	TemplateStatusArchived          = "ARCHIVED"
+	TemplateStatusAccountRestricted = "ACCOUNT_RESTRICTION"
+)
+
+type (
+	// TemplateDisableInfo is set when a template is disabled.
+	//
+	// DisableDate, disable_date — when the template was or will be disabled.
+	TemplateDisableInfo struct {
+		DisableDate string `json:"disable_date,omitempty"`
+	}
+
+	// TemplateOtherInfo carries the title and description of a paused or unpaused template.
+	TemplateOtherInfo struct {
+		Title       string `json:"title,omitempty"`
+		Description string `json:"description,omitempty"`
+	}
+
+	// TemplateStatusUpdate is the value of a message_template_status_update change. It is sent
+	// when a template is approved, rejected, paused or disabled.
+	//
+	// Event, event — the new status of the template, see the TemplateStatus constants.
+	// MessageTemplateID, message_template_id — the ID of the template, sent as a JSON number.
+	// MessageTemplateName, message_template_name — the name of the template.
+	// MessageTemplateLanguage, message_template_language — the language and locale code.
+	// Reason, reason — why the template was rejected, NONE when there is no reason.
+	// DisableInfo, disable_info — set when the template is disabled.
+	// OtherInfo, other_info — set when the template is paused or unpaused.
+	TemplateStatusUpdate struct {
+		Event                   string               `json:"event,omitempty"`
+		MessageTemplateID       json.Number          `json:"message_template_id,omitempty"`
+		MessageTemplateName     string               `json:"message_template_name,omitempty"`
+		MessageTemplateLanguage string               `json:"message_template_language,omitempty"`
+		Reason                  string               `json:"reason,omitempty"`
+		DisableInfo             *TemplateDisableInfo `json:"disable_info,omitempty"`
+		OtherInfo               *TemplateOtherInfo   `json:"other_info,omitempty"`
+	}
+
+	// TemplateCategoryUpdate is the value of a template_category_update change. It is sent when
+	// Meta changes the category of a template, for example from UTILITY to MARKETING.
+	//
+	// MessageTemplateID, message_template_id — the ID of the template, sent as a JSON number.
+	// MessageTemplateName, message_template_name — the name of the template.
+	// MessageTemplateLanguage, message_template_language — the language and locale code.
+	// PreviousCategory, previous_category — the category before the update.
+	// NewCategory, new_category — the category after the update.
+	// CorrectCategory, correct_category — the category the template will get, sent ahead of
+	// the update.
+	TemplateCategoryUpdate struct {
+		MessageTemplateID       json.Number `json:"message_template_id,omitempty"`
+		MessageTemplateName     string      `json:"message_template_name,omitempty"`
+		MessageTemplateLanguage string      `json:"message_template_language,omitempty"`
+		PreviousCategory        string      `json:"previous_category,omitempty"`
+		NewCategory             string      `json:"new_category,omitempty"`
+		CorrectCategory         string      `json:"correct_category,omitempty"`
+	}
+
+	// OnTemplateStatusUpdateHook is called for every message_template_status_update change.
+	OnTemplateStatusUpdateHook func(ctx context.Context, nctx *NotificationContext, update *TemplateStatusUpdate) error
+
+	// OnTemplateCategoryUpdateHook is called for every template_category_update change.
+	OnTemplateCategoryUpdateHook func(ctx context.Context, nctx *NotificationContext,
+		update *TemplateCategoryUpdate) error
+)
+
+var (
+	ErrOnTemplateStatusUpdateHook   = errors.New("on template status update hook error")
+	ErrOnTemplateCategoryUpdateHook = errors.New("on template category update hook error")
+)
+
+func attachHooksToTemplateStatusUpdate(ctx context.Context, id string, change *Change, hooks *Hooks) error {
+	return attachFieldHook(ctx, id, change, hooks.OnTemplateStatusUpdateHook, ErrOnTemplateStatusUpdateHook)
+}
+
+func attachHooksToTemplateCategoryUpdate(ctx context.Context, id string, change *Change, hooks *Hooks) error {
+	return attachFieldHook(ctx, id, change, hooks.OnTemplateCategoryUpdateHook, ErrOnTemplateCategoryUpdateHook)
+}
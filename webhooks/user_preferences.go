@@ -0,0 +1,165 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package webhooks
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	werrors "github.com/lowkruc/go-whatsapp-api/errors"
+)
+
+// Categories and values of a user preference.
+const (
+	UserPreferenceCategoryMarketing = "marketing_messages"
+	UserPreferenceStop              = "stop"
+	UserPreferenceResume            = "resume"
+)
+
+type (
+	// UserPreference is a preference set by a user, like stopping or resuming marketing messages.
+	//
+	// WaID, wa_id — the WhatsApp ID of the user.
+	// Detail, detail — a description of the preference.
+	// Category, category — what the preference is about, see UserPreferenceCategoryMarketing.
+	// Value, value — UserPreferenceStop or UserPreferenceResume.
+	// Timestamp, timestamp — when the preference was set, in seconds since the epoch.
+	UserPreference struct {
+		WaID      string `json:"wa_id,omitempty"`
+		Detail    string `json:"detail,omitempty"`
+		Category  string `json:"category,omitempty"`
+		Value     string `json:"value,omitempty"`
+		Timestamp int64  `json:"timestamp,omitempty"`
+	}
+
+	// UserPreferencesUpdate is the value of a user_preferences change.
+	UserPreferencesUpdate struct {
+		MessagingProduct string            `json:"messaging_product,omitempty"`
+		Metadata         *Metadata         `json:"metadata,omitempty"`
+		Contacts         []*Contact        `json:"contacts,omitempty"`
+		UserPreferences  []*UserPreference `json:"user_preferences,omitempty"`
+	}
+
+	// OnUserPreferencesHook is called for every user_preferences change. nctx carries the contacts
+	// and the metadata of the change.
+	OnUserPreferencesHook func(ctx context.Context, nctx *NotificationContext, update *UserPreferencesUpdate) error
+
+	// MarketingOptOuts keeps the WhatsApp IDs of the users that stopped marketing messages. It is
+	// in memory, so the opt-outs should also be persisted by the application to survive a restart.
+	MarketingOptOuts struct {
+		mu    sync.RWMutex
+		waIDs map[string]bool
+	}
+)
+
+var ErrOnUserPreferencesHook = errors.New("on user preferences hook error")
+
+func attachHooksToUserPreferences(ctx context.Context, id string, change *Change, hooks *Hooks) error {
+	if hooks.OnUserPreferencesHook == nil {
+		return nil
+	}
+
+	update, err := decodeChange[UserPreferencesUpdate](change, ErrOnUserPreferencesHook)
+	if err != nil {
+		return err
+	}
+
+	nctx := &NotificationContext{
+		ID:       id,
+		Contacts: update.Contacts,
+		Metadata: update.Metadata,
+	}
+
+	return hooks.OnUserPreferencesHook(ctx, nctx, update)
+}
+
+// NewMarketingOptOuts creates MarketingOptOuts with the WhatsApp IDs that already opted out.
+func NewMarketingOptOuts(waIDs ...string) *MarketingOptOuts {
+	optOuts := &MarketingOptOuts{waIDs: make(map[string]bool)}
+	for _, waID := range waIDs {
+		optOuts.waIDs[waID] = true
+	}
+
+	return optOuts
+}
+
+// Hook returns an OnUserPreferencesHook that records the marketing preferences and then calls
+// next. next may be nil.
+func (o *MarketingOptOuts) Hook(next OnUserPreferencesHook) OnUserPreferencesHook {
+	return func(ctx context.Context, nctx *NotificationContext, update *UserPreferencesUpdate) error {
+		for _, preference := range update.UserPreferences {
+			o.Apply(preference)
+		}
+
+		if next == nil {
+			return nil
+		}
+
+		return next(ctx, nctx, update)
+	}
+}
+
+// FailedHook returns an OnMessageFailedHook that records an opt-out for the recipients of the
+// messages that failed because they stopped marketing messages, and then calls next. next may be
+// nil.
+func (o *MarketingOptOuts) FailedHook(next OnMessageFailedHook) OnMessageFailedHook {
+	return func(ctx context.Context, nctx *NotificationContext, status *Status) error {
+		for _, reason := range status.FailureReasons() {
+			if reason == werrors.ReasonMarketingOptOut {
+				o.mu.Lock()
+				o.waIDs[status.RecipientID] = true
+				o.mu.Unlock()
+
+				break
+			}
+		}
+
+		if next == nil {
+			return nil
+		}
+
+		return next(ctx, nctx, status)
+	}
+}
+
+// Apply records the preference if it is about marketing messages.
+func (o *MarketingOptOuts) Apply(preference *UserPreference) {
+	if preference == nil || preference.Category != UserPreferenceCategoryMarketing {
+		return
+	}
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	switch preference.Value {
+	case UserPreferenceStop:
+		o.waIDs[preference.WaID] = true
+	case UserPreferenceResume:
+		delete(o.waIDs, preference.WaID)
+	}
+}
+
+// OptedOut reports whether the user stopped marketing messages.
+func (o *MarketingOptOuts) OptedOut(waID string) bool {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+
+	return o.waIDs[waID]
+}
@@ -0,0 +1,83 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package webhooks
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+)
+
+const userPreferencesPayload = `{
+  "object": "whatsapp_business_account",
+  "entry": [{
+    "id": "102290129340398",
+    "changes": [{
+      "field": "user_preferences",
+      "value": {
+        "messaging_product": "whatsapp",
+        "metadata": {"display_phone_number": "15550783881", "phone_number_id": "106540352242922"},
+        "contacts": [{"wa_id": "16505551234", "profile": {"name": "Kerry Fisher"}}],
+        "user_preferences": [{
+          "wa_id": "16505551234", "detail": "User requested to stop marketing messages",
+          "category": "marketing_messages", "value": "stop", "timestamp": 1731705721
+        }]
+      }
+    }]
+  }]
+}`
+
+func TestMarketingOptOuts_Hook(t *testing.T) {
+	t.Parallel()
+	var notification Notification
+	if err := json.Unmarshal([]byte(userPreferencesPayload), &notification); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+
+	called := false
+	optOuts := NewMarketingOptOuts()
+	listener := NewEventListener()
+	listener.OnUserPreferences(optOuts.Hook(func(ctx context.Context, n *NotificationContext,
+		u *UserPreferencesUpdate,
+	) error {
+		called = true
+
+		return nil
+	}))
+
+	if err := AttachHooksToNotification(context.TODO(), &notification, listener.h, NoOpHooksErrorHandler); err != nil {
+		t.Fatalf("AttachHooksToNotification() error = %v", err)
+	}
+
+	if !called {
+		t.Error("wrapped hook not called")
+	}
+
+	if !optOuts.OptedOut("16505551234") {
+		t.Error("expected 16505551234 to be opted out")
+	}
+
+	optOuts.Apply(&UserPreference{
+		WaID: "16505551234", Category: UserPreferenceCategoryMarketing, Value: UserPreferenceResume,
+	})
+	if optOuts.OptedOut("16505551234") {
+		t.Error("expected 16505551234 to have resumed marketing messages")
+	}
+}
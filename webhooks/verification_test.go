@@ -0,0 +1,88 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package webhooks
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestSubscriptionVerificationHandler(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name   string
+		token  string
+		query  string
+		status int
+		body   string
+	}{
+		{
+			name:   "valid",
+			token:  "meatyhamhock",
+			query:  "hub.mode=subscribe&hub.challenge=1158201444&hub.verify_token=meatyhamhock",
+			status: http.StatusOK,
+			body:   "1158201444",
+		},
+		{
+			name:   "wrong token",
+			token:  "meatyhamhock",
+			query:  "hub.mode=subscribe&hub.challenge=1158201444&hub.verify_token=other",
+			status: http.StatusForbidden,
+		},
+		{
+			name:   "wrong mode",
+			token:  "meatyhamhock",
+			query:  "hub.mode=unsubscribe&hub.challenge=1158201444&hub.verify_token=meatyhamhock",
+			status: http.StatusForbidden,
+		},
+		{
+			name:   "empty token",
+			token:  "",
+			query:  "hub.mode=subscribe&hub.challenge=1158201444&hub.verify_token=",
+			status: http.StatusForbidden,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			recorder := httptest.NewRecorder()
+			request := httptest.NewRequest(http.MethodGet, "/webhooks?"+tt.query, nil)
+			SubscriptionVerificationHandler(tt.token).ServeHTTP(recorder, request)
+
+			if recorder.Code != tt.status || recorder.Body.String() != tt.body {
+				t.Errorf("got %d %q, want %d %q", recorder.Code, recorder.Body.String(), tt.status, tt.body)
+			}
+		})
+	}
+}
+
+func TestEventListener_SubscriptionVerificationHandler(t *testing.T) {
+	t.Parallel()
+	recorder := httptest.NewRecorder()
+	request := httptest.NewRequest(http.MethodGet, "/webhooks?hub.mode=subscribe&hub.challenge=1", nil)
+	NewEventListener().SubscriptionVerificationHandler().ServeHTTP(recorder, request)
+
+	if recorder.Code != http.StatusForbidden {
+		t.Errorf("got %d without a verifier, want %d", recorder.Code, http.StatusForbidden)
+	}
+}
@@ -0,0 +1,189 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package webhooks
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+// Shim names reported by NormalizePayload.
+const (
+	ShimMissingField       = "missing_field"
+	ShimSystemNewWaID      = "system_new_wa_id"
+	ShimErrorTitle         = "error_title"
+	ShimUnsupportedMessage = "unsupported_message_type"
+	ShimNumericIDs         = "numeric_ids"
+)
+
+type (
+	// Shim rewrites a part of a decoded payload written for another Graph API version so that it
+	// matches the current model. Apply reports whether it changed anything.
+	Shim struct {
+		Name        string
+		Description string
+		Apply       func(value map[string]any) bool
+	}
+)
+
+// Shims are applied to the value of every change, in order, by NormalizePayload.
+var Shims = []*Shim{ //nolint:gochecknoglobals
+	{
+		Name:        ShimSystemNewWaID,
+		Description: "v11.0 and earlier send the new WhatsApp ID of system messages as new_wa_id instead of wa_id",
+		Apply:       applySystemNewWaID,
+	},
+	{
+		Name:        ShimErrorTitle,
+		Description: "v15.0 and earlier send error objects with a title and no message",
+		Apply:       applyErrorTitle,
+	},
+	{
+		Name:        ShimUnsupportedMessage,
+		Description: "newer versions send unsupported and ephemeral message types, the model uses unknown",
+		Apply:       applyUnsupportedMessage,
+	},
+}
+
+// NormalizePayload rewrites a notification payload received for any Graph API version so that it
+// decodes into the current model. It returns the normalized payload and the names of the shims
+// that changed it, the payload is returned unchanged when no shim applies.
+func NormalizePayload(data []byte) ([]byte, []string, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	var payload map[string]any
+	if err := decoder.Decode(&payload); err != nil {
+		return nil, nil, fmt.Errorf("normalize payload: %v", err)
+	}
+
+	var applied []string
+	mark := func(name string) {
+		for _, n := range applied {
+			if n == name {
+				return
+			}
+		}
+		applied = append(applied, name)
+	}
+
+	for _, entry := range objects(payload["entry"]) {
+		for _, change := range objects(entry["changes"]) {
+			value, ok := change["value"].(map[string]any)
+			if !ok {
+				continue
+			}
+
+			// early payloads had no field, only messages and statuses were sent then.
+			if field, _ := change["field"].(string); field == "" &&
+				(value["messages"] != nil || value["statuses"] != nil) {
+				change["field"] = MessagesField
+				mark(ShimMissingField)
+			}
+
+			for _, shim := range Shims {
+				if shim.Apply(value) {
+					mark(shim.Name)
+				}
+			}
+		}
+	}
+
+	normalized, err := json.Marshal(payload)
+	if err != nil {
+		return nil, nil, fmt.Errorf("normalize payload: %v", err)
+	}
+
+	// numbers are kept as json.Number, so a changed encoding means numeric IDs were converted.
+	if numeric, err := NormalizeNumbers(normalized); err == nil && !bytes.Equal(numeric, normalized) {
+		normalized = numeric
+		mark(ShimNumericIDs)
+	}
+
+	if len(applied) == 0 {
+		return data, nil, nil
+	}
+
+	return normalized, applied, nil
+}
+
+func objects(v any) []map[string]any {
+	list, _ := v.([]any)
+	result := make([]map[string]any, 0, len(list))
+	for _, item := range list {
+		if object, ok := item.(map[string]any); ok {
+			result = append(result, object)
+		}
+	}
+
+	return result
+}
+
+func applySystemNewWaID(value map[string]any) bool {
+	changed := false
+	for _, message := range objects(value["messages"]) {
+		system, ok := message["system"].(map[string]any)
+		if !ok {
+			continue
+		}
+
+		if newWaID, ok := system["new_wa_id"]; ok && system["wa_id"] == nil {
+			system["wa_id"] = newWaID
+			changed = true
+		}
+	}
+
+	return changed
+}
+
+func applyErrorTitle(value map[string]any) bool {
+	lists := [][]map[string]any{objects(value["errors"])}
+	for _, message := range objects(value["messages"]) {
+		lists = append(lists, objects(message["errors"]))
+	}
+	for _, status := range objects(value["statuses"]) {
+		lists = append(lists, objects(status["errors"]))
+	}
+
+	changed := false
+	for _, list := range lists {
+		for _, e := range list {
+			if title, ok := e["title"]; ok && e["message"] == nil {
+				e["message"] = title
+				changed = true
+			}
+		}
+	}
+
+	return changed
+}
+
+func applyUnsupportedMessage(value map[string]any) bool {
+	changed := false
+	for _, message := range objects(value["messages"]) {
+		if t, _ := message["type"].(string); t == "unsupported" || t == "ephemeral" {
+			message["type"] = string(UnknownMessageType)
+			changed = true
+		}
+	}
+
+	return changed
+}
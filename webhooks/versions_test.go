@@ -0,0 +1,97 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package webhooks
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// versionFixtures are the same system message and failed status as sent by different Graph API
+// versions. They all normalize to the current model.
+var versionFixtures = []struct { //nolint:gochecknoglobals
+	version string
+	payload string
+	shims   []string
+}{
+	{
+		version: "v11.0",
+		payload: `{"object":"whatsapp_business_account","entry":[{"id":"1","changes":[{"value":{
+			"messaging_product":"whatsapp","metadata":{"phone_number_id":"106540352242922"},
+			"messages":[{"from":"16505551234","id":"wamid.1","timestamp":1706461964,"type":"system",
+			"system":{"body":"User changed number","new_wa_id":"16505559999","type":"customer_changed_number"}}],
+			"statuses":[{"id":"wamid.2","recipient_id":"16505551234","status":"failed","timestamp":"1706461964",
+			"errors":[{"code":131051,"title":"Unsupported message type"}]}]}}]}]}`,
+		shims: []string{ShimMissingField, ShimSystemNewWaID, ShimErrorTitle, ShimNumericIDs},
+	},
+	{
+		version: "v15.0",
+		payload: `{"object":"whatsapp_business_account","entry":[{"id":"1","changes":[{"field":"messages","value":{
+			"messaging_product":"whatsapp","metadata":{"phone_number_id":"106540352242922"},
+			"messages":[{"from":"16505551234","id":"wamid.1","timestamp":"1706461964","type":"system",
+			"system":{"body":"User changed number","wa_id":"16505559999","type":"customer_changed_number"}}],
+			"statuses":[{"id":"wamid.2","recipient_id":"16505551234","status":"failed","timestamp":"1706461964",
+			"errors":[{"code":131051,"title":"Unsupported message type"}]}]}}]}]}`,
+		shims: []string{ShimErrorTitle},
+	},
+	{
+		version: "v17.0",
+		payload: `{"object":"whatsapp_business_account","entry":[{"id":"1","changes":[{"field":"messages","value":{
+			"messaging_product":"whatsapp","metadata":{"phone_number_id":"106540352242922"},
+			"messages":[{"from":"16505551234","id":"wamid.1","timestamp":"1706461964","type":"system",
+			"system":{"body":"User changed number","wa_id":"16505559999","type":"customer_changed_number"}}],
+			"statuses":[{"id":"wamid.2","recipient_id":"16505551234","status":"failed","timestamp":"1706461964",
+			"errors":[{"code":131051,"title":"Unsupported message type","message":"Unsupported message type"}]}]}}]}]}`,
+		shims: nil,
+	},
+}
+
+func TestNormalizePayload(t *testing.T) {
+	t.Parallel()
+	for _, fixture := range versionFixtures {
+		fixture := fixture
+		t.Run(fixture.version, func(t *testing.T) {
+			t.Parallel()
+			normalized, shims, err := NormalizePayload([]byte(fixture.payload))
+			if err != nil {
+				t.Fatalf("NormalizePayload() error = %v", err)
+			}
+
+			if !reflect.DeepEqual(shims, fixture.shims) {
+				t.Errorf("applied shims = %v, want %v", shims, fixture.shims)
+			}
+
+			var notification Notification
+			if err := json.Unmarshal(normalized, &notification); err != nil {
+				t.Fatalf("decode normalized payload: %v", err)
+			}
+
+			change := notification.Entry[0].Changes[0]
+			message := change.Value.Messages[0]
+			if change.Field != MessagesField || message.Timestamp != "1706461964" ||
+				message.System.WaID != "16505559999" ||
+				!strings.Contains(string(normalized), `"message":"Unsupported message type"`) {
+				t.Errorf("unexpected normalized payload %s", normalized)
+			}
+		})
+	}
+}
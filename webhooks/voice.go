@@ -0,0 +1,41 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package webhooks
+
+import (
+	"context"
+
+	"github.com/lowkruc/go-whatsapp-api/models"
+)
+
+// OnVoiceNoteHook is called for the voice notes, the audio messages recorded with the push to
+// talk button, as opposed to the audio files sent as attachments. Without it, the voice notes
+// go to the OnMediaMessageHook, where media.Voice tells them apart.
+type OnVoiceNoteHook func(
+	ctx context.Context, nctx *NotificationContext, mctx *MessageContext, media *models.MediaInfo) error
+
+// VoiceNote returns the audio of the message when it is a voice note, nil otherwise.
+func (message *Message) VoiceNote() *models.MediaInfo {
+	if message == nil || message.Audio == nil || !message.Audio.Voice {
+		return nil
+	}
+
+	return message.Audio
+}
@@ -23,17 +23,23 @@ import (
 	"bytes"
 	"context"
 	"crypto/hmac"
-	"crypto/sha256"
+	"crypto/subtle"
 	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"strconv"
 	"strings"
+	"time"
 
+	"github.com/lowkruc/go-whatsapp-api/audit"
+	wcrypto "github.com/lowkruc/go-whatsapp-api/crypto"
 	werrors "github.com/lowkruc/go-whatsapp-api/errors"
+	"github.com/lowkruc/go-whatsapp-api/guard"
 	"github.com/lowkruc/go-whatsapp-api/models"
+	"github.com/lowkruc/go-whatsapp-api/report"
 )
 
 // PayloadMaxSize is the maximum size of the payload that can be sent to the webhook.
@@ -44,6 +50,7 @@ const (
 	MessageStatusDelivered MessageStatus = "delivered"
 	MessageStatusRead      MessageStatus = "read"
 	MessageStatusSent      MessageStatus = "sent"
+	MessageStatusFailed    MessageStatus = "failed"
 )
 
 const (
@@ -64,14 +71,16 @@ const (
 )
 
 const (
-	InteractiveListReply   InteractiveReply = "list_reply"
-	InteractiveButtonReply InteractiveReply = "button_reply"
+	InteractiveListReply           InteractiveReply = "list_reply"
+	InteractiveButtonReply         InteractiveReply = "button_reply"
+	InteractiveCallPermissionReply InteractiveReply = "call_permission_reply"
+	InteractiveNfmReply            InteractiveReply = "nfm_reply"
 )
 
 type (
 
 	// InteractiveReply is the type of interactive reply. It can be one of the following:
-	// list_reply,or button_reply.
+	// list_reply, button_reply or call_permission_reply.
 	InteractiveReply string
 
 	// MessageType is type of message that has been received by the business that has subscribed
@@ -112,6 +121,8 @@ type (
 		Ctx       *Context
 	}
 
+	// OnOrderMessageHook is called for the orders, Order.Items and Order.Total give the exact
+	// prices of the products.
 	OnOrderMessageHook func(
 		ctx context.Context, nctx *NotificationContext, mctx *MessageContext, order *Order) error
 	OnButtonMessageHook func(
@@ -122,10 +133,19 @@ type (
 		ctx context.Context, nctx *NotificationContext, mctx *MessageContext, contacts *models.Contacts) error
 	OnMessageReactionHook func(
 		ctx context.Context, nctx *NotificationContext, mctx *MessageContext, reaction *models.Reaction) error
+
+	// OnReactionRemovedHook is called when a user removes their reaction to a message, the
+	// reaction has the ID of the message and an empty emoji. Without it, removed reactions go to
+	// the OnMessageReactionHook.
+	OnReactionRemovedHook func(
+		ctx context.Context, nctx *NotificationContext, mctx *MessageContext, reaction *models.Reaction) error
 	OnUnknownMessageHook func(
 		ctx context.Context, nctx *NotificationContext, mctx *MessageContext, errors []*werrors.Error) error
 	OnProductEnquiryHook func(
 		ctx context.Context, nctx *NotificationContext, mctx *MessageContext, text *Text) error
+
+	// OnInteractiveMessageHook is called for the interactive replies whose typed hook, like
+	// OnButtonReplyHook, OnListReplyHook or OnFlowReplyHook, is not set.
 	OnInteractiveMessageHook func(
 		ctx context.Context, nctx *NotificationContext, mctx *MessageContext, interactive *Interactive) error
 
@@ -154,6 +174,15 @@ type (
 	// This is called when a message status changes. For example, when a message is delivered or read.
 	OnMessageStatusChangeHook func(ctx context.Context, nctx *NotificationContext, status *Status) error
 
+	// OnMessageSentHook, OnMessageDeliveredHook, OnMessageReadHook and OnMessageFailedHook are called
+	// after OnMessageStatusChangeHook for the statuses with the matching MessageStatus. The status
+	// carries the conversation and pricing details, and the errors for failed messages, see
+	// Status.Err.
+	OnMessageSentHook      func(ctx context.Context, nctx *NotificationContext, status *Status) error
+	OnMessageDeliveredHook func(ctx context.Context, nctx *NotificationContext, status *Status) error
+	OnMessageReadHook      func(ctx context.Context, nctx *NotificationContext, status *Status) error
+	OnMessageFailedHook    func(ctx context.Context, nctx *NotificationContext, status *Status) error
+
 	// OnMessageReceivedHook is a hook that is called when a message is received. A notification
 	// can contain a lot of things like errors status changes etc. This is called when a
 	// notification contains a message. This work with the
@@ -170,23 +199,55 @@ type (
 	// M is the OnMessageReceivedHook called when a message is received.
 	// H is the MessageHooks called when a message is received.
 	Hooks struct {
-		OnOrderMessageHook        OnOrderMessageHook
-		OnButtonMessageHook       OnButtonMessageHook
-		OnLocationMessageHook     OnLocationMessageHook
-		OnContactsMessageHook     OnContactsMessageHook
-		OnMessageReactionHook     OnMessageReactionHook
-		OnUnknownMessageHook      OnUnknownMessageHook
-		OnProductEnquiryHook      OnProductEnquiryHook
-		OnInteractiveMessageHook  OnInteractiveMessageHook
-		OnMessageErrorsHook       OnMessageErrorsHook
-		OnTextMessageHook         OnTextMessageHook
-		OnReferralMessageHook     OnReferralMessageHook
-		OnCustomerIDChangeHook    OnCustomerIDChangeMessageHook
-		OnSystemMessageHook       OnSystemMessageHook
-		OnMediaMessageHook        OnMediaMessageHook
-		OnNotificationErrorHook   OnNotificationErrorHook
-		OnMessageStatusChangeHook OnMessageStatusChangeHook
-		OnMessageReceivedHook     OnMessageReceivedHook
+		OnOrderMessageHook             OnOrderMessageHook
+		OnButtonMessageHook            OnButtonMessageHook
+		OnLocationMessageHook          OnLocationMessageHook
+		OnContactsMessageHook          OnContactsMessageHook
+		OnMessageReactionHook          OnMessageReactionHook
+		OnReactionRemovedHook          OnReactionRemovedHook
+		OnUnknownMessageHook           OnUnknownMessageHook
+		OnProductEnquiryHook           OnProductEnquiryHook
+		OnInteractiveMessageHook       OnInteractiveMessageHook
+		OnMessageErrorsHook            OnMessageErrorsHook
+		OnTextMessageHook              OnTextMessageHook
+		OnReferralMessageHook          OnReferralMessageHook
+		OnCustomerIDChangeHook         OnCustomerIDChangeMessageHook
+		OnSystemMessageHook            OnSystemMessageHook
+		OnMediaMessageHook             OnMediaMessageHook
+		OnStickerMessageHook           OnStickerMessageHook
+		OnLiveLocationUpdateHook       OnLiveLocationUpdateHook
+		OnAdReferralHook               OnAdReferralHook
+		OnNumberChangeHook             OnNumberChangeHook
+		OnIdentityChangeHook           OnIdentityChangeHook
+		OnReplyToBusinessMessageHook   OnReplyToBusinessMessageHook
+		OnVoiceNoteHook                OnVoiceNoteHook
+		OnNotificationErrorHook        OnNotificationErrorHook
+		OnMessageStatusChangeHook      OnMessageStatusChangeHook
+		OnMessageReceivedHook          OnMessageReceivedHook
+		OnMessageSentHook              OnMessageSentHook
+		OnMessageDeliveredHook         OnMessageDeliveredHook
+		OnMessageReadHook              OnMessageReadHook
+		OnMessageFailedHook            OnMessageFailedHook
+		OnPaymentStatusHook            OnPaymentStatusHook
+		OnHandoverHook                 OnHandoverHook
+		OnPartnerSolutionHook          OnPartnerSolutionHook
+		OnTemplateStatusUpdateHook     OnTemplateStatusUpdateHook
+		OnTemplateCategoryUpdateHook   OnTemplateCategoryUpdateHook
+		OnPhoneNumberQualityUpdateHook OnPhoneNumberQualityUpdateHook
+		OnAccountUpdateHook            OnAccountUpdateHook
+		OnPartnerOnboardingHook        OnPartnerOnboardingHook
+		OnAccountReviewUpdateHook      OnAccountReviewUpdateHook
+		OnBusinessCapabilityUpdateHook OnBusinessCapabilityUpdateHook
+		OnSecurityEventHook            OnSecurityEventHook
+		OnFlowEventHook                OnFlowEventHook
+		OnCallEventHook                OnCallEventHook
+		OnCallPermissionReplyHook      OnCallPermissionReplyHook
+		OnButtonReplyHook              OnButtonReplyHook
+		OnListReplyHook                OnListReplyHook
+		OnFlowReplyHook                OnFlowReplyHook
+		OnUserPreferencesHook          OnUserPreferencesHook
+		OnMessageEchoHook              OnMessageEchoHook
+		OnChangeHook                   OnChangeHook
 	}
 
 	// MessageStatus is the status of a message.
@@ -237,6 +298,8 @@ type (
 		Skip       bool
 	}
 
+	// HooksErrorHandler is passed the HookErrors of the hooks called for each change, message and
+	// status. A fatal error returned by the handler, see IsFatalError, stops the hooks that follow.
 	HooksErrorHandler func(err error) error
 	// NotificationErrorHandler is a function that handles errors that occur when processing a notification.
 	// The function returns a NotificationErrHandlerResponse that is sent to the whatsapp server.
@@ -283,6 +346,111 @@ type (
 		AfterFunc         AfterFunc
 		ValidateSignature bool
 		Secret            string
+
+		// Secrets are tried after Secret when validating the signatures, and SecretProvider is
+		// asked for more on every notification. A notification signed with any of them is valid,
+		// which lets the app secret be rotated without rejecting notifications.
+		Secrets        []string
+		SecretProvider SecretProvider
+
+		// OnSignatureMismatch is called with a redacted report when ValidateSignature is set and
+		// the signature of a notification does not match. See LogSignatureMismatch.
+		OnSignatureMismatch OnSignatureMismatchFunc
+
+		// LenientNumbers accepts payloads where IDs and timestamps are sent as JSON numbers,
+		// they are converted to strings. See DecodeNotification.
+		LenientNumbers bool
+
+		// NormalizeVersions rewrites payloads of older or newer Graph API versions to the current
+		// model before decoding them. See NormalizePayload.
+		NormalizeVersions bool
+
+		// SuccessResponse is written when a notification has been handled, by default a 200 with
+		// an empty body is written. See JSONAck.
+		SuccessResponse *Response
+
+		// FailureResponse is written when the body can not be read or decoded, by default only
+		// the status code is written. Its StatusCode is ignored, the status code of the failure is
+		// kept so that Meta redelivers the notification. Errors passed to the
+		// NotificationErrorHandler are written as it returns them.
+		FailureResponse *Response
+
+		// ResponseHeaders are added to every response written by the handlers.
+		ResponseHeaders map[string]string
+
+		// FieldLimits bounds the size of the string fields of the decoded notifications before
+		// they reach the hooks. With FieldLimits.Truncate unset, a notification with a field over
+		// the limit is passed to the NotificationErrorHandler with guard.ErrFieldTooLarge. Values
+		// decoded with Change.DecodeValue are not checked.
+		FieldLimits *guard.Limits
+
+		// AuditTrail records every notification received and the dispatch of its changes to the
+		// hooks.
+		AuditTrail audit.Trail
+
+		// ErrorReporter is sent the panics of the hooks and the errors returned when dispatching
+		// a notification to the hooks. The panics are recovered and handled as errors.
+		ErrorReporter report.ErrorReporter
+
+		// Dispatcher runs the hooks asynchronously. The notification is acknowledged as soon as it is
+		// queued and the errors of the hooks are only sent to the ErrorReporter and the AuditTrail.
+		// AfterFunc is called once the notification is queued. When the queue is full and the
+		// Dispatcher rejects the notification, a 503 is written so that it is delivered again later.
+		Dispatcher *Dispatcher
+
+		// StageTimeouts bounds the time of the BeforeFunc, the hooks and the AfterFunc, each gets a
+		// context derived from the request context with its own deadline. The stages that exceed
+		// their budget are passed to OnStageExceeded.
+		StageTimeouts *StageTimeouts
+
+		// OnStageExceeded is called when a stage takes longer than its budget in StageTimeouts, for
+		// example to count them in metrics.
+		OnStageExceeded OnStageExceededFunc
+
+		// Deduplication drops the messages and statuses of redelivered notifications before the
+		// hooks are called, the notifications not answered with a 2xx are not remembered. The
+		// RawValue of the changes is left as received.
+		Deduplication *Deduplication
+
+		// StrictDecoding checks every notification with CheckStrict and passes the
+		// *StrictDecodingError to the NotificationErrorHandler, so that keys, webhook fields and
+		// message types the models do not know are noticed instead of silently ignored. The
+		// notification is still handled when the NotificationErrorHandler skips the error.
+		StrictDecoding bool
+
+		// ReplayWindow rejects the notifications with old messages or statuses that are not known,
+		// they are passed to the NotificationErrorHandler as a *StaleNotificationError. It is
+		// checked after the signature and before the Deduplication.
+		ReplayWindow *ReplayWindow
+
+		// Concurrency is the number of changes of a notification handled at once, see
+		// AttachHooksConcurrently. The changes about the same user keep their order. By default
+		// the changes are handled one after the other.
+		Concurrency int
+
+		// Control pauses the dispatch or turns the dry run on at runtime, see Control. Both are
+		// checked after the signature, the notifications are not deduplicated while paused.
+		Control *Control
+
+		// MaxBodySize bounds the size of the body, before and after decompression. Larger bodies
+		// are passed to the NotificationErrorHandler as ErrBodyTooLarge, or answered with a 413
+		// when it skips the error. Compressed bodies are decompressed up to the limit only, up to
+		// whttp.DefaultMaxDecodedSize when it is not set.
+		MaxBodySize int64
+
+		// ReadTimeout bounds the time to read the body. Slower bodies are passed to the
+		// NotificationErrorHandler as ErrBodyReadTimeout, or answered with a 408 when it skips the
+		// error. Set http.Server.ReadTimeout too, so that the connection is closed.
+		ReadTimeout time.Duration
+
+		// ResponseDeadline bounds the handling of a notification: the context of the hooks is
+		// done once it is over, so that the response is written in time.
+		ResponseDeadline time.Duration
+
+		// Publisher is passed every notification before the hooks, after the Deduplication. When
+		// it fails the error is passed to the NotificationErrorHandler, the hooks are still called
+		// when the error is skipped. Set no hooks to only publish the notifications.
+		Publisher Publisher
 	}
 
 	// VerificationRequest contains details sent by the whatsapp server during the verification process.
@@ -302,6 +470,9 @@ type (
 	// create your own logic in handling different types of notifications. Because when this is used for receiving
 	// notifications all types of notifications from Templates, Messages, Media, Contacts, etc. will be passed here,
 	// and you can handle them as you wish.
+	//
+	// The handler may write its own response to the http.ResponseWriter, if it does the listener
+	// does not write a response after it returns.
 	GlobalNotificationHandler func(context.Context, http.ResponseWriter, *Notification) error
 )
 
@@ -329,7 +500,7 @@ func NoOpNotificationErrorHandler(_ context.Context, _ *http.Request, err error)
 // errors. The errors are collected and returned as a single error. So in your implementation
 // of NotificationHooks, you can return a FatalError if you want to stop the processing of the notification.
 // immediately. If you want to continue processing the notification, you can return a non-fatal
-// error. The errors are collected and returned as a single error.
+// error. The errors are collected and returned as HookErrors, identifying the hook of each.
 // Also since all hooks errors are passed to the HooksErrorHandler, you can decide to either
 // escalate the non-fatal errors to fatal errors or just ignore them also you can decide to
 // ignore the fatal errors.
@@ -354,48 +525,68 @@ func AttachHooksToNotification(ctx context.Context, notification *Notification,
 		return nil
 	}
 
+	errs := &hookErrors{heh: heh}
 	entries := notification.Entry
 	for _, entry := range entries {
 		entry := entry
-		if err := attachHooksToEntry(ctx, entry, hooks, heh); err != nil {
-			return err
+		if attachHooksToEntry(ctx, entry, hooks, errs) {
+			break
 		}
 	}
 
-	return nil
+	return errs.err()
 }
 
-func attachHooksToEntry(ctx context.Context, entry *Entry, hooks *Hooks, heh HooksErrorHandler) error {
+// attachHooksToEntry calls the hooks of the changes of the entry and collects their errors, it
+// reports whether the HooksErrorHandler deemed one of them fatal.
+func attachHooksToEntry(ctx context.Context, entry *Entry, hooks *Hooks, errs *hookErrors) bool {
 	eid := entry.ID
 	changes := entry.Changes
 	for _, change := range changes {
 		change := change
+		ctx := changeContext(ctx, eid, change)
+		if hooks != nil && hooks.OnChangeHook != nil && change != nil {
+			if errs.handle(newHookErrors("OnChangeHook", "", hooks.OnChangeHook(ctx, eid, change))) {
+				return true
+			}
+		}
+
+		if attach, ok := fieldHooks[change.Field]; ok {
+			if errs.handle(newHookErrors(change.Field, "", attach(ctx, eid, change, hooks))) {
+				return true
+			}
+
+			continue
+		}
+
 		value := change.Value
 		if value == nil {
 			continue
 		}
 
-		if err := attachHooksToValue(ctx, eid, value, hooks, heh); err != nil {
-			return err
+		if attachHooksToValue(ctx, eid, value, hooks, errs) {
+			return true
 		}
 	}
 
-	return nil
+	return false
 }
 
 var (
 	ErrOnMessageStatusChangeHook = errors.New("on message status change hook error")
+	ErrOnMessageStatusHooks      = errors.New("on specific message status hooks error")
 	ErrOnMessageHooks            = errors.New("on specific message hooks error")
 	ErrOnNotificationErrorHook   = errors.New("on notification error hook error")
 	ErrOnGlobalMessageHook       = errors.New("on global message hook error")
 )
 
+// attachHooksToValue calls the hooks of the errors, statuses and messages of the value and collects
+// their errors, it reports whether the HooksErrorHandler deemed one of them fatal.
+//
 //nolint:cyclop
-func attachHooksToValue(ctx context.Context, id string, value *Value, hooks *Hooks,
-	hooksErrorHandler HooksErrorHandler,
-) error {
+func attachHooksToValue(ctx context.Context, id string, value *Value, hooks *Hooks, errs *hookErrors) bool {
 	if hooks == nil || value == nil {
-		return nil
+		return false
 	}
 
 	notificationCtx := &NotificationContext{
@@ -404,69 +595,101 @@ func attachHooksToValue(ctx context.Context, id string, value *Value, hooks *Hoo
 		Metadata: value.Metadata,
 	}
 
-	// nonFatalErrors is a slice of non-fatal errors that are collected from the hooks.
-	// can contain a maximum of 4 errors.
-	nonFatalErrors := make([]error, 0, 4) //nolint:gomnd
-
 	// call the Hooks
 	if hooks.OnNotificationErrorHook != nil {
 		for _, ev := range value.Errors {
 			ev := ev
-			if err := hooks.OnNotificationErrorHook(ctx, notificationCtx, ev); err != nil {
-				if IsFatalError(hooksErrorHandler(err)) {
-					return err
-				}
-				nonFatalErrors = append(nonFatalErrors, ErrOnNotificationErrorHook)
+			err := hooks.OnNotificationErrorHook(ctx, notificationCtx, ev)
+			if errs.handle(newKindHookErrors("OnNotificationErrorHook", "", ErrOnNotificationErrorHook, err)) {
+				return true
 			}
 		}
 	}
 
-	if hooks.OnMessageStatusChangeHook != nil {
-		for _, sv := range value.Statuses {
-			sv := sv
-			if err := hooks.OnMessageStatusChangeHook(ctx, notificationCtx, sv); err != nil {
-				if IsFatalError(hooksErrorHandler(err)) {
-					return err
-				}
-				nonFatalErrors = append(nonFatalErrors, ErrOnMessageStatusChangeHook)
-			}
+	for _, sv := range value.Statuses {
+		sv := sv
+		ctx := ctx
+		var sid, svalue string
+		if sv != nil {
+			ctx = contactContext(ctx, value.Contacts, sv.RecipientID)
+			sid, svalue = sv.ID, strings.ToLower(sv.StatusValue)
+		}
+		// the hooks of the status are all called, their errors are handled together.
+		var serrs HookErrors
+		if hooks.OnMessageStatusChangeHook != nil {
+			serrs = append(serrs, newKindHookErrors("OnMessageStatusChangeHook", sid, ErrOnMessageStatusChangeHook,
+				hooks.OnMessageStatusChangeHook(ctx, notificationCtx, sv))...)
+		}
+		serrs = append(serrs, newKindHookErrors("status:"+svalue, sid, ErrOnMessageStatusHooks,
+			attachHooksToStatus(ctx, notificationCtx, hooks, sv))...)
+		if errs.handle(serrs) {
+			return true
 		}
 	}
 
 	for _, mv := range value.Messages {
 		mv := mv
+		ctx := ctx
+		if mv != nil {
+			ctx = contactContext(ctx, value.Contacts, mv.From)
+		}
+		// the hooks of the message are all called, their errors are handled together.
+		var merrs HookErrors
 		if hooks.OnMessageReceivedHook != nil {
-			if err := hooks.OnMessageReceivedHook(ctx, notificationCtx, mv); err != nil {
-				if IsFatalError(hooksErrorHandler(err)) {
-					return err
-				}
-				nonFatalErrors = append(nonFatalErrors, ErrOnGlobalMessageHook)
-			}
+			merrs = append(merrs, newKindHookErrors("OnMessageReceivedHook", messageID(mv), ErrOnGlobalMessageHook,
+				hooks.OnMessageReceivedHook(ctx, notificationCtx, mv))...)
 		}
-
-		if err := attachHooksToMessage(ctx, notificationCtx, hooks, mv); err != nil {
-			if IsFatalError(hooksErrorHandler(err)) {
-				return err
-			}
-			nonFatalErrors = append(nonFatalErrors, ErrOnMessageHooks)
+		merrs = append(merrs, newHookErrors(MessagesField, messageID(mv),
+			attachHooksToMessage(ctx, notificationCtx, hooks, mv))...)
+		if errs.handle(merrs) {
+			return true
 		}
 	}
 
-	return getEncounteredError(nonFatalErrors)
+	return false
 }
 
-func getEncounteredError(errs []error) error {
-	var finalErr error
-	for i := 0; i < len(errs); i++ {
-		if finalErr == nil {
-			finalErr = errs[i]
+// attachHooksToStatus calls the hook matching the status value, statuses without a hook are ignored.
+// Payment statuses only go to the OnPaymentStatusHook.
+func attachHooksToStatus(ctx context.Context, nctx *NotificationContext, hooks *Hooks, status *Status) error {
+	if status == nil {
+		return nil
+	}
 
-			continue
+	if strings.EqualFold(status.Type, StatusTypePayment) {
+		if hooks.OnPaymentStatusHook == nil {
+			return nil
 		}
-		finalErr = fmt.Errorf("%v, %v", finalErr, errs[i])
+
+		return hooks.OnPaymentStatusHook(ctx, nctx, status)
 	}
 
-	return finalErr
+	var hook func(ctx context.Context, nctx *NotificationContext, status *Status) error
+	switch MessageStatus(strings.ToLower(status.StatusValue)) {
+	case MessageStatusSent:
+		hook = hooks.OnMessageSentHook
+	case MessageStatusDelivered:
+		hook = hooks.OnMessageDeliveredHook
+	case MessageStatusRead:
+		hook = hooks.OnMessageReadHook
+	case MessageStatusFailed:
+		hook = hooks.OnMessageFailedHook
+	}
+
+	if hook == nil {
+		return nil
+	}
+
+	return hook(ctx, nctx, status)
+}
+
+// messageID returns the ID of the message, empty when it is nil.
+func messageID(message *Message) string {
+	if message == nil {
+		return ""
+	}
+
+	return message.ID
 }
 
 var ErrFailedToAttachHookToMessage = errors.New("could not attach hooks to message")
@@ -485,6 +708,26 @@ func attachHooksToMessage(ctx context.Context, nctx *NotificationContext, hooks
 		Type:      message.Type,
 		Ctx:       message.Context,
 	}
+	// the hooks called before the hook of the message type do not stop it, their errors are
+	// collected.
+	var errs HookErrors
+	errs = append(errs, newKindHookErrors("OnAdReferralHook", message.ID, ErrOnAdReferralHook,
+		attachAdReferralHook(ctx, nctx, mctx, message, hooks))...)
+	errs = append(errs, newKindHookErrors("OnReplyToBusinessMessageHook", message.ID,
+		ErrOnReplyToBusinessMessageHook, attachReplyHook(ctx, nctx, mctx, hooks))...)
+	errs = append(errs, newHookErrors("message:"+message.Type, message.ID,
+		attachMessageTypeHook(ctx, nctx, mctx, message, hooks))...)
+	if len(errs) == 0 {
+		return nil
+	}
+
+	return errs
+}
+
+// attachMessageTypeHook calls the hook of the type of the message.
+func attachMessageTypeHook(ctx context.Context, nctx *NotificationContext, mctx *MessageContext,
+	message *Message, hooks *Hooks,
+) error {
 	messageType := ParseMessageType(message.Type)
 	switch messageType {
 	case OrderMessageType:
@@ -494,12 +737,30 @@ func attachHooksToMessage(ctx context.Context, nctx *NotificationContext, hooks
 		return hooks.OnButtonMessageHook(ctx, nctx, mctx, message.Button)
 
 	case AudioMessageType, VideoMessageType, ImageMessageType, DocumentMessageType, StickerMessageType:
-		return hooks.OnMediaMessageHook(ctx, nctx, mctx, message.Audio)
+		if messageType == StickerMessageType && hooks.OnStickerMessageHook != nil {
+			return hooks.OnStickerMessageHook(ctx, nctx, mctx, newSticker(message.Sticker))
+		}
+		if voice := message.VoiceNote(); voice != nil && hooks.OnVoiceNoteHook != nil {
+			return hooks.OnVoiceNoteHook(ctx, nctx, mctx, voice)
+		}
+
+		return hooks.OnMediaMessageHook(ctx, nctx, mctx, message.Media())
 
 	case InteractiveMessageType:
+		if reply := message.CallPermissionReply(); reply != nil && hooks.OnCallPermissionReplyHook != nil {
+			return hooks.OnCallPermissionReplyHook(ctx, nctx, mctx, reply)
+		}
+		if handled, err := attachHooksToInteractiveReply(ctx, nctx, mctx, message.Interactive, hooks); handled {
+			return err
+		}
+
 		return hooks.OnInteractiveMessageHook(ctx, nctx, mctx, message.Interactive)
 
 	case SystemMessageType:
+		if handled, err := attachHooksToSystemMessage(ctx, nctx, mctx, message, hooks); handled {
+			return err
+		}
+
 		return hooks.OnSystemMessageHook(ctx, nctx, mctx, message.System)
 
 	case UnknownMessageType:
@@ -509,16 +770,24 @@ func attachHooksToMessage(ctx context.Context, nctx *NotificationContext, hooks
 		if message.Referral != nil {
 			return hooks.OnReferralMessageHook(ctx, nctx, mctx, message.Text, message.Referral)
 		}
-		if mctx.Ctx != nil {
+		if mctx.ReferredProduct() != nil {
 			return hooks.OnProductEnquiryHook(ctx, nctx, mctx, message.Text)
 		}
 
 		return hooks.OnTextMessageHook(ctx, nctx, mctx, message.Text)
 
 	case ReactionMessageType:
+		if message.Reaction.Removed() && hooks.OnReactionRemovedHook != nil {
+			return hooks.OnReactionRemovedHook(ctx, nctx, mctx, message.Reaction)
+		}
+
 		return hooks.OnMessageReactionHook(ctx, nctx, mctx, message.Reaction)
 
 	case LocationMessageType:
+		if live := newLiveLocation(message.Location); live != nil && hooks.OnLiveLocationUpdateHook != nil {
+			return hooks.OnLiveLocationUpdateHook(ctx, nctx, mctx, live)
+		}
+
 		return hooks.OnLocationMessageHook(ctx, nctx, mctx, message.Location)
 
 	case ContactMessageType:
@@ -529,6 +798,10 @@ func attachHooksToMessage(ctx context.Context, nctx *NotificationContext, hooks
 			return hooks.OnContactsMessageHook(ctx, nctx, mctx, message.Contacts)
 		}
 		if message.Location != nil {
+			if live := newLiveLocation(message.Location); live != nil && hooks.OnLiveLocationUpdateHook != nil {
+				return hooks.OnLiveLocationUpdateHook(ctx, nctx, mctx, live)
+			}
+
 			return hooks.OnLocationMessageHook(ctx, nctx, mctx, message.Location)
 		}
 
@@ -555,41 +828,96 @@ var (
 // All the errors returned from reading the body, running the BeforeFunc and validating the signature
 // are passed to the NotificationErrorHandler, if neh returns true, the request is aborted and the
 // response status code is set to http.StatusInternalServerError.
+//
+// The raw payload is available to the BeforeFunc, the hooks and the AfterFunc with RawPayloadFromContext.
 func NotificationHandler(
 	hooks *Hooks, neh NotificationErrorHandler, heh HooksErrorHandler, options *HandlerOptions,
 ) http.Handler {
-	return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+	return Recover(http.HandlerFunc(func(w http.ResponseWriter, request *http.Request) {
+		writer := newResponseWriter(w, options)
 		var (
-			buff         bytes.Buffer
 			err          error
 			notification = &Notification{}
 		)
 		ctx := request.Context()
+		if options != nil && options.ResponseDeadline > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, options.ResponseDeadline)
+			defer cancel()
+		}
 
 		defer func() {
-			buff.Reset()
 			if options != nil {
 				if options.AfterFunc != nil {
-					options.AfterFunc(ctx, notification, err)
+					_ = runStage(ctx, options, StageAfter, func(ctx context.Context) error {
+						options.AfterFunc(ctx, notification, err)
+
+						return nil
+					})
 				}
 			}
 		}()
 
-		if _, err = io.Copy(&buff, request.Body); err != nil && !errors.Is(err, io.EOF) {
-			writer.WriteHeader(http.StatusInternalServerError)
-
+		// keep the raw body, decoding drains the buffer and the signature is computed over the raw bytes.
+		raw, body, ok := readNotification(writer, request, neh, options)
+		if !ok {
 			return
 		}
-		request.Body = io.NopCloser(&buff)
+		request.Body = io.NopCloser(bytes.NewReader(body))
+		ctx = WithRawPayload(ctx, body)
+
+		// the signature is computed over the payload as sent, so the normalized payload is only decoded.
+		decodable := body
+		if options != nil && options.NormalizeVersions && len(body) > 0 {
+			if decodable, _, err = NormalizePayload(body); err != nil {
+				writer.failure(options, http.StatusInternalServerError)
+
+				return
+			}
+		}
 
-		if err = json.NewDecoder(&buff).Decode(notification); err != nil && !errors.Is(err, io.EOF) {
-			writer.WriteHeader(http.StatusInternalServerError)
+		if options != nil && options.LenientNumbers && len(body) > 0 {
+			var decoded *Notification
+			if decoded, err = DecodeNotification(decodable, true); err != nil {
+				writer.failure(options, http.StatusInternalServerError)
+
+				return
+			}
+			*notification = *decoded
+		} else if err = json.NewDecoder(bytes.NewReader(decodable)).Decode(notification); err != nil &&
+			!errors.Is(err, io.EOF) {
+			writer.failure(options, http.StatusInternalServerError)
 
 			return
 		}
 
+		if options != nil && options.StrictDecoding && len(body) > 0 {
+			strict := decodable
+			if options.LenientNumbers {
+				strict, _ = NormalizeNumbers(decodable)
+			}
+			if serr := CheckStrict(strict); serr != nil {
+				err = serr
+				if handleError(ctx, writer, request, neh, err) {
+					return
+				}
+			}
+		}
+
+		if options != nil && options.FieldLimits != nil {
+			if _, lerr := options.FieldLimits.Apply(notification); lerr != nil {
+				err = lerr
+				if handleError(ctx, writer, request, neh, err) {
+					return
+				}
+			}
+		}
+
 		if options != nil && options.BeforeFunc != nil {
-			if bfe := options.BeforeFunc(ctx, notification); bfe != nil {
+			bfe := runStage(ctx, options, StageBefore, func(ctx context.Context) error {
+				return options.BeforeFunc(ctx, notification)
+			})
+			if bfe != nil {
 				err = fmt.Errorf("%v: %v", ErrOnBeforeFuncHook, bfe)
 				if handleError(ctx, writer, request, neh, err) {
 					return
@@ -598,22 +926,195 @@ func NotificationHandler(
 		}
 
 		if options != nil && options.ValidateSignature {
-			signature, _ := ExtractSignatureFromHeader(request.Header)
-			if !ValidateSignature(buff.Bytes(), signature, options.Secret) {
-				if handleError(ctx, writer, request, neh, ErrInvalidSignature) {
+			if err = validateSignature(ctx, request, options, raw, body); err != nil {
+				if handleError(ctx, writer, request, neh, err) {
 					return
 				}
 			}
 		}
-		// Apply the Hooks
-		if err = AttachHooksToNotification(ctx, notification, hooks, heh); err != nil {
+		if options != nil && options.AuditTrail != nil {
+			audit.Record(ctx, options.AuditTrail, audit.WebhookReceived, nil, map[string]string{
+				"object":  notification.Object,
+				"entries": strconv.Itoa(len(notification.Entry)),
+				"bytes":   strconv.Itoa(len(raw)),
+			})
+		}
+
+		if options != nil && options.ReplayWindow != nil {
+			if werr := options.ReplayWindow.check(ctx, notification, body); werr != nil {
+				err = werr
+				if handleError(ctx, writer, request, neh, err) {
+					return
+				}
+			}
+		}
+
+		if options != nil && options.Control.Paused() {
+			writer.failure(options, http.StatusServiceUnavailable)
+
+			return
+		}
+		if options != nil && options.Control.DryRun() {
+			writer.success(options)
+
+			return
+		}
+
+		defer deduplicate(ctx, writer, options, notification)()
+
+		if perr := publish(ctx, options, notification); perr != nil {
+			err = perr
+			reportError(ctx, options, err, "publish")
+			if handleError(ctx, writer, request, neh, err) {
+				return
+			}
+		}
+
+		// the hooks run after the response when they are dispatched asynchronously.
+		if options != nil && options.Dispatcher != nil {
+			dctx := detach(ctx)
+			if err = options.Dispatcher.Submit(ctx, func() {
+				_ = applyHooks(dctx, notification, hooks, heh, options)
+			}); err != nil {
+				reportError(ctx, options, err, "dispatch")
+				writer.failure(options, http.StatusServiceUnavailable)
+
+				return
+			}
+
+			writer.success(options)
+
+			return
+		}
+
+		// Apply the Hooks, a panic has been passed to the HooksErrorHandler and is still acknowledged
+		// so that Meta does not disable the subscription.
+		if err = applyHooks(ctx, notification, hooks, heh, options); err != nil && !isPanic(err) {
 			err = fmt.Errorf("%v: %v", ErrOnAttachNotificationHooks, err)
 			if handleError(ctx, writer, request, neh, err) {
 				return
 			}
 		}
 
-		writer.WriteHeader(http.StatusOK)
+		writer.success(options)
+	}), heh, options)
+}
+
+// deduplicate drops the messages and statuses of the notification that were already handled. The
+// returned function, to be deferred, forgets them again when the notification is not acknowledged:
+// it will be redelivered and they must not be dropped then.
+func deduplicate(ctx context.Context, writer *responseWriter, options *HandlerOptions,
+	notification *Notification,
+) func() {
+	if options == nil || options.Deduplication == nil {
+		return func() {}
+	}
+
+	keys, err := options.Deduplication.apply(ctx, notification)
+	if err != nil {
+		reportError(ctx, options, err, "deduplication")
+	}
+
+	return func() {
+		if len(keys) > 0 && !writer.succeeded() {
+			if err := options.Deduplication.forget(detach(ctx), keys); err != nil {
+				reportError(ctx, options, err, "deduplication")
+			}
+		}
+	}
+}
+
+// validateSignature checks the signature of the notification. Meta signs the uncompressed payload,
+// a proxy that compressed the body may also have signed the bytes it sends.
+func validateSignature(ctx context.Context, request *http.Request, options *HandlerOptions,
+	raw, body []byte,
+) error {
+	signature, _ := ExtractSignatureFromHeader(request.Header)
+	secrets, err := signatureSecrets(ctx, options)
+	if err != nil {
+		return err
+	}
+
+	if ValidateSignatureWithSecrets(body, signature, secrets...) ||
+		(!bytes.Equal(raw, body) && ValidateSignatureWithSecrets(raw, signature, secrets...)) {
+		return nil
+	}
+
+	if options.OnSignatureMismatch != nil {
+		options.OnSignatureMismatch(ctx, request, InspectSignatureMismatch(request, body, secrets[0]))
+	}
+
+	return ErrInvalidSignature
+}
+
+// applyHooks attaches the hooks to the notification, records it in the audit trail and reports
+// the error, if any.
+func applyHooks(ctx context.Context, notification *Notification, hooks *Hooks, heh HooksErrorHandler,
+	options *HandlerOptions,
+) error {
+	err := runStage(ctx, options, StageHooks, func(ctx context.Context) error {
+		return attachHooksRecover(ctx, notification, hooks, heh, options)
+	})
+	if options != nil && options.AuditTrail != nil {
+		audit.Record(ctx, options.AuditTrail, audit.HookInvoked, err, map[string]string{
+			"fields": strings.Join(notificationFields(notification), ","),
+		})
+	}
+	if err != nil {
+		reportError(ctx, options, err, "hooks")
+	}
+
+	return err
+}
+
+// attachHooksRecover calls AttachHooksToNotification, or AttachHooksConcurrently when the options
+// set a Concurrency, and returns a panic of a hook as a *report.PanicError, after passing it to
+// the HooksErrorHandler.
+func attachHooksRecover(ctx context.Context, notification *Notification, hooks *Hooks,
+	heh HooksErrorHandler, options *HandlerOptions,
+) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = recoveredPanic(r, heh)
+		}
+	}()
+
+	if options != nil && options.Concurrency > 1 {
+		return AttachHooksConcurrently(ctx, notification, hooks, heh, options.Concurrency)
+	}
+
+	return AttachHooksToNotification(ctx, notification, hooks, heh)
+}
+
+// notificationFields returns the distinct fields of the changes of the notification.
+func notificationFields(notification *Notification) []string {
+	var fields []string
+	seen := make(map[string]bool)
+	for _, entry := range notification.Entry {
+		if entry == nil {
+			continue
+		}
+		for _, change := range entry.Changes {
+			if change == nil || seen[change.Field] {
+				continue
+			}
+			seen[change.Field] = true
+			fields = append(fields, change.Field)
+		}
+	}
+
+	return fields
+}
+
+// reportError sends err to the ErrorReporter of the options, if any.
+func reportError(ctx context.Context, options *HandlerOptions, err error, stage string) {
+	if options == nil || options.ErrorReporter == nil {
+		return
+	}
+
+	options.ErrorReporter.CaptureError(ctx, err, report.Tags{
+		report.TagComponent: "webhooks",
+		report.TagStage:     stage,
 	})
 }
 
@@ -622,6 +1123,9 @@ func handleError(ctx context.Context, writer http.ResponseWriter, request *http.
 ) bool {
 	res := neh(ctx, request, err)
 	if !res.Skip {
+		if rw, ok := writer.(*responseWriter); ok && rw.written {
+			return true
+		}
 		code, headers, message := res.StatusCode, res.Headers, res.Body
 		for k, v := range headers {
 			writer.Header().Set(k, v)
@@ -665,6 +1169,9 @@ func handleError(ctx context.Context, writer http.ResponseWriter, request *http.
 //     (and thus, triggering a Verification Request), the dashboard will indicate if your endpoint validated the request
 //     correctly. If you are using the Graph APIs /app/subscriptions endpoint to configure the Webhooks product, the API
 //     will indicate success or failure with a response.
+//
+// Requests rejected by the verifier, or all requests when the verifier is nil, get a 403 Forbidden
+// and the challenge is not echoed.
 func VerifySubscriptionHandler(verifier SubscriptionVerifier) http.Handler {
 	return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
 		// Retrieve the query parameters from the request.
@@ -672,18 +1179,52 @@ func VerifySubscriptionHandler(verifier SubscriptionVerifier) http.Handler {
 		mode := q.Get("hub.mode")
 		challenge := q.Get("hub.challenge")
 		token := q.Get("hub.verify_token")
+		if verifier == nil {
+			writer.WriteHeader(http.StatusForbidden)
+
+			return
+		}
 		if err := verifier(request.Context(), &VerificationRequest{
 			Mode:      mode,
 			Challenge: challenge,
 			Token:     token,
 		}); err != nil {
-			writer.WriteHeader(http.StatusBadRequest)
+			writer.WriteHeader(http.StatusForbidden)
+
+			return
 		}
+		writer.Header().Set("Content-Type", "text/plain")
 		writer.WriteHeader(http.StatusOK)
 		_, _ = writer.Write([]byte(challenge))
 	})
 }
 
+// ErrVerificationFailed is returned by the verifier of TokenVerifier when the mode is not
+// subscribe or the token does not match.
+var ErrVerificationFailed = errors.New("subscription verification failed")
+
+// TokenVerifier returns a SubscriptionVerifier that accepts the requests with hub.mode set to
+// subscribe and hub.verify_token equal to verifyToken. An empty verifyToken accepts no request.
+func TokenVerifier(verifyToken string) SubscriptionVerifier {
+	return func(_ context.Context, request *VerificationRequest) error {
+		if verifyToken == "" || request.Mode != "subscribe" ||
+			subtle.ConstantTimeCompare([]byte(request.Token), []byte(verifyToken)) != 1 {
+			return ErrVerificationFailed
+		}
+
+		return nil
+	}
+}
+
+// SubscriptionVerificationHandler returns a http.Handler that answers the verification requests
+// with the challenge when the token is verifyToken, see TokenVerifier. Use
+// VerifySubscriptionHandler with your own SubscriptionVerifier for other checks.
+//
+//	http.Handle("/webhooks", webhooks.SubscriptionVerificationHandler(os.Getenv("VERIFY_TOKEN")))
+func SubscriptionVerificationHandler(verifyToken string) http.Handler {
+	return VerifySubscriptionHandler(TokenVerifier(verifyToken))
+}
+
 // ValidateSignature validates the signature of the payload. All Event Notification payloads are signed
 // with a SHA256 signature and include the signature in the request's X-Hub-Signature-256 header, preceded
 // with sha256=. You don't have to validate the payload, but you should.
@@ -703,12 +1244,7 @@ func ValidateSignature(payload []byte, signature, secret string) bool {
 	}
 
 	// Calculate the expected signature using the payload and secret
-	mac := hmac.New(sha256.New, []byte(secret))
-	_, err = mac.Write(payload)
-	if err != nil {
-		return false
-	}
-	expectedSignature := mac.Sum(nil)
+	expectedSignature := wcrypto.Default().HMACSHA256([]byte(secret), payload)
 
 	// Compare the expected and actual signatures
 	return hmac.Equal(decodeSig, expectedSignature)
@@ -22,10 +22,13 @@ package webhooks
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+
+	"github.com/lowkruc/go-whatsapp-api/models"
 )
 
 func Example_newEventListener() {
@@ -404,3 +407,135 @@ func TestExtractSignatureFromHeader(t *testing.T) {
 		})
 	}
 }
+
+func TestAttachHooksToNotification_ReactionRemoved(t *testing.T) {
+	t.Parallel()
+	reaction := func(id, emoji string) *Message {
+		return &Message{From: "16505551234", ID: id, Type: "reaction", Reaction: &models.Reaction{
+			MessageID: "wamid.original", Emoji: emoji,
+		}}
+	}
+	notification := &Notification{Entry: []*Entry{{ID: "waba.1", Changes: []*Change{{
+		Field: MessagesField,
+		Value: &Value{Messages: []*Message{reaction("wamid.1", "\U0001F44D"), reaction("wamid.2", "")}},
+	}}}}}
+
+	var reacted, removed []string
+	listener := NewEventListener()
+	listener.OnMessageReaction(func(ctx context.Context, nctx *NotificationContext, mctx *MessageContext,
+		reaction *models.Reaction,
+	) error {
+		reacted = append(reacted, mctx.ID)
+
+		return nil
+	})
+	listener.OnReactionRemoved(func(ctx context.Context, nctx *NotificationContext, mctx *MessageContext,
+		reaction *models.Reaction,
+	) error {
+		if !reaction.Removed() || reaction.MessageID != "wamid.original" {
+			t.Errorf("unexpected removed reaction %+v", reaction)
+		}
+		removed = append(removed, mctx.ID)
+
+		return nil
+	})
+
+	if err := AttachHooksToNotification(context.Background(), notification, listener.h, NoOpHooksErrorHandler); err != nil {
+		t.Fatalf("AttachHooksToNotification() error = %v", err)
+	}
+
+	if len(reacted) != 1 || reacted[0] != "wamid.1" || len(removed) != 1 || removed[0] != "wamid.2" {
+		t.Errorf("got reactions %v and removals %v", reacted, removed)
+	}
+}
+
+func TestAttachHooksToNotification_Sticker(t *testing.T) {
+	t.Parallel()
+	notification := &Notification{Entry: []*Entry{{ID: "waba.1", Changes: []*Change{{
+		Field: MessagesField,
+		Value: &Value{Messages: []*Message{
+			{From: "16505551234", ID: "wamid.1", Type: "sticker", Sticker: &models.MediaInfo{
+				ID: "media.1", MimeType: "image/webp", Sha256: "hash", Animated: true,
+			}},
+			{From: "16505551234", ID: "wamid.2", Type: "image", Image: &models.MediaInfo{ID: "media.2"}},
+		}},
+	}}}}}
+
+	var (
+		sticker *Sticker
+		media   []string
+	)
+	listener := NewEventListener()
+	listener.OnStickerMessage(func(ctx context.Context, nctx *NotificationContext, mctx *MessageContext,
+		s *Sticker,
+	) error {
+		sticker = s
+
+		return nil
+	})
+	listener.OnMediaMessage(func(ctx context.Context, nctx *NotificationContext, mctx *MessageContext,
+		m *models.MediaInfo,
+	) error {
+		media = append(media, m.ID)
+
+		return nil
+	})
+
+	if err := AttachHooksToNotification(context.Background(), notification, listener.h, NoOpHooksErrorHandler); err != nil {
+		t.Fatalf("AttachHooksToNotification() error = %v", err)
+	}
+
+	if sticker == nil || sticker.ID != "media.1" || !sticker.Animated || sticker.MimeType != "image/webp" ||
+		sticker.Sha256 != "hash" {
+		t.Errorf("unexpected sticker %+v", sticker)
+	}
+	if len(media) != 1 || media[0] != "media.2" {
+		t.Errorf("got media %v, want only the image", media)
+	}
+}
+
+func TestAttachHooksToNotification_VoiceNote(t *testing.T) {
+	t.Parallel()
+	payload := `{"object":"whatsapp_business_account","entry":[{"id":"waba.1","changes":[{"field":"messages",
+"value":{"messaging_product":"whatsapp","messages":[
+{"from":"16505551234","id":"wamid.1","type":"audio","audio":{"id":"media.1","mime_type":"audio/ogg; codecs=opus","voice":true}},
+{"from":"16505551234","id":"wamid.2","type":"audio","audio":{"id":"media.2","mime_type":"audio/mpeg"}}]}}]}]}`
+
+	var notification Notification
+	if err := json.Unmarshal([]byte(payload), &notification); err != nil {
+		t.Fatalf("json.Unmarshal() error = %v", err)
+	}
+
+	var voices, media []string
+	listener := NewEventListener()
+	listener.OnMediaMessage(func(ctx context.Context, nctx *NotificationContext, mctx *MessageContext,
+		m *models.MediaInfo,
+	) error {
+		media = append(media, m.ID)
+
+		return nil
+	})
+
+	if err := AttachHooksToNotification(context.Background(), &notification, listener.h, NoOpHooksErrorHandler); err != nil {
+		t.Fatalf("AttachHooksToNotification() error = %v", err)
+	}
+	if len(media) != 2 {
+		t.Fatalf("got media %v, want both audios without a voice note hook", media)
+	}
+
+	media = nil
+	listener.OnVoiceNote(func(ctx context.Context, nctx *NotificationContext, mctx *MessageContext,
+		m *models.MediaInfo,
+	) error {
+		voices = append(voices, m.ID)
+
+		return nil
+	})
+
+	if err := AttachHooksToNotification(context.Background(), &notification, listener.h, NoOpHooksErrorHandler); err != nil {
+		t.Fatalf("AttachHooksToNotification() error = %v", err)
+	}
+	if len(voices) != 1 || voices[0] != "media.1" || len(media) != 1 || media[0] != "media.2" {
+		t.Errorf("got voice notes %v and media %v", voices, media)
+	}
+}
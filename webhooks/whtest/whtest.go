@@ -0,0 +1,405 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+// Package whtest builds webhook notifications for the tests of the hooks, with the defaults of a
+// real payload and encoded like Meta sends them, so that the tests do not copy raw JSON.
+//
+// Example:
+//
+//	notification := whtest.New(t).
+//		Text("16505551234", "hi").
+//		Status("wamid.1", "16505551234", webhooks.MessageStatusRead).
+//		Notification()
+//	err := webhooks.AttachHooksToNotification(ctx, notification, hooks, webhooks.NoOpHooksErrorHandler)
+//
+// The notifications can also be sent to a handler, signed with the app secret:
+//
+//	recorder := whtest.New(t, whtest.WithSecret("secret")).Text("16505551234", "hi").Post(handler)
+package whtest
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/lowkruc/go-whatsapp-api/clock"
+	wcrypto "github.com/lowkruc/go-whatsapp-api/crypto"
+	werrors "github.com/lowkruc/go-whatsapp-api/errors"
+	"github.com/lowkruc/go-whatsapp-api/models"
+	"github.com/lowkruc/go-whatsapp-api/webhooks"
+)
+
+// The defaults of the notifications, the test numbers of the Meta documentation.
+const (
+	DefaultBusinessAccountID  = "102290129340398"
+	DefaultPhoneNumberID      = "106540352242922"
+	DefaultDisplayPhoneNumber = "15550783881"
+	DefaultProfileName        = "Kerry Fisher"
+)
+
+type (
+	// Builder builds a notification with one change per message, status or update added, in the
+	// order they are added. Its methods fail the test on error.
+	Builder struct {
+		t         testing.TB
+		accountID string
+		metadata  *webhooks.Metadata
+		name      string
+		secret    string
+		clock     clock.Clock
+		changes   []*webhooks.Change
+		sequence  int
+	}
+
+	// Option configures a Builder.
+	Option func(b *Builder)
+
+	// MessageOption configures a message added to a Builder.
+	MessageOption func(message *webhooks.Message)
+
+	// StatusOption configures a status added to a Builder.
+	StatusOption func(status *webhooks.Status)
+)
+
+// New returns a Builder of notifications of the test business account.
+func New(t testing.TB, options ...Option) *Builder {
+	t.Helper()
+	b := &Builder{
+		t:         t,
+		accountID: DefaultBusinessAccountID,
+		metadata: &webhooks.Metadata{
+			DisplayPhoneNumber: DefaultDisplayPhoneNumber,
+			PhoneNumberID:      DefaultPhoneNumberID,
+		},
+		name:  DefaultProfileName,
+		clock: clock.Real{},
+	}
+	for _, option := range options {
+		option(b)
+	}
+
+	return b
+}
+
+// WithBusinessAccountID sets the ID of the entries, the WhatsApp Business Account ID.
+func WithBusinessAccountID(id string) Option {
+	return func(b *Builder) {
+		b.accountID = id
+	}
+}
+
+// WithPhoneNumber sets the metadata of the business phone number.
+func WithPhoneNumber(phoneNumberID, displayPhoneNumber string) Option {
+	return func(b *Builder) {
+		b.metadata = &webhooks.Metadata{
+			DisplayPhoneNumber: displayPhoneNumber,
+			PhoneNumberID:      phoneNumberID,
+		}
+	}
+}
+
+// WithProfileName sets the profile name of the contacts that send the messages.
+func WithProfileName(name string) Option {
+	return func(b *Builder) {
+		b.name = name
+	}
+}
+
+// WithSecret sets the app secret used to sign the requests, see Builder.Request.
+func WithSecret(secret string) Option {
+	return func(b *Builder) {
+		b.secret = secret
+	}
+}
+
+// WithClock sets the clock of the timestamps, clock.Real by default.
+func WithClock(c clock.Clock) Option {
+	return func(b *Builder) {
+		b.clock = c
+	}
+}
+
+// WithMessageID sets the ID of the message, generated by default.
+func WithMessageID(id string) MessageOption {
+	return func(message *webhooks.Message) {
+		message.ID = id
+	}
+}
+
+// WithTimestamp sets the time the message was sent, the time of the clock by default.
+func WithTimestamp(t time.Time) MessageOption {
+	return func(message *webhooks.Message) {
+		message.Timestamp = timestamp(t)
+	}
+}
+
+// ReplyTo makes the message a reply to the message with the ID, sent by the business.
+func ReplyTo(messageID string) MessageOption {
+	return func(message *webhooks.Message) {
+		if message.Context == nil {
+			message.Context = &webhooks.Context{}
+		}
+		message.Context.ID = messageID
+		message.Context.From = DefaultDisplayPhoneNumber
+	}
+}
+
+// Forwarded marks the message as forwarded.
+func Forwarded() MessageOption {
+	return func(message *webhooks.Message) {
+		if message.Context == nil {
+			message.Context = &webhooks.Context{}
+		}
+		message.Context.Forwarded = true
+	}
+}
+
+// WithReferral sets the ad or post the message was sent from.
+func WithReferral(referral *webhooks.Referral) MessageOption {
+	return func(message *webhooks.Message) {
+		message.Referral = referral
+	}
+}
+
+// WithPricing sets the conversation and the pricing of the status, billable unless the category
+// is webhooks.PricingCategoryService or webhooks.PricingCategoryReferralConversion.
+func WithPricing(category string) StatusOption {
+	return func(status *webhooks.Status) {
+		status.Conversation = &webhooks.Conversation{
+			ID:     "conversation-" + status.RecipientID,
+			Origin: &webhooks.ConversationOrigin{Type: category},
+		}
+		status.Pricing = &webhooks.Pricing{
+			Billable: category != webhooks.PricingCategoryService &&
+				category != webhooks.PricingCategoryReferralConversion,
+			Category:     category,
+			PricingModel: "CBP",
+		}
+	}
+}
+
+// WithStatusError adds an error to the status, for the failed statuses.
+func WithStatusError(code int, title string) StatusOption {
+	return func(status *webhooks.Status) {
+		status.Errors = append(status.Errors, &werrors.Error{Code: code, Title: title})
+	}
+}
+
+// Text adds a text message sent by the user.
+func (b *Builder) Text(from, body string, options ...MessageOption) *Builder {
+	b.t.Helper()
+
+	return b.Message(from, &webhooks.Message{
+		Type: "text",
+		Text: &webhooks.Text{Body: body},
+	}, options...)
+}
+
+// Image adds an image sent by the user, mediaID is the ID to download it with.
+func (b *Builder) Image(from, mediaID, caption string, options ...MessageOption) *Builder {
+	b.t.Helper()
+
+	return b.Message(from, &webhooks.Message{
+		Type: "image",
+		Image: &models.MediaInfo{
+			ID:       mediaID,
+			Caption:  caption,
+			MimeType: "image/jpeg",
+			Sha256:   fmt.Sprintf("%x", sha256.Sum256([]byte(mediaID))),
+		},
+	}, options...)
+}
+
+// Order adds an order of the items of the catalog, placed by the user.
+func (b *Builder) Order(from, catalogID string, items []*webhooks.ProductItem, options ...MessageOption) *Builder {
+	b.t.Helper()
+
+	return b.Message(from, &webhooks.Message{
+		Type:  "order",
+		Order: &webhooks.Order{CatalogID: catalogID, ProductItems: items},
+	}, options...)
+}
+
+// Message adds a message sent by the user. Its ID and timestamp are set when empty, and the user is
+// added to the contacts.
+func (b *Builder) Message(from string, message *webhooks.Message, options ...MessageOption) *Builder {
+	b.t.Helper()
+	message.From = from
+	if message.ID == "" {
+		message.ID = b.nextID(from)
+	}
+	if message.Timestamp == "" {
+		message.Timestamp = timestamp(b.clock.Now())
+	}
+	for _, option := range options {
+		option(message)
+	}
+
+	b.changes = append(b.changes, &webhooks.Change{
+		Field: webhooks.MessagesField,
+		Value: &webhooks.Value{
+			MessagingProduct: "whatsapp",
+			Metadata:         b.metadata,
+			Contacts: []*webhooks.Contact{{
+				Profile: &webhooks.Profile{Name: b.name},
+				WaID:    from,
+			}},
+			Messages: []*webhooks.Message{message},
+		},
+	})
+
+	return b
+}
+
+// Status adds a status of the message sent to the recipient.
+func (b *Builder) Status(messageID, recipient string, value webhooks.MessageStatus,
+	options ...StatusOption,
+) *Builder {
+	b.t.Helper()
+	status := &webhooks.Status{
+		ID:          messageID,
+		RecipientID: recipient,
+		StatusValue: string(value),
+		Timestamp:   timestamp(b.clock.Now()),
+	}
+	for _, option := range options {
+		option(status)
+	}
+
+	b.changes = append(b.changes, &webhooks.Change{
+		Field: webhooks.MessagesField,
+		Value: &webhooks.Value{
+			MessagingProduct: "whatsapp",
+			Metadata:         b.metadata,
+			Statuses:         []*webhooks.Status{status},
+		},
+	})
+
+	return b
+}
+
+// TemplateStatusUpdate adds a message_template_status_update change.
+func (b *Builder) TemplateStatusUpdate(update *webhooks.TemplateStatusUpdate) *Builder {
+	b.t.Helper()
+
+	return b.Change(webhooks.MessageTemplateStatusUpdateField, update)
+}
+
+// Change adds a change of the field with the value encoded as is, for the fields without a method.
+func (b *Builder) Change(field string, value any) *Builder {
+	b.t.Helper()
+	raw, err := json.Marshal(value)
+	if err != nil {
+		b.t.Fatalf("whtest: encode the value of the %s change: %v", field, err)
+	}
+	b.changes = append(b.changes, &webhooks.Change{Field: field, RawValue: raw})
+
+	return b
+}
+
+// JSON returns the payload of the notification, encoded like Meta sends it: the non-ASCII
+// characters are escaped, see webhooks.ValidateSignature.
+func (b *Builder) JSON() []byte {
+	b.t.Helper()
+	payload, err := json.Marshal(&webhooks.Notification{
+		Object: "whatsapp_business_account",
+		Entry:  []*webhooks.Entry{{ID: b.accountID, Changes: b.changes}},
+	})
+	if err != nil {
+		b.t.Fatalf("whtest: encode the notification: %v", err)
+	}
+
+	return escapeNonASCII(payload)
+}
+
+// Notification returns the notification decoded from its payload, as the handlers decode it.
+func (b *Builder) Notification() *webhooks.Notification {
+	b.t.Helper()
+	notification := &webhooks.Notification{}
+	if err := json.Unmarshal(b.JSON(), notification); err != nil {
+		b.t.Fatalf("whtest: decode the notification: %v", err)
+	}
+
+	return notification
+}
+
+// Request returns a POST request of the notification to the target, signed with the secret of
+// WithSecret if any.
+func (b *Builder) Request(target string) *http.Request {
+	b.t.Helper()
+	payload := b.JSON()
+	request := httptest.NewRequest(http.MethodPost, target, bytes.NewReader(payload))
+	request.Header.Set("Content-Type", "application/json")
+	if b.secret != "" {
+		signature := wcrypto.Default().HMACSHA256([]byte(b.secret), payload)
+		request.Header.Set(webhooks.SignatureHeaderKey, "sha256="+hex.EncodeToString(signature))
+	}
+
+	return request
+}
+
+// Post sends the request of the notification to the handler and returns the response.
+func (b *Builder) Post(handler http.Handler) *httptest.ResponseRecorder {
+	b.t.Helper()
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, b.Request("/webhooks"))
+
+	return recorder
+}
+
+// nextID returns a message ID shaped like the wamid of WhatsApp.
+func (b *Builder) nextID(from string) string {
+	b.sequence++
+	id := fmt.Sprintf("%s-%d-%d", from, b.clock.Now().UnixNano(), b.sequence)
+
+	return "wamid." + base64.RawStdEncoding.EncodeToString([]byte(id))
+}
+
+func timestamp(t time.Time) string {
+	return strconv.FormatInt(t.Unix(), 10)
+}
+
+// escapeNonASCII escapes the non-ASCII characters of the JSON payload as \uXXXX.
+func escapeNonASCII(payload []byte) []byte {
+	var buff bytes.Buffer
+	for len(payload) > 0 {
+		r, size := utf8.DecodeRune(payload)
+		payload = payload[size:]
+		switch {
+		case r < utf8.RuneSelf:
+			buff.WriteRune(r)
+		case r > 0xFFFF:
+			r -= 0x10000
+			fmt.Fprintf(&buff, `\u%04x\u%04x`, 0xD800+(r>>10), 0xDC00+(r&0x3FF))
+		default:
+			fmt.Fprintf(&buff, `\u%04x`, r)
+		}
+	}
+
+	return buff.Bytes()
+}
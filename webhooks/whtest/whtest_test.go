@@ -0,0 +1,177 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package whtest_test
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/lowkruc/go-whatsapp-api/clock"
+	"github.com/lowkruc/go-whatsapp-api/models"
+	"github.com/lowkruc/go-whatsapp-api/webhooks"
+	"github.com/lowkruc/go-whatsapp-api/webhooks/whtest"
+)
+
+func TestBuilder_Notification(t *testing.T) {
+	t.Parallel()
+	now := time.Date(2023, 5, 1, 9, 0, 0, 0, time.UTC)
+	var (
+		texts    []string
+		images   []*models.MediaInfo
+		orders   []*webhooks.Order
+		statuses []*webhooks.Status
+		replies  []*webhooks.Reply
+		updates  []*webhooks.TemplateStatusUpdate
+	)
+	hooks := &webhooks.Hooks{
+		OnTextMessageHook: func(ctx context.Context, nctx *webhooks.NotificationContext,
+			mctx *webhooks.MessageContext, text *webhooks.Text,
+		) error {
+			if nctx.Metadata.PhoneNumberID != whtest.DefaultPhoneNumberID || mctx.Timestamp != "1682931600" {
+				t.Errorf("unexpected context %+v %+v", nctx.Metadata, mctx)
+			}
+			texts = append(texts, text.Body)
+
+			return nil
+		},
+		OnMediaMessageHook: func(ctx context.Context, nctx *webhooks.NotificationContext,
+			mctx *webhooks.MessageContext, media *models.MediaInfo,
+		) error {
+			images = append(images, media)
+
+			return nil
+		},
+		OnOrderMessageHook: func(ctx context.Context, nctx *webhooks.NotificationContext,
+			mctx *webhooks.MessageContext, order *webhooks.Order,
+		) error {
+			orders = append(orders, order)
+
+			return nil
+		},
+		OnMessageStatusChangeHook: func(ctx context.Context, nctx *webhooks.NotificationContext,
+			status *webhooks.Status,
+		) error {
+			statuses = append(statuses, status)
+
+			return nil
+		},
+		OnReplyToBusinessMessageHook: func(ctx context.Context, nctx *webhooks.NotificationContext,
+			mctx *webhooks.MessageContext, reply *webhooks.Reply,
+		) error {
+			replies = append(replies, reply)
+
+			return nil
+		},
+		OnTemplateStatusUpdateHook: func(ctx context.Context, nctx *webhooks.NotificationContext,
+			update *webhooks.TemplateStatusUpdate,
+		) error {
+			updates = append(updates, update)
+
+			return nil
+		},
+	}
+
+	notification := whtest.New(t, whtest.WithClock(clock.NewFake(now))).
+		Text("16505551234", "hi", whtest.ReplyTo("wamid.sent")).
+		Image("16505551234", "media-1", "a cat").
+		Order("16505551234", "catalog-1", []*webhooks.ProductItem{
+			{ProductRetailerID: "sku-1", Quantity: 2, ItemPrice: 9.99, Currency: "USD"},
+		}).
+		Status("wamid.sent", "16505551234", webhooks.MessageStatusDelivered,
+			whtest.WithPricing(webhooks.PricingCategoryMarketing)).
+		TemplateStatusUpdate(&webhooks.TemplateStatusUpdate{
+			Event: "APPROVED", MessageTemplateID: "1234", MessageTemplateName: "welcome",
+		}).
+		Notification()
+
+	err := webhooks.AttachHooksToNotification(context.TODO(), notification, hooks, webhooks.NoOpHooksErrorHandler)
+	if err != nil {
+		t.Fatalf("AttachHooksToNotification() error = %v", err)
+	}
+
+	if len(texts) != 1 || texts[0] != "hi" {
+		t.Errorf("got texts %v, want [hi]", texts)
+	}
+	if len(replies) != 1 || replies[0].MessageID != "wamid.sent" {
+		t.Errorf("got replies %+v, want the reply to wamid.sent", replies)
+	}
+	if len(images) != 1 || images[0].ID != "media-1" || images[0].Caption != "a cat" || images[0].Sha256 == "" {
+		t.Errorf("got images %+v, want media-1", images)
+	}
+	if len(orders) != 1 || len(orders[0].ProductItems) != 1 {
+		t.Fatalf("got orders %+v, want the order of sku-1", orders)
+	}
+	if price, err := orders[0].ProductItems[0].Price(); err != nil || price.String() != "9.99" {
+		t.Errorf("got price %s, %v, want 9.99", price, err)
+	}
+	if len(statuses) != 1 || !statuses[0].IsBillable() ||
+		statuses[0].PricingCategory() != webhooks.PricingCategoryMarketing {
+		t.Errorf("got statuses %+v, want a billable marketing status", statuses)
+	}
+	if len(updates) != 1 || updates[0].MessageTemplateName != "welcome" {
+		t.Errorf("got template updates %+v, want the welcome update", updates)
+	}
+}
+
+func TestBuilder_Post(t *testing.T) {
+	t.Parallel()
+	var received []string
+	listener := webhooks.NewEventListener(webhooks.WithHandlerOptions(&webhooks.HandlerOptions{
+		ValidateSignature: true,
+		Secret:            "secret",
+	}), webhooks.WithNotificationErrorHandler(
+		func(ctx context.Context, r *http.Request, err error) *webhooks.NotificationErrHandlerResponse {
+			return &webhooks.NotificationErrHandlerResponse{StatusCode: http.StatusUnauthorized}
+		}))
+	listener.OnTextMessage(func(ctx context.Context, nctx *webhooks.NotificationContext,
+		mctx *webhooks.MessageContext, text *webhooks.Text,
+	) error {
+		received = append(received, text.Body)
+
+		return nil
+	})
+	handler := listener.NotificationHandler()
+
+	signed := whtest.New(t, whtest.WithSecret("secret")).Text("16505551234", "äöå")
+	if code := signed.Post(handler).Code; code != http.StatusOK {
+		t.Errorf("signed: got status %d, want %d", code, http.StatusOK)
+	}
+	forged := whtest.New(t, whtest.WithSecret("other")).Text("16505551234", "hi")
+	if code := forged.Post(handler).Code; code != http.StatusUnauthorized {
+		t.Errorf("wrong secret: got status %d, want %d", code, http.StatusUnauthorized)
+	}
+	if len(received) != 1 || received[0] != "äöå" {
+		t.Errorf("got texts %v, want [äöå]", received)
+	}
+}
+
+func TestBuilder_JSON(t *testing.T) {
+	t.Parallel()
+	payload := whtest.New(t, whtest.WithProfileName("Zoë")).Text("16505551234", "äöå 👍").JSON()
+	wants := []string{`\u00e4\u00f6\u00e5 \ud83d\udc4d`, `"name":"Zo\u00eb"`, `"phone_number_id":"106540352242922"`}
+	for _, want := range wants {
+		if !bytes.Contains(payload, []byte(want)) {
+			t.Errorf("payload %s does not contain %s", payload, want)
+		}
+	}
+}
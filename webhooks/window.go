@@ -0,0 +1,160 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package webhooks
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var ErrStaleNotification = errors.New("notification is older than the replay window")
+
+type (
+	// DedupKeyChecker reports whether a deduplication key, see Deduplication, is known, without
+	// recording it. MemoryDedupStore implements it.
+	DedupKeyChecker interface {
+		Known(ctx context.Context, key string) (bool, error)
+	}
+
+	// ReplayWindow rejects the notifications with a message or status older than Window, unless
+	// Known has its deduplication key, so that a notification captured along with its signature
+	// can not be delivered again once the window is over. The keys of the messages and statuses
+	// already handled stay known while the DedupStore remembers them, and their redeliveries are
+	// then dropped by the Deduplication. When Known is nil or fails, old messages and statuses are
+	// rejected.
+	//
+	// Meta retries failed deliveries for up to 7 days, a Window shorter than the longest outage of
+	// the endpoint rejects the retries of the messages that were never handled.
+	ReplayWindow struct {
+		Window time.Duration
+		Known  DedupKeyChecker
+		now    func() time.Time
+	}
+
+	// StaleNotificationError is the error of a notification rejected by the ReplayWindow. Keys are
+	// the deduplication keys of the old messages and statuses, Oldest the oldest timestamp and
+	// Checksum the Checksum of the payload, to find it in the logs.
+	StaleNotificationError struct {
+		Checksum string
+		Keys     []string
+		Oldest   time.Time
+	}
+)
+
+func (e *StaleNotificationError) Error() string {
+	return fmt.Sprintf("%v: %d stale messages or statuses, oldest at %s, checksum %s",
+		ErrStaleNotification, len(e.Keys), e.Oldest.UTC().Format(time.RFC3339), e.Checksum)
+}
+
+func (e *StaleNotificationError) Unwrap() error {
+	return ErrStaleNotification
+}
+
+// Checksum returns the hex encoded SHA-256 of a notification payload.
+func Checksum(payload []byte) string {
+	sum := sha256.Sum256(payload)
+
+	return hex.EncodeToString(sum[:])
+}
+
+// Known reports whether the key is recorded and not expired.
+func (s *MemoryDedupStore) Known(_ context.Context, key string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	expiry, ok := s.keys[key]
+
+	return ok && expiry.After(s.now()), nil
+}
+
+// check returns a *StaleNotificationError when the notification has a message or status older
+// than the window with an unknown key.
+func (w *ReplayWindow) check(ctx context.Context, notification *Notification, payload []byte) error {
+	if w == nil || w.Window <= 0 || notification == nil {
+		return nil
+	}
+
+	now := time.Now
+	if w.now != nil {
+		now = w.now
+	}
+	limit := now().Add(-w.Window)
+
+	stale := &StaleNotificationError{}
+	verify := func(key, timestamp string) {
+		at, ok := unixTimestamp(timestamp)
+		if !ok || !at.Before(limit) {
+			return
+		}
+		if w.Known != nil {
+			if known, err := w.Known.Known(ctx, key); err == nil && known {
+				return
+			}
+		}
+		stale.Keys = append(stale.Keys, key)
+		if stale.Oldest.IsZero() || at.Before(stale.Oldest) {
+			stale.Oldest = at
+		}
+	}
+
+	for _, entry := range notification.Entry {
+		if entry == nil {
+			continue
+		}
+		for _, change := range entry.Changes {
+			if change == nil || change.Value == nil || change.Field != MessagesField {
+				continue
+			}
+			for _, message := range change.Value.Messages {
+				if message != nil {
+					verify("message:"+message.ID, message.Timestamp)
+				}
+			}
+			for _, status := range change.Value.Statuses {
+				if status != nil {
+					verify("status:"+status.ID+":"+status.StatusValue, status.Timestamp)
+				}
+			}
+		}
+	}
+
+	if len(stale.Keys) == 0 {
+		return nil
+	}
+	stale.Checksum = Checksum(payload)
+
+	return stale
+}
+
+// unixTimestamp parses a timestamp in seconds as sent in the webhooks.
+func unixTimestamp(timestamp string) (time.Time, bool) {
+	seconds, err := strconv.ParseInt(strings.TrimSpace(timestamp), 10, 64)
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	return time.Unix(seconds, 0), true
+}
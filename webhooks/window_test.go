@@ -0,0 +1,92 @@
+/*
+ * Copyright 2023 Pius Alfred <me.pius1102@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software
+ * and associated documentation files (the “Software”), to deal in the Software without restriction,
+ * including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all copies or substantial
+ * portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED “AS IS”, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT
+ * LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+ * IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+ * WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE
+ * SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package webhooks
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReplayWindow_Check(t *testing.T) {
+	t.Parallel()
+	var notification Notification
+	if err := json.Unmarshal([]byte(statusesPayload), &notification); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+
+	store := NewMemoryDedupStore()
+	if _, err := store.SeenOrAdd(context.Background(), "status:wamid.1:sent", time.Hour); err != nil {
+		t.Fatalf("SeenOrAdd: %v", err)
+	}
+	now := func() time.Time { return time.Unix(1750263775, 0).Add(10 * time.Minute) }
+	store.now = now
+	window := &ReplayWindow{Window: 10 * time.Minute, Known: store, now: now}
+
+	err := window.check(context.Background(), &notification, []byte(statusesPayload))
+	var stale *StaleNotificationError
+	if !errors.As(err, &stale) || !errors.Is(err, ErrStaleNotification) {
+		t.Fatalf("got error %v, want a *StaleNotificationError", err)
+	}
+	if strings.Join(stale.Keys, ",") != "status:wamid.2:delivered" {
+		t.Errorf("got stale keys %v, want [status:wamid.2:delivered]", stale.Keys)
+	}
+	if !stale.Oldest.Equal(time.Unix(1750263774, 0)) || stale.Checksum != Checksum([]byte(statusesPayload)) {
+		t.Errorf("got oldest %v and checksum %s", stale.Oldest, stale.Checksum)
+	}
+
+	window.Window = time.Hour
+	if err := window.check(context.Background(), &notification, []byte(statusesPayload)); err != nil {
+		t.Errorf("got error %v within the window", err)
+	}
+}
+
+func TestNotificationHandler_ReplayWindow(t *testing.T) {
+	t.Parallel()
+	called := false
+	var handled error
+	neh := func(ctx context.Context, request *http.Request, err error) *NotificationErrHandlerResponse {
+		handled = err
+
+		return &NotificationErrHandlerResponse{StatusCode: http.StatusForbidden}
+	}
+	hooks := &Hooks{
+		OnMessageStatusChangeHook: func(ctx context.Context, nctx *NotificationContext, status *Status) error {
+			called = true
+
+			return nil
+		},
+	}
+
+	// the statuses of the payload are from 2025, well over a day old.
+	handler := NotificationHandler(hooks, neh, NoOpHooksErrorHandler,
+		&HandlerOptions{ReplayWindow: &ReplayWindow{Window: 24 * time.Hour}})
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/", strings.NewReader(statusesPayload)))
+
+	if rr.Code != http.StatusForbidden || called || !errors.Is(handled, ErrStaleNotification) {
+		t.Errorf("got status %d, hooks called %v and error %v", rr.Code, called, handled)
+	}
+}
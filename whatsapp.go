@@ -24,13 +24,21 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"net"
 	"net/http"
+	"net/url"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/lowkruc/go-whatsapp-api/audit"
+	"github.com/lowkruc/go-whatsapp-api/guard"
 	whttp "github.com/lowkruc/go-whatsapp-api/http"
+	"github.com/lowkruc/go-whatsapp-api/lifecycle"
 	"github.com/lowkruc/go-whatsapp-api/models"
+	"github.com/lowkruc/go-whatsapp-api/moderation"
 	"github.com/lowkruc/go-whatsapp-api/qrcodes"
+	"github.com/lowkruc/go-whatsapp-api/report"
 )
 
 var ErrBadRequestFormat = errors.New("bad request")
@@ -144,11 +152,50 @@ type (
 		phoneNumberID     string
 		businessAccountID string
 		hooks             []whttp.Hook
+		hedging           *HedgePolicy
+		flights           *flightGroup
+		transport         *whttp.TransportConfig
+		reporter          report.ErrorReporter
+		audit             audit.Trail
+		limits            *guard.Limits
+		optOuts           OptOutChecker
+		spendGuard        SpendGuard
+		namespace         *namespaceCache
+		moderator         moderation.Moderator
+		dryRun            *atomic.Bool
+		bus               *lifecycle.Bus
+		closed            *atomic.Bool
+		active            *atomic.Int64
 	}
 
 	ClientOption func(*Client)
 )
 
+// WithErrorReporter sets the report.ErrorReporter that is sent the sends that failed after all
+// their attempts, like hedged sends and media downloads that ran out of retries.
+func WithErrorReporter(reporter report.ErrorReporter) ClientOption {
+	return func(client *Client) {
+		client.reporter = reporter
+	}
+}
+
+// WithAuditTrail records the requests sent by the client, the throttled ones and the retries of
+// hedged sends and media downloads in trail.
+func WithAuditTrail(trail audit.Trail) ClientOption {
+	return func(client *Client) {
+		client.audit = trail
+	}
+}
+
+// WithMessageLimits bounds the size of the string fields of the messages sent with SendMessage.
+// Without limits.Truncate, a message with a field over the limit is not sent and SendMessage
+// returns guard.ErrFieldTooLarge.
+func WithMessageLimits(limits *guard.Limits) ClientOption {
+	return func(client *Client) {
+		client.limits = limits
+	}
+}
+
 func WithHTTPClient(http *http.Client) ClientOption {
 	return func(client *Client) {
 		client.http = http
@@ -191,6 +238,37 @@ func WithHooks(hooks ...whttp.Hook) ClientOption {
 	}
 }
 
+// WithProxy sends the requests through proxy, an http, https or socks5 URL. A proxy can also
+// be set per request with whttp.WithRequestProxy. WithProxy, WithDialer and WithResolver have no
+// effect when the http client given with WithHTTPClient has a transport other than *http.Transport.
+func WithProxy(proxy *url.URL) ClientOption {
+	return func(client *Client) {
+		client.transportConfig().Proxy = proxy
+	}
+}
+
+// WithDialer replaces the function used to open connections.
+func WithDialer(dial whttp.DialContextFunc) ClientOption {
+	return func(client *Client) {
+		client.transportConfig().Dial = dial
+	}
+}
+
+// WithResolver sets the DNS resolver used to open connections.
+func WithResolver(resolver *net.Resolver) ClientOption {
+	return func(client *Client) {
+		client.transportConfig().Resolver = resolver
+	}
+}
+
+func (client *Client) transportConfig() *whttp.TransportConfig {
+	if client.transport == nil {
+		client.transport = &whttp.TransportConfig{}
+	}
+
+	return client.transport
+}
+
 func NewClient(opts ...ClientOption) *Client {
 	client := &Client{
 		rwm:               &sync.RWMutex{},
@@ -202,15 +280,50 @@ func NewClient(opts ...ClientOption) *Client {
 		phoneNumberID:     "",
 		businessAccountID: "",
 		hooks:             nil,
+		flights:           &flightGroup{},
+		namespace:         &namespaceCache{},
+		dryRun:            &atomic.Bool{},
+		closed:            &atomic.Bool{},
+		active:            &atomic.Int64{},
 	}
 
 	for _, opt := range opts {
 		opt(client)
 	}
 
+	// the audit hook is added after the options, so that WithHooks does not replace it.
+	if client.audit != nil {
+		client.hooks = append(client.hooks, audit.HTTPHook(client.audit))
+	}
+
+	// the transport is configured on a copy of the http client, so hooks keep working and the
+	// http client given with WithHTTPClient is not modified.
+	if client.transport != nil {
+		client.http, _ = whttp.ConfigureClient(client.http, client.transport)
+	}
+	// the dry run and the shutdown wrap the transport last, see SetDryRun and Shutdown.
+	client.http = withDryRun(client.http, client.dryRun)
+	client.http = withShutdown(client.http, client.closed, client.active)
+
+	client.bus.Emit(LifecycleComponent, lifecycle.Starting, nil)
+	client.bus.Emit(LifecycleComponent, lifecycle.Ready, nil)
+
 	return client
 }
 
+// reportError sends err to the client's report.ErrorReporter, errors caused by the context being
+// done are not reported.
+func (client *Client) reportError(ctx context.Context, err error, operation string) {
+	if ctx.Err() != nil {
+		return
+	}
+
+	report.OrNoOp(client.reporter).CaptureError(ctx, err, report.Tags{
+		report.TagComponent: "client",
+		report.TagOperation: operation,
+	})
+}
+
 type clientContext struct {
 	baseURL           string
 	apiVersion        string
@@ -259,6 +372,17 @@ type TextMessage struct {
 func (client *Client) SendTextMessage(ctx context.Context, recipient string,
 	message *TextMessage,
 ) (*ResponseMessage, error) {
+	body := message.Message
+	if client.moderator != nil {
+		moderated := &models.Message{To: recipient, Type: "text", Text: &models.Text{Body: body}}
+		if err := client.moderate(ctx, moderated); err != nil {
+			return nil, err
+		}
+		if moderated.Text != nil {
+			body = moderated.Text.Body
+		}
+	}
+
 	cctx := client.context()
 	request := &SendTextRequest{
 		BaseURL:       cctx.baseURL,
@@ -266,7 +390,7 @@ func (client *Client) SendTextMessage(ctx context.Context, recipient string,
 		PhoneNumberID: cctx.phoneNumberID,
 		ApiVersion:    cctx.apiVersion,
 		Recipient:     recipient,
-		Message:       message.Message,
+		Message:       body,
 		PreviewURL:    message.PreviewURL,
 	}
 	resp, err := SendText(ctx, client.http, request, client.hooks...)
@@ -277,10 +401,39 @@ func (client *Client) SendTextMessage(ctx context.Context, recipient string,
 	return resp, nil
 }
 
+// SendText sends a text message with the body to the recipient. The options can render a preview
+// of the URL in the body, see models.WithPreviewURL, or reply to a message, see
+// models.WithReplyContext. The message is moderated and sent like SendMessage.
+func (client *Client) SendText(ctx context.Context, recipient, body string, options ...models.MessageOption) (
+	*ResponseMessage, error,
+) {
+	message := models.NewMessage(recipient, append([]models.MessageOption{models.WithText(body)}, options...)...)
+	if err := client.moderate(ctx, message); err != nil {
+		return nil, err
+	}
+	resp, err := client.sendMessage(ctx, message)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send text message: %v", err)
+	}
+
+	return resp, nil
+}
+
 // SendLocationMessage sends a location message to a WhatsApp Business Account.
 func (client *Client) SendLocationMessage(ctx context.Context, recipient string,
 	message *models.Location,
 ) (*ResponseMessage, error) {
+	if client.moderator != nil {
+		location := *message
+		moderated := &models.Message{To: recipient, Type: locationMessageType, Location: &location}
+		if err := client.moderate(ctx, moderated); err != nil {
+			return nil, err
+		}
+		if moderated.Location != nil {
+			message = moderated.Location
+		}
+	}
+
 	request := &SendLocationRequest{
 		BaseURL:       client.baseURL,
 		AccessToken:   client.accessToken,
@@ -307,6 +460,17 @@ type ReactMessage struct {
 }
 
 func (client *Client) React(ctx context.Context, recipient string, req *ReactMessage) (*ResponseMessage, error) {
+	reaction := &models.Reaction{MessageID: req.MessageID, Emoji: req.Emoji}
+	if client.moderator != nil {
+		moderated := &models.Message{To: recipient, Type: reactionMessageType, Reaction: reaction}
+		if err := client.moderate(ctx, moderated); err != nil {
+			return nil, err
+		}
+		if moderated.Reaction != nil {
+			reaction = moderated.Reaction
+		}
+	}
+
 	cctx := client.context()
 	request := &ReactRequest{
 		BaseURL:       cctx.baseURL,
@@ -314,8 +478,8 @@ func (client *Client) React(ctx context.Context, recipient string, req *ReactMes
 		PhoneNumberID: cctx.phoneNumberID,
 		ApiVersion:    cctx.apiVersion,
 		Recipient:     recipient,
-		MessageID:     req.MessageID,
-		Emoji:         req.Emoji,
+		MessageID:     reaction.MessageID,
+		Emoji:         reaction.Emoji,
 	}
 
 	resp, err := React(ctx, client.http, request, client.hooks...)
@@ -341,6 +505,15 @@ type MediaMessage struct {
 func (client *Client) SendMedia(ctx context.Context, recipient string, req *MediaMessage,
 	cacheOptions *CacheOptions,
 ) (*ResponseMessage, error) {
+	caption := req.Caption
+	if client.moderator != nil {
+		moderated, media := mediaMessage(recipient, req)
+		if err := client.moderate(ctx, moderated); err != nil {
+			return nil, err
+		}
+		caption = media.Caption
+	}
+
 	cctx := client.context()
 	request := &SendMediaRequest{
 		BaseURL:       cctx.baseURL,
@@ -351,7 +524,7 @@ func (client *Client) SendMedia(ctx context.Context, recipient string, req *Medi
 		Type:          req.Type,
 		MediaID:       req.MediaID,
 		MediaLink:     req.MediaLink,
-		Caption:       req.Caption,
+		Caption:       caption,
 		Filename:      req.Filename,
 		Provider:      req.Provider,
 		CacheOptions:  cacheOptions,
@@ -375,6 +548,18 @@ type ReplyMessage struct {
 }
 
 func (client *Client) Reply(ctx context.Context, recipient string, req *ReplyMessage) (*ResponseMessage, error) {
+	content := req.Content
+	if client.moderator != nil {
+		moderated, err := replyMessage(recipient, req)
+		if err != nil {
+			return nil, fmt.Errorf("client reply: %v", err)
+		}
+		if err = client.moderate(ctx, moderated); err != nil {
+			return nil, err
+		}
+		content = replyContent(moderated, req.Type, content)
+	}
+
 	cctx := client.context()
 	request := &ReplyRequest{
 		BaseURL:       cctx.baseURL,
@@ -384,7 +569,7 @@ func (client *Client) Reply(ctx context.Context, recipient string, req *ReplyMes
 		Recipient:     recipient,
 		Context:       req.Context,
 		MessageType:   req.Type,
-		Content:       req.Content,
+		Content:       content,
 	}
 
 	resp, err := Reply(ctx, client.http, request, client.hooks...)
@@ -398,6 +583,14 @@ func (client *Client) Reply(ctx context.Context, recipient string, req *ReplyMes
 func (client *Client) SendContacts(ctx context.Context, recipient string, contacts []*models.Contact) (
 	*ResponseMessage, error,
 ) {
+	if client.moderator != nil {
+		moderated := &models.Message{To: recipient, Type: contactsMessageType, Contacts: contacts}
+		if err := client.moderate(ctx, moderated); err != nil {
+			return nil, err
+		}
+		contacts = moderated.Contacts
+	}
+
 	cctx := client.context()
 	req := &SendContactRequest{
 		BaseURL:       cctx.baseURL,
@@ -493,6 +686,9 @@ func (client *Client) SendInteractiveTemplate(ctx context.Context, recipient str
 		Type:          templateMessageType,
 		Template:      template,
 	}
+	if err := client.moderate(ctx, payload); err != nil {
+		return nil, err
+	}
 	reqCtx := &whttp.RequestContext{
 		Name:       "send template",
 		BaseURL:    cctx.baseURL,
@@ -544,6 +740,9 @@ func (client *Client) SendMediaTemplate(ctx context.Context, recipient string, r
 		Type:          templateMessageType,
 		Template:      template,
 	}
+	if err := client.moderate(ctx, payload); err != nil {
+		return nil, err
+	}
 
 	reqCtx := &whttp.RequestContext{
 		Name:       "send media template",
@@ -591,6 +790,9 @@ func (client *Client) SendTextTemplate(ctx context.Context, recipient string, re
 	}
 	template := models.NewTextTemplate(req.Name, tmpLanguage, req.Body)
 	payload := models.NewMessage(recipient, models.WithTemplate(template))
+	if err := client.moderate(ctx, payload); err != nil {
+		return nil, err
+	}
 	reqCtx := &whttp.RequestContext{
 		Name:       "send text template",
 		BaseURL:    cctx.baseURL,
@@ -625,6 +827,21 @@ func (client *Client) SendTextTemplate(ctx context.Context, recipient string, re
 // You can use models.NewTextTemplate, models.NewMediaTemplate and models.NewInteractiveTemplate to create a Template.
 // These are helper functions that will make your life easier.
 func (client *Client) SendTemplate(ctx context.Context, recipient string, req *Template) (*ResponseMessage, error) {
+	components := req.Components
+	if client.moderator != nil {
+		moderated := &models.Message{To: recipient, Type: templateMessageType, Template: &models.Template{
+			Language:   &models.TemplateLanguage{Code: req.LanguageCode, Policy: req.LanguagePolicy},
+			Name:       req.Name,
+			Components: components,
+		}}
+		if err := client.moderate(ctx, moderated); err != nil {
+			return nil, err
+		}
+		if moderated.Template != nil {
+			components = moderated.Template.Components
+		}
+	}
+
 	cctx := client.context()
 	request := &SendTemplateRequest{
 		BaseURL:                cctx.baseURL,
@@ -635,7 +852,7 @@ func (client *Client) SendTemplate(ctx context.Context, recipient string, req *T
 		TemplateLanguageCode:   req.LanguageCode,
 		TemplateLanguagePolicy: req.LanguagePolicy,
 		TemplateName:           req.Name,
-		TemplateComponents:     req.Components,
+		TemplateComponents:     components,
 	}
 
 	resp, err := SendTemplate(ctx, client.http, request, client.hooks...)
@@ -658,6 +875,9 @@ func (client *Client) SendInteractiveMessage(ctx context.Context, recipient stri
 		Type:          "interactive",
 		Interactive:   req,
 	}
+	if err := client.moderate(ctx, template); err != nil {
+		return nil, err
+	}
 	reqCtx := &whttp.RequestContext{
 		Name:       "send interactive message",
 		BaseURL:    cctx.baseURL,
@@ -683,6 +903,68 @@ func (client *Client) SendInteractiveMessage(ctx context.Context, recipient stri
 	return &message, nil
 }
 
+// SendMessage sends an already built models.Message. It is useful when the message has been
+// created by another layer, for example converted from a chat.Message, and the type specific
+// methods do not fit. The messaging product is always set to whatsapp.
+func (client *Client) SendMessage(ctx context.Context, message *models.Message) (*ResponseMessage, error) {
+	if message == nil {
+		return nil, fmt.Errorf("message is nil: %v", ErrBadRequestFormat)
+	}
+
+	if err := client.moderate(ctx, message); err != nil {
+		return nil, err
+	}
+
+	return client.sendMessage(ctx, message)
+}
+
+// sendMessage sends a message that has already been moderated.
+func (client *Client) sendMessage(ctx context.Context, message *models.Message) (*ResponseMessage, error) {
+	if _, err := client.limits.Apply(message); err != nil {
+		return nil, fmt.Errorf("send message: %v", err)
+	}
+
+	resp, err := client.postMessage(ctx, message)
+	if err != nil {
+		return nil, fmt.Errorf("send message: %v", err)
+	}
+
+	return resp, nil
+}
+
+// postMessage posts a message that has already been moderated and checked, it returns the error
+// of whttp.Do as is.
+func (client *Client) postMessage(ctx context.Context, message *models.Message) (*ResponseMessage, error) {
+	message.Product = messagingProduct
+	if message.RecipientType == "" {
+		message.RecipientType = individualRecipientType
+	}
+
+	cctx := client.context()
+	reqCtx := &whttp.RequestContext{
+		Name:       "send message",
+		BaseURL:    cctx.baseURL,
+		ApiVersion: cctx.apiVersion,
+		SenderID:   cctx.phoneNumberID,
+		Endpoints:  []string{"messages"},
+	}
+	params := &whttp.Request{
+		Method:  http.MethodPost,
+		Payload: message,
+		Context: reqCtx,
+		Headers: map[string]string{
+			"Content-Type": "application/json",
+		},
+		Bearer: cctx.accessToken,
+	}
+	var resp ResponseMessage
+	if err := whttp.Do(ctx, client.http, params, &resp, client.hooks...); err != nil {
+		return nil, err
+	}
+
+	return &resp, nil
+}
+
 ////////////// QrCode
 
 func (client *Client) CreateQrCode(ctx context.Context, message *qrcodes.CreateRequest) (